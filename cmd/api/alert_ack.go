@@ -0,0 +1,71 @@
+package main
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"crypto-alert/internal/logger"
+	"crypto-alert/internal/store"
+)
+
+// handleAlertAck acknowledges the pending escalation of a rule's critical
+// alert, so it isn't re-sent to the rule's escalate_to contacts, e.g. from an
+// on-call tool or script. Callers authenticate with the ALERT_ACK_SECRET or an
+// API key with the rules:write scope as a bearer token; by names who
+// acknowledged the alert.
+// Route: POST /api/alerts/ack?kind=token&rule_id=12&by=alice
+func handleAlertAck(w http.ResponseWriter, r *http.Request, rules *store.RuleActions, auth routeAuth) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if !auth.configured() || rules == nil {
+		http.Error(w, "Alert acknowledgements are not configured", http.StatusServiceUnavailable)
+		return
+	}
+	if !auth.check(w, r) {
+		return
+	}
+
+	q := r.URL.Query()
+	kind := q.Get("kind")
+	ruleID, err := strconv.ParseInt(q.Get("rule_id"), 10, 64)
+	if kind == "" || err != nil || ruleID <= 0 {
+		http.Error(w, "kind and rule_id are required", http.StatusBadRequest)
+		return
+	}
+	by := q.Get("by")
+	if by == "" {
+		by = "API"
+	}
+	if len(by) > 200 {
+		by = by[:200]
+	}
+
+	acked, err := rules.Acknowledge(kind, ruleID, by)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to acknowledge: %v", err), http.StatusInternalServerError)
+		return
+	}
+	if acked {
+		slog.Info("✅ Alert acknowledged", logger.FieldKind, kind, logger.FieldRuleID, ruleID, "by", maskEmails(by))
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"kind":         kind,
+		"rule_id":      ruleID,
+		"acknowledged": acked, // false when no escalation was pending
+	})
+}
+
+// validBearer reports whether the request's Authorization header carries the
+// bearer token secret
+func validBearer(r *http.Request, secret string) bool {
+	token, _ := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	return secret != "" && subtle.ConstantTimeCompare([]byte(token), []byte(secret)) == 1
+}
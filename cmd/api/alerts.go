@@ -0,0 +1,189 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"crypto-alert/internal/core"
+	"crypto-alert/internal/store"
+)
+
+// Page sizes of the alert history
+const (
+	defaultAlertsLimit = 50
+	maxAlertsLimit     = 500
+)
+
+// handleListAlerts returns the alerts the engine triggered, newest first.
+// symbol and protocol select the alerts of token and DeFi rules about that
+// token or protocol, subject those of any kind; tag the alerts of rules with
+// that tag; recipient the alerts sent to that email address, chat or
+// destination. from and to are RFC3339 times or yyyyMMdd days, to inclusive.
+// A page has limit alerts (default 50, at most 500) and the next_cursor to
+// pass as cursor for the next one, empty on the last page.
+// Route: GET /api/alerts?from=20260101&to=20260131&kind=&rule_id=&symbol=&protocol=&subject=&severity=&tag=&recipient=&limit=50&cursor=
+func handleListAlerts(w http.ResponseWriter, r *http.Request, history *store.AlertHistory) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if history == nil {
+		http.Error(w, "Alert history is not configured", http.StatusServiceUnavailable)
+		return
+	}
+
+	q := r.URL.Query()
+	f := store.AlertHistoryFilter{
+		Kind:      strings.ToLower(strings.TrimSpace(q.Get("kind"))),
+		Subject:   strings.TrimSpace(q.Get("subject")),
+		Severity:  strings.ToLower(strings.TrimSpace(q.Get("severity"))),
+		Tag:       strings.ToLower(strings.TrimPrefix(strings.TrimSpace(q.Get("tag")), "#")),
+		Recipient: strings.TrimSpace(q.Get("recipient")),
+		Limit:     defaultAlertsLimit,
+	}
+	for param, kind := range map[string]string{"symbol": "token", "protocol": "defi"} {
+		v := strings.TrimSpace(q.Get(param))
+		if v == "" {
+			continue
+		}
+		if (f.Kind != "" && f.Kind != kind) || f.Subject != "" {
+			http.Error(w, "symbol, protocol and subject can't be combined, nor symbol / protocol with another kind", http.StatusBadRequest)
+			return
+		}
+		f.Kind, f.Subject = kind, v
+	}
+	if f.Kind != "" && !slices.Contains(core.MuteKinds, f.Kind) {
+		http.Error(w, "kind must be one of: "+strings.Join(core.MuteKinds, ", "), http.StatusBadRequest)
+		return
+	}
+	if f.Tag != "" && !core.ValidTag(f.Tag) {
+		http.Error(w, "Invalid tag", http.StatusBadRequest)
+		return
+	}
+	switch core.Severity(f.Severity) {
+	case "", core.SeverityInfo, core.SeverityWarning, core.SeverityCritical:
+	default:
+		http.Error(w, "severity must be info, warning or critical", http.StatusBadRequest)
+		return
+	}
+
+	var err error
+	if f.Since, err = parseAlertTime(q.Get("from"), false); err != nil {
+		http.Error(w, "Invalid from: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if f.Until, err = parseAlertTime(q.Get("to"), true); err != nil {
+		http.Error(w, "Invalid to: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	for param, dst := range map[string]*int64{"rule_id": &f.RuleID, "cursor": &f.BeforeID} {
+		if s := q.Get(param); s != "" {
+			if *dst, err = strconv.ParseInt(s, 10, 64); err != nil || *dst <= 0 {
+				http.Error(w, "Invalid "+param, http.StatusBadRequest)
+				return
+			}
+		}
+	}
+	if s := q.Get("limit"); s != "" {
+		if f.Limit, err = strconv.Atoi(s); err != nil || f.Limit <= 0 {
+			http.Error(w, "Invalid limit", http.StatusBadRequest)
+			return
+		}
+		f.Limit = min(f.Limit, maxAlertsLimit)
+	}
+
+	alerts, err := history.List(f)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to list alerts: %v", err), http.StatusInternalServerError)
+		return
+	}
+	if alerts == nil {
+		alerts = []store.AlertHistoryEntry{}
+	}
+	nextCursor := ""
+	if len(alerts) == f.Limit {
+		nextCursor = strconv.FormatInt(alerts[len(alerts)-1].ID, 10)
+	}
+	for i := range alerts {
+		alerts[i].Message = maskEmails(alerts[i].Message)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"data":        alerts,
+		"next_cursor": nextCursor,
+	})
+}
+
+// handleGetAlert returns one alert of the history with its sends on each
+// channel; email recipients are masked.
+// Route: GET /api/alerts/{id}
+func handleGetAlert(w http.ResponseWriter, r *http.Request, history *store.AlertHistory) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if history == nil {
+		http.Error(w, "Alert history is not configured", http.StatusServiceUnavailable)
+		return
+	}
+	id, err := strconv.ParseInt(strings.TrimPrefix(r.URL.Path, "/api/alerts/"), 10, 64)
+	if err != nil || id <= 0 {
+		http.Error(w, "Invalid alert ID", http.StatusBadRequest)
+		return
+	}
+
+	alert, err := history.Get(id)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to get alert: %v", err), http.StatusInternalServerError)
+		return
+	}
+	if alert == nil {
+		http.Error(w, "Alert not found", http.StatusNotFound)
+		return
+	}
+	alert.Message = maskEmails(alert.Message)
+	deliveries, err := history.Deliveries(alert.EventID)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to get the alert's deliveries: %v", err), http.StatusInternalServerError)
+		return
+	}
+	if deliveries == nil {
+		deliveries = []store.AlertDelivery{}
+	}
+	for i := range deliveries {
+		deliveries[i].Recipient = maskEmails(deliveries[i].Recipient)
+		deliveries[i].Error = maskEmails(deliveries[i].Error)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"alert":      alert,
+		"deliveries": deliveries,
+	})
+}
+
+// parseAlertTime parses an RFC3339 time or a yyyyMMdd day, the start of the
+// day or with end the start of the next one; empty is the zero time
+func parseAlertTime(s string, end bool) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return time.Time{}, nil
+	}
+	if day, err := time.Parse("20060102", s); err == nil {
+		if end {
+			return day.AddDate(0, 0, 1), nil
+		}
+		return day, nil
+	}
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("expected an RFC3339 time or yyyyMMdd")
+	}
+	return t, nil
+}
@@ -0,0 +1,236 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+)
+
+// API scopes. logs:read covers what the dashboard reads: the logs, metrics
+// and alert history. rules:write covers the rules, their change log and
+// archiving, mutes and alert acknowledgements.
+const (
+	scopeLogsRead   = "logs:read"
+	scopeRulesWrite = "rules:write"
+)
+
+var apiScopes = []string{scopeLogsRead, scopeRulesWrite}
+
+// minAPIKeyLength is the length of the shortest key API_KEYS accepts
+const minAPIKeyLength = 16
+
+// apiKey is a named key of API_KEYS and the scopes it grants
+type apiKey struct {
+	name   string
+	key    string
+	scopes []string
+}
+
+// apiAuth authenticates callers of the log API by the keys of API_KEYS and
+// the HS256 JWTs signed with API_JWT_SECRET, sent as bearer tokens. With
+// neither set the log, metric and alert history endpoints are open, and the
+// others only take their own secrets.
+type apiAuth struct {
+	keys      []apiKey
+	jwtSecret []byte
+}
+
+func newAPIAuth(keys, jwtSecret string) (*apiAuth, error) {
+	parsed, err := parseAPIKeys(keys)
+	if err != nil {
+		return nil, err
+	}
+	return &apiAuth{keys: parsed, jwtSecret: []byte(jwtSecret)}, nil
+}
+
+// parseAPIKeys parses API_KEYS: comma-separated name=key:scopes entries, the
+// scopes joined by +, e.g. dashboard=<key>:logs:read,ops=<key>:logs:read+rules:write
+func parseAPIKeys(s string) ([]apiKey, error) {
+	var keys []apiKey
+	for _, entry := range strings.Split(s, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		name, rest, ok := strings.Cut(entry, "=")
+		key, scopes, ok2 := strings.Cut(rest, ":")
+		name, key = strings.TrimSpace(name), strings.TrimSpace(key)
+		if !ok || !ok2 || name == "" || scopes == "" {
+			return nil, fmt.Errorf("API key %q: expected name=key:scopes", name)
+		}
+		if len(key) < minAPIKeyLength {
+			return nil, fmt.Errorf("API key %s: the key must be at least %d characters", name, minAPIKeyLength)
+		}
+		for _, k := range keys {
+			if k.name == name {
+				return nil, fmt.Errorf("API key %s: duplicate name", name)
+			}
+			if k.key == key {
+				return nil, fmt.Errorf("API key %s: same key as %s", name, k.name)
+			}
+		}
+		k := apiKey{name: name, key: key}
+		for _, scope := range strings.Split(scopes, "+") {
+			scope = strings.TrimSpace(scope)
+			if !slices.Contains(apiScopes, scope) {
+				return nil, fmt.Errorf("API key %s: unknown scope %q, expected %s", name, scope, strings.Join(apiScopes, " or "))
+			}
+			k.scopes = append(k.scopes, scope)
+		}
+		keys = append(keys, k)
+	}
+	return keys, nil
+}
+
+// enabled reports whether callers have to authenticate to the log, metric and
+// alert history endpoints
+func (a *apiAuth) enabled() bool {
+	return len(a.keys) > 0 || len(a.jwtSecret) > 0
+}
+
+// grants reports whether any caller can get scope
+func (a *apiAuth) grants(scope string) bool {
+	if len(a.jwtSecret) > 0 {
+		return true
+	}
+	for _, k := range a.keys {
+		if slices.Contains(k.scopes, scope) {
+			return true
+		}
+	}
+	return false
+}
+
+// caller returns who the request's bearer token names, key:<name> or
+// jwt:<sub>, and its scopes, or ok false when it is neither one of the keys
+// nor a valid JWT
+func (a *apiAuth) caller(r *http.Request) (name string, scopes []string, ok bool) {
+	token, found := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !found || token == "" {
+		return "", nil, false
+	}
+	// Compare with every key, so the time taken doesn't tell which one matched
+	for _, k := range a.keys {
+		if subtle.ConstantTimeCompare([]byte(token), []byte(k.key)) == 1 {
+			name, scopes, ok = "key:"+k.name, k.scopes, true
+		}
+	}
+	if !ok && len(a.jwtSecret) > 0 {
+		name, scopes, ok = a.jwtCaller(token)
+	}
+	return name, scopes, ok
+}
+
+// jwtCaller returns the subject and scopes of an HS256 JWT signed with the
+// JWT secret, its sub and space-separated scope claims. The token must have
+// an exp claim and not be expired.
+func (a *apiAuth) jwtCaller(token string) (string, []string, bool) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return "", nil, false
+	}
+	var header struct {
+		Alg string `json:"alg"`
+	}
+	if !decodeJWTPart(parts[0], &header) || header.Alg != "HS256" {
+		return "", nil, false
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return "", nil, false
+	}
+	mac := hmac.New(sha256.New, a.jwtSecret)
+	mac.Write([]byte(parts[0] + "." + parts[1]))
+	if !hmac.Equal(sig, mac.Sum(nil)) {
+		return "", nil, false
+	}
+
+	var claims struct {
+		Sub   string  `json:"sub"`
+		Scope string  `json:"scope"`
+		Exp   float64 `json:"exp"`
+		Nbf   float64 `json:"nbf"`
+	}
+	if !decodeJWTPart(parts[1], &claims) {
+		return "", nil, false
+	}
+	now := float64(time.Now().Unix())
+	if claims.Exp == 0 || now >= claims.Exp || now < claims.Nbf {
+		return "", nil, false
+	}
+	return "jwt:" + claims.Sub, strings.Fields(claims.Scope), true
+}
+
+// decodeJWTPart decodes a base64url JSON part of a JWT into v
+func decodeJWTPart(part string, v interface{}) bool {
+	b, err := base64.RawURLEncoding.DecodeString(part)
+	return err == nil && json.Unmarshal(b, v) == nil
+}
+
+// check reports whether the request's bearer token grants scope, and
+// responds 401 or 403 when it doesn't
+func (a *apiAuth) check(w http.ResponseWriter, r *http.Request, scope string) bool {
+	_, scopes, ok := a.caller(r)
+	if !ok {
+		w.Header().Set("WWW-Authenticate", "Bearer")
+		http.Error(w, "Invalid token", http.StatusUnauthorized)
+		return false
+	}
+	if !slices.Contains(scopes, scope) {
+		http.Error(w, "Token lacks the "+scope+" scope", http.StatusForbidden)
+		return false
+	}
+	return true
+}
+
+// require serves next only to callers with scope, once auth is enabled
+func (a *apiAuth) require(scope string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if a.enabled() && !a.check(w, r, scope) {
+			return
+		}
+		next(w, r)
+	}
+}
+
+// route is the auth of an endpoint with its own secret, e.g. MUTE_API_SECRET:
+// callers authenticate with the secret, or a key or JWT with scope
+func (a *apiAuth) route(secret, scope string) routeAuth {
+	return routeAuth{auth: a, secret: secret, scope: scope}
+}
+
+type routeAuth struct {
+	auth   *apiAuth
+	secret string
+	scope  string
+}
+
+// configured reports whether any caller can use the endpoint
+func (ra routeAuth) configured() bool {
+	return ra.secret != "" || ra.auth.grants(ra.scope)
+}
+
+// check reports whether the request carries the endpoint's secret or a token
+// with its scope, and responds 401 or 403 when it doesn't
+func (ra routeAuth) check(w http.ResponseWriter, r *http.Request) bool {
+	return validBearer(r, ra.secret) || ra.auth.check(w, r, ra.scope)
+}
+
+// describe lists the keys and their scopes, for the startup log
+func (a *apiAuth) describe() string {
+	var names []string
+	for _, k := range a.keys {
+		names = append(names, k.name+" ("+strings.Join(k.scopes, ", ")+")")
+	}
+	if len(a.jwtSecret) > 0 {
+		names = append(names, "JWTs signed with API_JWT_SECRET")
+	}
+	return strings.Join(names, ", ")
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+)
+
+// apiCORS lets the browser pages of the allowed origins call the API from
+// another origin. Without any, only same-origin pages (such as the dashboard
+// behind its proxy) can read the responses.
+type apiCORS struct {
+	origins []string // scheme://host[:port], lowercase
+	any     bool     // "*": every origin
+}
+
+// newAPICORS creates the CORS policy of origins, each scheme://host[:port] or
+// "*" for every origin
+func newAPICORS(origins []string) (*apiCORS, error) {
+	c := &apiCORS{}
+	for _, s := range origins {
+		if s == "*" {
+			c.any = true
+			continue
+		}
+		u, err := url.Parse(s)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" || (u.Path != "" && u.Path != "/") || u.RawQuery != "" {
+			return nil, fmt.Errorf("origin %q: expected scheme://host[:port]", s)
+		}
+		c.origins = append(c.origins, strings.ToLower(u.Scheme+"://"+u.Host))
+	}
+	return c, nil
+}
+
+// allows reports whether the pages of origin may call the API
+func (c *apiCORS) allows(origin string) bool {
+	return origin != "" && (c.any || slices.Contains(c.origins, strings.ToLower(origin)))
+}
+
+// describe lists the allowed origins for the startup log
+func (c *apiCORS) describe() string {
+	if c.any {
+		return "*"
+	}
+	return strings.Join(c.origins, ", ")
+}
+
+// handler adds the CORS headers to the responses of next for allowed origins,
+// and answers preflight requests itself
+func (c *apiCORS) handler(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Vary", "Origin")
+		if origin := r.Header.Get("Origin"); c.allows(origin) {
+			if c.any {
+				w.Header().Set("Access-Control-Allow-Origin", "*")
+			} else {
+				w.Header().Set("Access-Control-Allow-Origin", origin)
+			}
+			w.Header().Set("Access-Control-Allow-Methods", "GET, OPTIONS")
+			w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+		}
+
+		if r.Method == http.MethodOptions {
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+
+		next(w, r)
+	}
+}
+
+// checkOrigin is the WebSocket upgrader's origin check: requests without an
+// Origin (not from a browser), from the API's own host and from the allowed
+// origins may connect
+func (c *apiCORS) checkOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" || c.allows(origin) {
+		return true
+	}
+	u, err := url.Parse(origin)
+	return err == nil && strings.EqualFold(u.Host, r.Host)
+}
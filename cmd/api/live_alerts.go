@@ -0,0 +1,329 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"crypto-alert/internal/config"
+	"crypto-alert/internal/core"
+	"crypto-alert/internal/logger"
+	"crypto-alert/internal/message"
+	"crypto-alert/internal/store"
+
+	"github.com/gorilla/websocket"
+)
+
+const (
+	liveAlertMaxAge       = 5 * time.Minute  // Older alerts, e.g. read again after a restart, aren't broadcast
+	liveAlertPoll         = 2 * time.Second  // How often the alert history is checked for new alerts
+	liveAlertPing         = 30 * time.Second // How often idle connections are pinged
+	liveAlertWriteTimeout = 10 * time.Second
+	liveAlertBuffer       = 64 // Alerts a connection can fall behind by before it is closed
+)
+
+// liveAlert is the message the live alerts WebSocket sends for each alert: the
+// alert without the rule's recipients
+type liveAlert struct {
+	Kind        string    `json:"kind"` // token, defi, predict or watch
+	RuleID      int64     `json:"rule_id"`
+	EventID     string    `json:"event_id,omitempty"`
+	Subject     string    `json:"subject"` // Token symbol, DeFi protocol, prediction market or watch source
+	Field       string    `json:"field"`
+	Value       float64   `json:"value"`
+	Threshold   float64   `json:"threshold"`
+	Direction   string    `json:"direction,omitempty"`
+	Severity    string    `json:"severity,omitempty"`
+	Message     string    `json:"message"`
+	Tags        []string  `json:"tags"`
+	TriggeredAt time.Time `json:"triggered_at"`
+}
+
+// alertHub broadcasts alerts to the connected WebSocket clients
+type alertHub struct {
+	mu      sync.Mutex
+	clients map[chan liveAlert]struct{}
+}
+
+func newAlertHub() *alertHub {
+	return &alertHub{clients: map[chan liveAlert]struct{}{}}
+}
+
+func (h *alertHub) subscribe() chan liveAlert {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	ch := make(chan liveAlert, liveAlertBuffer)
+	h.clients[ch] = struct{}{}
+	return ch
+}
+
+func (h *alertHub) unsubscribe(ch chan liveAlert) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if _, ok := h.clients[ch]; ok {
+		delete(h.clients, ch)
+		close(ch)
+	}
+}
+
+// broadcast hands a to every client, dropping those too far behind; alerts
+// older than liveAlertMaxAge are left out
+func (h *alertHub) broadcast(a liveAlert) {
+	if time.Since(a.TriggeredAt) > liveAlertMaxAge {
+		return
+	}
+	a.Message = maskEmails(a.Message)
+	if a.Tags == nil {
+		a.Tags = []string{}
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for ch := range h.clients {
+		select {
+		case ch <- a:
+		default:
+			delete(h.clients, ch)
+			close(ch)
+		}
+	}
+}
+
+// liveAlertTopics are the alert topics the hub follows on the event transport
+var liveAlertTopics = []string{message.TopicTokenAlert, message.TopicDeFiAlert, message.TopicPredictAlert, message.TopicWatchAlert}
+
+// startLiveAlerts starts a hub following the alert events of the event
+// transport or, when the engine delivers its alerts in-process, the alert
+// history. It returns nil when there is neither.
+func startLiveAlerts(ctx context.Context, cfg *config.Config, history *store.AlertHistory) *alertHub {
+	if cfg.EventTransport == message.TransportInProcess {
+		if history == nil {
+			return nil
+		}
+		hub := newAlertHub()
+		go hub.followHistory(ctx, history)
+		slog.Info("📣 Live alerts follow the alert history")
+		return hub
+	}
+
+	transport, err := message.NewTransport(message.TransportConfig{
+		Kind:         cfg.EventTransport,
+		KafkaBrokers: cfg.KafkaBrokers,
+		NATSURL:      cfg.NATSURL,
+		AMQPURL:      cfg.AMQPURL,
+		RedisURL:     cfg.RedisURL,
+	})
+	if err != nil {
+		slog.Warn("⚠️ Live alerts disabled", logger.FieldError, err)
+		return nil
+	}
+	context.AfterFunc(ctx, func() { transport.Close() })
+	if kafka, ok := transport.(*message.KafkaTransport); ok {
+		readers, err := message.KafkaReaderConfigs(os.Getenv, liveAlertTopics)
+		if err != nil {
+			slog.Warn("⚠️ Live alerts disabled: invalid kafka reader configuration", logger.FieldError, err)
+			return nil
+		}
+		// A new group starts at the latest alert rather than replaying the topics
+		for topic, rc := range readers {
+			rc.StartOffset = "last"
+			readers[topic] = rc
+		}
+		kafka.SetReaderConfigs(readers)
+	}
+	group := os.Getenv("LIVE_ALERTS_GROUP")
+	if group == "" {
+		group = "log-api-live"
+	}
+	hub := newAlertHub()
+	go hub.followTransport(ctx, transport, group)
+	slog.Info("📣 Live alerts follow the event transport", "transport", transport.Name(), "group", group)
+	return hub
+}
+
+// followTransport broadcasts the alert events of transport until ctx is done,
+// reading each alert topic with group, so the log API gets every event next
+// to the notification service
+func (h *alertHub) followTransport(ctx context.Context, transport message.Transport, group string) {
+	var consumers []message.Consumer
+	for _, topic := range liveAlertTopics {
+		consumers = append(consumers, message.Consumer{Group: group + "-" + strings.TrimPrefix(topic, "alerts."), Topic: topic})
+	}
+	transport.Prepare(ctx, consumers)
+	for _, c := range consumers {
+		go func() {
+			for ctx.Err() == nil {
+				err := transport.Consume(ctx, c, func(ctx context.Context, value []byte) error {
+					if a, ok := decodeLiveAlert(c.Topic, value); ok {
+						h.broadcast(a)
+					}
+					return nil
+				})
+				if err != nil && ctx.Err() == nil {
+					slog.Warn("⚠️ Live alerts consumer failed, retrying", "group", c.Group, logger.FieldError, err)
+					select {
+					case <-ctx.Done():
+					case <-time.After(5 * time.Second):
+					}
+				}
+			}
+		}()
+	}
+}
+
+// decodeLiveAlert reads the alert of an event of topic
+func decodeLiveAlert(topic string, value []byte) (liveAlert, bool) {
+	var event struct {
+		message.NotificationTargets
+		Symbol        string  `json:"symbol"`
+		Protocol      string  `json:"protocol"`
+		PredictMarket string  `json:"predict_market"`
+		Source        string  `json:"source"`
+		Field         string  `json:"field"`
+		Threshold     float64 `json:"threshold"`
+		Direction     string  `json:"direction"`
+		Price         float64 `json:"price"`
+		CurrentValue  float64 `json:"current_value"`
+		Value         float64 `json:"value"`
+		Message       string  `json:"message"`
+	}
+	if err := json.Unmarshal(value, &event); err != nil {
+		return liveAlert{}, false
+	}
+	a := liveAlert{
+		Kind: strings.TrimPrefix(topic, "alerts."), RuleID: event.RuleID, EventID: event.EventID,
+		Field: event.Field, Threshold: event.Threshold, Direction: event.Direction,
+		Severity: event.Severity, Message: event.Message, Tags: event.Tags, TriggeredAt: event.TriggeredAt,
+	}
+	if a.Severity == "" {
+		a.Severity = string(core.SeverityWarning)
+	}
+	switch topic {
+	case message.TopicTokenAlert:
+		a.Subject, a.Field, a.Value = event.Symbol, "PRICE", event.Price
+	case message.TopicDeFiAlert:
+		a.Subject, a.Value = event.Protocol, event.CurrentValue
+	case message.TopicPredictAlert:
+		a.Subject, a.Value = event.PredictMarket, event.CurrentValue
+	case message.TopicWatchAlert:
+		a.Subject, a.Value = event.Source, event.Value
+	}
+	return a, true
+}
+
+// followHistory broadcasts the alerts the engine records in the alert history
+// until ctx is done, for when the engine delivers its alerts in-process
+func (h *alertHub) followHistory(ctx context.Context, history *store.AlertHistory) {
+	lastID, err := history.LatestID()
+	for err != nil {
+		slog.Warn("⚠️ Live alerts: reading the alert history failed, retrying", logger.FieldError, err)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(liveAlertPoll):
+		}
+		lastID, err = history.LatestID()
+	}
+
+	ticker := time.NewTicker(liveAlertPoll)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		entries, err := history.After(lastID, 100)
+		if err != nil {
+			slog.Warn("⚠️ Live alerts: reading the alert history failed", logger.FieldError, err)
+			continue
+		}
+		for _, e := range entries {
+			lastID = e.ID
+			h.broadcast(liveAlert{
+				Kind: e.RuleKind, RuleID: e.RuleID, EventID: e.EventID, Subject: e.Subject, Field: e.Field,
+				Value: e.Value, Threshold: e.Threshold, Direction: e.Direction, Severity: e.Severity,
+				Message: e.Message, Tags: e.Tags, TriggeredAt: e.TriggeredAt,
+			})
+		}
+	}
+}
+
+// liveAlertUpgrader accepts connections from the API's own host; main lets in
+// the origins of API_CORS_ORIGINS too
+var liveAlertUpgrader = websocket.Upgrader{}
+
+// handleLiveAlerts sends the alerts as the rules fire over a WebSocket, one
+// JSON liveAlert text message each. kind, severity and tag select the alerts
+// of one rule kind, severity or tag.
+// Route: GET /api/alerts/live?kind=defi&severity=critical&tag=treasury (WebSocket)
+func handleLiveAlerts(w http.ResponseWriter, r *http.Request, hub *alertHub, shutdown <-chan struct{}) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if hub == nil {
+		http.Error(w, "Live alerts are not configured", http.StatusServiceUnavailable)
+		return
+	}
+	q := r.URL.Query()
+	kind := strings.ToLower(strings.TrimSpace(q.Get("kind")))
+	if kind != "" && !slices.Contains(core.MuteKinds, kind) {
+		http.Error(w, "kind must be one of: "+strings.Join(core.MuteKinds, ", "), http.StatusBadRequest)
+		return
+	}
+	severity := strings.ToLower(strings.TrimSpace(q.Get("severity")))
+	tag := strings.ToLower(strings.TrimPrefix(strings.TrimSpace(q.Get("tag")), "#"))
+
+	conn, err := liveAlertUpgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return // Upgrade has responded
+	}
+	defer conn.Close()
+	alerts := hub.subscribe()
+	defer hub.unsubscribe(alerts)
+
+	// Read until the client goes away; it has nothing to say but pongs
+	closed := make(chan struct{})
+	go func() {
+		defer close(closed)
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+
+	ping := time.NewTicker(liveAlertPing)
+	defer ping.Stop()
+	for {
+		select {
+		case <-closed:
+			return
+		case <-shutdown:
+			conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseGoingAway, "shutting down"), time.Now().Add(liveAlertWriteTimeout))
+			return
+		case <-ping.C:
+			if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(liveAlertWriteTimeout)); err != nil {
+				return
+			}
+		case a, ok := <-alerts:
+			if !ok {
+				conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseTryAgainLater, "too far behind"), time.Now().Add(liveAlertWriteTimeout))
+				return
+			}
+			if (kind != "" && a.Kind != kind) || (severity != "" && a.Severity != severity) || (tag != "" && !core.HasTag(a.Tags, tag)) {
+				continue
+			}
+			conn.SetWriteDeadline(time.Now().Add(liveAlertWriteTimeout))
+			if err := conn.WriteJSON(a); err != nil {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"crypto-alert/internal/logger"
+	"crypto-alert/internal/store"
+)
+
+// logExportFlushEvery is how many entries an export writes between flushes
+const logExportFlushEvery = 1000
+
+// logExportWriter writes exported log entries as CSV or NDJSON
+type logExportWriter struct {
+	w     *bufio.Writer
+	csv   *csv.Writer // nil for NDJSON
+	rc    *http.ResponseController
+	count int
+}
+
+func (e *logExportWriter) write(entry store.LogEntry) error {
+	entry.Message = maskEmails(entry.Message)
+	if e.csv != nil {
+		if err := e.csv.Write([]string{entry.TS, entry.Level, entry.Service, entry.Message}); err != nil {
+			return err
+		}
+	} else {
+		b, _ := json.Marshal(entry)
+		e.w.Write(b)
+		if err := e.w.WriteByte('\n'); err != nil {
+			return err
+		}
+	}
+	if e.count++; e.count%logExportFlushEvery == 0 {
+		return e.flush()
+	}
+	return nil
+}
+
+// flush sends what was written so far to the client, as a chunk
+func (e *logExportWriter) flush() error {
+	if e.csv != nil {
+		e.csv.Flush()
+		if err := e.csv.Error(); err != nil {
+			return err
+		}
+	}
+	if err := e.w.Flush(); err != nil {
+		return err
+	}
+	return e.rc.Flush()
+}
+
+// handleExportLogs streams a day's log entries as a CSV (ts, level, service,
+// message columns) or NDJSON download, oldest first, emails masked. since, q,
+// level and service filter the entries like /api/logs/{date}. It reads
+// Elasticsearch a page at a time when it has the day's entries, and otherwise
+// the day's log files (or its archive) a line at a time, flushing every 1000
+// entries, so a large day is never held in memory.
+// Route: GET /api/logs/{yyyyMMdd}/export?format=csv|ndjson[&since=&q=&level=&service=]
+func handleExportLogs(w http.ResponseWriter, r *http.Request, date string, logs store.LogSource) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if _, err := time.Parse("20060102", date); err != nil || len(date) != 8 {
+		http.Error(w, "Invalid date format. Expected yyyyMMdd", http.StatusBadRequest)
+		return
+	}
+	q := r.URL.Query()
+	format := strings.ToLower(q.Get("format"))
+	if format == "" {
+		format = "csv"
+	}
+	if format != "csv" && format != "ndjson" {
+		http.Error(w, "format must be csv or ndjson", http.StatusBadRequest)
+		return
+	}
+	filter, err := parseLogFilter(q)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	ctx := r.Context()
+
+	rc := http.NewResponseController(w)
+	rc.SetWriteDeadline(time.Time{})
+	e := &logExportWriter{w: bufio.NewWriter(w), rc: rc}
+	if format == "csv" {
+		w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+		e.csv = csv.NewWriter(e.w)
+		e.csv.Write([]string{"ts", "level", "service", "message"})
+	} else {
+		w.Header().Set("Content-Type", "application/x-ndjson")
+	}
+	w.Header().Set("Content-Disposition", `attachment; filename="crypto-alert-logs-`+date+`.`+format+`"`)
+
+	// Nothing reaches the client before the first flush, after 1000 entries
+	err = logs.Scan(ctx, date, filter, e.write)
+	if err != nil && e.count == 0 {
+		w.Header().Del("Content-Disposition")
+		if errors.Is(err, os.ErrNotExist) {
+			http.Error(w, "No logs found for "+date, http.StatusNotFound)
+		} else {
+			slog.Error("Log export failed", "date", date, logger.FieldError, err)
+			http.Error(w, "Failed to read the logs of "+date, http.StatusInternalServerError)
+		}
+		return
+	}
+	if err != nil {
+		if ctx.Err() == nil {
+			slog.Error("Log export failed", "date", date, logger.FieldError, err)
+		}
+		// Break the connection, so the client doesn't take the cut download for the whole day
+		panic(http.ErrAbortHandler)
+	}
+	e.flush()
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"crypto-alert/internal/logger"
+	"crypto-alert/internal/store"
+)
+
+const (
+	logStreamInterval  = 2 * time.Second  // How often the log stream looks for new entries
+	logStreamKeepAlive = 15 * time.Second // How often an idle log stream sends a comment, so proxies keep it open
+)
+
+// handleLogStream streams the log entries logged from now on as Server-Sent
+// Events: a "log" event per entry with its JSON, emails masked, like
+// /api/logs/{date}. It follows Elasticsearch when it has today's logs, and
+// otherwise tails today's log file. q, level and service filter the entries. With
+// Elasticsearch, the last event of each batch has an ID, and a reconnecting
+// client that sends it as Last-Event-ID gets what it missed.
+// Route: GET /api/logs/stream[?q=<search>&level=warn,error&service=monitor]
+func handleLogStream(w http.ResponseWriter, r *http.Request, logs store.LogSource, shutdown <-chan struct{}) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	filter, err := parseLogFilter(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	filter.Since = "" // The stream starts now, or at Last-Event-ID
+	ctx := r.Context()
+
+	follower, err := logs.Follow(ctx, filter, r.Header.Get("Last-Event-ID"))
+	if err == nil && follower == nil {
+		err = fmt.Errorf("no log source to follow")
+	}
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to follow the logs: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	rc := http.NewResponseController(w)
+	rc.SetWriteDeadline(time.Time{})
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprint(w, "retry: 5000\n\n")
+	if err := rc.Flush(); err != nil {
+		return
+	}
+
+	ticker := time.NewTicker(logStreamInterval)
+	defer ticker.Stop()
+	lastWrite := time.Now()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-shutdown:
+			return
+		case <-ticker.C:
+		}
+
+		entries, id, err := follower.Next(ctx)
+		if err != nil && ctx.Err() == nil {
+			slog.Error("Log stream error", logger.FieldError, err)
+		}
+		if len(entries) == 0 {
+			if time.Since(lastWrite) < logStreamKeepAlive {
+				continue
+			}
+			fmt.Fprint(w, ": keep-alive\n\n")
+		}
+		for i, e := range entries {
+			e.Message = maskEmails(e.Message)
+			data, _ := json.Marshal(e)
+			if i == len(entries)-1 && id != "" {
+				fmt.Fprintf(w, "id: %s\n", id)
+			}
+			fmt.Fprintf(w, "event: log\ndata: %s\n\n", data)
+		}
+		if err := rc.Flush(); err != nil {
+			return
+		}
+		lastWrite = time.Now()
+	}
+}
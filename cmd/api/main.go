@@ -1,26 +1,54 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
-	"log"
+	"log/slog"
 	"net/http"
+	"net/url"
 	"os"
-	"path/filepath"
+	"os/signal"
 	"regexp"
-	"sort"
+	"slices"
+	"strconv"
 	"strings"
+	"syscall"
 	"time"
 
 	"crypto-alert/internal/config"
+	"crypto-alert/internal/logger"
+	"crypto-alert/internal/message"
 	"crypto-alert/internal/store"
+
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"golang.org/x/crypto/acme/autocert"
 )
 
 func main() {
 	// Load configuration
 	cfg, err := config.LoadConfig()
 	if err != nil {
-		log.Fatalf("Failed to load config: %v", err)
+		logger.Fatal("Failed to load config", logger.FieldError, err)
+	}
+
+	// API keys and JWTs, with the scopes they grant
+	auth, err := newAPIAuth(os.Getenv("API_KEYS"), os.Getenv("API_JWT_SECRET"))
+	if err != nil {
+		logger.Fatal("Invalid API_KEYS", logger.FieldError, err)
+	}
+
+	// Browser pages of other origins allowed to call the API
+	cors, err := newAPICORS(cfg.APICORSOrigins)
+	if err != nil {
+		logger.Fatal("Invalid API_CORS_ORIGINS", logger.FieldError, err)
+	}
+	liveAlertUpgrader.CheckOrigin = cors.checkOrigin
+
+	// Rate limit per API key or client IP
+	limiter, err := newAPILimiter(cfg.APIRateLimit, cfg.APIRateBurst, cfg.APITrustedProxies, auth)
+	if err != nil {
+		logger.Fatal("Invalid API_TRUSTED_PROXIES", logger.FieldError, err)
 	}
 
 	logDir := cfg.LogDir
@@ -28,22 +56,71 @@ func main() {
 		logDir = "logs"
 	}
 
-	// Ensure log directory exists
-	if err := os.MkdirAll(logDir, 0755); err != nil {
-		log.Fatalf("Failed to create log directory: %v", err)
-	}
+	// Log to the day's file in the log directory and to Elasticsearch, next to
+	// the engine and the notification service
+	esConfig := cfg.ESConfig()
+	if err := logger.InitLogger(logger.Config{
+		Dir:     logDir,
+		Service: logger.ServiceAPI,
+		Format:  cfg.LogFormat,
+		MaxSize: int64(cfg.LogMaxSizeMB) << 20,
+		ES:      esConfig,
+		Loki: &logger.LokiConfig{
+			URL:      cfg.LokiURL,
+			TenantID: cfg.LokiTenantID,
+			Username: cfg.LokiUsername,
+			Password: cfg.LokiPassword,
+		},
+	}); err != nil {
+		logger.Fatal("Failed to initialize logger", logger.FieldError, err)
+	}
+	defer logger.GetLogger().Close()
 
 	// Optional: ES client for log data (when ES is enabled)
 	var esLog *store.ESClient
 	if cfg.ESEnabled && len(cfg.ESAddresses) > 0 && cfg.ESIndex != "" {
 		var err error
-		esLog, err = store.NewESClient(cfg.ESAddresses, cfg.ESIndex)
+		esLog, err = store.NewESClient(esConfig)
 		if err != nil {
-			log.Printf("⚠️ Elasticsearch log source disabled: %v", err)
+			slog.Warn("⚠️ Elasticsearch log source disabled", logger.FieldError, err)
 			esLog = nil
 		} else {
 			defer esLog.Close()
-			log.Printf("📊 Log API will also read from Elasticsearch index: %s", cfg.ESIndex)
+			slog.Info("📊 Log API will also read from Elasticsearch", "index", cfg.ESIndex)
+		}
+	}
+
+	// Optional: the archive of the days the engine moved to S3-compatible storage
+	logArchive, err := store.NewLogArchive(store.S3Config{
+		Endpoint:  cfg.LogArchiveEndpoint,
+		Region:    cfg.LogArchiveRegion,
+		Bucket:    cfg.LogArchiveBucket,
+		AccessKey: cfg.LogArchiveAccessKey,
+		SecretKey: cfg.LogArchiveSecretKey,
+	}, cfg.LogArchivePrefix)
+	if err != nil {
+		logger.Fatal("Failed to set up the log archive", logger.FieldError, err)
+	}
+	if logArchive != nil {
+		slog.Info("📦 Log API will also read the archived days", "bucket", cfg.LogArchiveBucket, "prefix", cfg.LogArchivePrefix)
+	}
+
+	// The log endpoints read Elasticsearch when it has the logs asked for, and
+	// the log files (or their archive) otherwise
+	var logSources store.LogSources
+	if esLog != nil {
+		logSources = append(logSources, esLog)
+	}
+	logSources = append(logSources, store.NewFileLogSource(logDir, logArchive))
+
+	// Create or upgrade the tables, in case the API starts before the engine
+	if cfg.MySQLDSN != "" && cfg.DBMigrate {
+		applied, err := store.Migrate(cfg.MySQLDSN)
+		if err != nil {
+			slog.Warn("⚠️ Database migrations failed", logger.FieldError, err)
+		}
+		for _, name := range applied {
+			slog.Info("🗄️ Applied database migration", "migration", name)
 		}
 	}
 
@@ -52,60 +129,254 @@ func main() {
 	if cfg.MySQLDSN != "" {
 		ms, err := store.NewMetricStore(cfg.MySQLDSN)
 		if err != nil {
-			log.Printf("⚠️ MetricStore disabled: %v", err)
+			slog.Warn("⚠️ MetricStore disabled", logger.FieldError, err)
 		} else {
 			metricStore = ms
 			defer metricStore.Close()
-			log.Println("📈 MetricStore connected — dashboard endpoints active")
+			slog.Info("📈 MetricStore connected — dashboard endpoints active")
 		}
 	}
 
-	// CORS middleware
-	corsHandler := func(next http.HandlerFunc) http.HandlerFunc {
-		return func(w http.ResponseWriter, r *http.Request) {
-			w.Header().Set("Access-Control-Allow-Origin", "*")
-			w.Header().Set("Access-Control-Allow-Methods", "GET, OPTIONS")
-			w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
-
-			if r.Method == "OPTIONS" {
-				w.WriteHeader(http.StatusOK)
-				return
-			}
+	// Notification log for Resend delivery webhooks and unsubscribes
+	var notificationLog *store.NotificationLog
+	if cfg.MySQLDSN != "" {
+		nl, err := store.NewNotificationLog(cfg.MySQLDSN)
+		if err != nil {
+			slog.Warn("⚠️ Notification log disabled", logger.FieldError, err)
+		} else {
+			notificationLog = nl
+			defer notificationLog.Close()
+		}
+	}
+	resendWebhookSecret := os.Getenv("RESEND_WEBHOOK_SECRET")
 
-			next(w, r)
+	// Alert history for the alert endpoints
+	var alertHistory *store.AlertHistory
+	if cfg.MySQLDSN != "" {
+		alertHistory, err = store.NewAlertHistory(cfg.MySQLDSN)
+		if err != nil {
+			slog.Warn("⚠️ Alert history disabled", logger.FieldError, err)
+		} else {
+			defer alertHistory.Close()
+		}
+	}
+	unsubscribeSecret := os.Getenv("UNSUBSCRIBE_SECRET")
+
+	// Rule actions for the Telegram alert buttons and escalation acknowledgements
+	var ruleActions *store.RuleActions
+	if cfg.MySQLDSN != "" {
+		ruleActions, err = store.NewRuleActions(cfg.MySQLDSN)
+		if err != nil {
+			slog.Warn("⚠️ Rule actions disabled", logger.FieldError, err)
+		} else {
+			defer ruleActions.Close()
 		}
 	}
+	alertAckSecret := os.Getenv("ALERT_ACK_SECRET")
+
+	// Mute windows, set here or with the bot's /mute command
+	var mutes *store.Mutes
+	if cfg.MySQLDSN != "" {
+		mutes, err = store.NewMutes(cfg.MySQLDSN)
+		if err != nil {
+			slog.Warn("⚠️ Mutes disabled", logger.FieldError, err)
+		} else {
+			defer mutes.Close()
+		}
+	}
+	muteAPISecret := os.Getenv("MUTE_API_SECRET")
+
+	// Rule change audit log; its secret also guards the rule list and archiving
+	var ruleChanges *store.RuleChanges
+	if cfg.MySQLDSN != "" {
+		ruleChanges, err = store.NewRuleChanges(cfg.MySQLDSN)
+		if err != nil {
+			slog.Warn("⚠️ Rule changes disabled", logger.FieldError, err)
+		} else {
+			defer ruleChanges.Close()
+		}
+	}
+	rulesAPISecret := os.Getenv("RULES_API_SECRET")
+
+	// Telegram bot updates by webhook, for /start chat registrations and the
+	// alert buttons
+	var telegramBot *message.TelegramBot
+	telegramWebhookSecret := os.Getenv("TELEGRAM_WEBHOOK_SECRET")
+	if botToken := os.Getenv("TELEGRAM_BOT_TOKEN"); botToken != "" && telegramWebhookSecret != "" && cfg.MySQLDSN != "" {
+		chats, err := store.NewTelegramChats(cfg.MySQLDSN)
+		if err != nil {
+			slog.Warn("⚠️ Telegram webhook disabled", logger.FieldError, err)
+		} else {
+			defer chats.Close()
+			telegramBot = message.NewTelegramBot(botToken, chats, ruleActions)
+			telegramBot.RequireRegistrationCodes(os.Getenv("TELEGRAM_REGISTRATION_SECRET"))
+			if mutes != nil {
+				telegramBot.EnableMutes(mutes, os.Getenv("TELEGRAM_ADMIN_CHATS"))
+			}
+		}
+	}
+
+	// Stopped by SIGINT or SIGTERM, which shut the server down
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// Live alerts for the dashboard's toasts
+	liveAlerts := startLiveAlerts(ctx, cfg, alertHistory)
+
+	// CORS middleware
+	corsHandler := cors.handler
 
 	// Metrics routes (register before /api/logs/ catch-all)
-	http.HandleFunc("/api/metrics/history", corsHandler(func(w http.ResponseWriter, r *http.Request) {
+	http.HandleFunc("/api/metrics/history", corsHandler(auth.require(scopeLogsRead, func(w http.ResponseWriter, r *http.Request) {
 		handleGetMetricHistory(w, r, metricStore)
-	}))
+	})))
 
-	http.HandleFunc("/api/metrics", corsHandler(func(w http.ResponseWriter, r *http.Request) {
+	http.HandleFunc("/api/metrics", corsHandler(auth.require(scopeLogsRead, func(w http.ResponseWriter, r *http.Request) {
 		handleListMetrics(w, r, metricStore)
-	}))
+	})))
 
-	// Log routes
-	http.HandleFunc("/api/logs/dates", corsHandler(func(w http.ResponseWriter, r *http.Request) {
-		handleGetDates(w, r, logDir, esLog)
-	}))
+	http.HandleFunc("/api/stats", corsHandler(auth.require(scopeLogsRead, func(w http.ResponseWriter, r *http.Request) {
+		handleGetStats(w, r, logSources, alertHistory, notificationLog)
+	})))
 
-	http.HandleFunc("/api/logs/checkpoint/", corsHandler(func(w http.ResponseWriter, r *http.Request) {
-		handleGetCheckpoint(w, r, logDir, esLog)
-	}))
+	// Alert history routes (/api/alerts/ack and /api/alerts/live are matched
+	// before /api/alerts/)
+	http.HandleFunc("/api/alerts", corsHandler(auth.require(scopeLogsRead, func(w http.ResponseWriter, r *http.Request) {
+		handleListAlerts(w, r, alertHistory)
+	})))
 
-	http.HandleFunc("/api/logs/", corsHandler(func(w http.ResponseWriter, r *http.Request) {
-		handleGetLogs(w, r, logDir, esLog)
+	http.HandleFunc("/api/alerts/", corsHandler(auth.require(scopeLogsRead, func(w http.ResponseWriter, r *http.Request) {
+		handleGetAlert(w, r, alertHistory)
+	})))
+
+	http.HandleFunc("/api/alerts/live", auth.require(scopeLogsRead, func(w http.ResponseWriter, r *http.Request) {
+		handleLiveAlerts(w, r, liveAlerts, ctx.Done())
 	}))
 
+	// Resend delivery webhooks (server to server, no CORS)
+	http.HandleFunc("/api/webhooks/resend", func(w http.ResponseWriter, r *http.Request) {
+		handleResendWebhook(w, r, notificationLog, resendWebhookSecret)
+	})
+
+	// Telegram bot updates (server to server, no CORS)
+	http.HandleFunc("/api/webhooks/telegram", func(w http.ResponseWriter, r *http.Request) {
+		handleTelegramWebhook(w, r, telegramBot, telegramWebhookSecret)
+	})
+
+	// Acknowledgements of critical alerts, so they aren't escalated (server to server, no CORS)
+	http.HandleFunc("/api/alerts/ack", func(w http.ResponseWriter, r *http.Request) {
+		handleAlertAck(w, r, ruleActions, auth.route(alertAckSecret, scopeRulesWrite))
+	})
+
+	// Mute windows (server to server, no CORS)
+	http.HandleFunc("/api/mutes", func(w http.ResponseWriter, r *http.Request) {
+		handleMutes(w, r, mutes, auth.route(muteAPISecret, scopeRulesWrite))
+	})
+
+	// Rules, their change audit log and archiving (server to server, no CORS)
+	rulesAuth := auth.route(rulesAPISecret, scopeRulesWrite)
+	http.HandleFunc("/api/rules", func(w http.ResponseWriter, r *http.Request) {
+		handleListRules(w, r, ruleActions, rulesAuth)
+	})
+
+	http.HandleFunc("/api/rules/changes", func(w http.ResponseWriter, r *http.Request) {
+		handleListRuleChanges(w, r, ruleChanges, rulesAuth)
+	})
+
+	http.HandleFunc("/api/rules/archive", func(w http.ResponseWriter, r *http.Request) {
+		handleRuleArchive(w, r, ruleActions, rulesAuth, false)
+	})
+
+	http.HandleFunc("/api/rules/restore", func(w http.ResponseWriter, r *http.Request) {
+		handleRuleArchive(w, r, ruleActions, rulesAuth, true)
+	})
+
+	// Unsubscribe links from alert emails (opened by recipients, no CORS)
+	http.HandleFunc("/api/unsubscribe", func(w http.ResponseWriter, r *http.Request) {
+		handleUnsubscribe(w, r, notificationLog, unsubscribeSecret)
+	})
+
+	// Log routes
+	http.HandleFunc("/api/logs/dates", corsHandler(auth.require(scopeLogsRead, func(w http.ResponseWriter, r *http.Request) {
+		handleGetDates(w, r, logSources)
+	})))
+
+	http.HandleFunc("/api/logs/stream", corsHandler(auth.require(scopeLogsRead, func(w http.ResponseWriter, r *http.Request) {
+		handleLogStream(w, r, logSources, ctx.Done())
+	})))
+
+	http.HandleFunc("/api/logs/checkpoint/", corsHandler(auth.require(scopeLogsRead, func(w http.ResponseWriter, r *http.Request) {
+		handleGetCheckpoint(w, r, logSources)
+	})))
+
+	http.HandleFunc("/api/logs/", corsHandler(auth.require(scopeLogsRead, func(w http.ResponseWriter, r *http.Request) {
+		if date, ok := strings.CutSuffix(strings.TrimPrefix(r.URL.Path, "/api/logs/"), "/export"); ok {
+			handleExportLogs(w, r, date, logSources)
+			return
+		}
+		handleGetLogs(w, r, logSources)
+	})))
+
+	// Prometheus metrics of the API's requests
+	http.HandleFunc("/metrics", auth.require(scopeLogsRead, promhttp.Handler().ServeHTTP))
+
 	port := os.Getenv("API_PORT")
 	if port == "" {
 		port = "8181"
 	}
 
-	log.Printf("🚀 Log API server starting on port %s", port)
-	log.Printf("📁 Serving logs from: %s", logDir)
-	log.Fatal(http.ListenAndServe(":"+port, nil))
+	srv := &http.Server{Addr: ":" + port, Handler: instrument(limiter.limit(http.DefaultServeMux)), ReadHeaderTimeout: 10 * time.Second}
+	listen := srv.ListenAndServe
+	switch {
+	case cfg.APITLSCert != "":
+		listen = func() error { return srv.ListenAndServeTLS(cfg.APITLSCert, cfg.APITLSKey) }
+		slog.Info("🔒 Serving HTTPS with a certificate file", "cert", cfg.APITLSCert)
+	case len(cfg.APIAutocertDomains) > 0:
+		// TLS-ALPN-01 challenges: Let's Encrypt connects to port 443 of the domains
+		m := &autocert.Manager{
+			Prompt:     autocert.AcceptTOS,
+			HostPolicy: autocert.HostWhitelist(cfg.APIAutocertDomains...),
+			Cache:      autocert.DirCache(cfg.APIAutocertCache),
+			Email:      cfg.APIAutocertEmail,
+		}
+		srv.TLSConfig = m.TLSConfig()
+		listen = func() error { return srv.ListenAndServeTLS("", "") }
+		slog.Info("🔒 Serving HTTPS with Let's Encrypt certificates", "domains", cfg.APIAutocertDomains)
+	}
+
+	slog.Info("🚀 Log API server starting", "port", port)
+	slog.Info("📁 Serving logs", "dir", logDir)
+	if auth.enabled() {
+		slog.Info("🔑 API callers authenticate", "with", auth.describe())
+	} else {
+		slog.Warn("⚠️ API_KEYS and API_JWT_SECRET are not set: the log, metric and alert history endpoints are open to anyone who can reach the port", "port", port)
+	}
+	if limiter != nil {
+		slog.Info("🚦 Rate limit per API key or client IP", "requests_per_minute", cfg.APIRateLimit, "burst", limiter.burst)
+	}
+	if len(cfg.APICORSOrigins) > 0 {
+		slog.Info("🌐 Pages of these origins may call the API", "origins", cors.describe())
+	}
+
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- listen()
+	}()
+	select {
+	case err := <-serveErr:
+		logger.Fatal("Log API server error", logger.FieldError, err)
+	case <-ctx.Done():
+	}
+
+	// Streams end with ctx; other requests get the timeout to finish
+	slog.Info("🛑 Shutting down log API...")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), time.Duration(cfg.APIShutdownTimeout)*time.Second)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		slog.Warn("⚠️ Requests still running, closing them", "timeout_seconds", cfg.APIShutdownTimeout, logger.FieldError, err)
+		srv.Close()
+	}
+	slog.Info("✅ Shutdown complete")
 }
 
 var emailRegex = regexp.MustCompile(`[a-zA-Z0-9._%+\-]+@[a-zA-Z0-9.\-]+\.[a-zA-Z]{2,}`)
@@ -192,51 +463,23 @@ func handleGetMetricHistory(w http.ResponseWriter, r *http.Request, ms *store.Me
 	json.NewEncoder(w).Encode(map[string]interface{}{"data": points})
 }
 
-func handleGetDates(w http.ResponseWriter, r *http.Request, logDir string, esLog *store.ESClient) {
+// handleGetDates returns the days with logs in any of the log sources, newest first.
+// Route: GET /api/logs/dates
+func handleGetDates(w http.ResponseWriter, r *http.Request, logs store.LogSource) {
 	if r.Method != http.MethodGet {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
 
-	dateSet := make(map[string]struct{})
-
-	// From Elasticsearch
-	if esLog != nil {
-		dates, err := esLog.GetDates(r.Context())
-		if err != nil {
-			log.Printf("ES GetDates error: %v", err)
-		} else {
-			for _, d := range dates {
-				dateSet[d] = struct{}{}
-			}
-		}
-	}
-
-	// From log files
-	files, err := os.ReadDir(logDir)
+	dates, err := logs.Dates(r.Context())
 	if err != nil {
-		http.Error(w, fmt.Sprintf("Failed to read log directory: %v", err), http.StatusInternalServerError)
+		http.Error(w, fmt.Sprintf("Failed to read the log dates: %v", err), http.StatusInternalServerError)
 		return
 	}
-	for _, file := range files {
-		if file.IsDir() {
-			continue
-		}
-		name := file.Name()
-		if len(name) == 12 && strings.HasSuffix(name, ".log") {
-			dateStr := name[:8]
-			if _, err := time.Parse("20060102", dateStr); err == nil {
-				dateSet[dateStr] = struct{}{}
-			}
-		}
+	if dates == nil {
+		dates = []string{}
 	}
 
-	dates := make([]string, 0, len(dateSet))
-	for d := range dateSet {
-		dates = append(dates, d)
-	}
-	sort.Sort(sort.Reverse(sort.StringSlice(dates)))
-
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(dates)
 }
@@ -244,7 +487,7 @@ func handleGetDates(w http.ResponseWriter, r *http.Request, logDir string, esLog
 // handleGetCheckpoint returns the RFC3339 timestamp of the most recent log entry for a given date.
 // Route: GET /api/logs/checkpoint/{yyyyMMdd}
 // Response: { "checkpoint": "<RFC3339 or empty string>" }
-func handleGetCheckpoint(w http.ResponseWriter, r *http.Request, logDir string, esLog *store.ESClient) {
+func handleGetCheckpoint(w http.ResponseWriter, r *http.Request, logs store.LogSource) {
 	if r.Method != http.MethodGet {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 		return
@@ -260,35 +503,31 @@ func handleGetCheckpoint(w http.ResponseWriter, r *http.Request, logDir string,
 		return
 	}
 
-	var checkpoint string
-
-	// Prefer Elasticsearch
-	if esLog != nil {
-		cp, err := esLog.GetCheckpoint(r.Context(), dateStr)
-		if err != nil {
-			log.Printf("ES GetCheckpoint error: %v", err)
-		} else {
-			checkpoint = cp
-		}
-	}
-
-	// Fall back to log file
-	if checkpoint == "" {
-		logFile := filepath.Join(logDir, fmt.Sprintf("%s.log", dateStr))
-		if content, err := os.ReadFile(logFile); err == nil {
-			checkpoint = store.GetCheckpointFromFile(string(content))
-		}
+	checkpoint, err := logs.Checkpoint(r.Context(), dateStr)
+	if err != nil {
+		slog.Error("Log Checkpoint error", logger.FieldError, err)
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]string{"checkpoint": checkpoint})
 }
 
-// handleGetLogs returns log entries for a given date.
-// Route: GET /api/logs/{yyyyMMdd}[?since=<RFC3339>&q=<search>]
-//   - since: when provided, returns only entries strictly after that timestamp (checkpoint diff)
-//   - q:     optional message content filter
-func handleGetLogs(w http.ResponseWriter, r *http.Request, logDir string, esLog *store.ESClient) {
+// Page sizes of the logs endpoint
+const (
+	defaultLogsLimit = 1000
+	maxLogsLimit     = 5000
+)
+
+// handleGetLogs returns a page of log entries for a given date, oldest first,
+// with the number of entries the query matches that day.
+// Route: GET /api/logs/{yyyyMMdd}[?since=<RFC3339>&q=<search>&level=error&service=monitor&limit=1000&cursor=]
+//   - since:  when provided, returns only entries strictly after that timestamp (checkpoint diff)
+//   - q:      optional message content filter
+//   - level:  optional comma-separated levels (info, warn, error)
+//   - service: optional comma-separated services (monitor, notification-service, log-api)
+//   - limit:  entries per page (default 1000, at most 5000)
+//   - cursor: the next_cursor of the previous page; empty on the last page
+func handleGetLogs(w http.ResponseWriter, r *http.Request, logs store.LogSource) {
 	if r.Method != http.MethodGet {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 		return
@@ -308,48 +547,79 @@ func handleGetLogs(w http.ResponseWriter, r *http.Request, logDir string, esLog
 		return
 	}
 
-	since := strings.TrimSpace(r.URL.Query().Get("since")) // incremental: only return logs after this checkpoint
-	searchQ := strings.TrimSpace(r.URL.Query().Get("q"))   // optional message content filter
-
-	var entries []store.LogEntry
-
-	// Prefer Elasticsearch when available
-	if esLog != nil {
-		var (
-			ents []store.LogEntry
-			err  error
-		)
-		if since != "" {
-			ents, err = esLog.GetLogsSince(r.Context(), path, since, searchQ)
-		} else {
-			ents, err = esLog.GetLogsForDate(r.Context(), path, searchQ)
-		}
-		if err != nil {
-			log.Printf("ES GetLogs error: %v", err)
-		} else if len(ents) > 0 {
-			entries = ents
+	q := r.URL.Query()
+	filter, err := parseLogFilter(q)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	limit := defaultLogsLimit
+	if s := q.Get("limit"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n <= 0 {
+			http.Error(w, "Invalid limit", http.StatusBadRequest)
+			return
 		}
+		limit = min(n, maxLogsLimit)
+	}
+	cursor, err := store.ParseLogCursor(q.Get("cursor"))
+	if err != nil {
+		http.Error(w, "Invalid cursor", http.StatusBadRequest)
+		return
 	}
 
-	// Fall back to log file when no ES data
-	if len(entries) == 0 {
-		logFile := filepath.Join(logDir, fmt.Sprintf("%s.log", path))
-		if content, err := os.ReadFile(logFile); err == nil {
-			if since != "" {
-				entries = store.GetLogsFromFileSince(string(content), since, searchQ)
-			} else {
-				entries = store.GetLogsFromFile(string(content), searchQ)
-			}
-		}
+	page, err := logs.Page(r.Context(), path, store.LogQuery{LogFilter: filter, After: cursor, Limit: limit})
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to read the logs: %v", err), http.StatusInternalServerError)
+		return
 	}
 
 	// Mask emails in message for response
-	for i := range entries {
-		entries[i].Message = maskEmails(entries[i].Message)
+	entries := make([]store.LogEntry, len(page.Entries))
+	for i, e := range page.Entries {
+		e.Message = maskEmails(e.Message)
+		entries[i] = e
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]interface{}{
-		"logs": entries,
+		"logs":        entries,
+		"total":       page.Total,
+		"next_cursor": page.Next,
 	})
 }
+
+// parseLogFilter parses the filter parameters of the log endpoints: since (a
+// checkpoint), q (message text), and level and service, comma-separated log
+// levels (case-insensitive) and services
+func parseLogFilter(q url.Values) (store.LogFilter, error) {
+	f := store.LogFilter{
+		Since: strings.TrimSpace(q.Get("since")), // incremental: only return logs after this checkpoint
+		Query: strings.TrimSpace(q.Get("q")),     // optional message content filter
+	}
+	for _, part := range strings.Split(q.Get("level"), ",") {
+		if strings.TrimSpace(part) == "" {
+			continue
+		}
+		level, ok := logger.ParseLevel(part)
+		if !ok {
+			return f, fmt.Errorf("level must be one of: %s", strings.ToLower(strings.Join(logger.Levels, ", ")))
+		}
+		if !slices.Contains(f.Levels, level) {
+			f.Levels = append(f.Levels, level)
+		}
+	}
+	for _, part := range strings.Split(q.Get("service"), ",") {
+		service := strings.ToLower(strings.TrimSpace(part))
+		if service == "" {
+			continue
+		}
+		if !slices.Contains(logger.Services, service) {
+			return f, fmt.Errorf("service must be one of: %s", strings.Join(logger.Services, ", "))
+		}
+		if !slices.Contains(f.Services, service) {
+			f.Services = append(f.Services, service)
+		}
+	}
+	return f, nil
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"bufio"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	apiRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "api_requests_total",
+		Help: "API requests, by route pattern and status code.",
+	}, []string{"route", "code"})
+	apiRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "api_request_duration_seconds",
+		Help:    "Duration of API requests, by route pattern; the log streams last as long as the client stays connected.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"route"})
+)
+
+// instrument counts the requests next serves and how long they take, by the
+// ServeMux pattern they matched. Requests that matched none, e.g. the ones
+// the rate limiter turned away, are counted as route "other".
+func instrument(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		sw := &statusWriter{ResponseWriter: w, code: http.StatusOK}
+		next.ServeHTTP(sw, r)
+		route := r.Pattern
+		if route == "" {
+			route = "other"
+		}
+		apiRequests.WithLabelValues(route, strconv.Itoa(sw.code)).Inc()
+		apiRequestDuration.WithLabelValues(route).Observe(time.Since(start).Seconds())
+	})
+}
+
+// statusWriter records the status code of a response. Unwrap lets
+// http.ResponseController flush the log streams through it; Hijack lets the
+// live alerts upgrade to a WebSocket, which asserts http.Hijacker.
+type statusWriter struct {
+	http.ResponseWriter
+	code        int
+	wroteHeader bool
+}
+
+func (w *statusWriter) WriteHeader(code int) {
+	if !w.wroteHeader {
+		w.code, w.wroteHeader = code, true
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *statusWriter) Write(b []byte) (int, error) {
+	w.wroteHeader = true
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *statusWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// Hijack hands the connection over, counting the request as switched protocols
+func (w *statusWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	conn, rw, err := http.NewResponseController(w.ResponseWriter).Hijack()
+	if err == nil && !w.wroteHeader {
+		w.code, w.wroteHeader = http.StatusSwitchingProtocols, true
+	}
+	return conn, rw, err
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"crypto-alert/internal/core"
+	"crypto-alert/internal/message"
+	"crypto-alert/internal/store"
+)
+
+// muteJSON is a mute as the mute endpoints return it
+type muteJSON struct {
+	ID         int64     `json:"id"`
+	Scope      string    `json:"scope"`
+	Kind       string    `json:"kind,omitempty"`
+	Subject    string    `json:"subject,omitempty"`
+	Tag        string    `json:"tag,omitempty"`
+	Until      time.Time `json:"until"`
+	Reason     string    `json:"reason,omitempty"`
+	CreatedBy  string    `json:"created_by,omitempty"`
+	Suppressed int       `json:"suppressed"`
+}
+
+func toMuteJSON(m core.Mute) muteJSON {
+	return muteJSON{
+		ID:         m.ID,
+		Scope:      m.Scope(),
+		Kind:       m.Kind,
+		Subject:    m.Subject,
+		Tag:        m.Tag,
+		Until:      m.Until.UTC().Truncate(time.Second),
+		Reason:     m.Reason,
+		CreatedBy:  m.CreatedBy,
+		Suppressed: m.Suppressed,
+	}
+}
+
+// handleMutes lists, creates and ends mute windows, e.g. around planned
+// maintenance. Callers authenticate with the MUTE_API_SECRET or an API
+// key with the rules:write scope as a bearer token. POST mutes every
+// notification for duration (90m, 2h, 3d), or only those of kind (token,
+// defi, predict or watch) and optionally subject (token symbol, DeFi
+// protocol, prediction market or watch source), and with tag only those of
+// the rules with that tag; notify is a channel:destination pair that gets the
+// summary of what was suppressed when the mute ends. DELETE ends the mute id early, or every mute without id.
+// Route: GET/POST/DELETE /api/mutes?duration=2h&kind=defi&subject=aave&tag=treasury&reason=...&by=...&notify=telegram:-100123
+func handleMutes(w http.ResponseWriter, r *http.Request, mutes *store.Mutes, auth routeAuth) {
+	if r.Method != http.MethodGet && r.Method != http.MethodPost && r.Method != http.MethodDelete {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if !auth.configured() || mutes == nil {
+		http.Error(w, "Mutes are not configured", http.StatusServiceUnavailable)
+		return
+	}
+	if !auth.check(w, r) {
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	q := r.URL.Query()
+
+	switch r.Method {
+	case http.MethodGet:
+		active, err := mutes.Active()
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Failed to list mutes: %v", err), http.StatusInternalServerError)
+			return
+		}
+		out := []muteJSON{}
+		for _, m := range active {
+			out = append(out, toMuteJSON(m))
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"data": out})
+
+	case http.MethodDelete:
+		var id int64
+		if s := q.Get("id"); s != "" {
+			var err error
+			if id, err = strconv.ParseInt(s, 10, 64); err != nil || id <= 0 {
+				http.Error(w, "Invalid id", http.StatusBadRequest)
+				return
+			}
+		}
+		ended, err := mutes.End(id)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Failed to end mutes: %v", err), http.StatusInternalServerError)
+			return
+		}
+		slog.Info("🔊 Mutes ended through the API", "mutes", ended)
+		json.NewEncoder(w).Encode(map[string]interface{}{"ended": ended})
+
+	case http.MethodPost:
+		d, err := message.ParseMuteDuration(q.Get("duration"))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		m := core.Mute{
+			Kind:      strings.ToLower(q.Get("kind")),
+			Subject:   q.Get("subject"),
+			Tag:       strings.ToLower(strings.TrimPrefix(q.Get("tag"), "#")),
+			Reason:    q.Get("reason"),
+			CreatedBy: q.Get("by"),
+			Notify:    q.Get("notify"),
+		}
+		if m.Kind != "" && !slices.Contains(core.MuteKinds, m.Kind) {
+			http.Error(w, "kind must be one of: "+strings.Join(core.MuteKinds, ", "), http.StatusBadRequest)
+			return
+		}
+		if m.Subject != "" && m.Kind == "" {
+			http.Error(w, "subject needs kind", http.StatusBadRequest)
+			return
+		}
+		if m.Tag != "" && !core.ValidTag(m.Tag) {
+			http.Error(w, "tag must be 1 to 32 letters, digits, '-', '_' or '.'", http.StatusBadRequest)
+			return
+		}
+		if name, to, ok := strings.Cut(m.Notify, ":"); m.Notify != "" && (!ok || to == "" || name == "") {
+			http.Error(w, "notify must be a channel:destination pair", http.StatusBadRequest)
+			return
+		}
+		if m.CreatedBy == "" {
+			m.CreatedBy = "API"
+		}
+		if len(m.Subject) > 255 || len(m.Reason) > 255 || len(m.CreatedBy) > 255 || len(m.Notify) > 512 {
+			http.Error(w, "subject, reason and by can be at most 255 characters, notify 512", http.StatusBadRequest)
+			return
+		}
+		m.ID, err = mutes.Create(m, d)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Failed to create mute: %v", err), http.StatusInternalServerError)
+			return
+		}
+		m.Until = time.Now().Add(d)
+		slog.Info("🔇 Mute created", "mute_id", m.ID, "scope", m.Scope(), "until", m.Until.UTC(), "by", maskEmails(m.CreatedBy))
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(toMuteJSON(m))
+	}
+}
@@ -0,0 +1,168 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"net/netip"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// apiBucket holds up to burst tokens and refills perMinute tokens a minute
+type apiBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// apiLimiter rate limits the API's callers with a token bucket each, so a
+// scraper or a runaway dashboard can't wear down Elasticsearch, the log
+// directory or the database: callers with an API key or JWT by its name, the
+// others by IP address (by /64 for IPv6). The webhooks are left out, their
+// senders retry on errors anyway.
+type apiLimiter struct {
+	perMinute int
+	burst     int
+	auth      *apiAuth
+	trusted   []netip.Prefix // Proxies whose X-Forwarded-For names the client
+
+	mu        sync.Mutex
+	buckets   map[string]*apiBucket
+	lastSweep time.Time
+}
+
+// newAPILimiter creates a limiter allowing perMinute requests a minute with
+// bursts of up to burst, or returns nil when perMinute is 0 or less
+func newAPILimiter(perMinute, burst int, trustedProxies []string, auth *apiAuth) (*apiLimiter, error) {
+	if perMinute <= 0 {
+		return nil, nil
+	}
+	if burst < 1 {
+		burst = 1
+	}
+	l := &apiLimiter{perMinute: perMinute, burst: burst, auth: auth, buckets: map[string]*apiBucket{}}
+	for _, s := range trustedProxies {
+		p, err := netip.ParsePrefix(s)
+		if err != nil {
+			addr, err2 := netip.ParseAddr(s)
+			if err2 != nil {
+				return nil, fmt.Errorf("trusted proxy %q: not an IP address or CIDR", s)
+			}
+			p = netip.PrefixFrom(addr, addr.BitLen())
+		}
+		l.trusted = append(l.trusted, p.Masked())
+	}
+	return l, nil
+}
+
+// limit serves next to callers with a token left and responds 429 to the
+// others, with X-RateLimit-* headers and Retry-After. A nil limiter serves
+// everyone.
+func (l *apiLimiter) limit(next http.Handler) http.Handler {
+	if l == nil {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodOptions || strings.HasPrefix(r.URL.Path, "/api/webhooks/") {
+			next.ServeHTTP(w, r)
+			return
+		}
+		ok, remaining, wait := l.allow(l.client(r), time.Now())
+		w.Header().Set("X-RateLimit-Limit", strconv.Itoa(l.perMinute))
+		w.Header().Set("X-RateLimit-Remaining", strconv.Itoa(remaining))
+		if !ok {
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+			http.Error(w, "Too many requests", http.StatusTooManyRequests)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// allow takes a token from client's bucket, and returns whether there was
+// one, how many are left and how long until the next one
+func (l *apiLimiter) allow(client string, now time.Time) (bool, int, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.sweep(now)
+	b := l.buckets[client]
+	if b == nil {
+		b = &apiBucket{tokens: float64(l.burst), last: now}
+		l.buckets[client] = b
+	}
+	b.tokens = math.Min(float64(l.burst), b.tokens+float64(l.perMinute)*now.Sub(b.last).Minutes())
+	b.last = now
+	if b.tokens < 1 {
+		return false, 0, time.Duration((1 - b.tokens) / float64(l.perMinute) * float64(time.Minute))
+	}
+	b.tokens--
+	return true, int(b.tokens), 0
+}
+
+// sweep drops, once a minute, the buckets that have filled up again, so the
+// map doesn't keep every IP address that ever called
+func (l *apiLimiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < time.Minute {
+		return
+	}
+	l.lastSweep = now
+	full := time.Duration(float64(l.burst) / float64(l.perMinute) * float64(time.Minute))
+	for client, b := range l.buckets {
+		if now.Sub(b.last) >= full {
+			delete(l.buckets, client)
+		}
+	}
+}
+
+// client names the bucket of the request: its API key or JWT subject, or
+// else its IP address
+func (l *apiLimiter) client(r *http.Request) string {
+	if name, _, ok := l.auth.caller(r); ok {
+		return name
+	}
+	addr := l.clientAddr(r)
+	if addr.Is6() {
+		p, _ := addr.Prefix(64)
+		return "ip:" + p.String()
+	}
+	return "ip:" + addr.String()
+}
+
+// clientAddr is the request's remote address or, when that is a trusted
+// proxy, the last address in X-Forwarded-For that isn't one
+func (l *apiLimiter) clientAddr(r *http.Request) netip.Addr {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	addr, _ := netip.ParseAddr(host)
+	addr = addr.Unmap()
+	if !l.isTrusted(addr) {
+		return addr
+	}
+	hops := strings.Split(strings.Join(r.Header.Values("X-Forwarded-For"), ","), ",")
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop, err := netip.ParseAddr(strings.TrimSpace(hops[i]))
+		if err != nil {
+			break
+		}
+		addr = hop.Unmap()
+		if !l.isTrusted(addr) {
+			break
+		}
+	}
+	return addr
+}
+
+func (l *apiLimiter) isTrusted(addr netip.Addr) bool {
+	for _, p := range l.trusted {
+		if p.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"crypto-alert/internal/logger"
+	"crypto-alert/internal/store"
+)
+
+// resendWebhookTolerance bounds the age of a signed webhook, against replays
+const resendWebhookTolerance = 5 * time.Minute
+
+// resendEvent is the part of a Resend webhook event the API uses
+type resendEvent struct {
+	Type string `json:"type"`
+	Data struct {
+		EmailID string   `json:"email_id"`
+		To      []string `json:"to"`
+		Bounce  *struct {
+			Type    string `json:"type"`
+			SubType string `json:"subType"`
+			Message string `json:"message"`
+		} `json:"bounce"`
+	} `json:"data"`
+}
+
+// handleResendWebhook updates notification_log from Resend delivery events and
+// adds hard-bounced addresses to the email suppression list.
+// Route: POST /api/webhooks/resend
+func handleResendWebhook(w http.ResponseWriter, r *http.Request, nl *store.NotificationLog, secret string) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if secret == "" || nl == nil {
+		http.Error(w, "Resend webhooks are not configured", http.StatusServiceUnavailable)
+		return
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, 1<<20))
+	if err != nil {
+		http.Error(w, "Failed to read body", http.StatusBadRequest)
+		return
+	}
+	if err := verifyResendSignature(secret, r.Header, body, time.Now()); err != nil {
+		slog.Warn("⚠️ Rejected Resend webhook", logger.FieldError, err)
+		http.Error(w, "Invalid signature", http.StatusUnauthorized)
+		return
+	}
+
+	var event resendEvent
+	if err := json.Unmarshal(body, &event); err != nil {
+		http.Error(w, "Invalid event", http.StatusBadRequest)
+		return
+	}
+
+	var status, errMsg string
+	switch event.Type {
+	case "email.delivered":
+		status = store.DeliveryStatusDelivered
+	case "email.bounced":
+		status = store.DeliveryStatusBounced
+		if b := event.Data.Bounce; b != nil {
+			errMsg = fmt.Sprintf("%s bounce (%s): %s", b.Type, b.SubType, b.Message)
+		}
+	case "email.complained":
+		status = store.DeliveryStatusComplained
+		errMsg = "recipient marked the email as spam"
+	default:
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	n, err := nl.UpdateStatus(event.Data.EmailID, status, errMsg)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to update delivery status: %v", err), http.StatusInternalServerError)
+		return
+	}
+	slog.Info("📬 Resend event", "type", event.Type, "email_id", event.Data.EmailID, "deliveries", n)
+
+	// Hard bounces won't succeed on a later send, so stop emailing the address
+	if b := event.Data.Bounce; event.Type == "email.bounced" && b != nil && b.Type == "Permanent" {
+		for _, to := range event.Data.To {
+			if err := nl.SuppressEmail(to, errMsg); err != nil {
+				http.Error(w, fmt.Sprintf("Failed to suppress address: %v", err), http.StatusInternalServerError)
+				return
+			}
+			slog.Info("🚫 Suppressed address after a hard bounce", "email", maskEmails(to))
+		}
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// verifyResendSignature checks the Svix signature Resend sends with each
+// webhook: an HMAC-SHA256 over "<svix-id>.<svix-timestamp>.<body>" keyed with
+// the base64 part of the whsec_ signing secret.
+func verifyResendSignature(secret string, h http.Header, body []byte, now time.Time) error {
+	id := h.Get("svix-id")
+	timestamp := h.Get("svix-timestamp")
+	signatures := h.Get("svix-signature")
+	if id == "" || timestamp == "" || signatures == "" {
+		return errors.New("missing svix headers")
+	}
+
+	ts, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid svix-timestamp %q", timestamp)
+	}
+	if age := now.Sub(time.Unix(ts, 0)); age > resendWebhookTolerance || age < -resendWebhookTolerance {
+		return fmt.Errorf("timestamp outside tolerance (%v)", age.Round(time.Second))
+	}
+
+	key, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(secret, "whsec_"))
+	if err != nil {
+		return fmt.Errorf("invalid signing secret: %w", err)
+	}
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(id + "." + timestamp + "."))
+	mac.Write(body)
+	expected := base64.StdEncoding.EncodeToString(mac.Sum(nil))
+
+	// The header holds space-separated "v1,<signature>" entries, one per active secret
+	for _, sig := range strings.Fields(signatures) {
+		version, value, ok := strings.Cut(sig, ",")
+		if ok && version == "v1" && hmac.Equal([]byte(value), []byte(expected)) {
+			return nil
+		}
+	}
+	return errors.New("no matching signature")
+}
@@ -0,0 +1,188 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"crypto-alert/internal/core"
+	"crypto-alert/internal/logger"
+	"crypto-alert/internal/store"
+)
+
+// handleListRules returns the live rules, by kind and ID: each with its
+// subject, condition, state and tags. kind and tag select the rules of one
+// kind or with one tag. Callers authenticate with the RULES_API_SECRET or an
+// API key with the rules:write scope as a bearer token.
+// Route: GET /api/rules?kind=defi&tag=treasury
+func handleListRules(w http.ResponseWriter, r *http.Request, rules *store.RuleActions, auth routeAuth) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if !auth.configured() || rules == nil {
+		http.Error(w, "Rules are not configured", http.StatusServiceUnavailable)
+		return
+	}
+	if !auth.check(w, r) {
+		return
+	}
+
+	q := r.URL.Query()
+	kind := strings.ToLower(strings.TrimSpace(q.Get("kind")))
+	if kind != "" && !slices.Contains(core.MuteKinds, kind) {
+		http.Error(w, "kind must be one of: "+strings.Join(core.MuteKinds, ", "), http.StatusBadRequest)
+		return
+	}
+	tag := strings.ToLower(strings.TrimPrefix(strings.TrimSpace(q.Get("tag")), "#"))
+	if tag != "" && !core.ValidTag(tag) {
+		http.Error(w, "Invalid tag", http.StatusBadRequest)
+		return
+	}
+
+	list, err := rules.List(kind, tag)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to list rules: %v", err), http.StatusInternalServerError)
+		return
+	}
+	if list == nil {
+		list = []core.RuleSummary{}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"data": list})
+}
+
+// handleListRuleChanges returns the rule change audit log, newest first: who
+// created, updated or deleted a rule, when, and the rule's row before and
+// after. The rows hold recipients and webhook URLs, so callers authenticate
+// with the RULES_API_SECRET or an API key with the rules:write scope as a
+// bearer token. kind and rule_id select the changes of one kind or one rule. A page has limit changes (default 50, at
+// most 500) and the next_cursor to pass as cursor for the next one, empty on
+// the last page.
+// Route: GET /api/rules/changes?kind=token&rule_id=12&limit=50&cursor=
+func handleListRuleChanges(w http.ResponseWriter, r *http.Request, changes *store.RuleChanges, auth routeAuth) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if !auth.configured() || changes == nil {
+		http.Error(w, "Rule changes are not configured", http.StatusServiceUnavailable)
+		return
+	}
+	if !auth.check(w, r) {
+		return
+	}
+
+	q := r.URL.Query()
+	kind := strings.ToLower(strings.TrimSpace(q.Get("kind")))
+	if kind != "" && !slices.Contains(core.MuteKinds, kind) {
+		http.Error(w, "kind must be one of: "+strings.Join(core.MuteKinds, ", "), http.StatusBadRequest)
+		return
+	}
+	var ruleID, beforeID int64
+	var err error
+	for param, dst := range map[string]*int64{"rule_id": &ruleID, "cursor": &beforeID} {
+		if s := q.Get(param); s != "" {
+			if *dst, err = strconv.ParseInt(s, 10, 64); err != nil || *dst <= 0 {
+				http.Error(w, "Invalid "+param, http.StatusBadRequest)
+				return
+			}
+		}
+	}
+	if ruleID != 0 && kind == "" {
+		http.Error(w, "rule_id needs a kind", http.StatusBadRequest)
+		return
+	}
+	limit := defaultAlertsLimit
+	if s := q.Get("limit"); s != "" {
+		if limit, err = strconv.Atoi(s); err != nil || limit <= 0 {
+			http.Error(w, "Invalid limit", http.StatusBadRequest)
+			return
+		}
+		limit = min(limit, maxAlertsLimit)
+	}
+
+	list, err := changes.List(kind, ruleID, beforeID, limit)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to list rule changes: %v", err), http.StatusInternalServerError)
+		return
+	}
+	if list == nil {
+		list = []store.RuleChange{}
+	}
+	nextCursor := ""
+	if len(list) == limit {
+		nextCursor = strconv.FormatInt(list[len(list)-1].ID, 10)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"data":        list,
+		"next_cursor": nextCursor,
+	})
+}
+
+// handleRuleArchive archives a rule, or restores an archived one with
+// restore: an archived rule stops alerting on the engine's next rule reload,
+// but its row stays, so its alert history and changes keep pointing at it.
+// Callers authenticate with the RULES_API_SECRET or an API key with the
+// rules:write scope as a bearer token; by names who made the change in the
+// rule change audit log.
+// Route: POST /api/rules/archive?kind=token&rule_id=12&by=alice, POST /api/rules/restore?...
+func handleRuleArchive(w http.ResponseWriter, r *http.Request, rules *store.RuleActions, auth routeAuth, restore bool) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if !auth.configured() || rules == nil {
+		http.Error(w, "Rule archiving is not configured", http.StatusServiceUnavailable)
+		return
+	}
+	if !auth.check(w, r) {
+		return
+	}
+
+	q := r.URL.Query()
+	kind := strings.ToLower(q.Get("kind"))
+	ruleID, err := strconv.ParseInt(q.Get("rule_id"), 10, 64)
+	if kind == "" || err != nil || ruleID <= 0 {
+		http.Error(w, "kind and rule_id are required", http.StatusBadRequest)
+		return
+	}
+	if !slices.Contains(core.MuteKinds, kind) {
+		http.Error(w, "kind must be one of: "+strings.Join(core.MuteKinds, ", "), http.StatusBadRequest)
+		return
+	}
+	by := "API"
+	if s := q.Get("by"); s != "" {
+		if len(s) > 200 {
+			s = s[:200]
+		}
+		by = s + " (API)"
+	}
+
+	action, done := rules.Archive, "archived"
+	if restore {
+		action, done = rules.Restore, "restored"
+	}
+	if err := action(kind, ruleID, by); err != nil {
+		if errors.Is(err, store.ErrRuleNotFound) {
+			http.Error(w, "Rule not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, fmt.Sprintf("Failed to change the rule: %v", err), http.StatusInternalServerError)
+		return
+	}
+	slog.Info("🗄️ Rule changed", logger.FieldKind, kind, logger.FieldRuleID, ruleID, "action", done, "by", maskEmails(by))
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"kind":    kind,
+		"rule_id": ruleID,
+		done:      true,
+	})
+}
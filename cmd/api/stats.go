@@ -0,0 +1,226 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"crypto-alert/internal/core"
+	"crypto-alert/internal/logger"
+	"crypto-alert/internal/store"
+)
+
+// Stats ranges and top list sizes
+const (
+	defaultStatsDays = 7
+	maxStatsDays     = 92
+	defaultStatsTop  = 10
+	maxStatsTop      = 100
+)
+
+// statsLogPhrases are the counts /api/stats takes from the logs, each the log
+// lines containing all of its phrases: the check rounds of the engine, and
+// the alerts and deliveries for when there is no database
+var statsLogPhrases = map[string][]string{
+	"checks":  {"🔍 Checking"},
+	"token":   {"✅ Alert published"},
+	"defi":    {"✅ DeFi alert published"},
+	"predict": {"✅ Predict market alert published"},
+	"watch":   {"✅ Watch alert published"},
+	"sent":    {"✅ [", "] sent "},
+	"failed":  {"❌", "failed to send"},
+}
+
+// dayStats are the counts of a day, or of all days of the range
+type dayStats struct {
+	Date          string         `json:"date,omitempty"` // yyyyMMdd, UTC
+	Checks        int            `json:"checks"`
+	Alerts        map[string]int `json:"alerts"` // Per rule kind
+	Notifications struct {
+		Succeeded int `json:"succeeded"` // Sent, and delivered for email
+		Failed    int `json:"failed"`    // Failed to send, or bounced
+		Skipped   int `json:"skipped"`   // Suppressed, rate limited, digested or muted
+	} `json:"notifications"`
+}
+
+func newDayStats(date string) *dayStats {
+	s := &dayStats{Date: date, Alerts: map[string]int{}}
+	for _, kind := range core.MuteKinds {
+		s.Alerts[kind] = 0
+	}
+	return s
+}
+
+// symbolStats is a token symbol and the alerts its rules triggered
+type symbolStats struct {
+	Symbol string `json:"symbol"`
+	Alerts int    `json:"alerts"`
+}
+
+// handleGetStats returns per-day counts for the dashboard's summary cards:
+// the engine's check rounds, the alerts triggered per rule kind and the
+// notifications sent, failed and skipped, with their totals and the token
+// symbols with the most alerts. Checks come from the logs, in Elasticsearch
+// or else the log files; alerts and notifications from the alert history and
+// notification log, or from the logs without a database. from and to are
+// yyyyMMdd days (UTC), to inclusive, by default the last 7 days; top is the
+// number of symbols (default 10, at most 100).
+// Route: GET /api/stats?from=20260101&to=20260107&top=10
+func handleGetStats(w http.ResponseWriter, r *http.Request, logs store.LogSource, history *store.AlertHistory, notificationLog *store.NotificationLog) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	q := r.URL.Query()
+	to := time.Now().UTC().Truncate(24 * time.Hour)
+	if s := q.Get("to"); s != "" {
+		t, err := time.Parse("20060102", s)
+		if err != nil {
+			http.Error(w, "Invalid to, expected yyyyMMdd", http.StatusBadRequest)
+			return
+		}
+		to = t
+	}
+	from := to.AddDate(0, 0, 1-defaultStatsDays)
+	if s := q.Get("from"); s != "" {
+		t, err := time.Parse("20060102", s)
+		if err != nil {
+			http.Error(w, "Invalid from, expected yyyyMMdd", http.StatusBadRequest)
+			return
+		}
+		from = t
+	}
+	until := to.AddDate(0, 0, 1)
+	if !from.Before(until) {
+		http.Error(w, "from must not be after to", http.StatusBadRequest)
+		return
+	}
+	if until.Sub(from) > maxStatsDays*24*time.Hour {
+		http.Error(w, fmt.Sprintf("The range can be at most %d days", maxStatsDays), http.StatusBadRequest)
+		return
+	}
+	top := defaultStatsTop
+	if s := q.Get("top"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 0 {
+			http.Error(w, "Invalid top", http.StatusBadRequest)
+			return
+		}
+		top = min(n, maxStatsTop)
+	}
+
+	var days []*dayStats
+	byDate := map[string]*dayStats{}
+	for d := from; d.Before(until); d = d.AddDate(0, 0, 1) {
+		s := newDayStats(d.Format("20060102"))
+		days = append(days, s)
+		byDate[s.Date] = s
+	}
+	sources := map[string]string{}
+
+	// The log counts, from Elasticsearch when it has any of the days
+	logCounts, source, err := logs.CountPhrases(r.Context(), from, until, statsLogPhrases)
+	if err != nil {
+		slog.Error("Log CountPhrases error", logger.FieldError, err)
+	}
+	sources["logs"] = source
+	for date, counts := range logCounts {
+		if s := byDate[date]; s != nil {
+			s.Checks = counts["checks"]
+		}
+	}
+
+	if history != nil {
+		counts, err := history.DailyCounts(from, until)
+		if err != nil {
+			slog.Error("Alert history DailyCounts error", logger.FieldError, err)
+			http.Error(w, "Failed to read the alert history", http.StatusInternalServerError)
+			return
+		}
+		for _, c := range counts {
+			if s := byDate[c.Day]; s != nil {
+				s.Alerts[c.Kind] += c.Count
+			}
+		}
+		sources["alerts"] = "alert_history"
+	} else {
+		for date, counts := range logCounts {
+			if s := byDate[date]; s != nil {
+				for _, kind := range core.MuteKinds {
+					s.Alerts[kind] = counts[kind]
+				}
+			}
+		}
+		sources["alerts"] = "logs"
+	}
+
+	if notificationLog != nil {
+		counts, err := notificationLog.DailyStatusCounts(from, until)
+		if err != nil {
+			slog.Error("Notification log DailyStatusCounts error", logger.FieldError, err)
+			http.Error(w, "Failed to read the notification log", http.StatusInternalServerError)
+			return
+		}
+		for _, c := range counts {
+			s := byDate[c.Day]
+			if s == nil {
+				continue
+			}
+			switch c.Status {
+			case store.DeliveryStatusSent, store.DeliveryStatusDelivered, store.DeliveryStatusComplained:
+				s.Notifications.Succeeded += c.Count
+			case store.DeliveryStatusFailed, store.DeliveryStatusBounced:
+				s.Notifications.Failed += c.Count
+			default:
+				s.Notifications.Skipped += c.Count
+			}
+		}
+		sources["notifications"] = "notification_log"
+	} else {
+		for date, counts := range logCounts {
+			if s := byDate[date]; s != nil {
+				s.Notifications.Succeeded, s.Notifications.Failed = counts["sent"], counts["failed"]
+			}
+		}
+		sources["notifications"] = "logs"
+	}
+
+	totals := newDayStats("")
+	for _, s := range days {
+		totals.Checks += s.Checks
+		for kind, n := range s.Alerts {
+			totals.Alerts[kind] += n
+		}
+		totals.Notifications.Succeeded += s.Notifications.Succeeded
+		totals.Notifications.Failed += s.Notifications.Failed
+		totals.Notifications.Skipped += s.Notifications.Skipped
+	}
+
+	// The alert history has the symbols; the logs have them only in free text
+	topSymbols := []symbolStats{}
+	if history != nil && top > 0 {
+		subjects, err := history.TopSubjects("token", from, until, top)
+		if err != nil {
+			slog.Error("Alert history TopSubjects error", logger.FieldError, err)
+			http.Error(w, "Failed to read the alert history", http.StatusInternalServerError)
+			return
+		}
+		for _, s := range subjects {
+			topSymbols = append(topSymbols, symbolStats{Symbol: s.Subject, Alerts: s.Alerts})
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"from":        from.Format("20060102"),
+		"to":          to.Format("20060102"),
+		"days":        days,
+		"totals":      totals,
+		"top_symbols": topSymbols,
+		"sources":     sources,
+	})
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+
+	"crypto-alert/internal/logger"
+	"crypto-alert/internal/message"
+)
+
+// handleTelegramWebhook answers the bot's commands when Telegram delivers its
+// updates by webhook instead of the notification service's long polling. The
+// webhook must be set with secret_token, which Telegram sends back in the
+// X-Telegram-Bot-Api-Secret-Token header.
+// Route: POST /api/webhooks/telegram
+func handleTelegramWebhook(w http.ResponseWriter, r *http.Request, bot *message.TelegramBot, secret string) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if secret == "" || bot == nil {
+		http.Error(w, "Telegram webhooks are not configured", http.StatusServiceUnavailable)
+		return
+	}
+	if subtle.ConstantTimeCompare([]byte(r.Header.Get("X-Telegram-Bot-Api-Secret-Token")), []byte(secret)) != 1 {
+		slog.Warn("⚠️ Rejected Telegram webhook: invalid secret token")
+		http.Error(w, "Invalid secret token", http.StatusUnauthorized)
+		return
+	}
+
+	var update message.TelegramUpdate
+	if err := json.NewDecoder(io.LimitReader(r.Body, 1<<20)).Decode(&update); err != nil {
+		http.Error(w, "Invalid update", http.StatusBadRequest)
+		return
+	}
+	// Telegram redelivers updates that aren't acknowledged with a 2xx, so a
+	// failure to handle one is only logged
+	if err := bot.HandleUpdate(update); err != nil {
+		slog.Warn("⚠️ Telegram update failed", "update_id", update.UpdateID, logger.FieldError, err)
+	}
+	w.WriteHeader(http.StatusOK)
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"fmt"
+	"html/template"
+	"log/slog"
+	"net/http"
+
+	"crypto-alert/internal/message"
+	"crypto-alert/internal/store"
+)
+
+// unsubscribePage asks to confirm an unsubscribe opened from an email link,
+// so link scanners that fetch the URL don't unsubscribe anyone, and reports
+// the result once confirmed.
+var unsubscribePage = template.Must(template.New("unsubscribe").Parse(`<!DOCTYPE html>
+<html>
+<head><meta charset="UTF-8"><meta name="viewport" content="width=device-width, initial-scale=1.0"><title>Unsubscribe</title></head>
+<body style="font-family: -apple-system, BlinkMacSystemFont, 'Segoe UI', Roboto, sans-serif; max-width: 480px; margin: 64px auto; padding: 0 16px; color: #333;">
+{{if .Done}}<h2>You're unsubscribed</h2>
+<p>{{.Email}} won't receive crypto alert emails any more.</p>
+{{else}}<h2>Unsubscribe</h2>
+<p>Stop sending crypto alert emails to {{.Email}}?</p>
+<form method="POST"><button type="submit" style="padding: 10px 20px; font-size: 16px;">Unsubscribe</button></form>
+{{end}}</body>
+</html>
+`))
+
+// handleUnsubscribe adds the address of a signed unsubscribe link to the email
+// suppression list. GET shows a confirmation page; POST unsubscribes, both
+// from that page and as the one-click unsubscribe of mail clients (RFC 8058).
+// Route: GET/POST /api/unsubscribe?email=...&token=...
+func handleUnsubscribe(w http.ResponseWriter, r *http.Request, nl *store.NotificationLog, secret string) {
+	if r.Method != http.MethodGet && r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if secret == "" || nl == nil {
+		http.Error(w, "Unsubscribe links are not configured", http.StatusServiceUnavailable)
+		return
+	}
+
+	email := r.URL.Query().Get("email")
+	if email == "" || !message.ValidUnsubscribeToken(secret, email, r.URL.Query().Get("token")) {
+		http.Error(w, "Invalid unsubscribe link", http.StatusForbidden)
+		return
+	}
+
+	if r.Method == http.MethodPost {
+		if err := nl.SuppressEmail(email, "unsubscribed"); err != nil {
+			http.Error(w, fmt.Sprintf("Failed to unsubscribe: %v", err), http.StatusInternalServerError)
+			return
+		}
+		slog.Info("🚫 Suppressed address after an unsubscribe", "email", maskEmails(email))
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	_ = unsubscribePage.Execute(w, map[string]interface{}{
+		"Email": email,
+		"Done":  r.Method == http.MethodPost,
+	})
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/pprof"
+	"runtime"
+	"time"
+
+	"crypto-alert/internal/core"
+	"crypto-alert/internal/data/defi"
+	"crypto-alert/internal/logger"
+)
+
+// debugStatus is the body of /debug/status
+type debugStatus struct {
+	StartedAt   time.Time                  `json:"started_at"`
+	Uptime      string                     `json:"uptime"`
+	GoVersion   string                     `json:"go_version"`
+	Goroutines  int                        `json:"goroutines"`
+	Memory      debugMemory                `json:"memory"`
+	DeFiClients int64                      `json:"defi_clients"`
+	Rules       map[string]debugRuleCounts `json:"rules"`
+}
+
+// debugMemory is what runtime.MemStats tells about the heap and the GC
+type debugMemory struct {
+	HeapAllocBytes uint64     `json:"heap_alloc_bytes"`
+	HeapInuseBytes uint64     `json:"heap_inuse_bytes"`
+	HeapObjects    uint64     `json:"heap_objects"`
+	SysBytes       uint64     `json:"sys_bytes"`
+	NumGC          uint32     `json:"num_gc"`
+	LastGC         *time.Time `json:"last_gc,omitempty"`
+}
+
+// debugRuleCounts counts the rules of a kind the engine holds
+type debugRuleCounts struct {
+	Total   int `json:"total"`
+	Enabled int `json:"enabled"`
+}
+
+// serveDebug serves the pprof profiles and the engine's runtime status on
+// addr until ctx is done. It has no authentication: bind it to localhost or
+// a private network.
+//
+//	GET /debug/pprof/  pprof profiles (heap, goroutine, profile, trace, ...)
+//	GET /debug/status  goroutines, memory, open DeFi clients and rule counts as JSON
+func serveDebug(ctx context.Context, addr string, engine *core.DecisionEngine) {
+	startedAt := time.Now()
+	mux := http.NewServeMux()
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	mux.HandleFunc("/debug/status", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(newDebugStatus(engine, startedAt))
+	})
+	// No WriteTimeout: CPU profiles and traces take as long as their seconds parameter
+	srv := &http.Server{Addr: addr, Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = srv.Shutdown(shutdownCtx)
+	}()
+	slog.Info("🩺 Debug endpoints (pprof, status) listening", "addr", addr)
+	if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		slog.Warn("⚠️  Debug endpoints stopped", logger.FieldError, err)
+	}
+}
+
+// newDebugStatus reads the runtime status of the engine
+func newDebugStatus(engine *core.DecisionEngine, startedAt time.Time) debugStatus {
+	var m runtime.MemStats
+	runtime.ReadMemStats(&m)
+	status := debugStatus{
+		StartedAt:  startedAt.UTC().Truncate(time.Second),
+		Uptime:     time.Since(startedAt).Truncate(time.Second).String(),
+		GoVersion:  runtime.Version(),
+		Goroutines: runtime.NumGoroutine(),
+		Memory: debugMemory{
+			HeapAllocBytes: m.HeapAlloc,
+			HeapInuseBytes: m.HeapInuse,
+			HeapObjects:    m.HeapObjects,
+			SysBytes:       m.Sys,
+			NumGC:          m.NumGC,
+		},
+		DeFiClients: defi.ActiveClients(),
+		Rules:       map[string]debugRuleCounts{},
+	}
+	if m.LastGC > 0 {
+		lastGC := time.Unix(0, int64(m.LastGC)).UTC()
+		status.Memory.LastGC = &lastGC
+	}
+
+	status.Rules["token"] = countRules(engine.GetRules(), func(r *core.AlertRule) bool { return r.Enabled })
+	status.Rules["defi"] = countRules(engine.GetDeFiRules(), func(r *core.DeFiAlertRule) bool { return r.Enabled })
+	status.Rules["predict"] = countRules(engine.GetPredictMarketRules(), func(r *core.PredictMarketAlertRule) bool { return r.Enabled })
+	status.Rules["watch"] = countRules(engine.GetWatchRules(), func(r *core.WatchAlertRule) bool { return r.Enabled })
+	return status
+}
+
+// countRules counts rules and those of them that are enabled
+func countRules[R any](rules []R, enabled func(R) bool) debugRuleCounts {
+	c := debugRuleCounts{Total: len(rules)}
+	for _, r := range rules {
+		if enabled(r) {
+			c.Enabled++
+		}
+	}
+	return c
+}
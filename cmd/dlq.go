@@ -0,0 +1,155 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"slices"
+	"strings"
+	"syscall"
+	"time"
+
+	"crypto-alert/internal/logger"
+	"crypto-alert/internal/message"
+
+	"github.com/joho/godotenv"
+)
+
+const dlqUsage = `Usage:
+  crypto-alert dlq list [--from <RFC3339>]
+  crypto-alert dlq redrive (--id <id>[,...] | --all) [--from <RFC3339>]`
+
+// runDLQ runs `crypto-alert dlq`, which lists the alerts the notification
+// service dead-lettered and re-drives selected ones. It reads the dead-letter
+// topic of the EVENT_TRANSPORT the service uses; RabbitMQ can't replay it.
+func runDLQ(args []string) {
+	_ = godotenv.Load()
+	if len(args) == 0 || (args[0] != "list" && args[0] != "redrive") {
+		fmt.Fprintln(os.Stderr, dlqUsage)
+		os.Exit(2)
+	}
+	fs := flag.NewFlagSet("dlq "+args[0], flag.ExitOnError)
+	fromFlag := fs.String("from", "", "only dead letters published since this time (RFC3339; default 7 days ago)")
+	ids := fs.String("id", "", "redrive: comma-separated IDs from dlq list (partition:offset on Kafka)")
+	all := fs.Bool("all", false, "redrive: every dead letter since --from")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), dlqUsage)
+		fs.PrintDefaults()
+	}
+	_ = fs.Parse(args[1:])
+	from := time.Now().Add(-7 * 24 * time.Hour)
+	if *fromFlag != "" {
+		t, err := time.Parse(time.RFC3339, *fromFlag)
+		if err != nil {
+			fs.Usage()
+			os.Exit(2)
+		}
+		from = t
+	}
+	selected := map[string]bool{}
+	for _, id := range strings.Split(*ids, ",") {
+		if id = strings.TrimSpace(id); id != "" {
+			selected[id] = true
+		}
+	}
+	if args[0] == "redrive" && len(selected) == 0 && !*all {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	brokers := strings.Split(os.Getenv("KAFKA_BROKERS"), ",")
+	if brokers[0] == "" {
+		brokers = []string{"localhost:9092"}
+	}
+	transport, err := message.NewTransport(message.TransportConfig{
+		Kind:         os.Getenv("EVENT_TRANSPORT"),
+		KafkaBrokers: brokers,
+		NATSURL:      os.Getenv("NATS_URL"),
+		AMQPURL:      os.Getenv("AMQP_URL"),
+		RedisURL:     os.Getenv("REDIS_URL"),
+	})
+	if err != nil {
+		logger.Fatal("Event transport error", logger.FieldError, err)
+	}
+	defer transport.Close()
+	replayer, ok := transport.(message.Replayer)
+	if !ok {
+		logger.Fatal("The transport can't read the dead-letter topic back; nothing is dead-lettered on it", "transport", transport.Name())
+	}
+	publisher := message.NewAlertPublisher(transport)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	var listed, redriven int
+	err = replayer.Replay(ctx, message.TopicDeadLetter, from, func(e message.ReplayedEvent) error {
+		id := e.ID
+		var dead message.DeadLetterEvent
+		if err := json.Unmarshal(e.Value, &dead); err != nil {
+			slog.Warn("⚠️  Undecodable dead letter", logger.FieldEventID, id, logger.FieldError, err)
+			return nil
+		}
+		if args[0] == "list" {
+			listed++
+			printDeadLetter(id, dead)
+			return nil
+		}
+		if !*all && !selected[id] {
+			return nil
+		}
+		delete(selected, id)
+		if err := redrive(publisher, dead); err != nil {
+			return fmt.Errorf("redrive %s: %w", id, err)
+		}
+		redriven++
+		slog.Info("🔁 Re-drove dead letter", logger.FieldTopic, dead.Topic, logger.FieldEventID, id, "channels", dead.Channels)
+		return nil
+	})
+	if err != nil {
+		logger.Fatal("Reading the dead-letter topic failed", logger.FieldError, err)
+	}
+	switch args[0] {
+	case "list":
+		fmt.Printf("%d dead letters since %s\n", listed, from.UTC().Format(time.RFC3339))
+	case "redrive":
+		for id := range selected {
+			slog.Warn("⚠️  Dead letter not found", logger.FieldEventID, id, "since", from.UTC())
+		}
+		slog.Info("🔁 Re-drove dead letters", "dead_letters", redriven)
+	}
+}
+
+// printDeadLetter writes a dead letter with its error reasons to stdout
+func printDeadLetter(id string, dead message.DeadLetterEvent) {
+	var alert struct {
+		RuleID int64 `json:"rule_id"`
+	}
+	_ = json.Unmarshal(dead.Payload, &alert)
+	fmt.Printf("%-10s %s  %s  rule %d  %s after %d retries\n",
+		id, dead.FailedAt.UTC().Format(time.RFC3339), dead.Topic, alert.RuleID, dead.Reason, dead.Attempts)
+	channels := make([]string, 0, len(dead.Errors))
+	for ch := range dead.Errors {
+		channels = append(channels, ch)
+	}
+	slices.Sort(channels)
+	for _, ch := range channels {
+		fmt.Printf("    %s: %s\n", ch, dead.Errors[ch])
+	}
+}
+
+// redrive sends a dead letter again through the retry topic, to the channels
+// it failed on (all channels when it couldn't be decoded or handled). A retry, unlike
+// the original event, isn't skipped as already delivered, and the channels
+// that succeeded don't get the alert twice.
+func redrive(publisher *message.AlertPublisher, dead message.DeadLetterEvent) error {
+	return publisher.PublishRetry(message.RetryEvent{
+		Topic:     dead.Topic,
+		Channels:  dead.Channels,
+		Attempt:   1,
+		NotBefore: time.Now(),
+		Payload:   dead.Payload,
+	})
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"crypto-alert/internal/core"
+	"crypto-alert/internal/logger"
+	"crypto-alert/internal/message"
+	"crypto-alert/internal/store"
+)
+
+// historySender records every alert in the alert history before sending it
+type historySender struct {
+	message.MessageSender
+	history *store.AlertHistory
+}
+
+// WithContext passes the trace of ctx on to the publisher, see message.WithContext
+func (s historySender) WithContext(ctx context.Context) message.MessageSender {
+	return historySender{MessageSender: message.WithContext(s.MessageSender, ctx), history: s.history}
+}
+
+func (s historySender) SendAlert(to string, decision *core.AlertDecision) error {
+	r := decision.Rule
+	s.record(store.AlertHistoryEntry{
+		RuleKind: "token", RuleID: r.ID, EventID: eventID(message.TopicTokenAlert, r.ID, decision.TriggeredAt), Subject: r.Symbol, Field: "PRICE",
+		Value: decision.CurrentPrice.Price, Threshold: r.Threshold, Direction: string(r.Direction),
+		Severity: severity(r.Severity), Message: decision.Message, Tags: r.Tags,
+	})
+	return s.MessageSender.SendAlert(to, decision)
+}
+
+func (s historySender) SendDeFiAlert(to string, decision *core.DeFiAlertDecision) error {
+	r := decision.Rule
+	s.record(store.AlertHistoryEntry{
+		RuleKind: "defi", RuleID: r.ID, EventID: eventID(message.TopicDeFiAlert, r.ID, decision.TriggeredAt), Subject: r.Protocol, Field: r.Field,
+		Value: decision.CurrentValue, Threshold: r.Threshold, Direction: string(r.Direction),
+		Severity: severity(r.Severity), Message: decision.Message, Tags: r.Tags,
+	})
+	return s.MessageSender.SendDeFiAlert(to, decision)
+}
+
+func (s historySender) SendPredictMarketAlert(to string, decision *core.PredictMarketAlertDecision) error {
+	r := decision.Rule
+	s.record(store.AlertHistoryEntry{
+		RuleKind: "predict", RuleID: r.ID, EventID: eventID(message.TopicPredictAlert, r.ID, decision.TriggeredAt), Subject: r.PredictMarket, Field: r.Field,
+		Value: decision.CurrentValue, Threshold: r.Threshold, Direction: string(r.Direction),
+		Severity: severity(r.Severity), Message: decision.Message, Tags: r.Tags,
+	})
+	return s.MessageSender.SendPredictMarketAlert(to, decision)
+}
+
+func (s historySender) SendWatchAlert(to string, decision *core.WatchAlertDecision) error {
+	r := decision.Rule
+	s.record(store.AlertHistoryEntry{
+		RuleKind: "watch", RuleID: r.ID, EventID: eventID(message.TopicWatchAlert, r.ID, decision.TriggeredAt), Subject: r.Source, Field: r.Field,
+		Value: decision.Observation.Value, Threshold: r.Threshold, Direction: string(r.Direction),
+		Severity: severity(r.Severity), Message: decision.Message, Tags: r.Tags,
+	})
+	return s.MessageSender.SendWatchAlert(to, decision)
+}
+
+// severity is the severity of a rule's alerts; rules without one alert as warnings
+func severity(s core.Severity) string {
+	if s == "" {
+		return string(core.SeverityWarning)
+	}
+	return string(s)
+}
+
+// eventID is the ID of the alert event the publisher sends for the decision,
+// which its deliveries are logged under; unknown when the decision has no
+// trigger time
+func eventID(topic string, ruleID int64, triggeredAt time.Time) string {
+	if triggeredAt.IsZero() {
+		return ""
+	}
+	return message.AlertEventID(topic, ruleID, triggeredAt)
+}
+
+// record logs instead of failing, so the alert still goes out
+func (s historySender) record(e store.AlertHistoryEntry) {
+	if err := s.history.Record(e); err != nil {
+		slog.Warn("⚠️  Failed to record the alert in the alert history", logger.FieldKind, e.RuleKind, logger.FieldRuleID, e.RuleID, logger.FieldError, err)
+	}
+}
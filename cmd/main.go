@@ -3,70 +3,197 @@ package main
 import (
 	"context"
 	"fmt"
-	"log"
+	"log/slog"
+	"math"
 	"os"
 	"os/signal"
+	"slices"
+	"strings"
+	"sync"
 	"syscall"
 	"time"
 
 	"crypto-alert/internal/config"
 	"crypto-alert/internal/core"
 	"crypto-alert/internal/data/defi"
+	"crypto-alert/internal/data/ens"
+	"crypto-alert/internal/data/watch"
 	"crypto-alert/internal/logger"
 	"crypto-alert/internal/message"
+	"crypto-alert/internal/notify"
+	"crypto-alert/internal/data/prediction"
+	"crypto-alert/internal/data/prediction/limitless"
 	"crypto-alert/internal/data/prediction/polymarket"
 	"crypto-alert/internal/data/price"
 	"crypto-alert/internal/store"
+	"crypto-alert/internal/tracing"
 )
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "dlq" {
+		runDLQ(os.Args[2:])
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "telegram-code" {
+		runTelegramCode(os.Args[2:])
+		return
+	}
+
 	// Load configuration
 	cfg, err := config.LoadConfig()
 	if err != nil {
-		log.Fatalf("Failed to load config: %v", err)
+		logger.Fatal("Failed to load config", logger.FieldError, err)
 	}
 
 	// Initialize logger with date-based file rotation and optional Elasticsearch
-	esConfig := &logger.ESConfig{
-		Enabled:   cfg.ESEnabled,
-		Addresses: cfg.ESAddresses,
-		Index:     cfg.ESIndex,
-	}
-	if err := logger.InitLogger(cfg.LogDir, esConfig); err != nil {
-		log.Fatalf("Failed to initialize logger: %v", err)
+	esConfig := cfg.ESConfig()
+	if err := logger.InitLogger(logger.Config{
+		Dir:     cfg.LogDir,
+		Service: logger.ServiceMonitor,
+		Format:  cfg.LogFormat,
+		MaxSize: int64(cfg.LogMaxSizeMB) << 20,
+		ES:      esConfig,
+		Loki: &logger.LokiConfig{
+			URL:      cfg.LokiURL,
+			TenantID: cfg.LokiTenantID,
+			Username: cfg.LokiUsername,
+			Password: cfg.LokiPassword,
+		},
+	}); err != nil {
+		logger.Fatal("Failed to initialize logger", logger.FieldError, err)
 	}
 	defer logger.GetLogger().Close()
 
+	// Spans of the alert path, exported when OTEL_EXPORTER_OTLP_ENDPOINT is set
+	shutdownTracing, err := tracing.Init(context.Background(), "crypto-alert-"+logger.ServiceMonitor)
+	if err != nil {
+		logger.Fatal("Failed to set up tracing", logger.FieldError, err)
+	}
+	defer shutdownTracing()
+
 	// Initialize components
 	pythClient := price.NewPythClient(cfg.PythAPIURL, cfg.PythAPIKey)
 	decisionEngine := core.NewDecisionEngine()
 
-	// Setup Kafka alert publisher (notification-service handles email delivery)
-	kafkaPublisher := message.NewKafkaAlertPublisher(cfg.KafkaBrokers)
-	defer kafkaPublisher.Close()
-	var emailSender message.MessageSender = kafkaPublisher
-	log.Printf("📨 Kafka publisher connected to brokers: %v", cfg.KafkaBrokers)
+	// Setup the alert publisher on Kafka, NATS, RabbitMQ or Redis (notification-service handles email delivery),
+	// or on an in-process queue when no broker is configured
+	transport, err := message.NewTransport(message.TransportConfig{
+		Kind:         cfg.EventTransport,
+		KafkaBrokers: cfg.KafkaBrokers,
+		Kafka: message.KafkaWriterConfig{
+			BatchSize: cfg.KafkaBatchSize,
+			Linger:    time.Duration(cfg.KafkaLingerMs) * time.Millisecond,
+			Acks:      cfg.KafkaAcks,
+			Async:     cfg.KafkaAsync,
+			// In async mode a failed write only shows up here, after the alert
+			// was counted as sent
+			OnError: func(topic string, key []byte, err error) {
+				slog.Error("❌ Alert event was not written to Kafka", logger.FieldTopic, topic, logger.FieldRuleID, string(key), logger.FieldError, err)
+			},
+		},
+		NATSURL:  cfg.NATSURL,
+		AMQPURL:  cfg.AMQPURL,
+		RedisURL: cfg.RedisURL,
+	})
+	if err != nil {
+		logger.Fatal("Failed to set up the event transport", logger.FieldError, err)
+	}
+	defer transport.Close()
+	// Create missing topics with the configured partitions and retention
+	if kafkaTransport, ok := transport.(*message.KafkaTransport); ok {
+		kafkaTransport.CreateTopics(context.Background(), message.KafkaTopicConfig{
+			Partitions:        cfg.KafkaPartitions,
+			ReplicationFactor: cfg.KafkaReplicas,
+			Retention:         time.Duration(cfg.KafkaRetentionHours) * time.Hour,
+		})
+	}
+	publisher := message.NewAlertPublisher(transport)
+	var emailSender message.MessageSender = publisher
+	slog.Info("📨 Alert publisher connected", "transport", transport.Name())
+
+	// Create or upgrade the tables before anything reads them
+	if cfg.MySQLDSN != "" && cfg.DBMigrate {
+		applied, err := store.Migrate(cfg.MySQLDSN)
+		if err != nil {
+			logger.Fatal("Failed to migrate the database", logger.FieldError, err)
+		}
+		for _, name := range applied {
+			slog.Info("🗄️  Applied database migration", "migration", name)
+		}
+	}
 
 	// Initialize metric store for dashboard time-series data
 	metricStore, err := store.NewMetricStore(cfg.MySQLDSN)
 	if err != nil {
-		log.Printf("⚠️  MetricStore disabled (dashboard charts unavailable): %v", err)
+		slog.Warn("⚠️  MetricStore disabled (dashboard charts unavailable)", logger.FieldError, err)
 		metricStore = nil
 	} else {
 		defer metricStore.Close()
-		log.Println("📈 MetricStore connected — dashboard data will be recorded")
+		slog.Info("📈 MetricStore connected — dashboard data will be recorded")
 	}
 
+	// Triggered alerts are recorded in alert_history before they're sent
+	alertHistory, err := store.NewAlertHistory(cfg.MySQLDSN)
+	if err != nil {
+		slog.Warn("⚠️  Alert history disabled", logger.FieldError, err)
+	} else {
+		defer alertHistory.Close()
+		emailSender = historySender{MessageSender: emailSender, history: alertHistory}
+	}
+
+	// ENS resolver for rule addresses configured as names (needs ETH_RPC_URL)
+	ensResolver, err := ens.NewResolver(time.Duration(cfg.ENSCacheTTL) * time.Second)
+	if err != nil {
+		slog.Warn("⚠️  ENS resolution disabled (rules using ENS names will be skipped)", logger.FieldError, err)
+		ensResolver = nil
+	} else {
+		defer ensResolver.Close()
+	}
+
+	// Rules are read over one pooled connection, kept open for the reloads
+	// (MySQL, or PostgreSQL / SQLite for a postgres:// / sqlite: MYSQL_DSN)
+	ruleStore, err := store.NewRuleStore(cfg.MySQLDSN)
+	if err != nil {
+		logger.Fatal("Failed to connect to the rules database", logger.FieldError, err)
+	}
+	defer ruleStore.Close()
+	// Triggers are written back to the rule tables (last_triggered, ONCE rules disabled)
+	decisionEngine.SetTriggerRecorder(ruleStore)
+
 	// Load alert rules from MySQL
-	if err := loadAlertRulesFromMySQL(decisionEngine, cfg.MySQLDSN); err != nil {
-		log.Fatalf("Failed to load alert rules from MySQL: %v", err)
+	if err := loadAlertRulesFromMySQL(decisionEngine, ruleStore, ensResolver); err != nil {
+		logger.Fatal("Failed to load alert rules from MySQL", logger.FieldError, err)
 	}
 
+	// Gamma API client for prediction rules configured by market slug / condition ID
+	gammaClient := polymarket.NewGammaClient()
+
+	// Prediction market venues, keyed by the rule's predict_market
+	predictSources := prediction.NewSources(polymarket.NewClient(), limitless.NewClient())
+
 	// Load prediction market rules from MySQL (before goroutines start)
-	if err := loadPredictMarketRulesFromMySQL(decisionEngine, cfg.MySQLDSN); err != nil {
-		log.Printf("⚠️  Failed to load prediction market rules from MySQL: %v", err)
+	if err := loadPredictMarketRulesFromMySQL(decisionEngine, ruleStore, predictSources, gammaClient); err != nil {
+		slog.Warn("⚠️  Failed to load prediction market rules from MySQL", logger.FieldError, err)
 	}
 
+	// Load watch rules (Safe multisig, ...) from MySQL
+	if err := loadWatchRulesFromMySQL(decisionEngine, ruleStore, ensResolver); err != nil {
+		slog.Warn("⚠️  Failed to load watch rules from MySQL", logger.FieldError, err)
+	}
+	// Rules changed while the engine was down go to the rule change audit log
+	recordRuleChanges(ruleStore)
+	watchManager := watch.NewManager(watch.Options{
+		SafeAPIKey: cfg.SafeAPIKey,
+		PythAPIURL: cfg.PythAPIURL,
+		PythAPIKey: cfg.PythAPIKey,
+
+		SnapshotURL:    cfg.SnapshotURL,
+		SnapshotAPIKey: cfg.SnapshotAPIKey,
+
+		BitcoinAPIURL: cfg.BitcoinAPIURL,
+	})
+	defer watchManager.Close()
+
 	// Create context for graceful shutdown
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
@@ -75,17 +202,79 @@ func main() {
 	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
 
+	// Without a message broker the engine delivers the notifications itself
+	notifyDone := make(chan struct{})
+	if cfg.EventTransport == message.TransportInProcess {
+		go func() {
+			defer close(notifyDone)
+			if err := notify.Run(ctx, transport); err != nil {
+				logger.Fatal("In-process notifications failed", logger.FieldError, err)
+			}
+		}()
+	} else {
+		close(notifyDone)
+	}
+
+	// Prometheus metrics of the check cycles
+	if cfg.MetricsAddr != "" {
+		go serveMetrics(ctx, cfg.MetricsAddr)
+	}
+
+	// pprof and runtime status, to diagnose memory and goroutine growth
+	if cfg.DebugAddr != "" {
+		go serveDebug(ctx, cfg.DebugAddr, decisionEngine)
+	}
+
+	// Latest values and feed errors for the daily summary
+	status := newFeedStatus()
+
 	// Start the alert monitoring loops
-	go monitorPrices(ctx, pythClient, decisionEngine, emailSender, metricStore, cfg)
-	go monitorDeFi(ctx, decisionEngine, emailSender, metricStore, cfg)
-	go monitorPredictMarkets(ctx, decisionEngine, emailSender, metricStore, cfg)
+	go monitorPrices(ctx, pythClient, decisionEngine, emailSender, metricStore, status, cfg)
+	go monitorDeFi(ctx, decisionEngine, emailSender, metricStore, status, cfg)
+	go monitorPredictMarkets(ctx, decisionEngine, predictSources, emailSender, metricStore, status, cfg)
+	go monitorWatch(ctx, watchManager, decisionEngine, emailSender, status, cfg)
+
+	// Start the daily summary job
+	if cfg.DailySummaryHour >= 0 {
+		go sendDailySummaries(ctx, decisionEngine, status, publisher, cfg.DailySummaryHour)
+		slog.Info("📋 Daily summary emails enabled", "hour_utc", cfg.DailySummaryHour)
+	}
+
+	// Start the log archive and retention job, for the logs of all the services
+	logArchive, err := store.NewLogArchive(store.S3Config{
+		Endpoint:  cfg.LogArchiveEndpoint,
+		Region:    cfg.LogArchiveRegion,
+		Bucket:    cfg.LogArchiveBucket,
+		AccessKey: cfg.LogArchiveAccessKey,
+		SecretKey: cfg.LogArchiveSecretKey,
+	}, cfg.LogArchivePrefix)
+	if err != nil {
+		logger.Fatal("Failed to set up the log archive", logger.FieldError, err)
+	}
+	if cfg.LogRetentionDays > 0 || logArchive != nil {
+		janitor := &logJanitor{logDir: cfg.LogDir, archive: logArchive, days: cfg.LogRetentionDays}
+		if cfg.ESEnabled && cfg.LogRetentionDays > 0 {
+			if janitor.esLog, err = store.NewESClient(esConfig); err != nil {
+				slog.Warn("⚠️  Log retention: no Elasticsearch client, only pruning the log directory", "dir", cfg.LogDir, logger.FieldError, err)
+			} else {
+				defer janitor.esLog.Close()
+			}
+		}
+		go janitor.run(ctx)
+		if logArchive != nil {
+			slog.Info("📦 Archiving the closed days of the logs", "bucket", cfg.LogArchiveBucket, "prefix", cfg.LogArchivePrefix)
+		}
+		if cfg.LogRetentionDays > 0 {
+			slog.Info("🧹 Keeping the recent days of logs", "days", cfg.LogRetentionDays)
+		}
+	}
 
 	// Start hot-reload loop (periodically re-reads rules from MySQL without restart)
 	if cfg.RuleReloadInterval > 0 {
-		go reloadRulesLoop(ctx, decisionEngine, cfg)
+		go reloadRulesLoop(ctx, decisionEngine, ruleStore, cfg, ensResolver, predictSources, gammaClient)
 	}
 
-	log.Println("🚀 Crypto Alert System started")
+	slog.Info("🚀 Crypto Alert System started")
 
 	// Get symbols from alert rules for logging
 	rules := decisionEngine.GetRules()
@@ -96,7 +285,7 @@ func main() {
 		}
 	}
 	if len(symbols) > 0 {
-		log.Printf("📊 Monitoring price symbols: %v", symbols)
+		slog.Info("📊 Monitoring price symbols", "symbols", symbols)
 	}
 
 	// Get DeFi rules for logging
@@ -106,26 +295,31 @@ func main() {
 	// Log prediction market rules
 	predictRules := decisionEngine.GetPredictMarketRules()
 	if len(predictRules) > 0 {
-		log.Printf("📊 Monitoring prediction markets: %d rule(s)", len(predictRules))
+		slog.Info("📊 Monitoring prediction markets", "rules", len(predictRules))
 		for _, r := range predictRules {
 			if r.Enabled {
-				log.Printf("  - %s token %s (%s): %s %g", r.PredictMarket, r.TokenID, r.Outcome, r.Field, r.Threshold)
+				slog.Info("📊 Watching", logger.FieldSymbol, r.PredictMarket, logger.FieldTokenID, r.TokenID, "outcome", r.Outcome, "field", r.Field, "threshold", r.Threshold)
 			}
 		}
 	}
 
-	if len(symbols) == 0 && len(defiRules) == 0 && len(predictRules) == 0 {
-		log.Println("⚠️  No enabled alert rules found")
+	// Log watch rules
+	watchRules := decisionEngine.GetWatchRules()
+	watch.LogWatchRules(watchRules)
+
+	if len(symbols) == 0 && len(defiRules) == 0 && len(predictRules) == 0 && len(watchRules) == 0 {
+		slog.Warn("⚠️  No enabled alert rules found")
 	}
-	log.Printf("⏱️  Check interval: %d seconds", cfg.CheckInterval)
-	log.Println("Press Ctrl+C to stop...")
+	slog.Info("⏱️  Check interval", "seconds", cfg.CheckInterval)
+	slog.Info("Press Ctrl+C to stop...")
 
 	// Wait for shutdown signal
 	<-sigChan
-	log.Println("\n🛑 Shutting down...")
+	slog.Info("🛑 Shutting down...")
 	cancel()
 	time.Sleep(1 * time.Second)
-	log.Println("✅ Shutdown complete")
+	<-notifyDone // In-process notifications drain their in-flight alerts
+	slog.Info("✅ Shutdown complete")
 }
 
 // monitorPrices continuously monitors prices and triggers alerts
@@ -135,14 +329,15 @@ func monitorPrices(
 	decisionEngine *core.DecisionEngine,
 	sender message.MessageSender,
 	metricStore *store.MetricStore,
+	status *feedStatus,
 	cfg *config.Config,
 ) {
 	ticker := time.NewTicker(time.Duration(cfg.CheckInterval) * time.Second)
 	defer ticker.Stop()
 
 	// Run immediately on startup
-	if err := checkAndAlert(ctx, pythClient, decisionEngine, sender, metricStore); err != nil {
-		log.Printf("Error checking prices: %v", err)
+	if err := checkAndAlert(ctx, pythClient, decisionEngine, sender, metricStore, status); err != nil {
+		slog.Error("Error checking prices", logger.FieldError, err)
 	}
 
 	for {
@@ -150,8 +345,8 @@ func monitorPrices(
 		case <-ctx.Done():
 			return
 		case <-ticker.C:
-			if err := checkAndAlert(ctx, pythClient, decisionEngine, sender, metricStore); err != nil {
-				log.Printf("Error checking prices: %v", err)
+			if err := checkAndAlert(ctx, pythClient, decisionEngine, sender, metricStore, status); err != nil {
+				slog.Error("Error checking prices", logger.FieldError, err)
 			}
 		}
 	}
@@ -164,6 +359,7 @@ func checkAndAlert(
 	decisionEngine *core.DecisionEngine,
 	sender message.MessageSender,
 	metricStore *store.MetricStore,
+	status *feedStatus,
 ) error {
 	// Build symbol to price feed ID mapping from alert rules
 	rules := decisionEngine.GetRules()
@@ -176,43 +372,67 @@ func checkAndAlert(
 	}
 
 	if len(symbolToFeedID) == 0 {
-		log.Println("⚠️  No enabled alert rules found")
+		slog.Warn("⚠️  No enabled alert rules found")
 		return nil
 	}
 
-	log.Printf("🔍 Checking prices for %d symbol(s)...", len(symbolToFeedID))
+	ctx, checked := startCheck(ctx, "token")
+	defer checked()
+	slog.Info("🔍 Checking prices", "symbols", len(symbolToFeedID))
 
 	// Fetch prices from Pyth oracle using price feed IDs from rules
-	prices, err := pythClient.GetMultiplePrices(ctx, symbolToFeedID)
+	fetchCtx, fetched := startFetch(ctx, "pyth")
+	prices, err := pythClient.GetMultiplePrices(fetchCtx, symbolToFeedID)
+	fetched(err)
 	if err != nil {
+		status.recordError(pythFeed, err)
 		return fmt.Errorf("failed to fetch prices: %w", err)
 	}
+	// Symbols whose price failed to fetch are left out
+	if missing := len(symbolToFeedID) - len(prices); missing > 0 {
+		fetchErrors.WithLabelValues("pyth").Add(float64(missing))
+	}
 
 	// Display current prices and store snapshots
 	for symbol, priceData := range prices {
 		if err := priceData.Validate(); err != nil {
-			log.Printf("⚠️  Invalid price data for %s: %v", symbol, err)
+			slog.Warn("⚠️  Invalid price data", logger.FieldSymbol, symbol, logger.FieldError, err)
+			status.recordError(pythFeed+" "+symbol, err)
 			continue
 		}
-		log.Printf("💰 %s: $%g", symbol, priceData.Price)
+		slog.Info("💰 Price", logger.FieldSymbol, symbol, "price", priceData.Price)
+		status.recordPrice(symbol, priceData.Price)
 		if metricStore != nil {
 			if err := metricStore.InsertMetricSnapshot("token", symbol, symbol, "price", priceData.Price); err != nil {
-				log.Printf("⚠️  Failed to store price metric for %s: %v", symbol, err)
+				slog.Warn("⚠️  Failed to store price metric", logger.FieldSymbol, symbol, logger.FieldError, err)
 			}
 		}
 	}
 
 	// Evaluate alert rules
+	_, span := tracing.Start(ctx, "evaluate token")
 	decisions := decisionEngine.EvaluateAll(prices)
+	span.End()
+	for _, rule := range rules {
+		if _, ok := prices[rule.Symbol]; ok && rule.Enabled {
+			rulesEvaluated.WithLabelValues("token").Inc()
+		}
+	}
 
 	// Send alerts for triggered rules
 	for _, decision := range decisions {
 		if decision.ShouldAlert {
-			log.Printf("🚨 Alert triggered: %s", decision.Message)
-			if err := sender.SendAlert(decision.Rule.RecipientEmail, decision); err != nil {
-				log.Printf("❌ Failed to send alert to %s: %v", decision.Rule.RecipientEmail, err)
+			alertLog := slog.With(logger.FieldKind, "token", logger.FieldRuleID, decision.Rule.ID, logger.FieldSymbol, decision.CurrentPrice.Symbol)
+			alertLog.Info("🚨 Alert triggered", "message", decision.Message)
+			alertCtx, alertSpan := startAlert(ctx, "token", decision.Rule.ID)
+			decision.PriceHistory = metricHistory(metricStore, "token", decision.CurrentPrice.Symbol, "price", 24*time.Hour)
+			recipients := strings.Join(decision.Rule.RecipientEmails, ", ")
+			err := message.WithContext(sender, alertCtx).SendAlert(recipients, decision)
+			tracing.End(alertSpan, err)
+			if err != nil {
+				alertLog.Error("❌ Failed to send alert", "recipients", recipients, logger.FieldError, err)
 			} else {
-				log.Printf("✅ Alert published for %s to %s", decision.CurrentPrice.Symbol, decision.Rule.RecipientEmail)
+				alertLog.Info("✅ Alert published", "recipients", recipients)
 			}
 		}
 	}
@@ -220,20 +440,49 @@ func checkAndAlert(
 	return nil
 }
 
+// metricHistoryMaxPoints caps the recorded values sent with an alert for its chart
+const metricHistoryMaxPoints = 96
+
+// metricHistory returns the values recorded for a metric over period, thinned
+// out to at most metricHistoryMaxPoints, or nil when there is no metric store
+// or nothing recorded yet.
+func metricHistory(metricStore *store.MetricStore, metricType, identifier, field string, period time.Duration) []core.HistoryPoint {
+	if metricStore == nil {
+		return nil
+	}
+	points, err := metricStore.GetMetricHistory(metricType, identifier, field, time.Now().Add(-period))
+	if err != nil {
+		slog.Warn("⚠️  Failed to load metric history", "metric_type", metricType, "identifier", identifier, "field", field, logger.FieldError, err)
+		return nil
+	}
+
+	step := max(1, (len(points)+metricHistoryMaxPoints-1)/metricHistoryMaxPoints)
+	var history []core.HistoryPoint
+	for i := 0; i < len(points); i += step {
+		t, err := time.Parse(time.RFC3339, points[i].RecordedAt)
+		if err != nil {
+			continue
+		}
+		history = append(history, core.HistoryPoint{Time: t, Value: points[i].Value})
+	}
+	return history
+}
+
 // monitorDeFi continuously monitors DeFi protocols and triggers alerts
 func monitorDeFi(
 	ctx context.Context,
 	decisionEngine *core.DecisionEngine,
 	sender message.MessageSender,
 	metricStore *store.MetricStore,
+	status *feedStatus,
 	cfg *config.Config,
 ) {
 	ticker := time.NewTicker(time.Duration(cfg.CheckInterval) * time.Second)
 	defer ticker.Stop()
 
 	// Run immediately on startup
-	if err := checkAndAlertDeFi(ctx, decisionEngine, sender, metricStore); err != nil {
-		log.Printf("Error checking DeFi: %v", err)
+	if err := checkAndAlertDeFi(ctx, decisionEngine, sender, metricStore, status); err != nil {
+		slog.Error("Error checking DeFi", logger.FieldError, err)
 	}
 
 	for {
@@ -241,8 +490,8 @@ func monitorDeFi(
 		case <-ctx.Done():
 			return
 		case <-ticker.C:
-			if err := checkAndAlertDeFi(ctx, decisionEngine, sender, metricStore); err != nil {
-				log.Printf("Error checking DeFi: %v", err)
+			if err := checkAndAlertDeFi(ctx, decisionEngine, sender, metricStore, status); err != nil {
+				slog.Error("Error checking DeFi", logger.FieldError, err)
 			}
 		}
 	}
@@ -254,53 +503,69 @@ func checkAndAlertDeFi(
 	decisionEngine *core.DecisionEngine,
 	sender message.MessageSender,
 	metricStore *store.MetricStore,
+	status *feedStatus,
 ) error {
 	defiRules := decisionEngine.GetDeFiRules()
 	if len(defiRules) == 0 {
 		return nil
 	}
 
+	ctx, checked := startCheck(ctx, "defi")
+	defer checked()
 	clientManager := defi.NewClientManager()
 	defer clientManager.Close()
 
-	log.Printf("🔍 Checking DeFi protocols for %d rule(s)...", len(defiRules))
+	slog.Info("🔍 Checking DeFi protocols", "rules", len(defiRules))
 
 	for _, rule := range defiRules {
 		if !rule.Enabled {
 			continue
 		}
 
-		value, chainName, err := clientManager.GetFieldValue(ctx, rule)
+		fetchCtx, fetched := startFetch(ctx, rule.Protocol)
+		value, chainName, err := clientManager.GetFieldValue(fetchCtx, rule)
+		fetched(err)
 		if err != nil {
-			log.Printf("⚠️  %v", err)
+			slog.Warn("⚠️  Failed to read the DeFi value", logger.FieldRuleID, rule.ID, logger.FieldSymbol, rule.Protocol, logger.FieldError, err)
+			status.recordError(defiFeed(rule), err)
 			continue
 		}
 
 		categoryStr := defi.GetCategoryString(rule)
 		displayName := defi.GetDisplayName(rule)
-		log.Printf("💰 %s%s %s on %s - %s%s: %g", rule.Protocol, categoryStr, rule.Version, chainName, rule.Field, displayName, value)
+		slog.Info("💰 DeFi value", logger.FieldRuleID, rule.ID, logger.FieldSymbol, rule.Protocol, "category", rule.Category, "version", rule.Version,
+			logger.FieldChain, chainName, "market", strings.Trim(displayName, " ()"), "field", rule.Field, "value", value)
 
+		defiIdentifier := defiMetricIdentifier(rule)
+		label := fmt.Sprintf("%s%s %s%s on %s", rule.Protocol, categoryStr, rule.Version, displayName, chainName)
+		status.recordDeFi(rule, label, value)
 		if metricStore != nil {
-			rawID := defi.GetIdentifier(rule)
-			defiIdentifier := fmt.Sprintf("%s-%s-%s-%s", rule.Protocol, rule.Version, rule.ChainID, rawID)
-			label := fmt.Sprintf("%s%s %s%s on %s", rule.Protocol, categoryStr, rule.Version, displayName, chainName)
 			if err := metricStore.InsertMetricSnapshot("defi", defiIdentifier, label, rule.Field, value); err != nil {
-				log.Printf("⚠️  Failed to store DeFi metric: %v", err)
+				slog.Warn("⚠️  Failed to store DeFi metric", logger.FieldRuleID, rule.ID, logger.FieldError, err)
 			}
 		}
 
 		// Evaluate alert rules
 		identifier := defi.GetIdentifier(rule)
+		_, span := tracing.Start(ctx, "evaluate defi", tracing.AttrRuleID.Int64(rule.ID))
 		decisions := decisionEngine.EvaluateDeFi(rule.ChainID, identifier, rule.Field, value, chainName)
+		span.End()
+		rulesEvaluated.WithLabelValues("defi").Inc()
 
 		// Send alerts for triggered rules
 		for _, decision := range decisions {
 			if decision.ShouldAlert {
-				log.Printf("🚨 Alert triggered: %s", decision.Message)
-				if err := sender.SendDeFiAlert(decision.Rule.RecipientEmail, decision); err != nil {
-					log.Printf("❌ Failed to send DeFi alert to %s: %v", decision.Rule.RecipientEmail, err)
+				alertLog := slog.With(logger.FieldKind, "defi", logger.FieldRuleID, decision.Rule.ID, logger.FieldSymbol, decision.Rule.Protocol, logger.FieldChain, decision.ChainName)
+				alertLog.Info("🚨 Alert triggered", "message", decision.Message)
+				alertCtx, alertSpan := startAlert(ctx, "defi", decision.Rule.ID)
+				decision.ValueHistory = metricHistory(metricStore, "defi", defiIdentifier, rule.Field, 24*time.Hour)
+				recipients := strings.Join(decision.Rule.RecipientEmails, ", ")
+				err := message.WithContext(sender, alertCtx).SendDeFiAlert(recipients, decision)
+				tracing.End(alertSpan, err)
+				if err != nil {
+					alertLog.Error("❌ Failed to send DeFi alert", "recipients", recipients, logger.FieldError, err)
 				} else {
-					log.Printf("✅ DeFi alert published for %s %s to %s", decision.Rule.Protocol, decision.Rule.Field, decision.Rule.RecipientEmail)
+					alertLog.Info("✅ DeFi alert published", "field", decision.Rule.Field, "recipients", recipients)
 				}
 			}
 		}
@@ -310,41 +575,74 @@ func checkAndAlertDeFi(
 }
 
 // loadAlertRulesFromMySQL loads alert rules from MySQL (web3.alert_rule_token_config, web3.alert_rule_defi_config)
-func loadAlertRulesFromMySQL(engine *core.DecisionEngine, dsn string) error {
-	priceRules, defiRules, err := store.LoadAlertRulesFromMySQL(dsn)
+func loadAlertRulesFromMySQL(engine *core.DecisionEngine, rules store.RuleStore, resolver *ens.Resolver) error {
+	priceRules, defiRules, err := rules.LoadAlertRules()
 	if err != nil {
 		return err
 	}
+	defiRules = resolveDeFiRuleENS(resolver, defiRules)
 	return addAlertRulesToEngine(engine, priceRules, defiRules, "MySQL")
 }
 
 // loadPredictMarketRulesFromMySQL loads prediction market rules from MySQL and adds them to the engine
-func loadPredictMarketRulesFromMySQL(engine *core.DecisionEngine, dsn string) error {
-	rules, err := store.LoadPredictMarketRulesFromMySQL(dsn)
+func loadPredictMarketRulesFromMySQL(engine *core.DecisionEngine, ruleStore store.RuleStore, sources *prediction.Sources, gamma *polymarket.GammaClient) error {
+	rules, err := ruleStore.LoadPredictMarketRules()
 	if err != nil {
 		return err
 	}
+	rules = resolvePredictRuleTokens(sources, gamma, rules)
 	for _, rule := range rules {
 		engine.AddPredictMarketRule(rule)
 	}
-	log.Printf("✅ Loaded %d prediction market rule(s) from MySQL", len(rules))
+	slog.Info("✅ Loaded prediction market rules from MySQL", "rules", len(rules))
 	return nil
 }
 
-// monitorPredictMarkets continuously monitors prediction market prices and triggers alerts
+// loadWatchRulesFromMySQL loads watch rules from MySQL and adds them to the engine
+func loadWatchRulesFromMySQL(engine *core.DecisionEngine, ruleStore store.RuleStore, resolver *ens.Resolver) error {
+	rules, err := ruleStore.LoadWatchRules()
+	if err != nil {
+		return err
+	}
+	rules = resolveWatchRuleENS(resolver, rules)
+	for _, rule := range rules {
+		engine.AddWatchRule(rule)
+	}
+	slog.Info("✅ Loaded watch rules from MySQL", "rules", len(rules))
+	return nil
+}
+
+// monitorPredictMarkets continuously monitors prediction market prices and triggers alerts.
+// With the WebSocket stream enabled, Polymarket rules are also evaluated on every
+// book change for their token; the polling loop reads the live books and only
+// falls back to the REST API while the stream is disconnected.
 func monitorPredictMarkets(
 	ctx context.Context,
 	decisionEngine *core.DecisionEngine,
+	sources *prediction.Sources,
 	sender message.MessageSender,
 	metricStore *store.MetricStore,
+	status *feedStatus,
 	cfg *config.Config,
 ) {
 	ticker := time.NewTicker(time.Duration(cfg.CheckInterval) * time.Second)
 	defer ticker.Stop()
 
+	// Recent midpoints for MOVE rules
+	moves := prediction.NewMoveTracker()
+
+	var stream *polymarket.MarketStream
+	var updates <-chan string
+	if cfg.PolymarketWSEnabled {
+		stream = polymarket.NewMarketStream(cfg.PolymarketWSURL)
+		stream.SetTokens(predictTokenIDs(polymarketRules(decisionEngine.GetPredictMarketRules()), false))
+		updates = stream.Updates()
+		go stream.Run(ctx)
+	}
+
 	// Run immediately on startup
-	if err := checkAndAlertPredictMarkets(ctx, decisionEngine, sender, metricStore); err != nil {
-		log.Printf("Error checking prediction markets: %v", err)
+	if err := checkAndAlertPredictMarkets(ctx, decisionEngine, sources, sender, metricStore, status, stream, moves); err != nil {
+		slog.Error("Error checking prediction markets", logger.FieldError, err)
 	}
 
 	for {
@@ -352,78 +650,380 @@ func monitorPredictMarkets(
 		case <-ctx.Done():
 			return
 		case <-ticker.C:
-			if err := checkAndAlertPredictMarkets(ctx, decisionEngine, sender, metricStore); err != nil {
-				log.Printf("Error checking prediction markets: %v", err)
+			if stream != nil {
+				// Pick up rules added or removed by hot-reload
+				stream.SetTokens(predictTokenIDs(polymarketRules(decisionEngine.GetPredictMarketRules()), false))
+			}
+			if err := checkAndAlertPredictMarkets(ctx, decisionEngine, sources, sender, metricStore, status, stream, moves); err != nil {
+				slog.Error("Error checking prediction markets", logger.FieldError, err)
+			}
+		case tokenID := <-updates:
+			var rules []*core.PredictMarketAlertRule
+			for _, rule := range polymarketRules(decisionEngine.GetPredictMarketRules()) {
+				if slices.Contains(rule.Tokens(), tokenID) {
+					rules = append(rules, rule)
+				}
+			}
+			// Multi-outcome rules also need the books of their other tokens
+			if prices, books, ok := streamPredictMarkets(stream, predictTokenIDs(rules, false)); ok {
+				evaluatePredictMarketRules(ctx, decisionEngine, sender, metricStore, moves, rules, prices, books)
 			}
 		}
 	}
 }
 
-// checkAndAlertPredictMarkets fetches Polymarket prices and sends alerts if conditions are met
+// polymarketRules returns the rules whose venue is Polymarket (the only venue with a stream)
+func polymarketRules(rules []*core.PredictMarketAlertRule) []*core.PredictMarketAlertRule {
+	return predictRulesByVenue(rules)["polymarket"]
+}
+
+// predictRulesByVenue groups rules by lower-cased predict_market
+func predictRulesByVenue(rules []*core.PredictMarketAlertRule) map[string][]*core.PredictMarketAlertRule {
+	byVenue := make(map[string][]*core.PredictMarketAlertRule)
+	for _, rule := range rules {
+		venue := strings.ToLower(rule.PredictMarket)
+		byVenue[venue] = append(byVenue[venue], rule)
+	}
+	return byVenue
+}
+
+// predictTokenIDs returns the unique token IDs of enabled rules; with booksOnly
+// set, only tokens of SPREAD / DEPTH rules (which need the order book).
+func predictTokenIDs(rules []*core.PredictMarketAlertRule, booksOnly bool) []string {
+	set := make(map[string]struct{})
+	for _, rule := range rules {
+		if !rule.Enabled {
+			continue
+		}
+		switch rule.Field {
+		case core.PredictFieldSpread, core.PredictFieldDepth:
+		default:
+			if booksOnly {
+				continue
+			}
+		}
+		for _, id := range rule.Tokens() {
+			set[id] = struct{}{}
+		}
+	}
+	ids := make([]string, 0, len(set))
+	for id := range set {
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+// checkAndAlertPredictMarkets fetches prediction market prices from each rule's
+// venue and sends alerts if conditions are met
 func checkAndAlertPredictMarkets(
 	ctx context.Context,
 	decisionEngine *core.DecisionEngine,
+	sources *prediction.Sources,
 	sender message.MessageSender,
 	metricStore *store.MetricStore,
+	status *feedStatus,
+	stream *polymarket.MarketStream,
+	moves *prediction.MoveTracker,
 ) error {
 	rules := decisionEngine.GetPredictMarketRules()
 	if len(rules) == 0 {
 		return nil
 	}
 
-	// Collect unique token IDs across all enabled rules
-	tokenIDSet := make(map[string]struct{})
-	for _, rule := range rules {
-		if rule.Enabled {
-			tokenIDSet[rule.TokenID] = struct{}{}
+	ctx, checked := startCheck(ctx, "predict")
+	defer checked()
+	for venue, venueRules := range predictRulesByVenue(rules) {
+		source, ok := sources.Get(venue)
+		if !ok {
+			slog.Warn("⚠️  Unsupported prediction market, skipping its rules", logger.FieldSymbol, venue, "rules", len(venueRules))
+			continue
+		}
+		if err := checkPredictMarketVenue(ctx, decisionEngine, source, sender, metricStore, stream, moves, venueRules); err != nil {
+			slog.Error("Error checking prediction market", logger.FieldSymbol, source.Name(), logger.FieldError, err)
+			status.recordError(venue, err)
 		}
 	}
-	if len(tokenIDSet) == 0 {
+	return nil
+}
+
+// checkPredictMarketVenue fetches prices for one venue's rules, records them, and evaluates the rules
+func checkPredictMarketVenue(
+	ctx context.Context,
+	decisionEngine *core.DecisionEngine,
+	source prediction.Source,
+	sender message.MessageSender,
+	metricStore *store.MetricStore,
+	stream *polymarket.MarketStream,
+	moves *prediction.MoveTracker,
+	rules []*core.PredictMarketAlertRule,
+) error {
+	// Collect unique token IDs across all enabled rules
+	tokenIDs := predictTokenIDs(rules, false)
+	if len(tokenIDs) == 0 {
 		return nil
 	}
 
-	tokenIDs := make([]string, 0, len(tokenIDSet))
-	for id := range tokenIDSet {
-		tokenIDs = append(tokenIDs, id)
+	var prices map[string]*prediction.TokenPrices
+	var books map[string]*prediction.OrderBook
+	ok := false
+	if source.Name() == "polymarket" {
+		prices, books, ok = streamPredictMarkets(stream, tokenIDs)
 	}
+	if ok {
+		slog.Info("🔍 Checking prediction market prices from WebSocket books", logger.FieldSymbol, source.Name(), "tokens", len(tokenIDs))
+	} else {
+		slog.Info("🔍 Checking prediction market prices", logger.FieldSymbol, source.Name(), "tokens", len(tokenIDs))
 
-	log.Printf("🔍 Checking Polymarket prices for %d token(s)...", len(tokenIDs))
-
-	client := polymarket.NewClient()
-	prices, err := client.GetTokenPrices(ctx, tokenIDs)
-	if err != nil {
-		return fmt.Errorf("failed to fetch Polymarket prices: %w", err)
+		var err error
+		fetchCtx, fetched := startFetch(ctx, source.Name())
+		prices, err = source.GetTokenPrices(fetchCtx, tokenIDs)
+		fetched(err)
+		if err != nil {
+			return fmt.Errorf("failed to fetch %s prices: %w", source.Name(), err)
+		}
+		// Order books are only needed for SPREAD / DEPTH rules
+		books = source.GetOrderBooks(ctx, predictTokenIDs(rules, true))
 	}
 
-	// Evaluate each rule against its token's midpoint price
+	// Log and record each token's prices once
+	logged := make(map[string]bool, len(tokenIDs))
 	for _, rule := range rules {
-		if !rule.Enabled {
-			continue
-		}
 		tp, ok := prices[rule.TokenID]
-		if !ok {
-			log.Printf("⚠️  No price data for Polymarket token %s", rule.TokenID)
+		if !rule.Enabled || !ok || rule.TokenID == "" || logged[rule.TokenID] {
 			continue
 		}
+		logged[rule.TokenID] = true
 
-		log.Printf("💰 [%s] [%s] %s - midpoint=%.4f buy=%.4f sell=%.4f",
-			rule.PredictMarket, rule.Outcome, rule.Question, tp.Midpoint, tp.BuyPrice, tp.SellPrice)
+		slog.Info("💰 Prediction market prices", logger.FieldRuleID, rule.ID, logger.FieldSymbol, rule.PredictMarket, logger.FieldQuestion, rule.Question,
+			"outcome", rule.Outcome, "midpoint", tp.Midpoint, "buy", tp.BuyPrice, "sell", tp.SellPrice)
 
 		if metricStore != nil {
 			label := fmt.Sprintf("%s (%s)", rule.Question, rule.Outcome)
 			metricStore.InsertMetricSnapshot("predict", rule.TokenID, label, "MIDPOINT", tp.Midpoint)
 			metricStore.InsertMetricSnapshot("predict", rule.TokenID, label, "BUY", tp.BuyPrice)
 			metricStore.InsertMetricSnapshot("predict", rule.TokenID, label, "SELL", tp.SellPrice)
+			if book, ok := books[rule.TokenID]; ok {
+				metricStore.InsertMetricSnapshot("predict", rule.TokenID, label, "SPREAD", book.Spread())
+			}
+		}
+	}
+
+	evaluatePredictMarketRules(ctx, decisionEngine, sender, metricStore, moves, rules, prices, books)
+	return nil
+}
+
+// predictMarketHistory summarizes the midpoints recorded for a token over period,
+// or returns nil when there is no metric store or nothing recorded yet.
+func predictMarketHistory(metricStore *store.MetricStore, tokenID string, period time.Duration) *core.PredictMarketHistory {
+	if metricStore == nil || tokenID == "" {
+		return nil
+	}
+	points, err := metricStore.GetMetricHistory("predict", tokenID, "MIDPOINT", time.Now().Add(-period))
+	if err != nil {
+		slog.Warn("⚠️  Failed to load midpoint history", logger.FieldTokenID, tokenID, logger.FieldError, err)
+		return nil
+	}
+	if len(points) == 0 {
+		return nil
+	}
+	h := &core.PredictMarketHistory{
+		Period:  period,
+		Open:    points[0].Value,
+		Low:     points[0].Value,
+		High:    points[0].Value,
+		Samples: len(points),
+	}
+	for _, p := range points[1:] {
+		h.Low = min(h.Low, p.Value)
+		h.High = max(h.High, p.Value)
+	}
+	return h
+}
+
+// streamPredictMarkets returns prices and books for all tokens from the live
+// WebSocket books, or ok=false when the stream is off, disconnected, or hasn't
+// received a snapshot for every token yet.
+func streamPredictMarkets(stream *polymarket.MarketStream, tokenIDs []string) (map[string]*prediction.TokenPrices, map[string]*prediction.OrderBook, bool) {
+	if stream == nil || !stream.Connected() {
+		return nil, nil, false
+	}
+	prices := make(map[string]*prediction.TokenPrices, len(tokenIDs))
+	books := make(map[string]*prediction.OrderBook, len(tokenIDs))
+	for _, id := range tokenIDs {
+		book, ok := stream.Book(id)
+		if !ok {
+			return nil, nil, false
+		}
+		books[id] = book
+		prices[id] = book.Prices()
+	}
+	return prices, books, true
+}
+
+// evaluatePredictMarketRules evaluates each rule against the current value of its field
+func evaluatePredictMarketRules(
+	ctx context.Context,
+	decisionEngine *core.DecisionEngine,
+	sender message.MessageSender,
+	metricStore *store.MetricStore,
+	moves *prediction.MoveTracker,
+	rules []*core.PredictMarketAlertRule,
+	prices map[string]*prediction.TokenPrices,
+	books map[string]*prediction.OrderBook,
+) {
+	for _, rule := range rules {
+		if !rule.Enabled {
+			continue
+		}
+		tokens := rule.Tokens()
+		tp, ok := prices[tokens[0]]
+		if !ok {
+			slog.Warn("⚠️  No price data for Polymarket token", logger.FieldTokenID, tokens[0])
+			continue
+		}
+
+		value := tp.Midpoint
+		switch rule.Field {
+		case core.PredictFieldSpread, core.PredictFieldDepth:
+			book, ok := books[rule.TokenID]
+			if !ok {
+				slog.Warn("⚠️  No order book for Polymarket token", logger.FieldTokenID, rule.TokenID)
+				continue
+			}
+			if rule.Field == core.PredictFieldSpread {
+				value = book.Spread()
+			} else {
+				value = book.Depth(rule.DepthSide, rule.DepthPrice)
+			}
+		case core.PredictFieldMove:
+			window := rule.MoveWindow
+			if window <= 0 {
+				window = prediction.DefaultMoveWindow
+			}
+			key := fmt.Sprintf("%s:%s:%s", strings.ToLower(rule.PredictMarket), rule.TokenID, window)
+			value = math.Abs(moves.Record(key, tp.Midpoint, window)) * 100
+		case core.PredictFieldSum, core.PredictFieldDiff:
+			midpoints := make([]float64, 0, len(tokens))
+			for _, id := range tokens {
+				if p, ok := prices[id]; ok {
+					midpoints = append(midpoints, p.Midpoint)
+				}
+			}
+			if len(midpoints) != len(tokens) {
+				slog.Warn("⚠️  Missing price data for predict market rule, skipping", logger.FieldRuleID, rule.ID)
+				continue
+			}
+			if rule.Field == core.PredictFieldDiff {
+				if len(midpoints) != 2 {
+					slog.Warn("⚠️  Predict market DIFF rule doesn't have 2 outcomes, skipping", logger.FieldRuleID, rule.ID, "outcomes", len(midpoints))
+					continue
+				}
+				value = midpoints[0] - midpoints[1]
+				break
+			}
+			value = 0
+			for _, m := range midpoints {
+				value += m
+			}
+		}
+
+		_, span := tracing.Start(ctx, "evaluate predict", tracing.AttrRuleID.Int64(rule.ID))
+		decision := decisionEngine.EvaluatePredictMarketRule(rule, value, tp.Midpoint, tp.BuyPrice, tp.SellPrice)
+		span.End()
+		rulesEvaluated.WithLabelValues("predict").Inc()
+		if decision != nil && decision.ShouldAlert {
+			alertLog := slog.With(logger.FieldKind, "predict", logger.FieldRuleID, decision.Rule.ID, logger.FieldSymbol, decision.Rule.PredictMarket, logger.FieldQuestion, decision.Rule.Question)
+			alertLog.Info("🚨 Alert triggered", "message", decision.Message)
+			alertCtx, alertSpan := startAlert(ctx, "predict", decision.Rule.ID)
+			decision.History = predictMarketHistory(metricStore, rule.TokenID, 24*time.Hour)
+			recipients := strings.Join(decision.Rule.RecipientEmails, ", ")
+			err := message.WithContext(sender, alertCtx).SendPredictMarketAlert(recipients, decision)
+			tracing.End(alertSpan, err)
+			if err != nil {
+				alertLog.Error("❌ Failed to send predict market alert", "recipients", recipients, logger.FieldError, err)
+			} else {
+				alertLog.Info("✅ Predict market alert published", "recipients", recipients)
+			}
+		}
+	}
+}
+
+// monitorWatch continuously polls watch sources (Safe multisig, ...) and triggers alerts
+func monitorWatch(
+	ctx context.Context,
+	manager *watch.Manager,
+	decisionEngine *core.DecisionEngine,
+	sender message.MessageSender,
+	status *feedStatus,
+	cfg *config.Config,
+) {
+	ticker := time.NewTicker(time.Duration(cfg.CheckInterval) * time.Second)
+	defer ticker.Stop()
+
+	// Run immediately on startup
+	if err := checkAndAlertWatch(ctx, manager, decisionEngine, sender, status); err != nil {
+		slog.Error("Error checking watch sources", logger.FieldError, err)
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := checkAndAlertWatch(ctx, manager, decisionEngine, sender, status); err != nil {
+				slog.Error("Error checking watch sources", logger.FieldError, err)
+			}
+		}
+	}
+}
+
+// checkAndAlertWatch collects observations for each watch rule and sends alerts for new events
+func checkAndAlertWatch(
+	ctx context.Context,
+	manager *watch.Manager,
+	decisionEngine *core.DecisionEngine,
+	sender message.MessageSender,
+	status *feedStatus,
+) error {
+	rules := decisionEngine.GetWatchRules()
+	if len(rules) == 0 {
+		return nil
+	}
+
+	ctx, checked := startCheck(ctx, "watch")
+	defer checked()
+	slog.Info("🔍 Checking watch sources", "rules", len(rules))
+
+	for _, rule := range rules {
+		if !rule.Enabled {
+			continue
 		}
 
-		decisions := decisionEngine.EvaluatePredictMarket(rule.TokenID, tp.Midpoint, tp.BuyPrice, tp.SellPrice)
+		fetchCtx, fetched := startFetch(ctx, rule.Source)
+		observations, chainName, err := manager.Observe(fetchCtx, rule)
+		fetched(err)
+		if err != nil {
+			slog.Warn("⚠️  Failed to observe the watch source", logger.FieldRuleID, rule.ID, logger.FieldSymbol, rule.Source, logger.FieldError, err)
+			status.recordError(watchFeed(rule), err)
+			continue
+		}
+
+		_, span := tracing.Start(ctx, "evaluate watch", tracing.AttrRuleID.Int64(rule.ID))
+		decisions := decisionEngine.EvaluateWatch(rule, observations, chainName)
+		span.End()
+		rulesEvaluated.WithLabelValues("watch").Inc()
 		for _, decision := range decisions {
 			if decision.ShouldAlert {
-				log.Printf("🚨 Alert triggered: %s", decision.Message)
-				if err := sender.SendPredictMarketAlert(decision.Rule.RecipientEmail, decision); err != nil {
-					log.Printf("❌ Failed to send predict market alert to %s: %v", decision.Rule.RecipientEmail, err)
+				alertLog := slog.With(logger.FieldKind, "watch", logger.FieldRuleID, decision.Rule.ID, logger.FieldSymbol, decision.Rule.Source, logger.FieldChain, decision.ChainName)
+				alertLog.Info("🚨 Alert triggered", "message", decision.Message)
+				alertCtx, alertSpan := startAlert(ctx, "watch", decision.Rule.ID)
+				recipients := strings.Join(decision.Rule.RecipientEmails, ", ")
+				err := message.WithContext(sender, alertCtx).SendWatchAlert(recipients, decision)
+				tracing.End(alertSpan, err)
+				if err != nil {
+					alertLog.Error("❌ Failed to send watch alert", "recipients", recipients, logger.FieldError, err)
 				} else {
-					log.Printf("✅ Predict market alert published for %s to %s", decision.Rule.Question, decision.Rule.RecipientEmail)
+					alertLog.Info("✅ Watch alert published", "field", decision.Rule.Field, "recipients", recipients)
 				}
 			}
 		}
@@ -434,33 +1034,298 @@ func checkAndAlertPredictMarkets(
 
 // reloadRulesLoop periodically fetches all rules from MySQL and hot-swaps them
 // into the engine, preserving LastTriggered so frequency suppression survives.
-func reloadRulesLoop(ctx context.Context, engine *core.DecisionEngine, cfg *config.Config) {
+// A checksum of the rule tables is polled first, and the rules are only read
+// again when a row was added, removed or updated, or once the ENS cache TTL
+// has passed so renamed ENS names are picked up.
+func reloadRulesLoop(ctx context.Context, engine *core.DecisionEngine, rules store.RuleStore, cfg *config.Config, resolver *ens.Resolver, sources *prediction.Sources, gamma *polymarket.GammaClient) {
 	ticker := time.NewTicker(time.Duration(cfg.RuleReloadInterval) * time.Second)
 	defer ticker.Stop()
+	version, err := rules.RulesVersion()
+	if err != nil {
+		slog.Warn("⚠️  Hot-reload: can't tell when rules change, reading them on every reload", logger.FieldError, err)
+	}
+	lastReload := time.Now()
 	for {
 		select {
 		case <-ctx.Done():
 			return
 		case <-ticker.C:
-			reloadRules(engine, cfg)
+			current, err := rules.RulesVersion()
+			if err == nil && current == version && time.Since(lastReload) < time.Duration(cfg.ENSCacheTTL)*time.Second {
+				continue
+			}
+			if reloadRules(engine, rules, resolver, sources, gamma) {
+				version, lastReload = current, time.Now()
+			}
 		}
 	}
 }
 
-func reloadRules(engine *core.DecisionEngine, cfg *config.Config) {
-	priceRules, defiRules, err := store.LoadAlertRulesFromMySQL(cfg.MySQLDSN)
+// reloadRules reads all rules again and swaps them into the engine. It
+// reports whether they were read.
+func reloadRules(engine *core.DecisionEngine, rules store.RuleStore, resolver *ens.Resolver, sources *prediction.Sources, gamma *polymarket.GammaClient) bool {
+	before := ruleIDs(engine)
+	priceRules, defiRules, err := rules.LoadAlertRules()
 	if err != nil {
-		log.Printf("⚠️  Hot-reload: failed to load token/DeFi rules: %v", err)
-		return
+		slog.Warn("⚠️  Hot-reload: failed to load token/DeFi rules", logger.FieldError, err)
+		return false
 	}
-	predictRules, err := store.LoadPredictMarketRulesFromMySQL(cfg.MySQLDSN)
+	predictRules, err := rules.LoadPredictMarketRules()
 	if err != nil {
-		log.Printf("⚠️  Hot-reload: failed to load predict market rules: %v", err)
-		return
+		slog.Warn("⚠️  Hot-reload: failed to load predict market rules", logger.FieldError, err)
+		return false
+	}
+	watchRules, err := rules.LoadWatchRules()
+	if err != nil {
+		slog.Warn("⚠️  Hot-reload: failed to load watch rules", logger.FieldError, err)
+		return false
+	}
+	defiRules = resolveDeFiRuleENS(resolver, defiRules)
+	watchRules = resolveWatchRuleENS(resolver, watchRules)
+	predictRules = resolvePredictRuleTokens(sources, gamma, predictRules)
+	engine.ReplaceRules(priceRules, defiRules, predictRules, watchRules)
+	after := ruleIDs(engine)
+	var added, removed int
+	for id := range after {
+		if !before[id] {
+			added++
+		}
+	}
+	for id := range before {
+		if !after[id] {
+			removed++
+		}
+	}
+	slog.Info("🔄 Hot-reload: rules reloaded", "price_rules", len(priceRules), "defi_rules", len(defiRules), "predict_rules", len(predictRules),
+		"watch_rules", len(watchRules), "added", added, "removed", removed)
+	recordRuleChanges(rules)
+	return true
+}
+
+// recordRuleChanges records the rules changed in the database, e.g. in the
+// web UI, in the rule change audit log
+func recordRuleChanges(rules store.RuleStore) {
+	n, err := rules.RecordChanges()
+	if err != nil {
+		slog.Warn("⚠️  Failed to record rule changes", logger.FieldError, err)
+	} else if n > 0 {
+		slog.Info("📝 Rule changes recorded", "changes", n)
+	}
+}
+
+// ruleIDs returns the engine's rules as kind:id keys
+func ruleIDs(engine *core.DecisionEngine) map[string]bool {
+	ids := map[string]bool{}
+	for _, r := range engine.GetRules() {
+		ids[fmt.Sprintf("token:%d", r.ID)] = true
+	}
+	for _, r := range engine.GetDeFiRules() {
+		ids[fmt.Sprintf("defi:%d", r.ID)] = true
+	}
+	for _, r := range engine.GetPredictMarketRules() {
+		ids[fmt.Sprintf("predict:%d", r.ID)] = true
+	}
+	for _, r := range engine.GetWatchRules() {
+		ids[fmt.Sprintf("watch:%d", r.ID)] = true
+	}
+	return ids
+}
+
+// resolveDeFiRuleENS replaces ENS names in DeFi rule address params with their
+// addresses. Names are re-resolved on each reload once the resolver cache expires.
+// Rules whose names cannot be resolved are skipped.
+func resolveDeFiRuleENS(resolver *ens.Resolver, rules []*core.DeFiAlertRule) []*core.DeFiAlertRule {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	resolved := make([]*core.DeFiAlertRule, 0, len(rules))
+	for _, r := range rules {
+		fields := []*string{
+			&r.MarketTokenContract, &r.BorrowTokenContract, &r.CollateralTokenContract,
+			&r.OracleAddress, &r.IRMAddress, &r.MarketContractAddress,
+			&r.VaultTokenAddress, &r.DepositTokenContract, &r.LedgerAddress,
+		}
+		if !ens.HasName(fields...) {
+			resolved = append(resolved, r)
+			continue
+		}
+		if resolver == nil {
+			slog.Warn("⚠️  Skipping DeFi rule: uses ENS names but ENS resolution is disabled", logger.FieldKind, "defi", logger.FieldRuleID, r.ID)
+			continue
+		}
+		names := make(map[string]string)
+		if err := resolver.ResolveFields(ctx, names, fields...); err != nil {
+			slog.Warn("⚠️  Skipping DeFi rule", logger.FieldKind, "defi", logger.FieldRuleID, r.ID, logger.FieldError, err)
+			continue
+		}
+		r.ENSNames = names
+		resolved = append(resolved, r)
 	}
-	engine.ReplaceRules(priceRules, defiRules, predictRules)
-	log.Printf("🔄 Hot-reload: %d price, %d DeFi, %d predict market rule(s) active",
-		len(priceRules), len(defiRules), len(predictRules))
+	return resolved
+}
+
+// resolvePredictRuleTokens fills in the token ID and market details of prediction
+// rules configured by market slug or condition ID: Polymarket rules via the
+// Gamma API, other venues via their TokenResolver.
+// Rules whose market or outcome cannot be resolved are skipped.
+func resolvePredictRuleTokens(sources *prediction.Sources, gamma *polymarket.GammaClient, rules []*core.PredictMarketAlertRule) []*core.PredictMarketAlertRule {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	resolved := make([]*core.PredictMarketAlertRule, 0, len(rules))
+	for _, r := range rules {
+		if !strings.EqualFold(r.PredictMarket, "polymarket") {
+			// Only Polymarket resolves the outcomes of a multi-outcome rule
+			if (r.Field == core.PredictFieldSum || r.Field == core.PredictFieldDiff) && len(r.TokenIDs) < 2 {
+				slog.Warn("⚠️  Skipping predict market rule: rules of this market and field need token_ids", logger.FieldRuleID, r.ID, logger.FieldSymbol, r.PredictMarket, "field", r.Field)
+				continue
+			}
+			if r.TokenID == "" && len(r.TokenIDs) == 0 {
+				source, _ := sources.Get(r.PredictMarket)
+				tr, ok := source.(prediction.TokenResolver)
+				if !ok {
+					slog.Warn("⚠️  Skipping predict market rule: rules of this market need token_id", logger.FieldRuleID, r.ID, logger.FieldSymbol, r.PredictMarket)
+					continue
+				}
+				tokenID, question, err := tr.ResolveToken(ctx, r.MarketSlug, r.Outcome)
+				if err != nil {
+					slog.Warn("⚠️  Skipping predict market rule", logger.FieldRuleID, r.ID, logger.FieldError, err)
+					continue
+				}
+				r.TokenID = tokenID
+				if r.Outcome == "" {
+					r.Outcome = "YES"
+				}
+				if r.Question == "" {
+					r.Question = question
+				}
+			}
+			resolved = append(resolved, r)
+			continue
+		}
+		if r.Field == core.PredictFieldSum || r.Field == core.PredictFieldDiff {
+			if err := resolveMultiOutcomeTokens(ctx, gamma, r); err != nil {
+				slog.Warn("⚠️  Skipping predict market rule", logger.FieldRuleID, r.ID, logger.FieldError, err)
+				continue
+			}
+			resolved = append(resolved, r)
+			continue
+		}
+		if r.TokenID != "" {
+			resolved = append(resolved, r)
+			continue
+		}
+		market, err := gamma.FindMarket(ctx, r.MarketSlug, r.ConditionID, r.GroupItem)
+		if err != nil {
+			slog.Warn("⚠️  Skipping predict market rule", logger.FieldRuleID, r.ID, logger.FieldError, err)
+			continue
+		}
+		if r.Outcome == "" {
+			r.Outcome = "YES"
+		}
+		tokenID, err := market.TokenID(r.Outcome)
+		if err != nil {
+			slog.Warn("⚠️  Skipping predict market rule", logger.FieldRuleID, r.ID, logger.FieldError, err)
+			continue
+		}
+		if market.Closed {
+			slog.Warn("⚠️  Predict market rule's market is closed", logger.FieldRuleID, r.ID, "market", market.Slug)
+		}
+		r.TokenID = tokenID
+		r.ConditionID = market.ConditionID
+		r.QuestionID = market.QuestionID
+		r.NegRisk = market.NegRisk
+		if r.Question == "" {
+			r.Question = market.Question
+		}
+		resolved = append(resolved, r)
+	}
+	return resolved
+}
+
+// resolveMultiOutcomeTokens fills TokenIDs of a SUM / DIFF rule from its event:
+// one token per group item, or for SUM without group items every open market.
+func resolveMultiOutcomeTokens(ctx context.Context, gamma *polymarket.GammaClient, r *core.PredictMarketAlertRule) error {
+	if len(r.TokenIDs) > 0 {
+		return nil
+	}
+	markets, err := gamma.SlugMarkets(ctx, r.MarketSlug)
+	if err != nil {
+		return err
+	}
+	if len(r.GroupItems) > 0 {
+		selected := make([]*polymarket.Market, 0, len(r.GroupItems))
+		for _, item := range r.GroupItems {
+			m, err := polymarket.SelectMarket(markets, item, r.MarketSlug)
+			if err != nil {
+				return err
+			}
+			selected = append(selected, m)
+		}
+		markets = selected
+	} else {
+		open := make([]*polymarket.Market, 0, len(markets))
+		for _, m := range markets {
+			if !m.Closed {
+				open = append(open, m)
+				r.GroupItems = append(r.GroupItems, m.GroupItemTitle)
+			}
+		}
+		markets = open
+	}
+	if len(markets) < 2 {
+		return fmt.Errorf("%s has %d open market(s), a multi-outcome rule needs at least 2", r.MarketSlug, len(markets))
+	}
+
+	if r.Outcome == "" {
+		r.Outcome = "YES"
+	}
+	for _, m := range markets {
+		tokenID, err := m.TokenID(r.Outcome)
+		if err != nil {
+			return err
+		}
+		r.TokenIDs = append(r.TokenIDs, tokenID)
+	}
+	r.NegRisk = markets[0].NegRisk
+	if r.Question == "" {
+		r.Question = r.MarketSlug
+	}
+	return nil
+}
+
+// resolveWatchRuleENS replaces ENS names in watch rule address params with their addresses.
+func resolveWatchRuleENS(resolver *ens.Resolver, rules []*core.WatchAlertRule) []*core.WatchAlertRule {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	resolved := make([]*core.WatchAlertRule, 0, len(rules))
+	for _, r := range rules {
+		p := &r.Params
+		fields := []*string{&p.SafeAddress, &p.WalletAddress, &p.GovernorAddress, &p.ChainlinkFeed}
+		for i := range p.BridgeAddresses {
+			fields = append(fields, &p.BridgeAddresses[i])
+		}
+		for i := range p.TokenAddresses {
+			fields = append(fields, &p.TokenAddresses[i])
+		}
+		if !ens.HasName(fields...) {
+			resolved = append(resolved, r)
+			continue
+		}
+		if resolver == nil {
+			slog.Warn("⚠️  Skipping watch rule: uses ENS names but ENS resolution is disabled", logger.FieldKind, "watch", logger.FieldRuleID, r.ID)
+			continue
+		}
+		names := make(map[string]string)
+		if err := resolver.ResolveFields(ctx, names, fields...); err != nil {
+			slog.Warn("⚠️  Skipping watch rule", logger.FieldKind, "watch", logger.FieldRuleID, r.ID, logger.FieldError, err)
+			continue
+		}
+		r.ENSNames = names
+		resolved = append(resolved, r)
+	}
+	return resolved
 }
 
 func addAlertRulesToEngine(engine *core.DecisionEngine, priceRules []*core.AlertRule, defiRules []*core.DeFiAlertRule, source string) error {
@@ -471,9 +1336,269 @@ func addAlertRulesToEngine(engine *core.DecisionEngine, priceRules []*core.Alert
 		engine.AddDeFiRule(rule)
 	}
 	totalRules := len(priceRules) + len(defiRules)
-	log.Printf("✅ Loaded %d price rule(s) and %d DeFi rule(s) from %s", len(priceRules), len(defiRules), source)
+	slog.Info("✅ Loaded price and DeFi rules", "price_rules", len(priceRules), "defi_rules", len(defiRules), "source", source)
 	if totalRules == 0 {
 		return fmt.Errorf("no alert rules found in %s", source)
 	}
 	return nil
 }
+
+// summaryPeriod is the period a daily summary covers
+const summaryPeriod = 24 * time.Hour
+
+// feedStatus keeps what the daily summary reports about the feeds: the latest
+// price of each symbol, the latest value of each DeFi field and the errors of
+// each feed over the last summary period.
+type feedStatus struct {
+	mu     sync.Mutex
+	prices map[string]message.SummaryValue // By symbol
+	defi   map[string]message.SummaryValue // By defiFieldKey
+	errors map[string]*feedErrors          // By feed name
+}
+
+// feedErrors are the recent errors of one feed
+type feedErrors struct {
+	times []time.Time
+	last  string
+}
+
+// newFeedStatus creates an empty feed status
+func newFeedStatus() *feedStatus {
+	return &feedStatus{
+		prices: map[string]message.SummaryValue{},
+		defi:   map[string]message.SummaryValue{},
+		errors: map[string]*feedErrors{},
+	}
+}
+
+// recordPrice records the latest price of symbol
+func (s *feedStatus) recordPrice(symbol string, value float64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.prices[symbol] = message.SummaryValue{Label: symbol, Value: value, Time: time.Now()}
+}
+
+// recordDeFi records the latest value of a DeFi rule's field
+func (s *feedStatus) recordDeFi(rule *core.DeFiAlertRule, label string, value float64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.defi[defiFieldKey(rule)] = message.SummaryValue{Label: label + " " + rule.Field, Value: value, Time: time.Now()}
+}
+
+// recordError records a failed read of feed
+func (s *feedStatus) recordError(feed string, err error) {
+	now := time.Now()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e := s.errors[feed]
+	if e == nil {
+		e = &feedErrors{}
+		s.errors[feed] = e
+	}
+	e.times = append(pruneBefore(e.times, now.Add(-summaryPeriod)), now)
+	e.last = err.Error()
+}
+
+// price returns the latest price of symbol
+func (s *feedStatus) price(symbol string) (message.SummaryValue, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	v, ok := s.prices[symbol]
+	return v, ok
+}
+
+// defiValue returns the latest value of a DeFi rule's field
+func (s *feedStatus) defiValue(rule *core.DeFiAlertRule) (message.SummaryValue, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	v, ok := s.defi[defiFieldKey(rule)]
+	return v, ok
+}
+
+// feedError returns the errors of feed since the given time, and false when
+// it had none
+func (s *feedStatus) feedError(feed string, since time.Time) (message.SummaryFeedError, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e := s.errors[feed]
+	if e == nil {
+		return message.SummaryFeedError{}, false
+	}
+	e.times = pruneBefore(e.times, since)
+	if len(e.times) == 0 {
+		delete(s.errors, feed)
+		return message.SummaryFeedError{}, false
+	}
+	return message.SummaryFeedError{Feed: feed, Count: len(e.times), LastError: e.last, LastAt: e.times[len(e.times)-1]}, true
+}
+
+// pruneBefore drops the times before t from the sorted times
+func pruneBefore(times []time.Time, t time.Time) []time.Time {
+	i := 0
+	for i < len(times) && times[i].Before(t) {
+		i++
+	}
+	return times[i:]
+}
+
+// Feed names used in the daily summary
+const pythFeed = "Pyth"
+
+// defiMetricIdentifier returns the identifier a DeFi rule's values are recorded under
+func defiMetricIdentifier(rule *core.DeFiAlertRule) string {
+	return fmt.Sprintf("%s-%s-%s-%s", rule.Protocol, rule.Version, rule.ChainID, defi.GetIdentifier(rule))
+}
+
+// defiFieldKey identifies the value a DeFi rule reads
+func defiFieldKey(rule *core.DeFiAlertRule) string {
+	return defiMetricIdentifier(rule) + "/" + rule.Field
+}
+
+// defiFeed returns the feed name of a DeFi rule
+func defiFeed(rule *core.DeFiAlertRule) string {
+	return fmt.Sprintf("%s%s %s%s (chain %s)", rule.Protocol, defi.GetCategoryString(rule), rule.Version, defi.GetDisplayName(rule), rule.ChainID)
+}
+
+// watchFeed returns the feed name of a watch rule
+func watchFeed(rule *core.WatchAlertRule) string {
+	if rule.Label != "" {
+		return rule.Source + " " + rule.Label
+	}
+	return fmt.Sprintf("%s (watch rule %d)", rule.Source, rule.ID)
+}
+
+// sendDailySummaries publishes each recipient's daily summary every day at
+// hour (UTC)
+func sendDailySummaries(ctx context.Context, engine *core.DecisionEngine, status *feedStatus, publisher *message.AlertPublisher, hour int) {
+	for {
+		now := time.Now().UTC()
+		next := time.Date(now.Year(), now.Month(), now.Day(), hour, 0, 0, 0, time.UTC)
+		if !next.After(now) {
+			next = next.AddDate(0, 0, 1)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(time.Until(next)):
+		}
+
+		summaries := buildDailySummaries(engine, status, time.Now())
+		for _, summary := range summaries {
+			if err := publisher.PublishSummary(summary); err != nil {
+				slog.Error("❌ Failed to publish daily summary", "to", summary.RecipientEmail, logger.FieldError, err)
+			}
+		}
+		slog.Info("📋 Daily summaries published", "recipients", len(summaries))
+	}
+}
+
+// dailySummary collects one recipient's summary, skipping entries it already has
+type dailySummary struct {
+	event message.SummaryEvent
+	seen  map[string]bool
+}
+
+// add reports whether key is new to the summary and marks it seen
+func (d *dailySummary) add(key string) bool {
+	if d.seen[key] {
+		return false
+	}
+	d.seen[key] = true
+	return true
+}
+
+// addFeedError adds the errors of feed, if it had any
+func (d *dailySummary) addFeedError(status *feedStatus, feed string) {
+	if !d.add("feed/" + feed) {
+		return
+	}
+	if e, ok := status.feedError(feed, d.event.Since); ok {
+		d.event.FeedErrors = append(d.event.FeedErrors, e)
+	}
+}
+
+// addFired adds a rule that fired since the start of the summary
+func (d *dailySummary) addFired(kind string, id int64, label string, lastTriggered *time.Time) {
+	if lastTriggered == nil || lastTriggered.Before(d.event.Since) || !d.add(fmt.Sprintf("rule/%s/%d/%s", kind, id, label)) {
+		return
+	}
+	d.event.Fired = append(d.event.Fired, message.SummaryRule{Kind: kind, RuleID: id, Label: label, LastTriggered: *lastTriggered})
+}
+
+// buildDailySummaries returns the daily summary of every email recipient of
+// the enabled rules, covering the summary period up to now
+func buildDailySummaries(engine *core.DecisionEngine, status *feedStatus, now time.Time) []message.SummaryEvent {
+	summaries := map[string]*dailySummary{}
+	recipients := func(emails, channels []string) []*dailySummary {
+		if len(channels) > 0 && !slices.Contains(channels, message.ChannelEmail) {
+			return nil
+		}
+		var out []*dailySummary
+		for _, to := range emails {
+			d := summaries[to]
+			if d == nil {
+				d = &dailySummary{
+					event: message.SummaryEvent{RecipientEmail: to, Since: now.Add(-summaryPeriod), Until: now},
+					seen:  map[string]bool{},
+				}
+				summaries[to] = d
+			}
+			out = append(out, d)
+		}
+		return out
+	}
+
+	for _, r := range engine.GetRules() {
+		if !r.Enabled {
+			continue
+		}
+		for _, d := range recipients(r.RecipientEmails, r.Channels) {
+			if v, ok := status.price(r.Symbol); ok && d.add("price/"+r.Symbol) {
+				d.event.Prices = append(d.event.Prices, v)
+			}
+			d.addFired("token", r.ID, fmt.Sprintf("%s %s %g", r.Symbol, r.Direction, r.Threshold), r.LastTriggered)
+			d.addFeedError(status, pythFeed)
+			d.addFeedError(status, pythFeed+" "+r.Symbol)
+		}
+	}
+	for _, r := range engine.GetDeFiRules() {
+		if !r.Enabled {
+			continue
+		}
+		for _, d := range recipients(r.RecipientEmails, r.Channels) {
+			if v, ok := status.defiValue(r); ok && d.add("defi/"+defiFieldKey(r)) {
+				d.event.DeFiValues = append(d.event.DeFiValues, v)
+			}
+			d.addFired("defi", r.ID, fmt.Sprintf("%s %s %s %s %g", r.Protocol, r.Version, r.Field, r.Direction, r.Threshold), r.LastTriggered)
+			d.addFeedError(status, defiFeed(r))
+		}
+	}
+	for _, r := range engine.GetPredictMarketRules() {
+		if !r.Enabled {
+			continue
+		}
+		for _, d := range recipients(r.RecipientEmails, r.Channels) {
+			d.addFired("predict", r.ID, fmt.Sprintf("%s (%s) %s %s %g", r.Question, r.Outcome, r.Field, r.Direction, r.Threshold), r.LastTriggered)
+			d.addFeedError(status, r.PredictMarket)
+		}
+	}
+	for _, r := range engine.GetWatchRules() {
+		if !r.Enabled {
+			continue
+		}
+		for _, d := range recipients(r.RecipientEmails, r.Channels) {
+			d.addFired("watch", r.ID, watchFeed(r)+" "+r.Field, r.LastTriggered)
+			d.addFeedError(status, watchFeed(r))
+		}
+	}
+
+	events := make([]message.SummaryEvent, 0, len(summaries))
+	for _, d := range summaries {
+		events = append(events, d.event)
+	}
+	slices.SortFunc(events, func(a, b message.SummaryEvent) int {
+		return strings.Compare(a.RecipientEmail, b.RecipientEmail)
+	})
+	return events
+}
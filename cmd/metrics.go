@@ -0,0 +1,95 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"crypto-alert/internal/logger"
+	"crypto-alert/internal/tracing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var (
+	checkDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "engine_check_duration_seconds",
+		Help:    "Duration of a check cycle, by rule kind (token, defi, predict or watch).",
+		Buckets: []float64{0.1, 0.25, 0.5, 1, 2.5, 5, 10, 20, 30, 60},
+	}, []string{"kind"})
+	fetchDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "engine_fetch_duration_seconds",
+		Help:    "Duration of a fetch from a data source, by source (pyth, a DeFi protocol, a prediction market or a watch source).",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"source"})
+	fetchErrors = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "engine_fetch_errors_total",
+		Help: "Failed fetches from a data source, by source.",
+	}, []string{"source"})
+	rulesEvaluated = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "engine_rules_evaluated_total",
+		Help: "Rules evaluated against a fetched value, by rule kind.",
+	}, []string{"kind"})
+	alertsTriggered = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "engine_alerts_triggered_total",
+		Help: "Alerts triggered, by rule kind.",
+	}, []string{"kind"})
+)
+
+// startCheck starts the span of a check cycle of kind. The returned function
+// ends it and records its duration.
+func startCheck(ctx context.Context, kind string) (context.Context, func()) {
+	start := time.Now()
+	ctx, span := tracing.Start(ctx, "check "+kind, tracing.AttrKind.String(kind))
+	return ctx, func() {
+		span.End()
+		checkDuration.WithLabelValues(kind).Observe(time.Since(start).Seconds())
+	}
+}
+
+// startFetch starts the span of a fetch from source. The returned function
+// ends it with the fetch's error and records its duration and error.
+func startFetch(ctx context.Context, source string) (context.Context, func(error)) {
+	source = strings.ToLower(source)
+	start := time.Now()
+	ctx, span := tracing.Start(ctx, "fetch "+source, tracing.AttrSource.String(source))
+	return ctx, func(err error) {
+		tracing.End(span, err)
+		fetchDuration.WithLabelValues(source).Observe(time.Since(start).Seconds())
+		if err != nil {
+			fetchErrors.WithLabelValues(source).Inc()
+		}
+	}
+}
+
+// startAlert counts a triggered alert of kind and starts its span, which the
+// alert's event is published in
+func startAlert(ctx context.Context, kind string, ruleID int64) (context.Context, trace.Span) {
+	alertsTriggered.WithLabelValues(kind).Inc()
+	return tracing.Start(ctx, "alert "+kind, tracing.AttrKind.String(kind), tracing.AttrRuleID.Int64(ruleID))
+}
+
+// serveMetrics serves the Prometheus metrics of the engine on addr until ctx
+// is done. With in-process notifications, they include the notification
+// service's.
+func serveMetrics(ctx context.Context, addr string) {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.Handler())
+	srv := &http.Server{Addr: addr, Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = srv.Shutdown(shutdownCtx)
+	}()
+	slog.Info("📈 Metrics listening", "addr", addr)
+	if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		slog.Warn("⚠️  Metrics endpoint stopped", logger.FieldError, err)
+	}
+}
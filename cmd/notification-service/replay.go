@@ -0,0 +1,47 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"crypto-alert/internal/logger"
+	"crypto-alert/internal/message"
+	"crypto-alert/internal/notify"
+)
+
+// replay runs `notification-service replay`, which re-sends, or with
+// --dry-run prints, the alert events published on a Kafka topic since a time
+func replay(args []string) {
+	fs := flag.NewFlagSet("replay", flag.ExitOnError)
+	topic := fs.String("topic", "", "alert topic to replay, e.g. "+message.TopicTokenAlert)
+	fromFlag := fs.String("from", "", "replay the events published since this time (RFC3339, e.g. 2026-01-02T15:04:05Z)")
+	dryRun := fs.Bool("dry-run", false, "print the events and their destinations instead of sending them")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: notification-service replay --topic alerts.token --from <RFC3339> [--dry-run]")
+		fs.PrintDefaults()
+	}
+	_ = fs.Parse(args)
+	from, err := time.Parse(time.RFC3339, *fromFlag)
+	if *topic == "" || err != nil {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	// Replays read Kafka's history, whatever EVENT_TRANSPORT the service uses
+	transport, err := message.NewKafkaTransport(envSlice("KAFKA_BROKERS", "localhost:9092"), message.KafkaWriterConfig{})
+	if err != nil {
+		logger.Fatal("Kafka error", logger.FieldError, err)
+	}
+	defer transport.Close()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	if err := notify.Replay(ctx, transport, *topic, from, *dryRun); err != nil {
+		logger.Fatal("Replay failed", logger.FieldError, err)
+	}
+}
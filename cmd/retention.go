@@ -0,0 +1,112 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"crypto-alert/internal/logger"
+	"crypto-alert/internal/store"
+)
+
+// logRetentionInterval is how often old logs are looked for
+const logRetentionInterval = time.Hour
+
+// logJanitor archives the closed days of the log files and deletes the logs
+// older than the retention. The engine does it for all the services, which
+// share the log directory and index.
+type logJanitor struct {
+	logDir  string
+	esLog   *store.ESClient   // nil without Elasticsearch
+	archive *store.LogArchive // nil without an archive
+	days    int               // Retention, 0 to keep all logs
+}
+
+// run cleans up on start and then every hour
+func (j *logJanitor) run(ctx context.Context) {
+	ticker := time.NewTicker(logRetentionInterval)
+	defer ticker.Stop()
+	for {
+		before := ""
+		if j.days > 0 {
+			before = time.Now().AddDate(0, 0, 1-j.days).Format("20060102")
+		}
+		keepFrom := j.archiveClosedDays(ctx)
+		j.pruneLogs(ctx, before, keepFrom)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// archiveClosedDays uploads the days before today that have log files and
+// aren't archived yet. It returns the first of them it failed to upload, whose
+// files have to stay, or "" when they all are archived.
+func (j *logJanitor) archiveClosedDays(ctx context.Context) string {
+	if j.archive == nil {
+		return ""
+	}
+	local, err := store.LogDates(j.logDir)
+	if err != nil {
+		slog.Warn("⚠️  Log archive: failed to read the log directory", "dir", j.logDir, logger.FieldError, err)
+		return ""
+	}
+	today := time.Now().Format("20060102")
+	archivedDates, err := j.archive.Dates(ctx)
+	if err != nil {
+		slog.Warn("⚠️  Log archive: failed to list the archived days", logger.FieldError, err)
+		if len(local) > 0 {
+			return local[len(local)-1] // Keep every day until the archive can be read
+		}
+		return ""
+	}
+	archived := make(map[string]bool, len(archivedDates))
+	for _, d := range archivedDates {
+		archived[d] = true
+	}
+
+	failed := ""
+	for _, d := range local { // Newest first
+		if d >= today || archived[d] {
+			continue
+		}
+		if err := j.archive.Upload(ctx, j.logDir, d); err != nil {
+			if ctx.Err() == nil {
+				slog.Warn("⚠️  Log archive: failed to upload a day", "day", d, logger.FieldError, err)
+			}
+			failed = d
+			continue
+		}
+		slog.Info("📦 Log archive: uploaded a day", "day", d)
+	}
+	return failed
+}
+
+// pruneLogs deletes the logs of the days before before ("" for none), except
+// for the files of keepFrom on
+func (j *logJanitor) pruneLogs(ctx context.Context, before, keepFrom string) {
+	if before == "" {
+		return
+	}
+	fileBefore := before
+	if keepFrom != "" && keepFrom < fileBefore {
+		fileBefore = keepFrom
+	}
+	if n, err := store.RemoveLogDaysBefore(j.logDir, fileBefore); err != nil {
+		slog.Warn("⚠️  Log retention: failed to remove old log files", "before", fileBefore, logger.FieldError, err)
+	} else if n > 0 {
+		slog.Info("🧹 Log retention: removed old log files", "days", n, "before", fileBefore)
+	}
+
+	if j.esLog != nil {
+		if n, err := j.esLog.DeleteLogsBefore(ctx, before); err != nil {
+			if ctx.Err() == nil {
+				slog.Warn("⚠️  Log retention: failed to delete old Elasticsearch logs", "before", before, logger.FieldError, err)
+			}
+		} else if n > 0 {
+			slog.Info("🧹 Log retention: deleted old Elasticsearch log entries", "entries", n, "before", before)
+		}
+	}
+}
@@ -0,0 +1,32 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"crypto-alert/internal/message"
+
+	"github.com/joho/godotenv"
+)
+
+const telegramCodeUsage = `Usage:
+  crypto-alert telegram-code <email or token> [...]`
+
+// runTelegramCode runs `crypto-alert telegram-code`, which prints the
+// registration code of each token, for the user to register their chats with
+// the bot by /start <token> <code>
+func runTelegramCode(args []string) {
+	_ = godotenv.Load()
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, telegramCodeUsage)
+		os.Exit(2)
+	}
+	secret := os.Getenv("TELEGRAM_REGISTRATION_SECRET")
+	if secret == "" {
+		fmt.Fprintln(os.Stderr, "TELEGRAM_REGISTRATION_SECRET is not set: the bot registers tokens without codes")
+		os.Exit(1)
+	}
+	for _, token := range args {
+		fmt.Printf("%s\t/start %s %s\n", token, token, message.TelegramRegistrationCode(secret, token))
+	}
+}
@@ -1,5 +1,5 @@
 import { useState, useEffect, useRef } from 'react'
-import { RefreshCw, Calendar, AlertCircle, Loader, Search, BarChart3, ScrollText } from 'lucide-react'
+import { RefreshCw, Calendar, AlertCircle, Loader, Search, BarChart3, ScrollText, Bell, X, Download } from 'lucide-react'
 import Dashboard from './Dashboard'
 
 function App() {
@@ -8,12 +8,17 @@ function App() {
   const [selectedDate, setSelectedDate]     = useState('')
   const [availableDates, setAvailableDates] = useState([])
   const [searchTerm, setSearchTerm]         = useState('')
+  const [levelFilter, setLevelFilter]       = useState('')  // '' | 'warn,error' | 'error'
+  const [serviceFilter, setServiceFilter]   = useState('')  // '' | 'monitor' | 'notification-service' | 'log-api'
   const [loading, setLoading]               = useState(false)
   const [autoRefresh, setAutoRefresh]       = useState(true)
   const [error, setError]                   = useState(null)
+  const [toasts, setToasts]                 = useState([])  // Live alerts, newest last
   // checkpoint: RFC3339 timestamp of the last known log entry for the selected date.
   const checkpointRef  = useRef('')
   const searchTermRef  = useRef('')
+  const levelFilterRef = useRef('')
+  const serviceFilterRef = useRef('')
   const logEndRef      = useRef(null)
   const scrollContainerRef = useRef(null)
 
@@ -44,20 +49,32 @@ function App() {
     return ''
   }
 
+  // Fetches every page of the day's logs, following next_cursor
+  const fetchLogPages = async (date, params) => {
+    let all = []
+    let cursor = ''
+    do {
+      if (cursor) params.set('cursor', cursor)
+      const response = await fetch(`/api/logs/${date}?${params.toString()}`)
+      if (!response.ok) throw new Error(`Failed to fetch logs: ${response.statusText}`)
+      const data = await response.json()
+      all = all.concat(data.logs || [])
+      cursor = data.next_cursor || ''
+    } while (cursor)
+    return all
+  }
+
   const fetchLogs = async (date) => {
     if (!date) return
     const params = new URLSearchParams()
     if (searchTermRef.current.trim()) params.set('q', searchTermRef.current.trim())
-    const query = params.toString()
-    const url = `/api/logs/${date}${query ? `?${query}` : ''}`
+    if (levelFilterRef.current) params.set('level', levelFilterRef.current)
+    if (serviceFilterRef.current) params.set('service', serviceFilterRef.current)
 
     setLoading(true)
     setError(null)
     try {
-      const response = await fetch(url)
-      if (!response.ok) throw new Error(`Failed to fetch logs: ${response.statusText}`)
-      const data = await response.json()
-      setLogs(data.logs || [])
+      setLogs(await fetchLogPages(date, params))
       const cp = await fetchCheckpoint(date)
       checkpointRef.current = cp
     } catch (err) {
@@ -72,10 +89,9 @@ function App() {
     try {
       const params = new URLSearchParams({ since })
       if (searchTermRef.current.trim()) params.set('q', searchTermRef.current.trim())
-      const res = await fetch(`/api/logs/${date}?${params.toString()}`)
-      if (!res.ok) return
-      const data = await res.json()
-      const newLogs = data.logs || []
+      if (levelFilterRef.current) params.set('level', levelFilterRef.current)
+      if (serviceFilterRef.current) params.set('service', serviceFilterRef.current)
+      const newLogs = await fetchLogPages(date, params)
       if (newLogs.length > 0) {
         setLogs(prev => [...newLogs, ...prev])
       }
@@ -83,6 +99,8 @@ function App() {
   }
 
   useEffect(() => { searchTermRef.current = searchTerm }, [searchTerm])
+  useEffect(() => { levelFilterRef.current = levelFilter }, [levelFilter])
+  useEffect(() => { serviceFilterRef.current = serviceFilter }, [serviceFilter])
 
   useEffect(() => {
     if (autoRefresh && logEndRef.current) {
@@ -105,10 +123,27 @@ function App() {
     if (!selectedDate) return
     const t = setTimeout(() => fetchLogs(selectedDate), 400)
     return () => clearTimeout(t)
-  }, [searchTerm, selectedDate])
+  }, [searchTerm, levelFilter, serviceFilter, selectedDate])
 
+  // Live tail of the latest day over Server-Sent Events
   useEffect(() => {
-    if (!autoRefresh || !selectedDate) return
+    if (!autoRefresh || !selectedDate || selectedDate !== availableDates[0]) return
+    const params = new URLSearchParams()
+    if (searchTerm.trim()) params.set('q', searchTerm.trim())
+    if (levelFilter) params.set('level', levelFilter)
+    if (serviceFilter) params.set('service', serviceFilter)
+    const source = new EventSource(`/api/logs/stream?${params.toString()}`)
+    source.addEventListener('log', (e) => {
+      const entry = JSON.parse(e.data)
+      setLogs(prev => [...prev, entry])
+      if (entry.ts) checkpointRef.current = entry.ts
+    })
+    return () => source.close()
+  }, [autoRefresh, selectedDate, searchTerm, levelFilter, serviceFilter, availableDates])
+
+  // Earlier days poll the checkpoint, in case their logs still change
+  useEffect(() => {
+    if (!autoRefresh || !selectedDate || selectedDate === availableDates[0]) return
     const interval = setInterval(async () => {
       const latestCheckpoint = await fetchCheckpoint(selectedDate)
       if (!latestCheckpoint || latestCheckpoint === checkpointRef.current) return
@@ -121,7 +156,33 @@ function App() {
       }
     }, 30000)
     return () => clearInterval(interval)
-  }, [autoRefresh, selectedDate])
+  }, [autoRefresh, selectedDate, availableDates])
+
+  // Alert toasts from the live alerts WebSocket, reconnecting when it drops
+  useEffect(() => {
+    let socket
+    let retry
+    let closed = false
+    const connect = () => {
+      const scheme = window.location.protocol === 'https:' ? 'wss' : 'ws'
+      socket = new WebSocket(`${scheme}://${window.location.host}/api/alerts/live`)
+      socket.onmessage = (e) => {
+        const alert = JSON.parse(e.data)
+        const id = `${alert.event_id || alert.rule_id}-${Date.now()}`
+        setToasts(prev => [...prev.slice(-4), { ...alert, id }])
+        setTimeout(() => setToasts(prev => prev.filter(t => t.id !== id)), 10000)
+      }
+      socket.onclose = () => {
+        if (!closed) retry = setTimeout(connect, 5000)
+      }
+    }
+    connect()
+    return () => {
+      closed = true
+      clearTimeout(retry)
+      socket.close()
+    }
+  }, [])
 
   const formatDateDisplay = (dateStr) => {
     if (!dateStr) return ''
@@ -191,6 +252,20 @@ function App() {
                 <RefreshCw className={`w-4 h-4 ${loading ? 'animate-spin-slow' : ''}`} />
                 Refresh
               </button>
+              {selectedDate && (
+                <a
+                  href={`/api/logs/${selectedDate}/export?${new URLSearchParams({
+                    format: 'csv',
+                    ...(searchTerm.trim() && { q: searchTerm.trim() }),
+                    ...(levelFilter && { level: levelFilter }),
+                    ...(serviceFilter && { service: serviceFilter }),
+                  }).toString()}`}
+                  className="flex items-center gap-2 bg-[#2a2a3e] text-dark-text border border-[#3a3a4e] px-4 py-2 rounded-md text-sm no-underline transition-colors hover:bg-[#3a3a4e] w-full md:w-auto"
+                >
+                  <Download className="w-4 h-4" />
+                  Export CSV
+                </a>
+              )}
             </div>
           )}
         </div>
@@ -213,6 +288,25 @@ function App() {
                 className="flex-1 bg-transparent border-none text-dark-text text-sm outline-none placeholder:text-dark-text-secondary"
               />
             </div>
+            <select
+              value={levelFilter}
+              onChange={(e) => setLevelFilter(e.target.value)}
+              className="bg-dark-surface-hover border border-dark-border rounded-md px-4 py-2 text-dark-text text-sm cursor-pointer outline-none"
+            >
+              <option value="">All levels</option>
+              <option value="warn,error">Warnings and errors</option>
+              <option value="error">Errors</option>
+            </select>
+            <select
+              value={serviceFilter}
+              onChange={(e) => setServiceFilter(e.target.value)}
+              className="bg-dark-surface-hover border border-dark-border rounded-md px-4 py-2 text-dark-text text-sm cursor-pointer outline-none"
+            >
+              <option value="">All services</option>
+              <option value="monitor">Monitor</option>
+              <option value="notification-service">Notification service</option>
+              <option value="log-api">Log API</option>
+            </select>
             <label className="flex items-center gap-2 text-dark-text text-sm cursor-pointer">
               <input
                 type="checkbox"
@@ -250,7 +344,9 @@ function App() {
             {logs.map((entry, index) => (
               <div
                 key={entry.ts ? `${entry.ts}-${index}` : index}
-                className="p-3 mb-2 rounded-lg bg-dark-surface border-l-[3px] border-l-blue-500 hover:bg-dark-surface-hover transition-colors"
+                className={`p-3 mb-2 rounded-lg bg-dark-surface border-l-[3px] hover:bg-dark-surface-hover transition-colors ${
+                  entry.level === 'ERROR' ? 'border-l-red-500' : entry.level === 'WARN' ? 'border-l-yellow-500' : 'border-l-blue-500'
+                }`}
               >
                 <div className="text-dark-text text-sm break-words whitespace-pre-wrap font-mono">
                   {typeof entry === 'string' ? entry : entry.message}
@@ -272,6 +368,32 @@ function App() {
           </footer>
         </>
       )}
+
+      {/* Live alert toasts */}
+      <div className="fixed bottom-4 right-4 flex flex-col gap-2 z-50 w-80">
+        {toasts.map(t => (
+          <div
+            key={t.id}
+            className={`flex items-start gap-3 p-3 rounded-lg bg-dark-surface border-l-[3px] shadow-lg ${
+              t.severity === 'critical' ? 'border-l-red-500' : t.severity === 'info' ? 'border-l-blue-500' : 'border-l-yellow-500'
+            }`}
+          >
+            <Bell className="w-4 h-4 mt-0.5 text-dark-text-muted shrink-0" />
+            <div className="flex-1 min-w-0">
+              <div className="text-dark-text text-sm font-medium">
+                {t.subject} {t.field} {t.direction} {t.threshold}
+              </div>
+              <div className="text-dark-text-muted text-xs break-words whitespace-pre-wrap">{t.message}</div>
+            </div>
+            <button
+              onClick={() => setToasts(prev => prev.filter(x => x.id !== t.id))}
+              className="text-dark-text-muted hover:text-dark-text bg-transparent border-none cursor-pointer"
+            >
+              <X className="w-4 h-4" />
+            </button>
+          </div>
+        ))}
+      </div>
     </div>
   )
 }
@@ -194,6 +194,52 @@ function MetricCard({ metric, range }) {
   )
 }
 
+function StatCard({ label, value, detail }) {
+  return (
+    <div className="bg-dark-surface border border-dark-border rounded-lg p-4">
+      <div className="text-dark-text-muted text-xs uppercase tracking-widest">{label}</div>
+      <div className="text-dark-text text-2xl font-semibold mt-1">{value}</div>
+      {detail && <div className="text-dark-text-secondary text-xs mt-1">{detail}</div>}
+    </div>
+  )
+}
+
+// Summary cards of the last 7 days from /api/stats
+function SummaryCards() {
+  const [stats, setStats] = useState(null)
+
+  useEffect(() => {
+    fetch('/api/stats')
+      .then(r => r.ok ? r.json() : null)
+      .then(setStats)
+      .catch(() => setStats(null))
+  }, [])
+
+  if (!stats) return null
+  const { totals, top_symbols: topSymbols } = stats
+  const alerts = Object.values(totals.alerts).reduce((a, b) => a + b, 0)
+  const byKind = Object.entries(totals.alerts)
+    .filter(([, n]) => n > 0)
+    .map(([kind, n]) => `${TYPE_COLORS[kind]?.label || kind} ${n}`)
+    .join(' · ')
+
+  return (
+    <CollapsibleSection title="Last 7 Days">
+      <div className="grid grid-cols-2 xl:grid-cols-5 gap-4">
+        <StatCard label="Checks" value={totals.checks} />
+        <StatCard label="Alerts" value={alerts} detail={byKind} />
+        <StatCard label="Notifications sent" value={totals.notifications.succeeded} />
+        <StatCard label="Notifications failed" value={totals.notifications.failed} />
+        <StatCard
+          label="Top symbols"
+          value={topSymbols[0]?.symbol || '—'}
+          detail={topSymbols.slice(0, 3).map(s => `${s.symbol} ${s.alerts}`).join(' · ')}
+        />
+      </div>
+    </CollapsibleSection>
+  )
+}
+
 function CollapsibleSection({ title, children, defaultOpen = true }) {
   const [open, setOpen] = useState(defaultOpen)
   return (
@@ -261,6 +307,8 @@ export default function Dashboard() {
 
       {/* Content */}
       <div className="flex-1 overflow-y-auto p-8 bg-dark-bg scrollbar-thin">
+        <SummaryCards />
+
         {loading && (
           <div className="flex items-center justify-center gap-3 py-16 text-dark-text-muted text-base">
             <Loader className="w-5 h-5 animate-spin-slow" />
@@ -11,6 +11,17 @@ const getBackendUrl = () => {
   return 'http://127.0.0.1:8181'
 }
 
+// API key the proxy sends to the backend (a logs:read key of its API_KEYS), so it never reaches the browser
+const backendHeaders = process.env.BACKEND_API_KEY
+  ? { Authorization: `Bearer ${process.env.BACKEND_API_KEY}` }
+  : {}
+
+// The dashboard's pages are same-origin with this proxy, so their WebSocket
+// upgrades go to the backend without the Origin its origin check would refuse
+const dropWebSocketOrigin = (proxy) => {
+  proxy.on('proxyReqWs', (proxyReq) => proxyReq.removeHeader('origin'))
+}
+
 // https://vite.dev/config/
 export default defineConfig({
   plugins: [react()],
@@ -21,6 +32,9 @@ export default defineConfig({
       '/api': {
         target: getBackendUrl(),
         changeOrigin: true,
+        headers: backendHeaders,
+        ws: true, // /api/alerts/live
+        configure: dropWebSocketOrigin,
         rewrite: (path) => path,
       },
     },
@@ -35,6 +49,9 @@ export default defineConfig({
       '/api': {
         target: getBackendUrl(),
         changeOrigin: true,
+        headers: backendHeaders,
+        ws: true, // /api/alerts/live
+        configure: dropWebSocketOrigin,
         rewrite: (path) => path,
       },
     },
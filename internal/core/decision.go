@@ -2,6 +2,7 @@ package core
 
 import (
 	"fmt"
+	"strings"
 	"sync"
 	"time"
 
@@ -19,6 +20,33 @@ const (
 	DirectionLessThan           Direction = "<"
 )
 
+// Severity classifies how urgent a rule's alerts are
+type Severity string
+
+const (
+	SeverityInfo     Severity = "info"
+	SeverityWarning  Severity = "warning"
+	SeverityCritical Severity = "critical"
+)
+
+// Locale selects the language of a rule's notifications
+type Locale string
+
+const (
+	LocaleEnglish Locale = "en"
+	LocaleChinese Locale = "zh"
+	LocaleSpanish Locale = "es"
+)
+
+// TelegramFormat selects the Telegram parse mode of a rule's alerts
+type TelegramFormat string
+
+const (
+	TelegramFormatHTML       TelegramFormat = "html"
+	TelegramFormatMarkdownV2 TelegramFormat = "markdownv2"
+	TelegramFormatText       TelegramFormat = "text"
+)
+
 // FrequencyUnit represents the unit for frequency
 type FrequencyUnit string
 
@@ -35,40 +63,69 @@ type Frequency struct {
 	Unit   FrequencyUnit // DAY, HOUR, ONCE, NEVER
 }
 
+// NotificationSettings are the delivery settings every kind of rule has:
+// where its alerts go and how they are sent
+type NotificationSettings struct {
+	RecipientEmails     []string       // Email addresses to send alerts to
+	TelegramChatIDs     []string       // Optional Telegram chat IDs for notifications
+	Channels            []string       // Optional channel allow-list; empty notifies every channel the rule has a destination for
+	WebhookURL          string         // Optional URL that receives the signed alert event JSON
+	WhatsAppTo          string         // Optional WhatsApp number (E.164) for notifications
+	TeamsWebhookURL     string         // Optional Microsoft Teams incoming webhook URL
+	NtfyTopic           string         // Optional ntfy topic for push notifications
+	PushoverUserKey     string         // Optional Pushover user or group key
+	Severity            Severity       // info | warning | critical
+	PagerDutyRoutingKey string         // Optional PagerDuty Events API v2 routing key
+	OpsgenieAPIKey      string         // Optional Opsgenie API integration key
+	ResolvesRuleID      int64          // Optional ID of the rule whose incident this rule resolves
+	MessageTemplate     string         // Optional Go template for the notification subject and body
+	Locale              Locale         // Language of the built-in notification messages; empty is English
+	TelegramFormat      TelegramFormat // Parse mode of Telegram alerts; empty is HTML
+	TelegramSilent      bool           // Send Telegram alerts without sound (disable_notification)
+	TelegramNoPreview   bool           // Don't show link previews in Telegram alerts
+	DigestInterval      time.Duration  // Batch non-critical email and Telegram alerts into a digest this often; 0 sends each alert
+	EscalateAfter       time.Duration  // Re-send unacknowledged critical alerts to EscalateTo after this long; 0 doesn't escalate
+	EscalateTo          []string       // channel:destination pairs escalated alerts go to, e.g. pagerduty:<routing key>
+}
+
 // AlertRule defines a price alert rule
 type AlertRule struct {
-	ID               int64 // MySQL row ID — used for hot-swap matching
-	Symbol           string
-	PriceFeedID      string // Pyth price feed ID for this symbol
-	Threshold        float64
-	Direction        Direction // >=, >, =, <=, <
-	Enabled          bool
-	RecipientEmail   string // Email address to send alerts to
-	TelegramChatID   string // Optional Telegram chat ID for notifications
-	LastTriggered    *time.Time
-	Frequency        *Frequency // Optional frequency configuration
+	ID          int64 // MySQL row ID — used for hot-swap matching
+	Symbol      string
+	PriceFeedID string // Pyth price feed ID for this symbol
+	Threshold   float64
+	Direction   Direction // >=, >, =, <=, <
+	Enabled     bool
+	Tags        []string // Free-form lowercase labels, e.g. treasury, that rule lists, mutes and digests go by
+	NotificationSettings
+	TelegramChart bool // Attach a chart of the recent values to Telegram alerts
+	LastTriggered *time.Time
+	SnoozedUntil  *time.Time // Alerts are held back until then, e.g. after "Snooze 1h" on a Telegram alert
+	Frequency     *Frequency // Optional frequency configuration
 }
 
 // DeFiAlertRule defines a DeFi protocol alert rule
 type DeFiAlertRule struct {
-	ID                      int64 // MySQL row ID — used for hot-swap matching
-	Protocol                string
-	Category                string // "market" or "vault" (for Morpho), empty for others
-	Version                 string
-	ChainID                 string
-	MarketTokenContract     string // For Aave: token contract, For Morpho market: market_id, For Morpho vault: vault_token_address
-	Field                   string // "TVL", "APY", "UTILIZATION", "LIQUIDITY"
-	Threshold               float64
-	Direction               Direction // >=, >, =, <=, <
-	Enabled                 bool
-	RecipientEmail          string
-	TelegramChatID          string // Optional Telegram chat ID for notifications
-	LastTriggered           *time.Time
-	Frequency               *Frequency
+	ID                  int64 // MySQL row ID — used for hot-swap matching
+	Protocol            string
+	Category            string // "market" or "vault" (for Morpho), empty for others
+	Version             string
+	ChainID             string
+	MarketTokenContract string // For Aave: token contract, For Morpho market: market_id, For Morpho vault: vault_token_address
+	Field               string // "TVL", "APY", "UTILIZATION", "LIQUIDITY"
+	Threshold           float64
+	Direction           Direction // >=, >, =, <=, <
+	Enabled             bool
+	Tags                []string // Free-form lowercase labels, e.g. treasury, that rule lists, mutes and digests go by
+	NotificationSettings
+	TelegramChart bool // Attach a chart of the recent values to Telegram alerts
+	LastTriggered *time.Time
+	SnoozedUntil  *time.Time // Alerts are held back until then, e.g. after "Snooze 1h" on a Telegram alert
+	Frequency     *Frequency
 	// Display names (optional, for better logging/alert messages)
-	MarketTokenName         string // For Aave: display name of the token (e.g., "USDC")
-	MarketTokenPair         string // For Morpho market: display pair (e.g., "USDC/WETH")
-	VaultName               string // For Morpho vault: display name of the vault
+	MarketTokenName string // For Aave: display name of the token (e.g., "USDC")
+	MarketTokenPair string // For Morpho market: display pair (e.g., "USDC/WETH")
+	VaultName       string // For Morpho vault: display name of the vault
 	// Morpho-specific fields
 	BorrowTokenContract     string // For Morpho market (loan token)
 	CollateralTokenContract string // For Morpho market
@@ -79,7 +136,17 @@ type DeFiAlertRule struct {
 	VaultTokenAddress       string // For Morpho vault (same as MarketTokenContract)
 	DepositTokenContract    string // For Morpho vault
 	// Hyperliquid-specific fields
-	LedgerAddress           string // For Hyperliquid vault: the vault ledger address
+	LedgerAddress string // For Hyperliquid vault: the vault ledger address
+	// ENSNames maps lower-case resolved addresses to the ENS names they were configured as
+	ENSNames map[string]string
+}
+
+// FormatAddress returns "name.eth (0x...)" when addr was configured as an ENS name, otherwise addr
+func FormatAddress(ensNames map[string]string, addr string) string {
+	if name, ok := ensNames[strings.ToLower(addr)]; ok {
+		return fmt.Sprintf("%s (%s)", name, addr)
+	}
+	return addr
 }
 
 // AlertDecision represents the result of evaluating an alert rule
@@ -88,6 +155,14 @@ type AlertDecision struct {
 	Rule         *AlertRule
 	CurrentPrice *price.PriceData
 	Message      string
+	PriceHistory []HistoryPoint // Recorded prices of the last 24h, oldest first (nil when no history is stored)
+	TriggeredAt  time.Time      // When the rule triggered (UTC); with the rule ID it identifies the alert
+}
+
+// HistoryPoint is one recorded value of a metric, e.g. a token price or a protocol's TVL
+type HistoryPoint struct {
+	Time  time.Time
+	Value float64
 }
 
 // DeFiAlertDecision represents the result of evaluating a DeFi alert rule
@@ -95,40 +170,86 @@ type DeFiAlertDecision struct {
 	ShouldAlert  bool
 	Rule         *DeFiAlertRule
 	CurrentValue float64
+	ValueHistory []HistoryPoint // Recorded values of the rule's field over the last 24h, oldest first (nil when no history is stored)
 	ChainName    string
 	Message      string
+	TriggeredAt  time.Time // When the rule triggered (UTC); with the rule ID it identifies the alert
 }
 
 // PredictMarketAlertRule defines a prediction market alert rule.
 // Threshold comparison is performed against the midpoint price.
 type PredictMarketAlertRule struct {
-	ID             int64 // MySQL row ID — used for hot-swap matching
-	PredictMarket  string     // e.g., "polymarket"
-	TokenID        string     // CLOB token ID to monitor
-	Field          string     // "MIDPOINT"
-	Threshold      float64
-	Direction      Direction
-	Enabled          bool
-	RecipientEmail   string
-	TelegramChatID   string // Optional Telegram chat ID for notifications
-	LastTriggered    *time.Time
-	Frequency        *Frequency
+	ID            int64  // MySQL row ID — used for hot-swap matching
+	PredictMarket string // e.g., "polymarket"
+	TokenID       string // CLOB token ID to monitor
+	Field         string // "MIDPOINT", "SPREAD", "DEPTH", "SUM", "DIFF", or "MOVE"
+	Threshold     float64
+	Direction     Direction
+	Enabled       bool
+	Tags          []string // Free-form lowercase labels, e.g. treasury, that rule lists, mutes and digests go by
+	NotificationSettings
+	LastTriggered *time.Time
+	SnoozedUntil  *time.Time // Alerts are held back until then, e.g. after "Snooze 1h" on a Telegram alert
+	Frequency     *Frequency
 	// Display context (populated from params)
 	NegRisk     bool
 	QuestionID  string
 	Question    string
 	ConditionID string
 	Outcome     string // "YES" or "NO"
+	// Market lookup used to resolve TokenID when it isn't configured
+	MarketSlug string
+	GroupItem  string
+	// Multi-outcome rules (SUM / DIFF): the outcome tokens combined, and the
+	// candidate names used to resolve them within a multi-market event
+	TokenIDs   []string
+	GroupItems []string
+	// Probability move (MOVE field only)
+	MoveWindow time.Duration
+	// Order book depth (DEPTH field only)
+	DepthSide  string  // "BID" or "ASK"
+	DepthPrice float64 // Price level: bids >= level or asks <= level are counted
+}
+
+// Prediction market rule fields
+const (
+	PredictFieldMidpoint = "MIDPOINT" // Midpoint of best bid and ask
+	PredictFieldSpread   = "SPREAD"   // Best ask minus best bid
+	PredictFieldDepth    = "DEPTH"    // USDC notional resting on one side up to a price level
+	PredictFieldSum      = "SUM"      // Sum of the midpoints of TokenIDs
+	PredictFieldDiff     = "DIFF"     // Midpoint of TokenIDs[0] minus midpoint of TokenIDs[1]
+	PredictFieldMove     = "MOVE"     // Largest midpoint move within MoveWindow, in points (0.01 = 1 point)
+)
+
+// Tokens returns the CLOB token IDs the rule reads: TokenIDs for multi-outcome
+// rules, otherwise TokenID.
+func (r *PredictMarketAlertRule) Tokens() []string {
+	if len(r.TokenIDs) > 0 {
+		return r.TokenIDs
+	}
+	return []string{r.TokenID}
 }
 
 // PredictMarketAlertDecision represents the result of evaluating a prediction market alert rule.
 type PredictMarketAlertDecision struct {
 	ShouldAlert      bool
 	Rule             *PredictMarketAlertRule
+	CurrentValue     float64 // Value of Rule.Field that triggered the alert
 	CurrentMidpoint  float64
 	CurrentBuyPrice  float64
 	CurrentSellPrice float64
 	Message          string
+	History          *PredictMarketHistory // Recent midpoint context (nil when no history is stored)
+	TriggeredAt      time.Time             // When the rule triggered (UTC); with the rule ID it identifies the alert
+}
+
+// PredictMarketHistory summarizes a token's recorded midpoints over a period
+type PredictMarketHistory struct {
+	Period  time.Duration
+	Open    float64 // First midpoint in the period
+	Low     float64
+	High    float64
+	Samples int
 }
 
 // DecisionEngine handles price comparison and alert decisions.
@@ -138,6 +259,16 @@ type DecisionEngine struct {
 	rules              []*AlertRule
 	defiRules          []*DeFiAlertRule
 	predictMarketRules []*PredictMarketAlertRule
+	watchRules         []*WatchAlertRule
+	triggers           TriggerRecorder // Told about every trigger of a MySQL rule; nil when not set
+}
+
+// TriggerRecorder persists that a rule triggered, so the rule's state
+// survives a restart. kind is token, defi, predict or watch; once is set for
+// ONCE rules, which won't alert again. It is called with the engine locked,
+// so it must not block.
+type TriggerRecorder interface {
+	RecordTrigger(kind string, id int64, once bool)
 }
 
 // NewDecisionEngine creates a new decision engine
@@ -146,7 +277,25 @@ func NewDecisionEngine() *DecisionEngine {
 		rules:              make([]*AlertRule, 0),
 		defiRules:          make([]*DeFiAlertRule, 0),
 		predictMarketRules: make([]*PredictMarketAlertRule, 0),
+		watchRules:         make([]*WatchAlertRule, 0),
+	}
+}
+
+// SetTriggerRecorder makes the engine tell r about every trigger of a rule
+// loaded from MySQL
+func (e *DecisionEngine) SetTriggerRecorder(r TriggerRecorder) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.triggers = r
+}
+
+// recordTrigger tells the trigger recorder, if any, that a rule triggered;
+// caller must hold e.mu
+func (e *DecisionEngine) recordTrigger(kind string, id int64, frequency *Frequency) {
+	if e.triggers == nil || id == 0 {
+		return
 	}
+	e.triggers.RecordTrigger(kind, id, frequency != nil && frequency.Unit == FrequencyUnitOnce)
 }
 
 // AddRule adds an alert rule to the engine
@@ -212,7 +361,7 @@ func (e *DecisionEngine) GetPredictMarketRules() []*PredictMarketAlertRule {
 // ReplaceRules atomically swaps all rule sets, preserving LastTriggered from
 // existing rules that share the same MySQL ID. Call this to hot-reload rules
 // from the database without restarting the process.
-func (e *DecisionEngine) ReplaceRules(price []*AlertRule, defi []*DeFiAlertRule, predict []*PredictMarketAlertRule) {
+func (e *DecisionEngine) ReplaceRules(price []*AlertRule, defi []*DeFiAlertRule, predict []*PredictMarketAlertRule, watch []*WatchAlertRule) {
 	e.mu.Lock()
 	defer e.mu.Unlock()
 
@@ -235,27 +384,45 @@ func (e *DecisionEngine) ReplaceRules(price []*AlertRule, defi []*DeFiAlertRule,
 			oldPredict[r.ID] = r
 		}
 	}
+	oldWatch := make(map[int64]*WatchAlertRule, len(e.watchRules))
+	for _, r := range e.watchRules {
+		if r.ID != 0 {
+			oldWatch[r.ID] = r
+		}
+	}
 
-	// Carry LastTriggered forward so frequency suppression survives a reload.
+	// Carry LastTriggered forward so frequency suppression survives a reload;
+	// the value read from MySQL stands when the rule hasn't triggered here yet.
 	for _, r := range price {
-		if old, ok := oldPrice[r.ID]; ok {
+		if old, ok := oldPrice[r.ID]; ok && old.LastTriggered != nil {
 			r.LastTriggered = old.LastTriggered
 		}
 	}
 	for _, r := range defi {
-		if old, ok := oldDefi[r.ID]; ok {
+		if old, ok := oldDefi[r.ID]; ok && old.LastTriggered != nil {
 			r.LastTriggered = old.LastTriggered
 		}
 	}
 	for _, r := range predict {
-		if old, ok := oldPredict[r.ID]; ok {
+		if old, ok := oldPredict[r.ID]; ok && old.LastTriggered != nil {
 			r.LastTriggered = old.LastTriggered
 		}
 	}
+	// Watch rules also keep their seen-event set so known events don't re-alert.
+	for _, r := range watch {
+		if old, ok := oldWatch[r.ID]; ok {
+			if old.LastTriggered != nil {
+				r.LastTriggered = old.LastTriggered
+			}
+			r.seen = old.seen
+			r.primed = old.primed
+		}
+	}
 
 	e.rules = price
 	e.defiRules = defi
 	e.predictMarketRules = predict
+	e.watchRules = watch
 }
 
 // Evaluate checks if a price should trigger an alert based on rules.
@@ -270,7 +437,7 @@ func (e *DecisionEngine) evaluateLocked(priceData *price.PriceData) []*AlertDeci
 	decisions := make([]*AlertDecision, 0)
 
 	for _, rule := range e.rules {
-		if !rule.Enabled {
+		if !rule.Enabled || snoozed(rule.SnoozedUntil) {
 			continue
 		}
 
@@ -375,16 +542,18 @@ func (e *DecisionEngine) evaluateLocked(priceData *price.PriceData) []*AlertDeci
 				}
 			}
 
+			now := time.Now()
 			decisions = append(decisions, &AlertDecision{
 				ShouldAlert:  true,
 				Rule:         rule,
 				CurrentPrice: priceData,
 				Message:      message,
+				TriggeredAt:  now.UTC(),
 			})
 
 			// Update last triggered time
-			now := time.Now()
 			rule.LastTriggered = &now
+			e.recordTrigger("token", rule.ID, rule.Frequency)
 		}
 	}
 
@@ -406,6 +575,8 @@ func (e *DecisionEngine) EvaluateAll(prices map[string]*price.PriceData) []*Aler
 }
 
 // EvaluatePredictMarket checks if a prediction market midpoint should trigger an alert.
+// Only MIDPOINT rules for tokenID are evaluated; rules on book metrics go through
+// EvaluatePredictMarketRule with their own value.
 // buyPrice and sellPrice are passed through to the decision for inclusion in alert emails.
 func (e *DecisionEngine) EvaluatePredictMarket(tokenID string, midpoint, buyPrice, sellPrice float64) []*PredictMarketAlertDecision {
 	e.mu.Lock()
@@ -418,108 +589,75 @@ func (e *DecisionEngine) evaluatePredictMarketLocked(tokenID string, midpoint, b
 	decisions := make([]*PredictMarketAlertDecision, 0)
 
 	for _, rule := range e.predictMarketRules {
-		if !rule.Enabled {
+		if rule.TokenID != tokenID || rule.Field != PredictFieldMidpoint {
 			continue
 		}
-		if rule.TokenID != tokenID {
-			continue
+		if d := evaluatePredictMarketRuleLocked(rule, midpoint, midpoint, buyPrice, sellPrice); d != nil {
+			e.recordTrigger("predict", rule.ID, rule.Frequency)
+			decisions = append(decisions, d)
 		}
+	}
 
-		shouldAlert := false
-		message := ""
+	return decisions
+}
 
-		switch rule.Direction {
-		case DirectionGreaterThanOrEqual:
-			if midpoint >= rule.Threshold {
-				shouldAlert = true
-				message = fmt.Sprintf(
-					"🚨 Alert: Polymarket token %s midpoint is %.4f, which is >= threshold of %g",
-					tokenID, midpoint, rule.Threshold,
-				)
-			}
-		case DirectionGreaterThan:
-			if midpoint > rule.Threshold {
-				shouldAlert = true
-				message = fmt.Sprintf(
-					"🚨 Alert: Polymarket token %s midpoint is %.4f, which is > threshold of %g",
-					tokenID, midpoint, rule.Threshold,
-				)
-			}
-		case DirectionEqual:
-			epsilon := 0.0001
-			if midpoint >= rule.Threshold-epsilon && midpoint <= rule.Threshold+epsilon {
-				shouldAlert = true
-				message = fmt.Sprintf(
-					"🚨 Alert: Polymarket token %s midpoint is %.4f, which equals threshold of %g",
-					tokenID, midpoint, rule.Threshold,
-				)
-			}
-		case DirectionLessThanOrEqual:
-			if midpoint <= rule.Threshold {
-				shouldAlert = true
-				message = fmt.Sprintf(
-					"🚨 Alert: Polymarket token %s midpoint is %.4f, which is <= threshold of %g",
-					tokenID, midpoint, rule.Threshold,
-				)
-			}
-		case DirectionLessThan:
-			if midpoint < rule.Threshold {
-				shouldAlert = true
-				message = fmt.Sprintf(
-					"🚨 Alert: Polymarket token %s midpoint is %.4f, which is < threshold of %g",
-					tokenID, midpoint, rule.Threshold,
-				)
-			}
-		}
+// EvaluatePredictMarketRule evaluates a single prediction market rule against value,
+// the current reading of the rule's Field (midpoint, spread, or depth).
+func (e *DecisionEngine) EvaluatePredictMarketRule(rule *PredictMarketAlertRule, value, midpoint, buyPrice, sellPrice float64) *PredictMarketAlertDecision {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	d := evaluatePredictMarketRuleLocked(rule, value, midpoint, buyPrice, sellPrice)
+	if d != nil {
+		e.recordTrigger("predict", rule.ID, rule.Frequency)
+	}
+	return d
+}
 
-		if shouldAlert {
-			if rule.Frequency != nil {
-				switch rule.Frequency.Unit {
-				case FrequencyUnitOnce:
-					if rule.LastTriggered != nil {
-						rule.Enabled = false
-						continue
-					}
-				case FrequencyUnitNever:
-					continue
-				case FrequencyUnitDay:
-					if rule.LastTriggered != nil {
-						requiredDuration := time.Duration(rule.Frequency.Number) * 24 * time.Hour
-						if time.Since(*rule.LastTriggered) < requiredDuration {
-							continue
-						}
-					}
-				case FrequencyUnitHour:
-					if rule.LastTriggered != nil {
-						requiredDuration := time.Duration(rule.Frequency.Number) * time.Hour
-						if time.Since(*rule.LastTriggered) < requiredDuration {
-							continue
-						}
-					}
-				}
-			} else {
-				if rule.LastTriggered != nil {
-					if time.Since(*rule.LastTriggered) < time.Hour {
-						continue
-					}
-				}
-			}
+// evaluatePredictMarketRuleLocked is the lock-free implementation; caller must hold e.mu.
+func evaluatePredictMarketRuleLocked(rule *PredictMarketAlertRule, value, midpoint, buyPrice, sellPrice float64) *PredictMarketAlertDecision {
+	if !rule.Enabled || snoozed(rule.SnoozedUntil) {
+		return nil
+	}
 
-			decisions = append(decisions, &PredictMarketAlertDecision{
-				ShouldAlert:      true,
-				Rule:             rule,
-				CurrentMidpoint:  midpoint,
-				CurrentBuyPrice:  buyPrice,
-				CurrentSellPrice: sellPrice,
-				Message:          message,
-			})
+	epsilon := 0.0001
+	if rule.Field == PredictFieldDepth {
+		epsilon = 0.01
+	}
+	if !matchesThreshold(value, rule.Threshold, rule.Direction, epsilon) {
+		return nil
+	}
 
-			now := time.Now()
-			rule.LastTriggered = &now
+	if suppressedByFrequency(rule.Frequency, rule.LastTriggered) {
+		if rule.Frequency != nil && rule.Frequency.Unit == FrequencyUnitOnce {
+			rule.Enabled = false
 		}
+		return nil
 	}
 
-	return decisions
+	subject := "token " + rule.TokenID
+	if len(rule.GroupItems) > 0 {
+		subject = strings.Join(rule.GroupItems, ", ")
+	} else if len(rule.TokenIDs) > 0 {
+		subject = "tokens " + strings.Join(rule.TokenIDs, ", ")
+	}
+	message := fmt.Sprintf(
+		"🚨 Alert: %s %s %s is %.4f, which is %s threshold of %g",
+		rule.PredictMarket, subject, strings.ToLower(rule.Field), value, rule.Direction, rule.Threshold,
+	)
+
+	now := time.Now()
+	rule.LastTriggered = &now
+
+	return &PredictMarketAlertDecision{
+		ShouldAlert:      true,
+		Rule:             rule,
+		CurrentValue:     value,
+		CurrentMidpoint:  midpoint,
+		CurrentBuyPrice:  buyPrice,
+		CurrentSellPrice: sellPrice,
+		Message:          message,
+		TriggeredAt:      now.UTC(),
+	}
 }
 
 // EvaluateDeFi checks if a DeFi value should trigger an alert based on rules
@@ -534,7 +672,7 @@ func (e *DecisionEngine) evaluateDeFiLocked(chainID, tokenAddress, field string,
 	decisions := make([]*DeFiAlertDecision, 0)
 
 	for _, rule := range e.defiRules {
-		if !rule.Enabled {
+		if !rule.Enabled || snoozed(rule.SnoozedUntil) {
 			continue
 		}
 
@@ -660,17 +798,19 @@ func (e *DecisionEngine) evaluateDeFiLocked(chainID, tokenAddress, field string,
 				}
 			}
 
+			now := time.Now()
 			decisions = append(decisions, &DeFiAlertDecision{
 				ShouldAlert:  true,
 				Rule:         rule,
 				CurrentValue: currentValue,
 				ChainName:    chainName,
 				Message:      message,
+				TriggeredAt:  now.UTC(),
 			})
 
 			// Update last triggered time
-			now := time.Now()
 			rule.LastTriggered = &now
+			e.recordTrigger("defi", rule.ID, rule.Frequency)
 		}
 	}
 
@@ -0,0 +1,146 @@
+package core
+
+import (
+	"fmt"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// MessageTemplateData is the data of a rule's message template. Fields that
+// don't apply to the alert type are left empty.
+type MessageTemplateData struct {
+	Type      string // token | defi | predict | watch
+	Symbol    string // Token alerts
+	Price     float64
+	Value     float64 // The alerted value; the price for token alerts
+	Threshold float64
+	Direction string
+	Field     string
+	Protocol  string // DeFi alerts
+	Version   string
+	Chain     string
+	Question  string // Prediction market alerts
+	Outcome   string
+	Source    string // Watch alerts
+	Label     string
+	Title     string
+	URL       string
+	Severity  string
+	Message   string // The built-in alert message
+	Timestamp time.Time
+}
+
+// messageTemplateSubject names the optional template that renders the subject
+const messageTemplateSubject = "subject"
+
+// ParseMessageTemplate parses a rule's message template. The template renders
+// the notification body; a {{define "subject"}}...{{end}} block, when present,
+// renders the subject line.
+func ParseMessageTemplate(text string) (*template.Template, error) {
+	tmpl, err := template.New("message_template").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid message_template: %w", err)
+	}
+	return tmpl, nil
+}
+
+// ValidateMessageTemplate checks that a message template parses and only uses
+// fields of MessageTemplateData. An empty template is valid.
+func ValidateMessageTemplate(text string) error {
+	if text == "" {
+		return nil
+	}
+	_, _, err := RenderMessageTemplate(text, MessageTemplateData{})
+	return err
+}
+
+// RenderMessageTemplate renders a message template into a subject, empty when
+// the template doesn't define one, and a body.
+func RenderMessageTemplate(text string, data MessageTemplateData) (subject, body string, err error) {
+	tmpl, err := ParseMessageTemplate(text)
+	if err != nil {
+		return "", "", err
+	}
+
+	var buf strings.Builder
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", "", fmt.Errorf("invalid message_template: %w", err)
+	}
+	body = strings.TrimSpace(buf.String())
+
+	if tmpl.Lookup(messageTemplateSubject) != nil {
+		buf.Reset()
+		if err := tmpl.ExecuteTemplate(&buf, messageTemplateSubject, data); err != nil {
+			return "", "", fmt.Errorf("invalid message_template subject: %w", err)
+		}
+		subject = strings.TrimSpace(buf.String())
+	}
+	return subject, body, nil
+}
+
+// TemplateData returns the message template data of a token alert
+func (d *AlertDecision) TemplateData() MessageTemplateData {
+	data := MessageTemplateData{Type: "token", Message: d.Message, Timestamp: time.Now()}
+	if r := d.Rule; r != nil {
+		data.Threshold = r.Threshold
+		data.Direction = string(r.Direction)
+		data.Severity = string(r.Severity)
+		data.Symbol = r.Symbol
+	}
+	if p := d.CurrentPrice; p != nil {
+		data.Symbol = p.Symbol
+		data.Price = p.Price
+		data.Value = p.Price
+		data.Timestamp = p.Timestamp
+	}
+	return data
+}
+
+// TemplateData returns the message template data of a DeFi alert
+func (d *DeFiAlertDecision) TemplateData() MessageTemplateData {
+	data := MessageTemplateData{Type: "defi", Value: d.CurrentValue, Chain: d.ChainName, Message: d.Message, Timestamp: time.Now()}
+	if r := d.Rule; r != nil {
+		data.Threshold = r.Threshold
+		data.Direction = string(r.Direction)
+		data.Severity = string(r.Severity)
+		data.Field = r.Field
+		data.Protocol = r.Protocol
+		data.Version = r.Version
+	}
+	return data
+}
+
+// TemplateData returns the message template data of a prediction market alert
+func (d *PredictMarketAlertDecision) TemplateData() MessageTemplateData {
+	data := MessageTemplateData{Type: "predict", Value: d.CurrentValue, Price: d.CurrentMidpoint, Message: d.Message, Timestamp: time.Now()}
+	if r := d.Rule; r != nil {
+		data.Threshold = r.Threshold
+		data.Direction = string(r.Direction)
+		data.Severity = string(r.Severity)
+		data.Field = r.Field
+		data.Protocol = r.PredictMarket
+		data.Question = r.Question
+		data.Outcome = r.Outcome
+	}
+	return data
+}
+
+// TemplateData returns the message template data of a watch alert
+func (d *WatchAlertDecision) TemplateData() MessageTemplateData {
+	data := MessageTemplateData{Type: "watch", Chain: d.ChainName, Message: d.Message, Timestamp: time.Now()}
+	if r := d.Rule; r != nil {
+		data.Threshold = r.Threshold
+		data.Direction = string(r.Direction)
+		data.Severity = string(r.Severity)
+		data.Field = r.Field
+		data.Source = r.Source
+		data.Label = r.Label
+	}
+	if o := d.Observation; o != nil {
+		data.Value = o.Value
+		data.Title = o.Title
+		data.URL = o.URL
+	}
+	return data
+}
@@ -0,0 +1,58 @@
+package core
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// MuteKinds are the rule kinds a mute can be limited to
+var MuteKinds = []string{"token", "defi", "predict", "watch"}
+
+// Mute silences notifications until Until, e.g. during planned maintenance:
+// all of them, those of one rule kind, or those of one kind about one
+// subject; with Tag, only those of the rules with that tag
+type Mute struct {
+	ID         int64
+	Kind       string // token, defi, predict or watch; empty mutes every kind
+	Subject    string // Token symbol, DeFi protocol, prediction market or watch source; empty mutes the whole kind
+	Tag        string // Rule tag; empty mutes the rules with any tags or none
+	Until      time.Time
+	Reason     string
+	CreatedBy  string
+	Notify     string // channel:destination pair that gets the summary of suppressed alerts when the mute ends
+	Suppressed int    // Alerts suppressed so far
+}
+
+// Matches reports whether the mute silences an alert of a rule of kind with
+// tags about subject
+func (m Mute) Matches(kind, subject string, tags []string) bool {
+	if m.Kind != "" && m.Kind != kind {
+		return false
+	}
+	if m.Tag != "" && !HasTag(tags, m.Tag) {
+		return false
+	}
+	return m.Subject == "" || strings.EqualFold(m.Subject, subject)
+}
+
+// Scope describes what the mute silences, e.g. "all alerts", "defi alerts of
+// aave" or "token alerts tagged #treasury"
+func (m Mute) Scope() string {
+	var scope string
+	switch {
+	case m.Kind == "":
+		scope = "all alerts"
+	case m.Subject == "":
+		scope = m.Kind + " alerts"
+	default:
+		scope = fmt.Sprintf("%s alerts of %s", m.Kind, m.Subject)
+	}
+	if m.Tag != "" {
+		if m.Kind == "" {
+			scope = "alerts"
+		}
+		scope += " tagged #" + m.Tag
+	}
+	return scope
+}
@@ -0,0 +1,43 @@
+package core
+
+import (
+	"strings"
+	"unicode"
+)
+
+// ValidTag reports whether tag is a valid rule tag: 1 to 32 letters, digits,
+// '-', '_' or '.'
+func ValidTag(tag string) bool {
+	if tag == "" || len(tag) > 32 {
+		return false
+	}
+	for _, c := range tag {
+		if !unicode.IsLetter(c) && !unicode.IsDigit(c) && c != '-' && c != '_' && c != '.' {
+			return false
+		}
+	}
+	return true
+}
+
+// HasTag reports whether tags has tag, ignoring case
+func HasTag(tags []string, tag string) bool {
+	for _, t := range tags {
+		if strings.EqualFold(t, tag) {
+			return true
+		}
+	}
+	return false
+}
+
+// RuleSummary is a rule of any kind as rule lists show it
+type RuleSummary struct {
+	Kind      string   `json:"kind"` // token, defi, predict or watch
+	ID        int64    `json:"id"`
+	Subject   string   `json:"subject"` // Token symbol, DeFi protocol, prediction market or watch source
+	Field     string   `json:"field"`
+	Direction string   `json:"direction,omitempty"`
+	Threshold float64  `json:"threshold"`
+	Enabled   bool     `json:"enabled"`
+	Snoozed   bool     `json:"snoozed"`
+	Tags      []string `json:"tags"`
+}
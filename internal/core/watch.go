@@ -0,0 +1,254 @@
+package core
+
+import (
+	"fmt"
+	"time"
+)
+
+// WatchParams holds source-specific settings for a watch rule. Only the fields
+// relevant to the rule's Source are populated.
+type WatchParams struct {
+	// Safe multisig
+	SafeAddress  string `json:"safe_address,omitempty"`
+	TxServiceURL string `json:"tx_service_url,omitempty"` // Optional override of the Safe Transaction Service base URL
+	// Wallet approvals
+	WalletAddress  string   `json:"wallet_address,omitempty"`
+	TokenAddresses []string `json:"token_addresses,omitempty"` // Optional for approvals: only watch these token contracts
+	// Oracle deviation
+	Symbol        string `json:"symbol,omitempty"`         // e.g. "ETH/USD"
+	PythFeedID    string `json:"pyth_feed_id,omitempty"`   // Pyth price feed ID
+	ChainlinkFeed string `json:"chainlink_feed,omitempty"` // Chainlink aggregator address on ChainID
+	// Governance
+	SnapshotSpace   string `json:"snapshot_space,omitempty"`   // e.g. "aave.eth"
+	GovernorAddress string `json:"governor_address,omitempty"` // OpenZeppelin Governor / GovernorBravo contract on ChainID
+	// Bridge TVL (token_addresses lists the tokens to sum; "native" for ETH)
+	BridgeAddresses []string `json:"bridge_addresses,omitempty"`
+	WindowMinutes   int      `json:"window_minutes,omitempty"` // Look-back window for drop detection (default 60)
+	// Bitcoin address (one of btc_address / xpub)
+	BTCAddress string `json:"btc_address,omitempty"`
+	Xpub       string `json:"xpub,omitempty"`     // xpub / ypub / zpub account key
+	XpubGap    int    `json:"xpub_gap,omitempty"` // Receive and change addresses to derive (default 20)
+}
+
+// WatchDetail is a labelled value shown in watch alert notifications.
+type WatchDetail struct {
+	Label string `json:"label"`
+	Value string `json:"value"`
+}
+
+// WatchAlertRule defines an alert rule for data sources that don't fit the
+// token / DeFi / prediction market shapes (Safe multisigs, wallets, governance, ...).
+// Discrete-event fields (e.g. Safe "PROPOSED") alert once per event, filtered by
+// Threshold/Direction when a direction is set; measured fields are always
+// compared against Threshold using Direction.
+type WatchAlertRule struct {
+	ID        int64  // MySQL row ID — used for hot-swap matching
+	Source    string // e.g. "safe"
+	ChainID   string
+	Field     string // Source-specific trigger, e.g. "PROPOSED", "QUORUM"
+	Threshold float64
+	Direction Direction // Required for measured fields, optional filter for discrete events
+	Enabled   bool
+	Tags      []string // Free-form lowercase labels, e.g. treasury, that rule lists, mutes and digests go by
+	NotificationSettings
+	LastTriggered *time.Time
+	SnoozedUntil  *time.Time // Alerts are held back until then, e.g. after "Snooze 1h" on a Telegram alert
+	Frequency     *Frequency
+	Label         string // Optional display name (e.g. "Treasury Safe")
+	Params        WatchParams
+	ENSNames      map[string]string // Lower-case resolved address -> configured ENS name
+
+	// seen holds keys of discrete events that were already observed, with when
+	// they were last observed, so each event alerts at most once. Keys the
+	// source stopped returning are forgotten after watchSeenTTL. primed is
+	// false until the first observation batch has been recorded; events that
+	// existed before that are not "new".
+	seen   map[string]time.Time
+	primed bool
+}
+
+// watchSeenTTL is how long a watch rule remembers a discrete event its source
+// no longer returns, e.g. a Safe transaction that fell out of the fetched
+// page. It outlasts any source outage the rule should not re-alert after.
+const watchSeenTTL = 7 * 24 * time.Hour
+
+// DisplayAddress formats an address from the rule's params, including its ENS name if it had one.
+func (r *WatchAlertRule) DisplayAddress(addr string) string {
+	return FormatAddress(r.ENSNames, addr)
+}
+
+// WatchObservation is a single reading from a watch source. Discrete events set
+// Key (e.g. a Safe transaction hash); measured readings leave Key empty and
+// carry Value for threshold comparison.
+type WatchObservation struct {
+	Key       string
+	Value     float64
+	Unlimited bool // Value stands for an unlimited amount, e.g. of an approval, and is math.MaxFloat64
+	Title     string
+	Details   []WatchDetail
+	URL       string
+	// AlertOnPrime marks state-based events (e.g. "voting ends soon") that should
+	// alert even when they already hold on the first pass after startup.
+	AlertOnPrime bool
+}
+
+// WatchAlertDecision represents the result of evaluating a watch rule.
+type WatchAlertDecision struct {
+	ShouldAlert bool
+	Rule        *WatchAlertRule
+	Observation *WatchObservation
+	ChainName   string
+	Message     string
+	TriggeredAt time.Time // When the rule triggered (UTC); with the rule ID it identifies the alert
+}
+
+// AddWatchRule adds a watch alert rule to the engine
+func (e *DecisionEngine) AddWatchRule(rule *WatchAlertRule) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.watchRules = append(e.watchRules, rule)
+}
+
+// GetWatchRules returns a snapshot of all watch alert rules
+func (e *DecisionEngine) GetWatchRules() []*WatchAlertRule {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	cp := make([]*WatchAlertRule, len(e.watchRules))
+	copy(cp, e.watchRules)
+	return cp
+}
+
+// EvaluateWatch evaluates the observations collected for a single watch rule.
+func (e *DecisionEngine) EvaluateWatch(rule *WatchAlertRule, observations []*WatchObservation, chainName string) []*WatchAlertDecision {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.evaluateWatchLocked(rule, observations, chainName)
+}
+
+// evaluateWatchLocked is the lock-free implementation; caller must hold e.mu.
+func (e *DecisionEngine) evaluateWatchLocked(rule *WatchAlertRule, observations []*WatchObservation, chainName string) []*WatchAlertDecision {
+	decisions := make([]*WatchAlertDecision, 0)
+	if !rule.Enabled {
+		return decisions
+	}
+	if rule.seen == nil {
+		rule.seen = make(map[string]time.Time)
+	}
+	observedAt := time.Now()
+	defer forgetUnseen(rule.seen, observedAt)
+	priming := !rule.primed
+	rule.primed = true
+	// A snoozed rule keeps recording events, so the ones that happened while
+	// it was snoozed don't alert once it wakes up
+	snoozing := snoozed(rule.SnoozedUntil)
+
+	name := rule.Label
+	if name == "" {
+		name = rule.Source
+	}
+
+	for _, obs := range observations {
+		var message string
+
+		if obs.Key != "" {
+			// Discrete event: alert once per key once it matches the (optional)
+			// threshold. Matches on the priming pass are only recorded.
+			if _, ok := rule.seen[obs.Key]; ok {
+				rule.seen[obs.Key] = observedAt
+				continue
+			}
+			if rule.Direction != "" && !matchesThreshold(obs.Value, rule.Threshold, rule.Direction, 0.01) {
+				continue
+			}
+			rule.seen[obs.Key] = observedAt
+			if (priming && !obs.AlertOnPrime) || snoozing {
+				continue
+			}
+			if rule.Frequency != nil && rule.Frequency.Unit == FrequencyUnitNever {
+				continue
+			}
+			message = fmt.Sprintf("🚨 Alert: %s on %s - %s", name, chainName, obs.Title)
+		} else {
+			if snoozing || !matchesThreshold(obs.Value, rule.Threshold, rule.Direction, 0.01) {
+				continue
+			}
+			if suppressedByFrequency(rule.Frequency, rule.LastTriggered) {
+				if rule.Frequency != nil && rule.Frequency.Unit == FrequencyUnitOnce {
+					rule.Enabled = false
+				}
+				continue
+			}
+			message = fmt.Sprintf(
+				"🚨 Alert: %s on %s - %s is %g, which is %s threshold of %g",
+				name, chainName, rule.Field, obs.Value, rule.Direction, rule.Threshold,
+			)
+		}
+
+		now := time.Now()
+		decisions = append(decisions, &WatchAlertDecision{
+			ShouldAlert: true,
+			Rule:        rule,
+			Observation: obs,
+			ChainName:   chainName,
+			Message:     message,
+			TriggeredAt: now.UTC(),
+		})
+
+		rule.LastTriggered = &now
+		e.recordTrigger("watch", rule.ID, rule.Frequency)
+	}
+
+	return decisions
+}
+
+// forgetUnseen removes the event keys not observed within watchSeenTTL of now
+func forgetUnseen(seen map[string]time.Time, now time.Time) {
+	for key, last := range seen {
+		if now.Sub(last) > watchSeenTTL {
+			delete(seen, key)
+		}
+	}
+}
+
+// matchesThreshold reports whether value satisfies the direction/threshold comparison.
+// epsilon is the tolerance used for DirectionEqual.
+func matchesThreshold(value, threshold float64, direction Direction, epsilon float64) bool {
+	switch direction {
+	case DirectionGreaterThanOrEqual:
+		return value >= threshold
+	case DirectionGreaterThan:
+		return value > threshold
+	case DirectionEqual:
+		return value >= threshold-epsilon && value <= threshold+epsilon
+	case DirectionLessThanOrEqual:
+		return value <= threshold
+	case DirectionLessThan:
+		return value < threshold
+	}
+	return false
+}
+
+// snoozed reports whether a rule snoozed until until is still snoozed
+func snoozed(until *time.Time) bool {
+	return until != nil && time.Now().Before(*until)
+}
+
+// suppressedByFrequency reports whether an alert should be suppressed given the
+// rule's frequency configuration and last trigger time. Without a frequency,
+// duplicate alerts within one hour are suppressed.
+func suppressedByFrequency(freq *Frequency, lastTriggered *time.Time) bool {
+	if freq == nil {
+		return lastTriggered != nil && time.Since(*lastTriggered) < time.Hour
+	}
+	switch freq.Unit {
+	case FrequencyUnitOnce:
+		return lastTriggered != nil
+	case FrequencyUnitNever:
+		return true
+	case FrequencyUnitDay:
+		return lastTriggered != nil && time.Since(*lastTriggered) < time.Duration(freq.Number)*24*time.Hour
+	case FrequencyUnitHour:
+		return lastTriggered != nil && time.Since(*lastTriggered) < time.Duration(freq.Number)*time.Hour
+	}
+	return false
+}
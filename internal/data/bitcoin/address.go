@@ -0,0 +1,193 @@
+package bitcoin
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/btcutil/hdkeychain"
+	"github.com/btcsuite/btcd/chaincfg"
+)
+
+const (
+	FieldReceived FieldType = "RECEIVED" // A transaction paid into the watched address(es); value is the net BTC received
+	FieldSpent    FieldType = "SPENT"    // A transaction spent from the watched address(es); value is the net BTC sent
+	FieldBalance  FieldType = "BALANCE"  // Total balance in BTC, including unconfirmed transactions
+)
+
+// DefaultXpubGap is how many receive and change addresses are derived from an xpub
+const DefaultXpubGap = 20
+
+const satsPerBTC = 1e8
+
+// AddressTx is a transaction touching the watched address set, with the net
+// flow relative to that set.
+type AddressTx struct {
+	TxID        string
+	Confirmed   bool
+	BlockHeight int64
+	Time        time.Time
+	Received    float64 // BTC paid to watched addresses
+	Spent       float64 // BTC spent from watched addresses
+}
+
+// Net returns the net BTC flow into the watched set (negative when spending)
+func (t *AddressTx) Net() float64 {
+	return t.Received - t.Spent
+}
+
+type esploraTx struct {
+	TxID string `json:"txid"`
+	Vin  []struct {
+		Prevout *struct {
+			Address string `json:"scriptpubkey_address"`
+			Value   int64  `json:"value"`
+		} `json:"prevout"`
+	} `json:"vin"`
+	Vout []struct {
+		Address string `json:"scriptpubkey_address"`
+		Value   int64  `json:"value"`
+	} `json:"vout"`
+	Status struct {
+		Confirmed   bool  `json:"confirmed"`
+		BlockHeight int64 `json:"block_height"`
+		BlockTime   int64 `json:"block_time"`
+	} `json:"status"`
+}
+
+type esploraStats struct {
+	FundedSum int64 `json:"funded_txo_sum"`
+	SpentSum  int64 `json:"spent_txo_sum"`
+}
+
+// GetTransactions returns the recent transactions (mempool plus the latest
+// confirmed page) of all addresses, merged by txid.
+// Route: GET /api/address/{address}/txs
+func (c *Client) GetTransactions(ctx context.Context, addresses []string) ([]*AddressTx, error) {
+	owned := make(map[string]bool, len(addresses))
+	for _, a := range addresses {
+		owned[a] = true
+	}
+
+	seen := make(map[string]bool)
+	txs := make([]*AddressTx, 0)
+	for _, addr := range addresses {
+		var raw []esploraTx
+		if err := c.get(ctx, "/api/address/"+addr+"/txs", &raw); err != nil {
+			return nil, fmt.Errorf("failed to fetch transactions for %s: %w", addr, err)
+		}
+		for _, r := range raw {
+			if seen[r.TxID] {
+				continue
+			}
+			seen[r.TxID] = true
+
+			tx := &AddressTx{
+				TxID:        r.TxID,
+				Confirmed:   r.Status.Confirmed,
+				BlockHeight: r.Status.BlockHeight,
+			}
+			if r.Status.BlockTime > 0 {
+				tx.Time = time.Unix(r.Status.BlockTime, 0)
+			}
+			for _, in := range r.Vin {
+				if in.Prevout != nil && owned[in.Prevout.Address] {
+					tx.Spent += float64(in.Prevout.Value) / satsPerBTC
+				}
+			}
+			for _, out := range r.Vout {
+				if owned[out.Address] {
+					tx.Received += float64(out.Value) / satsPerBTC
+				}
+			}
+			txs = append(txs, tx)
+		}
+	}
+	return txs, nil
+}
+
+// GetBalance returns the total balance of all addresses in BTC, including mempool.
+// Route: GET /api/address/{address}
+func (c *Client) GetBalance(ctx context.Context, addresses []string) (float64, error) {
+	var total int64
+	for _, addr := range addresses {
+		var raw struct {
+			ChainStats   esploraStats `json:"chain_stats"`
+			MempoolStats esploraStats `json:"mempool_stats"`
+		}
+		if err := c.get(ctx, "/api/address/"+addr, &raw); err != nil {
+			return 0, fmt.Errorf("failed to fetch balance for %s: %w", addr, err)
+		}
+		total += raw.ChainStats.FundedSum - raw.ChainStats.SpentSum
+		total += raw.MempoolStats.FundedSum - raw.MempoolStats.SpentSum
+	}
+	return float64(total) / satsPerBTC, nil
+}
+
+// AddressURL returns the explorer link for an address
+func (c *Client) AddressURL(address string) string {
+	return c.apiURL + "/address/" + address
+}
+
+// TxURL returns the explorer link for a transaction
+func (c *Client) TxURL(txID string) string {
+	return c.apiURL + "/tx/" + txID
+}
+
+// DeriveAddresses derives the first gap receive (m/0/i) and change (m/1/i)
+// addresses of an account-level extended public key. The script type follows
+// the key prefix: xpub → P2PKH, ypub → P2SH-P2WPKH, zpub → P2WPKH.
+func DeriveAddresses(xpub string, gap int) ([]string, error) {
+	if gap <= 0 {
+		gap = DefaultXpubGap
+	}
+	key, err := hdkeychain.NewKeyFromString(xpub)
+	if err != nil {
+		return nil, fmt.Errorf("invalid extended public key: %w", err)
+	}
+	if key.IsPrivate() {
+		return nil, fmt.Errorf("extended private keys are not accepted; use the xpub")
+	}
+
+	var encode func(pubKeyHash []byte) (btcutil.Address, error)
+	net := &chaincfg.MainNetParams
+	switch {
+	case strings.HasPrefix(xpub, "xpub"):
+		encode = func(h []byte) (btcutil.Address, error) { return btcutil.NewAddressPubKeyHash(h, net) }
+	case strings.HasPrefix(xpub, "ypub"):
+		encode = func(h []byte) (btcutil.Address, error) {
+			redeem := append([]byte{0x00, 0x14}, h...)
+			return btcutil.NewAddressScriptHash(redeem, net)
+		}
+	case strings.HasPrefix(xpub, "zpub"):
+		encode = func(h []byte) (btcutil.Address, error) { return btcutil.NewAddressWitnessPubKeyHash(h, net) }
+	default:
+		return nil, fmt.Errorf("unsupported extended key prefix %q (supported: xpub, ypub, zpub)", xpub[:min(4, len(xpub))])
+	}
+
+	addresses := make([]string, 0, 2*gap)
+	for _, branch := range []uint32{0, 1} {
+		chain, err := key.Derive(branch)
+		if err != nil {
+			return nil, fmt.Errorf("failed to derive branch %d: %w", branch, err)
+		}
+		for i := 0; i < gap; i++ {
+			child, err := chain.Derive(uint32(i))
+			if err != nil {
+				return nil, fmt.Errorf("failed to derive address %d/%d: %w", branch, i, err)
+			}
+			pub, err := child.ECPubKey()
+			if err != nil {
+				return nil, fmt.Errorf("failed to get public key %d/%d: %w", branch, i, err)
+			}
+			addr, err := encode(btcutil.Hash160(pub.SerializeCompressed()))
+			if err != nil {
+				return nil, fmt.Errorf("failed to encode address %d/%d: %w", branch, i, err)
+			}
+			addresses = append(addresses, addr.EncodeAddress())
+		}
+	}
+	return addresses, nil
+}
@@ -0,0 +1,149 @@
+package bitcoin
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// FieldType represents the Bitcoin metric to alert on
+type FieldType string
+
+const (
+	FieldFeeFastest   FieldType = "FEE_FASTEST"   // Next-block fee estimate (sat/vB)
+	FieldFeeHalfHour  FieldType = "FEE_HALF_HOUR" // ~3 block fee estimate (sat/vB)
+	FieldFeeHour      FieldType = "FEE_HOUR"      // ~6 block fee estimate (sat/vB)
+	FieldFeeEconomy   FieldType = "FEE_ECONOMY"   // Economy fee estimate (sat/vB)
+	FieldMempoolCount FieldType = "MEMPOOL_COUNT" // Unconfirmed transaction count
+	FieldMempoolVSize FieldType = "MEMPOOL_VSIZE" // Mempool size in vMB
+)
+
+// ChainID is the chain_id used by Bitcoin watch rules
+const ChainID = "bitcoin"
+
+// ChainName is the human-readable chain name for Bitcoin
+const ChainName = "Bitcoin"
+
+// DefaultAPIURL is the public mempool.space API (Esplora-compatible)
+const DefaultAPIURL = "https://mempool.space"
+
+// FeeEstimates holds mempool.space recommended fees in sat/vB
+type FeeEstimates struct {
+	Fastest  float64 `json:"fastestFee"`
+	HalfHour float64 `json:"halfHourFee"`
+	Hour     float64 `json:"hourFee"`
+	Economy  float64 `json:"economyFee"`
+	Minimum  float64 `json:"minimumFee"`
+}
+
+// MempoolStats summarizes the current mempool
+type MempoolStats struct {
+	Count    int64   `json:"count"`
+	VSize    int64   `json:"vsize"`     // virtual bytes
+	TotalFee float64 `json:"total_fee"` // sats
+}
+
+// Client handles interactions with a mempool.space / Esplora compatible REST API
+type Client struct {
+	apiURL     string
+	httpClient *http.Client
+}
+
+// NewClient creates a Bitcoin API client. apiURL defaults to mempool.space; any
+// Esplora-compatible base URL (e.g. https://blockstream.info) works for address queries.
+func NewClient(apiURL string) *Client {
+	if apiURL == "" {
+		apiURL = DefaultAPIURL
+	}
+	return &Client{
+		apiURL:     strings.TrimRight(apiURL, "/"),
+		httpClient: &http.Client{Timeout: 15 * time.Second},
+	}
+}
+
+// BaseURL returns the API base URL, which is also the block explorer's web root
+func (c *Client) BaseURL() string {
+	return c.apiURL
+}
+
+// Close closes the HTTP client (no-op, kept for interface consistency)
+func (c *Client) Close() {}
+
+// GetFeeEstimates fetches recommended fees.
+// Route: GET /api/v1/fees/recommended (mempool.space only)
+func (c *Client) GetFeeEstimates(ctx context.Context) (*FeeEstimates, error) {
+	var fees FeeEstimates
+	if err := c.get(ctx, "/api/v1/fees/recommended", &fees); err != nil {
+		return nil, err
+	}
+	return &fees, nil
+}
+
+// GetMempoolStats fetches mempool size.
+// Route: GET /api/mempool
+func (c *Client) GetMempoolStats(ctx context.Context) (*MempoolStats, error) {
+	var stats MempoolStats
+	if err := c.get(ctx, "/api/mempool", &stats); err != nil {
+		return nil, err
+	}
+	return &stats, nil
+}
+
+// GetMetric returns the value for a fee / mempool field
+func (c *Client) GetMetric(ctx context.Context, field FieldType) (float64, error) {
+	switch field {
+	case FieldFeeFastest, FieldFeeHalfHour, FieldFeeHour, FieldFeeEconomy:
+		fees, err := c.GetFeeEstimates(ctx)
+		if err != nil {
+			return 0, err
+		}
+		switch field {
+		case FieldFeeFastest:
+			return fees.Fastest, nil
+		case FieldFeeHalfHour:
+			return fees.HalfHour, nil
+		case FieldFeeHour:
+			return fees.Hour, nil
+		default:
+			return fees.Economy, nil
+		}
+	case FieldMempoolCount, FieldMempoolVSize:
+		stats, err := c.GetMempoolStats(ctx)
+		if err != nil {
+			return 0, err
+		}
+		if field == FieldMempoolCount {
+			return float64(stats.Count), nil
+		}
+		return float64(stats.VSize) / 1e6, nil
+	default:
+		return 0, fmt.Errorf("unsupported Bitcoin field: %s", field)
+	}
+}
+
+func (c *Client) get(ctx context.Context, path string, out any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.apiURL+path, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create HTTP request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to call Bitcoin API: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("Bitcoin API returned status %d: %s", resp.StatusCode, string(body))
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to parse Bitcoin API response: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,52 @@
+package bridge
+
+import (
+	"sync"
+	"time"
+)
+
+type sample struct {
+	at    time.Time
+	value float64
+}
+
+// DropTracker keeps recent balance samples per series so a reading can be
+// compared against the peak of the look-back window.
+type DropTracker struct {
+	mu     sync.Mutex
+	series map[string][]sample
+}
+
+// NewDropTracker creates an empty tracker
+func NewDropTracker() *DropTracker {
+	return &DropTracker{series: make(map[string][]sample)}
+}
+
+// Record stores value for key and returns the percentage drop from the highest
+// value seen within window (0 when the value is at or above the peak).
+func (t *DropTracker) Record(key string, value float64, window time.Duration) (dropPct, peak float64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := time.Now()
+	cutoff := now.Add(-window)
+	kept := t.series[key][:0]
+	for _, s := range t.series[key] {
+		if s.at.After(cutoff) {
+			kept = append(kept, s)
+		}
+	}
+	kept = append(kept, sample{at: now, value: value})
+	t.series[key] = kept
+
+	peak = value
+	for _, s := range kept {
+		if s.value > peak {
+			peak = s.value
+		}
+	}
+	if peak <= 0 {
+		return 0, peak
+	}
+	return (peak - value) / peak * 100, peak
+}
@@ -0,0 +1,222 @@
+package bridge
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"strings"
+	"sync"
+	"time"
+
+	"crypto-alert/internal/utils"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethclient"
+)
+
+// FieldType represents the bridge metric to alert on
+type FieldType string
+
+const (
+	FieldDrop FieldType = "DROP" // Largest % drop of any tracked token's bridge balance vs. its peak within the window
+)
+
+// NativeToken is the token address used in rule params for the chain's native asset (ETH)
+const NativeToken = "native"
+
+// DefaultWindow is the look-back window for DROP when the rule doesn't set one
+const DefaultWindow = time.Hour
+
+// ChainInfo holds chain information
+type ChainInfo struct {
+	ChainID   string
+	ChainName string
+}
+
+// Supported chains (RPC URLs come from ETH_RPC_URL / BASE_RPC_URL / ARB_RPC_URL)
+var supportedChains = map[string]ChainInfo{
+	"1":     {ChainID: "1", ChainName: "Ethereum Mainnet"},
+	"8453":  {ChainID: "8453", ChainName: "Base"},
+	"42161": {ChainID: "42161", ChainName: "Arbitrum One"},
+}
+
+const erc20BalanceABI = `[
+	{"constant":true,"inputs":[{"name":"account","type":"address"}],"name":"balanceOf","outputs":[{"name":"","type":"uint256"}],"stateMutability":"view","type":"function"},
+	{"constant":true,"inputs":[],"name":"symbol","outputs":[{"name":"","type":"string"}],"stateMutability":"view","type":"function"},
+	{"constant":true,"inputs":[],"name":"decimals","outputs":[{"name":"","type":"uint8"}],"stateMutability":"view","type":"function"}
+]`
+
+// TokenBalance is the balance of one token summed across all bridge addresses
+type TokenBalance struct {
+	Token   string // token address or NativeToken
+	Symbol  string
+	Balance float64 // scaled by token decimals
+}
+
+type tokenMeta struct {
+	symbol   string
+	decimals uint8
+}
+
+// Client reads token balances held by bridge contracts on a single chain
+type Client struct {
+	chainID   string
+	chainInfo ChainInfo
+	client    *ethclient.Client
+	erc20     abi.ABI
+
+	mu     sync.Mutex
+	tokens map[common.Address]tokenMeta
+}
+
+// NewClient creates a bridge balance client for the given chain
+func NewClient(chainID string) (*Client, error) {
+	chainInfo, ok := supportedChains[chainID]
+	if !ok {
+		return nil, fmt.Errorf("unsupported chain ID: %s. Supported chains: 1 (Ethereum), 8453 (Base), 42161 (Arbitrum One)", chainID)
+	}
+
+	rpcURL := utils.GetRPCURLForChain(chainID)
+	if rpcURL == "" {
+		return nil, fmt.Errorf("RPC URL not configured for chain %s (%s). Please set the appropriate environment variable (ETH_RPC_URL, BASE_RPC_URL, or ARB_RPC_URL)", chainID, chainInfo.ChainName)
+	}
+
+	client, err := ethclient.Dial(rpcURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to %s RPC: %w", chainInfo.ChainName, err)
+	}
+
+	parsedABI, err := abi.JSON(strings.NewReader(erc20BalanceABI))
+	if err != nil {
+		client.Close()
+		return nil, fmt.Errorf("failed to parse ERC20 ABI: %w", err)
+	}
+
+	return &Client{
+		chainID:   chainID,
+		chainInfo: chainInfo,
+		client:    client,
+		erc20:     parsedABI,
+		tokens:    make(map[common.Address]tokenMeta),
+	}, nil
+}
+
+// GetChainName returns the human-readable chain name
+func (c *Client) GetChainName() string {
+	return c.chainInfo.ChainName
+}
+
+// Close closes the RPC connection
+func (c *Client) Close() {
+	if c.client != nil {
+		c.client.Close()
+	}
+}
+
+// GetBalances returns, for each token, the total balance held by all holders.
+func (c *Client) GetBalances(ctx context.Context, holders, tokens []string) ([]*TokenBalance, error) {
+	balances := make([]*TokenBalance, 0, len(tokens))
+	for _, token := range tokens {
+		total := new(big.Float)
+		var symbol string
+		var decimals uint8
+
+		if strings.EqualFold(token, NativeToken) {
+			symbol, decimals = "ETH", 18
+			for _, h := range holders {
+				bal, err := c.client.BalanceAt(ctx, common.HexToAddress(h), nil)
+				if err != nil {
+					return nil, fmt.Errorf("failed to get native balance of %s: %w", h, err)
+				}
+				total.Add(total, new(big.Float).SetInt(bal))
+			}
+		} else {
+			tokenAddr := common.HexToAddress(token)
+			meta := c.tokenMetadata(ctx, tokenAddr)
+			symbol, decimals = meta.symbol, meta.decimals
+			for _, h := range holders {
+				bal, err := c.balanceOf(ctx, tokenAddr, common.HexToAddress(h))
+				if err != nil {
+					return nil, fmt.Errorf("failed to get %s balance of %s: %w", symbol, h, err)
+				}
+				total.Add(total, new(big.Float).SetInt(bal))
+			}
+		}
+
+		scale := new(big.Float).SetInt(new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(decimals)), nil))
+		value, _ := new(big.Float).Quo(total, scale).Float64()
+		balances = append(balances, &TokenBalance{Token: token, Symbol: symbol, Balance: value})
+	}
+	return balances, nil
+}
+
+func (c *Client) balanceOf(ctx context.Context, token, holder common.Address) (*big.Int, error) {
+	data, err := c.erc20.Pack("balanceOf", holder)
+	if err != nil {
+		return nil, fmt.Errorf("failed to pack balanceOf: %w", err)
+	}
+	result, err := c.client.CallContract(ctx, ethereum.CallMsg{To: &token, Data: data}, nil)
+	if err != nil {
+		return nil, err
+	}
+	out, err := c.erc20.Methods["balanceOf"].Outputs.UnpackValues(result)
+	if err != nil || len(out) == 0 {
+		return nil, fmt.Errorf("failed to unpack balanceOf: %v", err)
+	}
+	bal, ok := out[0].(*big.Int)
+	if !ok {
+		return nil, fmt.Errorf("unexpected balanceOf type")
+	}
+	return bal, nil
+}
+
+// tokenMetadata returns cached symbol/decimals for a token, falling back to the
+// address and 18 decimals when the contract doesn't implement the metadata calls.
+func (c *Client) tokenMetadata(ctx context.Context, token common.Address) tokenMeta {
+	c.mu.Lock()
+	if m, ok := c.tokens[token]; ok {
+		c.mu.Unlock()
+		return m
+	}
+	c.mu.Unlock()
+
+	meta := tokenMeta{symbol: token.Hex(), decimals: 18}
+	if out, err := c.call(ctx, token, "symbol"); err == nil && len(out) > 0 {
+		if sym, ok := out[0].(string); ok && sym != "" {
+			meta.symbol = sym
+		}
+	}
+	if out, err := c.call(ctx, token, "decimals"); err == nil && len(out) > 0 {
+		if d, ok := out[0].(uint8); ok {
+			meta.decimals = d
+		}
+	}
+
+	c.mu.Lock()
+	c.tokens[token] = meta
+	c.mu.Unlock()
+	return meta
+}
+
+func (c *Client) call(ctx context.Context, to common.Address, method string) ([]interface{}, error) {
+	m, ok := c.erc20.Methods[method]
+	if !ok {
+		return nil, fmt.Errorf("%s method not found in ERC20 ABI", method)
+	}
+	result, err := c.client.CallContract(ctx, ethereum.CallMsg{To: &to, Data: m.ID}, nil)
+	if err != nil {
+		return nil, err
+	}
+	return m.Outputs.UnpackValues(result)
+}
+
+// GetChainNameFromID returns the chain name for a given chain ID
+func GetChainNameFromID(chainID string) (string, error) {
+	chainInfo, ok := supportedChains[chainID]
+	if !ok {
+		return "", fmt.Errorf("unsupported chain ID: %s", chainID)
+	}
+	return chainInfo.ChainName, nil
+}
@@ -3,7 +3,9 @@ package defi
 import (
 	"context"
 	"fmt"
-	"log"
+	"log/slog"
+	"strings"
+	"sync/atomic"
 
 	"crypto-alert/internal/core"
 
@@ -14,6 +16,7 @@ import (
 	"crypto-alert/internal/data/defi/kamino"
 	"crypto-alert/internal/data/defi/morpho"
 	"crypto-alert/internal/data/defi/pendle"
+	"crypto-alert/internal/logger"
 )
 
 // ClientManager manages DeFi protocol clients
@@ -29,6 +32,14 @@ type clientKey struct {
 	identifier string // Unique identifier: market_id for Morpho markets, vault_token_address for vaults, token_address for Aave
 }
 
+// activeClients counts the clients the managers have open, see ActiveClients
+var activeClients atomic.Int64
+
+// ActiveClients returns how many DeFi clients are open across all the client managers
+func ActiveClients() int64 {
+	return activeClients.Load()
+}
+
 // NewClientManager creates a new client manager
 func NewClientManager() *ClientManager {
 	return &ClientManager{
@@ -70,6 +81,14 @@ func (cm *ClientManager) Close() {
 			}
 		}
 	}
+	activeClients.Add(-int64(len(cm.clients)))
+	clear(cm.clients)
+}
+
+// add keeps a client for the next rules of the same key
+func (cm *ClientManager) add(key clientKey, client interface{}) {
+	cm.clients[key] = client
+	activeClients.Add(1)
 }
 
 // GetFieldValue fetches the field value for a DeFi rule
@@ -87,7 +106,7 @@ func (cm *ClientManager) GetFieldValue(ctx context.Context, rule *core.DeFiAlert
 			if err != nil {
 				return 0, "", fmt.Errorf("failed to create Aave client for chain %s: %w", rule.ChainID, err)
 			}
-			cm.clients[key] = client
+			cm.add(key, client)
 		}
 
 		chainName, err = aave.GetChainNameFromID(rule.ChainID)
@@ -117,7 +136,7 @@ func (cm *ClientManager) GetFieldValue(ctx context.Context, rule *core.DeFiAlert
 				if err != nil {
 					return 0, "", fmt.Errorf("failed to create Morpho market client for chain %s: %w", rule.ChainID, err)
 				}
-				cm.clients[key] = client
+				cm.add(key, client)
 			}
 
 			chainName, err = morpho.GetChainNameFromID(rule.ChainID)
@@ -151,7 +170,7 @@ func (cm *ClientManager) GetFieldValue(ctx context.Context, rule *core.DeFiAlert
 				if err != nil {
 					return 0, "", fmt.Errorf("failed to create Morpho vault client for chain %s: %w", rule.ChainID, err)
 				}
-				cm.clients[key] = client
+				cm.add(key, client)
 			}
 
 			chainName, err = morpho.GetChainNameFromID(rule.ChainID)
@@ -191,7 +210,7 @@ func (cm *ClientManager) GetFieldValue(ctx context.Context, rule *core.DeFiAlert
 				if err != nil {
 					return 0, "", fmt.Errorf("failed to create Morpho v2 vault client for chain %s: %w", rule.ChainID, err)
 				}
-				cm.clients[key] = client
+				cm.add(key, client)
 			}
 
 			chainName, err = morpho.GetChainNameFromID(rule.ChainID)
@@ -231,7 +250,7 @@ func (cm *ClientManager) GetFieldValue(ctx context.Context, rule *core.DeFiAlert
 				if err != nil {
 					return 0, "", fmt.Errorf("failed to create Kamino vault client for chain %s: %w", rule.ChainID, err)
 				}
-				cm.clients[key] = client
+				cm.add(key, client)
 			}
 
 			chainName, err = kamino.GetChainNameFromID(rule.ChainID)
@@ -267,7 +286,7 @@ func (cm *ClientManager) GetFieldValue(ctx context.Context, rule *core.DeFiAlert
 				if err != nil {
 					return 0, "", fmt.Errorf("failed to create Pendle client for chain %s: %w", rule.ChainID, err)
 				}
-				cm.clients[key] = client
+				cm.add(key, client)
 			}
 
 			chainName, err = pendle.GetChainNameFromID(rule.ChainID)
@@ -306,7 +325,7 @@ func (cm *ClientManager) GetFieldValue(ctx context.Context, rule *core.DeFiAlert
 				if err != nil {
 					return 0, "", fmt.Errorf("failed to create Hyperliquid vault client: %w", err)
 				}
-				cm.clients[key] = client
+				cm.add(key, client)
 			}
 
 			chainName, err = hyperliquid.GetChainNameFromID(rule.ChainID)
@@ -397,16 +416,14 @@ func LogDeFiRules(rules []*core.DeFiAlertRule) {
 		return
 	}
 
-	log.Printf("📊 Monitoring DeFi protocols: %d rule(s)", len(rules))
+	slog.Info("📊 Monitoring DeFi protocols", "rules", len(rules))
 	for _, rule := range rules {
 		if rule.Enabled {
 			chainName, err := GetChainName(rule.Protocol, rule.ChainID)
 			if err != nil {
 				chainName = rule.ChainID
 			}
-			categoryStr := GetCategoryString(rule)
-			displayName := GetDisplayName(rule)
-			log.Printf("  - %s%s %s on %s (%s)%s: %s", rule.Protocol, categoryStr, rule.Version, chainName, rule.ChainID, displayName, rule.Field)
+			slog.Info("📊 Watching", logger.FieldSymbol, rule.Protocol, "category", rule.Category, "version", rule.Version, logger.FieldChain, chainName, "chain_id", rule.ChainID, "market", strings.Trim(GetDisplayName(rule), " ()"), "field", rule.Field)
 		}
 	}
 }
@@ -0,0 +1,176 @@
+package ens
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	"crypto-alert/internal/logger"
+	"crypto-alert/internal/utils"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethclient"
+)
+
+// registryAddress is the ENS registry on Ethereum mainnet
+var registryAddress = common.HexToAddress("0x00000000000C2E074eC69A0bFb2997BA6C7d2e1e")
+
+var (
+	resolverSelector = crypto.Keccak256([]byte("resolver(bytes32)"))[:4]
+	addrSelector     = crypto.Keccak256([]byte("addr(bytes32)"))[:4]
+)
+
+// IsName reports whether s looks like an ENS name rather than a hex address
+func IsName(s string) bool {
+	s = strings.TrimSpace(s)
+	return s != "" && !common.IsHexAddress(s) && strings.Contains(s, ".")
+}
+
+// HasName reports whether any of the fields holds an ENS name
+func HasName(fields ...*string) bool {
+	for _, f := range fields {
+		if f != nil && IsName(*f) {
+			return true
+		}
+	}
+	return false
+}
+
+// Namehash computes the EIP-137 namehash of an ENS name
+func Namehash(name string) common.Hash {
+	var node common.Hash
+	if name == "" {
+		return node
+	}
+	labels := strings.Split(strings.ToLower(strings.TrimSpace(name)), ".")
+	for i := len(labels) - 1; i >= 0; i-- {
+		label := crypto.Keccak256Hash([]byte(labels[i]))
+		node = crypto.Keccak256Hash(node.Bytes(), label.Bytes())
+	}
+	return node
+}
+
+type cacheEntry struct {
+	address    common.Address
+	resolvedAt time.Time
+}
+
+// Resolver resolves ENS names through the mainnet registry. Results are cached
+// for ttl, so calling Resolve on every rule reload re-resolves names at most
+// once per ttl.
+type Resolver struct {
+	client *ethclient.Client
+	ttl    time.Duration
+
+	mu    sync.Mutex
+	cache map[string]cacheEntry
+}
+
+// NewResolver creates a resolver using the Ethereum mainnet RPC (ETH_RPC_URL)
+func NewResolver(ttl time.Duration) (*Resolver, error) {
+	rpcURL := utils.GetRPCURLForChain("1")
+	if rpcURL == "" {
+		return nil, fmt.Errorf("RPC URL not configured for chain 1 (Ethereum Mainnet). Please set ETH_RPC_URL to resolve ENS names")
+	}
+	client, err := ethclient.Dial(rpcURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to Ethereum Mainnet RPC: %w", err)
+	}
+	return &Resolver{
+		client: client,
+		ttl:    ttl,
+		cache:  make(map[string]cacheEntry),
+	}, nil
+}
+
+// Close closes the RPC connection
+func (r *Resolver) Close() {
+	if r.client != nil {
+		r.client.Close()
+	}
+}
+
+// Resolve returns the address an ENS name points to
+func (r *Resolver) Resolve(ctx context.Context, name string) (common.Address, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+
+	r.mu.Lock()
+	cached, ok := r.cache[name]
+	r.mu.Unlock()
+	if ok && time.Since(cached.resolvedAt) < r.ttl {
+		return cached.address, nil
+	}
+
+	address, err := r.lookup(ctx, name)
+	if err != nil {
+		if ok {
+			// Keep using the last known address while the name can't be re-resolved.
+			slog.Warn("⚠️  ENS re-resolution failed, keeping the cached address", "name", name, "address", cached.address.Hex(), logger.FieldError, err)
+			return cached.address, nil
+		}
+		return common.Address{}, err
+	}
+
+	r.mu.Lock()
+	r.cache[name] = cacheEntry{address: address, resolvedAt: time.Now()}
+	r.mu.Unlock()
+	return address, nil
+}
+
+func (r *Resolver) lookup(ctx context.Context, name string) (common.Address, error) {
+	node := Namehash(name)
+	out, err := r.call(ctx, registryAddress, resolverSelector, node)
+	if err != nil {
+		return common.Address{}, fmt.Errorf("failed to look up resolver for %s: %w", name, err)
+	}
+	resolver := common.BytesToAddress(out)
+	if resolver == (common.Address{}) {
+		return common.Address{}, fmt.Errorf("ENS name %s has no resolver", name)
+	}
+
+	out, err = r.call(ctx, resolver, addrSelector, node)
+	if err != nil {
+		return common.Address{}, fmt.Errorf("failed to resolve %s: %w", name, err)
+	}
+	address := common.BytesToAddress(out)
+	if address == (common.Address{}) {
+		return common.Address{}, fmt.Errorf("ENS name %s does not resolve to an address", name)
+	}
+	return address, nil
+}
+
+// ResolveFields replaces every field holding an ENS name with the resolved
+// checksummed address and records address → name in names (lower-case keys),
+// so notifications can show both. Fields that already hold addresses are left as is.
+func (r *Resolver) ResolveFields(ctx context.Context, names map[string]string, fields ...*string) error {
+	for _, f := range fields {
+		if f == nil || !IsName(*f) {
+			continue
+		}
+		name := strings.TrimSpace(*f)
+		address, err := r.Resolve(ctx, name)
+		if err != nil {
+			return err
+		}
+		*f = address.Hex()
+		names[strings.ToLower(address.Hex())] = name
+	}
+	return nil
+}
+
+func (r *Resolver) call(ctx context.Context, to common.Address, selector []byte, node common.Hash) ([]byte, error) {
+	data := append(append([]byte{}, selector...), node.Bytes()...)
+	out, err := r.client.CallContract(ctx, ethereum.CallMsg{To: &to, Data: data}, nil)
+	if err != nil {
+		return nil, err
+	}
+	if len(out) < 32 {
+		return nil, fmt.Errorf("unexpected response length %d", len(out))
+	}
+	return out[:32], nil
+}
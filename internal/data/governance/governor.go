@@ -0,0 +1,340 @@
+package governance
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"strings"
+	"sync"
+	"time"
+
+	"crypto-alert/internal/utils"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethclient"
+)
+
+// ChainInfo holds chain information for on-chain Governor contracts
+type ChainInfo struct {
+	ChainID   string
+	ChainName string
+	// BlockTime is the average time between blocks returned by eth_blockNumber.
+	BlockTime time.Duration
+	// ClockBlockTime is the time between ticks of a block-number based governor clock.
+	// On Arbitrum block.number is the L1 block number, so it differs from BlockTime.
+	ClockBlockTime time.Duration
+	ExplorerURL    string
+}
+
+// Supported chains (RPC URLs come from ETH_RPC_URL / BASE_RPC_URL / ARB_RPC_URL)
+var supportedChains = map[string]ChainInfo{
+	"1":     {ChainID: "1", ChainName: "Ethereum Mainnet", BlockTime: 12 * time.Second, ClockBlockTime: 12 * time.Second, ExplorerURL: "https://etherscan.io"},
+	"8453":  {ChainID: "8453", ChainName: "Base", BlockTime: 2 * time.Second, ClockBlockTime: 2 * time.Second, ExplorerURL: "https://basescan.org"},
+	"42161": {ChainID: "42161", ChainName: "Arbitrum One", BlockTime: 250 * time.Millisecond, ClockBlockTime: 12 * time.Second, ExplorerURL: "https://arbiscan.io"},
+}
+
+// governorBlockRange caps a single eth_getLogs query. Queries are filtered by
+// one contract address, so a wider range than the approval scanner is fine.
+const governorBlockRange = 10000
+
+// governorLookback is how far back the first scan looks for proposals, so that
+// proposals created before startup can still trigger ENDING alerts.
+const governorLookback = 3 * 24 * time.Hour
+
+// governorStateActive is the ProposalState enum value for Active (OpenZeppelin Governor and GovernorBravo)
+const governorStateActive = 1
+
+var governorStateNames = []string{"pending", "active", "canceled", "defeated", "succeeded", "queued", "expired", "executed"}
+
+// governorABI covers OpenZeppelin Governor and Compound GovernorBravo; both emit the same ProposalCreated event.
+const governorABI = `[
+	{"anonymous":false,"inputs":[{"indexed":false,"name":"proposalId","type":"uint256"},{"indexed":false,"name":"proposer","type":"address"},{"indexed":false,"name":"targets","type":"address[]"},{"indexed":false,"name":"values","type":"uint256[]"},{"indexed":false,"name":"signatures","type":"string[]"},{"indexed":false,"name":"calldatas","type":"bytes[]"},{"indexed":false,"name":"voteStart","type":"uint256"},{"indexed":false,"name":"voteEnd","type":"uint256"},{"indexed":false,"name":"description","type":"string"}],"name":"ProposalCreated","type":"event"},
+	{"inputs":[{"name":"proposalId","type":"uint256"}],"name":"state","outputs":[{"name":"","type":"uint8"}],"stateMutability":"view","type":"function"},
+	{"inputs":[],"name":"clock","outputs":[{"name":"","type":"uint48"}],"stateMutability":"view","type":"function"},
+	{"inputs":[],"name":"CLOCK_MODE","outputs":[{"name":"","type":"string"}],"stateMutability":"view","type":"function"}
+]`
+
+type governorProposal struct {
+	id          *big.Int
+	proposer    common.Address
+	voteEnd     uint64
+	description string
+	txHash      string
+}
+
+// governorCursor is the scan state for one watched governor contract
+type governorCursor struct {
+	next      uint64
+	proposals map[string]*governorProposal // proposal ID -> proposal, pruned once no longer pending/active
+}
+
+// GovernorClient scans on-chain Governor contracts for proposals on a single chain
+type GovernorClient struct {
+	chainID   string
+	chainInfo ChainInfo
+	client    *ethclient.Client
+	abi       abi.ABI
+
+	mu      sync.Mutex
+	cursors map[string]*governorCursor
+}
+
+// NewGovernorClient creates a Governor scanner for the given chain
+func NewGovernorClient(chainID string) (*GovernorClient, error) {
+	chainInfo, ok := supportedChains[chainID]
+	if !ok {
+		return nil, fmt.Errorf("unsupported chain ID: %s. Supported chains: 1 (Ethereum), 8453 (Base), 42161 (Arbitrum One)", chainID)
+	}
+
+	rpcURL := utils.GetRPCURLForChain(chainID)
+	if rpcURL == "" {
+		return nil, fmt.Errorf("RPC URL not configured for chain %s (%s). Please set the appropriate environment variable (ETH_RPC_URL, BASE_RPC_URL, or ARB_RPC_URL)", chainID, chainInfo.ChainName)
+	}
+
+	client, err := ethclient.Dial(rpcURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to %s RPC: %w", chainInfo.ChainName, err)
+	}
+
+	parsedABI, err := abi.JSON(strings.NewReader(governorABI))
+	if err != nil {
+		client.Close()
+		return nil, fmt.Errorf("failed to parse Governor ABI: %w", err)
+	}
+
+	return &GovernorClient{
+		chainID:   chainID,
+		chainInfo: chainInfo,
+		client:    client,
+		abi:       parsedABI,
+		cursors:   make(map[string]*governorCursor),
+	}, nil
+}
+
+// GetChainName returns the human-readable chain name
+func (c *GovernorClient) GetChainName() string {
+	return c.chainInfo.ChainName
+}
+
+// Close closes the RPC connection
+func (c *GovernorClient) Close() {
+	if c.client != nil {
+		c.client.Close()
+	}
+}
+
+// GetProposals scans for ProposalCreated events since the previous call with the same
+// cursorKey and returns the newly created proposals plus all still open proposals.
+// The first call backfills governorLookback so proposals already in voting are known.
+func (c *GovernorClient) GetProposals(ctx context.Context, cursorKey, governor string) (created, open []*Proposal, err error) {
+	head, err := c.client.BlockNumber(ctx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get block number: %w", err)
+	}
+
+	c.mu.Lock()
+	cur, ok := c.cursors[cursorKey]
+	backfill := !ok
+	if backfill {
+		lookback := uint64(governorLookback / c.chainInfo.BlockTime)
+		start := uint64(0)
+		if head > lookback {
+			start = head - lookback
+		}
+		// Registered only after the backfill succeeds, so a failed backfill is retried in full.
+		cur = &governorCursor{next: start, proposals: make(map[string]*governorProposal)}
+	}
+	c.mu.Unlock()
+
+	address := common.HexToAddress(governor)
+	event := c.abi.Events["ProposalCreated"]
+
+	var newProposals []*governorProposal
+	for from := cur.next; from <= head; {
+		to := head
+		if to-from+1 > governorBlockRange {
+			to = from + governorBlockRange - 1
+		}
+
+		logs, err := c.client.FilterLogs(ctx, ethereum.FilterQuery{
+			FromBlock: new(big.Int).SetUint64(from),
+			ToBlock:   new(big.Int).SetUint64(to),
+			Addresses: []common.Address{address},
+			Topics:    [][]common.Hash{{event.ID}},
+		})
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to filter ProposalCreated logs in blocks %d-%d: %w", from, to, err)
+		}
+		for _, l := range logs {
+			if l.Removed {
+				continue
+			}
+			values, err := event.Inputs.Unpack(l.Data)
+			if err != nil || len(values) != 9 {
+				continue
+			}
+			id, _ := values[0].(*big.Int)
+			proposer, _ := values[1].(common.Address)
+			voteEnd, _ := values[7].(*big.Int)
+			description, _ := values[8].(string)
+			if id == nil || voteEnd == nil {
+				continue
+			}
+			newProposals = append(newProposals, &governorProposal{
+				id:          id,
+				proposer:    proposer,
+				voteEnd:     voteEnd.Uint64(),
+				description: description,
+				txHash:      l.TxHash.Hex(),
+			})
+		}
+
+		cur.next = to + 1
+		from = to + 1
+		if !backfill {
+			break // after the backfill each tick scans at most one chunk
+		}
+	}
+
+	c.mu.Lock()
+	c.cursors[cursorKey] = cur
+	for _, p := range newProposals {
+		cur.proposals[p.id.String()] = p
+	}
+	tracked := make([]*governorProposal, 0, len(cur.proposals))
+	for _, p := range cur.proposals {
+		tracked = append(tracked, p)
+	}
+	c.mu.Unlock()
+
+	now, clockTick, err := c.clock(ctx, address, head)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	isNew := make(map[string]bool, len(newProposals))
+	for _, p := range newProposals {
+		isNew[p.id.String()] = true
+	}
+
+	for _, p := range tracked {
+		state, err := c.state(ctx, address, p.id)
+		if err != nil {
+			continue
+		}
+		stateName := "unknown"
+		if int(state) < len(governorStateNames) {
+			stateName = governorStateNames[state]
+		}
+
+		end := time.Now()
+		if p.voteEnd > now {
+			end = end.Add(time.Duration(p.voteEnd-now) * clockTick)
+		}
+		proposal := &Proposal{
+			ID:       p.id.String(),
+			Title:    proposalTitle(p.description),
+			Author:   p.proposer.Hex(),
+			State:    stateName,
+			End:      end,
+			URL:      c.chainInfo.ExplorerURL + "/tx/" + p.txHash,
+			Platform: "governor",
+		}
+
+		if isNew[proposal.ID] {
+			created = append(created, proposal)
+		}
+		if state <= governorStateActive {
+			if state == governorStateActive {
+				open = append(open, proposal)
+			}
+		} else {
+			c.mu.Lock()
+			delete(cur.proposals, proposal.ID)
+			c.mu.Unlock()
+		}
+	}
+	return created, open, nil
+}
+
+// clock returns the governor's current clock value and the duration of one tick.
+// Governors implementing IERC6372 may use timestamps; older ones (GovernorBravo) use block numbers.
+func (c *GovernorClient) clock(ctx context.Context, governor common.Address, head uint64) (uint64, time.Duration, error) {
+	out, err := c.call(ctx, governor, "clock")
+	if err != nil {
+		return head, c.chainInfo.BlockTime, nil
+	}
+	now, ok := out[0].(*big.Int)
+	if !ok {
+		return 0, 0, fmt.Errorf("unexpected clock() response from governor %s", governor.Hex())
+	}
+	if mode, err := c.call(ctx, governor, "CLOCK_MODE"); err == nil {
+		if s, ok := mode[0].(string); ok && strings.Contains(s, "mode=timestamp") {
+			return now.Uint64(), time.Second, nil
+		}
+	}
+	return now.Uint64(), c.chainInfo.ClockBlockTime, nil
+}
+
+func (c *GovernorClient) state(ctx context.Context, governor common.Address, proposalID *big.Int) (uint8, error) {
+	data, err := c.abi.Pack("state", proposalID)
+	if err != nil {
+		return 0, err
+	}
+	result, err := c.client.CallContract(ctx, ethereum.CallMsg{To: &governor, Data: data}, nil)
+	if err != nil {
+		return 0, err
+	}
+	out, err := c.abi.Methods["state"].Outputs.UnpackValues(result)
+	if err != nil || len(out) == 0 {
+		return 0, fmt.Errorf("failed to unpack state(): %v", err)
+	}
+	s, ok := out[0].(uint8)
+	if !ok {
+		return 0, fmt.Errorf("unexpected state() type")
+	}
+	return s, nil
+}
+
+func (c *GovernorClient) call(ctx context.Context, to common.Address, method string) ([]interface{}, error) {
+	m, ok := c.abi.Methods[method]
+	if !ok {
+		return nil, fmt.Errorf("%s method not found in Governor ABI", method)
+	}
+	result, err := c.client.CallContract(ctx, ethereum.CallMsg{To: &to, Data: m.ID}, nil)
+	if err != nil {
+		return nil, err
+	}
+	out, err := m.Outputs.UnpackValues(result)
+	if err != nil {
+		return nil, err
+	}
+	if len(out) == 0 {
+		return nil, fmt.Errorf("empty %s response", method)
+	}
+	return out, nil
+}
+
+// proposalTitle returns the first line of a proposal description, without a leading markdown heading.
+func proposalTitle(description string) string {
+	title := strings.TrimSpace(strings.SplitN(strings.TrimSpace(description), "\n", 2)[0])
+	title = strings.TrimSpace(strings.TrimLeft(title, "#"))
+	if len(title) > 120 {
+		title = title[:117] + "..."
+	}
+	if title == "" {
+		title = "(untitled proposal)"
+	}
+	return title
+}
+
+// GetChainNameFromID returns the chain name for a given chain ID
+func GetChainNameFromID(chainID string) (string, error) {
+	chainInfo, ok := supportedChains[chainID]
+	if !ok {
+		return "", fmt.Errorf("unsupported chain ID: %s", chainID)
+	}
+	return chainInfo.ChainName, nil
+}
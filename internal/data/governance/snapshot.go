@@ -0,0 +1,155 @@
+package governance
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// FieldType represents the governance event to alert on
+type FieldType string
+
+const (
+	FieldCreated FieldType = "CREATED" // A new proposal was created
+	FieldEnding  FieldType = "ENDING"  // An active proposal's voting period ends within the rule threshold (hours)
+)
+
+// DefaultSnapshotURL is the public Snapshot Hub GraphQL endpoint
+const DefaultSnapshotURL = "https://hub.snapshot.org/graphql"
+
+// Proposal is a governance proposal from Snapshot or an on-chain Governor
+type Proposal struct {
+	ID       string
+	Title    string
+	Author   string
+	State    string // "pending", "active", "closed", ...
+	Start    time.Time
+	End      time.Time
+	URL      string
+	Choices  []string
+	Scores   []float64
+	Quorum   float64
+	TotalVP  float64
+	Platform string // "snapshot" or "governor"
+}
+
+// SnapshotClient queries the Snapshot Hub GraphQL API
+type SnapshotClient struct {
+	apiURL     string
+	apiKey     string
+	httpClient *http.Client
+}
+
+// NewSnapshotClient creates a Snapshot Hub client. apiKey is optional and raises rate limits.
+func NewSnapshotClient(apiURL, apiKey string) *SnapshotClient {
+	if apiURL == "" {
+		apiURL = DefaultSnapshotURL
+	}
+	return &SnapshotClient{
+		apiURL:     apiURL,
+		apiKey:     apiKey,
+		httpClient: &http.Client{Timeout: 15 * time.Second},
+	}
+}
+
+const snapshotProposalsQuery = `query Proposals($space: String!, $first: Int!) {
+  proposals(first: $first, skip: 0, where: {space: $space}, orderBy: "created", orderDirection: desc) {
+    id
+    title
+    author
+    state
+    start
+    end
+    link
+    choices
+    scores
+    scores_total
+    quorum
+  }
+}`
+
+// GetRecentProposals returns the most recently created proposals for a Snapshot space (e.g. "aave.eth")
+func (c *SnapshotClient) GetRecentProposals(ctx context.Context, space string, first int) ([]*Proposal, error) {
+	payload, err := json.Marshal(map[string]any{
+		"query":     snapshotProposalsQuery,
+		"variables": map[string]any{"space": space, "first": first},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal Snapshot query: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.apiURL, bytes.NewReader(payload))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create HTTP request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+	if c.apiKey != "" {
+		req.Header.Set("x-api-key", c.apiKey)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to call Snapshot Hub: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("Snapshot Hub returned status %d: %s", resp.StatusCode, string(body))
+	}
+
+	var raw struct {
+		Data struct {
+			Proposals []struct {
+				ID          string    `json:"id"`
+				Title       string    `json:"title"`
+				Author      string    `json:"author"`
+				State       string    `json:"state"`
+				Start       int64     `json:"start"`
+				End         int64     `json:"end"`
+				Link        string    `json:"link"`
+				Choices     []string  `json:"choices"`
+				Scores      []float64 `json:"scores"`
+				ScoresTotal float64   `json:"scores_total"`
+				Quorum      float64   `json:"quorum"`
+			} `json:"proposals"`
+		} `json:"data"`
+		Errors []struct {
+			Message string `json:"message"`
+		} `json:"errors"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&raw); err != nil {
+		return nil, fmt.Errorf("failed to parse Snapshot Hub response: %w", err)
+	}
+	if len(raw.Errors) > 0 {
+		return nil, fmt.Errorf("Snapshot Hub query error: %s", raw.Errors[0].Message)
+	}
+
+	proposals := make([]*Proposal, 0, len(raw.Data.Proposals))
+	for _, p := range raw.Data.Proposals {
+		link := p.Link
+		if link == "" {
+			link = fmt.Sprintf("https://snapshot.org/#/%s/proposal/%s", space, p.ID)
+		}
+		proposals = append(proposals, &Proposal{
+			ID:       p.ID,
+			Title:    p.Title,
+			Author:   p.Author,
+			State:    p.State,
+			Start:    time.Unix(p.Start, 0),
+			End:      time.Unix(p.End, 0),
+			URL:      link,
+			Choices:  p.Choices,
+			Scores:   p.Scores,
+			Quorum:   p.Quorum,
+			TotalVP:  p.ScoresTotal,
+			Platform: "snapshot",
+		})
+	}
+	return proposals, nil
+}
@@ -0,0 +1,247 @@
+package safe
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// FieldType represents the Safe event to alert on
+type FieldType string
+
+const (
+	FieldProposed FieldType = "PROPOSED" // A new transaction was proposed to the Safe
+	FieldQuorum   FieldType = "QUORUM"   // A pending transaction collected enough confirmations to execute
+)
+
+// ChainInfo holds chain information for the Safe Transaction Service
+type ChainInfo struct {
+	ChainID      string
+	ChainName    string
+	TxServiceURL string
+}
+
+// Supported chains and their Safe Transaction Service endpoints
+var supportedChains = map[string]ChainInfo{
+	"1": {
+		ChainID:      "1",
+		ChainName:    "Ethereum",
+		TxServiceURL: "https://safe-transaction-mainnet.safe.global",
+	},
+	"10": {
+		ChainID:      "10",
+		ChainName:    "Optimism",
+		TxServiceURL: "https://safe-transaction-optimism.safe.global",
+	},
+	"137": {
+		ChainID:      "137",
+		ChainName:    "Polygon",
+		TxServiceURL: "https://safe-transaction-polygon.safe.global",
+	},
+	"8453": {
+		ChainID:      "8453",
+		ChainName:    "Base",
+		TxServiceURL: "https://safe-transaction-base.safe.global",
+	},
+	"42161": {
+		ChainID:      "42161",
+		ChainName:    "Arbitrum One",
+		TxServiceURL: "https://safe-transaction-arbitrum.safe.global",
+	},
+}
+
+// SafeInfo holds the on-chain state of a Safe
+type SafeInfo struct {
+	Address   string
+	Nonce     int64
+	Threshold int
+	Owners    []string
+}
+
+// PendingTransaction is a proposed, not yet executed Safe transaction
+type PendingTransaction struct {
+	SafeTxHash            string
+	Nonce                 int64
+	To                    string
+	Value                 string
+	Method                string // Decoded method name, if the service could decode the calldata
+	ConfirmationsRequired int
+	Confirmations         int
+	Proposer              string
+	SubmissionDate        time.Time
+}
+
+// Client handles interactions with the Safe Transaction Service REST API
+type Client struct {
+	chainID    string
+	chainInfo  ChainInfo
+	httpClient *http.Client
+	apiKey     string
+}
+
+// NewClient creates a Safe Transaction Service client for the given chain.
+// txServiceURL overrides the default endpoint (e.g. a self-hosted service); apiKey is optional.
+func NewClient(chainID, txServiceURL, apiKey string) (*Client, error) {
+	chainInfo, ok := supportedChains[chainID]
+	if !ok {
+		if txServiceURL == "" {
+			return nil, fmt.Errorf("unsupported chain ID: %s. Supported chains: 1 (Ethereum), 10 (Optimism), 137 (Polygon), 8453 (Base), 42161 (Arbitrum One)", chainID)
+		}
+		chainInfo = ChainInfo{ChainID: chainID, ChainName: "Chain " + chainID}
+	}
+	if txServiceURL != "" {
+		chainInfo.TxServiceURL = strings.TrimRight(txServiceURL, "/")
+	}
+
+	return &Client{
+		chainID:    chainID,
+		chainInfo:  chainInfo,
+		httpClient: &http.Client{Timeout: 15 * time.Second},
+		apiKey:     apiKey,
+	}, nil
+}
+
+// Close closes the HTTP client (no-op, kept for interface consistency)
+func (c *Client) Close() {}
+
+// GetChainName returns the human-readable chain name
+func (c *Client) GetChainName() string {
+	return c.chainInfo.ChainName
+}
+
+// GetSafeInfo fetches nonce, threshold, and owners for a Safe.
+// Route: GET /api/v1/safes/{address}/
+func (c *Client) GetSafeInfo(ctx context.Context, safeAddress string) (*SafeInfo, error) {
+	addr := common.HexToAddress(safeAddress).Hex() // the service requires checksummed addresses
+	var raw struct {
+		Address   string      `json:"address"`
+		Nonce     json.Number `json:"nonce"`
+		Threshold int         `json:"threshold"`
+		Owners    []string    `json:"owners"`
+	}
+	if err := c.get(ctx, fmt.Sprintf("/api/v1/safes/%s/", addr), &raw); err != nil {
+		return nil, err
+	}
+	nonce, err := raw.Nonce.Int64()
+	if err != nil {
+		return nil, fmt.Errorf("parse Safe nonce %q: %w", raw.Nonce, err)
+	}
+	return &SafeInfo{
+		Address:   raw.Address,
+		Nonce:     nonce,
+		Threshold: raw.Threshold,
+		Owners:    raw.Owners,
+	}, nil
+}
+
+// GetPendingTransactions returns queued transactions (not executed, nonce >= current Safe nonce).
+// Route: GET /api/v1/safes/{address}/multisig-transactions/?executed=false&nonce__gte={nonce}
+func (c *Client) GetPendingTransactions(ctx context.Context, safeAddress string) ([]*PendingTransaction, error) {
+	info, err := c.GetSafeInfo(ctx, safeAddress)
+	if err != nil {
+		return nil, err
+	}
+
+	addr := common.HexToAddress(safeAddress).Hex()
+	path := fmt.Sprintf("/api/v1/safes/%s/multisig-transactions/?executed=false&nonce__gte=%d&ordering=nonce&limit=100", addr, info.Nonce)
+
+	var raw struct {
+		Results []struct {
+			SafeTxHash            string      `json:"safeTxHash"`
+			Nonce                 json.Number `json:"nonce"`
+			To                    string      `json:"to"`
+			Value                 string      `json:"value"`
+			ConfirmationsRequired int         `json:"confirmationsRequired"`
+			Proposer              string      `json:"proposer"`
+			SubmissionDate        time.Time   `json:"submissionDate"`
+			IsExecuted            bool        `json:"isExecuted"`
+			DataDecoded           *struct {
+				Method string `json:"method"`
+			} `json:"dataDecoded"`
+			Confirmations []struct {
+				Owner string `json:"owner"`
+			} `json:"confirmations"`
+		} `json:"results"`
+	}
+	if err := c.get(ctx, path, &raw); err != nil {
+		return nil, err
+	}
+
+	txs := make([]*PendingTransaction, 0, len(raw.Results))
+	for _, r := range raw.Results {
+		if r.IsExecuted {
+			continue
+		}
+		nonce, _ := r.Nonce.Int64()
+		required := r.ConfirmationsRequired
+		if required == 0 {
+			required = info.Threshold
+		}
+		tx := &PendingTransaction{
+			SafeTxHash:            r.SafeTxHash,
+			Nonce:                 nonce,
+			To:                    r.To,
+			Value:                 r.Value,
+			ConfirmationsRequired: required,
+			Confirmations:         len(r.Confirmations),
+			Proposer:              r.Proposer,
+			SubmissionDate:        r.SubmissionDate,
+		}
+		if r.DataDecoded != nil {
+			tx.Method = r.DataDecoded.Method
+		}
+		txs = append(txs, tx)
+	}
+	return txs, nil
+}
+
+// TransactionURL returns the Safe{Wallet} web link for a queued transaction.
+func (c *Client) TransactionURL(safeAddress, safeTxHash string) string {
+	prefix := map[string]string{"1": "eth", "10": "oeth", "137": "matic", "8453": "base", "42161": "arb1"}[c.chainID]
+	if prefix == "" {
+		return ""
+	}
+	addr := common.HexToAddress(safeAddress).Hex()
+	return fmt.Sprintf("https://app.safe.global/transactions/tx?safe=%s:%s&id=multisig_%s_%s", prefix, addr, addr, safeTxHash)
+}
+
+func (c *Client) get(ctx context.Context, path string, out any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.chainInfo.TxServiceURL+path, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create HTTP request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	if c.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+c.apiKey)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to call Safe Transaction Service: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("Safe Transaction Service returned status %d: %s", resp.StatusCode, string(body))
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to parse Safe Transaction Service response: %w", err)
+	}
+	return nil
+}
+
+// GetChainNameFromID returns the chain name for a given chain ID
+func GetChainNameFromID(chainID string) (string, error) {
+	chainInfo, ok := supportedChains[chainID]
+	if !ok {
+		return "", fmt.Errorf("unsupported chain ID: %s", chainID)
+	}
+	return chainInfo.ChainName, nil
+}
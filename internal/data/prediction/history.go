@@ -0,0 +1,59 @@
+package prediction
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// DefaultMoveWindow is the look-back window for MOVE rules that don't set one
+const DefaultMoveWindow = time.Hour
+
+// minSampleGap keeps WebSocket-driven evaluations from storing a sample per book update
+const minSampleGap = 5 * time.Second
+
+type sample struct {
+	at    time.Time
+	value float64
+}
+
+// MoveTracker retains recent midpoints per series so a reading can be compared
+// with where the price stood within a look-back window.
+type MoveTracker struct {
+	mu     sync.Mutex
+	series map[string][]sample
+}
+
+// NewMoveTracker creates an empty tracker
+func NewMoveTracker() *MoveTracker {
+	return &MoveTracker{series: make(map[string][]sample)}
+}
+
+// Record stores value for key and returns the largest move from any value seen
+// within window to value (signed: positive when the price rose).
+func (t *MoveTracker) Record(key string, value float64, window time.Duration) float64 {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := time.Now()
+	cutoff := now.Add(-window)
+	kept := t.series[key][:0]
+	for _, s := range t.series[key] {
+		if s.at.After(cutoff) {
+			kept = append(kept, s)
+		}
+	}
+
+	var move float64
+	for _, s := range kept {
+		if d := value - s.value; math.Abs(d) > math.Abs(move) {
+			move = d
+		}
+	}
+
+	if len(kept) == 0 || now.Sub(kept[len(kept)-1].at) >= minSampleGap {
+		kept = append(kept, sample{at: now, value: value})
+	}
+	t.series[key] = kept
+	return move
+}
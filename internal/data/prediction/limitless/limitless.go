@@ -0,0 +1,192 @@
+package limitless
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"crypto-alert/internal/data/prediction"
+	"crypto-alert/internal/logger"
+)
+
+const apiBaseURL = "https://api.limitless.exchange"
+
+// Limitless order book sizes are in 1e-6 share units (USDC decimals)
+const sizeScale = 1e6
+
+// TokenRef builds the token ID used in rules for an outcome of a market
+func TokenRef(slug, outcome string) string {
+	return slug + ":" + strings.ToUpper(outcome)
+}
+
+func parseTokenRef(tokenID string) (slug string, no bool) {
+	if i := strings.LastIndex(tokenID, ":"); i >= 0 {
+		return tokenID[:i], strings.EqualFold(tokenID[i+1:], "NO")
+	}
+	return tokenID, false
+}
+
+// Client is a Limitless Exchange API client. Limitless keeps one order book
+// per market, quoted for the YES outcome, so rules reference an outcome as
+// "<market slug>:<YES|NO>" and NO books are derived from the YES book.
+type Client struct {
+	httpClient *http.Client
+	baseURL    string
+}
+
+// NewClient creates a new Limitless client.
+func NewClient() *Client {
+	return &Client{
+		httpClient: &http.Client{Timeout: 10 * time.Second},
+		baseURL:    apiBaseURL,
+	}
+}
+
+// Name returns the venue name used in rules
+func (c *Client) Name() string {
+	return "limitless"
+}
+
+// ResolveToken checks that the market exists and returns the token reference
+// for outcome (YES or NO) along with the market title.
+// Route: GET /markets/{slug}
+func (c *Client) ResolveToken(ctx context.Context, slug, outcome string) (string, string, error) {
+	if slug == "" {
+		return "", "", fmt.Errorf("market slug is required for Limitless rules")
+	}
+	if outcome == "" {
+		outcome = "YES"
+	}
+	if !strings.EqualFold(outcome, "YES") && !strings.EqualFold(outcome, "NO") {
+		return "", "", fmt.Errorf("Limitless outcome must be YES or NO, got %q", outcome)
+	}
+
+	var market struct {
+		Title string `json:"title"`
+		Slug  string `json:"slug"`
+	}
+	if err := c.get(ctx, "/markets/"+url.PathEscape(slug), &market); err != nil {
+		return "", "", fmt.Errorf("limitless: fetch market %s: %w", slug, err)
+	}
+	return TokenRef(slug, outcome), market.Title, nil
+}
+
+// GetTokenPrices returns prices derived from each token's order book.
+func (c *Client) GetTokenPrices(ctx context.Context, tokenIDs []string) (map[string]*prediction.TokenPrices, error) {
+	result := make(map[string]*prediction.TokenPrices, len(tokenIDs))
+	for _, tokenID := range tokenIDs {
+		book, err := c.GetOrderBook(ctx, tokenID)
+		if err != nil {
+			return nil, fmt.Errorf("limitless: fetch prices: %w", err)
+		}
+		result[tokenID] = book.Prices()
+	}
+	return result, nil
+}
+
+// GetOrderBooks fetches order books for the given tokens, keyed by token ID.
+// Tokens whose book can't be fetched are logged and skipped.
+func (c *Client) GetOrderBooks(ctx context.Context, tokenIDs []string) map[string]*prediction.OrderBook {
+	books := make(map[string]*prediction.OrderBook, len(tokenIDs))
+	for _, tokenID := range tokenIDs {
+		book, err := c.GetOrderBook(ctx, tokenID)
+		if err != nil {
+			slog.Warn("⚠️  Limitless: failed to fetch order book", logger.FieldTokenID, tokenID, logger.FieldError, err)
+			continue
+		}
+		books[tokenID] = book
+	}
+	return books
+}
+
+// flexFloat accepts a JSON number or a numeric string
+type flexFloat float64
+
+func (f *flexFloat) UnmarshalJSON(b []byte) error {
+	s := strings.Trim(string(b), `"`)
+	if s == "" || s == "null" {
+		*f = 0
+		return nil
+	}
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return err
+	}
+	*f = flexFloat(v)
+	return nil
+}
+
+// GetOrderBook returns the book for a token reference. The NO book mirrors the
+// YES book: a YES bid at p is a NO ask at 1-p and vice versa.
+// Route: GET /markets/{slug}/orderbook
+func (c *Client) GetOrderBook(ctx context.Context, tokenID string) (*prediction.OrderBook, error) {
+	slug, no := parseTokenRef(tokenID)
+
+	var raw struct {
+		Bids []struct {
+			Price flexFloat `json:"price"`
+			Size  flexFloat `json:"size"`
+		} `json:"bids"`
+		Asks []struct {
+			Price flexFloat `json:"price"`
+			Size  flexFloat `json:"size"`
+		} `json:"asks"`
+	}
+	if err := c.get(ctx, "/markets/"+url.PathEscape(slug)+"/orderbook", &raw); err != nil {
+		return nil, err
+	}
+
+	book := &prediction.OrderBook{TokenID: tokenID}
+	for _, l := range raw.Bids {
+		level := prediction.OrderLevel{Price: float64(l.Price), Size: float64(l.Size) / sizeScale}
+		if no {
+			level.Price = 1 - level.Price
+			book.Asks = append(book.Asks, level)
+		} else {
+			book.Bids = append(book.Bids, level)
+		}
+	}
+	for _, l := range raw.Asks {
+		level := prediction.OrderLevel{Price: float64(l.Price), Size: float64(l.Size) / sizeScale}
+		if no {
+			level.Price = 1 - level.Price
+			book.Bids = append(book.Bids, level)
+		} else {
+			book.Asks = append(book.Asks, level)
+		}
+	}
+	book.SortLevels()
+	return book, nil
+}
+
+func (c *Client) get(ctx context.Context, path string, out any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+path, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("HTTP %d: %s", resp.StatusCode, string(body))
+	}
+	if err := json.Unmarshal(body, out); err != nil {
+		return fmt.Errorf("parse response: %w", err)
+	}
+	return nil
+}
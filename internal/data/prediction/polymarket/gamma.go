@@ -0,0 +1,236 @@
+package polymarket
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+const gammaBaseURL = "https://gamma-api.polymarket.com"
+
+// Market is a Polymarket market as described by the Gamma API. Outcomes and
+// TokenIDs are parallel: TokenIDs[i] is the CLOB token for Outcomes[i].
+type Market struct {
+	ConditionID    string
+	QuestionID     string
+	Question       string
+	Slug           string
+	GroupItemTitle string // Candidate / bucket name within a negRisk event
+	NegRisk        bool
+	Closed         bool
+	Outcomes       []string
+	TokenIDs       []string
+}
+
+// TokenID returns the CLOB token ID for an outcome (case-insensitive, e.g. "YES")
+func (m *Market) TokenID(outcome string) (string, error) {
+	for i, o := range m.Outcomes {
+		if strings.EqualFold(o, outcome) && i < len(m.TokenIDs) {
+			return m.TokenIDs[i], nil
+		}
+	}
+	return "", fmt.Errorf("market %s has no outcome %q (outcomes: %s)", m.Slug, outcome, strings.Join(m.Outcomes, ", "))
+}
+
+// gammaMarket is the raw Gamma market payload. outcomes and clobTokenIds are
+// JSON-encoded arrays inside string fields.
+type gammaMarket struct {
+	ConditionID    string `json:"conditionId"`
+	QuestionID     string `json:"questionID"`
+	Question       string `json:"question"`
+	Slug           string `json:"slug"`
+	GroupItemTitle string `json:"groupItemTitle"`
+	NegRisk        bool   `json:"negRisk"`
+	Closed         bool   `json:"closed"`
+	Outcomes       string `json:"outcomes"`
+	ClobTokenIDs   string `json:"clobTokenIds"`
+}
+
+func (g gammaMarket) toMarket() (*Market, error) {
+	m := &Market{
+		ConditionID:    g.ConditionID,
+		QuestionID:     g.QuestionID,
+		Question:       g.Question,
+		Slug:           g.Slug,
+		GroupItemTitle: g.GroupItemTitle,
+		NegRisk:        g.NegRisk,
+		Closed:         g.Closed,
+	}
+	if err := json.Unmarshal([]byte(g.Outcomes), &m.Outcomes); err != nil {
+		return nil, fmt.Errorf("parse outcomes of market %s: %w", g.Slug, err)
+	}
+	if err := json.Unmarshal([]byte(g.ClobTokenIDs), &m.TokenIDs); err != nil {
+		return nil, fmt.Errorf("parse clobTokenIds of market %s: %w", g.Slug, err)
+	}
+	return m, nil
+}
+
+// GammaClient looks up market metadata (token IDs, question, negRisk) from the
+// Polymarket Gamma API. Lookups are cached for the life of the client since a
+// market's tokens never change.
+type GammaClient struct {
+	httpClient *http.Client
+	baseURL    string
+
+	mu    sync.Mutex
+	cache map[string][]*Market
+}
+
+// NewGammaClient creates a new Gamma API client.
+func NewGammaClient() *GammaClient {
+	return &GammaClient{
+		httpClient: &http.Client{Timeout: 10 * time.Second},
+		baseURL:    gammaBaseURL,
+		cache:      make(map[string][]*Market),
+	}
+}
+
+// FindMarket resolves a market by condition ID or slug. The slug may be a
+// market slug or an event slug; an event with several markets (a negRisk
+// event) needs groupItem to pick the market by its candidate name or question.
+func (c *GammaClient) FindMarket(ctx context.Context, slug, conditionID, groupItem string) (*Market, error) {
+	var markets []*Market
+	var err error
+	switch {
+	case conditionID != "":
+		markets, err = c.cached("condition:"+conditionID, func() ([]*Market, error) {
+			return c.getMarkets(ctx, url.Values{"condition_ids": {conditionID}})
+		})
+	case slug != "":
+		markets, err = c.SlugMarkets(ctx, slug)
+	default:
+		return nil, fmt.Errorf("market slug or condition ID is required")
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(markets) == 0 {
+		return nil, fmt.Errorf("no Polymarket market found for %s", firstNonEmpty(conditionID, slug))
+	}
+
+	if groupItem == "" {
+		if len(markets) > 1 {
+			names := make([]string, 0, len(markets))
+			for _, m := range markets {
+				names = append(names, firstNonEmpty(m.GroupItemTitle, m.Question))
+			}
+			return nil, fmt.Errorf("%s has %d markets, set group_item to one of: %s", slug, len(markets), strings.Join(names, ", "))
+		}
+		return markets[0], nil
+	}
+	return SelectMarket(markets, groupItem, firstNonEmpty(slug, conditionID))
+}
+
+// SlugMarkets returns the market with the given slug, or all markets of the
+// event with that slug (several for a negRisk event).
+func (c *GammaClient) SlugMarkets(ctx context.Context, slug string) ([]*Market, error) {
+	return c.cached("slug:"+slug, func() ([]*Market, error) {
+		markets, err := c.getMarkets(ctx, url.Values{"slug": {slug}})
+		if err != nil || len(markets) > 0 {
+			return markets, err
+		}
+		return c.getEventMarkets(ctx, slug)
+	})
+}
+
+// SelectMarket picks the market whose candidate name or question matches groupItem
+func SelectMarket(markets []*Market, groupItem, source string) (*Market, error) {
+	for _, m := range markets {
+		if strings.EqualFold(m.GroupItemTitle, groupItem) || strings.EqualFold(m.Question, groupItem) {
+			return m, nil
+		}
+	}
+	return nil, fmt.Errorf("no market %q in %s", groupItem, source)
+}
+
+func (c *GammaClient) cached(key string, fetch func() ([]*Market, error)) ([]*Market, error) {
+	c.mu.Lock()
+	markets, ok := c.cache[key]
+	c.mu.Unlock()
+	if ok {
+		return markets, nil
+	}
+
+	markets, err := fetch()
+	if err != nil {
+		return nil, err
+	}
+	if len(markets) > 0 {
+		c.mu.Lock()
+		c.cache[key] = markets
+		c.mu.Unlock()
+	}
+	return markets, nil
+}
+
+// getMarkets calls GET /markets with the given filters.
+func (c *GammaClient) getMarkets(ctx context.Context, query url.Values) ([]*Market, error) {
+	var raw []gammaMarket
+	if err := c.get(ctx, "/markets?"+query.Encode(), &raw); err != nil {
+		return nil, fmt.Errorf("polymarket gamma: fetch markets: %w", err)
+	}
+	return toMarkets(raw)
+}
+
+// getEventMarkets calls GET /events?slug=<slug> and returns the event's markets.
+func (c *GammaClient) getEventMarkets(ctx context.Context, slug string) ([]*Market, error) {
+	var raw []struct {
+		Markets []gammaMarket `json:"markets"`
+	}
+	if err := c.get(ctx, "/events?"+url.Values{"slug": {slug}}.Encode(), &raw); err != nil {
+		return nil, fmt.Errorf("polymarket gamma: fetch event: %w", err)
+	}
+	if len(raw) == 0 {
+		return nil, nil
+	}
+	return toMarkets(raw[0].Markets)
+}
+
+func toMarkets(raw []gammaMarket) ([]*Market, error) {
+	markets := make([]*Market, 0, len(raw))
+	for _, g := range raw {
+		m, err := g.toMarket()
+		if err != nil {
+			return nil, err
+		}
+		markets = append(markets, m)
+	}
+	return markets, nil
+}
+
+func (c *GammaClient) get(ctx context.Context, path string, out any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+path, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("HTTP %d: %s", resp.StatusCode, string(body))
+	}
+	return json.Unmarshal(body, out)
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
@@ -5,10 +5,13 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
-	"log"
+	"log/slog"
 	"net/http"
 	"strconv"
 	"time"
+
+	"crypto-alert/internal/data/prediction"
+	"crypto-alert/internal/logger"
 )
 
 const clobBaseURL = "https://clob.polymarket.com"
@@ -27,21 +30,17 @@ func NewClient() *Client {
 	}
 }
 
-// TokenPrices holds the midpoint, buy-side, and sell-side prices for a single Polymarket token.
-// The midpoint is the average of the best bid and ask and is used for threshold comparison.
-type TokenPrices struct {
-	TokenID   string
-	Midpoint  float64
-	BuyPrice  float64
-	SellPrice float64
+// Name returns the venue name used in rules
+func (c *Client) Name() string {
+	return "polymarket"
 }
 
 // GetTokenPrices fetches midpoint, buy-side, and sell-side prices for the given token IDs.
 // It calls the /midpoints and /prices CLOB endpoints concurrently, logs all three prices
 // per token, and returns a map keyed by token ID.
-func (c *Client) GetTokenPrices(ctx context.Context, tokenIDs []string) (map[string]*TokenPrices, error) {
+func (c *Client) GetTokenPrices(ctx context.Context, tokenIDs []string) (map[string]*prediction.TokenPrices, error) {
 	if len(tokenIDs) == 0 {
-		return make(map[string]*TokenPrices), nil
+		return make(map[string]*prediction.TokenPrices), nil
 	}
 
 	midpoints, err := c.getMidpoints(ctx, tokenIDs)
@@ -54,9 +53,9 @@ func (c *Client) GetTokenPrices(ctx context.Context, tokenIDs []string) (map[str
 		return nil, fmt.Errorf("polymarket: fetch market prices: %w", err)
 	}
 
-	result := make(map[string]*TokenPrices, len(tokenIDs))
+	result := make(map[string]*prediction.TokenPrices, len(tokenIDs))
 	for _, tokenID := range tokenIDs {
-		tp := &TokenPrices{TokenID: tokenID}
+		tp := &prediction.TokenPrices{TokenID: tokenID}
 		if m, ok := midpoints[tokenID]; ok {
 			tp.Midpoint = m
 		}
@@ -103,7 +102,7 @@ func (c *Client) getMidpoints(ctx context.Context, tokenIDs []string) (map[strin
 		}
 		price, err := strconv.ParseFloat(raw.Mid, 64)
 		if err != nil {
-			log.Printf("⚠️  Polymarket: failed to parse midpoint for token %s: %v", tokenID, err)
+			slog.Warn("⚠️  Polymarket: failed to parse midpoint", logger.FieldTokenID, tokenID, logger.FieldError, err)
 			continue
 		}
 		result[tokenID] = price
@@ -147,7 +146,7 @@ func (c *Client) getMarketPrices(ctx context.Context, tokenIDs []string) (map[st
 			}
 			p, err := strconv.ParseFloat(raw.Price, 64)
 			if err != nil {
-				log.Printf("⚠️  Polymarket: failed to parse %s price for token %s: %v", side, tokenID, err)
+				slog.Warn("⚠️  Polymarket: failed to parse price", "side", side, logger.FieldTokenID, tokenID, logger.FieldError, err)
 				continue
 			}
 			sides[side] = p
@@ -156,3 +155,76 @@ func (c *Client) getMarketPrices(ctx context.Context, tokenIDs []string) (map[st
 	}
 	return result, nil
 }
+
+// GetOrderBook calls GET /book?token_id=<id> and returns the parsed order book.
+// Response format: {"bids": [{"price": "0.45", "size": "100"}], "asks": [...]}
+func (c *Client) GetOrderBook(ctx context.Context, tokenID string) (*prediction.OrderBook, error) {
+	url := fmt.Sprintf("%s/book?token_id=%s", c.baseURL, tokenID)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("HTTP %d: %s", resp.StatusCode, string(body))
+	}
+
+	var raw struct {
+		Bids []struct {
+			Price string `json:"price"`
+			Size  string `json:"size"`
+		} `json:"bids"`
+		Asks []struct {
+			Price string `json:"price"`
+			Size  string `json:"size"`
+		} `json:"asks"`
+	}
+	if err := json.Unmarshal(body, &raw); err != nil {
+		return nil, fmt.Errorf("parse book response: %w", err)
+	}
+
+	book := &prediction.OrderBook{TokenID: tokenID}
+	for _, l := range raw.Bids {
+		price, err1 := strconv.ParseFloat(l.Price, 64)
+		size, err2 := strconv.ParseFloat(l.Size, 64)
+		if err1 != nil || err2 != nil {
+			continue
+		}
+		book.Bids = append(book.Bids, prediction.OrderLevel{Price: price, Size: size})
+	}
+	for _, l := range raw.Asks {
+		price, err1 := strconv.ParseFloat(l.Price, 64)
+		size, err2 := strconv.ParseFloat(l.Size, 64)
+		if err1 != nil || err2 != nil {
+			continue
+		}
+		book.Asks = append(book.Asks, prediction.OrderLevel{Price: price, Size: size})
+	}
+	book.SortLevels()
+	return book, nil
+}
+
+// GetOrderBooks fetches order books for the given token IDs, keyed by token ID.
+// Tokens whose book can't be fetched are logged and skipped.
+func (c *Client) GetOrderBooks(ctx context.Context, tokenIDs []string) map[string]*prediction.OrderBook {
+	books := make(map[string]*prediction.OrderBook, len(tokenIDs))
+	for _, tokenID := range tokenIDs {
+		book, err := c.GetOrderBook(ctx, tokenID)
+		if err != nil {
+			slog.Warn("⚠️  Polymarket: failed to fetch order book", logger.FieldTokenID, tokenID, logger.FieldError, err)
+			continue
+		}
+		books[tokenID] = book
+	}
+	return books
+}
@@ -0,0 +1,313 @@
+package polymarket
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"crypto-alert/internal/data/prediction"
+	"crypto-alert/internal/logger"
+
+	"github.com/gorilla/websocket"
+)
+
+// DefaultMarketWSURL is the CLOB WebSocket market channel
+const DefaultMarketWSURL = "wss://ws-subscriptions-clob.polymarket.com/ws/market"
+
+const (
+	wsPingInterval   = 10 * time.Second
+	wsReadTimeout    = 3 * wsPingInterval // Without a message or PONG for this long the connection is dead
+	wsWriteTimeout   = 10 * time.Second
+	wsMinBackoff     = time.Second
+	wsMaxBackoff     = time.Minute
+	wsUpdateCapacity = 256
+)
+
+// MarketStream keeps live order books for a set of tokens from the CLOB
+// WebSocket market channel. It reconnects automatically; while disconnected
+// Connected reports false and callers should fall back to the REST API.
+type MarketStream struct {
+	url     string
+	updates chan string
+
+	mu        sync.RWMutex
+	tokens    []string
+	books     map[string]*prediction.OrderBook
+	connected bool
+	resub     chan struct{}
+}
+
+// NewMarketStream creates a stream for the given WebSocket URL (DefaultMarketWSURL if empty).
+func NewMarketStream(url string) *MarketStream {
+	if url == "" {
+		url = DefaultMarketWSURL
+	}
+	return &MarketStream{
+		url:     url,
+		updates: make(chan string, wsUpdateCapacity),
+		books:   make(map[string]*prediction.OrderBook),
+		resub:   make(chan struct{}, 1),
+	}
+}
+
+// Updates delivers the token ID of every book that changed. Updates are
+// dropped when the consumer falls behind; the book itself is always current.
+func (s *MarketStream) Updates() <-chan string {
+	return s.updates
+}
+
+// Connected reports whether the stream is currently subscribed
+func (s *MarketStream) Connected() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.connected
+}
+
+// Book returns a copy of the live order book for a token, if one has been received.
+func (s *MarketStream) Book(tokenID string) (*prediction.OrderBook, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if !s.connected {
+		return nil, false
+	}
+	book, ok := s.books[tokenID]
+	if !ok {
+		return nil, false
+	}
+	return book.Clone(), true
+}
+
+// SetTokens sets the tokens to subscribe to. When the set changes the stream
+// reconnects with the new subscription.
+func (s *MarketStream) SetTokens(tokenIDs []string) {
+	sorted := append([]string(nil), tokenIDs...)
+	sort.Strings(sorted)
+
+	s.mu.Lock()
+	changed := len(sorted) != len(s.tokens)
+	for i := 0; !changed && i < len(sorted); i++ {
+		changed = sorted[i] != s.tokens[i]
+	}
+	s.tokens = sorted
+	s.mu.Unlock()
+
+	if changed {
+		select {
+		case s.resub <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// Run connects and keeps the subscription alive until ctx is cancelled.
+func (s *MarketStream) Run(ctx context.Context) {
+	backoff := wsMinBackoff
+	for {
+		s.mu.RLock()
+		tokens := s.tokens
+		s.mu.RUnlock()
+
+		if len(tokens) == 0 {
+			select {
+			case <-ctx.Done():
+				return
+			case <-s.resub:
+				continue
+			}
+		}
+
+		start := time.Now()
+		err := s.session(ctx, tokens)
+		s.setDisconnected()
+		if ctx.Err() != nil {
+			return
+		}
+		if err == nil {
+			// Resubscribing after a token set change
+			backoff = wsMinBackoff
+			continue
+		}
+
+		if time.Since(start) > wsMaxBackoff {
+			backoff = wsMinBackoff
+		}
+		slog.Warn("⚠️  Polymarket WebSocket disconnected, using REST until it reconnects", "retry_in", backoff, logger.FieldError, err)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+		backoff = min(backoff*2, wsMaxBackoff)
+	}
+}
+
+// session runs one connection. It returns nil when the token set changed and
+// the caller should resubscribe, or the error that ended the connection.
+func (s *MarketStream) session(ctx context.Context, tokens []string) error {
+	conn, _, err := websocket.DefaultDialer.DialContext(ctx, s.url, nil)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	sub := map[string]any{"assets_ids": tokens, "type": "market"}
+	_ = conn.SetWriteDeadline(time.Now().Add(wsWriteTimeout))
+	if err := conn.WriteJSON(sub); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	s.connected = true
+	s.mu.Unlock()
+	slog.Info("🔌 Polymarket WebSocket subscribed", "tokens", len(tokens))
+
+	// Every message, including the PONG answering each PING, extends the read
+	// deadline, so a half-open connection ends the session instead of leaving
+	// a frozen book looking live
+	_ = conn.SetReadDeadline(time.Now().Add(wsReadTimeout))
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(wsReadTimeout))
+	})
+	readErr := make(chan error, 1)
+	go func() {
+		for {
+			_, data, err := conn.ReadMessage()
+			if err != nil {
+				var netErr net.Error
+				if errors.As(err, &netErr) && netErr.Timeout() {
+					err = fmt.Errorf("no message or PONG for %s: %w", wsReadTimeout, err)
+				}
+				readErr <- err
+				return
+			}
+			_ = conn.SetReadDeadline(time.Now().Add(wsReadTimeout))
+			s.handleMessage(data)
+		}
+	}()
+
+	ping := time.NewTicker(wsPingInterval)
+	defer ping.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-s.resub:
+			return nil
+		case err := <-readErr:
+			return err
+		case <-ping.C:
+			_ = conn.SetWriteDeadline(time.Now().Add(wsWriteTimeout))
+			if err := conn.WriteMessage(websocket.TextMessage, []byte("PING")); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func (s *MarketStream) setDisconnected() {
+	s.mu.Lock()
+	s.connected = false
+	s.books = make(map[string]*prediction.OrderBook)
+	s.mu.Unlock()
+}
+
+type wsLevel struct {
+	Price string `json:"price"`
+	Size  string `json:"size"`
+}
+
+type wsPriceChange struct {
+	AssetID string `json:"asset_id"`
+	Price   string `json:"price"`
+	Size    string `json:"size"`
+	Side    string `json:"side"`
+}
+
+type wsEvent struct {
+	EventType    string          `json:"event_type"`
+	AssetID      string          `json:"asset_id"`
+	Bids         []wsLevel       `json:"bids"`
+	Asks         []wsLevel       `json:"asks"`
+	PriceChanges []wsPriceChange `json:"price_changes"`
+}
+
+// handleMessage applies a market channel message. Messages are a single event
+// or an array of events; "book" replaces a token's book and "price_change"
+// updates individual levels.
+func (s *MarketStream) handleMessage(data []byte) {
+	data = bytes.TrimSpace(data)
+	if len(data) == 0 || data[0] != '[' && data[0] != '{' {
+		return // PONG
+	}
+	var events []wsEvent
+	if data[0] == '[' {
+		if err := json.Unmarshal(data, &events); err != nil {
+			slog.Warn("⚠️  Polymarket WebSocket: failed to parse message", logger.FieldError, err)
+			return
+		}
+	} else {
+		var e wsEvent
+		if err := json.Unmarshal(data, &e); err != nil {
+			slog.Warn("⚠️  Polymarket WebSocket: failed to parse message", logger.FieldError, err)
+			return
+		}
+		events = []wsEvent{e}
+	}
+
+	changed := make(map[string]bool)
+	s.mu.Lock()
+	for _, e := range events {
+		switch e.EventType {
+		case "book":
+			book := &prediction.OrderBook{TokenID: e.AssetID}
+			book.Bids = parseWSLevels(e.Bids)
+			book.Asks = parseWSLevels(e.Asks)
+			book.SortLevels()
+			s.books[e.AssetID] = book
+			changed[e.AssetID] = true
+		case "price_change":
+			for _, c := range e.PriceChanges {
+				book, ok := s.books[c.AssetID]
+				if !ok {
+					continue // wait for the snapshot
+				}
+				price, err1 := strconv.ParseFloat(c.Price, 64)
+				size, err2 := strconv.ParseFloat(c.Size, 64)
+				if err1 != nil || err2 != nil {
+					continue
+				}
+				book.SetLevel(c.Side, price, size)
+				changed[c.AssetID] = true
+			}
+		}
+	}
+	s.mu.Unlock()
+
+	for tokenID := range changed {
+		select {
+		case s.updates <- tokenID:
+		default:
+		}
+	}
+}
+
+func parseWSLevels(raw []wsLevel) []prediction.OrderLevel {
+	levels := make([]prediction.OrderLevel, 0, len(raw))
+	for _, l := range raw {
+		price, err1 := strconv.ParseFloat(l.Price, 64)
+		size, err2 := strconv.ParseFloat(l.Size, 64)
+		if err1 != nil || err2 != nil {
+			continue
+		}
+		levels = append(levels, prediction.OrderLevel{Price: price, Size: size})
+	}
+	return levels
+}
@@ -0,0 +1,161 @@
+package prediction
+
+import (
+	"context"
+	"sort"
+	"strings"
+)
+
+// Source is a prediction market venue. Token IDs are venue-specific outcome
+// identifiers; rules name their venue in predict_market.
+type Source interface {
+	// Name returns the venue name used in rules (e.g. "polymarket")
+	Name() string
+	// GetTokenPrices returns prices for the given tokens, keyed by token ID
+	GetTokenPrices(ctx context.Context, tokenIDs []string) (map[string]*TokenPrices, error)
+	// GetOrderBooks returns order books for the given tokens, keyed by token ID.
+	// Tokens whose book can't be fetched are left out.
+	GetOrderBooks(ctx context.Context, tokenIDs []string) map[string]*OrderBook
+}
+
+// TokenResolver is implemented by sources that can look up a token ID from a
+// market slug and outcome, so rules don't need raw token IDs.
+type TokenResolver interface {
+	// ResolveToken returns the token ID of outcome in the market and the market question
+	ResolveToken(ctx context.Context, slug, outcome string) (tokenID, question string, err error)
+}
+
+// Sources holds the configured venues keyed by name
+type Sources struct {
+	sources map[string]Source
+}
+
+// NewSources creates a registry of the given venues
+func NewSources(sources ...Source) *Sources {
+	s := &Sources{sources: make(map[string]Source, len(sources))}
+	for _, src := range sources {
+		s.sources[strings.ToLower(src.Name())] = src
+	}
+	return s
+}
+
+// Get returns the venue with the given name (case-insensitive)
+func (s *Sources) Get(name string) (Source, bool) {
+	src, ok := s.sources[strings.ToLower(name)]
+	return src, ok
+}
+
+// TokenPrices holds the midpoint, buy-side, and sell-side prices for a single outcome token.
+// The midpoint is the average of the best bid and ask and is used for threshold comparison.
+type TokenPrices struct {
+	TokenID   string
+	Midpoint  float64
+	BuyPrice  float64
+	SellPrice float64
+}
+
+// OrderLevel is a single price level of the order book
+type OrderLevel struct {
+	Price float64
+	Size  float64 // shares
+}
+
+// OrderBook is the order book for one outcome token. Bids are sorted best (highest)
+// first and asks best (lowest) first.
+type OrderBook struct {
+	TokenID string
+	Bids    []OrderLevel
+	Asks    []OrderLevel
+}
+
+// BestBid returns the highest bid price, or 0 when there are no bids
+func (b *OrderBook) BestBid() float64 {
+	if len(b.Bids) == 0 {
+		return 0
+	}
+	return b.Bids[0].Price
+}
+
+// BestAsk returns the lowest ask price, or 1 when there are no asks
+func (b *OrderBook) BestAsk() float64 {
+	if len(b.Asks) == 0 {
+		return 1
+	}
+	return b.Asks[0].Price
+}
+
+// Spread returns best ask minus best bid. An empty side counts as the price bound
+// (0 for bids, 1 for asks), so a one-sided book reports a wide spread.
+func (b *OrderBook) Spread() float64 {
+	return b.BestAsk() - b.BestBid()
+}
+
+// Depth returns the notional (price × size, in USDC) resting on one side of the
+// book at prices at least as good as level: bids >= level for "BID", asks <= level for "ASK".
+func (b *OrderBook) Depth(side string, level float64) float64 {
+	var total float64
+	switch side {
+	case "BID":
+		for _, l := range b.Bids {
+			if l.Price >= level {
+				total += l.Price * l.Size
+			}
+		}
+	case "ASK":
+		for _, l := range b.Asks {
+			if l.Price <= level {
+				total += l.Price * l.Size
+			}
+		}
+	}
+	return total
+}
+
+// Prices returns the token prices implied by the book: midpoint of best bid
+// and ask, buy at the best ask and sell at the best bid.
+func (b *OrderBook) Prices() *TokenPrices {
+	return &TokenPrices{
+		TokenID:   b.TokenID,
+		Midpoint:  (b.BestBid() + b.BestAsk()) / 2,
+		BuyPrice:  b.BestAsk(),
+		SellPrice: b.BestBid(),
+	}
+}
+
+// SetLevel sets the size resting at price on side ("BUY" for bids, "SELL" for
+// asks); a size of 0 removes the level.
+func (b *OrderBook) SetLevel(side string, price, size float64) {
+	levels := &b.Bids
+	if side == "SELL" {
+		levels = &b.Asks
+	}
+	for i, l := range *levels {
+		if l.Price == price {
+			if size == 0 {
+				*levels = append((*levels)[:i], (*levels)[i+1:]...)
+			} else {
+				(*levels)[i].Size = size
+			}
+			return
+		}
+	}
+	if size > 0 {
+		*levels = append(*levels, OrderLevel{Price: price, Size: size})
+		b.SortLevels()
+	}
+}
+
+// SortLevels orders bids best (highest) first and asks best (lowest) first
+func (b *OrderBook) SortLevels() {
+	sort.Slice(b.Bids, func(i, j int) bool { return b.Bids[i].Price > b.Bids[j].Price })
+	sort.Slice(b.Asks, func(i, j int) bool { return b.Asks[i].Price < b.Asks[j].Price })
+}
+
+// Clone returns a deep copy of the book
+func (b *OrderBook) Clone() *OrderBook {
+	return &OrderBook{
+		TokenID: b.TokenID,
+		Bids:    append([]OrderLevel(nil), b.Bids...),
+		Asks:    append([]OrderLevel(nil), b.Asks...),
+	}
+}
@@ -0,0 +1,163 @@
+package price
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/big"
+	"strings"
+	"sync"
+	"time"
+
+	"crypto-alert/internal/utils"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethclient"
+)
+
+// chainlinkChains lists chains with Chainlink price feeds and a configured RPC env var
+var chainlinkChains = map[string]string{
+	"1":     "Ethereum Mainnet",
+	"8453":  "Base",
+	"42161": "Arbitrum One",
+}
+
+const aggregatorV3ABI = `[
+	{"inputs":[],"name":"decimals","outputs":[{"internalType":"uint8","name":"","type":"uint8"}],"stateMutability":"view","type":"function"},
+	{"inputs":[],"name":"latestRoundData","outputs":[{"internalType":"uint80","name":"roundId","type":"uint80"},{"internalType":"int256","name":"answer","type":"int256"},{"internalType":"uint256","name":"startedAt","type":"uint256"},{"internalType":"uint256","name":"updatedAt","type":"uint256"},{"internalType":"uint80","name":"answeredInRound","type":"uint80"}],"stateMutability":"view","type":"function"}
+]`
+
+// ChainlinkClient reads Chainlink AggregatorV3 price feeds on a single chain
+type ChainlinkClient struct {
+	chainID   string
+	chainName string
+	client    *ethclient.Client
+	abi       abi.ABI
+
+	mu       sync.Mutex
+	decimals map[common.Address]uint8
+}
+
+// NewChainlinkClient creates a Chainlink feed reader for the given chain
+func NewChainlinkClient(chainID string) (*ChainlinkClient, error) {
+	chainName, ok := chainlinkChains[chainID]
+	if !ok {
+		return nil, fmt.Errorf("unsupported chain ID: %s. Supported chains: 1 (Ethereum), 8453 (Base), 42161 (Arbitrum One)", chainID)
+	}
+
+	rpcURL := utils.GetRPCURLForChain(chainID)
+	if rpcURL == "" {
+		return nil, fmt.Errorf("RPC URL not configured for chain %s (%s). Please set the appropriate environment variable (ETH_RPC_URL, BASE_RPC_URL, or ARB_RPC_URL)", chainID, chainName)
+	}
+
+	client, err := ethclient.Dial(rpcURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to %s RPC: %w", chainName, err)
+	}
+
+	parsedABI, err := abi.JSON(strings.NewReader(aggregatorV3ABI))
+	if err != nil {
+		client.Close()
+		return nil, fmt.Errorf("failed to parse AggregatorV3 ABI: %w", err)
+	}
+
+	return &ChainlinkClient{
+		chainID:   chainID,
+		chainName: chainName,
+		client:    client,
+		abi:       parsedABI,
+		decimals:  make(map[common.Address]uint8),
+	}, nil
+}
+
+// GetChainName returns the human-readable chain name
+func (c *ChainlinkClient) GetChainName() string {
+	return c.chainName
+}
+
+// Close closes the RPC connection
+func (c *ChainlinkClient) Close() {
+	if c.client != nil {
+		c.client.Close()
+	}
+}
+
+// GetPrice reads latestRoundData from the aggregator at feedAddress.
+// Timestamp is the round's updatedAt, so callers can detect stale feeds.
+func (c *ChainlinkClient) GetPrice(ctx context.Context, symbol, feedAddress string) (*PriceData, error) {
+	feed := common.HexToAddress(feedAddress)
+
+	decimals, err := c.feedDecimals(ctx, feed)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read decimals for %s feed %s: %w", symbol, feedAddress, err)
+	}
+
+	out, err := c.call(ctx, feed, "latestRoundData")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read latestRoundData for %s feed %s: %w", symbol, feedAddress, err)
+	}
+	if len(out) < 4 {
+		return nil, fmt.Errorf("unexpected latestRoundData response for %s", symbol)
+	}
+	answer, ok := out[1].(*big.Int)
+	if !ok {
+		return nil, fmt.Errorf("unexpected answer type for %s", symbol)
+	}
+	updatedAt, ok := out[3].(*big.Int)
+	if !ok {
+		return nil, fmt.Errorf("unexpected updatedAt type for %s", symbol)
+	}
+
+	price, _ := new(big.Float).SetInt(answer).Float64()
+	price *= math.Pow(10, -float64(decimals))
+
+	return &PriceData{
+		Symbol:    symbol,
+		Price:     price,
+		Timestamp: time.Unix(updatedAt.Int64(), 0),
+	}, nil
+}
+
+func (c *ChainlinkClient) feedDecimals(ctx context.Context, feed common.Address) (uint8, error) {
+	c.mu.Lock()
+	if d, ok := c.decimals[feed]; ok {
+		c.mu.Unlock()
+		return d, nil
+	}
+	c.mu.Unlock()
+
+	out, err := c.call(ctx, feed, "decimals")
+	if err != nil {
+		return 0, err
+	}
+	d, ok := out[0].(uint8)
+	if !ok {
+		return 0, fmt.Errorf("unexpected decimals type")
+	}
+
+	c.mu.Lock()
+	c.decimals[feed] = d
+	c.mu.Unlock()
+	return d, nil
+}
+
+func (c *ChainlinkClient) call(ctx context.Context, to common.Address, method string) ([]interface{}, error) {
+	m, ok := c.abi.Methods[method]
+	if !ok {
+		return nil, fmt.Errorf("%s method not found in AggregatorV3 ABI", method)
+	}
+	result, err := c.client.CallContract(ctx, ethereum.CallMsg{To: &to, Data: m.ID}, nil)
+	if err != nil {
+		return nil, err
+	}
+	out, err := m.Outputs.UnpackValues(result)
+	if err != nil {
+		return nil, err
+	}
+	if len(out) == 0 {
+		return nil, fmt.Errorf("empty %s response", method)
+	}
+	return out, nil
+}
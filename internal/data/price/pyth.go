@@ -5,12 +5,14 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
-	"log"
+	"log/slog"
 	"math"
 	"net/http"
 	"strconv"
 	"sync"
 	"time"
+
+	"crypto-alert/internal/logger"
 )
 
 // PriceData represents price information from Pyth oracle
@@ -151,7 +153,7 @@ func (c *PythClient) GetMultiplePrices(ctx context.Context, symbolToFeedID map[s
 			priceData, err := c.GetPrice(ctx, sym, fid)
 			if err != nil {
 				// Log error but continue with other symbols
-				log.Printf("⚠️  Failed to fetch price for %s: %v", sym, err)
+				slog.Warn("⚠️  Failed to fetch price", logger.FieldSymbol, sym, logger.FieldError, err)
 				return
 			}
 
@@ -0,0 +1,258 @@
+package wallet
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"strings"
+	"sync"
+
+	"crypto-alert/internal/utils"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethclient"
+)
+
+// FieldType represents the approval condition to alert on
+type FieldType string
+
+const (
+	FieldUnlimited FieldType = "UNLIMITED" // Approval for an effectively unlimited amount
+	FieldAmount    FieldType = "AMOUNT"    // Approval amount (in token units) compared against the rule threshold
+)
+
+// ChainInfo holds chain information
+type ChainInfo struct {
+	ChainID     string
+	ChainName   string
+	ExplorerURL string
+}
+
+// Supported chains (RPC URLs come from ETH_RPC_URL / BASE_RPC_URL / ARB_RPC_URL)
+var supportedChains = map[string]ChainInfo{
+	"1":     {ChainID: "1", ChainName: "Ethereum Mainnet", ExplorerURL: "https://etherscan.io"},
+	"8453":  {ChainID: "8453", ChainName: "Base", ExplorerURL: "https://basescan.org"},
+	"42161": {ChainID: "42161", ChainName: "Arbitrum One", ExplorerURL: "https://arbiscan.io"},
+}
+
+// maxBlockRange caps a single eth_getLogs query; most RPC providers reject wider ranges.
+const maxBlockRange = 2000
+
+// approvalTopic is keccak256("Approval(address,address,uint256)")
+var approvalTopic = crypto.Keccak256Hash([]byte("Approval(address,address,uint256)"))
+
+// unlimitedThreshold treats anything >= 2^160-1 as unlimited. Wallets commonly
+// approve max uint256, and Permit2 uses max uint160.
+var unlimitedThreshold = new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 160), big.NewInt(1))
+
+const erc20MetadataABI = `[
+	{"constant":true,"inputs":[],"name":"symbol","outputs":[{"name":"","type":"string"}],"stateMutability":"view","type":"function"},
+	{"constant":true,"inputs":[],"name":"decimals","outputs":[{"name":"","type":"uint8"}],"stateMutability":"view","type":"function"}
+]`
+
+// Approval is a decoded ERC-20 Approval event emitted for the watched wallet
+type Approval struct {
+	TxHash      string
+	LogIndex    uint
+	BlockNumber uint64
+	Token       common.Address
+	TokenSymbol string
+	Spender     common.Address
+	RawAmount   *big.Int
+	Amount      float64 // RawAmount scaled by token decimals
+	Unlimited   bool
+}
+
+type tokenMeta struct {
+	symbol   string
+	decimals uint8
+}
+
+// ApprovalScanner scans a chain for Approval events where the watched wallet is the owner.
+// It keeps a per-wallet block cursor so each poll only scans new blocks.
+type ApprovalScanner struct {
+	chainID   string
+	chainInfo ChainInfo
+	client    *ethclient.Client
+	erc20     abi.ABI
+
+	mu      sync.Mutex
+	cursors map[string]uint64 // cursor key -> next block to scan
+	tokens  map[common.Address]tokenMeta
+}
+
+// NewApprovalScanner creates a scanner for the given chain
+func NewApprovalScanner(chainID string) (*ApprovalScanner, error) {
+	chainInfo, ok := supportedChains[chainID]
+	if !ok {
+		return nil, fmt.Errorf("unsupported chain ID: %s. Supported chains: 1 (Ethereum), 8453 (Base), 42161 (Arbitrum One)", chainID)
+	}
+
+	rpcURL := utils.GetRPCURLForChain(chainID)
+	if rpcURL == "" {
+		return nil, fmt.Errorf("RPC URL not configured for chain %s (%s). Please set the appropriate environment variable (ETH_RPC_URL, BASE_RPC_URL, or ARB_RPC_URL)", chainID, chainInfo.ChainName)
+	}
+
+	client, err := ethclient.Dial(rpcURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to %s RPC: %w", chainInfo.ChainName, err)
+	}
+
+	parsedABI, err := abi.JSON(strings.NewReader(erc20MetadataABI))
+	if err != nil {
+		client.Close()
+		return nil, fmt.Errorf("failed to parse ERC20 ABI: %w", err)
+	}
+
+	return &ApprovalScanner{
+		chainID:   chainID,
+		chainInfo: chainInfo,
+		client:    client,
+		erc20:     parsedABI,
+		cursors:   make(map[string]uint64),
+		tokens:    make(map[common.Address]tokenMeta),
+	}, nil
+}
+
+// GetChainName returns the human-readable chain name
+func (s *ApprovalScanner) GetChainName() string {
+	return s.chainInfo.ChainName
+}
+
+// TxURL returns the block explorer link for a transaction
+func (s *ApprovalScanner) TxURL(txHash string) string {
+	return s.chainInfo.ExplorerURL + "/tx/" + txHash
+}
+
+// Close closes the RPC connection
+func (s *ApprovalScanner) Close() {
+	if s.client != nil {
+		s.client.Close()
+	}
+}
+
+// Scan returns Approval events for wallet since the previous call with the same cursorKey.
+// The first call only establishes the cursor at the current head and returns nothing.
+// tokenFilter optionally restricts the scan to specific token contracts.
+func (s *ApprovalScanner) Scan(ctx context.Context, cursorKey, wallet string, tokenFilter []string) ([]*Approval, error) {
+	head, err := s.client.BlockNumber(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get block number: %w", err)
+	}
+
+	s.mu.Lock()
+	from, ok := s.cursors[cursorKey]
+	if !ok {
+		s.cursors[cursorKey] = head + 1
+		s.mu.Unlock()
+		return nil, nil
+	}
+	s.mu.Unlock()
+
+	if from > head {
+		return nil, nil
+	}
+	to := head
+	if to-from+1 > maxBlockRange {
+		to = from + maxBlockRange - 1
+	}
+
+	query := ethereum.FilterQuery{
+		FromBlock: new(big.Int).SetUint64(from),
+		ToBlock:   new(big.Int).SetUint64(to),
+		Topics: [][]common.Hash{
+			{approvalTopic},
+			{common.BytesToHash(common.HexToAddress(wallet).Bytes())},
+		},
+	}
+	for _, t := range tokenFilter {
+		query.Addresses = append(query.Addresses, common.HexToAddress(t))
+	}
+
+	logs, err := s.client.FilterLogs(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to filter Approval logs in blocks %d-%d: %w", from, to, err)
+	}
+
+	approvals := make([]*Approval, 0, len(logs))
+	for _, l := range logs {
+		// ERC-721 Approval has the same signature but 4 topics and no data; skip it.
+		if len(l.Topics) != 3 || len(l.Data) < 32 || l.Removed {
+			continue
+		}
+		raw := new(big.Int).SetBytes(l.Data[:32])
+		if raw.Sign() == 0 {
+			continue // revocation
+		}
+		meta := s.tokenMetadata(ctx, l.Address)
+		amount, _ := new(big.Float).Quo(new(big.Float).SetInt(raw), new(big.Float).SetInt(new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(meta.decimals)), nil))).Float64()
+		approvals = append(approvals, &Approval{
+			TxHash:      l.TxHash.Hex(),
+			LogIndex:    l.Index,
+			BlockNumber: l.BlockNumber,
+			Token:       l.Address,
+			TokenSymbol: meta.symbol,
+			Spender:     common.BytesToAddress(l.Topics[2].Bytes()),
+			RawAmount:   raw,
+			Amount:      amount,
+			Unlimited:   raw.Cmp(unlimitedThreshold) >= 0,
+		})
+	}
+
+	s.mu.Lock()
+	s.cursors[cursorKey] = to + 1
+	s.mu.Unlock()
+	return approvals, nil
+}
+
+// tokenMetadata returns cached symbol/decimals for a token, falling back to the
+// address and 18 decimals when the contract doesn't implement the metadata calls.
+func (s *ApprovalScanner) tokenMetadata(ctx context.Context, token common.Address) tokenMeta {
+	s.mu.Lock()
+	if m, ok := s.tokens[token]; ok {
+		s.mu.Unlock()
+		return m
+	}
+	s.mu.Unlock()
+
+	meta := tokenMeta{symbol: token.Hex(), decimals: 18}
+	if out, err := s.call(ctx, token, "symbol"); err == nil && len(out) > 0 {
+		if sym, ok := out[0].(string); ok && sym != "" {
+			meta.symbol = sym
+		}
+	}
+	if out, err := s.call(ctx, token, "decimals"); err == nil && len(out) > 0 {
+		if d, ok := out[0].(uint8); ok {
+			meta.decimals = d
+		}
+	}
+
+	s.mu.Lock()
+	s.tokens[token] = meta
+	s.mu.Unlock()
+	return meta
+}
+
+func (s *ApprovalScanner) call(ctx context.Context, to common.Address, method string) ([]interface{}, error) {
+	m, ok := s.erc20.Methods[method]
+	if !ok {
+		return nil, fmt.Errorf("%s method not found in ERC20 ABI", method)
+	}
+	result, err := s.client.CallContract(ctx, ethereum.CallMsg{To: &to, Data: m.ID}, nil)
+	if err != nil {
+		return nil, err
+	}
+	return m.Outputs.UnpackValues(result)
+}
+
+// GetChainNameFromID returns the chain name for a given chain ID
+func GetChainNameFromID(chainID string) (string, error) {
+	chainInfo, ok := supportedChains[chainID]
+	if !ok {
+		return "", fmt.Errorf("unsupported chain ID: %s", chainID)
+	}
+	return chainInfo.ChainName, nil
+}
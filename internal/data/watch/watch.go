@@ -0,0 +1,640 @@
+package watch
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"crypto-alert/internal/core"
+	"crypto-alert/internal/data/bitcoin"
+	"crypto-alert/internal/data/bridge"
+	"crypto-alert/internal/data/governance"
+	"crypto-alert/internal/data/multisig/safe"
+	"crypto-alert/internal/data/price"
+	"crypto-alert/internal/data/wallet"
+	"crypto-alert/internal/logger"
+)
+
+// Options holds credentials and endpoints shared by watch sources.
+type Options struct {
+	SafeAPIKey string // Optional Safe Transaction Service API key
+	PythAPIURL string
+	PythAPIKey string
+
+	SnapshotURL    string
+	SnapshotAPIKey string // Optional Snapshot Hub API key
+
+	BitcoinAPIURL string // mempool.space / Esplora compatible API
+}
+
+// Manager dispatches watch rules to their data sources. Unlike defi.ClientManager
+// it is long-lived: event sources keep cursors and clients between ticks.
+type Manager struct {
+	mu   sync.Mutex
+	opts Options
+	safe map[string]*safe.Client // keyed by chain ID + service URL
+	// approvals holds one log scanner per chain; scanners keep per-rule block cursors
+	approvals map[string]*wallet.ApprovalScanner
+	pyth      *price.PythClient
+	chainlink map[string]*price.ChainlinkClient // keyed by chain ID
+	snapshot  *governance.SnapshotClient
+	governors map[string]*governance.GovernorClient // keyed by chain ID
+	bridges   map[string]*bridge.Client             // keyed by chain ID
+	tvl       *bridge.DropTracker
+	bitcoin   *bitcoin.Client
+	xpubs     map[string][]string // xpub|gap -> derived addresses
+}
+
+// NewManager creates a watch source manager
+func NewManager(opts Options) *Manager {
+	return &Manager{
+		opts:      opts,
+		safe:      make(map[string]*safe.Client),
+		approvals: make(map[string]*wallet.ApprovalScanner),
+		pyth:      price.NewPythClient(opts.PythAPIURL, opts.PythAPIKey),
+		chainlink: make(map[string]*price.ChainlinkClient),
+		snapshot:  governance.NewSnapshotClient(opts.SnapshotURL, opts.SnapshotAPIKey),
+		governors: make(map[string]*governance.GovernorClient),
+		bridges:   make(map[string]*bridge.Client),
+		tvl:       bridge.NewDropTracker(),
+		bitcoin:   bitcoin.NewClient(opts.BitcoinAPIURL),
+		xpubs:     make(map[string][]string),
+	}
+}
+
+// Close closes all managed clients
+func (m *Manager) Close() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, c := range m.safe {
+		c.Close()
+	}
+	for _, s := range m.approvals {
+		s.Close()
+	}
+	for _, c := range m.chainlink {
+		c.Close()
+	}
+	for _, c := range m.governors {
+		c.Close()
+	}
+	for _, c := range m.bridges {
+		c.Close()
+	}
+}
+
+// Observe collects the current observations for a watch rule and returns them
+// together with the human-readable chain name.
+func (m *Manager) Observe(ctx context.Context, rule *core.WatchAlertRule) ([]*core.WatchObservation, string, error) {
+	switch rule.Source {
+	case "safe":
+		return m.observeSafe(ctx, rule)
+	case "approval":
+		return m.observeApproval(ctx, rule)
+	case "oracle":
+		return m.observeOracle(ctx, rule)
+	case "snapshot":
+		return m.observeSnapshot(ctx, rule)
+	case "governor":
+		return m.observeGovernor(ctx, rule)
+	case "bridge":
+		return m.observeBridge(ctx, rule)
+	case "mempool":
+		return m.observeMempool(ctx, rule)
+	case "btc_address":
+		return m.observeBTCAddress(ctx, rule)
+	default:
+		return nil, "", fmt.Errorf("unsupported watch source: %s (supported: safe, approval, oracle, snapshot, governor, bridge, mempool, btc_address)", rule.Source)
+	}
+}
+
+func (m *Manager) safeClient(chainID, txServiceURL string) (*safe.Client, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	key := chainID + "|" + txServiceURL
+	if c, ok := m.safe[key]; ok {
+		return c, nil
+	}
+	c, err := safe.NewClient(chainID, txServiceURL, m.opts.SafeAPIKey)
+	if err != nil {
+		return nil, err
+	}
+	m.safe[key] = c
+	return c, nil
+}
+
+// observeSafe turns the Safe's queued transactions into discrete events. PROPOSED
+// emits one event per queued tx; QUORUM emits one per tx whose confirmations
+// reached the required threshold.
+func (m *Manager) observeSafe(ctx context.Context, rule *core.WatchAlertRule) ([]*core.WatchObservation, string, error) {
+	client, err := m.safeClient(rule.ChainID, rule.Params.TxServiceURL)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to create Safe client for chain %s: %w", rule.ChainID, err)
+	}
+	chainName := client.GetChainName()
+
+	txs, err := client.GetPendingTransactions(ctx, rule.Params.SafeAddress)
+	if err != nil {
+		return nil, chainName, fmt.Errorf("failed to fetch pending transactions for Safe %s on %s: %w", rule.Params.SafeAddress, chainName, err)
+	}
+
+	observations := make([]*core.WatchObservation, 0, len(txs))
+	for _, tx := range txs {
+		var title string
+		switch safe.FieldType(rule.Field) {
+		case safe.FieldProposed:
+			title = fmt.Sprintf("new transaction proposed (nonce %d)", tx.Nonce)
+		case safe.FieldQuorum:
+			if tx.Confirmations < tx.ConfirmationsRequired {
+				continue
+			}
+			title = fmt.Sprintf("transaction reached quorum %d/%d (nonce %d)", tx.Confirmations, tx.ConfirmationsRequired, tx.Nonce)
+		default:
+			return nil, chainName, fmt.Errorf("unsupported Safe field: %s", rule.Field)
+		}
+
+		method := tx.Method
+		if method == "" {
+			method = "transfer / raw call"
+		}
+		observations = append(observations, &core.WatchObservation{
+			Key:   rule.Field + ":" + tx.SafeTxHash,
+			Value: float64(tx.Confirmations),
+			Title: title,
+			Details: []core.WatchDetail{
+				{Label: "Safe", Value: rule.DisplayAddress(rule.Params.SafeAddress)},
+				{Label: "Nonce", Value: strconv.FormatInt(tx.Nonce, 10)},
+				{Label: "To", Value: tx.To},
+				{Label: "Value (wei)", Value: tx.Value},
+				{Label: "Method", Value: method},
+				{Label: "Confirmations", Value: fmt.Sprintf("%d/%d", tx.Confirmations, tx.ConfirmationsRequired)},
+				{Label: "Safe Tx Hash", Value: tx.SafeTxHash},
+			},
+			URL: client.TransactionURL(rule.Params.SafeAddress, tx.SafeTxHash),
+		})
+	}
+	return observations, chainName, nil
+}
+
+func (m *Manager) approvalScanner(chainID string) (*wallet.ApprovalScanner, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if s, ok := m.approvals[chainID]; ok {
+		return s, nil
+	}
+	s, err := wallet.NewApprovalScanner(chainID)
+	if err != nil {
+		return nil, err
+	}
+	m.approvals[chainID] = s
+	return s, nil
+}
+
+// observeApproval turns new ERC-20 Approval events granted by the watched wallet
+// into discrete events. UNLIMITED only emits effectively unlimited approvals;
+// AMOUNT emits every approval with its token amount as Value so the rule's
+// threshold can filter it (unlimited approvals count as math.MaxFloat64 and
+// are marked Unlimited).
+func (m *Manager) observeApproval(ctx context.Context, rule *core.WatchAlertRule) ([]*core.WatchObservation, string, error) {
+	scanner, err := m.approvalScanner(rule.ChainID)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to create approval scanner for chain %s: %w", rule.ChainID, err)
+	}
+	chainName := scanner.GetChainName()
+
+	cursorKey := fmt.Sprintf("%d|%s", rule.ID, strings.ToLower(rule.Params.WalletAddress))
+	approvals, err := scanner.Scan(ctx, cursorKey, rule.Params.WalletAddress, rule.Params.TokenAddresses)
+	if err != nil {
+		return nil, chainName, fmt.Errorf("failed to scan approvals for %s on %s: %w", rule.Params.WalletAddress, chainName, err)
+	}
+
+	observations := make([]*core.WatchObservation, 0, len(approvals))
+	for _, a := range approvals {
+		value := a.Amount
+		amount := strconv.FormatFloat(a.Amount, 'f', -1, 64)
+		if a.Unlimited {
+			value = math.MaxFloat64
+			amount = "Unlimited"
+		}
+
+		var title string
+		switch wallet.FieldType(rule.Field) {
+		case wallet.FieldUnlimited:
+			if !a.Unlimited {
+				continue
+			}
+			title = fmt.Sprintf("unlimited %s approval granted to %s", a.TokenSymbol, a.Spender.Hex())
+		case wallet.FieldAmount:
+			title = fmt.Sprintf("%s %s approval granted to %s", amount, a.TokenSymbol, a.Spender.Hex())
+		default:
+			return nil, chainName, fmt.Errorf("unsupported approval field: %s", rule.Field)
+		}
+
+		observations = append(observations, &core.WatchObservation{
+			Key:       fmt.Sprintf("%s:%d", a.TxHash, a.LogIndex),
+			Value:     value,
+			Unlimited: a.Unlimited,
+			Title:     title,
+			Details: []core.WatchDetail{
+				{Label: "Wallet", Value: rule.DisplayAddress(rule.Params.WalletAddress)},
+				{Label: "Token", Value: fmt.Sprintf("%s (%s)", a.TokenSymbol, a.Token.Hex())},
+				{Label: "Spender", Value: a.Spender.Hex()},
+				{Label: "Amount", Value: amount},
+				{Label: "Block", Value: strconv.FormatUint(a.BlockNumber, 10)},
+				{Label: "Tx Hash", Value: a.TxHash},
+			},
+			URL: scanner.TxURL(a.TxHash),
+		})
+	}
+	return observations, chainName, nil
+}
+
+func (m *Manager) chainlinkClient(chainID string) (*price.ChainlinkClient, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if c, ok := m.chainlink[chainID]; ok {
+		return c, nil
+	}
+	c, err := price.NewChainlinkClient(chainID)
+	if err != nil {
+		return nil, err
+	}
+	m.chainlink[chainID] = c
+	return c, nil
+}
+
+// observeOracle reads the same asset from Pyth and Chainlink and reports a single
+// measured value. DEVIATION is the absolute difference relative to Chainlink, in
+// percent; STALENESS is the age in seconds of the older of the two prices.
+func (m *Manager) observeOracle(ctx context.Context, rule *core.WatchAlertRule) ([]*core.WatchObservation, string, error) {
+	client, err := m.chainlinkClient(rule.ChainID)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to create Chainlink client for chain %s: %w", rule.ChainID, err)
+	}
+	chainName := client.GetChainName()
+	symbol := rule.Params.Symbol
+
+	pythPrice, err := m.pyth.GetPrice(ctx, symbol, rule.Params.PythFeedID)
+	if err != nil {
+		return nil, chainName, fmt.Errorf("failed to fetch Pyth price for %s: %w", symbol, err)
+	}
+	clPrice, err := client.GetPrice(ctx, symbol, rule.Params.ChainlinkFeed)
+	if err != nil {
+		return nil, chainName, fmt.Errorf("failed to fetch Chainlink price for %s on %s: %w", symbol, chainName, err)
+	}
+	if err := pythPrice.Validate(); err != nil {
+		return nil, chainName, fmt.Errorf("invalid Pyth price for %s: %w", symbol, err)
+	}
+	if err := clPrice.Validate(); err != nil {
+		return nil, chainName, fmt.Errorf("invalid Chainlink price for %s: %w", symbol, err)
+	}
+
+	deviation := math.Abs(pythPrice.Price-clPrice.Price) / clPrice.Price * 100
+	oldest := pythPrice.Timestamp
+	if clPrice.Timestamp.Before(oldest) {
+		oldest = clPrice.Timestamp
+	}
+	staleness := time.Since(oldest).Seconds()
+
+	var value float64
+	switch rule.Field {
+	case "DEVIATION":
+		value = deviation
+	case "STALENESS":
+		value = staleness
+	default:
+		return nil, chainName, fmt.Errorf("unsupported oracle field: %s", rule.Field)
+	}
+
+	return []*core.WatchObservation{{
+		Value: value,
+		Title: fmt.Sprintf("%s Pyth $%.6f vs Chainlink $%.6f (%.4f%% apart)", symbol, pythPrice.Price, clPrice.Price, deviation),
+		Details: []core.WatchDetail{
+			{Label: "Symbol", Value: symbol},
+			{Label: "Pyth Price", Value: fmt.Sprintf("$%.6f", pythPrice.Price)},
+			{Label: "Pyth Updated", Value: pythPrice.Timestamp.UTC().Format(time.RFC3339)},
+			{Label: "Chainlink Price", Value: fmt.Sprintf("$%.6f", clPrice.Price)},
+			{Label: "Chainlink Updated", Value: clPrice.Timestamp.UTC().Format(time.RFC3339)},
+			{Label: "Deviation", Value: fmt.Sprintf("%.4f%%", deviation)},
+			{Label: "Chainlink Feed", Value: rule.Params.ChainlinkFeed},
+		},
+	}}, chainName, nil
+}
+
+// snapshotPageSize is how many of a space's most recent proposals are checked per tick
+const snapshotPageSize = 20
+
+// observeSnapshot reports Snapshot proposals for a space. CREATED emits one event
+// per proposal; ENDING emits one per active proposal with Value set to the hours
+// left, so the rule's threshold (e.g. <= 24) decides when it fires.
+func (m *Manager) observeSnapshot(ctx context.Context, rule *core.WatchAlertRule) ([]*core.WatchObservation, string, error) {
+	chainName := "Snapshot"
+	proposals, err := m.snapshot.GetRecentProposals(ctx, rule.Params.SnapshotSpace, snapshotPageSize)
+	if err != nil {
+		return nil, chainName, fmt.Errorf("failed to fetch Snapshot proposals for %s: %w", rule.Params.SnapshotSpace, err)
+	}
+
+	var created, open []*governance.Proposal
+	for _, p := range proposals {
+		created = append(created, p)
+		if p.State == "active" {
+			open = append(open, p)
+		}
+	}
+	observations, err := proposalObservations(rule, rule.Params.SnapshotSpace, created, open)
+	return observations, chainName, err
+}
+
+func (m *Manager) governorClient(chainID string) (*governance.GovernorClient, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if c, ok := m.governors[chainID]; ok {
+		return c, nil
+	}
+	c, err := governance.NewGovernorClient(chainID)
+	if err != nil {
+		return nil, err
+	}
+	m.governors[chainID] = c
+	return c, nil
+}
+
+// observeGovernor reports proposals of an on-chain Governor contract, with the
+// same CREATED / ENDING semantics as observeSnapshot.
+func (m *Manager) observeGovernor(ctx context.Context, rule *core.WatchAlertRule) ([]*core.WatchObservation, string, error) {
+	client, err := m.governorClient(rule.ChainID)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to create Governor client for chain %s: %w", rule.ChainID, err)
+	}
+	chainName := client.GetChainName()
+
+	cursorKey := fmt.Sprintf("%d|%s", rule.ID, strings.ToLower(rule.Params.GovernorAddress))
+	created, open, err := client.GetProposals(ctx, cursorKey, rule.Params.GovernorAddress)
+	if err != nil {
+		return nil, chainName, fmt.Errorf("failed to fetch proposals for Governor %s on %s: %w", rule.Params.GovernorAddress, chainName, err)
+	}
+	observations, err := proposalObservations(rule, rule.DisplayAddress(rule.Params.GovernorAddress), created, open)
+	return observations, chainName, err
+}
+
+func proposalObservations(rule *core.WatchAlertRule, dao string, created, open []*governance.Proposal) ([]*core.WatchObservation, error) {
+	var proposals []*governance.Proposal
+	switch governance.FieldType(rule.Field) {
+	case governance.FieldCreated:
+		proposals = created
+	case governance.FieldEnding:
+		proposals = open
+	default:
+		return nil, fmt.Errorf("unsupported governance field: %s", rule.Field)
+	}
+
+	observations := make([]*core.WatchObservation, 0, len(proposals))
+	for _, p := range proposals {
+		hoursLeft := time.Until(p.End).Hours()
+		if hoursLeft < 0 {
+			hoursLeft = 0
+		}
+
+		obs := &core.WatchObservation{
+			Key:   rule.Field + ":" + p.ID,
+			Value: hoursLeft,
+			Details: []core.WatchDetail{
+				{Label: "DAO", Value: dao},
+				{Label: "Proposal", Value: p.Title},
+				{Label: "Author", Value: p.Author},
+				{Label: "State", Value: p.State},
+				{Label: "Voting Ends", Value: p.End.UTC().Format("2006-01-02 15:04 UTC")},
+			},
+			URL: p.URL,
+		}
+		if governance.FieldType(rule.Field) == governance.FieldCreated {
+			obs.Title = fmt.Sprintf("new proposal: %s", p.Title)
+		} else {
+			obs.Title = fmt.Sprintf("voting ends in %.1fh: %s", hoursLeft, p.Title)
+			obs.AlertOnPrime = true
+		}
+		if p.TotalVP > 0 && len(p.Choices) == len(p.Scores) {
+			for i, choice := range p.Choices {
+				obs.Details = append(obs.Details, core.WatchDetail{
+					Label: "Votes: " + choice,
+					Value: fmt.Sprintf("%.2f (%.1f%%)", p.Scores[i], p.Scores[i]/p.TotalVP*100),
+				})
+			}
+		}
+		observations = append(observations, obs)
+	}
+	return observations, nil
+}
+
+func (m *Manager) bridgeClient(chainID string) (*bridge.Client, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if c, ok := m.bridges[chainID]; ok {
+		return c, nil
+	}
+	c, err := bridge.NewClient(chainID)
+	if err != nil {
+		return nil, err
+	}
+	m.bridges[chainID] = c
+	return c, nil
+}
+
+// observeBridge sums each token's balance across the bridge addresses and reports
+// the largest drop (%) of any token versus its peak within the rule's window.
+// Tokens are compared individually, so no USD pricing is needed.
+func (m *Manager) observeBridge(ctx context.Context, rule *core.WatchAlertRule) ([]*core.WatchObservation, string, error) {
+	client, err := m.bridgeClient(rule.ChainID)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to create bridge client for chain %s: %w", rule.ChainID, err)
+	}
+	chainName := client.GetChainName()
+
+	if bridge.FieldType(rule.Field) != bridge.FieldDrop {
+		return nil, chainName, fmt.Errorf("unsupported bridge field: %s", rule.Field)
+	}
+
+	balances, err := client.GetBalances(ctx, rule.Params.BridgeAddresses, rule.Params.TokenAddresses)
+	if err != nil {
+		return nil, chainName, fmt.Errorf("failed to fetch bridge balances on %s: %w", chainName, err)
+	}
+
+	window := bridge.DefaultWindow
+	if rule.Params.WindowMinutes > 0 {
+		window = time.Duration(rule.Params.WindowMinutes) * time.Minute
+	}
+
+	var worst *bridge.TokenBalance
+	var worstDrop, worstPeak float64
+	details := make([]core.WatchDetail, 0, len(balances)+1)
+	for _, b := range balances {
+		drop, peak := m.tvl.Record(fmt.Sprintf("%d|%s", rule.ID, strings.ToLower(b.Token)), b.Balance, window)
+		if worst == nil || drop > worstDrop {
+			worst, worstDrop, worstPeak = b, drop, peak
+		}
+		details = append(details, core.WatchDetail{
+			Label: b.Symbol,
+			Value: fmt.Sprintf("%.4f (-%.2f%% vs %s peak)", b.Balance, drop, window),
+		})
+	}
+	if worst == nil {
+		return nil, chainName, nil
+	}
+	bridges := make([]string, 0, len(rule.Params.BridgeAddresses))
+	for _, addr := range rule.Params.BridgeAddresses {
+		bridges = append(bridges, rule.DisplayAddress(addr))
+	}
+	details = append(details, core.WatchDetail{Label: "Bridge Addresses", Value: strings.Join(bridges, ", ")})
+
+	return []*core.WatchObservation{{
+		Value:   worstDrop,
+		Title:   fmt.Sprintf("%s balance %.4f, down %.2f%% from %.4f", worst.Symbol, worst.Balance, worstDrop, worstPeak),
+		Details: details,
+	}}, chainName, nil
+}
+
+// observeMempool reports a single Bitcoin fee estimate or mempool size reading.
+func (m *Manager) observeMempool(ctx context.Context, rule *core.WatchAlertRule) ([]*core.WatchObservation, string, error) {
+	value, err := m.bitcoin.GetMetric(ctx, bitcoin.FieldType(rule.Field))
+	if err != nil {
+		return nil, bitcoin.ChainName, fmt.Errorf("failed to fetch Bitcoin %s: %w", rule.Field, err)
+	}
+
+	unit := "sat/vB"
+	switch bitcoin.FieldType(rule.Field) {
+	case bitcoin.FieldMempoolCount:
+		unit = "txs"
+	case bitcoin.FieldMempoolVSize:
+		unit = "vMB"
+	}
+
+	return []*core.WatchObservation{{
+		Value: value,
+		Title: fmt.Sprintf("%s is %g %s", rule.Field, value, unit),
+		Details: []core.WatchDetail{
+			{Label: "Metric", Value: rule.Field},
+			{Label: "Value", Value: fmt.Sprintf("%g %s", value, unit)},
+		},
+		URL: m.bitcoin.BaseURL(),
+	}}, bitcoin.ChainName, nil
+}
+
+// btcAddresses returns the watched address set of a rule, deriving (and caching) xpub addresses.
+func (m *Manager) btcAddresses(rule *core.WatchAlertRule) ([]string, error) {
+	if rule.Params.BTCAddress != "" {
+		return []string{rule.Params.BTCAddress}, nil
+	}
+
+	key := fmt.Sprintf("%s|%d", rule.Params.Xpub, rule.Params.XpubGap)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if addrs, ok := m.xpubs[key]; ok {
+		return addrs, nil
+	}
+	addrs, err := bitcoin.DeriveAddresses(rule.Params.Xpub, rule.Params.XpubGap)
+	if err != nil {
+		return nil, err
+	}
+	m.xpubs[key] = addrs
+	return addrs, nil
+}
+
+// observeBTCAddress reports activity on a Bitcoin address or xpub. RECEIVED and
+// SPENT emit one event per transaction with the net BTC moved as Value, so the
+// rule's threshold filters small payments; BALANCE is a measured reading.
+func (m *Manager) observeBTCAddress(ctx context.Context, rule *core.WatchAlertRule) ([]*core.WatchObservation, string, error) {
+	addrs, err := m.btcAddresses(rule)
+	if err != nil {
+		return nil, bitcoin.ChainName, fmt.Errorf("failed to resolve Bitcoin addresses: %w", err)
+	}
+	watched := rule.Params.BTCAddress
+	if watched == "" {
+		watched = rule.Params.Xpub[:min(16, len(rule.Params.Xpub))] + "..."
+	}
+
+	field := bitcoin.FieldType(rule.Field)
+	if field == bitcoin.FieldBalance {
+		balance, err := m.bitcoin.GetBalance(ctx, addrs)
+		if err != nil {
+			return nil, bitcoin.ChainName, err
+		}
+		obs := &core.WatchObservation{
+			Value: balance,
+			Title: fmt.Sprintf("balance of %s is %.8f BTC", watched, balance),
+			Details: []core.WatchDetail{
+				{Label: "Address", Value: watched},
+				{Label: "Balance", Value: fmt.Sprintf("%.8f BTC", balance)},
+			},
+		}
+		if rule.Params.BTCAddress != "" {
+			obs.URL = m.bitcoin.AddressURL(rule.Params.BTCAddress)
+		}
+		return []*core.WatchObservation{obs}, bitcoin.ChainName, nil
+	}
+	if field != bitcoin.FieldReceived && field != bitcoin.FieldSpent {
+		return nil, bitcoin.ChainName, fmt.Errorf("unsupported btc_address field: %s", rule.Field)
+	}
+
+	txs, err := m.bitcoin.GetTransactions(ctx, addrs)
+	if err != nil {
+		return nil, bitcoin.ChainName, err
+	}
+
+	observations := make([]*core.WatchObservation, 0)
+	for _, tx := range txs {
+		net := tx.Net()
+		var amount float64
+		var title string
+		switch {
+		case field == bitcoin.FieldReceived && net > 0:
+			amount = net
+			title = fmt.Sprintf("%s received %.8f BTC", watched, amount)
+		case field == bitcoin.FieldSpent && net < 0:
+			amount = -net
+			title = fmt.Sprintf("%s sent %.8f BTC", watched, amount)
+		default:
+			continue
+		}
+
+		status := "unconfirmed"
+		if tx.Confirmed {
+			status = fmt.Sprintf("confirmed in block %d", tx.BlockHeight)
+		}
+		observations = append(observations, &core.WatchObservation{
+			Key:   rule.Field + ":" + tx.TxID,
+			Value: amount,
+			Title: title,
+			Details: []core.WatchDetail{
+				{Label: "Address", Value: watched},
+				{Label: "Amount", Value: fmt.Sprintf("%.8f BTC", amount)},
+				{Label: "Status", Value: status},
+				{Label: "Tx ID", Value: tx.TxID},
+			},
+			URL: m.bitcoin.TxURL(tx.TxID),
+		})
+	}
+	return observations, bitcoin.ChainName, nil
+}
+
+// LogWatchRules logs information about watch rules
+func LogWatchRules(rules []*core.WatchAlertRule) {
+	if len(rules) == 0 {
+		return
+	}
+
+	slog.Info("📊 Monitoring watch sources", "rules", len(rules))
+	for _, rule := range rules {
+		if rule.Enabled {
+			label := rule.Label
+			if label == "" {
+				label = rule.Source
+			}
+			slog.Info("📊 Watching", logger.FieldSymbol, label, "source", rule.Source, logger.FieldChain, rule.ChainID, "field", rule.Field)
+		}
+	}
+}
@@ -3,9 +3,16 @@ package logger
 import (
 	"bytes"
 	"context"
+	"crypto/tls"
+	"crypto/x509"
 	"encoding/json"
-	"strings"
+	"fmt"
+	"maps"
+	"net/http"
+	"os"
+	"path/filepath"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/elastic/go-elasticsearch/v9"
@@ -17,37 +24,161 @@ type ESConfig struct {
 	Enabled   bool
 	Addresses []string
 	Index     string
+
+	// Authentication: an API key (base64 id:key, as Elasticsearch returns
+	// it) or else a username and password
+	Username string
+	Password string
+	APIKey   string
+
+	CACert             string // PEM file of the CA that signed the cluster's certificate, for https addresses
+	InsecureSkipVerify bool   // Don't verify the cluster's certificate (testing only)
+	OpenSearch         bool   // The cluster is OpenSearch, which the client's product check would refuse
+
+	// Setup creates the index template and ILM policy of the index on
+	// startup, making it a data stream deleted after RetentionDays (0 keeps it)
+	Setup         bool
+	RetentionDays int
+
+	// BufferDir keeps the lines Elasticsearch can't take while it's down, up
+	// to BufferMaxSize bytes, and replays them once it's back (a subdirectory
+	// per service). Without it, or with no size, the lines are dropped.
+	BufferDir     string
+	BufferMaxSize int64
+}
+
+// NewESClient creates a client of the cluster of cfg, with its credentials
+// and TLS settings
+func NewESClient(cfg *ESConfig) (*elasticsearch.Client, error) {
+	tlsConfig := &tls.Config{InsecureSkipVerify: cfg.InsecureSkipVerify}
+	if cfg.CACert != "" {
+		pem, err := os.ReadFile(cfg.CACert)
+		if err != nil {
+			return nil, fmt.Errorf("read CA certificate: %w", err)
+		}
+		tlsConfig.RootCAs = x509.NewCertPool()
+		if !tlsConfig.RootCAs.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no PEM certificate in %s", cfg.CACert)
+		}
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = tlsConfig
+	var rt http.RoundTripper = transport
+	if cfg.OpenSearch {
+		rt = openSearchTransport{transport}
+	}
+	return elasticsearch.NewClient(elasticsearch.Config{
+		Addresses: cfg.Addresses,
+		Username:  cfg.Username,
+		Password:  cfg.Password,
+		APIKey:    cfg.APIKey,
+		Transport: rt,
+	})
+}
+
+// openSearchTransport marks OpenSearch's responses as Elasticsearch's, which
+// the client checks for; the log queries work on both
+type openSearchTransport struct {
+	next http.RoundTripper
+}
+
+func (t openSearchTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	res, err := t.next.RoundTrip(req)
+	if err == nil && res.Header.Get("X-Elastic-Product") == "" {
+		res.Header.Set("X-Elastic-Product", "Elasticsearch")
+	}
+	return res, err
 }
 
 // logDoc is the document we index per log line.
 type logDoc struct {
-	Timestamp string `json:"@timestamp"`
-	Message   string `json:"message"`
+	Timestamp string
+	Service   string         // One of Services
+	Level     string         // INFO, WARN or ERROR
+	Message   string         // The text line, as in the log file
+	Fields    map[string]any // The record's attributes: rule_id, symbol, chain, ...
+}
+
+// MarshalJSON puts the fields next to @timestamp, service, level and message,
+// which they can't replace
+func (d logDoc) MarshalJSON() ([]byte, error) {
+	m := make(map[string]any, len(d.Fields)+4)
+	maps.Copy(m, d.Fields)
+	m["@timestamp"] = d.Timestamp
+	m["level"] = d.Level
+	m["message"] = d.Message
+	if d.Service != "" {
+		m["service"] = d.Service
+	}
+	return json.Marshal(m)
+}
+
+// Batching and retries of the Elasticsearch bulk requests
+const (
+	esBufferSize       = 4096 // Lines waiting for the writer, the rest goes to the disk buffer
+	esBulkSize         = 500
+	esBulkBytes        = 5 << 20
+	esBulkInterval     = time.Second
+	esBulkMaxRetries   = 5
+	esBulkMinBackoff   = 500 * time.Millisecond
+	esBulkMaxBackoff   = 30 * time.Second
+	esCloseTimeout     = 5 * time.Second // For the last batch on Close
+	esDropReportPeriod = time.Minute
+)
+
+// ESStats counts the lines of the Elasticsearch writer since it started
+type ESStats struct {
+	Indexed  uint64 // Lines Elasticsearch accepted
+	Dropped  uint64 // Lines dropped because the writer's buffers were full
+	Failed   uint64 // Lines Elasticsearch rejected, or still couldn't take after the retries without a disk buffer
+	Spilled  uint64 // Lines written to the disk buffer, to be replayed
+	Buffered int64  // Bytes waiting in the disk buffer
 }
 
-// esWriter implements io.Writer and sends log lines to Elasticsearch asynchronously.
+// esWriter sends log documents to Elasticsearch asynchronously, in bulk
+// requests of up to 500 lines or 5 MB, at least every second. With a disk
+// buffer, the batches it can't send go to disk while Elasticsearch is down.
 type esWriter struct {
+	cfg    *ESConfig
 	client *elasticsearch.Client
 	index  string
-	ch     chan []byte
-	done   chan struct{}
+	setUp  bool // The template and policy are there, see setup
+	ch     chan logDoc
+	done   chan struct{} // Closed by Close once the last batch had esCloseTimeout
 	wg     sync.WaitGroup
+	buffer *esBuffer // nil without a disk buffer
+	down   bool      // The last batch went to the buffer: the next ones go there too, until a replay succeeds
+
+	indexed, dropped, failed, spilled atomic.Uint64
+	reported                          ESStats // Dropped, Failed and Spilled at the last report
+	reportedAt                        time.Time
 }
 
-// newESWriter creates an ES writer and starts the background indexer. Call Close() when done.
-func newESWriter(cfg *ESConfig) (*esWriter, error) {
-	client, err := elasticsearch.NewClient(elasticsearch.Config{
-		Addresses: cfg.Addresses,
-	})
+// newESWriter creates an ES writer of service's lines and starts the
+// background indexer. Call Close() when done.
+func newESWriter(cfg *ESConfig, service string) (*esWriter, error) {
+	client, err := NewESClient(cfg)
 	if err != nil {
 		return nil, err
 	}
+	var buffer *esBuffer
+	if cfg.BufferDir != "" && cfg.BufferMaxSize > 0 {
+		if buffer, err = openESBuffer(filepath.Join(cfg.BufferDir, service), cfg.BufferMaxSize); err != nil {
+			_ = client.Close(context.Background())
+			return nil, fmt.Errorf("failed to open the disk buffer: %w", err)
+		}
+	}
 
 	w := &esWriter{
+		cfg:    cfg,
+		setUp:  !cfg.Setup,
 		client: client,
 		index:  cfg.Index,
-		ch:     make(chan []byte, 1024),
+		ch:     make(chan logDoc, esBufferSize),
 		done:   make(chan struct{}),
+		buffer: buffer,
+
+		reportedAt: time.Now(),
 	}
 	w.wg.Add(1)
 	go w.run()
@@ -56,60 +187,280 @@ func newESWriter(cfg *ESConfig) (*esWriter, error) {
 
 func (w *esWriter) run() {
 	defer w.wg.Done()
-	ctx := context.Background()
+	ticker := time.NewTicker(esBulkInterval)
+	defer ticker.Stop()
+	var batch bytes.Buffer
+	n := 0
+	flush := func() {
+		if n > 0 {
+			w.send(batch.Bytes(), n)
+		}
+		batch.Reset()
+		n = 0
+	}
 	for {
 		select {
-		case <-w.done:
-			return
-		case p, ok := <-w.ch:
+		case doc, ok := <-w.ch:
 			if !ok {
+				flush()
 				return
 			}
-			msg := strings.TrimSuffix(string(p), "\n")
-			if msg == "" {
+			lines, err := bulkLines(doc)
+			if err != nil {
+				w.failed.Add(1)
 				continue
 			}
-			doc := logDoc{
-				Timestamp: time.Now().UTC().Format(time.RFC3339Nano),
-				Message:   msg,
+			batch.Write(lines)
+			if n++; n >= esBulkSize || batch.Len() >= esBulkBytes {
+				flush()
 			}
-			body, _ := json.Marshal(doc)
-			req := esapi.IndexRequest{
-				Index:   w.index,
-				Body:    bytes.NewReader(body),
-				Refresh: "false",
+		case <-ticker.C:
+			flush()
+			if w.buffer != nil {
+				w.replay()
 			}
-			res, err := req.Do(ctx, w.client)
-			if err == nil && res != nil && res.Body != nil {
-				_ = res.Body.Close()
+			w.report()
+		}
+	}
+}
+
+// bulkLines returns the bulk request lines of doc
+func bulkLines(doc logDoc) ([]byte, error) {
+	body, err := json.Marshal(doc)
+	if err != nil {
+		return nil, err
+	}
+	lines := make([]byte, 0, len(body)+len(`{"create":{}}`)+2)
+	lines = append(lines, `{"create":{}}`+"\n"...) // Data streams only take creates
+	lines = append(lines, body...)
+	return append(lines, '\n'), nil
+}
+
+// send indexes a batch of n documents, or writes it to the disk buffer when
+// Elasticsearch is down or can't take it after the retries
+func (w *esWriter) send(body []byte, n int) {
+	if w.down {
+		w.spill(body, n)
+		return
+	}
+	if !w.setUp {
+		w.setUp = w.setup()
+	}
+	if body, n = w.flush(body, n); n == 0 {
+		return
+	}
+	if w.buffer == nil {
+		w.failed.Add(uint64(n))
+		return
+	}
+	w.down = true
+	w.spill(body, n)
+}
+
+// spill writes n documents' bulk lines to the disk buffer, or drops them when
+// it is full
+func (w *esWriter) spill(lines []byte, n int) {
+	if w.buffer.write(lines) {
+		w.spilled.Add(uint64(n))
+	} else {
+		w.dropped.Add(uint64(n))
+	}
+}
+
+// replay sends the disk buffer's segments back to Elasticsearch, oldest
+// first, a few per tick so that the live lines keep flowing. A failed replay
+// marks Elasticsearch as down; a successful one, or an empty buffer, as up.
+func (w *esWriter) replay() {
+	for range esBufferReplayPerTick {
+		path, lines, err := w.buffer.oldest()
+		if path == "" {
+			w.down = false
+			return
+		}
+		if err != nil {
+			w.warn("dropping the unreadable %s: %v", path, err)
+		} else {
+			if !w.setUp {
+				if w.setUp = w.setup(); !w.setUp {
+					w.down = true
+					return
+				}
+			}
+			retry, err := w.bulk(lines, bytes.Count(lines, []byte("\n"))/2)
+			if err != nil {
+				w.down = true
+				return
+			}
+			if len(retry) > 0 && !w.buffer.write(retry) {
+				// Back to the buffer, for a later replay
+				w.dropped.Add(uint64(bytes.Count(retry, []byte("\n")) / 2))
 			}
+			w.down = len(retry) > 0 // Overloaded
+		}
+		if err := w.buffer.remove(path); err != nil {
+			w.warn("failed to remove the replayed %s: %v", path, err)
+			w.down = true
+		}
+		if w.down {
+			return
 		}
 	}
 }
 
-// Write implements io.Writer. It copies the payload and sends it to the indexer goroutine (non-blocking if buffer not full).
-func (w *esWriter) Write(p []byte) (n int, err error) {
-	n = len(p)
-	if n == 0 {
-		return 0, nil
+// flush indexes the n documents of the bulk body, retrying the whole request
+// and the documents Elasticsearch rejects with 429 Too Many Requests, with a
+// growing backoff. It returns the documents it gave up on, and their number.
+func (w *esWriter) flush(body []byte, n int) ([]byte, int) {
+	backoff := esBulkMinBackoff
+	for attempt := 0; ; attempt++ {
+		retry, err := w.bulk(body, n)
+		if err == nil && len(retry) == 0 {
+			return nil, 0
+		}
+		if err == nil {
+			body, n = retry, bytes.Count(retry, []byte("\n"))/2
+		}
+		if attempt == esBulkMaxRetries {
+			reason := "429 Too Many Requests"
+			if err != nil {
+				reason = err.Error()
+			}
+			w.warn("gave up on %d line(s) after %d retries: %s", n, esBulkMaxRetries, reason)
+			return body, n
+		}
+		select {
+		case <-time.After(backoff):
+		case <-w.done:
+			// Closing, and out of time for the last batch
+			return body, n
+		}
+		backoff = min(backoff*2, esBulkMaxBackoff)
+	}
+}
+
+// bulk sends one bulk request of n documents. It returns the bulk body of
+// the documents to retry, and an error when the whole request is to be
+// retried; documents rejected for good are counted as failed.
+func (w *esWriter) bulk(body []byte, n int) ([]byte, error) {
+	res, err := esapi.BulkRequest{
+		Index:      w.index,
+		Body:       bytes.NewReader(body),
+		FilterPath: []string{"errors", "items.*.status", "items.*.error.reason"},
+	}.Do(context.Background(), w.client)
+	if err != nil {
+		return nil, err
 	}
-	// Copy so caller can reuse buffer
-	cp := make([]byte, n)
-	copy(cp, p)
+	defer res.Body.Close()
+	if res.StatusCode == http.StatusTooManyRequests || res.StatusCode >= 500 {
+		return nil, fmt.Errorf("bulk request: %s", res.Status())
+	}
+	if res.IsError() {
+		w.failed.Add(uint64(n))
+		w.warn("rejected %d lines: %s", n, res.String())
+		return nil, nil
+	}
+	var out struct {
+		Errors bool
+		Items  []map[string]struct {
+			Status int
+			Error  struct {
+				Reason string
+			}
+		}
+	}
+	if err := json.NewDecoder(res.Body).Decode(&out); err != nil || !out.Errors {
+		w.indexed.Add(uint64(n))
+		return nil, nil
+	}
+
+	// Documents of the items that failed: two lines each, in the items' order
+	lines := bytes.SplitAfter(body, []byte("\n"))
+	var retry []byte
+	rejected, reason := 0, ""
+	for i, item := range out.Items {
+		for _, result := range item {
+			switch {
+			case result.Status < 300:
+				w.indexed.Add(1)
+			case result.Status == http.StatusTooManyRequests && 2*i+1 < len(lines):
+				retry = append(retry, lines[2*i]...)
+				retry = append(retry, lines[2*i+1]...)
+			default:
+				if rejected++; reason == "" {
+					reason = result.Error.Reason
+				}
+			}
+		}
+	}
+	if rejected > 0 {
+		w.failed.Add(uint64(rejected))
+		w.warn("rejected %d line(s), e.g.: %s", rejected, reason)
+	}
+	return retry, nil
+}
+
+// add sends doc to the indexer goroutine. When its channel is full, doc goes
+// to the disk buffer, or is dropped without one, rather than blocking
+// application logs.
+func (w *esWriter) add(doc logDoc) {
 	select {
-	case w.ch <- cp:
-		return n, nil
+	case w.ch <- doc:
 	default:
-		// Buffer full, drop to avoid blocking application logs
-		return n, nil
+		if w.buffer == nil {
+			w.dropped.Add(1)
+			return
+		}
+		if lines, err := bulkLines(doc); err != nil {
+			w.failed.Add(1)
+		} else {
+			w.spill(lines, 1)
+		}
+	}
+}
+
+// stats returns the writer's counters
+func (w *esWriter) stats() ESStats {
+	s := ESStats{Indexed: w.indexed.Load(), Dropped: w.dropped.Load(), Failed: w.failed.Load(), Spilled: w.spilled.Load()}
+	if w.buffer != nil {
+		s.Buffered = w.buffer.buffered()
+	}
+	return s
+}
+
+// report writes to stderr how many lines were buffered, dropped or failed
+// since the last report, at most once a minute. Not through the logger: Close
+// waits for the writer while holding the logger's lock.
+func (w *esWriter) report() {
+	if time.Since(w.reportedAt) < esDropReportPeriod {
+		return
+	}
+	s := w.stats()
+	if s.Dropped == w.reported.Dropped && s.Failed == w.reported.Failed && s.Spilled == w.reported.Spilled {
+		return
 	}
+	fmt.Fprintf(os.Stderr, "⚠️  Elasticsearch log writer: %d line(s) buffered on disk (%d bytes waiting), %d dropped (buffers full) and %d failed since %s\n",
+		s.Spilled-w.reported.Spilled, s.Buffered, s.Dropped-w.reported.Dropped, s.Failed-w.reported.Failed, w.reportedAt.Format(logTimeLayout))
+	w.reported, w.reportedAt = s, time.Now()
+}
+
+// warn writes a problem of the writer to stderr, see report
+func (w *esWriter) warn(format string, v ...any) {
+	fmt.Fprintf(os.Stderr, "⚠️  Elasticsearch log writer: "+format+"\n", v...)
 }
 
-// Close stops the indexer and releases the ES client.
+// Close indexes the lines still waiting, giving up on retries after a few
+// seconds (the rest goes to the disk buffer, for the next run), stops the
+// indexer and releases the ES client.
 func (w *esWriter) Close() error {
-	close(w.done)
 	close(w.ch)
+	timer := time.AfterFunc(esCloseTimeout, func() { close(w.done) })
 	w.wg.Wait()
+	if !timer.Stop() {
+		<-w.done // The timer's close ran, or is about to
+	}
+	if w.buffer != nil {
+		_ = w.buffer.Close()
+	}
 	if w.client != nil {
 		return w.client.Close(context.Background())
 	}
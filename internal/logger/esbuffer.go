@@ -0,0 +1,165 @@
+package logger
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Disk buffer of the Elasticsearch writer
+const (
+	esBufferSegmentSize   = esBulkBytes // A segment is replayed as one bulk request
+	esBufferReplayPerTick = 8           // Segments replayed per second at most, next to the live lines
+)
+
+// esBuffer keeps on disk the bulk lines Elasticsearch couldn't take, in
+// segment files <seq>.ndjson of up to 5 MB, until they are replayed. It
+// survives restarts: the segments left by the last run are replayed too.
+type esBuffer struct {
+	dir     string
+	maxSize int64
+
+	mu       sync.Mutex
+	segments []string // Oldest first; the last one is cur while it is open
+	sizes    map[string]int64
+	size     int64 // Of all the segments
+	cur      *os.File
+	curSize  int64
+	seq      uint64
+}
+
+// openESBuffer opens the buffer in dir, creating the directory if needed
+func openESBuffer(dir string, maxSize int64) (*esBuffer, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	b := &esBuffer{dir: dir, maxSize: maxSize, sizes: make(map[string]int64)}
+	var seqs []uint64
+	for _, e := range entries {
+		seq, err := strconv.ParseUint(strings.TrimSuffix(e.Name(), ".ndjson"), 10, 64)
+		if e.IsDir() || !strings.HasSuffix(e.Name(), ".ndjson") || err != nil {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil {
+			continue
+		}
+		if info.Size() == 0 {
+			_ = os.Remove(filepath.Join(dir, e.Name()))
+			continue
+		}
+		seqs = append(seqs, seq)
+		b.sizes[b.path(seq)] = info.Size()
+		b.size += info.Size()
+	}
+	sort.Slice(seqs, func(i, j int) bool { return seqs[i] < seqs[j] })
+	for _, seq := range seqs {
+		b.segments = append(b.segments, b.path(seq))
+		b.seq = seq
+	}
+	return b, nil
+}
+
+func (b *esBuffer) path(seq uint64) string {
+	return filepath.Join(b.dir, fmt.Sprintf("%012d.ndjson", seq))
+}
+
+// write appends bulk lines to the newest segment. It returns false when they
+// don't fit in the buffer, or couldn't be written.
+func (b *esBuffer) write(lines []byte) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	size := int64(len(lines))
+	if b.size+size > b.maxSize {
+		return false
+	}
+	if b.cur != nil && b.curSize > 0 && b.curSize+size > esBufferSegmentSize {
+		b.closeCurrent()
+	}
+	if b.cur == nil {
+		b.seq++
+		f, err := os.OpenFile(b.path(b.seq), os.O_CREATE|os.O_WRONLY|os.O_EXCL, 0644)
+		if err != nil {
+			return false
+		}
+		b.cur, b.curSize = f, 0
+		b.segments = append(b.segments, f.Name())
+	}
+	n, err := b.cur.Write(lines)
+	b.curSize += int64(n)
+	b.sizes[b.cur.Name()] += int64(n)
+	b.size += int64(n)
+	if err != nil {
+		// Start a new segment: this one ends with a partial line, which the
+		// replay's bulk request will refuse
+		b.closeCurrent()
+		return false
+	}
+	return true
+}
+
+// closeCurrent closes the segment being written, if any. The caller holds mu.
+func (b *esBuffer) closeCurrent() {
+	if b.cur != nil {
+		_ = b.cur.Close()
+		b.cur = nil
+	}
+}
+
+// oldest returns the oldest segment and its lines, closing it first when it
+// is the one being written. The path is "" when the buffer is empty.
+func (b *esBuffer) oldest() (string, []byte, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if len(b.segments) == 0 {
+		return "", nil, nil
+	}
+	path := b.segments[0]
+	if b.cur != nil && b.cur.Name() == path {
+		b.closeCurrent()
+	}
+	lines, err := os.ReadFile(path)
+	return path, lines, err
+}
+
+// remove deletes a segment once it's replayed
+func (b *esBuffer) remove(path string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for i, p := range b.segments {
+		if p == path {
+			b.segments = append(b.segments[:i], b.segments[i+1:]...)
+			break
+		}
+	}
+	b.size -= b.sizes[path]
+	delete(b.sizes, path)
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// buffered returns the bytes waiting in the buffer
+func (b *esBuffer) buffered() int64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.size
+}
+
+// Close closes the segment being written; the segments stay for the next run
+func (b *esBuffer) Close() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.closeCurrent()
+	return nil
+}
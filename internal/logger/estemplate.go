@@ -0,0 +1,139 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/elastic/go-elasticsearch/v9/esapi"
+)
+
+// Rollover of the log data stream's backing indices
+const (
+	esRolloverMaxAge       = "1d"
+	esRolloverMaxShardSize = "50gb"
+	esSetupTimeout         = 30 * time.Second
+)
+
+// setup creates the ILM policy and the index template of the log index,
+// which becomes a data stream rolled over daily (or at 50 GB) and deleted
+// after RetentionDays. It runs before the first bulk request, so the index
+// isn't created with dynamic mappings first. It returns false when it is to
+// be tried again, because Elasticsearch couldn't be reached.
+func (w *esWriter) setup() bool {
+	ctx, cancel := context.WithTimeout(context.Background(), esSetupTimeout)
+	defer cancel()
+
+	policy := w.index + "-policy"
+	settings := map[string]any{}
+	if !w.cfg.OpenSearch { // OpenSearch has ISM policies instead
+		phases := map[string]any{
+			"hot": map[string]any{
+				"actions": map[string]any{
+					"rollover": map[string]any{"max_age": esRolloverMaxAge, "max_primary_shard_size": esRolloverMaxShardSize},
+				},
+			},
+		}
+		if w.cfg.RetentionDays > 0 {
+			phases["delete"] = map[string]any{
+				"min_age": strconv.Itoa(w.cfg.RetentionDays) + "d",
+				"actions": map[string]any{"delete": map[string]any{}},
+			}
+		}
+		done, err := w.put(ctx, map[string]any{"policy": map[string]any{"phases": phases}}, func(body *bytes.Reader) esapi.Request {
+			return esapi.ILMPutLifecycleRequest{Policy: policy, Body: body}
+		})
+		if !done {
+			return false
+		}
+		if err != nil {
+			w.warn("failed to create the ILM policy %s: %v", policy, err)
+		} else {
+			settings["index.lifecycle.name"] = policy
+		}
+	}
+
+	template := map[string]any{
+		"index_patterns": []string{w.index},
+		"data_stream":    map[string]any{},
+		"priority":       200,
+		"template": map[string]any{
+			"settings": settings,
+			"mappings": map[string]any{
+				"dynamic_templates": attributeTemplates(),
+				"properties": map[string]any{
+					"@timestamp": map[string]any{"type": "date"},
+					"message":    map[string]any{"type": "text"},
+					"level":      map[string]any{"type": "keyword"},
+					"service":    map[string]any{"type": "keyword"},
+				},
+			},
+		},
+		"_meta": map[string]any{"description": "crypto-alert logs"},
+	}
+	done, err := w.put(ctx, template, func(body *bytes.Reader) esapi.Request {
+		return esapi.IndicesPutIndexTemplateRequest{Name: w.index, Body: body}
+	})
+	if !done {
+		return false
+	}
+	if err != nil {
+		w.warn("failed to create the index template %s, indexing with dynamic mappings: %v", w.index, err)
+		return true
+	}
+
+	// An index of an older version keeps taking the lines, without the template
+	res, err := esapi.IndicesGetDataStreamRequest{Name: []string{w.index}}.Do(ctx, w.client)
+	if err != nil {
+		return false
+	}
+	res.Body.Close()
+	if res.StatusCode == http.StatusNotFound {
+		if res, err := (esapi.IndicesExistsRequest{Index: []string{w.index}}).Do(ctx, w.client); err == nil {
+			res.Body.Close()
+			if res.StatusCode == http.StatusOK {
+				w.warn("%s is a regular index, not a data stream: the template and the ILM policy only apply once it is reindexed into one", w.index)
+			}
+		}
+	}
+	return true
+}
+
+// attributeTemplates map the records' attributes as keywords, whatever
+// their first value, so that rule_id:42 matches whether it was logged as a
+// number or a string
+func attributeTemplates() []any {
+	var templates []any
+	for _, t := range []string{"string", "long", "double", "boolean"} {
+		templates = append(templates, map[string]any{"attributes_" + t: map[string]any{
+			"match_mapping_type": t,
+			"mapping":            map[string]any{"type": "keyword", "ignore_above": 1024},
+		}})
+	}
+	return templates
+}
+
+// put sends the request of newReq with doc as its body. done is false when
+// the request is to be retried; err is set when Elasticsearch refused it.
+func (w *esWriter) put(ctx context.Context, doc any, newReq func(*bytes.Reader) esapi.Request) (done bool, err error) {
+	body, err := json.Marshal(doc)
+	if err != nil {
+		return true, err
+	}
+	res, err := newReq(bytes.NewReader(body)).Do(ctx, w.client)
+	if err != nil {
+		return false, nil
+	}
+	defer res.Body.Close()
+	if res.StatusCode == http.StatusTooManyRequests || res.StatusCode >= 500 {
+		return false, nil
+	}
+	if res.IsError() {
+		return true, fmt.Errorf("%s", res.String())
+	}
+	return true, nil
+}
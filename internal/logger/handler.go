@@ -0,0 +1,183 @@
+package logger
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Attribute keys the services log their records with, indexed as fields of
+// the Elasticsearch documents
+const (
+	FieldRuleID   = "rule_id"  // ID of the alert rule
+	FieldKind     = "kind"     // Rule kind: token, defi, predict or watch
+	FieldSymbol   = "symbol"   // Token symbol, DeFi protocol, prediction market or watch source
+	FieldQuestion = "question" // Question of a prediction market
+	FieldTokenID  = "token_id" // Outcome token of a prediction market
+	FieldChain    = "chain"    // Chain name
+	FieldTopic    = "topic"    // Event transport topic
+	FieldEventID  = "event_id" // ID of an alert event
+	FieldChannel  = "channel"  // Notification channel, e.g. email or telegram
+	FieldError    = "error"
+)
+
+// Log output formats of stdout; the log files are always text
+const (
+	FormatText = "text"
+	FormatJSON = "json"
+)
+
+// handler is the slog.Handler of a Logger. Records below info are dropped.
+type handler struct {
+	l      *Logger
+	attrs  []slog.Attr // Of WithAttrs, with their groups in the keys
+	prefix string      // Groups of WithGroup, "group." each
+}
+
+// Handler returns the slog.Handler writing records to l: text lines to stdout
+// (or JSON with the json format) and the day's file, and documents with the
+// records' attributes as fields to Elasticsearch
+func (l *Logger) Handler() slog.Handler {
+	return &handler{l: l}
+}
+
+func (h *handler) Enabled(_ context.Context, level slog.Level) bool {
+	return level >= slog.LevelInfo
+}
+
+func (h *handler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	h2 := *h
+	h2.attrs = slices.Clip(h.attrs)
+	for _, a := range attrs {
+		h2.attrs = appendAttr(h2.attrs, h.prefix, a)
+	}
+	return &h2
+}
+
+func (h *handler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	h2 := *h
+	h2.prefix += name + "."
+	return &h2
+}
+
+func (h *handler) Handle(_ context.Context, r slog.Record) error {
+	attrs := slices.Clip(h.attrs)
+	r.Attrs(func(a slog.Attr) bool {
+		attrs = appendAttr(attrs, h.prefix, a)
+		return true
+	})
+	h.l.write(r.Time, levelName(r.Level, r.Message), r.Message, attrs)
+	return nil
+}
+
+// appendAttr appends a with its value resolved, flattening groups into
+// dotted keys; empty attributes are left out
+func appendAttr(attrs []slog.Attr, prefix string, a slog.Attr) []slog.Attr {
+	a.Value = a.Value.Resolve()
+	if a.Equal(slog.Attr{}) {
+		return attrs
+	}
+	if a.Value.Kind() == slog.KindGroup {
+		if a.Key != "" {
+			prefix += a.Key + "."
+		}
+		for _, ga := range a.Value.Group() {
+			attrs = appendAttr(attrs, prefix, ga)
+		}
+		return attrs
+	}
+	a.Key = prefix + a.Key
+	return append(attrs, a)
+}
+
+// levelName is the level of a record: WARN and ERROR for records at those
+// levels, and for info records the level their message implies (see
+// LevelOf), so lines of the log package keep theirs
+func levelName(level slog.Level, msg string) string {
+	switch {
+	case level >= slog.LevelError:
+		return LevelError
+	case level >= slog.LevelWarn:
+		return LevelWarn
+	}
+	return LevelOf(msg)
+}
+
+// textLine formats a record the way the log package did, "2006/01/02
+// 15:04:05 [service] message", followed by its attributes as key=value. The
+// level goes before the message when the message doesn't imply it, so
+// LevelOf derives it from the line.
+func textLine(t time.Time, service, level, msg string, attrs []slog.Attr) string {
+	var b strings.Builder
+	b.WriteString(t.Format(logTimeLayout))
+	b.WriteByte(' ')
+	b.WriteString(ServiceLabel(service))
+	if LevelOf(msg) != level {
+		b.WriteString(level)
+		b.WriteByte(' ')
+	}
+	b.WriteString(msg)
+	for _, a := range attrs {
+		b.WriteByte(' ')
+		b.WriteString(a.Key)
+		b.WriteByte('=')
+		s := a.Value.String()
+		if s == "" || strings.ContainsFunc(s, func(r rune) bool { return r <= ' ' || r == '"' || r == '=' }) {
+			s = strconv.Quote(s)
+		}
+		b.WriteString(s)
+	}
+	b.WriteByte('\n')
+	return b.String()
+}
+
+// jsonLine formats a record as a JSON object with time, level, service, msg
+// and its attributes
+func jsonLine(t time.Time, service, level, msg string, attrs []slog.Attr) []byte {
+	m := fields(attrs)
+	m["time"] = t.UTC().Format(time.RFC3339Nano)
+	m["level"] = level
+	m["msg"] = msg
+	if service != "" {
+		m["service"] = service
+	}
+	b, _ := json.Marshal(m)
+	return append(b, '\n')
+}
+
+// fields are the attributes as JSON values by key
+func fields(attrs []slog.Attr) map[string]any {
+	m := make(map[string]any, len(attrs)+4)
+	for _, a := range attrs {
+		m[a.Key] = jsonValue(a.Value)
+	}
+	return m
+}
+
+func jsonValue(v slog.Value) any {
+	switch v.Kind() {
+	case slog.KindTime:
+		return v.Time().UTC().Format(time.RFC3339Nano)
+	case slog.KindDuration:
+		return v.Duration().String()
+	case slog.KindAny:
+		switch a := v.Any().(type) {
+		case error:
+			return a.Error()
+		case fmt.Stringer:
+			return a.String()
+		}
+		if _, err := json.Marshal(v.Any()); err != nil {
+			return v.String()
+		}
+	}
+	return v.Any()
+}
@@ -0,0 +1,62 @@
+package logger
+
+import (
+	"strings"
+	"time"
+)
+
+// Log levels, derived from how the services start their log lines
+const (
+	LevelInfo  = "INFO"
+	LevelWarn  = "WARN"
+	LevelError = "ERROR"
+)
+
+// Levels are the log levels, lowest first
+var Levels = []string{LevelInfo, LevelWarn, LevelError}
+
+// ParseLevel returns the level s names, case-insensitively and also taking
+// warning for WARN; ok is false for anything else
+func ParseLevel(s string) (level string, ok bool) {
+	switch strings.ToUpper(strings.TrimSpace(s)) {
+	case LevelInfo:
+		return LevelInfo, true
+	case LevelWarn, "WARNING":
+		return LevelWarn, true
+	case LevelError:
+		return LevelError, true
+	}
+	return "", false
+}
+
+// LevelOf derives the level of a log line, with or without its timestamp and
+// service label: ERROR for lines starting with ❌, 💥 or 🪦 or with a word
+// like "Error" or "Failed", WARN for ⚠️ or "Warning", and INFO for the rest
+func LevelOf(line string) string {
+	line = strings.TrimSpace(line)
+	if len(line) >= len(logTimeLayout) {
+		if _, err := time.Parse(logTimeLayout, line[:len(logTimeLayout)]); err == nil {
+			line = strings.TrimSpace(line[len(logTimeLayout):])
+		}
+	}
+	_, line = CutService(line)
+	for _, prefix := range []string{"❌", "💥", "🪦"} {
+		if strings.HasPrefix(line, prefix) {
+			return LevelError
+		}
+	}
+	if strings.HasPrefix(line, "⚠") {
+		return LevelWarn
+	}
+
+	word, _, _ := strings.Cut(strings.ToLower(line), " ")
+	for _, prefix := range []string{"error", "fatal", "panic", "failed"} {
+		if strings.HasPrefix(word, prefix) {
+			return LevelError
+		}
+	}
+	if strings.HasPrefix(word, "warn") {
+		return LevelWarn
+	}
+	return LevelInfo
+}
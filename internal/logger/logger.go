@@ -2,135 +2,182 @@ package logger
 
 import (
 	"fmt"
-	"io"
 	"log"
+	"log/slog"
 	"os"
-	"path/filepath"
+	"strings"
 	"sync"
 	"time"
 )
 
+// Timestamp layout of the log lines, log.LstdFlags's
+const logTimeLayout = "2006/01/02 15:04:05"
+
 var (
 	defaultLogger *Logger
 	once          sync.Once
 )
 
-// Logger wraps the standard log.Logger with date-based file rotation and optional Elasticsearch shipping.
+// Config holds the settings of a Logger.
+type Config struct {
+	Dir     string      // Directory of the day's log files
+	Service string      // Labels the lines, one of Services
+	Format  string      // Format of stdout, FormatText (default) or FormatJSON
+	MaxSize int64       // Bytes after which the day's file is rotated into a gzipped segment, 0 for no limit
+	ES      *ESConfig   // Optional Elasticsearch shipping
+	Loki    *LokiConfig // Optional Grafana Loki shipping
+}
+
+// Logger is a structured log/slog destination with date- and size-based file rotation and optional Elasticsearch and Loki shipping.
 type Logger struct {
 	logDir      string
+	service     string // Labels the lines, see ServiceLabel
+	json        bool   // Writes JSON to stdout instead of text lines
+	maxSize     int64
 	currentDate string
 	logFile     *os.File
-	logger      *log.Logger
+	size        int64     // Of the day's file, as far as we know
+	checked     time.Time // When the day's file was last checked for a rotation by another service
+	compressing sync.WaitGroup
+	closing     chan struct{} // Closed by Close: compress the rotated segments right away
+	closeOnce   sync.Once
 	esWriter    *esWriter
+	lokiWriter  *lokiWriter
 	mu          sync.Mutex
 }
 
 // InitLogger initializes the default logger with the specified log directory and optional ES config.
-// If esConfig is non-nil and Enabled, logs are also shipped to Elasticsearch (v9.3.0).
-func InitLogger(logDir string, esConfig *ESConfig) error {
+// If cfg.ES is non-nil and Enabled, logs are also shipped to Elasticsearch (v9.3.0), and with a cfg.Loki URL to Loki.
+// It becomes slog's default logger, which the log package also writes to.
+func InitLogger(cfg Config) error {
 	var err error
 	once.Do(func() {
-		defaultLogger, err = NewLogger(logDir, esConfig)
+		defaultLogger, err = NewLogger(cfg)
 		if err != nil {
 			return
 		}
-		// Replace standard log output
-		log.SetOutput(defaultLogger)
-		log.SetFlags(log.LstdFlags)
+		// Replace standard log output; the handler writes the timestamps
+		slog.SetDefault(slog.New(defaultLogger.Handler()))
+		log.SetPrefix("")
 	})
 	return err
 }
 
-// NewLogger creates a new logger instance with date-based file rotation and optional ES writer.
-func NewLogger(logDir string, esConfig *ESConfig) (*Logger, error) {
+// NewLogger creates a new logger instance with date- and size-based file rotation and optional ES and Loki writers.
+func NewLogger(cfg Config) (*Logger, error) {
+	if cfg.Format != "" && cfg.Format != FormatText && cfg.Format != FormatJSON {
+		return nil, fmt.Errorf("unknown log format %q, expected %s or %s", cfg.Format, FormatText, FormatJSON)
+	}
+
 	// Create log directory if it doesn't exist
-	if err := os.MkdirAll(logDir, 0755); err != nil {
+	if err := os.MkdirAll(cfg.Dir, 0755); err != nil {
 		return nil, fmt.Errorf("failed to create log directory: %w", err)
 	}
 
 	l := &Logger{
-		logDir: logDir,
+		logDir:  cfg.Dir,
+		service: cfg.Service,
+		json:    cfg.Format == FormatJSON,
+		maxSize: cfg.MaxSize,
+		closing: make(chan struct{}),
 	}
 
-	if esConfig != nil && esConfig.Enabled && len(esConfig.Addresses) > 0 && esConfig.Index != "" {
-		esw, err := newESWriter(esConfig)
+	if esConfig := cfg.ES; esConfig != nil && esConfig.Enabled && len(esConfig.Addresses) > 0 && esConfig.Index != "" {
+		esw, err := newESWriter(esConfig, cfg.Service)
 		if err != nil {
 			return nil, fmt.Errorf("failed to create Elasticsearch log writer: %w", err)
 		}
 		l.esWriter = esw
 	}
 
+	if cfg.Loki != nil && cfg.Loki.URL != "" {
+		lw, err := newLokiWriter(cfg.Loki, cfg.Service)
+		if err != nil {
+			if l.esWriter != nil {
+				_ = l.esWriter.Close()
+			}
+			return nil, fmt.Errorf("failed to create Loki log writer: %w", err)
+		}
+		l.lokiWriter = lw
+	}
+
 	// Initialize the log file for today
 	if err := l.rotateIfNeeded(); err != nil {
 		if l.esWriter != nil {
 			_ = l.esWriter.Close()
 		}
+		if l.lokiWriter != nil {
+			_ = l.lokiWriter.Close()
+		}
 		return nil, err
 	}
 
 	return l, nil
 }
 
-// rotateIfNeeded checks if we need to rotate to a new log file based on the date
+// rotateIfNeeded checks if we need to rotate to a new log file based on the date or size
 func (l *Logger) rotateIfNeeded() error {
 	l.mu.Lock()
 	defer l.mu.Unlock()
 
 	today := time.Now().Format("20060102")
 
-	// If date hasn't changed, no need to rotate
+	// If date hasn't changed, only the size can rotate the file
 	if l.currentDate == today && l.logFile != nil {
-		return nil
-	}
-
-	// Close existing log file if open
-	if l.logFile != nil {
-		l.logFile.Close()
+		return l.rotateBySize()
 	}
 
 	// Open new log file for today
-	logFileName := filepath.Join(l.logDir, fmt.Sprintf("%s.log", today))
-	logFile, err := os.OpenFile(logFileName, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
-	if err != nil {
-		return fmt.Errorf("failed to open log file: %w", err)
-	}
-
-	l.logFile = logFile
 	l.currentDate = today
-	writers := []io.Writer{os.Stdout, logFile}
-	if l.esWriter != nil {
-		writers = append(writers, l.esWriter)
-	}
-	l.logger = log.New(io.MultiWriter(writers...), "", log.LstdFlags)
-
-	return nil
+	return l.openLogFile()
 }
 
-// Write implements io.Writer interface (stdout, file, and optional ES).
+// Write implements io.Writer: p is logged as an info record, or at the level its text implies.
 func (l *Logger) Write(p []byte) (n int, err error) {
-	// Check if we need to rotate (date changed)
-	if err := l.rotateIfNeeded(); err != nil {
-		return os.Stdout.Write(p)
-	}
+	msg := strings.TrimSuffix(string(p), "\n")
+	l.write(time.Now(), LevelOf(msg), msg, nil)
+	return len(p), nil
+}
+
+// write logs a record: a text line to stdout and the day's file, or JSON to
+// stdout in the json format, a document to Elasticsearch and the line to Loki
+func (l *Logger) write(t time.Time, level, msg string, attrs []slog.Attr) {
+	line := textLine(t, l.service, level, msg, attrs)
+
+	// Check if we need to rotate (date changed); stdout gets the line anyway
+	rotateErr := l.rotateIfNeeded()
 
 	l.mu.Lock()
 	defer l.mu.Unlock()
 
-	n1, err1 := os.Stdout.Write(p)
-	if err1 != nil {
-		return n1, err1
+	if l.json {
+		_, _ = os.Stdout.Write(jsonLine(t, l.service, level, msg, attrs))
+	} else {
+		_, _ = os.Stdout.WriteString(line)
 	}
-	if l.logFile != nil {
-		_, _ = l.logFile.Write(p)
+	if l.logFile != nil && rotateErr == nil {
+		n, _ := l.logFile.WriteString(line)
+		l.size += int64(n)
 	}
 	if l.esWriter != nil {
-		_, _ = l.esWriter.Write(p)
+		l.esWriter.add(logDoc{
+			Timestamp: t.UTC().Format(time.RFC3339Nano),
+			Service:   l.service,
+			Level:     level,
+			Message:   strings.TrimSuffix(line, "\n"),
+			Fields:    fields(attrs),
+		})
+	}
+	if l.lokiWriter != nil {
+		l.lokiWriter.add(lokiEntry{t: t, level: level, line: strings.TrimSuffix(line, "\n")})
 	}
-	return n1, nil
 }
 
-// Close closes the log file and Elasticsearch writer (if any).
+// Close compresses the rotated segments still waiting for it, and closes the
+// log file and the Elasticsearch and Loki writers (if any).
 func (l *Logger) Close() error {
+	l.closeOnce.Do(func() { close(l.closing) })
+	l.compressing.Wait() // Before taking mu, the compression logs its errors
 	l.mu.Lock()
 	defer l.mu.Unlock()
 
@@ -138,69 +185,37 @@ func (l *Logger) Close() error {
 		_ = l.esWriter.Close()
 		l.esWriter = nil
 	}
+	if l.lokiWriter != nil {
+		_ = l.lokiWriter.Close()
+		l.lokiWriter = nil
+	}
 	if l.logFile != nil {
 		return l.logFile.Close()
 	}
 	return nil
 }
 
-// GetLogger returns the default logger instance
-func GetLogger() *Logger {
-	return defaultLogger
-}
-
-// Printf logs a formatted message
-func Printf(format string, v ...interface{}) {
-	if defaultLogger != nil {
-		defaultLogger.rotateIfNeeded()
-		defaultLogger.mu.Lock()
-		defer defaultLogger.mu.Unlock()
-		if defaultLogger.logger != nil {
-			defaultLogger.logger.Printf(format, v...)
-		}
-	} else {
-		log.Printf(format, v...)
-	}
-}
-
-// Println logs a message with a newline
-func Println(v ...interface{}) {
-	if defaultLogger != nil {
-		defaultLogger.rotateIfNeeded()
-		defaultLogger.mu.Lock()
-		defer defaultLogger.mu.Unlock()
-		if defaultLogger.logger != nil {
-			defaultLogger.logger.Println(v...)
-		}
-	} else {
-		log.Println(v...)
+// ESStats returns the counters of the Elasticsearch writer, zero without one
+func (l *Logger) ESStats() ESStats {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.esWriter == nil {
+		return ESStats{}
 	}
+	return l.esWriter.stats()
 }
 
-// Fatalf logs a fatal error and exits
-func Fatalf(format string, v ...interface{}) {
-	if defaultLogger != nil {
-		defaultLogger.rotateIfNeeded()
-		defaultLogger.mu.Lock()
-		defer defaultLogger.mu.Unlock()
-		if defaultLogger.logger != nil {
-			defaultLogger.logger.Fatalf(format, v...)
-		}
-	} else {
-		log.Fatalf(format, v...)
-	}
+// GetLogger returns the default logger instance
+func GetLogger() *Logger {
+	return defaultLogger
 }
 
-// Fatal logs a fatal error and exits
-func Fatal(v ...interface{}) {
+// Fatal logs msg with its attributes as an error, closes the default logger
+// so the lines waiting to be shipped go out, and exits with status 1
+func Fatal(msg string, args ...any) {
+	slog.Error(msg, args...)
 	if defaultLogger != nil {
-		defaultLogger.rotateIfNeeded()
-		defaultLogger.mu.Lock()
-		defer defaultLogger.mu.Unlock()
-		if defaultLogger.logger != nil {
-			defaultLogger.logger.Fatal(v...)
-		}
-	} else {
-		log.Fatal(v...)
+		_ = defaultLogger.Close()
 	}
+	os.Exit(1)
 }
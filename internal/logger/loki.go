@@ -0,0 +1,155 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// LokiConfig holds Grafana Loki connection settings for log shipping.
+type LokiConfig struct {
+	URL      string // Base URL, e.g. http://loki:3100; the lines go to its /loki/api/v1/push
+	TenantID string // Optional X-Scope-OrgID of a multi-tenant Loki
+	Username string // Optional basic auth, e.g. the Grafana Cloud user ID
+	Password string
+}
+
+// Batching of the Loki pushes
+const (
+	lokiBatchSize     = 1000
+	lokiBatchInterval = time.Second
+	lokiPushTimeout   = 10 * time.Second
+)
+
+// lokiEntry is a line of a Loki stream
+type lokiEntry struct {
+	t     time.Time
+	level string
+	line  string // The text line, as in the log file
+}
+
+// lokiWriter pushes the log lines to Loki asynchronously, in batches, as
+// streams labelled with the service and the level
+type lokiWriter struct {
+	cfg     *LokiConfig
+	pushURL string
+	service string
+	client  *http.Client
+	ch      chan lokiEntry
+	wg      sync.WaitGroup
+}
+
+// newLokiWriter creates a Loki writer and starts the background pusher. Call Close() when done.
+func newLokiWriter(cfg *LokiConfig, service string) (*lokiWriter, error) {
+	u, err := url.Parse(strings.TrimSuffix(cfg.URL, "/"))
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("invalid Loki URL %q: expected http(s)://host[:port]", cfg.URL)
+	}
+	w := &lokiWriter{
+		cfg:     cfg,
+		pushURL: u.String() + "/loki/api/v1/push",
+		service: service,
+		client:  &http.Client{Timeout: lokiPushTimeout},
+		ch:      make(chan lokiEntry, 1024),
+	}
+	w.wg.Add(1)
+	go w.run()
+	return w, nil
+}
+
+func (w *lokiWriter) run() {
+	defer w.wg.Done()
+	ticker := time.NewTicker(lokiBatchInterval)
+	defer ticker.Stop()
+	var batch []lokiEntry
+	for {
+		select {
+		case e, ok := <-w.ch:
+			if !ok {
+				w.push(batch)
+				return
+			}
+			if batch = append(batch, e); len(batch) >= lokiBatchSize {
+				w.push(batch)
+				batch = nil
+			}
+		case <-ticker.C:
+			w.push(batch)
+			batch = nil
+		}
+	}
+}
+
+// push sends a batch as one stream per level. Failed pushes are dropped, as
+// logging them would only add lines to the next batch.
+func (w *lokiWriter) push(batch []lokiEntry) {
+	if len(batch) == 0 {
+		return
+	}
+	type stream struct {
+		Stream map[string]string `json:"stream"`
+		Values [][2]string       `json:"values"`
+	}
+	var streams []*stream
+	byLevel := map[string]*stream{}
+	for _, e := range batch {
+		s := byLevel[e.level]
+		if s == nil {
+			labels := map[string]string{"job": "crypto-alert", "level": e.level}
+			if w.service != "" {
+				labels["service"] = w.service
+			}
+			s = &stream{Stream: labels}
+			byLevel[e.level] = s
+			streams = append(streams, s)
+		}
+		s.Values = append(s.Values, [2]string{strconv.FormatInt(e.t.UnixNano(), 10), e.line})
+	}
+	body, err := json.Marshal(map[string]any{"streams": streams})
+	if err != nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), lokiPushTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.pushURL, bytes.NewReader(body))
+	if err != nil {
+		return
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if w.cfg.TenantID != "" {
+		req.Header.Set("X-Scope-OrgID", w.cfg.TenantID)
+	}
+	if w.cfg.Username != "" || w.cfg.Password != "" {
+		req.SetBasicAuth(w.cfg.Username, w.cfg.Password)
+	}
+	res, err := w.client.Do(req)
+	if err == nil {
+		_, _ = io.Copy(io.Discard, res.Body)
+		_ = res.Body.Close()
+	}
+}
+
+// add sends e to the pusher goroutine (non-blocking if buffer not full).
+func (w *lokiWriter) add(e lokiEntry) {
+	select {
+	case w.ch <- e:
+	default:
+		// Buffer full, drop to avoid blocking application logs
+	}
+}
+
+// Close pushes the lines still waiting and stops the pusher.
+func (w *lokiWriter) Close() error {
+	close(w.ch)
+	w.wg.Wait()
+	return nil
+}
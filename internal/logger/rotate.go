@@ -0,0 +1,159 @@
+package logger
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// segmentCompressDelay is how long a rotated segment stays uncompressed,
+	// for the other services that still write to it to notice the rotation
+	segmentCompressDelay = 5 * time.Second
+	// rotationCheckInterval is how often the day's file is checked for a
+	// rotation by another service
+	rotationCheckInterval = time.Second
+)
+
+// LogFileName is the name of a day's log file (yyyyMMdd.log), or with n > 0
+// of its n-th segment rotated aside by size (yyyyMMdd.n.log, gzipped to
+// yyyyMMdd.n.log.gz)
+func LogFileName(date string, n int) string {
+	if n > 0 {
+		return date + "." + strconv.Itoa(n) + ".log"
+	}
+	return date + ".log"
+}
+
+// ParseLogFileName parses a name of LogFileName, gzipped or not; ok is false
+// for other files
+func ParseLogFileName(name string) (date string, n int, gzipped, ok bool) {
+	name, gzipped = strings.CutSuffix(name, ".gz")
+	name, ok = strings.CutSuffix(name, ".log")
+	if !ok || len(name) < 8 {
+		return "", 0, false, false
+	}
+	date, rest := name[:8], name[8:]
+	if _, err := time.Parse("20060102", date); err != nil {
+		return "", 0, false, false
+	}
+	if rest != "" {
+		var err error
+		if n, err = strconv.Atoi(strings.TrimPrefix(rest, ".")); err != nil || n <= 0 || rest[0] != '.' {
+			return "", 0, false, false
+		}
+	} else if gzipped {
+		return "", 0, false, false // Only segments are gzipped
+	}
+	return date, n, gzipped, true
+}
+
+// openLogFile opens the day's log file for appending
+func (l *Logger) openLogFile() error {
+	if l.logFile != nil {
+		l.logFile.Close()
+		l.logFile = nil
+	}
+	logFile, err := os.OpenFile(filepath.Join(l.logDir, LogFileName(l.currentDate, 0)), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open log file: %w", err)
+	}
+	l.logFile = logFile
+	l.size, l.checked = 0, time.Now()
+	if info, err := logFile.Stat(); err == nil {
+		l.size = info.Size()
+	}
+	return nil
+}
+
+// rotateBySize moves the day's file aside as its next segment once it has
+// maxSize bytes, and gzips the segment in the background. The services share
+// the file, so it also reopens the file when another service moved it aside.
+// Called with l.mu held.
+func (l *Logger) rotateBySize() error {
+	if l.maxSize <= 0 || (l.size < l.maxSize && time.Since(l.checked) < rotationCheckInterval) {
+		return nil
+	}
+	l.checked = time.Now()
+	info, err := l.logFile.Stat()
+	if err != nil {
+		return nil
+	}
+	l.size = info.Size() // With what the other services wrote
+	path := filepath.Join(l.logDir, LogFileName(l.currentDate, 0))
+	if current, err := os.Stat(path); err != nil || !os.SameFile(info, current) {
+		return l.openLogFile()
+	}
+	if l.size < l.maxSize {
+		return nil
+	}
+
+	segment := filepath.Join(l.logDir, LogFileName(l.currentDate, lastSegment(l.logDir, l.currentDate)+1))
+	if err := os.Rename(path, segment); err != nil {
+		return fmt.Errorf("failed to rotate log file: %w", err)
+	}
+	l.compressing.Add(1)
+	go func() {
+		defer l.compressing.Done()
+		select {
+		case <-time.After(segmentCompressDelay):
+		case <-l.closing:
+		}
+		if err := compressSegment(segment); err != nil {
+			slog.Warn("⚠️  Failed to compress log segment", "segment", segment, FieldError, err)
+		}
+	}()
+	return l.openLogFile()
+}
+
+// lastSegment returns the number of the day's last segment in logDir, 0
+// without any
+func lastSegment(logDir, date string) int {
+	last := 0
+	matches, _ := filepath.Glob(filepath.Join(logDir, date+".*.log*"))
+	for _, m := range matches {
+		if d, n, _, ok := ParseLogFileName(filepath.Base(m)); ok && d == date {
+			last = max(last, n)
+		}
+	}
+	return last
+}
+
+// compressSegment gzips the segment at path to path.gz and removes it
+func compressSegment(path string) error {
+	in, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(out.Name())
+
+	zw := gzip.NewWriter(out)
+	_, err = io.Copy(zw, in)
+	if err == nil {
+		err = zw.Close()
+	}
+	if err == nil {
+		err = out.Sync()
+	}
+	if cerr := out.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return err
+	}
+	if err := os.Rename(out.Name(), path+".gz"); err != nil {
+		return err
+	}
+	return os.Remove(path)
+}
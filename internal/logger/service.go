@@ -0,0 +1,34 @@
+package logger
+
+import "strings"
+
+// Services writing to the logs, the label of their lines
+const (
+	ServiceMonitor      = "monitor"
+	ServiceNotification = "notification-service"
+	ServiceAPI          = "log-api"
+)
+
+// Services are the services that label their log lines
+var Services = []string{ServiceMonitor, ServiceNotification, ServiceAPI}
+
+// ServiceLabel is the label of service's lines, written after the timestamp:
+// "[monitor] ", or nothing without a service
+func ServiceLabel(service string) string {
+	if service == "" {
+		return ""
+	}
+	return "[" + service + "] "
+}
+
+// CutService returns the service of a log line without its timestamp, and the
+// rest of the line after its label. Lines from before services labelled them,
+// and those starting with another bracketed word, have no service.
+func CutService(line string) (service, rest string) {
+	for _, s := range Services {
+		if rest, ok := strings.CutPrefix(line, ServiceLabel(s)); ok {
+			return s, rest
+		}
+	}
+	return "", line
+}
@@ -0,0 +1,274 @@
+package message
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"maps"
+	"net/url"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"crypto-alert/internal/core"
+	"crypto-alert/internal/logger"
+)
+
+// Channel names. A rule notifies a channel when it has a destination for it,
+// see NotificationTargets.Destinations.
+const (
+	ChannelEmail     = "email"
+	ChannelTelegram  = "telegram"
+	ChannelWhatsApp  = "whatsapp"
+	ChannelTeams     = "teams"
+	ChannelNtfy      = "ntfy"
+	ChannelPushover  = "pushover"
+	ChannelPagerDuty = "pagerduty"
+	ChannelOpsgenie  = "opsgenie"
+	ChannelWebhook   = "webhook"
+)
+
+// ErrSkipped is returned by a channel that deliberately didn't deliver an
+// alert, e.g. PagerDuty for a rule that isn't critical.
+var ErrSkipped = errors.New("alert skipped by channel")
+
+// Delivery addresses one alert to one channel.
+type Delivery struct {
+	Topic   string              // Kafka topic the alert was read from
+	To      string              // The rule's destination for the channel
+	Targets NotificationTargets // The rule's notification settings (severity, rule IDs, ...)
+	Payload []byte              // Raw alert event JSON
+	Receipt *Receipt            // Filled in by channels that get a message ID back; may be nil
+}
+
+// Receipt holds what a channel learned about a sent message.
+type Receipt struct {
+	MessageID string // Provider message ID, e.g. the Resend email ID or SMTP Message-ID
+}
+
+// DestinationHash identifies a destination of channel without revealing it: a
+// SHA-256 of the full destination. Unlike DisplayDestination, two
+// destinations never share it, so it tells the destinations apart in the
+// notification log.
+func DestinationHash(channel, to string) string {
+	sum := sha256.Sum256([]byte(channel + "\x00" + to))
+	return hex.EncodeToString(sum[:])
+}
+
+// DisplayDestination returns a destination of channel in a form that is safe
+// to log and store: integration keys are cut to their last 4 characters and
+// webhook URLs, which carry their secret in the path, to scheme and host.
+func DisplayDestination(channel, to string) string {
+	switch channel {
+	case ChannelPagerDuty, ChannelOpsgenie, ChannelPushover:
+		if len(to) <= 4 {
+			return "****"
+		}
+		return "****" + to[len(to)-4:]
+	case ChannelWebhook, ChannelTeams:
+		u, err := url.Parse(to)
+		if err != nil || u.Host == "" {
+			return "****"
+		}
+		return u.Scheme + "://" + u.Host + "/****"
+	}
+	return to
+}
+
+// NotificationChannel delivers alerts to one notification service.
+type NotificationChannel interface {
+	Name() string
+	SendTokenAlert(d Delivery, decision *core.AlertDecision) error
+	SendDeFiAlert(d Delivery, decision *core.DeFiAlertDecision) error
+	SendPredictAlert(d Delivery, decision *core.PredictMarketAlertDecision) error
+	SendWatchAlert(d Delivery, decision *core.WatchAlertDecision) error
+	// SendNotice sends a plain message about the alerts, e.g. that some were
+	// rate limited. Channels that only carry alerts return ErrSkipped.
+	SendNotice(d Delivery, subject, text string) error
+}
+
+// ChannelFactory builds a channel from the environment. It returns a nil
+// channel when the channel isn't configured.
+type ChannelFactory func(getenv func(string) string) (NotificationChannel, error)
+
+var (
+	channelsMu       sync.Mutex
+	channelNames     []string
+	channelFactories = map[string]ChannelFactory{}
+)
+
+// RegisterChannel makes a channel available under name. Channels register
+// themselves from init; registering a name twice panics.
+func RegisterChannel(name string, factory ChannelFactory) {
+	channelsMu.Lock()
+	defer channelsMu.Unlock()
+	if _, dup := channelFactories[name]; dup {
+		panic("message: channel " + name + " registered twice")
+	}
+	channelNames = append(channelNames, name)
+	channelFactories[name] = factory
+}
+
+// Defaults for the retry and circuit breaker settings of NewChannels
+const (
+	DefaultNotifyMaxRetries       = 2
+	DefaultNotifyRetryBackoff     = time.Second
+	DefaultNotifyBreakerThreshold = 5
+	DefaultNotifyBreakerCooldown  = time.Minute
+)
+
+// NewChannels builds every registered channel that is configured, in
+// registration order. Each channel retries failed sends (NOTIFY_MAX_RETRIES,
+// NOTIFY_RETRY_BACKOFF) and has a circuit breaker per destination
+// (NOTIFY_BREAKER_THRESHOLD consecutive failures open it for NOTIFY_BREAKER_COOLDOWN).
+// CHART_URL sets the chart endpoint of the email and Telegram charts, and
+// NOTIFY_<SEVERITY>_CHANNELS / NOTIFY_<SEVERITY>_EXTRA the severity routes.
+func NewChannels(getenv func(string) string) ([]NotificationChannel, error) {
+	channelsMu.Lock()
+	defer channelsMu.Unlock()
+
+	policy := RetryPolicy{
+		MaxRetries: envIntOr(getenv, "NOTIFY_MAX_RETRIES", DefaultNotifyMaxRetries),
+		MinBackoff: envDurationOr(getenv, "NOTIFY_RETRY_BACKOFF", DefaultNotifyRetryBackoff),
+		MaxBackoff: 30 * time.Second,
+	}
+	threshold := envIntOr(getenv, "NOTIFY_BREAKER_THRESHOLD", DefaultNotifyBreakerThreshold)
+	cooldown := envDurationOr(getenv, "NOTIFY_BREAKER_COOLDOWN", DefaultNotifyBreakerCooldown)
+
+	// CHART_URL points the alert charts at a self-hosted QuickChart; "off" leaves them out
+	switch u := getenv("CHART_URL"); u {
+	case "":
+	case "off":
+		SetPriceChartURL("")
+	default:
+		SetPriceChartURL(u)
+	}
+
+	// NOTIFY_<SEVERITY>_CHANNELS / NOTIFY_<SEVERITY>_EXTRA route alerts by severity
+	routes, err := severityRoutesFromEnv(getenv)
+	if err != nil {
+		return nil, err
+	}
+	SetSeverityRoutes(routes)
+	for severity, route := range routes {
+		slog.Info("🧭 Severity route", "severity", severity, "channels", route.Channels, "extra", slices.Sorted(maps.Keys(route.Extra)))
+	}
+
+	var channels []NotificationChannel
+	for _, name := range channelNames {
+		ch, err := channelFactories[name](getenv)
+		if err != nil {
+			return nil, fmt.Errorf("%s channel: %w", name, err)
+		}
+		if ch == nil {
+			slog.Info("ℹ️  Notifications disabled (not configured)", logger.FieldChannel, name)
+			continue
+		}
+		slog.Info("📨 Notifications enabled", logger.FieldChannel, name)
+		channels = append(channels, WithRetry(ch, policy, NewCircuitBreaker(threshold, cooldown)))
+	}
+	return channels, nil
+}
+
+// MessageSender sends each alert type to a single destination (email address,
+// chat ID, topic, ...). It is implemented by AlertPublisher, which hands
+// alerts to the notification service, and by the channel senders.
+type MessageSender interface {
+	SendAlert(to string, decision *core.AlertDecision) error
+	SendDeFiAlert(to string, decision *core.DeFiAlertDecision) error
+	SendPredictMarketAlert(to string, decision *core.PredictMarketAlertDecision) error
+	SendWatchAlert(to string, decision *core.WatchAlertDecision) error
+}
+
+// ContextSender is implemented by the MessageSenders that wrap another one,
+// e.g. to record the alerts, so WithContext reaches the AlertPublisher inside
+type ContextSender interface {
+	MessageSender
+	WithContext(ctx context.Context) MessageSender
+}
+
+// WithContext returns sender with its alerts continuing the trace of ctx, or
+// sender itself when it doesn't publish events
+func WithContext(sender MessageSender, ctx context.Context) MessageSender {
+	switch s := sender.(type) {
+	case *AlertPublisher:
+		return s.WithContext(ctx)
+	case ContextSender:
+		return s.WithContext(ctx)
+	}
+	return sender
+}
+
+var (
+	_ MessageSender = (*AlertPublisher)(nil)
+	_ MessageSender = (*ResendEmailSender)(nil)
+	_ MessageSender = (*SMTPSender)(nil)
+	_ MessageSender = (*TelegramSender)(nil)
+	_ MessageSender = (*WhatsAppSender)(nil)
+	_ MessageSender = (*TeamsSender)(nil)
+	_ MessageSender = (*NtfySender)(nil)
+	_ MessageSender = (*PushoverSender)(nil)
+
+	_ emailSender = (*ResendEmailSender)(nil)
+	_ emailSender = (*SMTPSender)(nil)
+
+	_ noticeSender = (*TelegramSender)(nil)
+	_ noticeSender = (*WhatsAppSender)(nil)
+	_ noticeSender = (*TeamsSender)(nil)
+	_ noticeSender = (*NtfySender)(nil)
+	_ noticeSender = (*PushoverSender)(nil)
+)
+
+// senderChannel adapts a MessageSender to NotificationChannel.
+type senderChannel struct {
+	name   string
+	sender MessageSender
+}
+
+func (c senderChannel) Name() string { return c.name }
+
+func (c senderChannel) SendTokenAlert(d Delivery, decision *core.AlertDecision) error {
+	return c.sender.SendAlert(d.To, decision)
+}
+
+func (c senderChannel) SendDeFiAlert(d Delivery, decision *core.DeFiAlertDecision) error {
+	return c.sender.SendDeFiAlert(d.To, decision)
+}
+
+func (c senderChannel) SendPredictAlert(d Delivery, decision *core.PredictMarketAlertDecision) error {
+	return c.sender.SendPredictMarketAlert(d.To, decision)
+}
+
+func (c senderChannel) SendWatchAlert(d Delivery, decision *core.WatchAlertDecision) error {
+	return c.sender.SendWatchAlert(d.To, decision)
+}
+
+func (c senderChannel) SendNotice(d Delivery, subject, text string) error {
+	if s, ok := c.sender.(noticeSender); ok {
+		return s.SendNotice(d.To, subject, text)
+	}
+	return ErrSkipped
+}
+
+// noticeSender is implemented by the MessageSenders that can send a plain message
+type noticeSender interface {
+	SendNotice(to, subject, text string) error
+}
+
+func envIntOr(getenv func(string) string, key string, defaultVal int) int {
+	if v, err := strconv.Atoi(getenv(key)); err == nil {
+		return v
+	}
+	return defaultVal
+}
+
+func envDurationOr(getenv func(string) string, key string, defaultVal time.Duration) time.Duration {
+	if v, err := time.ParseDuration(getenv(key)); err == nil {
+		return v
+	}
+	return defaultVal
+}
@@ -0,0 +1,150 @@
+package message
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"crypto-alert/internal/core"
+)
+
+// DefaultPriceChartURL is the QuickChart endpoint that renders the alert charts
+const DefaultPriceChartURL = "https://quickchart.io/chart"
+
+// priceChartURL is the chart endpoint; empty disables charts
+var priceChartURL = DefaultPriceChartURL
+
+// Size of the chart images in pixels
+const (
+	chartWidth  = 560
+	chartHeight = 200
+)
+
+// SetPriceChartURL sets the QuickChart-compatible endpoint that renders the
+// alert charts, e.g. a self-hosted QuickChart. An empty URL leaves the charts
+// out. Call it before sending alerts.
+func SetPriceChartURL(u string) {
+	priceChartURL = u
+}
+
+// tokenChartPoints returns the recent prices of a token alert, ending with the
+// alerted price
+func tokenChartPoints(decision *core.AlertDecision) []core.HistoryPoint {
+	points := decision.PriceHistory
+	if p := decision.CurrentPrice; p != nil && (len(points) == 0 || p.Timestamp.After(points[len(points)-1].Time)) {
+		points = append(points[:len(points):len(points)], core.HistoryPoint{Time: p.Timestamp, Value: p.Price})
+	}
+	return points
+}
+
+// defiChartPoints returns the recent values of a DeFi alert's field, ending
+// with the alerted value
+func defiChartPoints(decision *core.DeFiAlertDecision) []core.HistoryPoint {
+	points := decision.ValueHistory
+	return append(points[:len(points):len(points)], core.HistoryPoint{Time: time.Now(), Value: decision.CurrentValue})
+}
+
+// chartConfig returns the Chart.js configuration, as QuickChart expects it, of
+// a line chart of points with threshold as a dashed line. It returns nil when
+// there are fewer than two points to draw.
+func chartConfig(points []core.HistoryPoint, threshold float64) map[string]interface{} {
+	if len(points) < 2 {
+		return nil
+	}
+
+	labels := make([]string, len(points))
+	values := make([]float64, len(points))
+	thresholds := make([]float64, len(points))
+	for i, pt := range points {
+		labels[i] = pt.Time.UTC().Format("15:04")
+		values[i] = chartRound(pt.Value)
+		thresholds[i] = chartRound(threshold)
+	}
+
+	return map[string]interface{}{
+		"type": "line",
+		"data": map[string]interface{}{
+			"labels": labels,
+			"datasets": []map[string]interface{}{
+				{"data": values, "borderColor": "#667eea", "borderWidth": 2, "pointRadius": 0, "fill": false},
+				{"data": thresholds, "borderColor": "#ef4444", "borderWidth": 1, "borderDash": []int{4, 4}, "pointRadius": 0, "fill": false},
+			},
+		},
+		"options": map[string]interface{}{
+			"legend": map[string]interface{}{"display": false},
+			"scales": map[string]interface{}{
+				"xAxes": []map[string]interface{}{{"ticks": map[string]interface{}{"maxTicksLimit": 6}}},
+			},
+		},
+	}
+}
+
+// priceChartImageURL returns the URL of the price chart image of a token
+// alert. It returns "" when charts are disabled or fewer than two prices are known.
+func priceChartImageURL(decision *core.AlertDecision) string {
+	if priceChartURL == "" || decision.Rule == nil {
+		return ""
+	}
+	config := chartConfig(tokenChartPoints(decision), decision.Rule.Threshold)
+	if config == nil {
+		return ""
+	}
+	c, err := json.Marshal(config)
+	if err != nil {
+		return ""
+	}
+
+	q := url.Values{}
+	q.Set("w", strconv.Itoa(chartWidth))
+	q.Set("h", strconv.Itoa(chartHeight))
+	q.Set("bkg", "white")
+	q.Set("c", string(c))
+	return priceChartURL + "?" + q.Encode()
+}
+
+// renderChartPNG has the chart endpoint render a chart of points as a PNG. It
+// returns nil without an error when charts are disabled or there are fewer
+// than two points.
+func renderChartPNG(client *http.Client, points []core.HistoryPoint, threshold float64) ([]byte, error) {
+	config := chartConfig(points, threshold)
+	if priceChartURL == "" || config == nil {
+		return nil, nil
+	}
+
+	body, err := json.Marshal(map[string]interface{}{
+		"chart":           config,
+		"width":           chartWidth,
+		"height":          chartHeight,
+		"backgroundColor": "white",
+		"format":          "png",
+	})
+	if err != nil {
+		return nil, fmt.Errorf("marshal chart: %w", err)
+	}
+
+	resp, err := client.Post(priceChartURL, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("render chart: %w", err)
+	}
+	defer resp.Body.Close()
+
+	png, err := io.ReadAll(io.LimitReader(resp.Body, 5<<20))
+	if err != nil {
+		return nil, fmt.Errorf("read chart: %w", err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("chart service returned status %d: %s", resp.StatusCode, string(png))
+	}
+	return png, nil
+}
+
+// chartRound cuts a value to 6 significant digits, which keeps the chart URL short
+func chartRound(v float64) float64 {
+	r, _ := strconv.ParseFloat(strconv.FormatFloat(v, 'g', 6, 64), 64)
+	return r
+}
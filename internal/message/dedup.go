@@ -0,0 +1,68 @@
+package message
+
+import (
+	"sync"
+	"time"
+)
+
+// DefaultDedupWindow is how long NewDeduper remembers a delivered event
+const DefaultDedupWindow = 24 * time.Hour
+
+// Deduper remembers the IDs of the alert events delivered within its window,
+// so an event read again after a crash or an offset reset isn't sent twice.
+// The IDs are kept in memory; the notification service also checks the
+// delivery log for the destinations an event reached before a restart.
+type Deduper struct {
+	window time.Duration
+
+	mu        sync.Mutex
+	seen      map[string]time.Time // Event ID -> when it expires
+	lastPrune time.Time
+}
+
+// NewDeduper creates a deduper remembering events for window. A window of 0
+// or less disables it.
+func NewDeduper(window time.Duration) *Deduper {
+	return &Deduper{window: window, seen: map[string]time.Time{}}
+}
+
+// Enabled reports whether the deduper remembers events
+func (d *Deduper) Enabled() bool {
+	return d.window > 0
+}
+
+// Window returns how long events are remembered
+func (d *Deduper) Window() time.Duration {
+	return d.window
+}
+
+// Seen reports whether the event with the given ID was delivered within the window
+func (d *Deduper) Seen(id string) bool {
+	if !d.Enabled() || id == "" {
+		return false
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	expires, ok := d.seen[id]
+	return ok && time.Now().Before(expires)
+}
+
+// Add remembers that the event with the given ID was delivered
+func (d *Deduper) Add(id string) {
+	if !d.Enabled() || id == "" {
+		return
+	}
+	now := time.Now()
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.seen[id] = now.Add(d.window)
+	if now.Sub(d.lastPrune) < time.Minute {
+		return
+	}
+	d.lastPrune = now
+	for id, expires := range d.seen {
+		if !now.Before(expires) {
+			delete(d.seen, id)
+		}
+	}
+}
@@ -0,0 +1,175 @@
+package message
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"crypto-alert/internal/core"
+)
+
+// Digestible reports whether an alert for targets on channel goes into a
+// digest instead of being sent: the rule has a digest interval, the channel
+// is email or Telegram, and the alert isn't critical.
+func Digestible(channel string, targets NotificationTargets) bool {
+	if targets.DigestMinutes <= 0 || targets.Severity == string(core.SeverityCritical) {
+		return false
+	}
+	return channel == ChannelEmail || channel == ChannelTelegram
+}
+
+// DigestEntry is one alert held back for a digest
+type DigestEntry struct {
+	Topic   string
+	RuleID  int64
+	Message string
+	Time    time.Time
+	Tag     string // The rule's first tag, which the digest groups the alerts by
+}
+
+// Digest is the batch of alerts due for one destination
+type Digest struct {
+	Channel string
+	Entries []DigestEntry
+	Last    Delivery // The most recent alert's delivery; addresses the digest
+}
+
+// pendingDigest collects a destination's alerts until its interval has passed
+// since the first of them
+type pendingDigest struct {
+	Digest
+	due time.Time
+}
+
+// Digester batches the alerts of each destination into periodic digests.
+type Digester struct {
+	mu      sync.Mutex
+	pending map[destinationKey]*pendingDigest
+}
+
+// NewDigester creates an empty digester
+func NewDigester() *Digester {
+	return &Digester{pending: map[destinationKey]*pendingDigest{}}
+}
+
+// Add holds back an alert for d's destination on channel. The destination's
+// digest is due the rule's digest interval after its first alert; a rule with
+// a shorter interval brings it forward.
+func (g *Digester) Add(channel string, d Delivery) {
+	now := time.Now()
+	key := destinationKey{channel, d.To}
+	due := now.Add(time.Duration(d.Targets.DigestMinutes) * time.Minute)
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	p := g.pending[key]
+	if p == nil {
+		p = &pendingDigest{Digest: Digest{Channel: channel}, due: due}
+		g.pending[key] = p
+	}
+	if due.Before(p.due) {
+		p.due = due
+	}
+	entry := DigestEntry{Topic: d.Topic, RuleID: d.Targets.RuleID, Message: AlertMessage(d.Payload), Time: now}
+	if len(d.Targets.Tags) > 0 {
+		entry.Tag = d.Targets.Tags[0]
+	}
+	p.Entries = append(p.Entries, entry)
+	p.Last = d
+}
+
+// Due returns and removes the digests whose interval has passed
+func (g *Digester) Due() []Digest {
+	now := time.Now()
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	var due []Digest
+	for key, p := range g.pending {
+		if !now.Before(p.due) {
+			due = append(due, p.Digest)
+			delete(g.pending, key)
+		}
+	}
+	return due
+}
+
+// Flush returns and removes every pending digest, due or not, e.g. to send
+// them on shutdown
+func (g *Digester) Flush() []Digest {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	var pending []Digest
+	for key, p := range g.pending {
+		pending = append(pending, p.Digest)
+		delete(g.pending, key)
+	}
+	return pending
+}
+
+// count returns the number of alerts in the digest, e.g. "1 alert" or "3 alerts"
+func (d Digest) count() string {
+	if len(d.Entries) == 1 {
+		return "1 alert"
+	}
+	return fmt.Sprintf("%d alerts", len(d.Entries))
+}
+
+// Subject returns the subject line of the digest
+func (d Digest) Subject() string {
+	return "Alert digest: " + d.count()
+}
+
+// Text returns the digest body, one line per alert in the order they came
+// in. When rules have tags, the alerts are grouped under their rule's first
+// tag, the tags in the order of their first alert and untagged alerts last.
+func (d Digest) Text() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s since %s:\n", d.count(), d.Entries[0].Time.UTC().Format("2006-01-02 15:04 UTC"))
+	var tags []string
+	byTag := map[string][]DigestEntry{}
+	for _, e := range d.Entries {
+		if _, ok := byTag[e.Tag]; !ok && e.Tag != "" {
+			tags = append(tags, e.Tag)
+		}
+		byTag[e.Tag] = append(byTag[e.Tag], e)
+	}
+	if len(tags) > 0 && len(byTag[""]) > 0 {
+		tags = append(tags, "")
+	}
+	if len(tags) == 0 {
+		tags = []string{""}
+	}
+	for _, tag := range tags {
+		b.WriteString("\n")
+		if len(byTag) > 1 || tag != "" {
+			label := "#" + tag
+			if tag == "" {
+				label = "Untagged"
+			}
+			fmt.Fprintf(&b, "%s (%d)\n", label, len(byTag[tag]))
+		}
+		for _, e := range byTag[tag] {
+			fmt.Fprintf(&b, "• %s  %s\n", e.Time.UTC().Format("15:04"), e.Message)
+		}
+	}
+	b.WriteString("\nCritical alerts are still sent right away.")
+	return b.String()
+}
+
+// AlertMessage returns the one-line message of an alert event, or "alert"
+// when the event has none.
+func AlertMessage(payload []byte) string {
+	var event struct {
+		Message string `json:"message"`
+	}
+	if err := json.Unmarshal(payload, &event); err != nil || event.Message == "" {
+		return "alert"
+	}
+	return event.Message
+}
@@ -4,22 +4,119 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
+	"html"
 	"io"
-	"log"
+	"log/slog"
 	"net/http"
 	"strings"
 
 	"crypto-alert/internal/core"
+	"crypto-alert/internal/logger"
 )
 
-// MessageSender interface for sending alerts
-type MessageSender interface {
-	Send(message string) error
-	SendWithSubject(subject, message string) error
-	SendToEmail(toEmail, subject, message string) error
-	SendAlert(toEmail string, decision *core.AlertDecision) error
-	SendDeFiAlert(toEmail string, decision *core.DeFiAlertDecision) error
-	SendPredictMarketAlert(toEmail string, decision *core.PredictMarketAlertDecision) error
+func init() {
+	// Email goes through SMTP when SMTP_HOST is set, otherwise through Resend
+	RegisterChannel(ChannelEmail, func(getenv func(string) string) (NotificationChannel, error) {
+		var sender emailSender
+		if host := getenv("SMTP_HOST"); host != "" {
+			from := getenv("SMTP_FROM")
+			if from == "" {
+				return nil, fmt.Errorf("SMTP_FROM is required when SMTP_HOST is set")
+			}
+			slog.Info("📧 Sending email via SMTP", "host", host)
+			sender = NewSMTPSender(host, envIntOr(getenv, "SMTP_PORT", 587), getenv("SMTP_USER"), getenv("SMTP_PASS"), from)
+		} else {
+			apiKey := getenv("RESEND_API_KEY")
+			if apiKey == "" {
+				return nil, nil
+			}
+			from := getenv("RESEND_FROM_EMAIL")
+			if from == "" {
+				return nil, fmt.Errorf("RESEND_FROM_EMAIL is required when RESEND_API_KEY is set")
+			}
+			sender = NewResendEmailSender(apiKey, from)
+		}
+
+		// Templates that fail validation keep the built-in ones rather than disabling email
+		if dir := getenv("EMAIL_TEMPLATES_DIR"); dir != "" {
+			loaded, err := LoadEmailTemplates(dir)
+			if err != nil {
+				slog.Warn("⚠️  Invalid email templates, using the built-in ones instead", "dir", dir, logger.FieldError, err)
+			}
+			if len(loaded) > 0 {
+				slog.Info("📧 Using email templates", "dir", dir, "templates", loaded)
+			}
+		}
+		// Alert emails carry a signed unsubscribe link when the endpoint is configured
+		unsubscribeURL := getenv("UNSUBSCRIBE_URL")
+		if unsubscribeURL != "" && getenv("UNSUBSCRIBE_SECRET") == "" {
+			return nil, fmt.Errorf("UNSUBSCRIBE_SECRET is required when UNSUBSCRIBE_URL is set")
+		}
+		return emailChannel{sender: sender, unsubscribeURL: unsubscribeURL, unsubscribeSecret: getenv("UNSUBSCRIBE_SECRET")}, nil
+	})
+}
+
+// emailSender sends one email and returns the provider's message ID.
+// It is implemented by ResendEmailSender and SMTPSender.
+type emailSender interface {
+	SendEmail(toEmail, subject, textBody, htmlBody string, headers map[string]string) (string, error)
+}
+
+// emailChannel formats alerts as emails and records the provider message ID
+// of each sent email on the delivery receipt. With an unsubscribe URL, every
+// email links to it and carries List-Unsubscribe headers.
+type emailChannel struct {
+	sender            emailSender
+	unsubscribeURL    string
+	unsubscribeSecret string
+}
+
+func (c emailChannel) Name() string { return ChannelEmail }
+
+func (c emailChannel) SendTokenAlert(d Delivery, decision *core.AlertDecision) error {
+	subject, textBody, htmlBody := FormatAlertEmail(decision)
+	subject, htmlBody = styleEmail(decision.Rule.Severity, subject, htmlBody)
+	return c.send(d, subject, textBody, htmlBody)
+}
+
+func (c emailChannel) SendDeFiAlert(d Delivery, decision *core.DeFiAlertDecision) error {
+	subject, textBody, htmlBody := FormatDeFiAlertEmail(decision)
+	subject, htmlBody = styleEmail(decision.Rule.Severity, subject, htmlBody)
+	return c.send(d, subject, textBody, htmlBody)
+}
+
+func (c emailChannel) SendPredictAlert(d Delivery, decision *core.PredictMarketAlertDecision) error {
+	subject, textBody, htmlBody := FormatPredictMarketAlertEmail(decision)
+	subject, htmlBody = styleEmail(decision.Rule.Severity, subject, htmlBody)
+	return c.send(d, subject, textBody, htmlBody)
+}
+
+func (c emailChannel) SendWatchAlert(d Delivery, decision *core.WatchAlertDecision) error {
+	subject, textBody, htmlBody := FormatWatchAlertEmail(decision)
+	subject, htmlBody = styleEmail(decision.Rule.Severity, subject, htmlBody)
+	return c.send(d, subject, textBody, htmlBody)
+}
+
+func (c emailChannel) SendNotice(d Delivery, subject, text string) error {
+	htmlBody := "<p>" + strings.ReplaceAll(html.EscapeString(text), "\n", "<br>") + "</p>"
+	return c.send(d, subject, text, htmlBody)
+}
+
+func (c emailChannel) send(d Delivery, subject, textBody, htmlBody string) error {
+	var headers map[string]string
+	if c.unsubscribeURL != "" {
+		link := UnsubscribeURL(c.unsubscribeURL, c.unsubscribeSecret, d.To)
+		textBody, htmlBody = withUnsubscribeFooter(textBody, htmlBody, link)
+		headers = unsubscribeHeaders(link)
+	}
+	messageID, err := c.sender.SendEmail(d.To, subject, textBody, htmlBody, headers)
+	if err != nil {
+		return err
+	}
+	if d.Receipt != nil {
+		d.Receipt.MessageID = messageID
+	}
+	return nil
 }
 
 // ResendEmailSender sends alerts via Resend API
@@ -36,16 +133,6 @@ func NewResendEmailSender(apiKey, fromEmail string) *ResendEmailSender {
 	}
 }
 
-// Send sends a message via email to default recipient (not used, use SendToEmail instead)
-func (r *ResendEmailSender) Send(message string) error {
-	return fmt.Errorf("Send() requires recipient email, use SendToEmail() instead")
-}
-
-// SendWithSubject sends a message via email with a custom subject (not used, use SendToEmail instead)
-func (r *ResendEmailSender) SendWithSubject(subject, message string) error {
-	return fmt.Errorf("SendWithSubject() requires recipient email, use SendToEmail() instead")
-}
-
 // SendToEmail sends an email via Resend API to a specific recipient
 func (r *ResendEmailSender) SendToEmail(toEmail, subject, message string) error {
 	return r.SendToEmailWithHTML(toEmail, subject, message, "")
@@ -53,14 +140,21 @@ func (r *ResendEmailSender) SendToEmail(toEmail, subject, message string) error
 
 // SendToEmailWithHTML sends an email via Resend API with both text and HTML content
 func (r *ResendEmailSender) SendToEmailWithHTML(toEmail, subject, textBody, htmlBody string) error {
+	_, err := r.SendEmail(toEmail, subject, textBody, htmlBody, nil)
+	return err
+}
+
+// SendEmail sends an email via Resend API with the extra headers and returns
+// the Resend email ID
+func (r *ResendEmailSender) SendEmail(toEmail, subject, textBody, htmlBody string, headers map[string]string) (string, error) {
 	if r.apiKey == "" {
-		return fmt.Errorf("Resend API key is not configured")
+		return "", fmt.Errorf("Resend API key is not configured")
 	}
 	if r.fromEmail == "" {
-		return fmt.Errorf("sender email is not configured")
+		return "", fmt.Errorf("sender email is not configured")
 	}
 	if toEmail == "" {
-		return fmt.Errorf("recipient email is required")
+		return "", fmt.Errorf("recipient email is required")
 	}
 
 	// Resend API endpoint
@@ -81,16 +175,19 @@ func (r *ResendEmailSender) SendToEmailWithHTML(toEmail, subject, textBody, html
 		// Fallback: convert text to simple HTML
 		payload["html"] = fmt.Sprintf("<p>%s</p>", strings.ReplaceAll(textBody, "\n", "<br>"))
 	}
+	if len(headers) > 0 {
+		payload["headers"] = headers
+	}
 
 	jsonData, err := json.Marshal(payload)
 	if err != nil {
-		return fmt.Errorf("failed to marshal email payload: %w", err)
+		return "", fmt.Errorf("failed to marshal email payload: %w", err)
 	}
 
 	// Create HTTP request
 	req, err := http.NewRequest("POST", apiURL, bytes.NewBuffer(jsonData))
 	if err != nil {
-		return fmt.Errorf("failed to create HTTP request: %w", err)
+		return "", fmt.Errorf("failed to create HTTP request: %w", err)
 	}
 
 	// Set headers
@@ -101,23 +198,28 @@ func (r *ResendEmailSender) SendToEmailWithHTML(toEmail, subject, textBody, html
 	client := &http.Client{}
 	resp, err := client.Do(req)
 	if err != nil {
-		return fmt.Errorf("failed to send email via Resend: %w", err)
+		return "", fmt.Errorf("failed to send email via Resend: %w", err)
 	}
 	defer resp.Body.Close()
 
 	// Read response
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return fmt.Errorf("failed to read response: %w", err)
+		return "", fmt.Errorf("failed to read response: %w", err)
 	}
 
 	// Check status code
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		return fmt.Errorf("Resend API returned status %d: %s", resp.StatusCode, string(body))
+		return "", fmt.Errorf("Resend API returned status %d: %s", resp.StatusCode, string(body))
 	}
 
-	log.Printf("📧 Email sent via Resend:\nTo: %s\nSubject: %s\n", toEmail, subject)
-	return nil
+	var result struct {
+		ID string `json:"id"`
+	}
+	_ = json.Unmarshal(body, &result)
+
+	slog.Info("📧 Email sent via Resend", "to", toEmail, "subject", subject)
+	return result.ID, nil
 }
 
 // SendAlert sends an alert email using the formatted template
@@ -137,3 +239,9 @@ func (r *ResendEmailSender) SendPredictMarketAlert(toEmail string, decision *cor
 	subject, textBody, htmlBody := FormatPredictMarketAlertEmail(decision)
 	return r.SendToEmailWithHTML(toEmail, subject, textBody, htmlBody)
 }
+
+// SendWatchAlert sends a watch alert email using the formatted template
+func (r *ResendEmailSender) SendWatchAlert(toEmail string, decision *core.WatchAlertDecision) error {
+	subject, textBody, htmlBody := FormatWatchAlertEmail(decision)
+	return r.SendToEmailWithHTML(toEmail, subject, textBody, htmlBody)
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"html/template"
 	"math"
+	"sort"
 	"strings"
 	"time"
 
@@ -127,6 +128,12 @@ This is an automated alert from your crypto price monitoring system.
 
 // FormatAlertHTML formats the HTML email body for an alert
 func FormatAlertHTML(symbol string, price float64, threshold float64, direction string, timestamp time.Time) string {
+	return formatAlertHTML(symbol, price, threshold, direction, timestamp, "")
+}
+
+// formatAlertHTML formats the HTML email body for an alert, with the price
+// chart image at chartURL when it isn't empty
+func formatAlertHTML(symbol string, price float64, threshold float64, direction string, timestamp time.Time, chartURL string) string {
 	var directionText string
 	var directionEmoji string
 	switch direction {
@@ -182,6 +189,12 @@ func FormatAlertHTML(symbol string, price float64, threshold float64, direction
 					<div style="font-size: 32px; font-weight: bold; color: {{.PriceColor}};">${{.Price}}</div>
 				</div>
 			</div>
+			{{if .ChartURL}}
+			<div style="margin: 20px 0;">
+				<div style="font-size: 14px; color: #6b7280; text-transform: uppercase; letter-spacing: 1px; margin-bottom: 8px;">Last 24 Hours</div>
+				<img src="{{.ChartURL}}" alt="{{.Symbol}} price over the last 24 hours" width="560" style="width: 100%; max-width: 560px; height: auto; display: block;">
+			</div>
+			{{end}}
 			
 			<div style="border-top: 1px solid #e5e7eb; padding-top: 20px; margin-top: 20px;">
 				<table style="width: 100%; border-collapse: collapse;">
@@ -215,15 +228,7 @@ func FormatAlertHTML(symbol string, price float64, threshold float64, direction
 `
 
 	// Prepare template data
-	data := struct {
-		Symbol         string
-		Price          string
-		Threshold      string
-		DirectionText  string
-		DirectionEmoji string
-		PriceColor     string
-		Timestamp      string
-	}{
+	data := tokenEmailData{
 		Symbol:         symbol,
 		Price:          fmt.Sprintf("%g", price),
 		Threshold:      fmt.Sprintf("%g", threshold),
@@ -231,6 +236,12 @@ func FormatAlertHTML(symbol string, price float64, threshold float64, direction
 		DirectionEmoji: directionEmoji,
 		PriceColor:     priceColor,
 		Timestamp:      timestamp.Format(time.RFC3339),
+		ChartURL:       chartURL,
+	}
+
+	// Operator-provided template from EMAIL_TEMPLATES_DIR, if any
+	if html, ok := renderEmailTemplateOverride(EmailTemplateToken, data); ok {
+		return html
 	}
 
 	// Parse and execute template
@@ -276,6 +287,14 @@ func FormatAlertEmail(decision *core.AlertDecision) (subject, textBody, htmlBody
 	if decision.CurrentPrice == nil || decision.Rule == nil {
 		return "", "", ""
 	}
+	// The rule's message template, if any, replaces the built-in message
+	defer func() {
+		subject, textBody, htmlBody = applyMessageTemplate(decision.Rule.MessageTemplate, decision.TemplateData(), subject, textBody, htmlBody)
+	}()
+	// Rules with a non-English locale get the translated message
+	if a, ok := localizeTokenAlert(decision); ok {
+		return a.email()
+	}
 
 	symbol := decision.CurrentPrice.Symbol
 	price := decision.CurrentPrice.Price
@@ -285,7 +304,7 @@ func FormatAlertEmail(decision *core.AlertDecision) (subject, textBody, htmlBody
 
 	subject = FormatAlertSubject(symbol, price, threshold, direction)
 	textBody = FormatAlertMessage(symbol, price, threshold, direction, timestamp)
-	htmlBody = FormatAlertHTML(symbol, price, threshold, direction, timestamp)
+	htmlBody = formatAlertHTML(symbol, price, threshold, direction, timestamp, priceChartImageURL(decision))
 
 	return subject, textBody, htmlBody
 }
@@ -500,20 +519,7 @@ func FormatDeFiAlertHTML(protocol, version, field, chainName string, value, thre
 	}
 
 	// Prepare template data
-	data := struct {
-		Protocol       string
-		Version        string
-		Field          string
-		ChainName      string
-		Value          string
-		Threshold      string
-		DirectionText  string
-		DirectionEmoji string
-		ValueColor     string
-		Timestamp      string
-		MarketInfo     string
-		MarketInfoLabel string
-	}{
+	data := defiEmailData{
 		Protocol:       protocol,
 		Version:        version,
 		Field:          field,
@@ -528,6 +534,11 @@ func FormatDeFiAlertHTML(protocol, version, field, chainName string, value, thre
 		MarketInfoLabel: marketInfoLabel,
 	}
 
+	// Operator-provided template from EMAIL_TEMPLATES_DIR, if any
+	if html, ok := renderEmailTemplateOverride(EmailTemplateDeFi, data); ok {
+		return html
+	}
+
 	// Parse and execute template
 	tmpl, err := template.New("defi-email").Parse(htmlTemplate)
 	if err != nil {
@@ -580,18 +591,93 @@ func FormatDeFiAlertHTML(protocol, version, field, chainName string, value, thre
 	return buf.String()
 }
 
+// predictFieldLabel returns the human-readable name of a prediction market rule's field
+func predictFieldLabel(r *core.PredictMarketAlertRule) string {
+	return predictFieldLabelIn(core.LocaleEnglish, r)
+}
+
+// predictFieldLabelIn returns the name of a prediction market rule's field in locale
+func predictFieldLabelIn(locale core.Locale, r *core.PredictMarketAlertRule) string {
+	switch r.Field {
+	case core.PredictFieldSpread:
+		return tr(locale, "Spread")
+	case core.PredictFieldSum:
+		return tr(locale, "Sum of Outcomes")
+	case core.PredictFieldMove:
+		minutes := int(r.MoveWindow.Minutes())
+		if minutes <= 0 {
+			minutes = 60
+		}
+		return fmt.Sprintf(tr(locale, "%dm Move (points)"), minutes)
+	case core.PredictFieldDiff:
+		if len(r.GroupItems) == 2 {
+			return fmt.Sprintf("%s − %s", r.GroupItems[0], r.GroupItems[1])
+		}
+		return tr(locale, "Outcome Difference")
+	case core.PredictFieldDepth:
+		if r.DepthSide == "ASK" {
+			return fmt.Sprintf(tr(locale, "Ask Depth <= %g (USDC)"), r.DepthPrice)
+		}
+		return fmt.Sprintf(tr(locale, "Bid Depth >= %g (USDC)"), r.DepthPrice)
+	default:
+		return tr(locale, "Midpoint")
+	}
+}
+
+// predictTrendArrow compares the current midpoint with the start of the history period
+func predictTrendArrow(open, current float64) string {
+	switch {
+	case current-open > 0.005:
+		return "↑"
+	case open-current > 0.005:
+		return "↓"
+	default:
+		return "→"
+	}
+}
+
+// formatPredictHistory renders a history summary like "24h: low 0.3100 / high 0.4500 ↑ (from 0.3300)"
+func formatPredictHistory(h *core.PredictMarketHistory, current float64) string {
+	if h == nil {
+		return ""
+	}
+	return fmt.Sprintf("%.0fh: low %.4f / high %.4f %s (from %.4f)",
+		h.Period.Hours(), h.Low, h.High, predictTrendArrow(h.Open, current), h.Open)
+}
+
 // FormatPredictMarketAlertEmail formats subject, plain-text body, and HTML body for a prediction market alert.
 func FormatPredictMarketAlertEmail(decision *core.PredictMarketAlertDecision) (subject, textBody, htmlBody string) {
 	if decision.Rule == nil {
 		return "", "", ""
 	}
+	// The rule's message template, if any, replaces the built-in message
+	defer func() {
+		subject, textBody, htmlBody = applyMessageTemplate(decision.Rule.MessageTemplate, decision.TemplateData(), subject, textBody, htmlBody)
+	}()
+	// Rules with a non-English locale get the translated message
+	if a, ok := localizePredictMarketAlert(decision); ok {
+		return a.email()
+	}
 	r := decision.Rule
 	direction := string(r.Direction)
 	timestamp := time.Now()
 
 	// Subject
-	subject = fmt.Sprintf("🚨 Prediction Market Alert: %s midpoint %s %g",
-		r.PredictMarket, direction, r.Threshold)
+	fieldLabel := predictFieldLabel(r)
+	subject = fmt.Sprintf("🚨 Prediction Market Alert: %s %s %s %g",
+		r.PredictMarket, strings.ToLower(fieldLabel), direction, r.Threshold)
+
+	history := formatPredictHistory(decision.History, decision.CurrentMidpoint)
+	var historyLine string
+	if history != "" {
+		historyLine = fmt.Sprintf("History:        %s\n", history)
+	}
+
+	// Value of the alerted field, shown separately unless it is the midpoint
+	var fieldValueLine string
+	if r.Field != "" && r.Field != core.PredictFieldMidpoint {
+		fieldValueLine = fmt.Sprintf("%s: %.4f\n", fieldLabel, decision.CurrentValue)
+	}
 
 	// Direction text
 	var directionText string
@@ -617,11 +703,11 @@ Platform: %s
 Question: %s
 Outcome: %s
 
-Midpoint Price: %.4f
+%sMidpoint Price: %.4f
 Buy Price:      %.4f
 Sell Price:     %.4f
-Threshold:      %g
-Outcome Met:    Midpoint is %s threshold
+%sThreshold:      %g
+Outcome Met:    %s is %s threshold
 Timestamp: %s
 
 This is an automated alert from your prediction market monitoring system.
@@ -629,10 +715,13 @@ This is an automated alert from your prediction market monitoring system.
 		r.PredictMarket,
 		r.Question,
 		r.Outcome,
+		fieldValueLine,
 		decision.CurrentMidpoint,
 		decision.CurrentBuyPrice,
 		decision.CurrentSellPrice,
+		historyLine,
 		r.Threshold,
+		fieldLabel,
 		directionText,
 		timestamp.Format(time.RFC3339),
 	)
@@ -692,6 +781,10 @@ This is an automated alert from your prediction market monitoring system.
 						<td style="padding: 10px 0; color: #6b7280; font-weight: 500;">Outcome:</td>
 						<td style="padding: 10px 0; text-align: right; font-weight: 600;">{{.Outcome}}</td>
 					</tr>{{end}}
+					{{if .FieldValue}}<tr>
+						<td style="padding: 10px 0; color: #6b7280; font-weight: 500;">{{.FieldLabel}}:</td>
+						<td style="padding: 10px 0; text-align: right; font-weight: 600;">{{.FieldValue}}</td>
+					</tr>{{end}}
 					<tr>
 						<td style="padding: 10px 0; color: #6b7280; font-weight: 500;">Midpoint:</td>
 						<td style="padding: 10px 0; text-align: right; font-weight: 600; color: {{.MidpointColor}};">{{.Midpoint}}</td>
@@ -704,13 +797,17 @@ This is an automated alert from your prediction market monitoring system.
 						<td style="padding: 10px 0; color: #6b7280; font-weight: 500;">Sell Price:</td>
 						<td style="padding: 10px 0; text-align: right; font-weight: 600;">{{.SellPrice}}</td>
 					</tr>
+					{{if .History}}<tr>
+						<td style="padding: 10px 0; color: #6b7280; font-weight: 500;">History:</td>
+						<td style="padding: 10px 0; text-align: right; font-weight: 600;">{{.History}}</td>
+					</tr>{{end}}
 					<tr>
 						<td style="padding: 10px 0; color: #6b7280; font-weight: 500;">Threshold:</td>
 						<td style="padding: 10px 0; text-align: right; font-weight: 600;">{{.Threshold}}</td>
 					</tr>
 					<tr>
 						<td style="padding: 10px 0; color: #6b7280; font-weight: 500;">Outcome Met:</td>
-						<td style="padding: 10px 0; text-align: right; font-weight: 600;">Midpoint is {{.DirectionText}} threshold</td>
+						<td style="padding: 10px 0; text-align: right; font-weight: 600;">{{.FieldLabel}} is {{.DirectionText}} threshold</td>
 					</tr>
 					<tr>
 						<td style="padding: 10px 0; color: #6b7280; font-weight: 500;">Timestamp:</td>
@@ -721,29 +818,19 @@ This is an automated alert from your prediction market monitoring system.
 		</div>
 		<div style="text-align: center; color: #6b7280; font-size: 12px; margin-top: 20px;">
 			<p style="margin: 0;">This is an automated alert from your prediction market monitoring system.</p>
-			<p style="margin: 5px 0 0 0;">Powered by Polymarket CLOB API</p>
+			<p style="margin: 5px 0 0 0;">Powered by {{.PredictMarket}} market data</p>
 		</div>
 	</div>
 </body>
 </html>
 `
 
-	data := struct {
-		PredictMarket  string
-		Question       string
-		Outcome        string
-		Midpoint       string
-		BuyPrice       string
-		SellPrice      string
-		Threshold      string
-		DirectionText  string
-		DirectionEmoji string
-		MidpointColor  string
-		Timestamp      string
-	}{
+	data := predictEmailData{
 		PredictMarket:  r.PredictMarket,
 		Question:       r.Question,
 		Outcome:        r.Outcome,
+		FieldLabel:     fieldLabel,
+		History:        history,
 		Midpoint:       fmt.Sprintf("%.4f", decision.CurrentMidpoint),
 		BuyPrice:       fmt.Sprintf("%.4f", decision.CurrentBuyPrice),
 		SellPrice:      fmt.Sprintf("%.4f", decision.CurrentSellPrice),
@@ -753,6 +840,14 @@ This is an automated alert from your prediction market monitoring system.
 		MidpointColor:  midpointColor,
 		Timestamp:      timestamp.Format(time.RFC3339),
 	}
+	if fieldValueLine != "" {
+		data.FieldValue = fmt.Sprintf("%.4f", decision.CurrentValue)
+	}
+
+	// Operator-provided template from EMAIL_TEMPLATES_DIR, if any
+	if html, ok := renderEmailTemplateOverride(EmailTemplatePredict, data); ok {
+		return subject, textBody, html
+	}
 
 	tmpl, err := template.New("predict-market-email").Parse(htmlTemplate)
 	if err != nil {
@@ -775,6 +870,14 @@ func FormatDeFiAlertEmail(decision *core.DeFiAlertDecision) (subject, textBody,
 	if decision.Rule == nil {
 		return "", "", ""
 	}
+	// The rule's message template, if any, replaces the built-in message
+	defer func() {
+		subject, textBody, htmlBody = applyMessageTemplate(decision.Rule.MessageTemplate, decision.TemplateData(), subject, textBody, htmlBody)
+	}()
+	// Rules with a non-English locale get the translated message
+	if a, ok := localizeDeFiAlert(decision); ok {
+		return a.email()
+	}
 
 	protocol := decision.Rule.Protocol
 	version := decision.Rule.Version
@@ -809,9 +912,172 @@ func FormatDeFiAlertEmail(decision *core.DeFiAlertDecision) (subject, textBody,
 		}
 	}
 
+	if ens := formatENSNames(decision.Rule.ENSNames); ens != "" {
+		if marketInfo != "" {
+			marketInfo += " · "
+		}
+		marketInfo += ens
+	}
+
 	subject = FormatDeFiAlertSubject(protocol, version, field, chainName, value, threshold, direction, marketInfo)
 	textBody = FormatDeFiAlertMessage(protocol, version, field, chainName, value, threshold, direction, timestamp, marketInfo)
 	htmlBody = FormatDeFiAlertHTML(protocol, version, field, chainName, value, threshold, direction, timestamp, marketInfo)
 
 	return subject, textBody, htmlBody
 }
+
+// formatENSNames lists ENS names with their resolved addresses, e.g. "vault.eth (0xAbc...)"
+func formatENSNames(names map[string]string) string {
+	if len(names) == 0 {
+		return ""
+	}
+	parts := make([]string, 0, len(names))
+	for addr, name := range names {
+		parts = append(parts, fmt.Sprintf("%s (%s)", name, addr))
+	}
+	sort.Strings(parts)
+	return strings.Join(parts, ", ")
+}
+
+// watchSourceName returns a display name for a watch rule source
+func watchSourceName(source string) string {
+	switch source {
+	case "safe":
+		return "Safe Multisig"
+	case "approval":
+		return "Token Approval"
+	case "oracle":
+		return "Oracle Deviation"
+	case "snapshot":
+		return "Snapshot Governance"
+	case "governor":
+		return "On-chain Governance"
+	case "bridge":
+		return "Bridge TVL"
+	case "mempool":
+		return "Bitcoin Mempool"
+	case "btc_address":
+		return "Bitcoin Address"
+	default:
+		return source
+	}
+}
+
+// FormatWatchAlertEmail formats subject, plain-text body, and HTML body for a watch alert.
+func FormatWatchAlertEmail(decision *core.WatchAlertDecision) (subject, textBody, htmlBody string) {
+	if decision.Rule == nil || decision.Observation == nil {
+		return "", "", ""
+	}
+	// The rule's message template, if any, replaces the built-in message
+	defer func() {
+		subject, textBody, htmlBody = applyMessageTemplate(decision.Rule.MessageTemplate, decision.TemplateData(), subject, textBody, htmlBody)
+	}()
+	// Rules with a non-English locale get the translated message
+	if a, ok := localizeWatchAlert(decision); ok {
+		return a.email()
+	}
+	r := decision.Rule
+	o := decision.Observation
+	timestamp := time.Now()
+
+	sourceName := watchSourceName(r.Source)
+	name := r.Label
+	if name == "" {
+		name = sourceName
+	}
+
+	subject = fmt.Sprintf("🔔 %s Alert: %s - %s", sourceName, name, o.Title)
+
+	var text strings.Builder
+	fmt.Fprintf(&text, "%s Alert Triggered!\n\n", sourceName)
+	fmt.Fprintf(&text, "Watch: %s\nChain: %s\nEvent: %s\n", name, decision.ChainName, o.Title)
+	for _, d := range o.Details {
+		fmt.Fprintf(&text, "%s: %s\n", d.Label, d.Value)
+	}
+	if o.URL != "" {
+		fmt.Fprintf(&text, "Link: %s\n", o.URL)
+	}
+	fmt.Fprintf(&text, "Timestamp: %s\n\nThis is an automated alert from your crypto monitoring system.\n", timestamp.Format(time.RFC3339))
+	textBody = text.String()
+
+	htmlTemplate := `
+<!DOCTYPE html>
+<html>
+<head>
+	<meta charset="UTF-8">
+	<meta name="viewport" content="width=device-width, initial-scale=1.0">
+	<title>{{.SourceName}} Alert</title>
+</head>
+<body style="font-family: -apple-system, BlinkMacSystemFont, 'Segoe UI', Roboto, 'Helvetica Neue', Arial, sans-serif; line-height: 1.6; color: #333; max-width: 600px; margin: 0 auto; padding: 20px;">
+	<div style="background: linear-gradient(135deg, #667eea 0%, #764ba2 100%); padding: 30px; border-radius: 10px 10px 0 0; text-align: center;">
+		<h1 style="color: white; margin: 0; font-size: 28px;">🔔 {{.SourceName}} Alert</h1>
+	</div>
+
+	<div style="background: #f9fafb; padding: 30px; border-radius: 0 0 10px 10px; border: 1px solid #e5e7eb;">
+		<div style="background: white; padding: 25px; border-radius: 8px; margin-bottom: 20px; box-shadow: 0 2px 4px rgba(0,0,0,0.1);">
+			<h2 style="margin-top: 0; color: #1f2937; font-size: 24px;">{{.Name}}</h2>
+			<p style="font-size: 18px; color: #1f2937; margin: 10px 0 20px 0;">{{.Title}}</p>
+
+			<div style="border-top: 1px solid #e5e7eb; padding-top: 20px; margin-top: 20px;">
+				<table style="width: 100%; border-collapse: collapse;">
+					<tr>
+						<td style="padding: 10px 0; color: #6b7280; font-weight: 500;">Chain:</td>
+						<td style="padding: 10px 0; text-align: right; font-weight: 600;">{{.ChainName}}</td>
+					</tr>
+					{{range .Details}}
+					<tr>
+						<td style="padding: 10px 0; color: #6b7280; font-weight: 500;">{{.Label}}:</td>
+						<td style="padding: 10px 0; text-align: right; font-weight: 600; word-break: break-all;">{{.Value}}</td>
+					</tr>
+					{{end}}
+					<tr>
+						<td style="padding: 10px 0; color: #6b7280; font-weight: 500;">Timestamp:</td>
+						<td style="padding: 10px 0; text-align: right; font-weight: 600;">{{.Timestamp}}</td>
+					</tr>
+				</table>
+			</div>
+			{{if .URL}}
+			<div style="text-align: center; margin-top: 20px;">
+				<a href="{{.URL}}" style="display: inline-block; background: #667eea; color: white; padding: 10px 20px; border-radius: 6px; text-decoration: none;">View details</a>
+			</div>
+			{{end}}
+		</div>
+
+		<div style="text-align: center; color: #6b7280; font-size: 12px; margin-top: 20px;">
+			<p style="margin: 0;">This is an automated alert from your crypto monitoring system.</p>
+		</div>
+	</div>
+</body>
+</html>
+`
+
+	data := watchEmailData{
+		SourceName: sourceName,
+		Name:       name,
+		Title:      o.Title,
+		ChainName:  decision.ChainName,
+		Details:    o.Details,
+		URL:        o.URL,
+		Timestamp:  timestamp.Format(time.RFC3339),
+	}
+
+	// Operator-provided template from EMAIL_TEMPLATES_DIR, if any
+	if html, ok := renderEmailTemplateOverride(EmailTemplateWatch, data); ok {
+		return subject, textBody, html
+	}
+
+	tmpl, err := template.New("watch-email").Parse(htmlTemplate)
+	if err != nil {
+		htmlBody = fmt.Sprintf("<html><body><h1>🔔 %s Alert</h1><p>%s</p></body></html>", sourceName, strings.ReplaceAll(textBody, "\n", "<br>"))
+		return subject, textBody, htmlBody
+	}
+
+	var buf strings.Builder
+	if err := tmpl.Execute(&buf, data); err != nil {
+		htmlBody = fmt.Sprintf("<html><body><h1>🔔 %s Alert</h1><p>%s</p></body></html>", sourceName, strings.ReplaceAll(textBody, "\n", "<br>"))
+		return subject, textBody, htmlBody
+	}
+
+	htmlBody = buf.String()
+	return subject, textBody, htmlBody
+}
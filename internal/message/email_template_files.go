@@ -0,0 +1,162 @@
+package message
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"html/template"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"crypto-alert/internal/core"
+	"crypto-alert/internal/logger"
+)
+
+// File names of the email templates an operator can override
+const (
+	EmailTemplateToken   = "token.html"
+	EmailTemplateDeFi    = "defi.html"
+	EmailTemplatePredict = "predict.html"
+	EmailTemplateWatch   = "watch.html"
+)
+
+// tokenEmailData is the data of the token alert email template
+type tokenEmailData struct {
+	Symbol         string
+	Price          string
+	Threshold      string
+	DirectionText  string
+	DirectionEmoji string
+	PriceColor     string
+	Timestamp      string
+	ChartURL       string // Price chart image of the last 24h; empty when there is no history
+}
+
+// defiEmailData is the data of the DeFi alert email template
+type defiEmailData struct {
+	Protocol        string
+	Version         string
+	Field           string
+	ChainName       string
+	Value           string
+	Threshold       string
+	DirectionText   string
+	DirectionEmoji  string
+	ValueColor      string
+	Timestamp       string
+	MarketInfo      string
+	MarketInfoLabel string
+}
+
+// predictEmailData is the data of the prediction market alert email template
+type predictEmailData struct {
+	PredictMarket  string
+	Question       string
+	Outcome        string
+	FieldLabel     string
+	FieldValue     string
+	History        string
+	Midpoint       string
+	BuyPrice       string
+	SellPrice      string
+	Threshold      string
+	DirectionText  string
+	DirectionEmoji string
+	MidpointColor  string
+	Timestamp      string
+}
+
+// watchEmailData is the data of the watch alert email template
+type watchEmailData struct {
+	SourceName string
+	Name       string
+	Title      string
+	ChainName  string
+	Details    []core.WatchDetail
+	URL        string
+	Timestamp  string
+}
+
+// emailTemplateSamples are executed against each override at load time, so a
+// template that refers to a missing field fails at startup instead of on an alert
+var emailTemplateSamples = map[string]interface{}{
+	EmailTemplateToken: tokenEmailData{
+		Symbol: "BTC", Price: "65000", Threshold: "60000", DirectionText: "greater than",
+		DirectionEmoji: "📈", PriceColor: "#10b981", Timestamp: "2025-01-01T00:00:00Z",
+	},
+	EmailTemplateDeFi: defiEmailData{
+		Protocol: "aave", Version: "v3", Field: "TVL", ChainName: "Ethereum", Value: "1.00 billion",
+		Threshold: "900000000", DirectionText: "greater than", DirectionEmoji: "📈", ValueColor: "#10b981",
+		Timestamp: "2025-01-01T00:00:00Z", MarketInfo: "USDC", MarketInfoLabel: "Token",
+	},
+	EmailTemplatePredict: predictEmailData{
+		PredictMarket: "polymarket", Question: "Will it happen?", Outcome: "Yes", FieldLabel: "Midpoint",
+		History: "24h: low 0.3100 / high 0.4500 ↑ (from 0.3300)", Midpoint: "0.4000", BuyPrice: "0.4100",
+		SellPrice: "0.3900", Threshold: "0.35", DirectionText: "greater than", DirectionEmoji: "📈",
+		MidpointColor: "#10b981", Timestamp: "2025-01-01T00:00:00Z",
+	},
+	EmailTemplateWatch: watchEmailData{
+		SourceName: "Safe Multisig", Name: "Treasury", Title: "2 pending transactions", ChainName: "Ethereum",
+		Details: []core.WatchDetail{{Label: "Nonce", Value: "42"}}, URL: "https://example.com",
+		Timestamp: "2025-01-01T00:00:00Z",
+	},
+}
+
+var (
+	emailTemplatesMu       sync.RWMutex
+	emailTemplateOverrides = map[string]*template.Template{}
+)
+
+// LoadEmailTemplates replaces the built-in HTML of the alert emails with the
+// html/template files in dir (token.html, defi.html, predict.html, watch.html).
+// Missing files keep the built-in template. Each file is parsed and executed
+// against sample data; files that fail are reported in the returned error and
+// keep the built-in template too. It returns the names of the loaded templates.
+func LoadEmailTemplates(dir string) ([]string, error) {
+	overrides := map[string]*template.Template{}
+	var loaded []string
+	var errs []error
+	for _, name := range []string{EmailTemplateToken, EmailTemplateDeFi, EmailTemplatePredict, EmailTemplateWatch} {
+		path := filepath.Join(dir, name)
+		if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		tmpl, err := template.ParseFiles(path)
+		if err == nil {
+			err = tmpl.Execute(io.Discard, emailTemplateSamples[name])
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", name, err))
+			continue
+		}
+		overrides[name] = tmpl
+		loaded = append(loaded, name)
+	}
+
+	emailTemplatesMu.Lock()
+	emailTemplateOverrides = overrides
+	emailTemplatesMu.Unlock()
+	return loaded, errors.Join(errs...)
+}
+
+// renderEmailTemplateOverride renders the override of the named template. It
+// reports false when there is no override or it fails, so the caller falls
+// back to the built-in template.
+func renderEmailTemplateOverride(name string, data interface{}) (string, bool) {
+	emailTemplatesMu.RLock()
+	tmpl := emailTemplateOverrides[name]
+	emailTemplatesMu.RUnlock()
+	if tmpl == nil {
+		return "", false
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		slog.Warn("⚠️  Email template failed, using the built-in one", "template", name, logger.FieldError, err)
+		return "", false
+	}
+	return buf.String(), true
+}
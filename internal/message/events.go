@@ -1,30 +1,258 @@
 package message
 
-import "time"
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"time"
 
-// Kafka topic names
+	"crypto-alert/internal/core"
+)
+
+// Event topic names (Kafka topics, NATS subjects)
 const (
 	TopicTokenAlert   = "alerts.token"
 	TopicDeFiAlert    = "alerts.defi"
 	TopicPredictAlert = "alerts.predict"
+	TopicWatchAlert   = "alerts.watch"
+	TopicRetry        = "alerts.retry"
+	TopicSummary      = "alerts.summary"
+	TopicDeadLetter   = "alerts.dead"
+)
+
+// RuleIncidentKey returns the incident key of a rule, used as the PagerDuty
+// dedup key and the Opsgenie alias so repeated alerts of the rule update one
+// incident and a recovery rule can resolve it. kind is the rule type: token,
+// defi, predict or watch.
+func RuleIncidentKey(kind string, ruleID int64) string {
+	return fmt.Sprintf("crypto-alert/%s/%d", kind, ruleID)
+}
+
+// AlertEventID returns the idempotency key of an alert: a hash of the topic,
+// the rule ID and when the rule triggered. The notification service skips
+// events whose ID it has already delivered, e.g. when a crash or an offset
+// reset makes it read them again.
+func AlertEventID(topic string, ruleID int64, triggeredAt time.Time) string {
+	sum := sha256.Sum256(fmt.Appendf(nil, "%s/%d/%d", topic, ruleID, triggeredAt.UnixNano()))
+	return hex.EncodeToString(sum[:16])
+}
+
+// RetryEvent re-queues an alert whose delivery failed on some channels, so the
+// notification service can commit the original message and retry later.
+type RetryEvent struct {
+	Topic     string          `json:"topic"`    // Topic of the original alert event
+	Channels  []string        `json:"channels"` // Channels to retry
+	Attempt   int             `json:"attempt"`  // 1 for the first retry
+	NotBefore time.Time       `json:"not_before"`
+	Payload   json.RawMessage `json:"payload"` // The original alert event
+}
+
+// Reasons an alert was dead-lettered
+const (
+	DeadLetterPermanent        = "permanent error"   // A channel rejected the alert, e.g. an HTTP 4xx response
+	DeadLetterRetriesExhausted = "retries exhausted" // A channel still failed after the last retry
+	DeadLetterUndecodable      = "undecodable"       // The event couldn't be decoded
+	DeadLetterHandlerFailed    = "handler failed"    // Handling the event kept failing, e.g. a panic
 )
 
-// TokenAlertEvent is the Kafka message payload for a price (token) alert.
+// DeadLetterEvent is the payload for alerts.dead: an alert the notification
+// service gave up on, kept with the reasons so it can be inspected and sent
+// again once the underlying issue is fixed.
+type DeadLetterEvent struct {
+	Topic    string            `json:"topic"`            // Topic of the original alert event
+	Reason   string            `json:"reason"`           // One of the DeadLetter reasons
+	Channels []string          `json:"channels"`         // Channels that failed; empty for all channels
+	Errors   map[string]string `json:"errors,omitempty"` // Channel (or "decode") -> last error
+	Attempts int               `json:"attempts"`         // Retries made before giving up
+	FailedAt time.Time         `json:"failed_at"`
+	Payload  json.RawMessage   `json:"payload"` // The original alert event
+}
+
+// NoticeEvent is the body of a notice posted to a rule's webhook, e.g. the
+// summary of alerts dropped by the notification rate limit.
+type NoticeEvent struct {
+	RuleID  int64  `json:"rule_id,omitempty"`
+	Topic   string `json:"topic"` // Topic of the alerts the notice is about
+	Subject string `json:"subject"`
+	Text    string `json:"text"`
+}
+
+// SummaryEvent is one recipient's daily summary: the latest values of the
+// prices and DeFi fields their rules monitor, the rules that fired and the
+// feeds that failed over the period.
+type SummaryEvent struct {
+	RecipientEmail string             `json:"recipient_email"`
+	Since          time.Time          `json:"since"`
+	Until          time.Time          `json:"until"`
+	Prices         []SummaryValue     `json:"prices,omitempty"`
+	DeFiValues     []SummaryValue     `json:"defi_values,omitempty"`
+	Fired          []SummaryRule      `json:"fired,omitempty"`
+	FeedErrors     []SummaryFeedError `json:"feed_errors,omitempty"`
+}
+
+// SummaryValue is the latest value of a monitored price or DeFi field
+type SummaryValue struct {
+	Label string    `json:"label"`
+	Value float64   `json:"value"`
+	Time  time.Time `json:"time"`
+}
+
+// SummaryRule is a rule that fired during the summary's period
+type SummaryRule struct {
+	Kind          string    `json:"kind"` // token, defi, predict or watch
+	RuleID        int64     `json:"rule_id,omitempty"`
+	Label         string    `json:"label"`
+	LastTriggered time.Time `json:"last_triggered"`
+}
+
+// SummaryFeedError is a feed that failed during the summary's period
+type SummaryFeedError struct {
+	Feed      string    `json:"feed"`
+	Count     int       `json:"count"`
+	LastError string    `json:"last_error"`
+	LastAt    time.Time `json:"last_at"`
+}
+
+// NotificationTargets are the rule's notification settings carried on every
+// alert event. Embedded so the fields stay at the top level of the JSON. A new
+// destination field belongs in privateTargetFields too.
+type NotificationTargets struct {
+	SchemaVersion        int       `json:"schema_version"`     // EventSchemaVersion of the publisher, missing on version 1 events
+	EventID              string    `json:"event_id,omitempty"` // AlertEventID of the alert, the same when the event is read again
+	TriggeredAt          time.Time `json:"triggered_at"`
+	RuleID               int64     `json:"rule_id,omitempty"`
+	RecipientEmail       string    `json:"recipient_email"`            // First of RecipientEmails, for consumers that predate the list
+	TelegramChatID       string    `json:"telegram_chat_id,omitempty"` // First of TelegramChatIDs, for consumers that predate the list
+	RecipientEmails      []string  `json:"recipient_emails,omitempty"`
+	TelegramChatIDs      []string  `json:"telegram_chat_ids,omitempty"`
+	Channels             []string  `json:"channels,omitempty"` // Channel allow-list, empty allows all
+	Tags                 []string  `json:"tags,omitempty"`     // The rule's tags
+	WebhookURL           string    `json:"webhook_url,omitempty"`
+	WhatsAppTo           string    `json:"whatsapp_to,omitempty"`
+	TeamsWebhookURL      string    `json:"teams_webhook_url,omitempty"`
+	NtfyTopic            string    `json:"ntfy_topic,omitempty"`
+	PushoverUserKey      string    `json:"pushover_user_key,omitempty"`
+	Severity             string    `json:"severity,omitempty"`
+	PagerDutyRoutingKey  string    `json:"pagerduty_routing_key,omitempty"`
+	OpsgenieAPIKey       string    `json:"opsgenie_api_key,omitempty"`
+	ResolvesRuleID       int64     `json:"resolves_rule_id,omitempty"`
+	MessageTemplate      string    `json:"message_template,omitempty"`
+	Locale               string    `json:"locale,omitempty"`
+	DigestMinutes        int       `json:"digest_minutes,omitempty"`
+	TelegramChart        bool      `json:"telegram_chart,omitempty"`
+	TelegramFormat       string    `json:"telegram_format,omitempty"`
+	TelegramSilent       bool      `json:"telegram_silent,omitempty"`
+	TelegramNoPreview    bool      `json:"telegram_no_preview,omitempty"`
+	EscalateAfterMinutes int       `json:"escalate_after_minutes,omitempty"` // Re-send unacknowledged critical alerts to EscalateTo
+	EscalateTo           []string  `json:"escalate_to,omitempty"`            // channel:destination pairs
+}
+
+// Destinations returns the alert's destinations for the named channel, or nil
+// when the alert doesn't go to that channel: the rule's own destinations plus
+// those its severity's route adds, on the channels the route allows.
+func (t NotificationTargets) Destinations(channel string) []string {
+	severity := core.Severity(t.Severity)
+	if severity == "" {
+		severity = core.SeverityWarning
+	}
+	route := severityRoutes[severity]
+	if len(route.Channels) > 0 && !slices.Contains(route.Channels, channel) {
+		return nil
+	}
+	return appendDestinations(t.ruleDestinations(channel), route.Extra[channel]...)
+}
+
+// ruleDestinations returns the rule's destinations for the named channel, or
+// nil when the rule doesn't notify that channel.
+func (t NotificationTargets) ruleDestinations(channel string) []string {
+	if len(t.Channels) > 0 && !slices.Contains(t.Channels, channel) {
+		return nil
+	}
+	switch channel {
+	case ChannelEmail:
+		return appendDestinations(nil, append([]string{t.RecipientEmail}, t.RecipientEmails...)...)
+	case ChannelTelegram:
+		return appendDestinations(nil, append([]string{t.TelegramChatID}, t.TelegramChatIDs...)...)
+	case ChannelWhatsApp:
+		return appendDestinations(nil, t.WhatsAppTo)
+	case ChannelTeams:
+		return appendDestinations(nil, t.TeamsWebhookURL)
+	case ChannelNtfy:
+		return appendDestinations(nil, t.NtfyTopic)
+	case ChannelPushover:
+		return appendDestinations(nil, t.PushoverUserKey)
+	case ChannelPagerDuty:
+		return appendDestinations(nil, t.PagerDutyRoutingKey)
+	case ChannelOpsgenie:
+		return appendDestinations(nil, t.OpsgenieAPIKey)
+	case ChannelWebhook:
+		return appendDestinations(nil, t.WebhookURL)
+	}
+	return nil
+}
+
+// appendDestinations appends the non-empty destinations not already in dst
+func appendDestinations(dst []string, destinations ...string) []string {
+	for _, d := range destinations {
+		if d != "" && !slices.Contains(dst, d) {
+			dst = append(dst, d)
+		}
+	}
+	return dst
+}
+
+// privateTargetFields are the JSON fields of NotificationTargets naming the
+// rule's destinations, some of them credentials (webhook URLs, integration and
+// API keys). They stay inside the service: see PublicAlertEvent.
+var privateTargetFields = []string{
+	"recipient_email", "telegram_chat_id", "recipient_emails", "telegram_chat_ids",
+	"webhook_url", "whatsapp_to", "teams_webhook_url", "ntfy_topic", "pushover_user_key",
+	"pagerduty_routing_key", "opsgenie_api_key", "escalate_to",
+}
+
+// PublicAlertEvent returns an alert event's JSON without the rule's
+// destinations and credentials, for payloads that leave the service, e.g. a
+// webhook body or PagerDuty's custom details. A payload that isn't a JSON
+// object is returned as is.
+func PublicAlertEvent(payload []byte) []byte {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(payload, &fields); err != nil {
+		return payload
+	}
+	for _, name := range privateTargetFields {
+		delete(fields, name)
+	}
+	public, err := json.Marshal(fields)
+	if err != nil {
+		return payload
+	}
+	return public
+}
+
+// TokenAlertEvent is the event payload for a price (token) alert.
 type TokenAlertEvent struct {
-	RecipientEmail   string    `json:"recipient_email"`
-	TelegramChatID   string    `json:"telegram_chat_id,omitempty"`
-	Symbol           string    `json:"symbol"`
-	Price            float64   `json:"price"`
-	Threshold        float64   `json:"threshold"`
-	Direction        string    `json:"direction"`
-	Timestamp        time.Time `json:"timestamp"`
-	Message          string    `json:"message"`
+	NotificationTargets
+	Symbol    string    `json:"symbol"`
+	Price     float64   `json:"price"`
+	Threshold float64   `json:"threshold"`
+	Direction string    `json:"direction"`
+	Timestamp time.Time `json:"timestamp"`
+	Message   string    `json:"message"`
+	// Recent prices for the alert chart, omitted when no history is stored
+	History []HistoryPointEvent `json:"history,omitempty"`
+}
+
+// HistoryPointEvent is one recorded value of a metric
+type HistoryPointEvent struct {
+	Time  time.Time `json:"time"`
+	Value float64   `json:"value"`
 }
 
-// DeFiAlertEvent is the Kafka message payload for a DeFi protocol alert.
+// DeFiAlertEvent is the event payload for a DeFi protocol alert.
 type DeFiAlertEvent struct {
-	RecipientEmail string `json:"recipient_email"`
-	TelegramChatID string `json:"telegram_chat_id,omitempty"`
+	NotificationTargets
 	// Rule identity
 	Protocol  string `json:"protocol"`
 	Category  string `json:"category"`
@@ -51,17 +279,21 @@ type DeFiAlertEvent struct {
 	MarketContractAddress   string `json:"market_contract_address"`
 	VaultTokenAddress       string `json:"vault_token_address"`
 	DepositTokenContract    string `json:"deposit_token_contract"`
+	// ENS names the rule's addresses were configured with (lower-case address -> name)
+	ENSNames map[string]string `json:"ens_names,omitempty"`
+	// Recent values of the field for the alert chart, omitted when no history is stored
+	History []HistoryPointEvent `json:"history,omitempty"`
 }
 
-// PredictMarketAlertEvent is the Kafka message payload for a prediction market alert.
+// PredictMarketAlertEvent is the event payload for a prediction market alert.
 type PredictMarketAlertEvent struct {
-	RecipientEmail   string  `json:"recipient_email"`
-	TelegramChatID   string  `json:"telegram_chat_id,omitempty"`
+	NotificationTargets
 	PredictMarket    string  `json:"predict_market"`
 	TokenID          string  `json:"token_id"`
 	Field            string  `json:"field"`
 	Threshold        float64 `json:"threshold"`
 	Direction        string  `json:"direction"`
+	CurrentValue     float64 `json:"current_value"` // Value of Field (equals CurrentMidpoint for MIDPOINT)
 	CurrentMidpoint  float64 `json:"current_midpoint"`
 	CurrentBuyPrice  float64 `json:"current_buy_price"`
 	CurrentSellPrice float64 `json:"current_sell_price"`
@@ -72,4 +304,45 @@ type PredictMarketAlertEvent struct {
 	QuestionID  string `json:"question_id"`
 	ConditionID string `json:"condition_id"`
 	NegRisk     bool   `json:"neg_risk"`
+	// Order book depth (DEPTH field only)
+	DepthSide  string  `json:"depth_side,omitempty"`
+	DepthPrice float64 `json:"depth_price,omitempty"`
+	// Multi-outcome rules (SUM / DIFF only)
+	TokenIDs   []string `json:"token_ids,omitempty"`
+	GroupItems []string `json:"group_items,omitempty"`
+	// Probability move (MOVE only)
+	MoveWindowMinutes int `json:"move_window_minutes,omitempty"`
+	// Recent midpoint context, omitted when no history is stored
+	History *PredictHistoryEvent `json:"history,omitempty"`
+}
+
+// PredictHistoryEvent summarizes a token's recent midpoints
+type PredictHistoryEvent struct {
+	PeriodHours float64 `json:"period_hours"`
+	Open        float64 `json:"open"`
+	Low         float64 `json:"low"`
+	High        float64 `json:"high"`
+	Samples     int     `json:"samples"`
+}
+
+// WatchAlertEvent is the event payload for a watch alert (Safe multisig, ...).
+type WatchAlertEvent struct {
+	NotificationTargets
+	// Rule identity
+	Source    string  `json:"source"`
+	ChainID   string  `json:"chain_id"`
+	ChainName string  `json:"chain_name"`
+	Field     string  `json:"field"`
+	Label     string  `json:"label,omitempty"`
+	Threshold float64 `json:"threshold"`
+	Direction string  `json:"direction,omitempty"`
+	// Observation
+	Key       string             `json:"key,omitempty"`
+	Value     float64            `json:"value"`
+	Unlimited bool               `json:"unlimited,omitempty"` // Value stands for an unlimited amount (e.g. an unlimited approval)
+	Title     string             `json:"title"`
+	Details   []core.WatchDetail `json:"details,omitempty"`
+	URL       string             `json:"url,omitempty"`
+	Message   string             `json:"message"`
+	Timestamp time.Time          `json:"timestamp"`
 }
@@ -0,0 +1,119 @@
+package message
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultGroupWindow is how long alerts for a destination are held back to be
+// grouped with the ones that follow
+const DefaultGroupWindow = 10 * time.Second
+
+// Groupable reports whether an alert on channel is held back to be grouped
+// with the other alerts for its destination: only email and Telegram group,
+// and retries are sent on their own.
+func Groupable(channel string, attempt int) bool {
+	return attempt == 0 && (channel == ChannelEmail || channel == ChannelTelegram)
+}
+
+// GroupedAlert is an alert held back for grouping
+type GroupedAlert struct {
+	Delivery Delivery
+	Send     func() error // Sends the alert on its own, when it turns out to be alone
+}
+
+// AlertGroup is the alerts for one destination that came in together
+type AlertGroup struct {
+	Channel string
+	Alerts  []GroupedAlert
+}
+
+// pendingGroup collects a destination's alerts until the group window has
+// passed since the first of them
+type pendingGroup struct {
+	AlertGroup
+	due time.Time
+}
+
+// Grouper merges the alerts a destination gets within a short window, e.g.
+// the many rules one check triggers in a market-wide crash, into one message.
+type Grouper struct {
+	window  time.Duration
+	mu      sync.Mutex
+	pending map[destinationKey]*pendingGroup
+}
+
+// NewGrouper creates a grouper that holds alerts back for window. A window of
+// 0 or less disables grouping.
+func NewGrouper(window time.Duration) *Grouper {
+	return &Grouper{window: window, pending: map[destinationKey]*pendingGroup{}}
+}
+
+// Enabled reports whether alerts are grouped
+func (g *Grouper) Enabled() bool {
+	return g.window > 0
+}
+
+// Add holds back an alert for its destination on channel. The destination's
+// group is due one window after its first alert.
+func (g *Grouper) Add(channel string, a GroupedAlert) {
+	key := destinationKey{channel, a.Delivery.To}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	p := g.pending[key]
+	if p == nil {
+		p = &pendingGroup{AlertGroup: AlertGroup{Channel: channel}, due: time.Now().Add(g.window)}
+		g.pending[key] = p
+	}
+	p.Alerts = append(p.Alerts, a)
+}
+
+// Due returns and removes the groups whose window has passed
+func (g *Grouper) Due() []AlertGroup {
+	now := time.Now()
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	var due []AlertGroup
+	for key, p := range g.pending {
+		if !now.Before(p.due) {
+			due = append(due, p.AlertGroup)
+			delete(g.pending, key)
+		}
+	}
+	return due
+}
+
+// Flush returns and removes every pending group, due or not, e.g. to send
+// them on shutdown
+func (g *Grouper) Flush() []AlertGroup {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	var pending []AlertGroup
+	for key, p := range g.pending {
+		pending = append(pending, p.AlertGroup)
+		delete(g.pending, key)
+	}
+	return pending
+}
+
+// Subject returns the subject line of the grouped message
+func (g AlertGroup) Subject() string {
+	return fmt.Sprintf("%d alerts triggered", len(g.Alerts))
+}
+
+// Text returns the grouped message, one line per alert in the order they came in
+func (g AlertGroup) Text() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%d alerts triggered together:\n\n", len(g.Alerts))
+	for _, a := range g.Alerts {
+		fmt.Fprintf(&b, "• %s\n", AlertMessage(a.Delivery.Payload))
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
@@ -0,0 +1,468 @@
+package message
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"html/template"
+	"log/slog"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"crypto-alert/internal/core"
+	"crypto-alert/internal/logger"
+)
+
+// translations maps the English phrases of the built-in messages to each
+// supported locale. English needs no entry; a phrase missing from a locale
+// falls back to English.
+var translations = map[core.Locale]map[string]string{
+	core.LocaleChinese: {
+		"greater than or equal to": "大于或等于",
+		"greater than":             "大于",
+		"equal to":                 "等于",
+		"less than or equal to":    "小于或等于",
+		"less than":                "小于",
+
+		"Crypto Alert Triggered":                   "加密货币警报已触发",
+		"DeFi Alert Triggered":                     "DeFi 警报已触发",
+		"Prediction Market Alert":                  "预测市场警报",
+		"%s Alert":                                 "%s 警报",
+		"🚨 Crypto Alert: %s %s %s":                 "🚨 加密货币警报：%s %s %s",
+		"🚨 DeFi Alert: %s %s %s on %s %s %s":       "🚨 DeFi 警报：%[4]s 上的 %[1]s %[2]s %[3]s %[5]s %[6]s",
+		"🚨 Prediction Market Alert: %s (%s) %s %s": "🚨 预测市场警报：%s（%s）%s %s",
+		"🔔 %s Alert: %s":                           "🔔 %s 警报：%s",
+		"%s on %s":                                 "%[2]s 上的 %[1]s",
+		"Price is %s %s":                           "价格%s %s",
+		"%s is %s %s":                              "%s%s %s",
+
+		"Current Price": "当前价格",
+		"Current Value": "当前值",
+		"Threshold":     "阈值",
+		"Condition":     "条件",
+		"Time":          "时间",
+		"Chain":         "链",
+		"Market":        "市场",
+		"Field":         "字段",
+		"Question":      "问题",
+		"Outcome":       "结果",
+		"Midpoint":      "中间价",
+		"Buy Price":     "买入价",
+		"Sell Price":    "卖出价",
+		"History":       "历史",
+		"View details":  "查看详情",
+		"Last 24 Hours": "最近 24 小时",
+
+		"Spread":                 "买卖价差",
+		"Sum of Outcomes":        "结果之和",
+		"%dm Move (points)":      "%d 分钟变动（点）",
+		"Outcome Difference":     "结果差值",
+		"Ask Depth <= %g (USDC)": "卖单深度 <= %g（USDC）",
+		"Bid Depth >= %g (USDC)": "买单深度 >= %g（USDC）",
+
+		"%.0fh: low %s / high %s %s (from %s)": "%.0f 小时：最低 %s / 最高 %s %s（起始 %s）",
+
+		"This is an automated alert from your crypto monitoring system.": "这是来自您的加密货币监控系统的自动警报。",
+	},
+	core.LocaleSpanish: {
+		"greater than or equal to": "mayor o igual que",
+		"greater than":             "mayor que",
+		"equal to":                 "igual a",
+		"less than or equal to":    "menor o igual que",
+		"less than":                "menor que",
+
+		"Crypto Alert Triggered":                   "Alerta cripto activada",
+		"DeFi Alert Triggered":                     "Alerta DeFi activada",
+		"Prediction Market Alert":                  "Alerta de mercado de predicción",
+		"%s Alert":                                 "Alerta de %s",
+		"🚨 Crypto Alert: %s %s %s":                 "🚨 Alerta cripto: %s %s %s",
+		"🚨 DeFi Alert: %s %s %s on %s %s %s":       "🚨 Alerta DeFi: %s %s %s en %s %s %s",
+		"🚨 Prediction Market Alert: %s (%s) %s %s": "🚨 Alerta de mercado de predicción: %s (%s) %s %s",
+		"🔔 %s Alert: %s":                           "🔔 Alerta de %s: %s",
+		"%s on %s":                                 "%s en %s",
+		"Price is %s %s":                           "El precio es %s %s",
+		"%s is %s %s":                              "%s es %s %s",
+
+		"Current Price": "Precio actual",
+		"Current Value": "Valor actual",
+		"Threshold":     "Umbral",
+		"Condition":     "Condición",
+		"Time":          "Hora",
+		"Chain":         "Cadena",
+		"Market":        "Mercado",
+		"Field":         "Campo",
+		"Question":      "Pregunta",
+		"Outcome":       "Resultado",
+		"Midpoint":      "Precio medio",
+		"Buy Price":     "Precio de compra",
+		"Sell Price":    "Precio de venta",
+		"History":       "Historial",
+		"View details":  "Ver detalles",
+		"Last 24 Hours": "Últimas 24 horas",
+
+		"Spread":                 "Diferencial",
+		"Sum of Outcomes":        "Suma de resultados",
+		"%dm Move (points)":      "Movimiento en %d min (puntos)",
+		"Outcome Difference":     "Diferencia de resultados",
+		"Ask Depth <= %g (USDC)": "Profundidad de venta <= %g (USDC)",
+		"Bid Depth >= %g (USDC)": "Profundidad de compra >= %g (USDC)",
+
+		"%.0fh: low %s / high %s %s (from %s)": "%.0f h: mínimo %s / máximo %s %s (desde %s)",
+
+		"This is an automated alert from your crypto monitoring system.": "Esta es una alerta automática de su sistema de monitoreo cripto.",
+	},
+}
+
+// tr returns the translation of an English phrase into locale
+func tr(locale core.Locale, phrase string) string {
+	if t, ok := translations[locale][phrase]; ok {
+		return t
+	}
+	return phrase
+}
+
+// isTranslated reports whether locale replaces the built-in English messages
+func isTranslated(locale core.Locale) bool {
+	_, ok := translations[locale]
+	return ok
+}
+
+// localeDirectionText returns the phrase for a comparison direction, e.g.
+// "greater than" for ">"
+func localeDirectionText(locale core.Locale, direction core.Direction) string {
+	switch direction {
+	case core.DirectionGreaterThanOrEqual:
+		return tr(locale, "greater than or equal to")
+	case core.DirectionGreaterThan:
+		return tr(locale, "greater than")
+	case core.DirectionEqual:
+		return tr(locale, "equal to")
+	case core.DirectionLessThanOrEqual:
+		return tr(locale, "less than or equal to")
+	case core.DirectionLessThan:
+		return tr(locale, "less than")
+	}
+	return string(direction)
+}
+
+// formatLocaleNumber formats value with the given number of decimals (-1 for
+// as many as needed) and the locale's separators: 1,234.5 or, in Spanish, 1.234,5.
+func formatLocaleNumber(locale core.Locale, value float64, decimals int) string {
+	s := strconv.FormatFloat(math.Abs(value), 'f', decimals, 64)
+	intPart, fracPart, _ := strings.Cut(s, ".")
+
+	thousands, decimal := ",", "."
+	if locale == core.LocaleSpanish {
+		thousands, decimal = ".", ","
+	}
+
+	var b strings.Builder
+	if value < 0 {
+		b.WriteString("-")
+	}
+	for i, digit := range intPart {
+		if i > 0 && (len(intPart)-i)%3 == 0 {
+			b.WriteString(thousands)
+		}
+		b.WriteRune(digit)
+	}
+	if fracPart != "" {
+		b.WriteString(decimal)
+		b.WriteString(fracPart)
+	}
+	return b.String()
+}
+
+// formatLocaleLargeNumber formats a large value with the locale's magnitude
+// words followed by the full number, e.g. "46.31 亿 (4,630,749,868.34)"
+func formatLocaleLargeNumber(locale core.Locale, value float64) string {
+	type magnitude struct {
+		size float64
+		word string
+	}
+	magnitudes := []magnitude{{1e12, "trillion"}, {1e9, "billion"}, {1e6, "million"}, {1e3, "thousand"}}
+	switch locale {
+	case core.LocaleChinese:
+		// Chinese counts in ten thousands (万) and hundred millions (亿)
+		magnitudes = []magnitude{{1e12, "万亿"}, {1e8, "亿"}, {1e4, "万"}}
+	case core.LocaleSpanish:
+		magnitudes = []magnitude{{1e12, "billones"}, {1e9, "mil millones"}, {1e6, "millones"}, {1e3, "mil"}}
+	}
+
+	full := formatLocaleNumber(locale, value, 2)
+	for _, m := range magnitudes {
+		if math.Abs(value) >= m.size {
+			return fmt.Sprintf("%s %s (%s)", formatLocaleNumber(locale, value/m.size, 2), m.word, full)
+		}
+	}
+	return full
+}
+
+// spanishMonths are the abbreviated month names of Spanish dates
+var spanishMonths = [...]string{"ene", "feb", "mar", "abr", "may", "jun", "jul", "ago", "sept", "oct", "nov", "dic"}
+
+// formatLocaleTime formats t in UTC the way the locale writes dates
+func formatLocaleTime(locale core.Locale, t time.Time) string {
+	t = t.UTC()
+	switch locale {
+	case core.LocaleChinese:
+		return t.Format("2006年1月2日 15:04:05 UTC")
+	case core.LocaleSpanish:
+		return fmt.Sprintf("%d %s %d, %s UTC", t.Day(), spanishMonths[t.Month()-1], t.Year(), t.Format("15:04:05"))
+	}
+	return t.Format(time.RFC3339)
+}
+
+// localizedFact is one labelled value of a localized alert
+type localizedFact struct {
+	Label string
+	Value string
+}
+
+// localizedAlert is an alert message translated into a rule's locale. It is
+// rendered into the email and Telegram message in place of the English ones.
+type localizedAlert struct {
+	Locale   core.Locale
+	Subject  string
+	Heading  string // e.g. "Crypto Alert Triggered"
+	Title    string
+	Summary  string
+	Facts    []localizedFact
+	URL      string // Optional "View details" link
+	ChartURL string // Optional price chart image
+}
+
+// localizeTokenAlert translates a token alert into the rule's locale. It
+// reports false for English, which keeps the built-in message.
+func localizeTokenAlert(decision *core.AlertDecision) (localizedAlert, bool) {
+	r := decision.Rule
+	p := decision.CurrentPrice
+	if r == nil || p == nil || !isTranslated(r.Locale) {
+		return localizedAlert{}, false
+	}
+	l := r.Locale
+	dir := localeDirectionText(l, r.Direction)
+	threshold := "$" + formatLocaleNumber(l, r.Threshold, -1)
+
+	return localizedAlert{
+		Locale:  l,
+		Subject: fmt.Sprintf(tr(l, "🚨 Crypto Alert: %s %s %s"), p.Symbol, dir, threshold),
+		Heading: tr(l, "Crypto Alert Triggered"),
+		Title:   fmt.Sprintf("%s %s", telegramDirectionEmoji(string(r.Direction)), p.Symbol),
+		Facts: []localizedFact{
+			{tr(l, "Current Price"), "$" + formatLocaleNumber(l, p.Price, -1)},
+			{tr(l, "Threshold"), threshold},
+			{tr(l, "Condition"), fmt.Sprintf(tr(l, "Price is %s %s"), dir, threshold)},
+			{tr(l, "Time"), formatLocaleTime(l, p.Timestamp)},
+		},
+		ChartURL: priceChartImageURL(decision),
+	}, true
+}
+
+// localizeDeFiAlert translates a DeFi alert into the rule's locale. It reports
+// false for English, which keeps the built-in message.
+func localizeDeFiAlert(decision *core.DeFiAlertDecision) (localizedAlert, bool) {
+	r := decision.Rule
+	if r == nil || !isTranslated(r.Locale) {
+		return localizedAlert{}, false
+	}
+	l := r.Locale
+	dir := localeDirectionText(l, r.Direction)
+
+	var value, threshold string
+	switch r.Field {
+	case "TVL":
+		value = formatLocaleLargeNumber(l, decision.CurrentValue)
+		threshold = formatLocaleNumber(l, r.Threshold, -1)
+	case "APY", "UTILIZATION":
+		value = formatLocaleNumber(l, decision.CurrentValue, -1) + "%"
+		threshold = formatLocaleNumber(l, r.Threshold, -1) + "%"
+	default:
+		value = formatLocaleNumber(l, decision.CurrentValue, -1)
+		threshold = formatLocaleNumber(l, r.Threshold, -1)
+	}
+
+	facts := []localizedFact{{tr(l, "Chain"), decision.ChainName}}
+	if marketInfo := telegramBuildMarketInfo(r); marketInfo != "" {
+		facts = append(facts, localizedFact{tr(l, "Market"), marketInfo})
+	}
+	if ens := formatENSNames(r.ENSNames); ens != "" {
+		facts = append(facts, localizedFact{"ENS", ens})
+	}
+	facts = append(facts,
+		localizedFact{tr(l, "Field"), r.Field},
+		localizedFact{tr(l, "Current Value"), value},
+		localizedFact{tr(l, "Threshold"), threshold},
+		localizedFact{tr(l, "Condition"), fmt.Sprintf(tr(l, "%s is %s %s"), r.Field, dir, threshold)},
+		localizedFact{tr(l, "Time"), formatLocaleTime(l, time.Now())},
+	)
+
+	return localizedAlert{
+		Locale:  l,
+		Subject: fmt.Sprintf(tr(l, "🚨 DeFi Alert: %s %s %s on %s %s %s"), r.Protocol, r.Version, r.Field, decision.ChainName, dir, threshold),
+		Heading: tr(l, "DeFi Alert Triggered"),
+		Title:   fmt.Sprintf("%s %s %s", telegramDirectionEmoji(string(r.Direction)), r.Protocol, r.Version),
+		Facts:   facts,
+	}, true
+}
+
+// localizePredictMarketAlert translates a prediction market alert into the
+// rule's locale. It reports false for English, which keeps the built-in message.
+func localizePredictMarketAlert(decision *core.PredictMarketAlertDecision) (localizedAlert, bool) {
+	r := decision.Rule
+	if r == nil || !isTranslated(r.Locale) {
+		return localizedAlert{}, false
+	}
+	l := r.Locale
+	dir := localeDirectionText(l, r.Direction)
+	fieldLabel := predictFieldLabelIn(l, r)
+	threshold := formatLocaleNumber(l, r.Threshold, -1)
+
+	facts := []localizedFact{
+		{tr(l, "Question"), r.Question},
+		{tr(l, "Outcome"), r.Outcome},
+	}
+	if r.Field != "" && r.Field != core.PredictFieldMidpoint {
+		facts = append(facts, localizedFact{fieldLabel, formatLocaleNumber(l, decision.CurrentValue, 4)})
+	}
+	facts = append(facts,
+		localizedFact{tr(l, "Midpoint"), formatLocaleNumber(l, decision.CurrentMidpoint, 4)},
+		localizedFact{tr(l, "Buy Price"), formatLocaleNumber(l, decision.CurrentBuyPrice, 4)},
+		localizedFact{tr(l, "Sell Price"), formatLocaleNumber(l, decision.CurrentSellPrice, 4)},
+	)
+	if h := decision.History; h != nil {
+		facts = append(facts, localizedFact{tr(l, "History"), fmt.Sprintf(tr(l, "%.0fh: low %s / high %s %s (from %s)"),
+			h.Period.Hours(), formatLocaleNumber(l, h.Low, 4), formatLocaleNumber(l, h.High, 4),
+			predictTrendArrow(h.Open, decision.CurrentMidpoint), formatLocaleNumber(l, h.Open, 4))})
+	}
+	facts = append(facts,
+		localizedFact{tr(l, "Threshold"), threshold},
+		localizedFact{tr(l, "Condition"), fmt.Sprintf(tr(l, "%s is %s %s"), fieldLabel, dir, threshold)},
+		localizedFact{tr(l, "Time"), formatLocaleTime(l, time.Now())},
+	)
+
+	return localizedAlert{
+		Locale:  l,
+		Subject: fmt.Sprintf(tr(l, "🚨 Prediction Market Alert: %s (%s) %s %s"), r.Question, r.Outcome, dir, threshold),
+		Heading: tr(l, "Prediction Market Alert"),
+		Title:   fmt.Sprintf("%s %s", telegramDirectionEmoji(string(r.Direction)), r.PredictMarket),
+		Facts:   facts,
+	}, true
+}
+
+// localizeWatchAlert translates a watch alert into the rule's locale. The
+// observation's title and details come from the source and stay as they are.
+// It reports false for English, which keeps the built-in message.
+func localizeWatchAlert(decision *core.WatchAlertDecision) (localizedAlert, bool) {
+	r := decision.Rule
+	o := decision.Observation
+	if r == nil || o == nil || !isTranslated(r.Locale) {
+		return localizedAlert{}, false
+	}
+	l := r.Locale
+
+	name := r.Label
+	if name == "" {
+		name = watchSourceName(r.Source)
+	}
+
+	facts := []localizedFact{{tr(l, "Chain"), decision.ChainName}}
+	for _, d := range o.Details {
+		facts = append(facts, localizedFact{d.Label, d.Value})
+	}
+	facts = append(facts, localizedFact{tr(l, "Time"), formatLocaleTime(l, time.Now())})
+
+	return localizedAlert{
+		Locale:  l,
+		Subject: fmt.Sprintf(tr(l, "🔔 %s Alert: %s"), watchSourceName(r.Source), name),
+		Heading: fmt.Sprintf(tr(l, "%s Alert"), watchSourceName(r.Source)),
+		Title:   fmt.Sprintf(tr(l, "%s on %s"), name, decision.ChainName),
+		Summary: o.Title,
+		Facts:   facts,
+		URL:     o.URL,
+	}, true
+}
+
+// localizedEmailTemplate lays out a localized alert email
+var localizedEmailTemplate = template.Must(template.New("localized").Parse(`<!DOCTYPE html>
+<html lang="{{.Locale}}">
+<head>
+	<meta charset="UTF-8">
+	<meta name="viewport" content="width=device-width, initial-scale=1.0">
+</head>
+<body style="font-family: -apple-system, BlinkMacSystemFont, 'Segoe UI', Roboto, 'Helvetica Neue', Arial, sans-serif; line-height: 1.6; color: #333; max-width: 600px; margin: 0 auto; padding: 20px; background-color: #f5f5f5;">
+	<div style="background-color: #ffffff; border-radius: 8px; padding: 30px; box-shadow: 0 2px 4px rgba(0,0,0,0.1);">
+		<div style="text-align: center; margin-bottom: 30px;">
+			<h1 style="color: #dc2626; margin: 0; font-size: 28px;">{{.Heading}}</h1>
+		</div>
+		<div style="background-color: #f9fafb; border-left: 4px solid #dc2626; padding: 20px; margin: 20px 0; border-radius: 4px;">
+			<h2 style="margin: 0 0 15px 0; color: #111827; font-size: 24px;">{{.Title}}</h2>
+			{{if .Summary}}<p style="margin: 0 0 15px 0;">{{.Summary}}</p>{{end}}
+			{{if .ChartURL}}<img src="{{.ChartURL}}" alt="{{.ChartTitle}}" width="560" style="width: 100%; max-width: 560px; height: auto; display: block; margin: 0 0 15px 0;">{{end}}
+			<table style="width: 100%; border-collapse: collapse;">
+				{{range .Facts}}<tr>
+					<td style="padding: 10px 0; color: #6b7280; font-weight: 500;">{{.Label}}:</td>
+					<td style="padding: 10px 0; text-align: right; font-weight: 600;">{{.Value}}</td>
+				</tr>
+				{{end}}
+			</table>
+			{{if .URL}}<p style="margin: 15px 0 0 0;"><a href="{{.URL}}">{{.ViewDetails}}</a></p>{{end}}
+		</div>
+		<div style="text-align: center; color: #6b7280; font-size: 12px; margin-top: 20px;">
+			<p style="margin: 0;">{{.Footer}}</p>
+		</div>
+	</div>
+</body>
+</html>
+`))
+
+// email renders the alert as an email subject, plain-text body and HTML body
+func (a localizedAlert) email() (subject, textBody, htmlBody string) {
+	footer := tr(a.Locale, "This is an automated alert from your crypto monitoring system.")
+
+	var text strings.Builder
+	fmt.Fprintf(&text, "%s\n\n%s\n", a.Heading, a.Title)
+	if a.Summary != "" {
+		fmt.Fprintf(&text, "%s\n", a.Summary)
+	}
+	text.WriteString("\n")
+	for _, f := range a.Facts {
+		fmt.Fprintf(&text, "%s: %s\n", f.Label, f.Value)
+	}
+	if a.URL != "" {
+		fmt.Fprintf(&text, "%s: %s\n", tr(a.Locale, "View details"), a.URL)
+	}
+	fmt.Fprintf(&text, "\n%s", footer)
+
+	var buf bytes.Buffer
+	err := localizedEmailTemplate.Execute(&buf, struct {
+		localizedAlert
+		ViewDetails string
+		ChartTitle  string
+		Footer      string
+	}{a, tr(a.Locale, "View details"), tr(a.Locale, "Last 24 Hours"), footer})
+	if err != nil {
+		slog.Warn("⚠️  Localized email failed", logger.FieldError, err)
+		return a.Subject, text.String(), "<html><body><pre>" + html.EscapeString(text.String()) + "</pre></body></html>"
+	}
+	return a.Subject, text.String(), buf.String()
+}
+
+// telegram renders the alert as a Telegram HTML message
+func (a localizedAlert) telegram() string {
+	var msg strings.Builder
+	fmt.Fprintf(&msg, "🚨 <b>%s</b>\n\n<b>%s</b>\n", html.EscapeString(a.Heading), html.EscapeString(a.Title))
+	if a.Summary != "" {
+		fmt.Fprintf(&msg, "%s\n", html.EscapeString(a.Summary))
+	}
+	msg.WriteString("\n")
+	for _, f := range a.Facts {
+		fmt.Fprintf(&msg, "<b>%s:</b> %s\n", html.EscapeString(f.Label), html.EscapeString(f.Value))
+	}
+	if a.URL != "" {
+		fmt.Fprintf(&msg, "<a href=\"%s\">%s</a>\n", html.EscapeString(a.URL), html.EscapeString(tr(a.Locale, "View details")))
+	}
+	return strings.TrimSuffix(msg.String(), "\n")
+}
@@ -0,0 +1,110 @@
+package message
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	kafka "github.com/segmentio/kafka-go"
+)
+
+// KafkaReaderConfig tunes how the Kafka transport consumes a topic, trading
+// throughput for latency; zero fields keep the defaults below
+type KafkaReaderConfig struct {
+	MinBytes       int           // Smallest fetch the broker waits to fill, default 1
+	MaxBytes       int           // Largest fetch, default 1MB
+	MaxWait        time.Duration // How long the broker waits to fill MinBytes, default 10s
+	SessionTimeout time.Duration // How long the group waits for a silent consumer before rebalancing, default 30s
+	StartOffset    string        // first (default) or last: where a group without a position starts
+	CommitInterval time.Duration // Commit handled events every interval instead of one by one, default 0
+}
+
+// KafkaReaderConfigs returns the reader settings of topics from the
+// environment: KAFKA_READER_<SETTING> for every topic, overridden per topic
+// by KAFKA_READER_<TOPIC>_<SETTING>, where <TOPIC> is the topic in upper case
+// with dots as underscores (alerts.token: KAFKA_READER_ALERTS_TOKEN_MAX_WAIT).
+// The settings are MIN_BYTES, MAX_BYTES, MAX_WAIT, SESSION_TIMEOUT,
+// START_OFFSET and COMMIT_INTERVAL.
+func KafkaReaderConfigs(getenv func(string) string, topics []string) (map[string]KafkaReaderConfig, error) {
+	out := map[string]KafkaReaderConfig{}
+	for _, topic := range topics {
+		prefix := "KAFKA_READER_" + strings.ToUpper(strings.NewReplacer(".", "_", "-", "_").Replace(topic)) + "_"
+		// A topic's setting, else the one for every topic
+		get := func(setting string) string {
+			if v := getenv(prefix + setting); v != "" {
+				return v
+			}
+			return getenv("KAFKA_READER_" + setting)
+		}
+		cfg := KafkaReaderConfig{
+			MinBytes:       envIntOr(get, "MIN_BYTES", 0),
+			MaxBytes:       envIntOr(get, "MAX_BYTES", 0),
+			MaxWait:        envDurationOr(get, "MAX_WAIT", 0),
+			SessionTimeout: envDurationOr(get, "SESSION_TIMEOUT", 0),
+			StartOffset:    get("START_OFFSET"),
+			CommitInterval: envDurationOr(get, "COMMIT_INTERVAL", 0),
+		}
+		if _, err := kafkaStartOffset(cfg.StartOffset); err != nil {
+			return nil, fmt.Errorf("%s: %w", topic, err)
+		}
+		if cfg.MinBytes > 0 && cfg.MaxBytes > 0 && cfg.MinBytes > cfg.MaxBytes {
+			return nil, fmt.Errorf("%s: min bytes %d is above max bytes %d", topic, cfg.MinBytes, cfg.MaxBytes)
+		}
+		out[topic] = cfg
+	}
+	return out, nil
+}
+
+// SetReaderConfigs tunes the readers of the topics in cfgs; other topics keep
+// the defaults. Call it before consuming.
+func (t *KafkaTransport) SetReaderConfigs(cfgs map[string]KafkaReaderConfig) {
+	t.readers = cfgs
+}
+
+// readerConfig returns the settings of topic's readers with the defaults
+// filled in
+func (t *KafkaTransport) readerConfig(topic, groupID string) kafka.ReaderConfig {
+	cfg := t.readers[topic]
+	rc := kafka.ReaderConfig{
+		Brokers:        t.brokers,
+		GroupID:        groupID,
+		Topic:          topic,
+		StartOffset:    t.readerStartOffset(topic),
+		MinBytes:       cfg.MinBytes,
+		MaxBytes:       cfg.MaxBytes,
+		MaxWait:        cfg.MaxWait,
+		SessionTimeout: cfg.SessionTimeout,
+		CommitInterval: cfg.CommitInterval,
+	}
+	if rc.MinBytes <= 0 {
+		rc.MinBytes = 1
+	}
+	if rc.MaxBytes <= 0 {
+		rc.MaxBytes = 1e6
+	}
+	if rc.MaxWait <= 0 {
+		rc.MaxWait = 10 * time.Second
+	}
+	if rc.SessionTimeout <= 0 {
+		rc.SessionTimeout = 30 * time.Second
+	}
+	return rc
+}
+
+// readerStartOffset returns where topic's readers start without a committed
+// offset
+func (t *KafkaTransport) readerStartOffset(topic string) int64 {
+	offset, _ := kafkaStartOffset(t.readers[topic].StartOffset)
+	return offset
+}
+
+// kafkaStartOffset maps the START_OFFSET setting to the reader's start offset
+func kafkaStartOffset(s string) (int64, error) {
+	switch strings.ToLower(s) {
+	case "", "first":
+		return kafka.FirstOffset, nil
+	case "last":
+		return kafka.LastOffset, nil
+	}
+	return 0, fmt.Errorf("unknown start offset %q, must be first or last", s)
+}
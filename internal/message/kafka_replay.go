@@ -0,0 +1,107 @@
+package message
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	kafka "github.com/segmentio/kafka-go"
+)
+
+// Replay reads the events published on topic since from, up to the last one
+// published when it started, and hands each to handle, partition by
+// partition and in order within a partition. It reads without a consumer
+// group, so no group's position moves. Events older than the topic's
+// retention are gone.
+func (t *KafkaTransport) Replay(ctx context.Context, topic string, from time.Time, handle func(ReplayedEvent) error) error {
+	client := &kafka.Client{
+		Addr:    kafka.TCP(t.brokers[0]),
+		Timeout: 10 * time.Second,
+	}
+	partitions, err := topicPartitions(ctx, client, topic)
+	if err != nil {
+		return fmt.Errorf("partitions of %s: %w", topic, err)
+	}
+	if len(partitions) == 0 {
+		return fmt.Errorf("topic %s doesn't exist", topic)
+	}
+	for _, p := range partitions {
+		start, end, err := replayOffsets(ctx, client, topic, p, from)
+		if err != nil {
+			return fmt.Errorf("offsets of %s partition %d: %w", topic, p, err)
+		}
+		if start < 0 || start >= end {
+			continue // Nothing published on the partition since from
+		}
+		if err := t.replayPartition(ctx, topic, p, start, end, handle); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// replayOffsets returns the offset of the first event published on a
+// partition at or after from, or -1 when there is none, and the offset the
+// next event will get
+func replayOffsets(ctx context.Context, client *kafka.Client, topic string, partition int, from time.Time) (int64, int64, error) {
+	// Kafka doesn't take the same partition twice in one request
+	end, err := client.ListOffsets(ctx, &kafka.ListOffsetsRequest{
+		Topics: map[string][]kafka.OffsetRequest{topic: {kafka.LastOffsetOf(partition)}},
+	})
+	if err != nil {
+		return 0, 0, err
+	}
+	start, err := client.ListOffsets(ctx, &kafka.ListOffsetsRequest{
+		Topics: map[string][]kafka.OffsetRequest{topic: {kafka.TimeOffsetOf(partition, from)}},
+	})
+	if err != nil {
+		return 0, 0, err
+	}
+
+	last := int64(-1)
+	for _, p := range end.Topics[topic] {
+		if p.Error != nil {
+			return 0, 0, p.Error
+		}
+		last = p.LastOffset
+	}
+	first := int64(-1)
+	for _, p := range start.Topics[topic] {
+		if p.Error != nil {
+			return 0, 0, p.Error
+		}
+		for offset := range p.Offsets {
+			first = max(first, offset)
+		}
+	}
+	return first, last, nil
+}
+
+// replayPartition reads a partition from start up to, not including, end
+func (t *KafkaTransport) replayPartition(ctx context.Context, topic string, partition int, start, end int64, handle func(ReplayedEvent) error) error {
+	r := kafka.NewReader(kafka.ReaderConfig{
+		Brokers:   t.brokers,
+		Topic:     topic,
+		Partition: partition,
+		MinBytes:  1,
+		MaxBytes:  1e6,
+		MaxWait:   time.Second,
+	})
+	defer r.Close()
+	if err := r.SetOffset(start); err != nil {
+		return err
+	}
+	for {
+		msg, err := r.ReadMessage(ctx)
+		if err != nil {
+			return fmt.Errorf("read %s partition %d: %w", topic, partition, err)
+		}
+		id := fmt.Sprintf("%d:%d", partition, msg.Offset)
+		if err := handle(ReplayedEvent{ID: id, Time: msg.Time, Value: msg.Value}); err != nil {
+			return err
+		}
+		if msg.Offset+1 >= end {
+			return nil
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package message
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"strconv"
+	"time"
+
+	"crypto-alert/internal/logger"
+
+	kafka "github.com/segmentio/kafka-go"
+)
+
+// Topics lists every topic the engine and the notification service use
+var Topics = []string{
+	TopicTokenAlert, TopicDeFiAlert, TopicPredictAlert, TopicWatchAlert,
+	TopicRetry, TopicSummary, TopicDeadLetter,
+}
+
+// KafkaTopicConfig is how the Kafka transport creates missing topics, instead
+// of leaving them to the broker's auto-create defaults
+type KafkaTopicConfig struct {
+	Partitions        int           // Default 3
+	ReplicationFactor int           // Default 1
+	Retention         time.Duration // Default 7 days
+}
+
+// CreateTopics creates the topics that don't exist yet with cfg's partitions,
+// replication factor and retention. Existing topics are left as they are.
+// Failures are logged: the broker may still auto-create the topics.
+func (t *KafkaTransport) CreateTopics(ctx context.Context, cfg KafkaTopicConfig) {
+	if cfg.Partitions <= 0 {
+		cfg.Partitions = 3
+	}
+	if cfg.ReplicationFactor <= 0 {
+		cfg.ReplicationFactor = 1
+	}
+	if cfg.Retention <= 0 {
+		cfg.Retention = 7 * 24 * time.Hour
+	}
+	client := &kafka.Client{
+		Addr:    kafka.TCP(t.brokers[0]),
+		Timeout: 15 * time.Second,
+	}
+	topics := make([]kafka.TopicConfig, 0, len(Topics))
+	for _, topic := range Topics {
+		topics = append(topics, kafka.TopicConfig{
+			Topic:             topic,
+			NumPartitions:     cfg.Partitions,
+			ReplicationFactor: cfg.ReplicationFactor,
+			ConfigEntries: []kafka.ConfigEntry{
+				{ConfigName: "retention.ms", ConfigValue: strconv.FormatInt(cfg.Retention.Milliseconds(), 10)},
+			},
+		})
+	}
+	resp, err := client.CreateTopics(ctx, &kafka.CreateTopicsRequest{Topics: topics})
+	if err != nil {
+		slog.Warn("⚠️  Kafka topic creation failed", logger.FieldError, err)
+		return
+	}
+	for _, topic := range Topics {
+		switch err := resp.Errors[topic]; {
+		case err == nil:
+			slog.Info("🆕 Created topic", logger.FieldTopic, topic, "partitions", cfg.Partitions, "replication_factor", cfg.ReplicationFactor, "retention", cfg.Retention)
+		case errors.Is(err, kafka.TopicAlreadyExists):
+		default:
+			slog.Warn("⚠️  Topic creation failed", logger.FieldTopic, topic, logger.FieldError, err)
+		}
+	}
+}
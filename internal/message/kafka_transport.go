@@ -0,0 +1,433 @@
+package message
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"time"
+
+	"crypto-alert/internal/logger"
+	"crypto-alert/internal/tracing"
+
+	kafka "github.com/segmentio/kafka-go"
+)
+
+// KafkaTransport carries events over Kafka topics. Events are keyed, so on
+// topics with several partitions the events of one key stay on one partition
+// and in order; consumer groups share the partitions among their instances.
+type KafkaTransport struct {
+	brokers []string
+	writer  *kafka.Writer
+	readers map[string]KafkaReaderConfig // Topic -> reader settings, see SetReaderConfigs
+}
+
+// KafkaWriterConfig tunes how the Kafka transport publishes; the zero value
+// publishes synchronously with the defaults below
+type KafkaWriterConfig struct {
+	BatchSize int           // Events per batch, default 100
+	Linger    time.Duration // How long a batch waits to fill up, default 10ms
+	Acks      string        // all (default), one or none: the replicas that must store an event
+	// Async returns from Publish without waiting for the broker; events that
+	// fail to be written are passed to OnError instead
+	Async   bool
+	OnError func(topic string, key []byte, err error)
+}
+
+// NewKafkaTransport creates a transport on the given Kafka brokers
+func NewKafkaTransport(brokers []string, cfg KafkaWriterConfig) (*KafkaTransport, error) {
+	acks, err := kafkaAcks(cfg.Acks)
+	if err != nil {
+		return nil, err
+	}
+	w := &kafka.Writer{
+		Addr:                   kafka.TCP(brokers...),
+		Balancer:               &kafka.Hash{},
+		AllowAutoTopicCreation: true,
+		BatchSize:              cfg.BatchSize,
+		BatchTimeout:           cfg.Linger,
+		RequiredAcks:           acks,
+		Async:                  cfg.Async,
+		WriteTimeout:           15 * time.Second,
+		ReadTimeout:            15 * time.Second,
+	}
+	if w.BatchSize <= 0 {
+		w.BatchSize = 100
+	}
+	if w.BatchTimeout <= 0 {
+		w.BatchTimeout = 10 * time.Millisecond
+	}
+	if cfg.Async {
+		onError := cfg.OnError
+		if onError == nil {
+			onError = func(topic string, key []byte, err error) {
+				slog.Error("❌ Event was not written to Kafka", logger.FieldTopic, topic, logger.FieldEventID, string(key), logger.FieldError, err)
+			}
+		}
+		w.Completion = func(messages []kafka.Message, err error) {
+			if err == nil {
+				return
+			}
+			for _, m := range messages {
+				kafkaWriteErrors.WithLabelValues(m.Topic).Inc()
+				onError(m.Topic, m.Key, err)
+			}
+		}
+	}
+	return &KafkaTransport{brokers: brokers, writer: w}, nil
+}
+
+// kafkaAcks parses KAFKA_ACKS
+func kafkaAcks(acks string) (kafka.RequiredAcks, error) {
+	switch acks {
+	case "", "all":
+		return kafka.RequireAll, nil
+	case "one":
+		return kafka.RequireOne, nil
+	case "none":
+		return kafka.RequireNone, nil
+	}
+	return 0, fmt.Errorf("unknown KAFKA_ACKS %q, must be all, one or none", acks)
+}
+
+func (t *KafkaTransport) Name() string {
+	return fmt.Sprintf("Kafka brokers %v", t.brokers)
+}
+
+func (t *KafkaTransport) Close() error {
+	return t.writer.Close()
+}
+
+// Publish writes an event to topic; events without a key are spread
+// round-robin over the partitions. In async mode it returns once the event is
+// queued for its batch.
+func (t *KafkaTransport) Publish(ctx context.Context, topic string, key, value []byte) error {
+	msg := kafka.Message{
+		Topic: topic,
+		Key:   key,
+		Value: value,
+	}
+	// The consumers continue the publisher's trace
+	tracing.Inject(ctx, (*kafkaHeaders)(&msg.Headers))
+	return t.writer.WriteMessages(ctx, msg)
+}
+
+// kafkaHeaders carries the trace context of an event in its message headers
+type kafkaHeaders []kafka.Header
+
+func (h *kafkaHeaders) Get(key string) string {
+	for _, header := range *h {
+		if header.Key == key {
+			return string(header.Value)
+		}
+	}
+	return ""
+}
+
+func (h *kafkaHeaders) Set(key, value string) {
+	*h = slices.DeleteFunc(*h, func(header kafka.Header) bool { return header.Key == key })
+	*h = append(*h, kafka.Header{Key: key, Value: []byte(value)})
+}
+
+func (h *kafkaHeaders) Keys() []string {
+	keys := make([]string, len(*h))
+	for i, header := range *h {
+		keys[i] = header.Key
+	}
+	return keys
+}
+
+// Prepare blocks until the Kafka group coordinator is truly ready, then
+// commits the earliest offset for every partition a consumer group hasn't
+// read yet.
+//
+// kafka.NewReader with a GroupID spawns a background goroutine that immediately
+// calls JoinGroup. Creating readers before the coordinator is ready floods the
+// logs with "Group Coordinator Not Available" errors from that goroutine.
+func (t *KafkaTransport) Prepare(ctx context.Context, consumers []Consumer) {
+	t.waitForGroupCoordinator(ctx)
+	t.initConsumerGroupOffsets(ctx, consumers)
+}
+
+// Consume reads the consumer's topic with a group reader, committing each
+// message once handle returns nil. With c.Workers above 1 several messages
+// are handled at once, see consumeConcurrently.
+func (t *KafkaTransport) Consume(ctx context.Context, c Consumer, handle func(ctx context.Context, value []byte) error) error {
+	if c.Workers > 1 {
+		return t.consumeConcurrently(ctx, c, handle)
+	}
+	r := t.newReader(c.Topic, c.Group)
+	defer r.Close()
+	for {
+		msg, err := r.FetchMessage(ctx)
+		if err != nil {
+			return err
+		}
+		// Keep the message uncommitted when handling fails, so the next
+		// reader reads it again
+		if err := handle(tracing.Extract(ctx, (*kafkaHeaders)(&msg.Headers)), msg.Value); err != nil {
+			return err
+		}
+		// Commit even while shutting down, so a handled message isn't read again
+		commitCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 5*time.Second)
+		_ = r.CommitMessages(commitCtx, msg)
+		cancel()
+	}
+}
+
+// Check asks a broker for the consumers' topics and for the coordinator of
+// each consumer group, failing when either isn't available
+func (t *KafkaTransport) Check(ctx context.Context, consumers []Consumer) error {
+	client := &kafka.Client{
+		Addr:    kafka.TCP(t.brokers[0]),
+		Timeout: 5 * time.Second,
+	}
+	var topics []string
+	groups := map[string]bool{}
+	for _, c := range consumers {
+		topics = append(topics, c.Topic)
+		groups[c.Group] = true
+	}
+	meta, err := client.Metadata(ctx, &kafka.MetadataRequest{Topics: topics})
+	if err != nil {
+		return err
+	}
+	for _, topic := range meta.Topics {
+		if topic.Error != nil {
+			return fmt.Errorf("topic %s: %w", topic.Name, topic.Error)
+		}
+	}
+	for group := range groups {
+		res, err := client.FindCoordinator(ctx, &kafka.FindCoordinatorRequest{
+			Key:     group,
+			KeyType: kafka.CoordinatorKeyTypeConsumer,
+		})
+		if err != nil {
+			return err
+		}
+		if res.Error != nil {
+			return fmt.Errorf("coordinator of group %s: %w", group, res.Error)
+		}
+	}
+	return nil
+}
+
+// Lag sums, over the partitions of the consumer's topic, how far the group's
+// committed offset is behind the partition's last offset
+func (t *KafkaTransport) Lag(ctx context.Context, c Consumer) (int64, error) {
+	client := &kafka.Client{
+		Addr:    kafka.TCP(t.brokers[0]),
+		Timeout: 10 * time.Second,
+	}
+	partitions, err := topicPartitions(ctx, client, c.Topic)
+	if err != nil || len(partitions) == 0 {
+		return 0, err
+	}
+	committed, err := client.OffsetFetch(ctx, &kafka.OffsetFetchRequest{
+		GroupID: c.Group,
+		Topics:  map[string][]int{c.Topic: partitions},
+	})
+	if err != nil {
+		return 0, err
+	}
+	requests := make([]kafka.OffsetRequest, 0, len(partitions))
+	for _, p := range partitions {
+		requests = append(requests, kafka.LastOffsetOf(p))
+	}
+	last, err := client.ListOffsets(ctx, &kafka.ListOffsetsRequest{
+		Topics: map[string][]kafka.OffsetRequest{c.Topic: requests},
+	})
+	if err != nil {
+		return 0, err
+	}
+	ends := map[int]int64{}
+	for _, p := range last.Topics[c.Topic] {
+		if p.Error != nil {
+			return 0, p.Error
+		}
+		ends[p.Partition] = p.LastOffset
+	}
+	var lag int64
+	for _, p := range committed.Topics[c.Topic] {
+		if p.Error != nil {
+			return 0, p.Error
+		}
+		// Without a committed offset the group reads from the start
+		lag += ends[p.Partition] - max(p.CommittedOffset, 0)
+	}
+	return lag, nil
+}
+
+// initConsumerGroupOffsets ensures every consumer group starts from the earliest
+// available message on each partition of its topic that has no committed offset.
+// On normal restarts the group already has a committed offset for every
+// partition, so this function is a no-op and duplicate emails are never sent.
+// Partitions added to a topic later get their earliest offset on the next start.
+// Topics whose reader StartOffset is "last" start from the latest offset instead,
+// skipping the backlog.
+func (t *KafkaTransport) initConsumerGroupOffsets(ctx context.Context, consumers []Consumer) {
+	client := &kafka.Client{
+		Addr:    kafka.TCP(t.brokers[0]),
+		Timeout: 10 * time.Second,
+	}
+	for _, spec := range consumers {
+		partitions, err := topicPartitions(ctx, client, spec.Topic)
+		if err != nil {
+			slog.Warn("⚠️  Partition discovery failed", "group", spec.Group, logger.FieldError, err)
+			continue
+		}
+		if len(partitions) == 0 {
+			continue // The topic doesn't exist yet; the readers start from their StartOffset
+		}
+		latest := t.readerStartOffset(spec.Topic) == kafka.LastOffset
+
+		// Check which partitions the group already has a committed offset for.
+		fetchResp, err := client.OffsetFetch(ctx, &kafka.OffsetFetchRequest{
+			GroupID: spec.Group,
+			Topics:  map[string][]int{spec.Topic: partitions},
+		})
+		if err != nil {
+			slog.Warn("⚠️  Offset check failed", "group", spec.Group, logger.FieldError, err)
+			continue
+		}
+		var missing []kafka.OffsetRequest
+		for _, p := range fetchResp.Topics[spec.Topic] {
+			if p.Error != nil {
+				continue
+			}
+			if p.CommittedOffset >= 0 {
+				// Already has a valid committed offset — leave it alone.
+				slog.Info("📌 Resuming from the committed offset", "group", spec.Group, logger.FieldTopic, spec.Topic, "partition", p.Partition, "offset", p.CommittedOffset)
+				continue
+			}
+			if latest {
+				missing = append(missing, kafka.LastOffsetOf(p.Partition))
+			} else {
+				missing = append(missing, kafka.FirstOffsetOf(p.Partition))
+			}
+		}
+		if len(missing) == 0 {
+			continue
+		}
+
+		// No committed offset: read the earliest (or latest) offset of those partitions.
+		listResp, err := client.ListOffsets(ctx, &kafka.ListOffsetsRequest{
+			Topics: map[string][]kafka.OffsetRequest{spec.Topic: missing},
+		})
+		if err != nil {
+			slog.Warn("⚠️  Reading offsets failed", "group", spec.Group, logger.FieldError, err)
+			continue
+		}
+		var commits []kafka.OffsetCommit
+		for _, p := range listResp.Topics[spec.Topic] {
+			if p.Error != nil {
+				slog.Warn("⚠️  Reading offsets failed", "group", spec.Group, "partition", p.Partition, logger.FieldError, p.Error)
+				continue
+			}
+			offset := p.FirstOffset
+			if latest {
+				offset = p.LastOffset
+			}
+			commits = append(commits, kafka.OffsetCommit{Partition: p.Partition, Offset: offset})
+		}
+		if len(commits) == 0 {
+			continue
+		}
+
+		// Commit the offsets so kafka-go starts consuming from there.
+		if _, err = client.OffsetCommit(ctx, &kafka.OffsetCommitRequest{
+			GroupID:      spec.Group,
+			GenerationID: -1, // -1 = standalone commit outside an active group session
+			Topics:       map[string][]kafka.OffsetCommit{spec.Topic: commits},
+		}); err != nil {
+			slog.Warn("⚠️  Offset init failed", "group", spec.Group, logger.FieldError, err)
+			continue
+		}
+		where := "earliest"
+		if latest {
+			where = "latest"
+		}
+		for _, c := range commits {
+			slog.Info("📌 Partition had no prior offset, initialized it", "group", spec.Group, logger.FieldTopic, spec.Topic, "partition", c.Partition, "offset", c.Offset, "from", where)
+		}
+	}
+}
+
+// topicPartitions returns the IDs of a topic's partitions, or none when the
+// topic doesn't exist yet
+func topicPartitions(ctx context.Context, client *kafka.Client, topic string) ([]int, error) {
+	resp, err := client.Metadata(ctx, &kafka.MetadataRequest{Topics: []string{topic}})
+	if err != nil {
+		return nil, err
+	}
+	for _, t := range resp.Topics {
+		if t.Name != topic {
+			continue
+		}
+		if errors.Is(t.Error, kafka.UnknownTopicOrPartition) {
+			return nil, nil
+		}
+		if t.Error != nil {
+			return nil, t.Error
+		}
+		partitions := make([]int, 0, len(t.Partitions))
+		for _, p := range t.Partitions {
+			partitions = append(partitions, p.ID)
+		}
+		slices.Sort(partitions)
+		return partitions, nil
+	}
+	return nil, nil
+}
+
+// waitForGroupCoordinator polls the Kafka group coordinator API with exponential backoff
+// until it responds successfully. Using kafka.Client.FindCoordinator directly avoids
+// creating a full Reader (which would itself trigger the noisy background join goroutine).
+func (t *KafkaTransport) waitForGroupCoordinator(ctx context.Context) {
+	if ctx.Err() != nil {
+		return
+	}
+	client := &kafka.Client{
+		Addr:    kafka.TCP(t.brokers[0]),
+		Timeout: 5 * time.Second,
+	}
+	backoff := 1 * time.Second
+	for {
+		if ctx.Err() != nil {
+			return
+		}
+		resp, err := client.FindCoordinator(ctx, &kafka.FindCoordinatorRequest{
+			Addr:    kafka.TCP(t.brokers[0]),
+			Key:     "__notification_healthcheck__",
+			KeyType: kafka.CoordinatorKeyTypeConsumer,
+		})
+		if err == nil && resp.Error == nil {
+			slog.Info("✅ Kafka group coordinator is ready")
+			return
+		}
+		reason := "unknown"
+		if err != nil {
+			reason = err.Error()
+		} else if resp.Error != nil {
+			reason = resp.Error.Error()
+		}
+		slog.Info("⏳ Waiting for Kafka group coordinator", "reason", reason, "retry_in", backoff)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+		if backoff < 30*time.Second {
+			backoff *= 2
+		}
+	}
+}
+
+func (t *KafkaTransport) newReader(topic, groupID string) *kafka.Reader {
+	cfg := t.readerConfig(topic, groupID)
+	cfg.ErrorLogger = kafka.LoggerFunc(func(msg string, args ...interface{}) {
+		slog.Error("❌ kafka-go error", logger.FieldTopic, topic, "detail", fmt.Sprintf(msg, args...))
+	})
+	return kafka.NewReader(cfg)
+}
@@ -0,0 +1,190 @@
+package message
+
+import (
+	"context"
+	"errors"
+	"hash/fnv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"crypto-alert/internal/tracing"
+
+	kafka "github.com/segmentio/kafka-go"
+)
+
+// errKafkaSkipped marks the messages a worker didn't handle because another
+// one failed
+var errKafkaSkipped = errors.New("skipped after an earlier failure")
+
+// kafkaResult is the outcome of handling one fetched message
+type kafkaResult struct {
+	msg kafka.Message
+	err error
+}
+
+// kafkaCommits tracks the messages being handled on each partition, in the
+// order they were fetched, so an offset is only committed once every message
+// before it on its partition has been handled
+type kafkaCommits struct {
+	pending map[int][]int64                 // Partition -> offsets in flight, in fetch order
+	done    map[int]map[int64]kafka.Message // Partition -> handled offsets not committed yet
+}
+
+func newKafkaCommits() *kafkaCommits {
+	return &kafkaCommits{pending: map[int][]int64{}, done: map[int]map[int64]kafka.Message{}}
+}
+
+func (k *kafkaCommits) fetched(msg kafka.Message) {
+	k.pending[msg.Partition] = append(k.pending[msg.Partition], msg.Offset)
+}
+
+// handled marks msg as handled and returns the message to commit, if the
+// messages before it on its partition have been handled too
+func (k *kafkaCommits) handled(msg kafka.Message) (kafka.Message, bool) {
+	done := k.done[msg.Partition]
+	if done == nil {
+		done = map[int64]kafka.Message{}
+		k.done[msg.Partition] = done
+	}
+	done[msg.Offset] = msg
+
+	var commit kafka.Message
+	var ok bool
+	pending := k.pending[msg.Partition]
+	for len(pending) > 0 {
+		m, isDone := done[pending[0]]
+		if !isDone {
+			break
+		}
+		delete(done, pending[0])
+		pending = pending[1:]
+		commit, ok = m, true
+	}
+	k.pending[msg.Partition] = pending
+	return commit, ok
+}
+
+// consumeConcurrently handles up to c.Workers messages at once. Messages with
+// the same key go to the same worker, so a rule's events are still handled in
+// order, while a slow provider call only holds back the events of its own
+// rule. Offsets are committed in order per partition: a message is committed
+// once it and all messages fetched before it on its partition are handled.
+//
+// When handling fails, no more messages are dispatched; the ones in flight
+// finish and the error is returned. The failed message and the ones after it
+// on its partition stay uncommitted, so the next Consume reads them again,
+// including any that had already been handled (delivery is at least once).
+func (t *KafkaTransport) consumeConcurrently(ctx context.Context, c Consumer, handle func(ctx context.Context, value []byte) error) error {
+	r := t.newReader(c.Topic, c.Group)
+	defer r.Close()
+
+	// Fetching stops on the first failure, while the messages in flight keep
+	// the consumer's context so they can finish
+	fetchCtx, stopFetching := context.WithCancel(ctx)
+	defer stopFetching()
+	fetched := make(chan kafka.Message)
+	fetchErr := make(chan error, 1)
+	go func() {
+		for {
+			msg, err := r.FetchMessage(fetchCtx)
+			if err != nil {
+				fetchErr <- err
+				return
+			}
+			select {
+			case fetched <- msg:
+			case <-fetchCtx.Done():
+				fetchErr <- fetchCtx.Err()
+				return
+			}
+		}
+	}()
+
+	results := make(chan kafkaResult, c.Workers)
+	queues := make([]chan kafka.Message, c.Workers)
+	var failed atomic.Bool // Queued messages are skipped after a failure, keeping their key's order
+	var wg sync.WaitGroup
+	for i := range queues {
+		queues[i] = make(chan kafka.Message, 1)
+		wg.Add(1)
+		go func(queue <-chan kafka.Message) {
+			defer wg.Done()
+			for msg := range queue {
+				if failed.Load() {
+					results <- kafkaResult{msg: msg, err: errKafkaSkipped}
+					continue
+				}
+				err := handle(tracing.Extract(ctx, (*kafkaHeaders)(&msg.Headers)), msg.Value)
+				if err != nil {
+					failed.Store(true)
+				}
+				results <- kafkaResult{msg: msg, err: err}
+			}
+		}(queues[i])
+	}
+
+	commits := newKafkaCommits()
+	var failure error
+	result := func(res kafkaResult) {
+		if res.err != nil {
+			if failure == nil || errors.Is(failure, errKafkaSkipped) {
+				failure = res.err
+			}
+			return
+		}
+		if msg, ok := commits.handled(res.msg); ok {
+			// Commit even while shutting down, so handled messages aren't read again
+			commitCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 5*time.Second)
+			_ = r.CommitMessages(commitCtx, msg)
+			cancel()
+		}
+	}
+
+	var next kafka.Message // Fetched, waiting for its worker when out is set
+	var out chan<- kafka.Message
+	var roundRobin int
+	for failure == nil {
+		in := fetched
+		if out != nil {
+			in = nil
+		}
+		select {
+		case msg := <-in:
+			commits.fetched(msg)
+			next, out = msg, queues[kafkaWorker(msg.Key, len(queues), &roundRobin)]
+		case out <- next:
+			out = nil
+		case res := <-results:
+			result(res)
+		case err := <-fetchErr:
+			failure = err
+		}
+	}
+
+	// Let the messages in flight finish and commit what they allow
+	stopFetching()
+	for _, queue := range queues {
+		close(queue)
+	}
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+	for res := range results {
+		result(res)
+	}
+	return failure
+}
+
+// kafkaWorker picks the worker for a message key; messages without a key
+// have no order to keep and are spread round-robin
+func kafkaWorker(key []byte, workers int, roundRobin *int) int {
+	if len(key) == 0 {
+		*roundRobin = (*roundRobin + 1) % workers
+		return *roundRobin
+	}
+	h := fnv.New32a()
+	h.Write(key)
+	return int(h.Sum32() % uint32(workers))
+}
@@ -0,0 +1,151 @@
+package message
+
+import (
+	"context"
+	"sync"
+
+	"crypto-alert/internal/tracing"
+
+	"go.opentelemetry.io/otel/propagation"
+)
+
+// MemoryTransport carries events inside one process, for single-node
+// deployments where the engine runs the notifications itself and no broker
+// is needed. Each topic keeps its events until every consumer group reading
+// it has handled them; a group that starts reading later gets the events
+// published before it. Events are lost when the process stops.
+type MemoryTransport struct {
+	mu      sync.Mutex
+	topics  map[string]*memoryTopic
+	changed chan struct{} // Closed and replaced on every publish
+}
+
+// memoryTopic is a topic's events not yet handled by all its groups.
+// offsets holds each group's position counted from the first event ever
+// published; base is the position of events[0].
+type memoryTopic struct {
+	events  []memoryEvent
+	base    int
+	offsets map[string]int
+}
+
+// memoryEvent is an event and the trace context it was published with
+type memoryEvent struct {
+	value   []byte
+	headers propagation.MapCarrier
+}
+
+// NewMemoryTransport creates an empty in-process transport
+func NewMemoryTransport() *MemoryTransport {
+	return &MemoryTransport{topics: map[string]*memoryTopic{}, changed: make(chan struct{})}
+}
+
+func (t *MemoryTransport) Name() string {
+	return "in-process queue"
+}
+
+func (t *MemoryTransport) Close() error {
+	return nil
+}
+
+// Publish appends an event to topic and wakes the consumers waiting for one.
+// Events are handed out in publishing order, so key isn't needed.
+func (t *MemoryTransport) Publish(ctx context.Context, topic string, key, value []byte) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	tp := t.topic(topic)
+	event := memoryEvent{value: value, headers: propagation.MapCarrier{}}
+	tracing.Inject(ctx, event.headers)
+	tp.events = append(tp.events, event)
+	close(t.changed)
+	t.changed = make(chan struct{})
+	return nil
+}
+
+// Prepare registers the consumer groups, so a group starts from the earliest
+// event still kept for the topic
+func (t *MemoryTransport) Prepare(ctx context.Context, consumers []Consumer) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for _, c := range consumers {
+		t.group(c)
+	}
+}
+
+// Consume hands the consumer's events to handle one at a time, moving the
+// group past an event once handle returns nil
+func (t *MemoryTransport) Consume(ctx context.Context, c Consumer, handle func(ctx context.Context, value []byte) error) error {
+	for {
+		t.mu.Lock()
+		tp := t.group(c)
+		offset := tp.offsets[c.Group]
+		i := offset - tp.base
+		pending := i < len(tp.events)
+		var event memoryEvent
+		if pending {
+			event = tp.events[i]
+		}
+		changed := t.changed
+		t.mu.Unlock()
+
+		if !pending {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-changed:
+				continue
+			}
+		}
+		if err := handle(tracing.Extract(ctx, event.headers), event.value); err != nil {
+			return err
+		}
+
+		t.mu.Lock()
+		tp.offsets[c.Group] = offset + 1
+		tp.trim()
+		t.mu.Unlock()
+	}
+}
+
+// Lag returns how many events of the consumer's topic the group hasn't
+// handled yet
+func (t *MemoryTransport) Lag(ctx context.Context, c Consumer) (int64, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	tp := t.group(c)
+	return int64(tp.base + len(tp.events) - tp.offsets[c.Group]), nil
+}
+
+// topic returns the topic's events, creating an empty topic when it doesn't
+// exist yet. Callers hold t.mu.
+func (t *MemoryTransport) topic(name string) *memoryTopic {
+	tp, ok := t.topics[name]
+	if !ok {
+		tp = &memoryTopic{offsets: map[string]int{}}
+		t.topics[name] = tp
+	}
+	return tp
+}
+
+// group returns the consumer's topic, registering the group at the earliest
+// kept event when it is new. Callers hold t.mu.
+func (t *MemoryTransport) group(c Consumer) *memoryTopic {
+	tp := t.topic(c.Topic)
+	if _, ok := tp.offsets[c.Group]; !ok {
+		tp.offsets[c.Group] = tp.base
+	}
+	return tp
+}
+
+// trim drops the events every group has handled
+func (tp *memoryTopic) trim() {
+	lowest := tp.base + len(tp.events)
+	for _, offset := range tp.offsets {
+		lowest = min(lowest, offset)
+	}
+	if n := lowest - tp.base; n > 0 {
+		clear(tp.events[:n])
+		tp.events = tp.events[n:]
+		tp.base = lowest
+	}
+}
@@ -0,0 +1,65 @@
+package message
+
+import (
+	"html"
+	"log/slog"
+	"strings"
+
+	"crypto-alert/internal/core"
+	"crypto-alert/internal/logger"
+)
+
+// applyMessageTemplate replaces a formatted alert with the rule's message
+// template, if it has one. The subject is only replaced when the template
+// defines one. A template that fails to render keeps the built-in message.
+func applyMessageTemplate(text string, data core.MessageTemplateData, subject, textBody, htmlBody string) (string, string, string) {
+	if text == "" {
+		return subject, textBody, htmlBody
+	}
+	customSubject, body, err := core.RenderMessageTemplate(text, data)
+	if err != nil {
+		slog.Warn("⚠️  Message template failed, using the built-in message", logger.FieldKind, data.Type, logger.FieldError, err)
+		return subject, textBody, htmlBody
+	}
+	if customSubject != "" {
+		subject = customSubject
+	}
+	htmlBody = "<html><body><h2>" + html.EscapeString(subject) + "</h2><p>" +
+		strings.ReplaceAll(html.EscapeString(body), "\n", "<br>") + "</p></body></html>"
+	return subject, body, htmlBody
+}
+
+// telegramMessageTemplate renders the rule's message template as a Telegram
+// HTML message. It reports false when the rule has no template or it fails.
+func telegramMessageTemplate(text string, data core.MessageTemplateData) (string, bool) {
+	if text == "" {
+		return "", false
+	}
+	subject, body, err := core.RenderMessageTemplate(text, data)
+	if err != nil {
+		slog.Warn("⚠️  Message template failed, using the built-in message", logger.FieldKind, data.Type, logger.FieldError, err)
+		return "", false
+	}
+	if subject == "" {
+		return html.EscapeString(body), true
+	}
+	return "<b>" + html.EscapeString(subject) + "</b>\n\n" + html.EscapeString(body), true
+}
+
+// withMessageTemplate replaces the card's summary, and its title when the
+// template defines a subject, with the rule's message template. The facts stay.
+func (c teamsCard) withMessageTemplate(text string, data core.MessageTemplateData) teamsCard {
+	if text == "" {
+		return c
+	}
+	subject, body, err := core.RenderMessageTemplate(text, data)
+	if err != nil {
+		slog.Warn("⚠️  Message template failed, using the built-in message", logger.FieldKind, data.Type, logger.FieldError, err)
+		return c
+	}
+	if subject != "" {
+		c.Title = subject
+	}
+	c.Summary = body
+	return c
+}
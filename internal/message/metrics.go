@@ -0,0 +1,17 @@
+package message
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	eventsPublished = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "message_events_published_total",
+		Help: "Events published to the event transport, by topic and outcome (ok or error).",
+	}, []string{"topic", "outcome"})
+	kafkaWriteErrors = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "message_kafka_write_errors_total",
+		Help: "Events an asynchronous Kafka writer failed to write after they were published, by topic.",
+	}, []string{"topic"})
+)
@@ -0,0 +1,141 @@
+package message
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"crypto-alert/internal/core"
+)
+
+// MaxMuteDuration is the longest a mute can last
+const MaxMuteDuration = 30 * 24 * time.Hour
+
+// ParseMuteDuration parses how long a mute lasts: a Go duration such as 90m
+// or 2h, or a number of days such as 3d
+func ParseMuteDuration(s string) (time.Duration, error) {
+	var d time.Duration
+	var err error
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		var n int
+		if n, err = strconv.Atoi(days); err == nil {
+			d = time.Duration(n) * 24 * time.Hour
+		}
+	} else {
+		d, err = time.ParseDuration(s)
+	}
+	if err != nil || d < time.Minute || d > MaxMuteDuration {
+		return 0, fmt.Errorf("invalid mute duration %q, must be between 1m and 30d, e.g. 90m, 2h or 3d", s)
+	}
+	return d, nil
+}
+
+// alertSubject returns what an alert event is about, as mutes name it: the
+// token symbol, DeFi protocol, prediction market or watch source, and the
+// tags of its rule
+func alertSubject(payload []byte) (string, []string) {
+	var event struct {
+		Symbol        string   `json:"symbol"`
+		Protocol      string   `json:"protocol"`
+		PredictMarket string   `json:"predict_market"`
+		Source        string   `json:"source"`
+		Tags          []string `json:"tags"`
+	}
+	if err := json.Unmarshal(payload, &event); err != nil {
+		return "", nil
+	}
+	for _, s := range []string{event.Symbol, event.Protocol, event.PredictMarket, event.Source} {
+		if s != "" {
+			return s, event.Tags
+		}
+	}
+	return "", event.Tags
+}
+
+// Muter holds the active mutes and tallies the alerts each of them suppresses
+// for its summary. Tallies are kept in memory and lost on restart.
+type Muter struct {
+	mu      sync.Mutex
+	mutes   []core.Mute
+	tallies map[int64]map[string]int // By mute ID: alert label -> count
+}
+
+// NewMuter creates a muter without mutes
+func NewMuter() *Muter {
+	return &Muter{tallies: map[int64]map[string]int{}}
+}
+
+// Set replaces the active mutes
+func (m *Muter) Set(mutes []core.Mute) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.mutes = mutes
+}
+
+// Muted returns the mute that silences an alert of topic, counting it under
+// what (e.g. the symbol) for the mute's summary, or nil when the alert isn't
+// muted
+func (m *Muter) Muted(topic string, payload []byte, what string) *core.Mute {
+	kind := strings.TrimPrefix(topic, "alerts.")
+	subject, tags := alertSubject(payload)
+	now := time.Now()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i := range m.mutes {
+		mute := m.mutes[i]
+		if !now.Before(mute.Until) || !mute.Matches(kind, subject, tags) {
+			continue
+		}
+		if m.tallies[mute.ID] == nil {
+			m.tallies[mute.ID] = map[string]int{}
+		}
+		m.tallies[mute.ID][kind+" "+what]++
+		return &mute
+	}
+	return nil
+}
+
+// Summary returns the summary of what an ended mute suppressed and forgets
+// its tally
+func (m *Muter) Summary(mute core.Mute) (subject, text string) {
+	m.mu.Lock()
+	tally := m.tallies[mute.ID]
+	delete(m.tallies, mute.ID)
+	m.mu.Unlock()
+
+	subject = fmt.Sprintf("Mute of %s ended: %d alerts suppressed", mute.Scope(), mute.Suppressed)
+	var b strings.Builder
+	fmt.Fprintf(&b, "The mute of %s ended at %s.", mute.Scope(), mute.Until.UTC().Format("2006-01-02 15:04 UTC"))
+	if mute.Reason != "" {
+		fmt.Fprintf(&b, " Reason: %s.", mute.Reason)
+	}
+	if mute.Suppressed == 0 {
+		b.WriteString(" No alerts were suppressed.")
+		return subject, b.String()
+	}
+	fmt.Fprintf(&b, " %d alerts were suppressed", mute.Suppressed)
+	if len(tally) == 0 {
+		b.WriteString(".")
+		return subject, b.String()
+	}
+	b.WriteString(":")
+	labels := make([]string, 0, len(tally))
+	for label := range tally {
+		labels = append(labels, label)
+	}
+	sort.Slice(labels, func(i, j int) bool {
+		if tally[labels[i]] != tally[labels[j]] {
+			return tally[labels[i]] > tally[labels[j]]
+		}
+		return labels[i] < labels[j]
+	})
+	for _, label := range labels {
+		fmt.Fprintf(&b, "\n- %s: %d", label, tally[label])
+	}
+	return subject, b.String()
+}
@@ -0,0 +1,247 @@
+package message
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"time"
+
+	"crypto-alert/internal/logger"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+// NATS JetStream stream holding the events; topics are its subjects
+const (
+	natsStream       = "ALERTS"
+	natsStreamMaxAge = 7 * 24 * time.Hour // Like Kafka's default retention
+	natsAckWait      = 30 * time.Second   // Extended while an event is being handled
+)
+
+// NATSTransport carries events over a NATS JetStream stream, for deployments
+// where running Kafka is overkill. Each consumer group is a durable pull
+// consumer with one event in flight at a time, so a group handles its events
+// in order; instances of the group take turns rather than sharing the load.
+type NATSTransport struct {
+	url string
+	nc  *nats.Conn
+	js  jetstream.JetStream
+}
+
+// NewNATSTransport connects to the NATS server at url and creates the
+// ALERTS stream when it doesn't exist yet
+func NewNATSTransport(url string) (*NATSTransport, error) {
+	if url == "" {
+		url = nats.DefaultURL
+	}
+	nc, err := nats.Connect(url,
+		nats.Name("crypto-alert"),
+		nats.MaxReconnects(-1),
+		nats.DisconnectErrHandler(func(_ *nats.Conn, err error) {
+			if err != nil {
+				slog.Warn("⚠️  NATS disconnected", logger.FieldError, err)
+			}
+		}),
+		nats.ReconnectHandler(func(*nats.Conn) {
+			slog.Info("✅ NATS reconnected")
+		}),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("connect to NATS at %s: %w", url, err)
+	}
+	js, err := jetstream.New(nc)
+	if err != nil {
+		nc.Close()
+		return nil, fmt.Errorf("jetstream: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+	if _, err := js.CreateOrUpdateStream(ctx, jetstream.StreamConfig{
+		Name:     natsStream,
+		Subjects: []string{"alerts.>"},
+		Storage:  jetstream.FileStorage,
+		MaxAge:   natsStreamMaxAge,
+	}); err != nil {
+		nc.Close()
+		return nil, fmt.Errorf("create stream %s: %w", natsStream, err)
+	}
+	return &NATSTransport{url: url, nc: nc, js: js}, nil
+}
+
+func (t *NATSTransport) Name() string {
+	return "NATS JetStream at " + t.url
+}
+
+func (t *NATSTransport) Close() error {
+	t.nc.Close()
+	return nil
+}
+
+// Publish writes an event to the topic's subject and waits for the stream to
+// store it. Subjects have no partitions, so key isn't needed for ordering.
+func (t *NATSTransport) Publish(ctx context.Context, topic string, key, value []byte) error {
+	_, err := t.js.Publish(ctx, topic, value)
+	return err
+}
+
+// Prepare creates the durable consumers that don't exist yet. A new consumer
+// starts from the earliest event in the stream; existing ones keep their
+// position.
+func (t *NATSTransport) Prepare(ctx context.Context, consumers []Consumer) {
+	for _, c := range consumers {
+		if _, err := t.consumer(ctx, c); err != nil {
+			slog.Warn("⚠️  NATS consumer setup failed", "group", c.Group, logger.FieldError, err)
+		}
+	}
+}
+
+func (t *NATSTransport) consumer(ctx context.Context, c Consumer) (jetstream.Consumer, error) {
+	return t.js.CreateOrUpdateConsumer(ctx, natsStream, jetstream.ConsumerConfig{
+		Durable:       c.Group,
+		FilterSubject: c.Topic,
+		DeliverPolicy: jetstream.DeliverAllPolicy,
+		AckPolicy:     jetstream.AckExplicitPolicy,
+		AckWait:       natsAckWait,
+		MaxAckPending: 1,
+	})
+}
+
+// Check fails unless the connection is up and the stream exists
+func (t *NATSTransport) Check(ctx context.Context, consumers []Consumer) error {
+	if status := t.nc.Status(); status != nats.CONNECTED {
+		return fmt.Errorf("connection %s", status)
+	}
+	_, err := t.js.Stream(ctx, natsStream)
+	return err
+}
+
+// Lag returns how many events of the consumer's subject the durable consumer
+// hasn't been handed yet
+func (t *NATSTransport) Lag(ctx context.Context, c Consumer) (int64, error) {
+	cons, err := t.js.Consumer(ctx, natsStream, c.Group)
+	if err != nil {
+		return 0, err
+	}
+	info, err := cons.Info(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return int64(info.NumPending), nil
+}
+
+// Consume pulls the consumer's events one at a time, acknowledging each once
+// handle returns nil. A failed event is negatively acknowledged, so it is
+// delivered again.
+func (t *NATSTransport) Consume(ctx context.Context, c Consumer, handle func(ctx context.Context, value []byte) error) error {
+	cons, err := t.consumer(ctx, c)
+	if err != nil {
+		return err
+	}
+	for ctx.Err() == nil {
+		if err := t.consumeOne(ctx, cons, handle); err != nil {
+			return err
+		}
+	}
+	return ctx.Err()
+}
+
+// consumeOne waits up to 10 seconds for the next event and handles it
+func (t *NATSTransport) consumeOne(ctx context.Context, cons jetstream.Consumer, handle func(ctx context.Context, value []byte) error) error {
+	fetchCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	batch, err := cons.Fetch(1, jetstream.FetchContext(fetchCtx))
+	if err != nil {
+		return err
+	}
+	for msg := range batch.Messages() {
+		if err := t.handle(ctx, msg, handle); err != nil {
+			_ = msg.Nak()
+			return err
+		}
+		if err := msg.Ack(); err != nil {
+			return fmt.Errorf("ack: %w", err)
+		}
+	}
+	if err := batch.Error(); err != nil && fetchCtx.Err() == nil {
+		return err
+	}
+	return nil
+}
+
+// handle runs handle on msg, telling the server the event is still in
+// progress while it runs, e.g. while a retry waits for its backoff
+func (t *NATSTransport) handle(ctx context.Context, msg jetstream.Msg, handle func(ctx context.Context, value []byte) error) error {
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		ticker := time.NewTicker(natsAckWait / 3)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				_ = msg.InProgress()
+			}
+		}
+	}()
+	return handle(ctx, msg.Data())
+}
+
+// Replay reads the events published on topic's subject since from, up to the
+// last one in the stream when it started, with an ordered consumer that goes
+// away afterwards. The IDs of the events are their stream sequences. Events
+// older than the stream's retention are gone.
+func (t *NATSTransport) Replay(ctx context.Context, topic string, from time.Time, handle func(ReplayedEvent) error) error {
+	stream, err := t.js.Stream(ctx, natsStream)
+	if err != nil {
+		return err
+	}
+	info, err := stream.Info(ctx)
+	if err != nil {
+		return err
+	}
+	end := info.State.LastSeq
+	cons, err := t.js.OrderedConsumer(ctx, natsStream, jetstream.OrderedConsumerConfig{
+		FilterSubjects: []string{topic},
+		DeliverPolicy:  jetstream.DeliverByStartTimePolicy,
+		OptStartTime:   &from,
+	})
+	if err != nil {
+		return fmt.Errorf("replay consumer for %s: %w", topic, err)
+	}
+	for {
+		batch, err := cons.Fetch(100, jetstream.FetchMaxWait(2*time.Second))
+		if err != nil {
+			return fmt.Errorf("read %s: %w", topic, err)
+		}
+		read := 0
+		for msg := range batch.Messages() {
+			read++
+			meta, err := msg.Metadata()
+			if err != nil {
+				return err
+			}
+			if meta.Sequence.Stream > end {
+				return nil // Published after Replay started
+			}
+			id := strconv.FormatUint(meta.Sequence.Stream, 10)
+			if err := handle(ReplayedEvent{ID: id, Time: meta.Timestamp, Value: msg.Data()}); err != nil {
+				return err
+			}
+			if meta.Sequence.Stream == end || meta.NumPending == 0 {
+				return nil
+			}
+		}
+		if err := batch.Error(); err != nil && !errors.Is(err, nats.ErrTimeout) {
+			return fmt.Errorf("read %s: %w", topic, err)
+		}
+		if read == 0 {
+			return ctx.Err() // Nothing left to read
+		}
+	}
+}
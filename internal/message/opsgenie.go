@@ -0,0 +1,183 @@
+package message
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"crypto-alert/internal/core"
+)
+
+func init() {
+	RegisterChannel(ChannelOpsgenie, func(getenv func(string) string) (NotificationChannel, error) {
+		return NewOpsgenieSender(getenv("OPSGENIE_API_URL")), nil
+	})
+}
+
+// DefaultOpsgenieAPIURL is the Opsgenie API for US accounts; EU accounts use https://api.eu.opsgenie.com
+const DefaultOpsgenieAPIURL = "https://api.opsgenie.com"
+
+// OpsgenieSender creates and closes Opsgenie alerts through the Alert API.
+// The API key belongs to an Opsgenie API integration, which routes alerts to
+// its team, so it is configured per rule.
+type OpsgenieSender struct {
+	baseURL string
+	client  *http.Client
+}
+
+// NewOpsgenieSender creates an Opsgenie sender for the given API URL (DefaultOpsgenieAPIURL if empty).
+func NewOpsgenieSender(baseURL string) *OpsgenieSender {
+	if baseURL == "" {
+		baseURL = DefaultOpsgenieAPIURL
+	}
+	return &OpsgenieSender{
+		baseURL: baseURL,
+		client:  &http.Client{Timeout: 15 * time.Second},
+	}
+}
+
+// OpsgenieAlert describes an alert to create
+type OpsgenieAlert struct {
+	Alias       string // Empty lets Opsgenie deduplicate on the message (the alert can't be auto-closed)
+	Message     string // Alert title (truncated to 130 characters)
+	Description string
+	Source      string
+	Entity      string // Affected asset, e.g. "BTC/USD" or "aave v3"
+	Severity    core.Severity
+	Tags        []string
+	Details     map[string]string
+}
+
+// OpsgeniePriority maps a rule severity to an Opsgenie priority
+func OpsgeniePriority(severity core.Severity) string {
+	switch severity {
+	case core.SeverityCritical:
+		return "P1"
+	case core.SeverityInfo:
+		return "P5"
+	default:
+		return "P3"
+	}
+}
+
+// CreateAlert creates an alert. While an open alert with the same alias
+// exists Opsgenie increments its count instead of notifying again.
+// Route: POST /v2/alerts
+func (o *OpsgenieSender) CreateAlert(apiKey string, alert OpsgenieAlert) error {
+	payload := map[string]interface{}{
+		"message":     truncateRunes(alert.Message, 130),
+		"description": truncateRunes(alert.Description, 15000),
+		"source":      alert.Source,
+		"entity":      alert.Entity,
+		"priority":    OpsgeniePriority(alert.Severity),
+		"tags":        alert.Tags,
+		"details":     alert.Details,
+	}
+	if alert.Alias != "" {
+		payload["alias"] = alert.Alias
+	}
+	if err := o.post(apiKey, "/v2/alerts", payload); err != nil {
+		return err
+	}
+	slog.Info("📟 Opsgenie alert created", "priority", OpsgeniePriority(alert.Severity), "alias", alert.Alias)
+	return nil
+}
+
+// CloseAlert closes the open alert with the given alias.
+// Route: POST /v2/alerts/{alias}/close?identifierType=alias
+func (o *OpsgenieSender) CloseAlert(apiKey, alias, note string) error {
+	path := "/v2/alerts/" + url.PathEscape(alias) + "/close?identifierType=alias"
+	if err := o.post(apiKey, path, map[string]string{"source": "crypto-alert", "note": note}); err != nil {
+		return err
+	}
+	slog.Info("📟 Opsgenie alert closed", "alias", alias)
+	return nil
+}
+
+func (o *OpsgenieSender) post(apiKey, path string, payload interface{}) error {
+	if apiKey == "" {
+		return fmt.Errorf("opsgenie API key is required")
+	}
+
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("marshal opsgenie payload: %w", err)
+	}
+
+	req, err := http.NewRequest("POST", o.baseURL+path, bytes.NewBuffer(data))
+	if err != nil {
+		return fmt.Errorf("create opsgenie request: %w", err)
+	}
+	req.Header.Set("Authorization", "GenieKey "+apiKey)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := o.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("send opsgenie request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("opsgenie API returned status %d: %s", resp.StatusCode, string(body))
+	}
+	return nil
+}
+
+func (o *OpsgenieSender) Name() string { return ChannelOpsgenie }
+
+// SendTokenAlert creates (or closes) the Opsgenie alert of a token alert rule.
+func (o *OpsgenieSender) SendTokenAlert(d Delivery, decision *core.AlertDecision) error {
+	return o.alert(d, decision.Message, decision.CurrentPrice.Symbol)
+}
+
+// SendDeFiAlert creates (or closes) the Opsgenie alert of a DeFi alert rule.
+func (o *OpsgenieSender) SendDeFiAlert(d Delivery, decision *core.DeFiAlertDecision) error {
+	return o.alert(d, decision.Message, decision.Rule.Protocol+" "+decision.Rule.Version)
+}
+
+// SendPredictAlert creates (or closes) the Opsgenie alert of a prediction market alert rule.
+func (o *OpsgenieSender) SendPredictAlert(d Delivery, decision *core.PredictMarketAlertDecision) error {
+	return o.alert(d, decision.Message, decision.Rule.Question)
+}
+
+// SendWatchAlert creates (or closes) the Opsgenie alert of a watch alert rule.
+func (o *OpsgenieSender) SendWatchAlert(d Delivery, decision *core.WatchAlertDecision) error {
+	return o.alert(d, decision.Message, decision.Rule.Source+" "+decision.Rule.Label)
+}
+
+// SendNotice is skipped: notices don't create Opsgenie alerts.
+func (o *OpsgenieSender) SendNotice(d Delivery, subject, text string) error {
+	return ErrSkipped
+}
+
+// alert handles the Opsgenie side of an alert: a recovery rule closes the
+// alert of the rule it names, and any other rule creates an Opsgenie alert whose
+// priority follows the rule severity, aliased by rule ID for deduplication.
+func (o *OpsgenieSender) alert(d Delivery, summary, entity string) error {
+	kind := strings.TrimPrefix(d.Topic, "alerts.")
+	if d.Targets.ResolvesRuleID != 0 {
+		return o.CloseAlert(d.To, RuleIncidentKey(kind, d.Targets.ResolvesRuleID), summary)
+	}
+
+	alert := OpsgenieAlert{
+		Message:     summary,
+		Description: summary,
+		Source:      "crypto-alert",
+		Entity:      entity,
+		Severity:    core.Severity(d.Targets.Severity),
+		Tags:        []string{"crypto-alert", kind, d.Targets.Severity},
+		Details:     map[string]string{"topic": d.Topic, "rule_id": strconv.FormatInt(d.Targets.RuleID, 10)},
+	}
+	if d.Targets.RuleID != 0 {
+		alert.Alias = RuleIncidentKey(kind, d.Targets.RuleID)
+	}
+	return o.CreateAlert(d.To, alert)
+}
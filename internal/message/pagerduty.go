@@ -0,0 +1,157 @@
+package message
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"crypto-alert/internal/core"
+)
+
+func init() {
+	RegisterChannel(ChannelPagerDuty, func(func(string) string) (NotificationChannel, error) {
+		return NewPagerDutySender(), nil
+	})
+}
+
+const pagerDutyEventsURL = "https://events.pagerduty.com/v2/enqueue"
+
+// PagerDutySender opens and resolves incidents through the PagerDuty Events
+// API v2. The routing key selects the PagerDuty service, so it is configured
+// per rule.
+type PagerDutySender struct {
+	client *http.Client
+}
+
+func NewPagerDutySender() *PagerDutySender {
+	return &PagerDutySender{
+		client: &http.Client{Timeout: 15 * time.Second},
+	}
+}
+
+// PagerDutyIncident describes the incident opened by a critical alert
+type PagerDutyIncident struct {
+	DedupKey string      // Empty lets PagerDuty assign one (the incident can't be auto-resolved)
+	Summary  string      // Incident title (truncated to 1024 characters)
+	Source   string      // Affected asset, e.g. "BTC/USD" or "aave v3"
+	Class    string      // Alert type, e.g. "alerts.token"
+	Details  interface{} // Attached as custom_details, typically the alert event
+}
+
+// Trigger opens an incident, or adds an alert to the open incident with the same dedup key.
+func (p *PagerDutySender) Trigger(routingKey string, incident PagerDutyIncident) error {
+	event := map[string]interface{}{
+		"routing_key":  routingKey,
+		"event_action": "trigger",
+		"payload": map[string]interface{}{
+			"summary":        truncateRunes(incident.Summary, 1024),
+			"source":         incident.Source,
+			"severity":       "critical",
+			"timestamp":      time.Now().UTC().Format(time.RFC3339),
+			"component":      "crypto-alert",
+			"class":          incident.Class,
+			"custom_details": incident.Details,
+		},
+	}
+	if incident.DedupKey != "" {
+		event["dedup_key"] = incident.DedupKey
+	}
+	return p.enqueue(event)
+}
+
+// Resolve resolves the incident with the given dedup key.
+func (p *PagerDutySender) Resolve(routingKey, dedupKey string) error {
+	return p.enqueue(map[string]interface{}{
+		"routing_key":  routingKey,
+		"event_action": "resolve",
+		"dedup_key":    dedupKey,
+	})
+}
+
+func (p *PagerDutySender) enqueue(event map[string]interface{}) error {
+	if event["routing_key"] == "" {
+		return fmt.Errorf("pagerduty routing key is required")
+	}
+
+	data, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("marshal pagerduty event: %w", err)
+	}
+
+	req, err := http.NewRequest("POST", pagerDutyEventsURL, bytes.NewBuffer(data))
+	if err != nil {
+		return fmt.Errorf("create pagerduty request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("send pagerduty event: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusAccepted && resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("pagerduty API returned status %d: %s", resp.StatusCode, string(body))
+	}
+
+	slog.Info("📟 PagerDuty event accepted", "action", event["event_action"], "dedup_key", event["dedup_key"])
+	return nil
+}
+
+func (p *PagerDutySender) Name() string { return ChannelPagerDuty }
+
+// SendTokenAlert pages (or resolves) the incident of a token alert rule.
+func (p *PagerDutySender) SendTokenAlert(d Delivery, decision *core.AlertDecision) error {
+	return p.page(d, decision.Message, decision.CurrentPrice.Symbol)
+}
+
+// SendDeFiAlert pages (or resolves) the incident of a DeFi alert rule.
+func (p *PagerDutySender) SendDeFiAlert(d Delivery, decision *core.DeFiAlertDecision) error {
+	return p.page(d, decision.Message, decision.Rule.Protocol+" "+decision.Rule.Version)
+}
+
+// SendPredictAlert pages (or resolves) the incident of a prediction market alert rule.
+func (p *PagerDutySender) SendPredictAlert(d Delivery, decision *core.PredictMarketAlertDecision) error {
+	return p.page(d, decision.Message, decision.Rule.Question)
+}
+
+// SendWatchAlert pages (or resolves) the incident of a watch alert rule.
+func (p *PagerDutySender) SendWatchAlert(d Delivery, decision *core.WatchAlertDecision) error {
+	return p.page(d, decision.Message, decision.Rule.Source+" "+decision.Rule.Label)
+}
+
+// SendNotice is skipped: notices don't page.
+func (p *PagerDutySender) SendNotice(d Delivery, subject, text string) error {
+	return ErrSkipped
+}
+
+// page handles the PagerDuty side of an alert: a recovery rule resolves the
+// incident of the rule it names, and any other critical rule opens (or adds to)
+// its own incident, keyed by rule ID so repeated alerts don't page again.
+// Alerts of non-critical rules are skipped.
+func (p *PagerDutySender) page(d Delivery, summary, source string) error {
+	kind := strings.TrimPrefix(d.Topic, "alerts.")
+	if d.Targets.ResolvesRuleID != 0 {
+		return p.Resolve(d.To, RuleIncidentKey(kind, d.Targets.ResolvesRuleID))
+	}
+	if core.Severity(d.Targets.Severity) != core.SeverityCritical {
+		return ErrSkipped
+	}
+
+	incident := PagerDutyIncident{
+		Summary: summary,
+		Source:  source,
+		Class:   d.Topic,
+		Details: json.RawMessage(PublicAlertEvent(d.Payload)),
+	}
+	if d.Targets.RuleID != 0 {
+		incident.DedupKey = RuleIncidentKey(kind, d.Targets.RuleID)
+	}
+	return p.Trigger(d.To, incident)
+}
@@ -0,0 +1,279 @@
+package message
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"crypto-alert/internal/core"
+	"crypto-alert/internal/tracing"
+)
+
+// AlertPublisher implements MessageSender by publishing alert events on the
+// event transport. Its to argument is the rule's comma-separated list of email
+// recipients. The notification-service consumes these events and delivers
+// them to the rule's channels.
+type AlertPublisher struct {
+	transport Transport
+	ctx       context.Context // Of the trace the events continue, see WithContext
+}
+
+// NewAlertPublisher creates a publisher that writes to the given transport.
+// Alert and retry events are keyed by rule ID, so on Kafka topics with several
+// partitions each rule's alerts land on one partition and stay in order.
+func NewAlertPublisher(transport Transport) *AlertPublisher {
+	return &AlertPublisher{transport: transport}
+}
+
+// WithContext returns a publisher whose events continue the trace of ctx,
+// e.g. of the check that triggered the alerts. The events are published even
+// when ctx is cancelled.
+func (p *AlertPublisher) WithContext(ctx context.Context) *AlertPublisher {
+	return &AlertPublisher{transport: p.transport, ctx: context.WithoutCancel(ctx)}
+}
+
+// SendAlert publishes a token price alert to the alerts.token topic.
+func (p *AlertPublisher) SendAlert(to string, decision *core.AlertDecision) error {
+	triggered := triggeredAt(decision.TriggeredAt)
+	targets := notificationTargets(TopicTokenAlert, decision.Rule.ID, triggered, to, decision.Rule.Tags, decision.Rule.NotificationSettings)
+	targets.TelegramChart = decision.Rule.TelegramChart
+	event := TokenAlertEvent{
+		NotificationTargets: targets,
+		Symbol:              decision.CurrentPrice.Symbol,
+		Price:               decision.CurrentPrice.Price,
+		Timestamp:           decision.CurrentPrice.Timestamp,
+		Threshold:           decision.Rule.Threshold,
+		Direction:           string(decision.Rule.Direction),
+		Message:             decision.Message,
+	}
+	for _, pt := range decision.PriceHistory {
+		event.History = append(event.History, HistoryPointEvent{Time: pt.Time, Value: pt.Value})
+	}
+	return p.publish(TopicTokenAlert, ruleKey(decision.Rule.ID), event)
+}
+
+// SendDeFiAlert publishes a DeFi alert to the alerts.defi topic.
+func (p *AlertPublisher) SendDeFiAlert(to string, decision *core.DeFiAlertDecision) error {
+	triggered := triggeredAt(decision.TriggeredAt)
+	r := decision.Rule
+	targets := notificationTargets(TopicDeFiAlert, r.ID, triggered, to, r.Tags, r.NotificationSettings)
+	targets.TelegramChart = r.TelegramChart
+	event := DeFiAlertEvent{
+		NotificationTargets:     targets,
+		Protocol:                r.Protocol,
+		Category:                r.Category,
+		Version:                 r.Version,
+		ChainID:                 r.ChainID,
+		ChainName:               decision.ChainName,
+		MarketTokenContract:     r.MarketTokenContract,
+		Field:                   r.Field,
+		Threshold:               r.Threshold,
+		Direction:               string(r.Direction),
+		CurrentValue:            decision.CurrentValue,
+		Message:                 decision.Message,
+		MarketTokenName:         r.MarketTokenName,
+		MarketTokenPair:         r.MarketTokenPair,
+		VaultName:               r.VaultName,
+		BorrowTokenContract:     r.BorrowTokenContract,
+		CollateralTokenContract: r.CollateralTokenContract,
+		OracleAddress:           r.OracleAddress,
+		IRMAddress:              r.IRMAddress,
+		LLTV:                    r.LLTV,
+		MarketContractAddress:   r.MarketContractAddress,
+		VaultTokenAddress:       r.VaultTokenAddress,
+		DepositTokenContract:    r.DepositTokenContract,
+		ENSNames:                r.ENSNames,
+	}
+	for _, pt := range decision.ValueHistory {
+		event.History = append(event.History, HistoryPointEvent{Time: pt.Time, Value: pt.Value})
+	}
+	return p.publish(TopicDeFiAlert, ruleKey(decision.Rule.ID), event)
+}
+
+// SendPredictMarketAlert publishes a prediction market alert to the alerts.predict topic.
+func (p *AlertPublisher) SendPredictMarketAlert(to string, decision *core.PredictMarketAlertDecision) error {
+	triggered := triggeredAt(decision.TriggeredAt)
+	r := decision.Rule
+	targets := notificationTargets(TopicPredictAlert, r.ID, triggered, to, r.Tags, r.NotificationSettings)
+	event := PredictMarketAlertEvent{
+		NotificationTargets: targets,
+		PredictMarket:       r.PredictMarket,
+		TokenID:             r.TokenID,
+		Field:               r.Field,
+		Threshold:           r.Threshold,
+		Direction:           string(r.Direction),
+		CurrentValue:        decision.CurrentValue,
+		CurrentMidpoint:     decision.CurrentMidpoint,
+		CurrentBuyPrice:     decision.CurrentBuyPrice,
+		CurrentSellPrice:    decision.CurrentSellPrice,
+		Message:             decision.Message,
+		Question:            r.Question,
+		Outcome:             r.Outcome,
+		QuestionID:          r.QuestionID,
+		ConditionID:         r.ConditionID,
+		NegRisk:             r.NegRisk,
+		DepthSide:           r.DepthSide,
+		DepthPrice:          r.DepthPrice,
+		TokenIDs:            r.TokenIDs,
+		GroupItems:          r.GroupItems,
+		MoveWindowMinutes:   int(r.MoveWindow.Minutes()),
+	}
+	if h := decision.History; h != nil {
+		event.History = &PredictHistoryEvent{
+			PeriodHours: h.Period.Hours(),
+			Open:        h.Open,
+			Low:         h.Low,
+			High:        h.High,
+			Samples:     h.Samples,
+		}
+	}
+	return p.publish(TopicPredictAlert, ruleKey(decision.Rule.ID), event)
+}
+
+// SendWatchAlert publishes a watch alert to the alerts.watch topic.
+func (p *AlertPublisher) SendWatchAlert(to string, decision *core.WatchAlertDecision) error {
+	triggered := triggeredAt(decision.TriggeredAt)
+	r := decision.Rule
+	o := decision.Observation
+	targets := notificationTargets(TopicWatchAlert, r.ID, triggered, to, r.Tags, r.NotificationSettings)
+	event := WatchAlertEvent{
+		NotificationTargets: targets,
+		Source:              r.Source,
+		ChainID:             r.ChainID,
+		ChainName:           decision.ChainName,
+		Field:               r.Field,
+		Label:               r.Label,
+		Threshold:           r.Threshold,
+		Direction:           string(r.Direction),
+		Key:                 o.Key,
+		Value:               o.Value,
+		Unlimited:           o.Unlimited,
+		Title:               o.Title,
+		Details:             o.Details,
+		URL:                 o.URL,
+		Message:             decision.Message,
+		Timestamp:           time.Now().UTC(),
+	}
+	return p.publish(TopicWatchAlert, ruleKey(decision.Rule.ID), event)
+}
+
+// notificationTargets returns the targets of an alert event of the rule with
+// id from its notification settings. to is the rule's comma-separated list
+// of email recipients.
+func notificationTargets(topic string, id int64, triggered time.Time, to string, tags []string, s core.NotificationSettings) NotificationTargets {
+	recipients := splitRecipients(to)
+	return NotificationTargets{
+		SchemaVersion:        EventSchemaVersion,
+		EventID:              AlertEventID(topic, id, triggered),
+		TriggeredAt:          triggered,
+		RuleID:               id,
+		RecipientEmail:       firstDestination(recipients),
+		TelegramChatID:       firstDestination(s.TelegramChatIDs),
+		RecipientEmails:      recipients,
+		TelegramChatIDs:      s.TelegramChatIDs,
+		Channels:             s.Channels,
+		Tags:                 tags,
+		WebhookURL:           s.WebhookURL,
+		WhatsAppTo:           s.WhatsAppTo,
+		TeamsWebhookURL:      s.TeamsWebhookURL,
+		NtfyTopic:            s.NtfyTopic,
+		PushoverUserKey:      s.PushoverUserKey,
+		Severity:             string(s.Severity),
+		PagerDutyRoutingKey:  s.PagerDutyRoutingKey,
+		OpsgenieAPIKey:       s.OpsgenieAPIKey,
+		ResolvesRuleID:       s.ResolvesRuleID,
+		MessageTemplate:      s.MessageTemplate,
+		Locale:               string(s.Locale),
+		TelegramFormat:       string(s.TelegramFormat),
+		TelegramSilent:       s.TelegramSilent,
+		TelegramNoPreview:    s.TelegramNoPreview,
+		DigestMinutes:        int(s.DigestInterval / time.Minute),
+		EscalateAfterMinutes: int(s.EscalateAfter / time.Minute),
+		EscalateTo:           s.EscalateTo,
+	}
+}
+
+func (p *AlertPublisher) publish(topic string, key []byte, event any) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("marshal kafka event for topic %s: %w", topic, err)
+	}
+	parent := p.ctx
+	if parent == nil {
+		parent = context.Background()
+	}
+	ctx, cancel := context.WithTimeout(parent, 20*time.Second)
+	defer cancel()
+	ctx, span := tracing.StartProducer(ctx, topic)
+	err = p.transport.Publish(ctx, topic, key, data)
+	tracing.End(span, err)
+	if err != nil {
+		eventsPublished.WithLabelValues(topic, "error").Inc()
+		return err
+	}
+	eventsPublished.WithLabelValues(topic, "ok").Inc()
+	return nil
+}
+
+// PublishRetry queues an alert for a later delivery attempt on the alerts.retry topic.
+func (p *AlertPublisher) PublishRetry(event RetryEvent) error {
+	var alert struct {
+		RuleID int64 `json:"rule_id"`
+	}
+	json.Unmarshal(event.Payload, &alert)
+	return p.publish(TopicRetry, ruleKey(alert.RuleID), event)
+}
+
+// PublishDeadLetter publishes an alert the notification service gave up on
+// to the alerts.dead topic.
+func (p *AlertPublisher) PublishDeadLetter(event DeadLetterEvent) error {
+	var alert struct {
+		RuleID int64 `json:"rule_id"`
+	}
+	json.Unmarshal(event.Payload, &alert)
+	return p.publish(TopicDeadLetter, ruleKey(alert.RuleID), event)
+}
+
+// PublishSummary publishes a recipient's daily summary on the alerts.summary topic.
+func (p *AlertPublisher) PublishSummary(event SummaryEvent) error {
+	return p.publish(TopicSummary, []byte(event.RecipientEmail), event)
+}
+
+// ruleKey is the message key of a rule's events; events without a rule get
+// no key and are spread round-robin over the partitions
+func ruleKey(ruleID int64) []byte {
+	if ruleID == 0 {
+		return nil
+	}
+	return strconv.AppendInt(nil, ruleID, 10)
+}
+
+// triggeredAt is the time the alert's rule triggered, now for decisions that
+// don't carry one
+func triggeredAt(t time.Time) time.Time {
+	if t.IsZero() {
+		return time.Now().UTC()
+	}
+	return t.UTC()
+}
+
+// splitRecipients splits a comma-separated recipient list
+func splitRecipients(to string) []string {
+	var out []string
+	for _, r := range strings.Split(to, ",") {
+		if r = strings.TrimSpace(r); r != "" {
+			out = append(out, r)
+		}
+	}
+	return out
+}
+
+func firstDestination(destinations []string) string {
+	if len(destinations) == 0 {
+		return ""
+	}
+	return destinations[0]
+}
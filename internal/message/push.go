@@ -0,0 +1,256 @@
+package message
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"crypto-alert/internal/core"
+)
+
+func init() {
+	RegisterChannel(ChannelNtfy, func(getenv func(string) string) (NotificationChannel, error) {
+		return senderChannel{ChannelNtfy, NewNtfySender(getenv("NTFY_URL"), getenv("NTFY_TOKEN"))}, nil
+	})
+	RegisterChannel(ChannelPushover, func(getenv func(string) string) (NotificationChannel, error) {
+		token := getenv("PUSHOVER_APP_TOKEN")
+		if token == "" {
+			return nil, nil
+		}
+		return senderChannel{ChannelPushover, NewPushoverSender(token)}, nil
+	})
+}
+
+const (
+	// DefaultNtfyURL is the public ntfy server; self-hosters point NTFY_URL at their own
+	DefaultNtfyURL = "https://ntfy.sh"
+
+	pushoverMessagesURL = "https://api.pushover.net/1/messages.json"
+	pushoverMaxTextLen  = 1024
+)
+
+// pushMessage is the content of a phone push notification
+type pushMessage struct {
+	Title    string
+	Body     string
+	URL      string // Optional link opened when the notification is tapped
+	Severity core.Severity
+}
+
+func tokenAlertPush(decision *core.AlertDecision) pushMessage {
+	subject, textBody, _ := FormatAlertEmail(decision)
+	return pushMessage{Title: subject, Body: textBody, Severity: decision.Rule.Severity}
+}
+
+func defiAlertPush(decision *core.DeFiAlertDecision) pushMessage {
+	subject, textBody, _ := FormatDeFiAlertEmail(decision)
+	return pushMessage{Title: subject, Body: textBody, Severity: decision.Rule.Severity}
+}
+
+func predictMarketAlertPush(decision *core.PredictMarketAlertDecision) pushMessage {
+	subject, textBody, _ := FormatPredictMarketAlertEmail(decision)
+	return pushMessage{Title: subject, Body: textBody, Severity: decision.Rule.Severity}
+}
+
+func watchAlertPush(decision *core.WatchAlertDecision) pushMessage {
+	subject, textBody, _ := FormatWatchAlertEmail(decision)
+	return pushMessage{Title: subject, Body: textBody, URL: decision.Observation.URL, Severity: decision.Rule.Severity}
+}
+
+// NtfySender publishes alerts to ntfy topics (ntfy.sh or a self-hosted server).
+type NtfySender struct {
+	baseURL string
+	token   string
+	client  *http.Client
+}
+
+// NewNtfySender creates an ntfy sender for the given server (DefaultNtfyURL if
+// empty). token is an optional access token for protected topics.
+func NewNtfySender(baseURL, token string) *NtfySender {
+	if baseURL == "" {
+		baseURL = DefaultNtfyURL
+	}
+	return &NtfySender{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		token:   token,
+		client:  &http.Client{Timeout: 15 * time.Second},
+	}
+}
+
+// ntfyPriority maps a rule severity to an ntfy priority (1-5)
+func ntfyPriority(severity core.Severity) string {
+	switch severity {
+	case core.SeverityCritical:
+		return "5"
+	case core.SeverityInfo:
+		return "2"
+	default:
+		return "4"
+	}
+}
+
+// SendAlert pushes a token price alert to an ntfy topic.
+func (n *NtfySender) SendAlert(topic string, decision *core.AlertDecision) error {
+	if topic == "" || decision == nil || decision.Rule == nil || decision.CurrentPrice == nil {
+		return nil
+	}
+	return n.publish(topic, tokenAlertPush(decision))
+}
+
+// SendDeFiAlert pushes a DeFi protocol alert to an ntfy topic.
+func (n *NtfySender) SendDeFiAlert(topic string, decision *core.DeFiAlertDecision) error {
+	if topic == "" || decision == nil || decision.Rule == nil {
+		return nil
+	}
+	return n.publish(topic, defiAlertPush(decision))
+}
+
+// SendPredictMarketAlert pushes a prediction market alert to an ntfy topic.
+func (n *NtfySender) SendPredictMarketAlert(topic string, decision *core.PredictMarketAlertDecision) error {
+	if topic == "" || decision == nil || decision.Rule == nil {
+		return nil
+	}
+	return n.publish(topic, predictMarketAlertPush(decision))
+}
+
+// SendWatchAlert pushes a watch alert to an ntfy topic.
+func (n *NtfySender) SendWatchAlert(topic string, decision *core.WatchAlertDecision) error {
+	if topic == "" || decision == nil || decision.Rule == nil || decision.Observation == nil {
+		return nil
+	}
+	return n.publish(topic, watchAlertPush(decision))
+}
+
+// SendNotice pushes a plain message to an ntfy topic.
+func (n *NtfySender) SendNotice(topic, subject, text string) error {
+	return n.publish(topic, pushMessage{Title: subject, Body: text})
+}
+
+// publish posts a message to a topic.
+// Route: POST /{topic}
+func (n *NtfySender) publish(topic string, msg pushMessage) error {
+	req, err := http.NewRequest("POST", n.baseURL+"/"+url.PathEscape(topic), strings.NewReader(msg.Body))
+	if err != nil {
+		return fmt.Errorf("create ntfy request: %w", err)
+	}
+	// Headers must be ASCII; titles with emoji are sent as RFC 2047 encoded words, which ntfy decodes
+	req.Header.Set("Title", mime.BEncoding.Encode("UTF-8", msg.Title))
+	req.Header.Set("Priority", ntfyPriority(msg.Severity))
+	req.Header.Set("Tags", "rotating_light")
+	if msg.URL != "" {
+		req.Header.Set("Click", msg.URL)
+	}
+	if n.token != "" {
+		req.Header.Set("Authorization", "Bearer "+n.token)
+	}
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("send ntfy message: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("ntfy returned status %d: %s", resp.StatusCode, string(body))
+	}
+
+	slog.Info("📨 ntfy message published", "ntfy_topic", topic)
+	return nil
+}
+
+// PushoverSender sends alerts through the Pushover Messages API.
+type PushoverSender struct {
+	appToken string
+	client   *http.Client
+}
+
+// NewPushoverSender creates a Pushover sender for the application token.
+func NewPushoverSender(appToken string) *PushoverSender {
+	return &PushoverSender{
+		appToken: appToken,
+		client:   &http.Client{Timeout: 15 * time.Second},
+	}
+}
+
+// pushoverPriority maps a rule severity to a Pushover priority. Critical alerts
+// use high priority, which bypasses the user's quiet hours.
+func pushoverPriority(severity core.Severity) string {
+	switch severity {
+	case core.SeverityCritical:
+		return "1"
+	case core.SeverityInfo:
+		return "-1"
+	default:
+		return "0"
+	}
+}
+
+// SendAlert pushes a token price alert to a Pushover user or group key.
+func (p *PushoverSender) SendAlert(userKey string, decision *core.AlertDecision) error {
+	if userKey == "" || decision == nil || decision.Rule == nil || decision.CurrentPrice == nil {
+		return nil
+	}
+	return p.send(userKey, tokenAlertPush(decision))
+}
+
+// SendDeFiAlert pushes a DeFi protocol alert to a Pushover user or group key.
+func (p *PushoverSender) SendDeFiAlert(userKey string, decision *core.DeFiAlertDecision) error {
+	if userKey == "" || decision == nil || decision.Rule == nil {
+		return nil
+	}
+	return p.send(userKey, defiAlertPush(decision))
+}
+
+// SendPredictMarketAlert pushes a prediction market alert to a Pushover user or group key.
+func (p *PushoverSender) SendPredictMarketAlert(userKey string, decision *core.PredictMarketAlertDecision) error {
+	if userKey == "" || decision == nil || decision.Rule == nil {
+		return nil
+	}
+	return p.send(userKey, predictMarketAlertPush(decision))
+}
+
+// SendWatchAlert pushes a watch alert to a Pushover user or group key.
+func (p *PushoverSender) SendWatchAlert(userKey string, decision *core.WatchAlertDecision) error {
+	if userKey == "" || decision == nil || decision.Rule == nil || decision.Observation == nil {
+		return nil
+	}
+	return p.send(userKey, watchAlertPush(decision))
+}
+
+// SendNotice pushes a plain message to a Pushover user or group key.
+func (p *PushoverSender) SendNotice(userKey, subject, text string) error {
+	return p.send(userKey, pushMessage{Title: subject, Body: text})
+}
+
+func (p *PushoverSender) send(userKey string, msg pushMessage) error {
+	form := url.Values{
+		"token":    {p.appToken},
+		"user":     {userKey},
+		"title":    {truncateRunes(msg.Title, 250)},
+		"message":  {truncateRunes(msg.Body, pushoverMaxTextLen)},
+		"priority": {pushoverPriority(msg.Severity)},
+	}
+	if msg.URL != "" {
+		form.Set("url", msg.URL)
+	}
+
+	resp, err := p.client.PostForm(pushoverMessagesURL, form)
+	if err != nil {
+		return fmt.Errorf("send pushover message: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("pushover API returned status %d: %s", resp.StatusCode, string(body))
+	}
+
+	slog.Info("📨 Pushover message sent")
+	return nil
+}
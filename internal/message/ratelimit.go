@@ -0,0 +1,150 @@
+package message
+
+import (
+	"sync"
+	"time"
+)
+
+// Defaults for NewRateLimiter, per window
+const (
+	DefaultRateLimitPerRecipient = 10
+	DefaultRateLimitPerChannel   = 100
+	DefaultRateLimitWindow       = 10 * time.Minute
+)
+
+// tokenBucket holds up to limit tokens and refills limit tokens per window
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+func (b *tokenBucket) refill(now time.Time, limit int, window time.Duration) {
+	b.tokens += float64(limit) * now.Sub(b.last).Seconds() / window.Seconds()
+	if b.tokens > float64(limit) {
+		b.tokens = float64(limit)
+	}
+	b.last = now
+}
+
+// SuppressedAlerts counts the alerts a rate limiter dropped for one destination
+type SuppressedAlerts struct {
+	Channel string
+	Count   int
+	Since   time.Time // When the first of them was dropped
+	Last    Delivery  // The most recently dropped delivery; addresses the summary
+}
+
+type destinationKey struct {
+	channel, to string
+}
+
+// RateLimiter caps how many alerts each destination and each channel get per
+// window with token buckets, so a misconfigured rule or a market crash can't
+// flood a recipient. Dropped alerts are counted per destination and handed out
+// as summaries once a window has passed since the first of them.
+type RateLimiter struct {
+	perRecipient int
+	perChannel   int
+	window       time.Duration
+
+	mu         sync.Mutex
+	buckets    map[destinationKey]*tokenBucket // Channel buckets have an empty to
+	suppressed map[destinationKey]*SuppressedAlerts
+}
+
+// NewRateLimiter creates a limiter allowing perRecipient alerts per destination
+// and perChannel alerts per channel in each window. A limit of 0 or less
+// disables that limit.
+func NewRateLimiter(perRecipient, perChannel int, window time.Duration) *RateLimiter {
+	if window <= 0 {
+		window = DefaultRateLimitWindow
+	}
+	return &RateLimiter{
+		perRecipient: perRecipient,
+		perChannel:   perChannel,
+		window:       window,
+		buckets:      map[destinationKey]*tokenBucket{},
+		suppressed:   map[destinationKey]*SuppressedAlerts{},
+	}
+}
+
+// Allow reports whether d may be sent on channel, taking a token from both the
+// destination's and the channel's bucket. A dropped delivery is counted towards
+// the destination's next summary. A nil limiter allows everything.
+func (l *RateLimiter) Allow(channel string, d Delivery) bool {
+	if l == nil {
+		return true
+	}
+	now := time.Now()
+	key := destinationKey{channel, d.To}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	recipient := l.bucket(key, l.perRecipient, now)
+	ch := l.bucket(destinationKey{channel: channel}, l.perChannel, now)
+	if (recipient == nil || recipient.tokens >= 1) && (ch == nil || ch.tokens >= 1) {
+		if recipient != nil {
+			recipient.tokens--
+		}
+		if ch != nil {
+			ch.tokens--
+		}
+		return true
+	}
+
+	s := l.suppressed[key]
+	if s == nil {
+		s = &SuppressedAlerts{Channel: channel, Since: now}
+		l.suppressed[key] = s
+	}
+	s.Count++
+	s.Last = d
+	return false
+}
+
+// bucket returns the refilled bucket of key, or nil when limit disables it
+func (l *RateLimiter) bucket(key destinationKey, limit int, now time.Time) *tokenBucket {
+	if limit <= 0 {
+		return nil
+	}
+	b := l.buckets[key]
+	if b == nil {
+		b = &tokenBucket{tokens: float64(limit), last: now}
+		l.buckets[key] = b
+	}
+	b.refill(now, limit, l.window)
+	return b
+}
+
+// DueSummaries returns and resets the counts of dropped alerts whose first
+// drop is at least a window old, so each destination gets at most one summary
+// per window.
+func (l *RateLimiter) DueSummaries() []SuppressedAlerts {
+	if l == nil {
+		return nil
+	}
+	now := time.Now()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	var due []SuppressedAlerts
+	for key, s := range l.suppressed {
+		if now.Sub(s.Since) >= l.window {
+			due = append(due, *s)
+			delete(l.suppressed, key)
+		}
+	}
+	return due
+}
+
+// PerRecipient returns the number of alerts a destination gets per window
+func (l *RateLimiter) PerRecipient() int {
+	return l.perRecipient
+}
+
+// Window returns the limiter's window
+func (l *RateLimiter) Window() time.Duration {
+	return l.window
+}
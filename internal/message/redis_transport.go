@@ -0,0 +1,262 @@
+package message
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"crypto-alert/internal/logger"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	redisStreamMaxAge = 7 * 24 * time.Hour // Like Kafka's default retention
+	redisClaimIdle    = time.Minute        // Pending entries idle this long are claimed from their consumer
+	redisEventField   = "event"            // Stream entry field holding the event
+)
+
+// RedisTransport carries events over Redis Streams, one stream per topic,
+// for users who already run Redis but not Kafka. Each consumer group is a
+// Redis consumer group; entries a crashed instance left pending are claimed
+// by another one after a minute.
+type RedisTransport struct {
+	client   *redis.Client
+	addr     string
+	consumer string // Name of this instance in the consumer groups
+}
+
+// NewRedisTransport connects to the Redis server at url (redis://host:6379/0)
+func NewRedisTransport(url string) (*RedisTransport, error) {
+	if url == "" {
+		url = "redis://localhost:6379/0"
+	}
+	opts, err := redis.ParseURL(url)
+	if err != nil {
+		return nil, fmt.Errorf("invalid REDIS_URL: %w", err)
+	}
+	client := redis.NewClient(opts)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := client.Ping(ctx).Err(); err != nil {
+		client.Close()
+		return nil, fmt.Errorf("connect to Redis at %s: %w", opts.Addr, err)
+	}
+	host, _ := os.Hostname()
+	return &RedisTransport{
+		client:   client,
+		addr:     opts.Addr,
+		consumer: fmt.Sprintf("%s-%d", host, os.Getpid()),
+	}, nil
+}
+
+func (t *RedisTransport) Name() string {
+	return "Redis Streams at " + t.addr
+}
+
+func (t *RedisTransport) Close() error {
+	return t.client.Close()
+}
+
+// Publish appends an event to the topic's stream, trimming entries older than
+// the retention. Streams have no partitions, so key isn't needed for ordering.
+func (t *RedisTransport) Publish(ctx context.Context, topic string, key, value []byte) error {
+	return t.client.XAdd(ctx, &redis.XAddArgs{
+		Stream: topic,
+		MinID:  strconv.FormatInt(time.Now().Add(-redisStreamMaxAge).UnixMilli(), 10),
+		Approx: true,
+		Values: []string{redisEventField, string(value)},
+	}).Err()
+}
+
+// Prepare creates the consumer groups that don't exist yet. A new group
+// starts from the earliest entry of its stream; existing ones keep their
+// position.
+func (t *RedisTransport) Prepare(ctx context.Context, consumers []Consumer) {
+	for _, c := range consumers {
+		if err := t.group(ctx, c); err != nil {
+			slog.Warn("⚠️  Redis consumer group setup failed", "group", c.Group, logger.FieldError, err)
+		}
+	}
+}
+
+func (t *RedisTransport) group(ctx context.Context, c Consumer) error {
+	err := t.client.XGroupCreateMkStream(ctx, c.Topic, c.Group, "0").Err()
+	if err != nil && strings.HasPrefix(err.Error(), "BUSYGROUP") {
+		return nil
+	}
+	return err
+}
+
+// Check pings the Redis server
+func (t *RedisTransport) Check(ctx context.Context, consumers []Consumer) error {
+	return t.client.Ping(ctx).Err()
+}
+
+// Lag returns how many entries of the consumer's stream the group hasn't read
+// yet (Redis 7 or newer; -1 when Redis can't tell)
+func (t *RedisTransport) Lag(ctx context.Context, c Consumer) (int64, error) {
+	groups, err := t.client.XInfoGroups(ctx, c.Topic).Result()
+	if err != nil {
+		return 0, err
+	}
+	for _, g := range groups {
+		if g.Name == c.Group {
+			return g.Lag, nil
+		}
+	}
+	return 0, nil
+}
+
+// Consume reads the consumer's stream one entry at a time, acknowledging each
+// once handle returns nil. It first handles the entries this instance left
+// pending, e.g. after handle failed, then claims those idle in other
+// instances, then reads new entries.
+func (t *RedisTransport) Consume(ctx context.Context, c Consumer, handle func(ctx context.Context, value []byte) error) error {
+	if err := t.group(ctx, c); err != nil {
+		return err
+	}
+	start := "0" // This instance's pending entries first
+	for ctx.Err() == nil {
+		if start == ">" {
+			claimed, err := t.claim(ctx, c)
+			if err != nil {
+				return err
+			}
+			if claimed > 0 {
+				start = "0"
+			}
+		}
+		streams, err := t.client.XReadGroup(ctx, &redis.XReadGroupArgs{
+			Group:    c.Group,
+			Consumer: t.consumer,
+			Streams:  []string{c.Topic, start},
+			Count:    1,
+			Block:    10 * time.Second,
+		}).Result()
+		if errors.Is(err, redis.Nil) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if len(streams) == 0 || len(streams[0].Messages) == 0 {
+			start = ">" // No pending entries left, read new ones
+			continue
+		}
+		msg := streams[0].Messages[0]
+		if err := t.handle(ctx, c, msg, handle); err != nil {
+			return err
+		}
+	}
+	return ctx.Err()
+}
+
+// claim moves the entries pending in other instances for longer than
+// redisClaimIdle, e.g. because the instance crashed, to this one, so they
+// are read with this instance's pending entries. It returns how many it
+// claimed.
+func (t *RedisTransport) claim(ctx context.Context, c Consumer) (int, error) {
+	claimed, _, err := t.client.XAutoClaimJustID(ctx, &redis.XAutoClaimArgs{
+		Stream:   c.Topic,
+		Group:    c.Group,
+		Consumer: t.consumer,
+		MinIdle:  redisClaimIdle,
+		Start:    "0",
+		Count:    10,
+	}).Result()
+	if err != nil {
+		return 0, err
+	}
+	if len(claimed) > 0 {
+		slog.Info("🔁 Claimed entries left pending by another instance", logger.FieldTopic, c.Topic, "entries", len(claimed))
+	}
+	return len(claimed), nil
+}
+
+// handle runs handle on an entry and acknowledges it when handle returns nil.
+// While handle runs the entry is claimed again every 20 seconds, so other
+// instances don't take it over, e.g. while a retry waits for its backoff.
+func (t *RedisTransport) handle(ctx context.Context, c Consumer, msg redis.XMessage, handle func(ctx context.Context, value []byte) error) error {
+	value, ok := msg.Values[redisEventField].(string)
+	if !ok {
+		// Not an event (or trimmed from the stream): nothing to handle
+		return t.client.XAck(ctx, c.Topic, c.Group, msg.ID).Err()
+	}
+
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		ticker := time.NewTicker(redisClaimIdle / 3)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				_ = t.client.XClaimJustID(ctx, &redis.XClaimArgs{
+					Stream:   c.Topic,
+					Group:    c.Group,
+					Consumer: t.consumer,
+					Messages: []string{msg.ID},
+				}).Err()
+			}
+		}
+	}()
+	if err := handle(ctx, []byte(value)); err != nil {
+		return err
+	}
+	// Acknowledge even while shutting down, so a handled entry isn't read again
+	ackCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 5*time.Second)
+	defer cancel()
+	return t.client.XAck(ackCtx, c.Topic, c.Group, msg.ID).Err()
+}
+
+// Replay reads the entries of topic's stream added since from, up to the last
+// one when it started. The IDs of the events are their entry IDs. Entries
+// older than the retention are gone.
+func (t *RedisTransport) Replay(ctx context.Context, topic string, from time.Time, handle func(ReplayedEvent) error) error {
+	last, err := t.client.XRevRangeN(ctx, topic, "+", "-", 1).Result()
+	if err != nil {
+		return fmt.Errorf("read %s: %w", topic, err)
+	}
+	if len(last) == 0 {
+		return nil // Nothing published on the topic
+	}
+	end := last[0].ID
+	start := strconv.FormatInt(from.UnixMilli(), 10) + "-0"
+	for {
+		entries, err := t.client.XRangeN(ctx, topic, start, end, 100).Result()
+		if err != nil {
+			return fmt.Errorf("read %s: %w", topic, err)
+		}
+		for _, entry := range entries {
+			value, ok := entry.Values[redisEventField].(string)
+			if !ok {
+				continue
+			}
+			if err := handle(ReplayedEvent{ID: entry.ID, Time: redisEntryTime(entry.ID), Value: []byte(value)}); err != nil {
+				return err
+			}
+		}
+		if len(entries) < 100 || entries[len(entries)-1].ID == end {
+			return nil
+		}
+		start = "(" + entries[len(entries)-1].ID // Exclusive, Redis 6.2 or newer
+	}
+}
+
+// redisEntryTime returns when an entry with an ID Redis generated was added
+func redisEntryTime(id string) time.Time {
+	ms, _, _ := strings.Cut(id, "-")
+	n, err := strconv.ParseInt(ms, 10, 64)
+	if err != nil {
+		return time.Time{}
+	}
+	return time.UnixMilli(n)
+}
@@ -0,0 +1,199 @@
+package message
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"crypto-alert/internal/core"
+	"crypto-alert/internal/logger"
+)
+
+// ErrCircuitOpen is returned without sending while the circuit breaker of a
+// channel's destination is open.
+var ErrCircuitOpen = errors.New("circuit breaker open")
+
+// PermanentError marks a send error that retrying can't fix, e.g. an HTTP 4xx
+// response. It is neither retried nor queued for a later retry.
+type PermanentError struct {
+	Err error
+}
+
+func (e *PermanentError) Error() string { return e.Err.Error() }
+
+func (e *PermanentError) Unwrap() error { return e.Err }
+
+// IsPermanent reports whether err is a PermanentError
+func IsPermanent(err error) bool {
+	var perm *PermanentError
+	return errors.As(err, &perm)
+}
+
+// RetryAfterError is a send rejected by the provider's rate limit, which asked
+// to wait After before sending again (e.g. Telegram's 429 retry_after)
+type RetryAfterError struct {
+	Err   error
+	After time.Duration
+}
+
+func (e *RetryAfterError) Error() string { return e.Err.Error() }
+
+func (e *RetryAfterError) Unwrap() error { return e.Err }
+
+// retryAfter returns how long the provider asked to wait when err is a RetryAfterError
+func retryAfter(err error) (time.Duration, bool) {
+	var ra *RetryAfterError
+	if errors.As(err, &ra) {
+		return ra.After, true
+	}
+	return 0, false
+}
+
+// RetryPolicy bounds the in-process retries of a channel send
+type RetryPolicy struct {
+	MaxRetries int           // Retries after the first attempt
+	MinBackoff time.Duration // Wait before the first retry, doubled for each further retry
+	MaxBackoff time.Duration
+}
+
+// retryPolicyProvider is implemented by channels that bring their own retry settings
+type retryPolicyProvider interface {
+	RetryPolicy() RetryPolicy
+}
+
+// CircuitBreaker stops sending to a destination of a channel after threshold
+// consecutive failed sends to it, until cooldown has passed. The first send
+// after the cooldown probes the destination: success closes its breaker,
+// failure opens it again. Each destination (webhook URL, routing key, chat,
+// ...) has its own breaker, so one rule's broken endpoint doesn't hold back
+// the alerts of the others.
+type CircuitBreaker struct {
+	threshold int
+	cooldown  time.Duration
+
+	mu    sync.Mutex
+	state map[string]*breakerState // By destination, only those that failed last
+}
+
+// breakerState is the breaker of one destination
+type breakerState struct {
+	failures  int
+	openUntil time.Time
+}
+
+// NewCircuitBreaker creates a breaker. A threshold of 0 or less never opens.
+func NewCircuitBreaker(threshold int, cooldown time.Duration) *CircuitBreaker {
+	return &CircuitBreaker{threshold: threshold, cooldown: cooldown, state: map[string]*breakerState{}}
+}
+
+// allow reports whether a send to destination to may be attempted
+func (b *CircuitBreaker) allow(to string) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	s, ok := b.state[to]
+	return !ok || time.Now().After(s.openUntil)
+}
+
+// record updates the breaker of destination to with the outcome of a send and
+// reports whether it just opened. A skipped send, e.g. a PagerDuty page of a
+// non-critical alert, counts as a success.
+func (b *CircuitBreaker) record(to string, err error) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if err == nil || errors.Is(err, ErrSkipped) || IsPermanent(err) {
+		delete(b.state, to)
+		return false
+	}
+	s, ok := b.state[to]
+	if !ok {
+		s = &breakerState{}
+		b.state[to] = s
+	}
+	s.failures++
+	if b.threshold <= 0 || s.failures < b.threshold {
+		return false
+	}
+	s.openUntil = time.Now().Add(b.cooldown)
+	return true
+}
+
+// retryingChannel retries failed sends of a channel and guards its destinations
+// with a circuit breaker.
+type retryingChannel struct {
+	NotificationChannel
+	policy  RetryPolicy
+	breaker *CircuitBreaker
+}
+
+// WithRetry wraps a channel so failed sends are retried with exponential backoff
+// and the breaker short-circuits sends to a destination that keeps failing.
+func WithRetry(ch NotificationChannel, policy RetryPolicy, breaker *CircuitBreaker) NotificationChannel {
+	if p, ok := ch.(retryPolicyProvider); ok {
+		policy = p.RetryPolicy()
+	}
+	return &retryingChannel{NotificationChannel: ch, policy: policy, breaker: breaker}
+}
+
+func (c *retryingChannel) SendTokenAlert(d Delivery, decision *core.AlertDecision) error {
+	return c.do(d.To, func() error { return c.NotificationChannel.SendTokenAlert(d, decision) })
+}
+
+func (c *retryingChannel) SendDeFiAlert(d Delivery, decision *core.DeFiAlertDecision) error {
+	return c.do(d.To, func() error { return c.NotificationChannel.SendDeFiAlert(d, decision) })
+}
+
+func (c *retryingChannel) SendPredictAlert(d Delivery, decision *core.PredictMarketAlertDecision) error {
+	return c.do(d.To, func() error { return c.NotificationChannel.SendPredictAlert(d, decision) })
+}
+
+func (c *retryingChannel) SendWatchAlert(d Delivery, decision *core.WatchAlertDecision) error {
+	return c.do(d.To, func() error { return c.NotificationChannel.SendWatchAlert(d, decision) })
+}
+
+func (c *retryingChannel) SendNotice(d Delivery, subject, text string) error {
+	return c.do(d.To, func() error { return c.NotificationChannel.SendNotice(d, subject, text) })
+}
+
+func (c *retryingChannel) do(to string, send func() error) error {
+	if !c.breaker.allow(to) {
+		return ErrCircuitOpen
+	}
+
+	backoff := c.policy.MinBackoff
+	var err error
+	attempts := 0
+	for attempt := 0; attempt <= c.policy.MaxRetries; attempt++ {
+		if attempt > 0 {
+			wait := backoff
+			if after, ok := retryAfter(err); ok && after > wait {
+				wait = after
+			}
+			slog.Info("⏳ Send failed, retrying", logger.FieldChannel, c.Name(), "retry_in", wait, "attempt", attempt, "max_retries", c.policy.MaxRetries, logger.FieldError, err)
+			time.Sleep(wait)
+			backoff *= 2
+			if c.policy.MaxBackoff > 0 && backoff > c.policy.MaxBackoff {
+				backoff = c.policy.MaxBackoff
+			}
+		}
+		err = send()
+		attempts++
+		if err == nil || errors.Is(err, ErrSkipped) || IsPermanent(err) {
+			break
+		}
+		// A rate limit longer than the longest backoff is left to the retry
+		// topic rather than holding up the other alerts
+		if after, ok := retryAfter(err); ok && c.policy.MaxBackoff > 0 && after > c.policy.MaxBackoff {
+			break
+		}
+	}
+
+	if c.breaker.record(to, err) {
+		slog.Warn("🔌 Circuit breaker opened after repeated failures", logger.FieldChannel, c.Name(), "destination", DisplayDestination(c.Name(), to), "cooldown", c.breaker.cooldown)
+	}
+	if err != nil && !errors.Is(err, ErrSkipped) && !IsPermanent(err) && attempts > 1 {
+		return fmt.Errorf("after %d attempts: %w", attempts, err)
+	}
+	return err
+}
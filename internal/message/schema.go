@@ -0,0 +1,122 @@
+package message
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// EventSchemaVersion is the version of the alert event schema this build
+// publishes and understands. Bump it when a change to the alert events needs
+// more than new optional fields, and add the upgrade from the previous version
+// to eventUpgrades. Events without schema_version are version 1.
+const EventSchemaVersion = 2
+
+// eventUpgrades upgrade an alert event from the version it is keyed by to the
+// next one, so a notification service reads events an older engine published
+type eventUpgrades map[int]func(fields map[string]json.RawMessage) error
+
+var alertEventUpgrades = eventUpgrades{
+	// Version 1 events may carry only the single recipient_email and
+	// telegram_chat_id the lists replaced
+	1: func(fields map[string]json.RawMessage) error {
+		for single, list := range map[string]string{"recipient_email": "recipient_emails", "telegram_chat_id": "telegram_chat_ids"} {
+			var value string
+			if raw, ok := fields[single]; !ok || json.Unmarshal(raw, &value) != nil || value == "" {
+				continue
+			}
+			if _, ok := fields[list]; ok {
+				continue
+			}
+			raw, err := json.Marshal([]string{value})
+			if err != nil {
+				return err
+			}
+			fields[list] = raw
+		}
+		return nil
+	},
+}
+
+// reportedUnknownFields remembers the unknown fields already logged, by
+// event type and field list, so each is logged once
+var reportedUnknownFields sync.Map
+
+// DecodeAlertEvent decodes an alert event payload into event, a pointer to
+// one of the alert event types. Events of an older schema version are
+// upgraded first. Fields this build doesn't know, e.g. from a newer engine,
+// are logged once instead of being dropped silently; the known fields are
+// still decoded, so the engine can be upgraded before the notification
+// service.
+func DecodeAlertEvent(payload []byte, event any) error {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(payload, &fields); err != nil {
+		return err
+	}
+	version := 1
+	if raw, ok := fields["schema_version"]; ok {
+		if err := json.Unmarshal(raw, &version); err != nil || version < 1 {
+			return fmt.Errorf("invalid schema_version %s", raw)
+		}
+	}
+	for v := version; v < EventSchemaVersion; v++ {
+		upgrade, ok := alertEventUpgrades[v]
+		if !ok {
+			continue
+		}
+		if err := upgrade(fields); err != nil {
+			return fmt.Errorf("upgrade event from schema version %d: %w", v, err)
+		}
+	}
+
+	t := reflect.TypeOf(event).Elem()
+	known := jsonFields(t)
+	var unknown []string
+	for name := range fields {
+		if !known[name] {
+			unknown = append(unknown, name)
+		}
+	}
+	if len(unknown) > 0 {
+		slices.Sort(unknown)
+		key := fmt.Sprintf("%s v%d %s", t.Name(), version, strings.Join(unknown, ","))
+		if _, seen := reportedUnknownFields.LoadOrStore(key, true); !seen {
+			slog.Warn("⚠️  Event has fields this build doesn't know, ignoring them", "event", t.Name(), "schema_version", version,
+				"build_schema_version", EventSchemaVersion, "fields", unknown)
+		}
+	}
+
+	upgraded, err := json.Marshal(fields)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(upgraded, event)
+}
+
+// jsonFields returns the JSON names of a struct's fields, including those of
+// its embedded structs
+func jsonFields(t reflect.Type) map[string]bool {
+	names := map[string]bool{}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if f.Anonymous && name == "" && f.Type.Kind() == reflect.Struct {
+			for n := range jsonFields(f.Type) {
+				names[n] = true
+			}
+			continue
+		}
+		if name == "-" || !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		names[name] = true
+	}
+	return names
+}
@@ -0,0 +1,123 @@
+package message
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"crypto-alert/internal/core"
+)
+
+// severityStyle is how alerts of one severity stand out. Warning, the default
+// severity, keeps the plain look.
+type severityStyle struct {
+	subjectPrefix    string // Prepended to email subjects
+	headerBackground string // CSS background of the email header
+	telegramEmoji    string // Replaces the leading 🚨 of Telegram messages
+}
+
+var severityStyles = map[core.Severity]severityStyle{
+	core.SeverityCritical: {
+		subjectPrefix:    "[CRITICAL] ",
+		headerBackground: "linear-gradient(135deg, #ef4444 0%, #991b1b 100%)",
+		telegramEmoji:    "🔴",
+	},
+	core.SeverityInfo: {
+		subjectPrefix:    "[INFO] ",
+		headerBackground: "linear-gradient(135deg, #3b82f6 0%, #06b6d4 100%)",
+		telegramEmoji:    "🔵",
+	},
+}
+
+// emailHeaderBackground matches the header background of the built-in email
+// templates, the first gradient in the HTML
+var emailHeaderBackground = regexp.MustCompile(`background: linear-gradient\(135deg, [^;"]*\)`)
+
+// styleEmail marks an email with its alert's severity: a subject prefix such
+// as [CRITICAL] and the severity's header color
+func styleEmail(severity core.Severity, subject, htmlBody string) (string, string) {
+	style, ok := severityStyles[severity]
+	if !ok {
+		return subject, htmlBody
+	}
+	if loc := emailHeaderBackground.FindStringIndex(htmlBody); loc != nil {
+		htmlBody = htmlBody[:loc[0]] + "background: " + style.headerBackground + htmlBody[loc[1]:]
+	}
+	return style.subjectPrefix + subject, htmlBody
+}
+
+// styleTelegram marks a Telegram message with its alert's severity emoji
+func styleTelegram(severity core.Severity, text string) string {
+	style, ok := severityStyles[severity]
+	if !ok {
+		return text
+	}
+	if rest, found := strings.CutPrefix(text, "🚨"); found {
+		return style.telegramEmoji + rest
+	}
+	return style.telegramEmoji + " " + text
+}
+
+// SeverityRoute routes the alerts of one severity: Channels limits them to
+// those channels (empty allows every channel) and Extra adds destinations,
+// by channel, that get them on top of the rule's own.
+type SeverityRoute struct {
+	Channels []string
+	Extra    map[string][]string
+}
+
+// severityRoutes are the routes by severity; severities without a route go to
+// the rule's channels only
+var severityRoutes = map[core.Severity]SeverityRoute{}
+
+// SetSeverityRoutes sets how alerts are routed by severity. Call it before
+// sending alerts.
+func SetSeverityRoutes(routes map[core.Severity]SeverityRoute) {
+	severityRoutes = routes
+}
+
+// severityRoutesFromEnv reads the severity routes: NOTIFY_<SEVERITY>_CHANNELS
+// is a comma-separated channel allow-list and NOTIFY_<SEVERITY>_EXTRA a
+// comma-separated list of channel:destination pairs, e.g.
+// NOTIFY_INFO_CHANNELS=email and NOTIFY_CRITICAL_EXTRA=pagerduty:<routing key>.
+func severityRoutesFromEnv(getenv func(string) string) (map[core.Severity]SeverityRoute, error) {
+	routes := map[core.Severity]SeverityRoute{}
+	for _, severity := range []core.Severity{core.SeverityInfo, core.SeverityWarning, core.SeverityCritical} {
+		prefix := "NOTIFY_" + strings.ToUpper(string(severity))
+		var route SeverityRoute
+		for _, name := range splitRecipients(getenv(prefix + "_CHANNELS")) {
+			if !slices.Contains(channelNames, name) {
+				return nil, fmt.Errorf("%s_CHANNELS: unknown channel %q", prefix, name)
+			}
+			route.Channels = append(route.Channels, name)
+		}
+		for _, pair := range splitRecipients(getenv(prefix + "_EXTRA")) {
+			name, to, ok := strings.Cut(pair, ":")
+			if !ok || to == "" || !slices.Contains(channelNames, name) {
+				return nil, fmt.Errorf("%s_EXTRA: %q is not a channel:destination pair", prefix, pair)
+			}
+			if route.Extra == nil {
+				route.Extra = map[string][]string{}
+			}
+			route.Extra[name] = append(route.Extra[name], to)
+		}
+		if route.Channels != nil || route.Extra != nil {
+			routes[severity] = route
+		}
+	}
+	return routes, nil
+}
+
+// parseSeverities parses a comma-separated list of severities, e.g. "info,warning"
+func parseSeverities(list string) ([]core.Severity, error) {
+	var severities []core.Severity
+	for _, s := range splitRecipients(list) {
+		severity := core.Severity(strings.ToLower(s))
+		if severity != core.SeverityInfo && severity != core.SeverityWarning && severity != core.SeverityCritical {
+			return nil, fmt.Errorf("unknown severity %q", s)
+		}
+		severities = append(severities, severity)
+	}
+	return severities, nil
+}
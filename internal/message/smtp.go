@@ -0,0 +1,227 @@
+package message
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"maps"
+	"mime"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"crypto-alert/internal/core"
+)
+
+// SMTPSender sends alerts through an SMTP server, as a self-hosted alternative
+// to ResendEmailSender. Port 465 uses implicit TLS; other ports upgrade with
+// STARTTLS when the server offers it.
+type SMTPSender struct {
+	host      string
+	port      int
+	username  string
+	password  string
+	fromEmail string
+}
+
+// NewSMTPSender creates a new SMTP email sender. username may be empty for
+// servers that don't require authentication.
+func NewSMTPSender(host string, port int, username, password, fromEmail string) *SMTPSender {
+	if port == 0 {
+		port = 587
+	}
+	return &SMTPSender{
+		host:      host,
+		port:      port,
+		username:  username,
+		password:  password,
+		fromEmail: fromEmail,
+	}
+}
+
+// SendToEmail sends a plain text email to a specific recipient
+func (s *SMTPSender) SendToEmail(toEmail, subject, message string) error {
+	return s.SendToEmailWithHTML(toEmail, subject, message, "")
+}
+
+// SendToEmailWithHTML sends an email with both text and HTML content
+func (s *SMTPSender) SendToEmailWithHTML(toEmail, subject, textBody, htmlBody string) error {
+	_, err := s.SendEmail(toEmail, subject, textBody, htmlBody, nil)
+	return err
+}
+
+// SendEmail sends an email with both text and HTML content and the extra
+// headers, and returns its Message-ID
+func (s *SMTPSender) SendEmail(toEmail, subject, textBody, htmlBody string, headers map[string]string) (string, error) {
+	if s.host == "" {
+		return "", fmt.Errorf("SMTP host is not configured")
+	}
+	if s.fromEmail == "" {
+		return "", fmt.Errorf("sender email is not configured")
+	}
+	if toEmail == "" {
+		return "", fmt.Errorf("recipient email is required")
+	}
+
+	from, err := mail.ParseAddress(s.fromEmail)
+	if err != nil {
+		return "", fmt.Errorf("invalid sender email %q: %w", s.fromEmail, err)
+	}
+	to, err := mail.ParseAddress(toEmail)
+	if err != nil {
+		return "", fmt.Errorf("invalid recipient email %q: %w", toEmail, err)
+	}
+
+	if htmlBody == "" {
+		htmlBody = fmt.Sprintf("<p>%s</p>", strings.ReplaceAll(textBody, "\n", "<br>"))
+	}
+	msg, messageID, err := buildMIMEMessage(from, to, subject, textBody, htmlBody, headers)
+	if err != nil {
+		return "", err
+	}
+
+	if err := s.deliver(from.Address, to.Address, msg); err != nil {
+		return "", fmt.Errorf("failed to send email via SMTP: %w", err)
+	}
+
+	slog.Info("📧 Email sent via SMTP", "to", toEmail, "subject", subject)
+	return messageID, nil
+}
+
+// deliver runs one SMTP transaction
+func (s *SMTPSender) deliver(from, to string, msg []byte) error {
+	addr := net.JoinHostPort(s.host, strconv.Itoa(s.port))
+	tlsConfig := &tls.Config{ServerName: s.host}
+
+	var conn net.Conn
+	var err error
+	dialer := &net.Dialer{Timeout: 15 * time.Second}
+	if s.port == 465 {
+		conn, err = tls.DialWithDialer(dialer, "tcp", addr, tlsConfig)
+	} else {
+		conn, err = dialer.Dial("tcp", addr)
+	}
+	if err != nil {
+		return err
+	}
+	_ = conn.SetDeadline(time.Now().Add(30 * time.Second))
+
+	c, err := smtp.NewClient(conn, s.host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer c.Close()
+
+	if s.port != 465 {
+		if ok, _ := c.Extension("STARTTLS"); ok {
+			if err := c.StartTLS(tlsConfig); err != nil {
+				return fmt.Errorf("starttls: %w", err)
+			}
+		}
+	}
+	if s.username != "" {
+		// PlainAuth refuses to send credentials over an unencrypted connection (except to localhost)
+		if err := c.Auth(smtp.PlainAuth("", s.username, s.password, s.host)); err != nil {
+			return fmt.Errorf("auth: %w", err)
+		}
+	}
+	if err := c.Mail(from); err != nil {
+		return err
+	}
+	if err := c.Rcpt(to); err != nil {
+		return err
+	}
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(msg); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return c.Quit()
+}
+
+// buildMIMEMessage assembles a multipart/alternative message with quoted-printable
+// text and HTML parts and the extra headers, and returns it with its Message-ID
+func buildMIMEMessage(from, to *mail.Address, subject, textBody, htmlBody string, headers map[string]string) ([]byte, string, error) {
+	boundaryBytes := make([]byte, 12)
+	if _, err := rand.Read(boundaryBytes); err != nil {
+		return nil, "", fmt.Errorf("generate MIME boundary: %w", err)
+	}
+	boundary := "crypto-alert-" + hex.EncodeToString(boundaryBytes)
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "From: %s\r\n", from.String())
+	fmt.Fprintf(&buf, "To: %s\r\n", to.String())
+	fmt.Fprintf(&buf, "Subject: %s\r\n", mime.QEncoding.Encode("UTF-8", subject))
+	fmt.Fprintf(&buf, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	messageID := fmt.Sprintf("<%s@%s>", hex.EncodeToString(boundaryBytes), messageIDDomain(from.Address))
+	fmt.Fprintf(&buf, "Message-ID: %s\r\n", messageID)
+	for _, name := range slices.Sorted(maps.Keys(headers)) {
+		fmt.Fprintf(&buf, "%s: %s\r\n", name, headers[name])
+	}
+	buf.WriteString("MIME-Version: 1.0\r\n")
+	fmt.Fprintf(&buf, "Content-Type: multipart/alternative; boundary=%q\r\n\r\n", boundary)
+
+	for _, part := range []struct{ contentType, body string }{
+		{"text/plain", textBody},
+		{"text/html", htmlBody},
+	} {
+		fmt.Fprintf(&buf, "--%s\r\n", boundary)
+		fmt.Fprintf(&buf, "Content-Type: %s; charset=UTF-8\r\n", part.contentType)
+		buf.WriteString("Content-Transfer-Encoding: quoted-printable\r\n\r\n")
+		qp := quotedprintable.NewWriter(&buf)
+		if _, err := qp.Write([]byte(part.body)); err != nil {
+			return nil, "", fmt.Errorf("encode %s part: %w", part.contentType, err)
+		}
+		if err := qp.Close(); err != nil {
+			return nil, "", fmt.Errorf("encode %s part: %w", part.contentType, err)
+		}
+		buf.WriteString("\r\n")
+	}
+	fmt.Fprintf(&buf, "--%s--\r\n", boundary)
+	return buf.Bytes(), messageID, nil
+}
+
+func messageIDDomain(addr string) string {
+	if i := strings.LastIndex(addr, "@"); i >= 0 {
+		return addr[i+1:]
+	}
+	return "localhost"
+}
+
+// SendAlert sends an alert email using the formatted template
+func (s *SMTPSender) SendAlert(toEmail string, decision *core.AlertDecision) error {
+	subject, textBody, htmlBody := FormatAlertEmail(decision)
+	return s.SendToEmailWithHTML(toEmail, subject, textBody, htmlBody)
+}
+
+// SendDeFiAlert sends a DeFi alert email using the formatted template
+func (s *SMTPSender) SendDeFiAlert(toEmail string, decision *core.DeFiAlertDecision) error {
+	subject, textBody, htmlBody := FormatDeFiAlertEmail(decision)
+	return s.SendToEmailWithHTML(toEmail, subject, textBody, htmlBody)
+}
+
+// SendPredictMarketAlert sends a prediction market alert email using the formatted template
+func (s *SMTPSender) SendPredictMarketAlert(toEmail string, decision *core.PredictMarketAlertDecision) error {
+	subject, textBody, htmlBody := FormatPredictMarketAlertEmail(decision)
+	return s.SendToEmailWithHTML(toEmail, subject, textBody, htmlBody)
+}
+
+// SendWatchAlert sends a watch alert email using the formatted template
+func (s *SMTPSender) SendWatchAlert(toEmail string, decision *core.WatchAlertDecision) error {
+	subject, textBody, htmlBody := FormatWatchAlertEmail(decision)
+	return s.SendToEmailWithHTML(toEmail, subject, textBody, htmlBody)
+}
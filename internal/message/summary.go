@@ -0,0 +1,61 @@
+package message
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Subject returns the subject line of the daily summary
+func (e SummaryEvent) Subject() string {
+	subject := "Daily summary for " + e.Until.UTC().Format("2006-01-02")
+	switch {
+	case len(e.Fired) == 1:
+		subject += ": 1 rule fired"
+	case len(e.Fired) > 1:
+		subject += fmt.Sprintf(": %d rules fired", len(e.Fired))
+	default:
+		subject += ": no alerts"
+	}
+	return subject
+}
+
+// Text returns the body of the daily summary. Every section is listed, empty
+// ones included, so the summary also shows that monitoring is running.
+func (e SummaryEvent) Text() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Summary of %s to %s.\n",
+		e.Since.UTC().Format("2006-01-02 15:04"), e.Until.UTC().Format("2006-01-02 15:04 UTC"))
+
+	b.WriteString("\nPrices:\n")
+	writeSummaryValues(&b, e.Prices, "$")
+
+	b.WriteString("\nDeFi values:\n")
+	writeSummaryValues(&b, e.DeFiValues, "")
+
+	b.WriteString("\nRules fired in the last 24 hours:\n")
+	if len(e.Fired) == 0 {
+		b.WriteString("• none\n")
+	}
+	for _, r := range e.Fired {
+		fmt.Fprintf(&b, "• %s (%s rule %d), last at %s\n", r.Label, r.Kind, r.RuleID, r.LastTriggered.UTC().Format("15:04 UTC"))
+	}
+
+	b.WriteString("\nFeed errors:\n")
+	if len(e.FeedErrors) == 0 {
+		b.WriteString("• none\n")
+	}
+	for _, f := range e.FeedErrors {
+		fmt.Fprintf(&b, "• %s: %d error(s), last at %s: %s\n", f.Feed, f.Count, f.LastAt.UTC().Format("15:04 UTC"), f.LastError)
+	}
+	return b.String()
+}
+
+// writeSummaryValues writes one line per value, or "none" when there are none
+func writeSummaryValues(b *strings.Builder, values []SummaryValue, prefix string) {
+	if len(values) == 0 {
+		b.WriteString("• none\n")
+	}
+	for _, v := range values {
+		fmt.Fprintf(b, "• %s: %s%g (%s)\n", v.Label, prefix, v.Value, v.Time.UTC().Format("15:04 UTC"))
+	}
+}
@@ -0,0 +1,248 @@
+package message
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"crypto-alert/internal/core"
+)
+
+func init() {
+	RegisterChannel(ChannelTeams, func(func(string) string) (NotificationChannel, error) {
+		return senderChannel{ChannelTeams, NewTeamsSender()}, nil
+	})
+}
+
+// TeamsSender posts alerts as Adaptive Cards to Microsoft Teams incoming
+// webhooks (classic connectors or Workflows "post to a channel" webhooks).
+// The webhook URL identifies the channel, so it is configured per rule.
+type TeamsSender struct {
+	client *http.Client
+}
+
+func NewTeamsSender() *TeamsSender {
+	return &TeamsSender{
+		client: &http.Client{Timeout: 15 * time.Second},
+	}
+}
+
+// teamsFact is one row of an Adaptive Card FactSet
+type teamsFact struct {
+	Title string `json:"title"`
+	Value string `json:"value"`
+}
+
+// teamsCard holds the parts of an alert card
+type teamsCard struct {
+	Title   string
+	Color   string // Adaptive Card text color: attention | warning | good | accent
+	Summary string
+	Facts   []teamsFact
+	URL     string // Optional "View details" link
+}
+
+// payload wraps the card in the message envelope Teams webhooks expect.
+func (c teamsCard) payload() map[string]interface{} {
+	body := []map[string]interface{}{
+		{"type": "TextBlock", "text": c.Title, "weight": "Bolder", "size": "Large", "color": c.Color, "wrap": true},
+	}
+	if c.Summary != "" {
+		body = append(body, map[string]interface{}{"type": "TextBlock", "text": c.Summary, "wrap": true})
+	}
+	if len(c.Facts) > 0 {
+		body = append(body, map[string]interface{}{"type": "FactSet", "facts": c.Facts})
+	}
+
+	card := map[string]interface{}{
+		"$schema": "http://adaptivecards.io/schemas/adaptive-card.json",
+		"type":    "AdaptiveCard",
+		"version": "1.4",
+		"body":    body,
+		"msteams": map[string]string{"width": "Full"},
+	}
+	if c.URL != "" {
+		card["actions"] = []map[string]string{
+			{"type": "Action.OpenUrl", "title": "View details", "url": c.URL},
+		}
+	}
+	return map[string]interface{}{
+		"type": "message",
+		"attachments": []map[string]interface{}{
+			{"contentType": "application/vnd.microsoft.card.adaptive", "content": card},
+		},
+	}
+}
+
+// send posts a card to a Teams webhook URL.
+func (t *TeamsSender) send(webhookURL string, card teamsCard) error {
+	if webhookURL == "" {
+		return fmt.Errorf("teams webhook URL is required")
+	}
+
+	data, err := json.Marshal(card.payload())
+	if err != nil {
+		return fmt.Errorf("marshal teams payload: %w", err)
+	}
+
+	req, err := http.NewRequest("POST", webhookURL, bytes.NewBuffer(data))
+	if err != nil {
+		return fmt.Errorf("create teams request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("send teams message: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("teams webhook returned status %d: %s", resp.StatusCode, string(body))
+	}
+
+	slog.Info("📨 Teams card posted", "title", card.Title)
+	return nil
+}
+
+// SendNotice posts a card with a plain message.
+func (t *TeamsSender) SendNotice(webhookURL, subject, text string) error {
+	return t.send(webhookURL, teamsCard{Title: subject, Color: "warning", Summary: text})
+}
+
+// SendAlert posts a token price alert card.
+func (t *TeamsSender) SendAlert(webhookURL string, decision *core.AlertDecision) error {
+	if webhookURL == "" || decision == nil || decision.Rule == nil || decision.CurrentPrice == nil {
+		return nil
+	}
+	return t.send(webhookURL, teamsTokenAlertCard(decision).withMessageTemplate(decision.Rule.MessageTemplate, decision.TemplateData()))
+}
+
+// SendDeFiAlert posts a DeFi protocol alert card.
+func (t *TeamsSender) SendDeFiAlert(webhookURL string, decision *core.DeFiAlertDecision) error {
+	if webhookURL == "" || decision == nil || decision.Rule == nil {
+		return nil
+	}
+	return t.send(webhookURL, teamsDeFiAlertCard(decision).withMessageTemplate(decision.Rule.MessageTemplate, decision.TemplateData()))
+}
+
+// SendPredictMarketAlert posts a prediction market alert card.
+func (t *TeamsSender) SendPredictMarketAlert(webhookURL string, decision *core.PredictMarketAlertDecision) error {
+	if webhookURL == "" || decision == nil || decision.Rule == nil {
+		return nil
+	}
+	return t.send(webhookURL, teamsPredictMarketAlertCard(decision).withMessageTemplate(decision.Rule.MessageTemplate, decision.TemplateData()))
+}
+
+// SendWatchAlert posts a watch alert card.
+func (t *TeamsSender) SendWatchAlert(webhookURL string, decision *core.WatchAlertDecision) error {
+	if webhookURL == "" || decision == nil || decision.Rule == nil || decision.Observation == nil {
+		return nil
+	}
+	return t.send(webhookURL, teamsWatchAlertCard(decision).withMessageTemplate(decision.Rule.MessageTemplate, decision.TemplateData()))
+}
+
+func teamsTokenAlertCard(decision *core.AlertDecision) teamsCard {
+	r := decision.Rule
+	p := decision.CurrentPrice
+	return teamsCard{
+		Title:   fmt.Sprintf("%s %s Price Alert", telegramDirectionEmoji(string(r.Direction)), p.Symbol),
+		Color:   "attention",
+		Summary: decision.Message,
+		Facts: []teamsFact{
+			{"Current Price", fmt.Sprintf("$%g", p.Price)},
+			{"Threshold", fmt.Sprintf("$%g", r.Threshold)},
+			{"Condition", fmt.Sprintf("Price %s $%g", r.Direction, r.Threshold)},
+			{"Time", p.Timestamp.Format(time.RFC3339)},
+		},
+	}
+}
+
+func teamsDeFiAlertCard(decision *core.DeFiAlertDecision) teamsCard {
+	r := decision.Rule
+	valueStr, thresholdStr := formatDeFiValues(decision)
+
+	facts := []teamsFact{{"Chain", decision.ChainName}}
+	if marketInfo := telegramBuildMarketInfo(r); marketInfo != "" {
+		facts = append(facts, teamsFact{"Market", marketInfo})
+	}
+	if ens := formatENSNames(r.ENSNames); ens != "" {
+		facts = append(facts, teamsFact{"ENS", ens})
+	}
+	facts = append(facts,
+		teamsFact{"Field", r.Field},
+		teamsFact{"Current Value", valueStr},
+		teamsFact{"Threshold", thresholdStr},
+		teamsFact{"Condition", fmt.Sprintf("%s %s %s", r.Field, r.Direction, thresholdStr)},
+		teamsFact{"Time", time.Now().UTC().Format(time.RFC3339)},
+	)
+
+	return teamsCard{
+		Title:   fmt.Sprintf("%s %s %s Alert", telegramDirectionEmoji(string(r.Direction)), r.Protocol, r.Version),
+		Color:   "attention",
+		Summary: decision.Message,
+		Facts:   facts,
+	}
+}
+
+func teamsPredictMarketAlertCard(decision *core.PredictMarketAlertDecision) teamsCard {
+	r := decision.Rule
+	fieldLabel := predictFieldLabel(r)
+
+	facts := []teamsFact{
+		{"Question", r.Question},
+		{"Outcome", r.Outcome},
+	}
+	if r.Field != "" && r.Field != core.PredictFieldMidpoint {
+		facts = append(facts, teamsFact{fieldLabel, fmt.Sprintf("%.4f", decision.CurrentValue)})
+	}
+	facts = append(facts,
+		teamsFact{"Midpoint", fmt.Sprintf("%.4f", decision.CurrentMidpoint)},
+		teamsFact{"Buy Price", fmt.Sprintf("%.4f", decision.CurrentBuyPrice)},
+		teamsFact{"Sell Price", fmt.Sprintf("%.4f", decision.CurrentSellPrice)},
+	)
+	if h := formatPredictHistory(decision.History, decision.CurrentMidpoint); h != "" {
+		facts = append(facts, teamsFact{"History", h})
+	}
+	facts = append(facts,
+		teamsFact{"Threshold", fmt.Sprintf("%g", r.Threshold)},
+		teamsFact{"Condition", fmt.Sprintf("%s %s %g", fieldLabel, r.Direction, r.Threshold)},
+		teamsFact{"Time", time.Now().UTC().Format(time.RFC3339)},
+	)
+
+	return teamsCard{
+		Title:   fmt.Sprintf("%s %s Prediction Market Alert", telegramDirectionEmoji(string(r.Direction)), r.PredictMarket),
+		Color:   "warning",
+		Summary: decision.Message,
+		Facts:   facts,
+	}
+}
+
+func teamsWatchAlertCard(decision *core.WatchAlertDecision) teamsCard {
+	r := decision.Rule
+	o := decision.Observation
+
+	name := r.Label
+	if name == "" {
+		name = watchSourceName(r.Source)
+	}
+
+	facts := []teamsFact{{"Chain", decision.ChainName}}
+	for _, d := range o.Details {
+		facts = append(facts, teamsFact{d.Label, d.Value})
+	}
+	facts = append(facts, teamsFact{"Time", time.Now().UTC().Format(time.RFC3339)})
+
+	return teamsCard{
+		Title:   fmt.Sprintf("🔔 %s Alert: %s", watchSourceName(r.Source), name),
+		Color:   "accent",
+		Summary: o.Title,
+		Facts:   facts,
+		URL:     o.URL,
+	}
+}
@@ -6,41 +6,202 @@ import (
 	"fmt"
 	"html"
 	"io"
-	"log"
+	"log/slog"
+	"mime/multipart"
 	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
+	"unicode/utf8"
 
 	"crypto-alert/internal/core"
+	"crypto-alert/internal/logger"
 )
 
+func init() {
+	RegisterChannel(ChannelTelegram, func(getenv func(string) string) (NotificationChannel, error) {
+		token := getenv("TELEGRAM_BOT_TOKEN")
+		if token == "" {
+			return nil, nil
+		}
+		sender := NewTelegramSender(token)
+		sender.pacer.interval = envDurationOr(getenv, "TELEGRAM_CHAT_INTERVAL", DefaultTelegramChatInterval)
+		// Snooze / disable buttons on alerts, answered by the bot (see TelegramBot)
+		sender.alertButtons = getenv("TELEGRAM_ALERT_BUTTONS") == "true"
+		var err error
+		if sender.silentSeverities, err = parseSeverities(getenv("TELEGRAM_SILENT_SEVERITIES")); err != nil {
+			return nil, fmt.Errorf("TELEGRAM_SILENT_SEVERITIES: %w", err)
+		}
+		if sender.noPreviewSeverities, err = parseSeverities(getenv("TELEGRAM_NO_PREVIEW_SEVERITIES")); err != nil {
+			return nil, fmt.Errorf("TELEGRAM_NO_PREVIEW_SEVERITIES: %w", err)
+		}
+		return senderChannel{ChannelTelegram, sender}, nil
+	})
+}
+
 // TelegramSender sends alert notifications via the Telegram Bot API.
 type TelegramSender struct {
-	botToken string
-	client   *http.Client
+	botToken            string
+	client              *http.Client
+	alertButtons        bool            // Attach the snooze / disable keyboard to alerts
+	silentSeverities    []core.Severity // Alerts of these severities are sent without sound
+	noPreviewSeverities []core.Severity // Alerts of these severities don't show link previews
+	pacer               *telegramPacer
+}
+
+// DefaultTelegramChatInterval is the least time between two messages to one
+// chat; Telegram rate-limits bots that post faster than about one a second
+const DefaultTelegramChatInterval = time.Second
+
+// telegramPacer spaces the messages to each chat by interval, so a burst of
+// alerts is sent a message a second instead of running into the rate limit
+type telegramPacer struct {
+	interval time.Duration
+	mu       sync.Mutex
+	next     map[string]time.Time // Earliest time of the next message, by chat
+}
+
+// wait blocks until a message may be sent to chat and reserves that slot
+func (p *telegramPacer) wait(chat string) {
+	if p.interval <= 0 {
+		return
+	}
+	p.mu.Lock()
+	now := time.Now()
+	at := p.next[chat]
+	if at.Before(now) {
+		at = now
+	}
+	p.next[chat] = at.Add(p.interval)
+	if len(p.next) > 1000 {
+		for c, next := range p.next {
+			if next.Before(now) {
+				delete(p.next, c)
+			}
+		}
+	}
+	p.mu.Unlock()
+	time.Sleep(time.Until(at))
+}
+
+// telegramOptions are how a message is sent besides its text
+type telegramOptions struct {
+	format    core.TelegramFormat // Parse mode the HTML text is converted to; empty is HTML
+	silent    bool                // disable_notification
+	noPreview bool                // disable_web_page_preview
+	keyboard  *telegramKeyboard   // Inline keyboard, or nil
 }
 
 func NewTelegramSender(botToken string) *TelegramSender {
 	return &TelegramSender{
 		botToken: botToken,
 		client:   &http.Client{Timeout: 15 * time.Second},
+		pacer:    &telegramPacer{interval: DefaultTelegramChatInterval, next: map[string]time.Time{}},
 	}
 }
 
-// sendMessage posts an HTML-formatted message to a Telegram chat.
-func (t *TelegramSender) sendMessage(chatID, text string) error {
-	if t.botToken == "" {
-		return fmt.Errorf("telegram bot token is not configured")
+// SplitTelegramTopic splits a Telegram destination into its chat and forum
+// topic: -1001234567890/42 is topic 42 of that group. Destinations without a
+// topic return topic 0, which posts to the chat's general topic.
+func SplitTelegramTopic(to string) (chat string, topic int64) {
+	i := strings.LastIndex(to, "/")
+	if i < 0 {
+		return to, 0
+	}
+	topic, err := strconv.ParseInt(to[i+1:], 10, 64)
+	if err != nil || topic <= 0 {
+		return to, 0
+	}
+	return to[:i], topic
+}
+
+// telegramKeyboard is an inline keyboard attached to a message
+type telegramKeyboard struct {
+	InlineKeyboard [][]telegramButton `json:"inline_keyboard"`
+}
+
+// telegramButton is an inline keyboard button; pressing it sends the bot a
+// callback query with its data
+type telegramButton struct {
+	Text         string `json:"text"`
+	CallbackData string `json:"callback_data"`
+}
+
+// alertOptions returns how an alert of a rule with the given severity and
+// Telegram settings is sent. Its severity can make it silent or drop link
+// previews on top of the rule's own settings.
+func (t *TelegramSender) alertOptions(severity core.Severity, format core.TelegramFormat, silent, noPreview bool) telegramOptions {
+	if severity == "" {
+		severity = core.SeverityWarning
+	}
+	return telegramOptions{
+		format:    format,
+		silent:    silent || slices.Contains(t.silentSeverities, severity),
+		noPreview: noPreview || slices.Contains(t.noPreviewSeverities, severity),
 	}
+}
+
+// alertKeyboard returns the snooze / disable keyboard of an alert of the rule
+// of kind (token, defi, predict or watch), or nil when alerts have no buttons.
+// Alerts that escalate unless acknowledged get an Acknowledge button on top.
+// Rules from the JSON config have no ID and can't be changed, so they get none.
+func (t *TelegramSender) alertKeyboard(kind string, ruleID int64, ack bool) *telegramKeyboard {
+	if !t.alertButtons || ruleID == 0 {
+		return nil
+	}
+	data := func(action string) string {
+		return alertActionData(t.botToken, action, kind, ruleID)
+	}
+	var rows [][]telegramButton
+	if ack {
+		rows = append(rows, []telegramButton{{Text: "✅ Acknowledge", CallbackData: data("ack")}})
+	}
+	rows = append(rows,
+		[]telegramButton{{Text: "😴 Snooze 1h", CallbackData: data("snooze1h")}, {Text: "😴 Snooze 24h", CallbackData: data("snooze24h")}},
+		[]telegramButton{{Text: "⛔ Disable rule", CallbackData: data("disable")}},
+	)
+	return &telegramKeyboard{InlineKeyboard: rows}
+}
+
+// escalates reports whether an alert of a rule with the given severity and
+// escalation delay is escalated unless acknowledged
+func escalates(severity core.Severity, after time.Duration) bool {
+	return severity == core.SeverityCritical && after > 0
+}
+
+// telegramCaptionLimit is the longest photo caption Telegram accepts, in characters
+const telegramCaptionLimit = 1024
+
+// sendMessage posts an HTML-formatted message to a Telegram chat, converted
+// to the format of opts.
+func (t *TelegramSender) sendMessage(chatID, text string, opts telegramOptions) error {
 	if chatID == "" {
 		return fmt.Errorf("telegram chat ID is required")
 	}
 
-	apiURL := fmt.Sprintf("https://api.telegram.org/bot%s/sendMessage", t.botToken)
-
+	chat, topic := SplitTelegramTopic(chatID)
+	t.pacer.wait(chat)
+	text, parseMode := telegramFormatText(opts.format, text)
 	payload := map[string]interface{}{
-		"chat_id":    chatID,
-		"text":       text,
-		"parse_mode": "HTML",
+		"chat_id": chat,
+		"text":    text,
+	}
+	if topic != 0 {
+		payload["message_thread_id"] = topic
+	}
+	if parseMode != "" {
+		payload["parse_mode"] = parseMode
+	}
+	if opts.silent {
+		payload["disable_notification"] = true
+	}
+	if opts.noPreview {
+		payload["disable_web_page_preview"] = true
+	}
+	if opts.keyboard != nil {
+		payload["reply_markup"] = opts.keyboard
 	}
 
 	data, err := json.Marshal(payload)
@@ -48,25 +209,137 @@ func (t *TelegramSender) sendMessage(chatID, text string) error {
 		return fmt.Errorf("marshal telegram payload: %w", err)
 	}
 
-	req, err := http.NewRequest("POST", apiURL, bytes.NewBuffer(data))
+	if _, err := t.call("sendMessage", "application/json", bytes.NewBuffer(data)); err != nil {
+		return fmt.Errorf("send telegram message: %w", err)
+	}
+	slog.Info("📨 Telegram message sent", "chat_id", chatID)
+	return nil
+}
+
+// sendPhoto uploads a PNG image to a Telegram chat with an optional
+// HTML-formatted caption, converted to the format of opts.
+func (t *TelegramSender) sendPhoto(chatID string, png []byte, caption string, opts telegramOptions) error {
+	if chatID == "" {
+		return fmt.Errorf("telegram chat ID is required")
+	}
+
+	chat, topic := SplitTelegramTopic(chatID)
+	t.pacer.wait(chat)
+	var body bytes.Buffer
+	w := multipart.NewWriter(&body)
+	w.WriteField("chat_id", chat)
+	if topic != 0 {
+		w.WriteField("message_thread_id", strconv.FormatInt(topic, 10))
+	}
+	if caption != "" {
+		caption, parseMode := telegramFormatText(opts.format, caption)
+		w.WriteField("caption", caption)
+		if parseMode != "" {
+			w.WriteField("parse_mode", parseMode)
+		}
+	}
+	if opts.silent {
+		w.WriteField("disable_notification", "true")
+	}
+	if opts.keyboard != nil {
+		markup, _ := json.Marshal(opts.keyboard)
+		w.WriteField("reply_markup", string(markup))
+	}
+	part, err := w.CreateFormFile("photo", "chart.png")
+	if err != nil {
+		return fmt.Errorf("create telegram photo form: %w", err)
+	}
+	part.Write(png)
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("create telegram photo form: %w", err)
+	}
+
+	if _, err := t.call("sendPhoto", w.FormDataContentType(), &body); err != nil {
+		return fmt.Errorf("send telegram photo: %w", err)
+	}
+	slog.Info("📨 Telegram chart sent", "chat_id", chatID)
+	return nil
+}
+
+// sendWithChart sends an alert message with a chart of points attached. When
+// the chart can't be rendered the message is sent on its own, and a message
+// too long for a photo caption is sent before the chart. The keyboard of opts
+// goes on the last message.
+func (t *TelegramSender) sendWithChart(chatID, text string, opts telegramOptions, points []core.HistoryPoint, threshold float64) error {
+	png, err := renderChartPNG(t.client, points, threshold)
+	if err != nil {
+		slog.Warn("⚠️  Telegram chart failed, sending the alert without it", "chat_id", chatID, logger.FieldError, err)
+	}
+	if png == nil {
+		return t.sendMessage(chatID, text, opts)
+	}
+	if utf8.RuneCountInString(text) > telegramCaptionLimit {
+		first := opts
+		first.keyboard = nil
+		if err := t.sendMessage(chatID, text, first); err != nil {
+			return err
+		}
+		text = ""
+	}
+	return t.sendPhoto(chatID, png, text, opts)
+}
+
+// call posts a request body to a Bot API method and returns the result.
+func (t *TelegramSender) call(method, contentType string, body io.Reader) (json.RawMessage, error) {
+	if t.botToken == "" {
+		return nil, fmt.Errorf("telegram bot token is not configured")
+	}
+
+	apiURL := fmt.Sprintf("https://api.telegram.org/bot%s/%s", t.botToken, method)
+	req, err := http.NewRequest("POST", apiURL, body)
 	if err != nil {
-		return fmt.Errorf("create telegram request: %w", err)
+		return nil, fmt.Errorf("create telegram request: %w", err)
 	}
-	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Content-Type", contentType)
 
 	resp, err := t.client.Do(req)
 	if err != nil {
-		return fmt.Errorf("send telegram message: %w", err)
+		return nil, err
 	}
 	defer resp.Body.Close()
 
-	body, _ := io.ReadAll(resp.Body)
+	respBody, _ := io.ReadAll(resp.Body)
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		return fmt.Errorf("telegram API returned status %d: %s", resp.StatusCode, string(body))
+		err := fmt.Errorf("telegram API returned status %d: %s", resp.StatusCode, string(respBody))
+		switch {
+		case resp.StatusCode == http.StatusTooManyRequests:
+			var apiErr struct {
+				Parameters struct {
+					RetryAfter int `json:"retry_after"`
+				} `json:"parameters"`
+			}
+			_ = json.Unmarshal(respBody, &apiErr)
+			return nil, &RetryAfterError{Err: err, After: time.Duration(apiErr.Parameters.RetryAfter) * time.Second}
+		case resp.StatusCode >= 400 && resp.StatusCode < 500:
+			// e.g. chat not found or the bot was blocked: resending won't help
+			return nil, &PermanentError{Err: err}
+		}
+		return nil, err
 	}
+	var result struct {
+		Result json.RawMessage `json:"result"`
+	}
+	_ = json.Unmarshal(respBody, &result)
+	return result.Result, nil
+}
 
-	log.Printf("📨 Telegram message sent to chat %s", chatID)
-	return nil
+// callJSON posts a JSON payload to a Bot API method and returns the result.
+func (t *TelegramSender) callJSON(method string, payload interface{}) (json.RawMessage, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("marshal telegram %s payload: %w", method, err)
+	}
+	return t.call(method, "application/json", bytes.NewReader(data))
+}
+
+// SendNotice sends a plain message to the specified Telegram chat.
+func (t *TelegramSender) SendNotice(chatID, subject, text string) error {
+	return t.sendMessage(chatID, "<b>"+html.EscapeString(subject)+"</b>\n\n"+html.EscapeString(text), telegramOptions{})
 }
 
 // SendAlert sends a token price alert to the specified Telegram chat.
@@ -74,7 +347,14 @@ func (t *TelegramSender) SendAlert(chatID string, decision *core.AlertDecision)
 	if chatID == "" || decision == nil || decision.Rule == nil || decision.CurrentPrice == nil {
 		return nil
 	}
-	return t.sendMessage(chatID, formatTokenAlertTelegram(decision))
+	r := decision.Rule
+	opts := t.alertOptions(r.Severity, r.TelegramFormat, r.TelegramSilent, r.TelegramNoPreview)
+	opts.keyboard = t.alertKeyboard("token", r.ID, escalates(r.Severity, r.EscalateAfter))
+	text := withChatSettings(chatID, r.Severity, styleTelegram(r.Severity, formatTokenAlertTelegram(decision)))
+	if r.TelegramChart {
+		return t.sendWithChart(chatID, text, opts, tokenChartPoints(decision), r.Threshold)
+	}
+	return t.sendMessage(chatID, text, opts)
 }
 
 // SendDeFiAlert sends a DeFi protocol alert to the specified Telegram chat.
@@ -82,7 +362,14 @@ func (t *TelegramSender) SendDeFiAlert(chatID string, decision *core.DeFiAlertDe
 	if chatID == "" || decision == nil || decision.Rule == nil {
 		return nil
 	}
-	return t.sendMessage(chatID, formatDeFiAlertTelegram(decision))
+	r := decision.Rule
+	opts := t.alertOptions(r.Severity, r.TelegramFormat, r.TelegramSilent, r.TelegramNoPreview)
+	opts.keyboard = t.alertKeyboard("defi", r.ID, escalates(r.Severity, r.EscalateAfter))
+	text := withChatSettings(chatID, r.Severity, styleTelegram(r.Severity, formatDeFiAlertTelegram(decision)))
+	if r.TelegramChart {
+		return t.sendWithChart(chatID, text, opts, defiChartPoints(decision), r.Threshold)
+	}
+	return t.sendMessage(chatID, text, opts)
 }
 
 // SendPredictMarketAlert sends a prediction market alert to the specified Telegram chat.
@@ -90,10 +377,32 @@ func (t *TelegramSender) SendPredictMarketAlert(chatID string, decision *core.Pr
 	if chatID == "" || decision == nil || decision.Rule == nil {
 		return nil
 	}
-	return t.sendMessage(chatID, formatPredictMarketAlertTelegram(decision))
+	r := decision.Rule
+	opts := t.alertOptions(r.Severity, r.TelegramFormat, r.TelegramSilent, r.TelegramNoPreview)
+	opts.keyboard = t.alertKeyboard("predict", r.ID, escalates(r.Severity, r.EscalateAfter))
+	text := withChatSettings(chatID, r.Severity, styleTelegram(r.Severity, formatPredictMarketAlertTelegram(decision)))
+	return t.sendMessage(chatID, text, opts)
+}
+
+// SendWatchAlert sends a watch alert (Safe multisig, ...) to the specified Telegram chat.
+func (t *TelegramSender) SendWatchAlert(chatID string, decision *core.WatchAlertDecision) error {
+	if chatID == "" || decision == nil || decision.Rule == nil || decision.Observation == nil {
+		return nil
+	}
+	r := decision.Rule
+	opts := t.alertOptions(r.Severity, r.TelegramFormat, r.TelegramSilent, r.TelegramNoPreview)
+	opts.keyboard = t.alertKeyboard("watch", r.ID, escalates(r.Severity, r.EscalateAfter))
+	text := withChatSettings(chatID, r.Severity, styleTelegram(r.Severity, formatWatchAlertTelegram(decision)))
+	return t.sendMessage(chatID, text, opts)
 }
 
 func formatTokenAlertTelegram(decision *core.AlertDecision) string {
+	if text, ok := telegramMessageTemplate(decision.Rule.MessageTemplate, decision.TemplateData()); ok {
+		return text
+	}
+	if a, ok := localizeTokenAlert(decision); ok {
+		return a.telegram()
+	}
 	r := decision.Rule
 	p := decision.CurrentPrice
 	emoji := telegramDirectionEmoji(string(r.Direction))
@@ -114,27 +423,17 @@ func formatTokenAlertTelegram(decision *core.AlertDecision) string {
 }
 
 func formatDeFiAlertTelegram(decision *core.DeFiAlertDecision) string {
+	if text, ok := telegramMessageTemplate(decision.Rule.MessageTemplate, decision.TemplateData()); ok {
+		return text
+	}
+	if a, ok := localizeDeFiAlert(decision); ok {
+		return a.telegram()
+	}
 	r := decision.Rule
 	emoji := telegramDirectionEmoji(string(r.Direction))
 	dir := html.EscapeString(string(r.Direction))
 
-	var valueStr, thresholdStr string
-	if r.Field == "TVL" {
-		formatted, approx := formatLargeNumber(decision.CurrentValue)
-		if approx != "" {
-			valueStr = fmt.Sprintf("%s (%s)", formatted, approx)
-		} else {
-			valueStr = formatted
-		}
-		thresholdFormatted, _ := formatLargeNumber(r.Threshold)
-		thresholdStr = thresholdFormatted
-	} else if r.Field == "APY" || r.Field == "UTILIZATION" {
-		valueStr = fmt.Sprintf("%g%%", decision.CurrentValue)
-		thresholdStr = fmt.Sprintf("%g%%", r.Threshold)
-	} else {
-		valueStr = fmt.Sprintf("%g", decision.CurrentValue)
-		thresholdStr = fmt.Sprintf("%g", r.Threshold)
-	}
+	valueStr, thresholdStr := formatDeFiValues(decision)
 
 	msg := fmt.Sprintf(
 		"🚨 <b>DeFi Alert Triggered</b>\n\n"+
@@ -145,6 +444,9 @@ func formatDeFiAlertTelegram(decision *core.DeFiAlertDecision) string {
 	if marketInfo := telegramBuildMarketInfo(r); marketInfo != "" {
 		msg += fmt.Sprintf("<b>Market:</b> %s\n", marketInfo)
 	}
+	if ens := formatENSNames(r.ENSNames); ens != "" {
+		msg += fmt.Sprintf("<b>ENS:</b> %s\n", html.EscapeString(ens))
+	}
 
 	msg += fmt.Sprintf(
 		"<b>Field:</b> %s\n"+
@@ -162,32 +464,105 @@ func formatDeFiAlertTelegram(decision *core.DeFiAlertDecision) string {
 }
 
 func formatPredictMarketAlertTelegram(decision *core.PredictMarketAlertDecision) string {
+	if text, ok := telegramMessageTemplate(decision.Rule.MessageTemplate, decision.TemplateData()); ok {
+		return text
+	}
+	if a, ok := localizePredictMarketAlert(decision); ok {
+		return a.telegram()
+	}
 	r := decision.Rule
 	emoji := telegramDirectionEmoji(string(r.Direction))
 	dir := html.EscapeString(string(r.Direction))
+	fieldLabel := html.EscapeString(predictFieldLabel(r))
+	var fieldValue string
+	if r.Field != "" && r.Field != core.PredictFieldMidpoint {
+		fieldValue = fmt.Sprintf("<b>%s:</b> %.4f\n", fieldLabel, decision.CurrentValue)
+	}
+	var history string
+	if h := formatPredictHistory(decision.History, decision.CurrentMidpoint); h != "" {
+		history = fmt.Sprintf("<b>History:</b> %s\n", html.EscapeString(h))
+	}
 	return fmt.Sprintf(
 		"🚨 <b>Prediction Market Alert</b>\n\n"+
 			"%s <b>%s</b>\n\n"+
 			"<b>Question:</b> %s\n"+
 			"<b>Outcome:</b> %s\n\n"+
+			"%s"+
 			"<b>Midpoint:</b> %.4f\n"+
 			"<b>Buy Price:</b> %.4f\n"+
 			"<b>Sell Price:</b> %.4f\n"+
+			"%s"+
 			"<b>Threshold:</b> %g\n"+
-			"<b>Condition:</b> Midpoint %s %g\n"+
+			"<b>Condition:</b> %s %s %g\n"+
 			"<b>Time:</b> %s",
 		emoji, r.PredictMarket,
 		r.Question,
 		r.Outcome,
+		fieldValue,
 		decision.CurrentMidpoint,
 		decision.CurrentBuyPrice,
 		decision.CurrentSellPrice,
+		history,
 		r.Threshold,
-		dir, r.Threshold,
+		fieldLabel, dir, r.Threshold,
 		time.Now().UTC().Format(time.RFC3339),
 	)
 }
 
+func formatWatchAlertTelegram(decision *core.WatchAlertDecision) string {
+	if text, ok := telegramMessageTemplate(decision.Rule.MessageTemplate, decision.TemplateData()); ok {
+		return text
+	}
+	if a, ok := localizeWatchAlert(decision); ok {
+		return a.telegram()
+	}
+	r := decision.Rule
+	o := decision.Observation
+
+	name := r.Label
+	if name == "" {
+		name = watchSourceName(r.Source)
+	}
+
+	msg := fmt.Sprintf(
+		"🔔 <b>%s Alert</b>\n\n"+
+			"<b>%s</b> on %s\n"+
+			"%s\n\n",
+		html.EscapeString(watchSourceName(r.Source)),
+		html.EscapeString(name), html.EscapeString(decision.ChainName),
+		html.EscapeString(o.Title),
+	)
+	for _, d := range o.Details {
+		msg += fmt.Sprintf("<b>%s:</b> %s\n", html.EscapeString(d.Label), html.EscapeString(d.Value))
+	}
+	if o.URL != "" {
+		msg += fmt.Sprintf("<a href=\"%s\">View details</a>\n", html.EscapeString(o.URL))
+	}
+	msg += fmt.Sprintf("<b>Time:</b> %s", time.Now().UTC().Format(time.RFC3339))
+	return msg
+}
+
+// formatDeFiValues formats a DeFi alert's current value and threshold for its field.
+func formatDeFiValues(decision *core.DeFiAlertDecision) (valueStr, thresholdStr string) {
+	r := decision.Rule
+	if r.Field == "TVL" {
+		formatted, approx := formatLargeNumber(decision.CurrentValue)
+		if approx != "" {
+			valueStr = fmt.Sprintf("%s (%s)", formatted, approx)
+		} else {
+			valueStr = formatted
+		}
+		thresholdStr, _ = formatLargeNumber(r.Threshold)
+	} else if r.Field == "APY" || r.Field == "UTILIZATION" {
+		valueStr = fmt.Sprintf("%g%%", decision.CurrentValue)
+		thresholdStr = fmt.Sprintf("%g%%", r.Threshold)
+	} else {
+		valueStr = fmt.Sprintf("%g", decision.CurrentValue)
+		thresholdStr = fmt.Sprintf("%g", r.Threshold)
+	}
+	return valueStr, thresholdStr
+}
+
 // telegramBuildMarketInfo returns a human-readable market/vault identifier string.
 func telegramBuildMarketInfo(r *core.DeFiAlertRule) string {
 	if r.Protocol == "aave" && r.MarketTokenName != "" {
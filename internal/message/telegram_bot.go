@@ -0,0 +1,563 @@
+package message
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"html"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"crypto-alert/internal/core"
+	"crypto-alert/internal/logger"
+)
+
+// TelegramUpdate is the part of a Bot API update the bot handles
+type TelegramUpdate struct {
+	UpdateID      int64                  `json:"update_id"`
+	Message       *TelegramMessage       `json:"message,omitempty"`
+	CallbackQuery *TelegramCallbackQuery `json:"callback_query,omitempty"`
+}
+
+// TelegramMessage is a message sent to the bot
+type TelegramMessage struct {
+	Chat struct {
+		ID        int64  `json:"id"`
+		Type      string `json:"type"` // private, group, supergroup or channel
+		Title     string `json:"title"`
+		Username  string `json:"username"`
+		FirstName string `json:"first_name"`
+	} `json:"chat"`
+	From *struct {
+		Username  string `json:"username"`
+		FirstName string `json:"first_name"`
+	} `json:"from,omitempty"`
+	MessageThreadID int64  `json:"message_thread_id"`
+	IsTopicMessage  bool   `json:"is_topic_message"`
+	Text            string `json:"text"`
+}
+
+// destination returns the Telegram destination of the message's chat, with
+// its forum topic when it was posted in one
+func (m *TelegramMessage) destination() string {
+	chatID := strconv.FormatInt(m.Chat.ID, 10)
+	if m.IsTopicMessage && m.MessageThreadID != 0 {
+		chatID += "/" + strconv.FormatInt(m.MessageThreadID, 10)
+	}
+	return chatID
+}
+
+// TelegramCallbackQuery is a press of an inline keyboard button
+type TelegramCallbackQuery struct {
+	ID   string `json:"id"`
+	From struct {
+		Username  string `json:"username"`
+		FirstName string `json:"first_name"`
+	} `json:"from"`
+	Message *TelegramMessage `json:"message,omitempty"` // The message with the button
+	Data    string           `json:"data"`
+}
+
+// TelegramRegistry stores the chats that registered with the bot and the
+// prefix and mentions chats set for their alerts
+type TelegramRegistry interface {
+	Register(token, chatID, name string) error
+	ChatIDs(token string) ([]string, error)
+	SetPrefix(chatID, prefix string) error
+	SetMentions(chatID string, mentions []string, severity core.Severity) error
+}
+
+// TelegramRuleActions changes the state of the rule of kind (token, defi,
+// predict or watch) with the given ID, or acknowledges its pending
+// escalation, and lists the rules for /rules
+type TelegramRuleActions interface {
+	Snooze(kind string, ruleID int64, d time.Duration, by string) error
+	Disable(kind string, ruleID int64, by string) error
+	Acknowledge(kind string, ruleID int64, by string) (bool, error)
+	List(kind, tag string) ([]core.RuleSummary, error)
+}
+
+// TelegramMutes stores the mute windows set with /mute
+type TelegramMutes interface {
+	Create(m core.Mute, d time.Duration) (int64, error)
+	Active() ([]core.Mute, error)
+	End(id int64) (int64, error)
+}
+
+// TelegramBot answers the commands users send the alert bot and the buttons
+// they press on alerts. /start <token> [code] registers the chat under token
+// (e.g. the user's email address), so rules can name the token instead of the
+// numeric chat ID; see RequireRegistrationCodes. /prefix and /mentions set what the chat adds to its alerts
+// (see TelegramChatSettings), and admin chats can /mute notifications and
+// list the /rules.
+type TelegramBot struct {
+	sender     *TelegramSender
+	registry   TelegramRegistry
+	rules      TelegramRuleActions
+	mutes      TelegramMutes
+	adminChats []string // Chat IDs allowed to /mute, /unmute and /rules
+
+	registrationSecret string // Signs the registration codes; empty registers each token first come, first served
+}
+
+// alertSnoozes are the snooze buttons of alerts, by callback action
+var alertSnoozes = map[string]time.Duration{
+	"snooze1h":  time.Hour,
+	"snooze24h": 24 * time.Hour,
+}
+
+// telegramPollTimeout is how long a getUpdates long poll waits for updates
+const telegramPollTimeout = 50 * time.Second
+
+// NewTelegramBot creates the bot for botToken, storing registrations in
+// registry and applying the alert buttons with rules
+func NewTelegramBot(botToken string, registry TelegramRegistry, rules TelegramRuleActions) *TelegramBot {
+	sender := NewTelegramSender(botToken)
+	sender.client = &http.Client{Timeout: telegramPollTimeout + 15*time.Second}
+	return &TelegramBot{sender: sender, registry: registry, rules: rules}
+}
+
+// EnableMutes lets the chats in adminChats, a comma-separated list of chat
+// IDs, mute notifications with /mute, /unmute and /mutes, stored in mutes,
+// and list the rules with /rules
+func (b *TelegramBot) EnableMutes(mutes TelegramMutes, adminChats string) {
+	b.mutes, b.adminChats = mutes, splitRecipients(adminChats)
+}
+
+// RequireRegistrationCodes makes /start register a chat only with the token's
+// registration code, TelegramRegistrationCode of secret, which proves the
+// token was given to whoever sends it. Without it a token can only be
+// registered by the first chat that claims it.
+func (b *TelegramBot) RequireRegistrationCodes(secret string) {
+	b.registrationSecret = secret
+}
+
+// TelegramRegistrationCode returns the code that registers chats under token
+// with /start <token> <code>: an HMAC-SHA256 of the token keyed with secret
+func TelegramRegistrationCode(secret, token string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte("telegram:" + token))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil)[:16])
+}
+
+// IsTelegramChatID reports whether a rule's Telegram destination is a chat ID
+// (e.g. -1001234567890, or -1001234567890/42 for a forum topic) or a public
+// @channel rather than a registration token
+func IsTelegramChatID(to string) bool {
+	to, _ = SplitTelegramTopic(to)
+	if strings.HasPrefix(to, "@") {
+		return true
+	}
+	_, err := strconv.ParseInt(to, 10, 64)
+	return err == nil
+}
+
+// HandleUpdate answers one update. Updates other than bot commands and
+// button presses are ignored.
+func (b *TelegramBot) HandleUpdate(u TelegramUpdate) error {
+	if u.CallbackQuery != nil {
+		return b.alertAction(u.CallbackQuery)
+	}
+	m := u.Message
+	if m == nil {
+		return nil
+	}
+	command, arg, _ := strings.Cut(strings.TrimSpace(m.Text), " ")
+	command, _, _ = strings.Cut(command, "@") // /start@alert_bot in groups
+	switch command {
+	case "/start":
+		return b.start(m, strings.Fields(arg))
+	case "/prefix":
+		return b.prefix(m, strings.TrimSpace(arg))
+	case "/mentions":
+		return b.mentions(m, strings.Fields(arg))
+	case "/mute", "/unmute", "/mutes":
+		return b.mute(m, command, strings.Fields(arg))
+	case "/rules":
+		return b.listRules(m, strings.Fields(arg))
+	}
+	return nil
+}
+
+// start registers the chat under the token of args and confirms it, or only
+// tells the chat its ID when there is no token. With registration codes
+// required, the token's code must follow it; without, a token registered by
+// another chat is refused. Sent in a forum topic, it registers the topic and
+// the ID includes it.
+func (b *TelegramBot) start(m *TelegramMessage, args []string) error {
+	chatID := m.destination()
+	where := "chat"
+	if _, topic := SplitTelegramTopic(chatID); topic != 0 {
+		where = "topic"
+	}
+	if len(args) == 0 {
+		return b.sender.sendMessage(chatID, fmt.Sprintf(
+			"👋 This %s's ID is <code>%s</code>. Use it as <code>telegram_chat_id</code> in your alert rules, or send <code>/start &lt;your email&gt;</code> to register this %s and use your email instead.",
+			where, chatID, where), telegramOptions{})
+	}
+	token, code := args[0], ""
+	if len(args) > 1 {
+		code = args[1]
+	}
+	if IsTelegramChatID(token) || len(token) > 255 || len(args) > 2 {
+		return b.sender.sendMessage(chatID, "⚠️ Use your email address or the token you were given, e.g. <code>/start alice@example.com</code>.", telegramOptions{})
+	}
+	if b.registrationSecret != "" {
+		if !hmac.Equal([]byte(code), []byte(TelegramRegistrationCode(b.registrationSecret, token))) {
+			slog.Info("🤖 Telegram chat sent a wrong registration code", "chat_id", chatID)
+			return b.sender.sendMessage(chatID, fmt.Sprintf(
+				"⚠️ Registering needs the code you were given with your token: <code>/start %s &lt;code&gt;</code>.", html.EscapeString(token)), telegramOptions{})
+		}
+	} else {
+		registered, err := b.registry.ChatIDs(token)
+		if err != nil {
+			b.sender.sendMessage(chatID, "❌ Registration failed, please try again later.", telegramOptions{})
+			return fmt.Errorf("look up telegram chats of a token: %w", err)
+		}
+		for _, id := range registered {
+			if chat, _ := SplitTelegramTopic(id); chat != strconv.FormatInt(m.Chat.ID, 10) {
+				slog.Info("🤖 Telegram chat tried to register a token another chat has", "chat_id", chatID)
+				return b.sender.sendMessage(chatID, "⚠️ This token is already registered by another chat. Ask your administrator to add this one.", telegramOptions{})
+			}
+		}
+	}
+
+	name := m.Chat.Title
+	if name == "" {
+		name = strings.TrimSpace(m.Chat.FirstName + " @" + m.Chat.Username)
+	}
+	if err := b.registry.Register(token, chatID, name); err != nil {
+		b.sender.sendMessage(chatID, "❌ Registration failed, please try again later.", telegramOptions{})
+		return fmt.Errorf("register telegram chat %s: %w", chatID, err)
+	}
+	slog.Info("🤖 Telegram chat registered", "chat_id", chatID)
+	return b.sender.sendMessage(chatID, fmt.Sprintf(
+		"✅ This %s is registered for <b>%s</b>. Alerts of rules with <code>telegram_chat_id</code> set to it now come here. (ID: <code>%s</code>)",
+		where, html.EscapeString(token), chatID), telegramOptions{})
+}
+
+// prefix sets the text prepended to the chat's alerts, e.g. /prefix [prod];
+// without text it removes the prefix
+func (b *TelegramBot) prefix(m *TelegramMessage, prefix string) error {
+	chatID := m.destination()
+	if utf8.RuneCountInString(prefix) > 255 {
+		return b.sender.sendMessage(chatID, "⚠️ The prefix can be at most 255 characters.", telegramOptions{})
+	}
+	if err := b.registry.SetPrefix(chatID, prefix); err != nil {
+		b.sender.sendMessage(chatID, "❌ The prefix couldn't be saved, please try again later.", telegramOptions{})
+		return fmt.Errorf("set telegram prefix of %s: %w", chatID, err)
+	}
+	slog.Info("🤖 Telegram chat prefix set", "chat_id", chatID)
+	if prefix == "" {
+		return b.sender.sendMessage(chatID, "✅ Alerts here no longer have a prefix.", telegramOptions{})
+	}
+	return b.sender.sendMessage(chatID, "✅ Alerts here now start with <b>"+html.EscapeString(prefix)+"</b>", telegramOptions{})
+}
+
+// mentions sets the users tagged on the chat's alerts, e.g. /mentions @alice
+// @bob for critical alerts or /mentions warning @alice for warnings and above;
+// without users it removes the mentions
+func (b *TelegramBot) mentions(m *TelegramMessage, args []string) error {
+	chatID := m.destination()
+	severity := core.SeverityCritical
+	if len(args) > 0 {
+		if _, ok := severityRank[core.Severity(strings.ToLower(args[0]))]; ok {
+			severity = core.Severity(strings.ToLower(args[0]))
+			args = args[1:]
+		}
+	}
+	for _, u := range args {
+		if len(u) < 2 || !strings.HasPrefix(u, "@") {
+			return b.sender.sendMessage(chatID, "⚠️ Usage: <code>/mentions [info|warning|critical] @user1 @user2</code>, or <code>/mentions</code> alone to stop mentioning anyone.", telegramOptions{})
+		}
+	}
+	if len(strings.Join(args, " ")) > 512 {
+		return b.sender.sendMessage(chatID, "⚠️ Too many mentions, they can be at most 512 characters.", telegramOptions{})
+	}
+	if err := b.registry.SetMentions(chatID, args, severity); err != nil {
+		b.sender.sendMessage(chatID, "❌ The mentions couldn't be saved, please try again later.", telegramOptions{})
+		return fmt.Errorf("set telegram mentions of %s: %w", chatID, err)
+	}
+	slog.Info("🤖 Telegram chat mentions set", "chat_id", chatID)
+	if len(args) == 0 {
+		return b.sender.sendMessage(chatID, "✅ Alerts here no longer mention anyone.", telegramOptions{})
+	}
+	return b.sender.sendMessage(chatID, fmt.Sprintf("✅ %s will be mentioned on <b>%s</b> alerts and above.",
+		html.EscapeString(strings.Join(args, " ")), severity), telegramOptions{})
+}
+
+// mute answers /mute <duration> [kind] [subject] [#tag], e.g. /mute 2h,
+// /mute 30m defi aave or /mute 1d #degen, /unmute [id] and /mutes in admin
+// chats. The mute's summary goes
+// to the chat that set it.
+func (b *TelegramBot) mute(m *TelegramMessage, command string, args []string) error {
+	chatID := m.destination()
+	if b.mutes == nil || !slices.Contains(b.adminChats, strconv.FormatInt(m.Chat.ID, 10)) {
+		return b.sender.sendMessage(chatID, "⚠️ Only admin chats can mute notifications (<code>TELEGRAM_ADMIN_CHATS</code>).", telegramOptions{})
+	}
+
+	switch command {
+	case "/mutes":
+		active, err := b.mutes.Active()
+		if err != nil {
+			return fmt.Errorf("list mutes: %w", err)
+		}
+		if len(active) == 0 {
+			return b.sender.sendMessage(chatID, "🔊 Nothing is muted.", telegramOptions{})
+		}
+		var lines []string
+		for _, mute := range active {
+			lines = append(lines, fmt.Sprintf("#%d %s until %s (%d suppressed)", mute.ID, mute.Scope(), mute.Until.UTC().Format("Jan 2 15:04 MST"), mute.Suppressed))
+		}
+		return b.sender.sendMessage(chatID, "🔇 Active mutes:\n"+html.EscapeString(strings.Join(lines, "\n")), telegramOptions{})
+	case "/unmute":
+		var id int64
+		if len(args) > 0 {
+			var err error
+			if id, err = strconv.ParseInt(strings.TrimPrefix(args[0], "#"), 10, 64); err != nil || id <= 0 {
+				return b.sender.sendMessage(chatID, "⚠️ Usage: <code>/unmute</code> to end every mute, or <code>/unmute &lt;id&gt;</code> (see <code>/mutes</code>).", telegramOptions{})
+			}
+		}
+		ended, err := b.mutes.End(id)
+		if err != nil {
+			b.sender.sendMessage(chatID, "❌ Unmuting failed, please try again later.", telegramOptions{})
+			return fmt.Errorf("end mutes: %w", err)
+		}
+		slog.Info("🔊 Mutes ended from Telegram", "mutes", ended, "chat_id", chatID)
+		return b.sender.sendMessage(chatID, fmt.Sprintf("🔊 Ended %d mute(s). Their summaries follow within a minute.", ended), telegramOptions{})
+	}
+
+	usage := "⚠️ Usage: <code>/mute &lt;duration&gt; [token|defi|predict|watch] [subject] [#tag]</code>, e.g. <code>/mute 2h</code>, <code>/mute 30m defi aave</code> or <code>/mute 1d #degen</code>."
+	var tag string
+	args = slices.DeleteFunc(args, func(arg string) bool {
+		if len(arg) > 1 && strings.HasPrefix(arg, "#") {
+			tag = strings.ToLower(arg[1:])
+			return true
+		}
+		return false
+	})
+	if len(args) == 0 || len(args) > 3 || (tag != "" && !core.ValidTag(tag)) {
+		return b.sender.sendMessage(chatID, usage, telegramOptions{})
+	}
+	d, err := ParseMuteDuration(args[0])
+	if err != nil {
+		return b.sender.sendMessage(chatID, "⚠️ "+html.EscapeString(err.Error())+"\n"+usage, telegramOptions{})
+	}
+	mute := core.Mute{Tag: tag, CreatedBy: "Telegram " + chatID, Notify: ChannelTelegram + ":" + chatID}
+	if m.From != nil {
+		if m.From.Username != "" {
+			mute.CreatedBy = "@" + m.From.Username + " (Telegram)"
+		} else {
+			mute.CreatedBy = m.From.FirstName + " (Telegram)"
+		}
+	}
+	if len(args) > 1 {
+		mute.Kind = strings.ToLower(args[1])
+		if !slices.Contains(core.MuteKinds, mute.Kind) {
+			return b.sender.sendMessage(chatID, usage, telegramOptions{})
+		}
+	}
+	if len(args) > 2 {
+		mute.Subject = args[2]
+	}
+	id, err := b.mutes.Create(mute, d)
+	if err != nil {
+		b.sender.sendMessage(chatID, "❌ Muting failed, please try again later.", telegramOptions{})
+		return fmt.Errorf("create mute: %w", err)
+	}
+	until := time.Now().Add(d).UTC().Format("Jan 2 15:04 MST")
+	slog.Info("🔇 Mute created", "mute_id", id, "scope", mute.Scope(), "until", until, "by", mute.CreatedBy)
+	return b.sender.sendMessage(chatID, fmt.Sprintf("🔇 Mute #%d: %s muted until %s. A summary of what was suppressed comes here when it ends; <code>/unmute %d</code> ends it early.",
+		id, html.EscapeString(mute.Scope()), until, id), telegramOptions{})
+}
+
+// maxListedRules is the most rules /rules lists, to stay within a message
+const maxListedRules = 50
+
+// listRules answers /rules [kind] [#tag] in admin chats, e.g. /rules #treasury
+// or /rules defi: the live rules, with their state and tags
+func (b *TelegramBot) listRules(m *TelegramMessage, args []string) error {
+	chatID := m.destination()
+	if b.rules == nil || !slices.Contains(b.adminChats, strconv.FormatInt(m.Chat.ID, 10)) {
+		return b.sender.sendMessage(chatID, "⚠️ Only admin chats can list the rules (<code>TELEGRAM_ADMIN_CHATS</code>).", telegramOptions{})
+	}
+	usage := "⚠️ Usage: <code>/rules [token|defi|predict|watch] [#tag]</code>, e.g. <code>/rules #treasury</code> or <code>/rules defi</code>."
+	var kind, tag string
+	for _, arg := range args {
+		arg = strings.ToLower(arg)
+		switch {
+		case strings.HasPrefix(arg, "#") && tag == "" && core.ValidTag(arg[1:]):
+			tag = arg[1:]
+		case slices.Contains(core.MuteKinds, arg) && kind == "":
+			kind = arg
+		default:
+			return b.sender.sendMessage(chatID, usage, telegramOptions{})
+		}
+	}
+	rules, err := b.rules.List(kind, tag)
+	if err != nil {
+		b.sender.sendMessage(chatID, "❌ The rules couldn't be read, please try again later.", telegramOptions{})
+		return fmt.Errorf("list rules: %w", err)
+	}
+	scope := "rules"
+	if kind != "" {
+		scope = kind + " rules"
+	}
+	if tag != "" {
+		scope += " tagged #" + tag
+	}
+	if len(rules) == 0 {
+		return b.sender.sendMessage(chatID, "📋 No "+html.EscapeString(scope)+".", telegramOptions{})
+	}
+	lines := []string{fmt.Sprintf("📋 %d %s:", len(rules), scope)}
+	for i, r := range rules {
+		if i == maxListedRules {
+			lines = append(lines, fmt.Sprintf("… and %d more", len(rules)-i))
+			break
+		}
+		line := fmt.Sprintf("%s #%d %s %s %s %g", r.Kind, r.ID, r.Subject, r.Field, r.Direction, r.Threshold)
+		switch {
+		case !r.Enabled:
+			line += " ⛔ disabled"
+		case r.Snoozed:
+			line += " 😴 snoozed"
+		}
+		for _, t := range r.Tags {
+			line += " #" + t
+		}
+		lines = append(lines, strings.Join(strings.Fields(line), " "))
+	}
+	return b.sender.sendMessage(chatID, html.EscapeString(strings.Join(lines, "\n")), telegramOptions{})
+}
+
+// alertAction applies the acknowledge, snooze or disable button pressed on an
+// alert, answers the press and tells the chat who changed the rule
+func (b *TelegramBot) alertAction(q *TelegramCallbackQuery) error {
+	action, kind, ruleID, err := parseAlertAction(b.sender.botToken, q.Data)
+	if err != nil {
+		b.answerCallback(q.ID, "⚠️ Unknown action")
+		return err
+	}
+	by := q.From.Username
+	if by != "" {
+		by = "@" + by
+	} else {
+		by = q.From.FirstName
+	}
+
+	var done string
+	if d, ok := alertSnoozes[action]; ok {
+		err = b.rules.Snooze(kind, ruleID, d, by+" (Telegram)")
+		done = fmt.Sprintf("😴 Rule %s #%d snoozed until %s", kind, ruleID, time.Now().Add(d).UTC().Format("Jan 2 15:04 MST"))
+	} else if action == "ack" {
+		var pending bool
+		pending, err = b.rules.Acknowledge(kind, ruleID, by+" (Telegram)")
+		if err == nil && !pending {
+			b.answerCallback(q.ID, "ℹ️ Nothing to acknowledge: the alert was already acknowledged or escalated.")
+			return nil
+		}
+		done = fmt.Sprintf("✅ Alert of rule %s #%d acknowledged", kind, ruleID)
+	} else {
+		err = b.rules.Disable(kind, ruleID, by+" (Telegram)")
+		done = fmt.Sprintf("⛔ Rule %s #%d disabled", kind, ruleID)
+	}
+	if err != nil {
+		b.answerCallback(q.ID, "❌ The rule couldn't be changed, please try again later.")
+		return fmt.Errorf("%s rule %s #%d: %w", action, kind, ruleID, err)
+	}
+	slog.Info("🤖 Rule changed from Telegram", "action", action, logger.FieldKind, kind, logger.FieldRuleID, ruleID, "by", by)
+
+	b.answerCallback(q.ID, done)
+	if q.Message != nil {
+		return b.sender.sendMessage(q.Message.destination(), html.EscapeString(done+" by "+by), telegramOptions{})
+	}
+	return nil
+}
+
+// alertActionData returns the callback data of an alert button,
+// <action>:<rule kind>:<rule ID>:<signature>. The signature, an HMAC-SHA256
+// of the rest keyed with the bot token, keeps clients from sending callback
+// queries of their own that change any rule.
+func alertActionData(botToken, action, kind string, ruleID int64) string {
+	data := fmt.Sprintf("%s:%s:%d", action, kind, ruleID)
+	return data + ":" + alertActionSignature(botToken, data)
+}
+
+// alertActionSignature signs the callback data of an alert button
+func alertActionSignature(botToken, data string) string {
+	mac := hmac.New(sha256.New, []byte(botToken))
+	mac.Write([]byte("alert-action:" + data))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil)[:12])
+}
+
+// parseAlertAction parses and verifies the callback data of an alert button
+// (see alertActionData)
+func parseAlertAction(botToken, data string) (action, kind string, ruleID int64, err error) {
+	parts := strings.Split(data, ":")
+	if len(parts) != 4 {
+		return "", "", 0, fmt.Errorf("invalid callback data %q", data)
+	}
+	signed := strings.Join(parts[:3], ":")
+	if !hmac.Equal([]byte(parts[3]), []byte(alertActionSignature(botToken, signed))) {
+		return "", "", 0, fmt.Errorf("invalid signature of callback data %q", signed)
+	}
+	action, kind = parts[0], parts[1]
+	if _, ok := alertSnoozes[action]; !ok && action != "disable" && action != "ack" {
+		return "", "", 0, fmt.Errorf("unknown alert action %q", action)
+	}
+	ruleID, err = strconv.ParseInt(parts[2], 10, 64)
+	if err != nil || ruleID <= 0 {
+		return "", "", 0, fmt.Errorf("invalid rule ID in callback data %q", data)
+	}
+	return action, kind, ruleID, nil
+}
+
+// answerCallback answers a button press with a notification shown to whoever
+// pressed it. Telegram keeps the button spinning until it's answered.
+func (b *TelegramBot) answerCallback(queryID, text string) {
+	if _, err := b.sender.callJSON("answerCallbackQuery", map[string]interface{}{
+		"callback_query_id": queryID,
+		"text":              text,
+	}); err != nil {
+		slog.Warn("⚠️  Telegram answerCallbackQuery failed", logger.FieldError, err)
+	}
+}
+
+// Poll long-polls the Bot API for updates and handles them until ctx is done.
+// It can't run while the bot has a webhook set.
+func (b *TelegramBot) Poll(ctx context.Context) {
+	var offset int64
+	for ctx.Err() == nil {
+		result, err := b.sender.callJSON("getUpdates", map[string]interface{}{
+			"offset":          offset,
+			"timeout":         int(telegramPollTimeout / time.Second),
+			"allowed_updates": []string{"message", "callback_query"},
+		})
+		var updates []TelegramUpdate
+		if err == nil {
+			err = json.Unmarshal(result, &updates)
+		}
+		if err != nil {
+			slog.Warn("⚠️  Telegram getUpdates failed", logger.FieldError, err)
+			select {
+			case <-ctx.Done():
+			case <-time.After(10 * time.Second):
+			}
+			continue
+		}
+		for _, u := range updates {
+			offset = u.UpdateID + 1
+			if err := b.HandleUpdate(u); err != nil {
+				slog.Warn("⚠️  Telegram update failed", "update_id", u.UpdateID, logger.FieldError, err)
+			}
+		}
+	}
+}
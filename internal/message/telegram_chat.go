@@ -0,0 +1,65 @@
+package message
+
+import (
+	"html"
+	"strings"
+
+	"crypto-alert/internal/core"
+)
+
+// TelegramChatSettings are what a chat adds to its alerts: Prefix before each
+// alert, e.g. "[prod]", and Mentions (@usernames) tagged on alerts of
+// MentionSeverity or above, e.g. the on-call for critical alerts in a group
+type TelegramChatSettings struct {
+	Prefix          string
+	Mentions        []string
+	MentionSeverity core.Severity
+}
+
+// telegramChatSettings looks up the settings of a chat; nil when chats have
+// no settings
+var telegramChatSettings func(chatID string) TelegramChatSettings
+
+// SetTelegramChatSettings sets how the settings of a Telegram chat (a chat ID,
+// or <chat>/<topic>) are looked up. Call it before sending alerts.
+func SetTelegramChatSettings(lookup func(chatID string) TelegramChatSettings) {
+	telegramChatSettings = lookup
+}
+
+// severityRank orders severities from info to critical
+var severityRank = map[core.Severity]int{
+	core.SeverityInfo:     0,
+	core.SeverityWarning:  1,
+	core.SeverityCritical: 2,
+}
+
+// atLeast reports whether severity is min or above; a rule without a severity
+// is a warning
+func atLeast(severity, min core.Severity) bool {
+	if severity == "" {
+		severity = core.SeverityWarning
+	}
+	if min == "" {
+		min = core.SeverityCritical
+	}
+	return severityRank[severity] >= severityRank[min]
+}
+
+// withChatSettings adds the prefix and mentions of chatID to the Telegram HTML
+// text of an alert. A forum topic without settings of its own uses its chat's.
+func withChatSettings(chatID string, severity core.Severity, text string) string {
+	if telegramChatSettings == nil {
+		return text
+	}
+	s := telegramChatSettings(chatID)
+	if chat, topic := SplitTelegramTopic(chatID); topic != 0 && s.Prefix == "" && len(s.Mentions) == 0 {
+		s = telegramChatSettings(chat)
+	}
+	if s.Prefix != "" {
+		text = html.EscapeString(s.Prefix) + "\n" + text
+	}
+	if len(s.Mentions) > 0 && atLeast(severity, s.MentionSeverity) {
+		text += "\n\n" + html.EscapeString(strings.Join(s.Mentions, " "))
+	}
+	return text
+}
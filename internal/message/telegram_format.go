@@ -0,0 +1,101 @@
+package message
+
+import (
+	"html"
+	"regexp"
+	"strings"
+
+	"crypto-alert/internal/core"
+)
+
+// telegramTag matches the HTML tags of the built-in Telegram messages
+var telegramTag = regexp.MustCompile(`<(/?)(b|i|code|a)(?:\s+href="([^"]*)")?>`)
+
+// telegramMarkdownSpecial are the characters MarkdownV2 needs escaped outside entities
+const telegramMarkdownSpecial = "_*[]()~`>#+-=|{}.!\\"
+
+// telegramFormatText converts a message formatted as Telegram HTML to format
+// and returns it with its parse_mode, empty for plain text.
+func telegramFormatText(format core.TelegramFormat, htmlText string) (string, string) {
+	switch format {
+	case core.TelegramFormatMarkdownV2:
+		return htmlToTelegramMarkdown(htmlText), "MarkdownV2"
+	case core.TelegramFormatText:
+		return htmlToTelegramText(htmlText), ""
+	}
+	return htmlText, "HTML"
+}
+
+// htmlToTelegramMarkdown converts Telegram HTML to MarkdownV2: bold, italic,
+// code and links keep their formatting and all other text is escaped.
+func htmlToTelegramMarkdown(s string) string {
+	var b strings.Builder
+	inCode := false
+	var href string
+	convertTags(s, func(text string) {
+		text = html.UnescapeString(text)
+		special := telegramMarkdownSpecial
+		if inCode {
+			special = "`\\"
+		}
+		for _, r := range text {
+			if strings.ContainsRune(special, r) {
+				b.WriteByte('\\')
+			}
+			b.WriteRune(r)
+		}
+	}, func(closing bool, tag, url string) {
+		switch tag {
+		case "b":
+			b.WriteString("*")
+		case "i":
+			b.WriteString("_")
+		case "code":
+			b.WriteString("`")
+			inCode = !closing
+		case "a":
+			if !closing {
+				href = html.UnescapeString(url)
+				b.WriteString("[")
+				return
+			}
+			b.WriteString("](" + strings.NewReplacer(`\`, `\\`, ")", `\)`).Replace(href) + ")")
+		}
+	})
+	return b.String()
+}
+
+// htmlToTelegramText converts Telegram HTML to plain text, with links as
+// "text (url)"
+func htmlToTelegramText(s string) string {
+	var b strings.Builder
+	var href string
+	convertTags(s, func(text string) {
+		b.WriteString(html.UnescapeString(text))
+	}, func(closing bool, tag, url string) {
+		if tag != "a" {
+			return
+		}
+		if !closing {
+			href = html.UnescapeString(url)
+		} else if href != "" {
+			b.WriteString(" (" + href + ")")
+		}
+	})
+	return b.String()
+}
+
+// convertTags calls text for the text between the tags of s and tag for each tag
+func convertTags(s string, text func(string), tag func(closing bool, name, href string)) {
+	last := 0
+	for _, m := range telegramTag.FindAllStringSubmatchIndex(s, -1) {
+		text(s[last:m[0]])
+		href := ""
+		if m[6] >= 0 {
+			href = s[m[6]:m[7]]
+		}
+		tag(m[3] > m[2], s[m[4]:m[5]], href)
+		last = m[1]
+	}
+	text(s[last:])
+}
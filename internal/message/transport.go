@@ -0,0 +1,104 @@
+package message
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// Event transports, selected with EVENT_TRANSPORT
+const (
+	TransportKafka    = "kafka"
+	TransportNATS     = "nats"
+	TransportRabbitMQ = "rabbitmq"
+	TransportRedis    = "redis"
+	// TransportInProcess runs the notifications inside the engine
+	TransportInProcess = "inprocess"
+)
+
+// Consumer is a named group reading one topic. Instances using the same
+// group share the topic's events, and the group resumes where it left off.
+type Consumer struct {
+	Group string
+	Topic string
+	// Workers is how many events the Kafka transport handles at once, keeping
+	// the events of a key in order; 0 or 1 handles one at a time, as the other
+	// transports do
+	Workers int
+}
+
+// Transport carries events from the engine to the notification service.
+// Events of the same key are delivered in the order they were published.
+type Transport interface {
+	// Publish writes an event to topic
+	Publish(ctx context.Context, topic string, key, value []byte) error
+	// Prepare waits until the transport is ready to consume and makes
+	// consumers that haven't read anything yet start from the earliest event
+	Prepare(ctx context.Context, consumers []Consumer)
+	// Consume hands each event of the consumer's topic to handle until ctx is
+	// done or reading or handling fails. An event is acknowledged once handle
+	// returns nil, also when ctx was cancelled meanwhile; when it returns an
+	// error, the event is read again on the next Consume.
+	Consume(ctx context.Context, c Consumer, handle func(ctx context.Context, value []byte) error) error
+	// Name describes the transport for the logs
+	Name() string
+	Close() error
+}
+
+// LagReporter is implemented by transports that can tell how many events of
+// its topic a consumer group hasn't handled yet
+type LagReporter interface {
+	Lag(ctx context.Context, c Consumer) (int64, error)
+}
+
+// Replayer is implemented by transports that keep the events after they are
+// consumed and can read a topic's history again, e.g. for `crypto-alert dlq`
+type Replayer interface {
+	// Replay reads the events published on topic since from, up to the last
+	// one published when it started, and hands each to handle. It reads
+	// without a consumer group, so no group's position moves.
+	Replay(ctx context.Context, topic string, from time.Time, handle func(ReplayedEvent) error) error
+}
+
+// ReplayedEvent is an event read again by Replay
+type ReplayedEvent struct {
+	ID    string    // Where the transport stored the event, e.g. partition:offset on Kafka
+	Time  time.Time // When the event was published
+	Value []byte
+}
+
+// Checker is implemented by transports that can tell whether the broker is
+// reachable and ready to serve the consumers
+type Checker interface {
+	Check(ctx context.Context, consumers []Consumer) error
+}
+
+// TransportConfig selects and configures the event transport
+type TransportConfig struct {
+	Kind         string // kafka (default), nats, rabbitmq, redis or inprocess
+	KafkaBrokers []string
+	Kafka        KafkaWriterConfig
+	NATSURL      string
+	AMQPURL      string
+	RedisURL     string
+}
+
+// NewTransport connects the transport cfg selects
+func NewTransport(cfg TransportConfig) (Transport, error) {
+	switch cfg.Kind {
+	case "", TransportKafka:
+		if len(cfg.KafkaBrokers) == 0 {
+			return nil, fmt.Errorf("KAFKA_BROKERS is required for the kafka transport")
+		}
+		return NewKafkaTransport(cfg.KafkaBrokers, cfg.Kafka)
+	case TransportNATS:
+		return NewNATSTransport(cfg.NATSURL)
+	case TransportRabbitMQ:
+		return NewRabbitMQTransport(cfg.AMQPURL)
+	case TransportRedis:
+		return NewRedisTransport(cfg.RedisURL)
+	case TransportInProcess:
+		return NewMemoryTransport(), nil
+	}
+	return nil, fmt.Errorf("unknown EVENT_TRANSPORT %q, must be %s, %s, %s, %s or %s", cfg.Kind, TransportKafka, TransportNATS, TransportRabbitMQ, TransportRedis, TransportInProcess)
+}
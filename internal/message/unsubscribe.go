@@ -0,0 +1,61 @@
+package message
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"html"
+	"net/url"
+	"strings"
+)
+
+// UnsubscribeToken returns the token that proves an unsubscribe link for
+// email was issued by us: an HMAC-SHA256 of the address keyed with secret.
+func UnsubscribeToken(secret, email string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte("unsubscribe:" + strings.ToLower(strings.TrimSpace(email))))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// ValidUnsubscribeToken reports whether token is the unsubscribe token of email
+func ValidUnsubscribeToken(secret, email, token string) bool {
+	return secret != "" && hmac.Equal([]byte(token), []byte(UnsubscribeToken(secret, email)))
+}
+
+// UnsubscribeURL returns the signed unsubscribe link of email on the
+// unsubscribe endpoint baseURL
+func UnsubscribeURL(baseURL, secret, email string) string {
+	q := url.Values{}
+	q.Set("email", email)
+	q.Set("token", UnsubscribeToken(secret, email))
+	sep := "?"
+	if strings.Contains(baseURL, "?") {
+		sep = "&"
+	}
+	return baseURL + sep + q.Encode()
+}
+
+// unsubscribeHeaders returns the List-Unsubscribe headers of an email with
+// the unsubscribe link link, which mail clients offer as a one-click
+// unsubscribe (RFC 8058).
+func unsubscribeHeaders(link string) map[string]string {
+	return map[string]string{
+		"List-Unsubscribe":      "<" + link + ">",
+		"List-Unsubscribe-Post": "List-Unsubscribe=One-Click",
+	}
+}
+
+// withUnsubscribeFooter appends the unsubscribe link to the text and HTML
+// bodies of an email
+func withUnsubscribeFooter(textBody, htmlBody, link string) (string, string) {
+	textBody += "\n\n--\nUnsubscribe from these alerts: " + link
+
+	footer := `<p style="margin:24px 0 0;font-size:12px;color:#888;text-align:center;">` +
+		`<a href="` + html.EscapeString(link) + `" style="color:#888;">Unsubscribe from these alerts</a></p>`
+	if i := strings.LastIndex(strings.ToLower(htmlBody), "</body>"); i >= 0 {
+		htmlBody = htmlBody[:i] + footer + htmlBody[i:]
+	} else {
+		htmlBody += footer
+	}
+	return textBody, htmlBody
+}
@@ -0,0 +1,154 @@
+package message
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"crypto-alert/internal/core"
+)
+
+func init() {
+	RegisterChannel(ChannelWebhook, func(getenv func(string) string) (NotificationChannel, error) {
+		secret := getenv("WEBHOOK_SECRET")
+		if secret == "" {
+			slog.Warn("⚠️  WEBHOOK_SECRET not set — rule webhooks will be sent unsigned")
+		}
+		return NewWebhookSender(secret, envDurationOr(getenv, "WEBHOOK_TIMEOUT", DefaultWebhookTimeout), envIntOr(getenv, "WEBHOOK_MAX_RETRIES", DefaultWebhookMaxRetries)), nil
+	})
+}
+
+// Webhook request headers. The signature is the hex HMAC-SHA256 of
+// "<timestamp>.<body>" keyed with the shared secret, so receivers can reject
+// both forged and replayed requests.
+const (
+	WebhookSignatureHeader = "X-Crypto-Alert-Signature"
+	WebhookTimestampHeader = "X-Crypto-Alert-Timestamp"
+	WebhookEventHeader     = "X-Crypto-Alert-Event"
+)
+
+// WebhookNoticeEvent is the event header value of notices, which carry a
+// NoticeEvent instead of an alert event
+const WebhookNoticeEvent = "notice"
+
+const (
+	DefaultWebhookTimeout    = 10 * time.Second
+	DefaultWebhookMaxRetries = 3
+	webhookMinBackoff        = time.Second
+)
+
+// WebhookSender POSTs alert events as JSON to user-configured URLs.
+type WebhookSender struct {
+	secret     []byte
+	client     *http.Client
+	maxRetries int
+}
+
+// NewWebhookSender creates a webhook sender. An empty secret sends requests
+// unsigned. A zero timeout uses DefaultWebhookTimeout and a negative
+// maxRetries uses DefaultWebhookMaxRetries.
+func NewWebhookSender(secret string, timeout time.Duration, maxRetries int) *WebhookSender {
+	if timeout <= 0 {
+		timeout = DefaultWebhookTimeout
+	}
+	if maxRetries < 0 {
+		maxRetries = DefaultWebhookMaxRetries
+	}
+	return &WebhookSender{
+		secret:     []byte(secret),
+		client:     &http.Client{Timeout: timeout},
+		maxRetries: maxRetries,
+	}
+}
+
+// Sign returns the signature header value for a payload sent at timestamp
+func (w *WebhookSender) Sign(timestamp string, payload []byte) string {
+	mac := hmac.New(sha256.New, w.secret)
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(payload)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// post makes one delivery attempt and reports whether a failure is retryable.
+func (w *WebhookSender) post(url, topic string, payload []byte) (bool, error) {
+	req, err := http.NewRequest("POST", url, bytes.NewReader(payload))
+	if err != nil {
+		return false, fmt.Errorf("create webhook request: %w", err)
+	}
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "crypto-alert-webhook/1.0")
+	req.Header.Set(WebhookEventHeader, topic)
+	req.Header.Set(WebhookTimestampHeader, timestamp)
+	if len(w.secret) > 0 {
+		req.Header.Set(WebhookSignatureHeader, w.Sign(timestamp, payload))
+	}
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return true, fmt.Errorf("send webhook: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		retry := resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500
+		return retry, fmt.Errorf("webhook returned status %d: %s", resp.StatusCode, string(body))
+	}
+	return false, nil
+}
+
+func (w *WebhookSender) Name() string { return ChannelWebhook }
+
+// RetryPolicy makes the channel wrapper retry webhooks per WEBHOOK_MAX_RETRIES
+func (w *WebhookSender) RetryPolicy() RetryPolicy {
+	return RetryPolicy{MaxRetries: w.maxRetries, MinBackoff: webhookMinBackoff}
+}
+
+// deliver makes one delivery attempt for the channel wrapper, which does the
+// retrying. The body is the event without the rule's destinations.
+func (w *WebhookSender) deliver(d Delivery) error {
+	retry, err := w.post(d.To, d.Topic, PublicAlertEvent(d.Payload))
+	if err != nil && !retry {
+		return &PermanentError{Err: err}
+	}
+	return err
+}
+
+// SendNotice posts a NoticeEvent to the rule's webhook URL.
+func (w *WebhookSender) SendNotice(d Delivery, subject, text string) error {
+	payload, err := json.Marshal(NoticeEvent{RuleID: d.Targets.RuleID, Topic: d.Topic, Subject: subject, Text: text})
+	if err != nil {
+		return &PermanentError{Err: fmt.Errorf("marshal notice: %w", err)}
+	}
+	return w.deliver(Delivery{Topic: WebhookNoticeEvent, To: d.To, Targets: d.Targets, Payload: payload})
+}
+
+// SendTokenAlert posts the token alert event to the rule's webhook URL.
+func (w *WebhookSender) SendTokenAlert(d Delivery, _ *core.AlertDecision) error {
+	return w.deliver(d)
+}
+
+// SendDeFiAlert posts the DeFi alert event to the rule's webhook URL.
+func (w *WebhookSender) SendDeFiAlert(d Delivery, _ *core.DeFiAlertDecision) error {
+	return w.deliver(d)
+}
+
+// SendPredictAlert posts the prediction market alert event to the rule's webhook URL.
+func (w *WebhookSender) SendPredictAlert(d Delivery, _ *core.PredictMarketAlertDecision) error {
+	return w.deliver(d)
+}
+
+// SendWatchAlert posts the watch alert event to the rule's webhook URL.
+func (w *WebhookSender) SendWatchAlert(d Delivery, _ *core.WatchAlertDecision) error {
+	return w.deliver(d)
+}
@@ -0,0 +1,277 @@
+package message
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"crypto-alert/internal/core"
+)
+
+func init() {
+	RegisterChannel(ChannelWhatsApp, func(getenv func(string) string) (NotificationChannel, error) {
+		provider := getenv("WHATSAPP_PROVIDER")
+		if provider == "" {
+			return nil, nil
+		}
+		wa, err := NewWhatsAppSender(WhatsAppConfig{
+			Provider:             provider,
+			TwilioAccountSID:     getenv("TWILIO_ACCOUNT_SID"),
+			TwilioAuthToken:      getenv("TWILIO_AUTH_TOKEN"),
+			TwilioFrom:           getenv("TWILIO_WHATSAPP_FROM"),
+			TwilioContentSID:     getenv("TWILIO_WHATSAPP_CONTENT_SID"),
+			MetaPhoneNumberID:    getenv("WHATSAPP_PHONE_NUMBER_ID"),
+			MetaAccessToken:      getenv("WHATSAPP_ACCESS_TOKEN"),
+			MetaTemplateName:     getenv("WHATSAPP_TEMPLATE_NAME"),
+			MetaTemplateLanguage: getenv("WHATSAPP_TEMPLATE_LANGUAGE"),
+		})
+		if err != nil {
+			return nil, err
+		}
+		return senderChannel{ChannelWhatsApp, wa}, nil
+	})
+}
+
+const (
+	twilioAPIBaseURL   = "https://api.twilio.com/2010-04-01"
+	metaGraphAPIURL    = "https://graph.facebook.com/v21.0"
+	whatsAppMaxTextLen = 4096
+)
+
+// WhatsApp providers
+const (
+	WhatsAppProviderTwilio = "twilio"
+	WhatsAppProviderMeta   = "meta"
+)
+
+// WhatsAppConfig configures a WhatsAppSender. Only the fields of the selected
+// provider are used.
+type WhatsAppConfig struct {
+	Provider string // twilio | meta
+
+	// Twilio WhatsApp API
+	TwilioAccountSID string
+	TwilioAuthToken  string
+	TwilioFrom       string // WhatsApp-enabled sender number, e.g. +14155238886
+	TwilioContentSID string // Optional approved content template (HX...)
+
+	// Meta WhatsApp Cloud API
+	MetaPhoneNumberID    string
+	MetaAccessToken      string
+	MetaTemplateName     string // Optional approved message template
+	MetaTemplateLanguage string // Template language code, default en_US
+}
+
+// WhatsAppSender sends alert notifications via the Twilio WhatsApp API or the
+// Meta WhatsApp Cloud API.
+//
+// WhatsApp only delivers free-form text inside the 24h customer service
+// window, so business-initiated alerts normally need an approved template.
+// When a template is configured it is sent with two body variables:
+// {{1}} the alert title and {{2}} the alert summary. Without one the full
+// alert text is sent as a plain message.
+type WhatsAppSender struct {
+	cfg    WhatsAppConfig
+	client *http.Client
+}
+
+func NewWhatsAppSender(cfg WhatsAppConfig) (*WhatsAppSender, error) {
+	switch cfg.Provider {
+	case WhatsAppProviderTwilio:
+		if cfg.TwilioAccountSID == "" || cfg.TwilioAuthToken == "" || cfg.TwilioFrom == "" {
+			return nil, fmt.Errorf("twilio WhatsApp requires account SID, auth token and sender number")
+		}
+	case WhatsAppProviderMeta:
+		if cfg.MetaPhoneNumberID == "" || cfg.MetaAccessToken == "" {
+			return nil, fmt.Errorf("meta WhatsApp requires phone number ID and access token")
+		}
+		if cfg.MetaTemplateLanguage == "" {
+			cfg.MetaTemplateLanguage = "en_US"
+		}
+	default:
+		return nil, fmt.Errorf("unknown WhatsApp provider %q (expected %s or %s)", cfg.Provider, WhatsAppProviderTwilio, WhatsAppProviderMeta)
+	}
+	return &WhatsAppSender{
+		cfg:    cfg,
+		client: &http.Client{Timeout: 15 * time.Second},
+	}, nil
+}
+
+// SendAlert sends a token price alert to a WhatsApp number.
+func (w *WhatsAppSender) SendAlert(to string, decision *core.AlertDecision) error {
+	if to == "" || decision == nil || decision.Rule == nil || decision.CurrentPrice == nil {
+		return nil
+	}
+	subject, textBody, _ := FormatAlertEmail(decision)
+	return w.sendMessage(to, subject, textBody, decision.Message)
+}
+
+// SendDeFiAlert sends a DeFi protocol alert to a WhatsApp number.
+func (w *WhatsAppSender) SendDeFiAlert(to string, decision *core.DeFiAlertDecision) error {
+	if to == "" || decision == nil || decision.Rule == nil {
+		return nil
+	}
+	subject, textBody, _ := FormatDeFiAlertEmail(decision)
+	return w.sendMessage(to, subject, textBody, decision.Message)
+}
+
+// SendPredictMarketAlert sends a prediction market alert to a WhatsApp number.
+func (w *WhatsAppSender) SendPredictMarketAlert(to string, decision *core.PredictMarketAlertDecision) error {
+	if to == "" || decision == nil || decision.Rule == nil {
+		return nil
+	}
+	subject, textBody, _ := FormatPredictMarketAlertEmail(decision)
+	return w.sendMessage(to, subject, textBody, decision.Message)
+}
+
+// SendWatchAlert sends a watch alert to a WhatsApp number.
+func (w *WhatsAppSender) SendWatchAlert(to string, decision *core.WatchAlertDecision) error {
+	if to == "" || decision == nil || decision.Rule == nil {
+		return nil
+	}
+	subject, textBody, _ := FormatWatchAlertEmail(decision)
+	return w.sendMessage(to, subject, textBody, decision.Message)
+}
+
+// SendNotice sends a plain message to a WhatsApp number.
+func (w *WhatsAppSender) SendNotice(to, subject, text string) error {
+	return w.sendMessage(to, subject, text, subject)
+}
+
+// sendMessage sends the alert as a template message when one is configured,
+// otherwise as plain text.
+func (w *WhatsAppSender) sendMessage(to, subject, textBody, summary string) error {
+	to = strings.TrimPrefix(strings.TrimSpace(to), "whatsapp:")
+	if to == "" {
+		return fmt.Errorf("whatsapp recipient is required")
+	}
+	// Template variables may not contain newlines or long runs of spaces
+	params := []string{whatsAppParam(subject), whatsAppParam(firstNonEmptyString(summary, subject))}
+	text := truncateRunes("*"+subject+"*\n\n"+textBody, whatsAppMaxTextLen)
+
+	var err error
+	if w.cfg.Provider == WhatsAppProviderTwilio {
+		err = w.sendTwilio(to, text, params)
+	} else {
+		err = w.sendMeta(to, text, params)
+	}
+	if err != nil {
+		return err
+	}
+	slog.Info("📨 WhatsApp message sent", "to", to, "provider", w.cfg.Provider)
+	return nil
+}
+
+// sendTwilio posts to the Twilio Messages API.
+// Route: POST /Accounts/{AccountSid}/Messages.json
+func (w *WhatsAppSender) sendTwilio(to, text string, params []string) error {
+	form := url.Values{
+		"From": {"whatsapp:" + strings.TrimPrefix(w.cfg.TwilioFrom, "whatsapp:")},
+		"To":   {"whatsapp:" + to},
+	}
+	if w.cfg.TwilioContentSID != "" {
+		vars := make(map[string]string, len(params))
+		for i, p := range params {
+			vars[fmt.Sprint(i+1)] = p
+		}
+		data, err := json.Marshal(vars)
+		if err != nil {
+			return fmt.Errorf("marshal twilio content variables: %w", err)
+		}
+		form.Set("ContentSid", w.cfg.TwilioContentSID)
+		form.Set("ContentVariables", string(data))
+	} else {
+		form.Set("Body", text)
+	}
+
+	apiURL := fmt.Sprintf("%s/Accounts/%s/Messages.json", twilioAPIBaseURL, url.PathEscape(w.cfg.TwilioAccountSID))
+	req, err := http.NewRequest("POST", apiURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return fmt.Errorf("create twilio request: %w", err)
+	}
+	req.SetBasicAuth(w.cfg.TwilioAccountSID, w.cfg.TwilioAuthToken)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	return w.do(req, "twilio")
+}
+
+// sendMeta posts to the WhatsApp Cloud API.
+// Route: POST /{phone-number-id}/messages
+func (w *WhatsAppSender) sendMeta(to, text string, params []string) error {
+	payload := map[string]interface{}{
+		"messaging_product": "whatsapp",
+		"to":                strings.TrimPrefix(to, "+"),
+	}
+	if w.cfg.MetaTemplateName != "" {
+		parameters := make([]map[string]string, 0, len(params))
+		for _, p := range params {
+			parameters = append(parameters, map[string]string{"type": "text", "text": p})
+		}
+		payload["type"] = "template"
+		payload["template"] = map[string]interface{}{
+			"name":     w.cfg.MetaTemplateName,
+			"language": map[string]string{"code": w.cfg.MetaTemplateLanguage},
+			"components": []map[string]interface{}{
+				{"type": "body", "parameters": parameters},
+			},
+		}
+	} else {
+		payload["type"] = "text"
+		payload["text"] = map[string]interface{}{"body": text, "preview_url": false}
+	}
+
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("marshal whatsapp payload: %w", err)
+	}
+	apiURL := fmt.Sprintf("%s/%s/messages", metaGraphAPIURL, url.PathEscape(w.cfg.MetaPhoneNumberID))
+	req, err := http.NewRequest("POST", apiURL, bytes.NewBuffer(data))
+	if err != nil {
+		return fmt.Errorf("create whatsapp request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+w.cfg.MetaAccessToken)
+	req.Header.Set("Content-Type", "application/json")
+	return w.do(req, "meta")
+}
+
+func (w *WhatsAppSender) do(req *http.Request, provider string) error {
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("send whatsapp message: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("%s WhatsApp API returned status %d: %s", provider, resp.StatusCode, string(body))
+	}
+	return nil
+}
+
+// whatsAppParam collapses whitespace so the value is a valid template variable
+func whatsAppParam(s string) string {
+	return truncateRunes(strings.Join(strings.Fields(s), " "), 1024)
+}
+
+// truncateRunes shortens s to at most n characters, ending with "..." when cut
+func truncateRunes(s string, n int) string {
+	r := []rune(s)
+	if len(r) <= n {
+		return s
+	}
+	return string(r[:n-3]) + "..."
+}
+
+func firstNonEmptyString(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
@@ -0,0 +1,96 @@
+package notify
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"crypto-alert/internal/logger"
+)
+
+// consumerJSON is a consumer as the admin endpoints return it
+type consumerJSON struct {
+	Topic    string     `json:"topic"`
+	Paused   bool       `json:"paused"`
+	PausedAt *time.Time `json:"paused_at,omitempty"`
+}
+
+// serveAdmin serves the admin interface on addr until ctx is done. Callers
+// authenticate with secret as a bearer token.
+//
+//	GET  /admin/consumers                          lists the topics and whether they are paused
+//	POST /admin/consumers/pause?topic=alerts.token  pauses a topic, or all without topic
+//	POST /admin/consumers/resume?topic=alerts.token resumes a topic, or all without topic
+func serveAdmin(ctx context.Context, addr, secret string, p *pauses) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/admin/consumers", func(w http.ResponseWriter, r *http.Request) {
+		handleConsumers(w, r, p, secret, "")
+	})
+	mux.HandleFunc("/admin/consumers/pause", func(w http.ResponseWriter, r *http.Request) {
+		handleConsumers(w, r, p, secret, "pause")
+	})
+	mux.HandleFunc("/admin/consumers/resume", func(w http.ResponseWriter, r *http.Request) {
+		handleConsumers(w, r, p, secret, "resume")
+	})
+	srv := &http.Server{Addr: addr, Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = srv.Shutdown(shutdownCtx)
+	}()
+	slog.Info("🛠️  Admin interface listening", "addr", addr)
+	if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		slog.Warn("⚠️  Admin interface stopped", logger.FieldError, err)
+	}
+}
+
+// handleConsumers lists the consumed topics, or with action pauses or
+// resumes one topic (or all of them without the topic parameter). A paused
+// topic's consumers hold the event they read until the topic is resumed, so
+// nothing is sent or acknowledged in between.
+func handleConsumers(w http.ResponseWriter, r *http.Request, p *pauses, secret, action string) {
+	if (action == "" && r.Method != http.MethodGet) || (action != "" && r.Method != http.MethodPost) {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	token, _ := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if subtle.ConstantTimeCompare([]byte(token), []byte(secret)) != 1 {
+		http.Error(w, "Invalid token", http.StatusUnauthorized)
+		return
+	}
+	topics := p.topics
+	if topic := r.URL.Query().Get("topic"); topic != "" {
+		if !p.known(topic) {
+			http.Error(w, "Unknown topic "+topic, http.StatusNotFound)
+			return
+		}
+		topics = []string{topic}
+	}
+
+	for _, topic := range topics {
+		switch {
+		case action == "pause" && p.pause(topic):
+			slog.Info("⏸️  Consumption paused through the admin interface", logger.FieldTopic, topic)
+		case action == "resume" && p.resume(topic):
+			slog.Info("▶️  Consumption resumed through the admin interface", logger.FieldTopic, topic)
+		}
+	}
+
+	out := []consumerJSON{}
+	for _, topic := range topics {
+		c := consumerJSON{Topic: topic}
+		if at, ok := p.pausedAt(topic); ok {
+			at = at.UTC().Truncate(time.Second)
+			c.Paused, c.PausedAt = true, &at
+		}
+		out = append(out, c)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"data": out})
+}
@@ -0,0 +1,195 @@
+package notify
+
+import (
+	"time"
+
+	"crypto-alert/internal/core"
+	"crypto-alert/internal/data/price"
+	"crypto-alert/internal/message"
+)
+
+// sendFunc sends a decoded alert on one channel
+type sendFunc func(message.NotificationChannel, message.Delivery) error
+
+// alertDecoders rebuild each topic's alert events into the decision the
+// channels format, returning the rule's targets and a label for the logs.
+var alertDecoders = map[string]func(payload []byte) (message.NotificationTargets, string, sendFunc, error){
+	message.TopicTokenAlert:   decodeTokenAlert,
+	message.TopicDeFiAlert:    decodeDeFiAlert,
+	message.TopicPredictAlert: decodePredictAlert,
+	message.TopicWatchAlert:   decodeWatchAlert,
+}
+
+// notificationSettings returns the settings of a rule that shape how its
+// alerts are sent from the event's targets
+func notificationSettings(t message.NotificationTargets) core.NotificationSettings {
+	return core.NotificationSettings{
+		Severity:          core.Severity(t.Severity),
+		MessageTemplate:   t.MessageTemplate,
+		Locale:            core.Locale(t.Locale),
+		TelegramFormat:    core.TelegramFormat(t.TelegramFormat),
+		TelegramSilent:    t.TelegramSilent,
+		TelegramNoPreview: t.TelegramNoPreview,
+		EscalateAfter:     time.Duration(t.EscalateAfterMinutes) * time.Minute,
+	}
+}
+
+// decodeTokenAlert rebuilds an alerts.token event into a price alert decision.
+func decodeTokenAlert(payload []byte) (message.NotificationTargets, string, sendFunc, error) {
+	var event message.TokenAlertEvent
+	if err := message.DecodeAlertEvent(payload, &event); err != nil {
+		return message.NotificationTargets{}, "", nil, err
+	}
+	decision := &core.AlertDecision{
+		ShouldAlert: true,
+		Rule: &core.AlertRule{
+			ID:                   event.RuleID,
+			Threshold:            event.Threshold,
+			Direction:            core.Direction(event.Direction),
+			NotificationSettings: notificationSettings(event.NotificationTargets),
+			TelegramChart:        event.TelegramChart,
+		},
+		CurrentPrice: &price.PriceData{
+			Symbol:    event.Symbol,
+			Price:     event.Price,
+			Timestamp: event.Timestamp,
+		},
+		Message:     event.Message,
+		TriggeredAt: event.TriggeredAt,
+	}
+	for _, pt := range event.History {
+		decision.PriceHistory = append(decision.PriceHistory, core.HistoryPoint{Time: pt.Time, Value: pt.Value})
+	}
+	return event.NotificationTargets, event.Symbol, func(ch message.NotificationChannel, d message.Delivery) error {
+		return ch.SendTokenAlert(d, decision)
+	}, nil
+}
+
+// decodeDeFiAlert rebuilds an alerts.defi event into a DeFi alert decision.
+func decodeDeFiAlert(payload []byte) (message.NotificationTargets, string, sendFunc, error) {
+	var event message.DeFiAlertEvent
+	if err := message.DecodeAlertEvent(payload, &event); err != nil {
+		return message.NotificationTargets{}, "", nil, err
+	}
+	decision := &core.DeFiAlertDecision{
+		ShouldAlert: true,
+		Rule: &core.DeFiAlertRule{
+			ID:                      event.RuleID,
+			Protocol:                event.Protocol,
+			Category:                event.Category,
+			Version:                 event.Version,
+			ChainID:                 event.ChainID,
+			MarketTokenContract:     event.MarketTokenContract,
+			Field:                   event.Field,
+			Threshold:               event.Threshold,
+			Direction:               core.Direction(event.Direction),
+			NotificationSettings:    notificationSettings(event.NotificationTargets),
+			TelegramChart:           event.TelegramChart,
+			MarketTokenName:         event.MarketTokenName,
+			MarketTokenPair:         event.MarketTokenPair,
+			VaultName:               event.VaultName,
+			BorrowTokenContract:     event.BorrowTokenContract,
+			CollateralTokenContract: event.CollateralTokenContract,
+			OracleAddress:           event.OracleAddress,
+			IRMAddress:              event.IRMAddress,
+			LLTV:                    event.LLTV,
+			MarketContractAddress:   event.MarketContractAddress,
+			VaultTokenAddress:       event.VaultTokenAddress,
+			DepositTokenContract:    event.DepositTokenContract,
+			ENSNames:                event.ENSNames,
+		},
+		CurrentValue: event.CurrentValue,
+		ChainName:    event.ChainName,
+		Message:      event.Message,
+		TriggeredAt:  event.TriggeredAt,
+	}
+	for _, pt := range event.History {
+		decision.ValueHistory = append(decision.ValueHistory, core.HistoryPoint{Time: pt.Time, Value: pt.Value})
+	}
+	return event.NotificationTargets, event.Protocol + " " + event.Field, func(ch message.NotificationChannel, d message.Delivery) error {
+		return ch.SendDeFiAlert(d, decision)
+	}, nil
+}
+
+// decodePredictAlert rebuilds an alerts.predict event into a prediction market alert decision.
+func decodePredictAlert(payload []byte) (message.NotificationTargets, string, sendFunc, error) {
+	var event message.PredictMarketAlertEvent
+	if err := message.DecodeAlertEvent(payload, &event); err != nil {
+		return message.NotificationTargets{}, "", nil, err
+	}
+	decision := &core.PredictMarketAlertDecision{
+		ShouldAlert: true,
+		Rule: &core.PredictMarketAlertRule{
+			ID:                   event.RuleID,
+			PredictMarket:        event.PredictMarket,
+			TokenID:              event.TokenID,
+			Field:                event.Field,
+			Threshold:            event.Threshold,
+			Direction:            core.Direction(event.Direction),
+			NotificationSettings: notificationSettings(event.NotificationTargets),
+			Question:             event.Question,
+			Outcome:              event.Outcome,
+			QuestionID:           event.QuestionID,
+			ConditionID:          event.ConditionID,
+			NegRisk:              event.NegRisk,
+			DepthSide:            event.DepthSide,
+			DepthPrice:           event.DepthPrice,
+			TokenIDs:             event.TokenIDs,
+			GroupItems:           event.GroupItems,
+			MoveWindow:           time.Duration(event.MoveWindowMinutes) * time.Minute,
+		},
+		CurrentValue:     event.CurrentValue,
+		CurrentMidpoint:  event.CurrentMidpoint,
+		CurrentBuyPrice:  event.CurrentBuyPrice,
+		CurrentSellPrice: event.CurrentSellPrice,
+		Message:          event.Message,
+		TriggeredAt:      event.TriggeredAt,
+	}
+	if h := event.History; h != nil {
+		decision.History = &core.PredictMarketHistory{
+			Period:  time.Duration(h.PeriodHours * float64(time.Hour)),
+			Open:    h.Open,
+			Low:     h.Low,
+			High:    h.High,
+			Samples: h.Samples,
+		}
+	}
+	return event.NotificationTargets, event.Question, func(ch message.NotificationChannel, d message.Delivery) error {
+		return ch.SendPredictAlert(d, decision)
+	}, nil
+}
+
+// decodeWatchAlert rebuilds an alerts.watch event into a watch alert decision.
+func decodeWatchAlert(payload []byte) (message.NotificationTargets, string, sendFunc, error) {
+	var event message.WatchAlertEvent
+	if err := message.DecodeAlertEvent(payload, &event); err != nil {
+		return message.NotificationTargets{}, "", nil, err
+	}
+	decision := &core.WatchAlertDecision{
+		ShouldAlert: true,
+		Rule: &core.WatchAlertRule{
+			ID:                   event.RuleID,
+			Source:               event.Source,
+			ChainID:              event.ChainID,
+			Field:                event.Field,
+			Label:                event.Label,
+			Threshold:            event.Threshold,
+			Direction:            core.Direction(event.Direction),
+			NotificationSettings: notificationSettings(event.NotificationTargets),
+		},
+		Observation: &core.WatchObservation{
+			Key:       event.Key,
+			Value:     event.Value,
+			Unlimited: event.Unlimited,
+			Title:     event.Title,
+			Details:   event.Details,
+			URL:       event.URL,
+		},
+		ChainName:   event.ChainName,
+		Message:     event.Message,
+		TriggeredAt: event.TriggeredAt,
+	}
+	return event.NotificationTargets, event.Source + " " + event.Field, func(ch message.NotificationChannel, d message.Delivery) error {
+		return ch.SendWatchAlert(d, decision)
+	}, nil
+}
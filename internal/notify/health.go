@@ -0,0 +1,172 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+
+	"crypto-alert/internal/message"
+)
+
+// health tracks what the health and readiness endpoints report: whether the
+// consumers are set up and how each consume loop is doing
+type health struct {
+	transport message.Transport
+	consumers []message.Consumer
+	timeout   time.Duration // How long a consumer may keep failing before the service is unhealthy
+
+	mu     sync.Mutex
+	ready  bool                      // Set once the consumer groups are prepared
+	states map[string]*consumerState // Topic -> its consume loop
+}
+
+// consumerState is how a topic's consume loop is doing
+type consumerState struct {
+	consumingSince time.Time // Start of the current Consume call; zero while backing off
+	failingSince   time.Time // First of the consecutive failures; zero when healthy
+	lastError      string
+	lastProcessed  time.Time
+}
+
+// consumerHealthJSON is a consumer as the health endpoints return it
+type consumerHealthJSON struct {
+	Topic         string     `json:"topic"`
+	Group         string     `json:"group"`
+	Status        string     `json:"status"` // starting, consuming, backing_off or failing
+	LastError     string     `json:"last_error,omitempty"`
+	LastProcessed *time.Time `json:"last_processed,omitempty"`
+}
+
+// stableAfter is how long a Consume call must run before the failures
+// before it are forgiven, on topics too quiet to prove recovery by handling
+// an event
+const stableAfter = time.Minute
+
+func newHealth(transport message.Transport, consumers []message.Consumer, timeout time.Duration) *health {
+	h := &health{transport: transport, consumers: consumers, timeout: timeout, states: map[string]*consumerState{}}
+	for _, c := range consumers {
+		h.states[c.Topic] = &consumerState{}
+	}
+	return h
+}
+
+// prepared marks the consumer groups as set up
+func (h *health) prepared() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.ready = true
+}
+
+// consuming marks topic's consume loop as reading from the transport
+func (h *health) consuming(topic string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.states[topic].consumingSince = time.Now()
+}
+
+// processed records an event of topic handled successfully
+func (h *health) processed(topic string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	st := h.states[topic]
+	st.lastProcessed = time.Now()
+	st.failingSince, st.lastError = time.Time{}, ""
+	lastProcessed.WithLabelValues(topic).SetToCurrentTime()
+}
+
+// failed records that topic's consume loop stopped on err and backs off
+func (h *health) failed(topic string, err error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	st := h.states[topic]
+	if st.failingSince.IsZero() || time.Since(st.consumingSince) >= stableAfter {
+		st.failingSince = time.Now()
+	}
+	st.consumingSince, st.lastError = time.Time{}, err.Error()
+}
+
+// report returns the consumers' states and whether they are all alive: none
+// has kept failing for longer than the timeout
+func (h *health) report() ([]consumerHealthJSON, bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	alive := true
+	out := make([]consumerHealthJSON, 0, len(h.consumers))
+	for _, c := range h.consumers {
+		st := h.states[c.Topic]
+		failing := !st.failingSince.IsZero() && time.Since(st.failingSince) > h.timeout &&
+			(st.consumingSince.IsZero() || time.Since(st.consumingSince) < stableAfter)
+		j := consumerHealthJSON{Topic: c.Topic, Group: c.Group, Status: "consuming", LastError: st.lastError}
+		switch {
+		case failing:
+			j.Status = "failing"
+			alive = false
+		case st.consumingSince.IsZero() && st.lastError == "":
+			j.Status = "starting"
+		case st.consumingSince.IsZero():
+			j.Status = "backing_off"
+		}
+		if !st.lastProcessed.IsZero() {
+			at := st.lastProcessed.UTC().Truncate(time.Second)
+			j.LastProcessed = &at
+		}
+		out = append(out, j)
+	}
+	return out, alive
+}
+
+// handleHealthz answers liveness probes: 503 when a consumer has kept
+// failing for longer than NOTIFY_LIVENESS_TIMEOUT, so the container is
+// restarted
+func (h *health) handleHealthz(w http.ResponseWriter, r *http.Request) {
+	consumers, alive := h.report()
+	status := "ok"
+	if !alive {
+		status = "failing"
+	}
+	writeHealth(w, alive, map[string]interface{}{"status": status, "consumers": consumers})
+}
+
+// handleReadyz answers readiness probes: 503 until the consumer groups are
+// prepared, while the transport can't be reached (for Kafka: a broker or a
+// group coordinator) and once shutdown has begun
+func (h *health) handleReadyz(ctx context.Context) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		h.mu.Lock()
+		ready := h.ready
+		h.mu.Unlock()
+		body := map[string]interface{}{"transport": h.transport.Name()}
+		switch {
+		case ctx.Err() != nil:
+			ready = false
+			body["error"] = "shutting down"
+		case !ready:
+			body["error"] = "consumer groups not prepared yet"
+		default:
+			if checker, ok := h.transport.(message.Checker); ok {
+				checkCtx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+				defer cancel()
+				if err := checker.Check(checkCtx, h.consumers); err != nil {
+					ready = false
+					body["error"] = err.Error()
+				}
+			}
+		}
+		body["status"] = "ready"
+		if !ready {
+			body["status"] = "not_ready"
+		}
+		body["consumers"], _ = h.report()
+		writeHealth(w, ready, body)
+	}
+}
+
+func writeHealth(w http.ResponseWriter, ok bool, body map[string]interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	if !ok {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	json.NewEncoder(w).Encode(body)
+}
@@ -0,0 +1,97 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"crypto-alert/internal/logger"
+	"crypto-alert/internal/message"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+var (
+	eventsProcessed = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "notify_events_processed_total",
+		Help: "Events handled by the notification service, by topic and outcome (ok or error).",
+	}, []string{"topic", "outcome"})
+	deliveriesTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "notify_deliveries_total",
+		Help: "Notification sends, by topic, channel and status (sent, failed, suppressed, rate_limited, digested, muted).",
+	}, []string{"topic", "channel", "status"})
+	retriesQueued = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "notify_retries_queued_total",
+		Help: "Alerts queued on the retry topic, by alert topic and channel.",
+	}, []string{"topic", "channel"})
+	deadLettersTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "notify_dead_letters_total",
+		Help: "Alerts sent to the dead-letter topic, by alert topic and reason.",
+	}, []string{"topic", "reason"})
+	lastProcessed = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "notify_last_processed_timestamp_seconds",
+		Help: "When the last event of a topic was handled successfully, as a Unix timestamp.",
+	}, []string{"topic"})
+	consumerLag = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "notify_consumer_lag",
+		Help: "Events of a topic the consumer group hasn't handled yet.",
+	}, []string{"topic", "group"})
+)
+
+// serveHTTP serves the Prometheus metrics and the health endpoints on addr
+// until ctx is done
+//
+//	GET /metrics  Prometheus metrics
+//	GET /healthz  liveness: 503 when a consumer keeps failing
+//	GET /readyz   readiness: 503 until the consumers are set up or while the transport is unreachable
+func serveHTTP(ctx context.Context, addr string, h *health) {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.Handler())
+	mux.HandleFunc("/healthz", h.handleHealthz)
+	mux.HandleFunc("/readyz", h.handleReadyz(ctx))
+	srv := &http.Server{Addr: addr, Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = srv.Shutdown(shutdownCtx)
+	}()
+	slog.Info("📈 Metrics and health endpoints listening", "addr", addr)
+	if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		slog.Warn("⚠️  Metrics and health endpoints stopped", logger.FieldError, err)
+	}
+}
+
+// watchLag updates the consumer lag gauge every interval until ctx is done,
+// if the transport can tell the lag
+func watchLag(ctx context.Context, transport message.Transport, consumers []message.Consumer, interval time.Duration) {
+	lag, ok := transport.(message.LagReporter)
+	if !ok {
+		return
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		for _, c := range consumers {
+			lagCtx, cancel := context.WithTimeout(ctx, interval)
+			n, err := lag.Lag(lagCtx, c)
+			cancel()
+			if err != nil {
+				if ctx.Err() == nil {
+					slog.Warn("⚠️  Consumer lag unavailable", logger.FieldTopic, c.Topic, logger.FieldError, err)
+				}
+				continue
+			}
+			consumerLag.WithLabelValues(c.Topic, c.Group).Set(float64(n))
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
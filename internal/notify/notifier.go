@@ -0,0 +1,663 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"crypto-alert/internal/core"
+	"crypto-alert/internal/logger"
+	"crypto-alert/internal/message"
+	"crypto-alert/internal/store"
+	"crypto-alert/internal/tracing"
+)
+
+// notifier sends decoded alerts to the configured channels
+type notifier struct {
+	channels      []message.NotificationChannel
+	deliveries    *store.NotificationLog // Records each send; nil without MySQL
+	telegramChats *store.TelegramChats   // Chats registered with the bot; nil without MySQL
+	escalations   *store.Escalations     // Critical alerts waiting to be acknowledged; nil without MySQL
+	mutes         *store.Mutes           // Mute windows; nil without MySQL
+	muter         *message.Muter         // The active mutes
+	dedup         *message.Deduper       // IDs of the alert events handled recently
+	limiter       *message.RateLimiter
+	digests       *message.Digester
+	groups        *message.Grouper
+	replay        bool // Sends each alert right away, bypassing digests, grouping and the rate limit
+}
+
+// deliver decodes an alert event and sends it to every destination the rule
+// has on each channel, limited to the only channels when set. Each send is
+// recorded in the notification log as the given attempt. It returns the
+// channels whose sends failed with their last error; a channel that failed
+// with a retryable error on any destination gets that error. The sends are
+// spans of the trace of ctx.
+func (n *notifier) deliver(ctx context.Context, topic string, payload []byte, only []string, attempt int) (map[string]error, error) {
+	decode, ok := alertDecoders[topic]
+	if !ok {
+		return nil, fmt.Errorf("unknown alert topic %s", topic)
+	}
+	targets, what, send, err := decode(payload)
+	if err != nil {
+		return nil, err
+	}
+	alertLog := slog.With(logger.FieldRuleID, targets.RuleID)
+	var sent map[string][]string // Hashes of the destinations the event was sent to before it was read again
+	if attempt == 0 {
+		if n.dedup.Seen(targets.EventID) {
+			alertLog.Info("♻️  Alert was already delivered, skipping it", logger.FieldTopic, topic, logger.FieldEventID, targets.EventID, "alert", what)
+			return nil, nil
+		}
+		sent = n.sentRecipients(targets.EventID)
+	}
+	mute := n.muter.Muted(topic, payload, what)
+	if mute != nil {
+		alertLog.Info("🔇 Alert muted", logger.FieldTopic, topic, "alert", what, "mute_id", mute.ID, "scope", mute.Scope(), "until", mute.Until.UTC())
+		if err := n.mutes.CountSuppressed(mute.ID); err != nil {
+			slog.Warn("⚠️  Failed to count alert suppressed by mute", "mute_id", mute.ID, logger.FieldError, err)
+		}
+	} else if attempt == 0 && len(sent) == 0 {
+		n.openEscalation(topic, payload, targets)
+	}
+
+	failed := map[string]error{}
+	for _, ch := range n.channels {
+		if only != nil && !slices.Contains(only, ch.Name()) {
+			continue
+		}
+		for _, to := range n.destinations(ch.Name(), targets) {
+			if slices.Contains(sent[ch.Name()], message.DestinationHash(ch.Name(), to)) {
+				alertLog.Info("♻️  Alert was already sent to the destination, skipping it", logger.FieldTopic, topic, logger.FieldChannel, ch.Name(), logger.FieldEventID, targets.EventID, "alert", what, "destination", message.DisplayDestination(ch.Name(), to))
+				continue
+			}
+			d := message.Delivery{Topic: topic, To: to, Targets: targets, Payload: payload, Receipt: &message.Receipt{}}
+			var err error
+			switch {
+			case mute != nil:
+				err = errMuted
+			case ch.Name() == message.ChannelEmail && n.emailSuppressed(to):
+				err = errSuppressed
+			case n.replay:
+				err = sendTraced(ctx, send, ch, d)
+			case message.Digestible(ch.Name(), targets):
+				n.digests.Add(ch.Name(), d)
+				err = errDigested
+			case n.groups.Enabled() && message.Groupable(ch.Name(), attempt):
+				n.groups.Add(ch.Name(), message.GroupedAlert{Delivery: d, Send: func() error { return sendTraced(ctx, send, ch, d) }})
+				continue // Sent and recorded with its group
+			case !n.limiter.Allow(ch.Name(), d):
+				err = errRateLimited
+			default:
+				err = sendTraced(ctx, send, ch, d)
+			}
+			if !errors.Is(err, message.ErrSkipped) {
+				n.record(d, ch.Name(), attempt, err)
+			}
+			switch {
+			case errors.Is(err, message.ErrSkipped):
+			case errors.Is(err, errMuted):
+			case errors.Is(err, errSuppressed):
+				alertLog.Info("🚫 Not sending alert to suppressed address", logger.FieldTopic, topic, logger.FieldChannel, ch.Name(), "alert", what)
+			case errors.Is(err, errRateLimited):
+				alertLog.Info("🚦 Alert dropped by the rate limit", logger.FieldTopic, topic, logger.FieldChannel, ch.Name(), "alert", what)
+			case errors.Is(err, errDigested):
+				alertLog.Info("🗂️  Alert held for the digest", logger.FieldTopic, topic, logger.FieldChannel, ch.Name(), "alert", what)
+			case err != nil:
+				alertLog.Error("❌ Failed to send alert", logger.FieldTopic, topic, logger.FieldChannel, ch.Name(), "alert", what, logger.FieldError, err)
+				if prev, ok := failed[ch.Name()]; !ok || message.IsPermanent(prev) {
+					failed[ch.Name()] = err
+				}
+			default:
+				alertLog.Info("✅ Sent alert", logger.FieldTopic, topic, logger.FieldChannel, ch.Name(), "alert", what)
+			}
+		}
+	}
+	return failed, nil
+}
+
+// sendTraced sends an alert to a destination of ch in a span of the trace of
+// ctx, which covers the channel's retries
+func sendTraced(ctx context.Context, send sendFunc, ch message.NotificationChannel, d message.Delivery) error {
+	_, span := tracing.Start(ctx, "send "+ch.Name(), tracing.AttrChannel.String(ch.Name()), tracing.AttrRuleID.Int64(d.Targets.RuleID))
+	err := send(ch, d)
+	tracing.End(span, err)
+	return err
+}
+
+// Errors of alerts that were deliberately not sent
+var (
+	errSuppressed  = errors.New("address is on the email suppression list")
+	errRateLimited = errors.New("dropped by the notification rate limit")
+	errDigested    = errors.New("held for the rule's digest")
+	errMuted       = errors.New("muted")
+)
+
+// destinations returns the rule's destinations on channel. Telegram
+// destinations that aren't chat IDs are registration tokens and are replaced
+// by the chats registered under them, in the token's forum topic if it names
+// one (alice@example.com/42); unregistered tokens are kept, so their sends
+// fail and show up in the notification log.
+func (n *notifier) destinations(channel string, targets message.NotificationTargets) []string {
+	dests := targets.Destinations(channel)
+	if channel != message.ChannelTelegram {
+		return dests
+	}
+	var resolved []string
+	for _, to := range dests {
+		if message.IsTelegramChatID(to) {
+			resolved = append(resolved, to)
+			continue
+		}
+		token, topic := message.SplitTelegramTopic(to)
+		chatIDs, err := n.telegramChats.ChatIDs(token)
+		if err != nil {
+			slog.Warn("⚠️  Failed to look up registered Telegram chats", logger.FieldError, err)
+		}
+		if len(chatIDs) == 0 {
+			resolved = append(resolved, to)
+			continue
+		}
+		for _, id := range chatIDs {
+			if _, registered := message.SplitTelegramTopic(id); topic != 0 && registered == 0 {
+				id += "/" + strconv.FormatInt(topic, 10)
+			}
+			if !slices.Contains(resolved, id) {
+				resolved = append(resolved, id)
+			}
+		}
+	}
+	return resolved
+}
+
+// sentRecipients returns, per channel, the hashes (message.DestinationHash) of
+// the destinations the alert event with the given ID was sent to within the dedup window according to the
+// notification log, e.g. before a crash or by an attempt to handle it that
+// failed. When the log can't be read the event is sent to every destination.
+func (n *notifier) sentRecipients(eventID string) map[string][]string {
+	if !n.dedup.Enabled() || eventID == "" {
+		return nil
+	}
+	sent, err := n.deliveries.EventRecipients(eventID, n.dedup.Window())
+	if err != nil {
+		slog.Warn("⚠️  Failed to check the notification log", logger.FieldEventID, eventID, logger.FieldError, err)
+		return nil
+	}
+	return sent
+}
+
+// markDelivered remembers an alert event once it was handled, i.e. its sends
+// are done and any retry is queued, so it is skipped when read again. An
+// event whose handling failed isn't remembered: it is read again and sent to
+// the destinations it didn't reach.
+func (n *notifier) markDelivered(topic string, payload []byte) {
+	if !n.dedup.Enabled() {
+		return
+	}
+	if decode, ok := alertDecoders[topic]; ok {
+		if targets, _, _, err := decode(payload); err == nil {
+			n.dedup.Add(targets.EventID)
+		}
+	}
+}
+
+// emailSuppressed reports whether an address is on the suppression list. When
+// the list can't be read the email is sent.
+func (n *notifier) emailSuppressed(to string) bool {
+	suppressed, err := n.deliveries.IsEmailSuppressed(to)
+	if err != nil {
+		slog.Warn("⚠️  Failed to check email suppression list", logger.FieldError, err)
+		return false
+	}
+	return suppressed
+}
+
+// record writes one send to the notification log. A failure to record is
+// logged and doesn't affect the delivery.
+func (n *notifier) record(d message.Delivery, channel string, attempt int, sendErr error) {
+	entry := store.NotificationLogEntry{
+		RuleID:            d.Targets.RuleID,
+		EventID:           d.Targets.EventID,
+		Topic:             d.Topic,
+		Channel:           channel,
+		Recipient:         message.DisplayDestination(channel, d.To),
+		DestinationHash:   message.DestinationHash(channel, d.To),
+		Status:            store.DeliveryStatusSent,
+		ProviderMessageID: d.Receipt.MessageID,
+		Attempt:           attempt,
+	}
+	switch {
+	case errors.Is(sendErr, errSuppressed):
+		entry.Status = store.DeliveryStatusSuppressed
+	case errors.Is(sendErr, errRateLimited):
+		entry.Status = store.DeliveryStatusRateLimited
+	case errors.Is(sendErr, errDigested):
+		entry.Status = store.DeliveryStatusDigested
+	case errors.Is(sendErr, errMuted):
+		entry.Status = store.DeliveryStatusMuted
+	case sendErr != nil:
+		entry.Status = store.DeliveryStatusFailed
+		entry.Error = sendErr.Error()
+	}
+	deliveriesTotal.WithLabelValues(d.Topic, channel, entry.Status).Inc()
+	if err := n.deliveries.Record(entry); err != nil {
+		slog.Warn("⚠️  Failed to record delivery", logger.FieldTopic, d.Topic, logger.FieldChannel, channel, logger.FieldError, err)
+	}
+}
+
+// sendSummaries tells each destination that had alerts dropped by the rate
+// limit how many, at most once per rate limit window.
+func (n *notifier) sendSummaries(ctx context.Context) {
+	ticker := time.NewTicker(time.Minute)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		for _, s := range n.limiter.DueSummaries() {
+			ch := n.channel(s.Channel)
+			if ch == nil {
+				continue
+			}
+			subject := fmt.Sprintf("%d additional alerts suppressed", s.Count)
+			text := fmt.Sprintf("%d more alerts for this destination were dropped by the notification rate limit since %s (at most %d per %v per recipient). The latest was from %s.",
+				s.Count, s.Since.UTC().Format("2006-01-02 15:04 UTC"), n.limiter.PerRecipient(), n.limiter.Window(), s.Last.Topic)
+			d := s.Last
+			d.Receipt = &message.Receipt{}
+			if err := ch.SendNotice(d, subject, text); err != nil && !errors.Is(err, message.ErrSkipped) {
+				slog.Error("❌ Failed to send rate limit summary", logger.FieldChannel, s.Channel, logger.FieldError, err)
+				continue
+			}
+			slog.Info("🚦 Sent rate limit summary", logger.FieldChannel, s.Channel, "subject", subject)
+		}
+	}
+}
+
+// sendDigests sends each destination's held-back alerts as one message once
+// its digest interval has passed. A digest that fails to send is logged and
+// dropped.
+func (n *notifier) sendDigests(ctx context.Context) {
+	ticker := time.NewTicker(time.Minute)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		n.sendDigestList(n.digests.Due())
+	}
+}
+
+// flushDigests sends the pending digests on shutdown, before they are due:
+// their alerts come from events that were already acknowledged, so they
+// would be lost otherwise
+func (n *notifier) flushDigests() {
+	pending := n.digests.Flush()
+	if len(pending) > 0 {
+		slog.Info("🗂️  Sending pending digests before shutdown", "digests", len(pending))
+	}
+	n.sendDigestList(pending)
+}
+
+// sendDigestList sends digests, recording each send
+func (n *notifier) sendDigestList(digests []message.Digest) {
+	for _, dg := range digests {
+		ch := n.channel(dg.Channel)
+		if ch == nil {
+			continue
+		}
+		d := dg.Last
+		d.Receipt = &message.Receipt{}
+		err := ch.SendNotice(d, dg.Subject(), dg.Text())
+		n.record(d, dg.Channel, 0, err)
+		if err != nil {
+			slog.Error("❌ Failed to send digest", logger.FieldChannel, dg.Channel, "alerts", len(dg.Entries), logger.FieldError, err)
+			continue
+		}
+		slog.Info("🗂️  Sent digest", logger.FieldChannel, dg.Channel, "alerts", len(dg.Entries))
+	}
+}
+
+// sendDailySummary emails a daily summary to its recipient, unless the address
+// is on the suppression list, and records the send in the notification log.
+func (n *notifier) sendDailySummary(event message.SummaryEvent, payload []byte) {
+	ch := n.channel(message.ChannelEmail)
+	if ch == nil || event.RecipientEmail == "" {
+		return
+	}
+	d := message.Delivery{
+		Topic:   message.TopicSummary,
+		To:      event.RecipientEmail,
+		Targets: message.NotificationTargets{RecipientEmail: event.RecipientEmail},
+		Payload: payload,
+		Receipt: &message.Receipt{},
+	}
+	var err error
+	if n.emailSuppressed(d.To) {
+		err = errSuppressed
+	} else {
+		err = ch.SendNotice(d, event.Subject(), event.Text())
+	}
+	if errors.Is(err, message.ErrSkipped) {
+		return
+	}
+	n.record(d, message.ChannelEmail, 0, err)
+	switch {
+	case errors.Is(err, errSuppressed):
+		slog.Info("🚫 Not sending daily summary to suppressed address", logger.FieldTopic, message.TopicSummary)
+	case err != nil:
+		slog.Error("❌ Failed to send daily summary", logger.FieldTopic, message.TopicSummary, logger.FieldError, err)
+	default:
+		slog.Info("✅ Sent daily summary", logger.FieldTopic, message.TopicSummary)
+	}
+}
+
+// sendGroups sends the alerts each destination got within the group window: a
+// lone alert as usual, several as one message. A group counts once against the
+// rate limit. Sends that fail are logged and recorded but not retried.
+func (n *notifier) sendGroups(ctx context.Context) {
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		n.sendGroupList(n.groups.Due())
+	}
+}
+
+// flushGroups sends the pending groups on shutdown, before their window has
+// passed: their alerts come from events that were already acknowledged, so
+// they would be lost otherwise
+func (n *notifier) flushGroups() {
+	pending := n.groups.Flush()
+	if len(pending) > 0 {
+		slog.Info("📦 Sending pending alert groups before shutdown", "groups", len(pending))
+	}
+	n.sendGroupList(pending)
+}
+
+// sendGroupList sends alert groups, recording each of their alerts
+func (n *notifier) sendGroupList(groups []message.AlertGroup) {
+	for _, g := range groups {
+		ch := n.channel(g.Channel)
+		if ch == nil {
+			continue
+		}
+		first := g.Alerts[0].Delivery
+		var err error
+		switch {
+		case !n.limiter.Allow(g.Channel, first):
+			err = errRateLimited
+		case len(g.Alerts) == 1:
+			err = g.Alerts[0].Send()
+		default:
+			err = ch.SendNotice(first, g.Subject(), g.Text())
+		}
+		if errors.Is(err, message.ErrSkipped) {
+			continue
+		}
+		for _, a := range g.Alerts {
+			d := a.Delivery
+			d.Receipt = first.Receipt
+			n.record(d, g.Channel, 0, err)
+		}
+		switch {
+		case errors.Is(err, errRateLimited):
+			slog.Info("🚦 Alert group dropped by the rate limit", logger.FieldTopic, first.Topic, logger.FieldChannel, g.Channel, "alerts", len(g.Alerts))
+		case err != nil:
+			slog.Error("❌ Failed to send alert group", logger.FieldTopic, first.Topic, logger.FieldChannel, g.Channel, "alerts", len(g.Alerts), logger.FieldError, err)
+		default:
+			slog.Info("✅ Sent alert group", logger.FieldTopic, first.Topic, logger.FieldChannel, g.Channel, "alerts", len(g.Alerts))
+		}
+	}
+}
+
+// openEscalation starts the escalation clock of a critical alert of a rule
+// with an escalation policy. Rules from the JSON config have no ID to
+// acknowledge them by and don't escalate.
+func (n *notifier) openEscalation(topic string, payload []byte, targets message.NotificationTargets) {
+	if n.escalations == nil || targets.RuleID == 0 || targets.EscalateAfterMinutes <= 0 || len(targets.EscalateTo) == 0 ||
+		core.Severity(targets.Severity) != core.SeverityCritical {
+		return
+	}
+	kind := strings.TrimPrefix(topic, "alerts.")
+	if err := n.escalations.Open(kind, targets.RuleID, topic, payload, time.Duration(targets.EscalateAfterMinutes)*time.Minute); err != nil {
+		slog.Warn("⚠️  Failed to open escalation", logger.FieldTopic, topic, logger.FieldKind, kind, logger.FieldRuleID, targets.RuleID, logger.FieldError, err)
+	}
+}
+
+// sendEscalations re-sends the critical alerts nobody acknowledged in time to
+// their rule's escalate_to destinations, bypassing digests, grouping and the
+// rate limit. Each alert is escalated once; failed sends are logged and
+// recorded but not retried.
+func (n *notifier) sendEscalations(ctx context.Context) {
+	if n.escalations == nil {
+		return
+	}
+	ticker := time.NewTicker(30 * time.Second)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		due, err := n.escalations.Due()
+		if err != nil {
+			slog.Warn("⚠️  Failed to read due escalations", logger.FieldError, err)
+			continue
+		}
+		for _, esc := range due {
+			n.escalate(esc)
+			if err := n.escalations.MarkEscalated(esc.ID); err != nil {
+				slog.Warn("⚠️  Failed to mark escalation sent", "escalation_id", esc.ID, logger.FieldError, err)
+			}
+		}
+	}
+}
+
+// escalate sends one unacknowledged alert to its rule's escalate_to destinations
+func (n *notifier) escalate(esc store.Escalation) {
+	decode, ok := alertDecoders[esc.Topic]
+	if !ok {
+		slog.Warn("⚠️  Escalation of an unknown alert topic", "escalation_id", esc.ID, logger.FieldTopic, esc.Topic)
+		return
+	}
+	targets, what, send, err := decode(esc.Payload)
+	if err != nil {
+		slog.Warn("⚠️  Escalation of undecodable alert", "escalation_id", esc.ID, logger.FieldError, err)
+		return
+	}
+	escLog := slog.With(logger.FieldKind, esc.RuleKind, logger.FieldRuleID, esc.RuleID)
+	for _, pair := range targets.EscalateTo {
+		name, to, _ := strings.Cut(pair, ":")
+		ch := n.channel(name)
+		if ch == nil {
+			escLog.Warn("⚠️  Can't escalate alert: channel isn't configured", logger.FieldTopic, esc.Topic, logger.FieldChannel, name, "alert", what)
+			continue
+		}
+		d := message.Delivery{Topic: esc.Topic, To: to, Targets: targets, Payload: esc.Payload, Receipt: &message.Receipt{}}
+		err := send(ch, d)
+		if errors.Is(err, message.ErrSkipped) {
+			continue
+		}
+		n.record(d, name, 0, err)
+		if err != nil {
+			escLog.Error("❌ Failed to escalate alert", logger.FieldTopic, esc.Topic, logger.FieldChannel, name, "alert", what, logger.FieldError, err)
+			continue
+		}
+		escLog.Info("📣 Escalated alert not acknowledged in time", logger.FieldTopic, esc.Topic, logger.FieldChannel, name, "alert", what, "escalate_after_minutes", targets.EscalateAfterMinutes)
+	}
+}
+
+// applyMutes keeps the active mutes up to date and sends the summary of each
+// mute that ended to its notify destination. New mutes and early unmutes take
+// effect within half a minute.
+func (n *notifier) applyMutes(ctx context.Context) {
+	if n.mutes == nil {
+		return
+	}
+	ticker := time.NewTicker(30 * time.Second)
+	defer ticker.Stop()
+	for {
+		active, err := n.mutes.Active()
+		if err != nil {
+			slog.Warn("⚠️  Failed to read mutes", logger.FieldError, err)
+		} else {
+			n.muter.Set(active)
+		}
+		ended, err := n.mutes.Ended()
+		if err != nil {
+			slog.Warn("⚠️  Failed to read ended mutes", logger.FieldError, err)
+		}
+		for _, m := range ended {
+			n.sendMuteSummary(m)
+			if err := n.mutes.MarkSummarized(m.ID); err != nil {
+				slog.Warn("⚠️  Failed to mark mute summary sent", "mute_id", m.ID, logger.FieldError, err)
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// sendMuteSummary tells the mute's notify destination what it suppressed
+func (n *notifier) sendMuteSummary(m core.Mute) {
+	subject, text := n.muter.Summary(m)
+	slog.Info("🔊 Mute ended", "mute_id", m.ID, "summary", subject)
+	name, to, ok := strings.Cut(m.Notify, ":")
+	if !ok {
+		return
+	}
+	ch := n.channel(name)
+	if ch == nil {
+		slog.Warn("⚠️  Can't send mute summary: channel isn't configured", "mute_id", m.ID, logger.FieldChannel, name)
+		return
+	}
+	d := message.Delivery{To: to, Receipt: &message.Receipt{}}
+	if err := ch.SendNotice(d, subject, text); err != nil && !errors.Is(err, message.ErrSkipped) {
+		slog.Error("❌ Failed to send mute summary", "mute_id", m.ID, logger.FieldChannel, name, logger.FieldError, err)
+	}
+}
+
+// channel returns the configured channel named name, or nil
+func (n *notifier) channel(name string) message.NotificationChannel {
+	i := slices.IndexFunc(n.channels, func(ch message.NotificationChannel) bool {
+		return ch.Name() == name
+	})
+	if i < 0 {
+		return nil
+	}
+	return n.channels[i]
+}
+
+// retryQueue queues alerts for channels that failed on the retry topic, with
+// exponential backoff between attempts. Alerts that failed with a permanent
+// error or still fail after maxAttempts go to the dead-letter topic.
+// A destination of a channel that failed is retried together with any of the
+// channel's destinations that succeeded.
+type retryQueue struct {
+	publisher   *message.AlertPublisher
+	maxAttempts int
+	backoff     time.Duration
+	deadLetters bool // Publish the alerts given up on to the dead-letter topic
+
+	handlerAttempts int // Failed attempts to handle an event before it is set aside
+}
+
+// schedule queues attempt number attempt for the channels that failed with a
+// retryable error, and dead-letters those that failed with a permanent one.
+// After maxAttempts every failed channel is dead-lettered. The retry event
+// continues the trace of ctx.
+func (q *retryQueue) schedule(ctx context.Context, topic string, payload []byte, failed map[string]error, attempt int) error {
+	retry, dead := map[string]error{}, map[string]error{}
+	for ch, err := range failed {
+		if message.IsPermanent(err) || attempt > q.maxAttempts {
+			dead[ch] = err
+		} else {
+			retry[ch] = err
+		}
+	}
+	if len(dead) > 0 {
+		reason := message.DeadLetterPermanent
+		if attempt > q.maxAttempts {
+			reason = message.DeadLetterRetriesExhausted
+			slog.Error("🛑 Giving up on alert after the last retry", logger.FieldTopic, topic, "channels", slices.Sorted(maps.Keys(failed)), "retries", q.maxAttempts)
+		}
+		if err := q.deadLetter(topic, payload, dead, attempt-1, reason); err != nil {
+			return err
+		}
+	}
+	if len(retry) == 0 {
+		return nil
+	}
+	channels := slices.Sorted(maps.Keys(retry))
+	event := message.RetryEvent{
+		Topic:     topic,
+		Channels:  channels,
+		Attempt:   attempt,
+		NotBefore: time.Now().Add(q.backoff << (attempt - 1)),
+		Payload:   payload,
+	}
+	if err := q.publisher.WithContext(ctx).PublishRetry(event); err != nil {
+		return fmt.Errorf("queue retry for %v: %w", channels, err)
+	}
+	for _, ch := range channels {
+		retriesQueued.WithLabelValues(topic, ch).Inc()
+	}
+	slog.Info("🔁 Queued retry", logger.FieldTopic, topic, "attempt", attempt, "channels", channels, "not_before", event.NotBefore)
+	return nil
+}
+
+// deadLetter publishes an alert that failed on the given channels to the
+// dead-letter topic, from where `crypto-alert dlq redrive` can send it again.
+// An undecodable alert is passed with its error as the "decode" channel, one
+// that kept failing to be handled as the "handler" channel.
+func (q *retryQueue) deadLetter(topic string, payload []byte, failed map[string]error, attempts int, reason string) error {
+	if !q.deadLetters {
+		slog.Error("🛑 Gave up on alert", logger.FieldTopic, topic, "reason", reason, "channels", slices.Sorted(maps.Keys(failed)))
+		return nil
+	}
+	if !json.Valid(payload) {
+		payload, _ = json.Marshal(string(payload))
+	}
+	event := message.DeadLetterEvent{
+		Topic:    topic,
+		Reason:   reason,
+		Attempts: attempts,
+		FailedAt: time.Now().UTC(),
+		Payload:  payload,
+		Errors:   map[string]string{},
+	}
+	for ch, err := range failed {
+		event.Errors[ch] = err.Error()
+		// "decode" and "handler" aren't channels: such alerts are redriven to
+		// all of the rule's channels
+		if ch != "decode" && ch != "handler" {
+			event.Channels = append(event.Channels, ch)
+		}
+	}
+	slices.Sort(event.Channels)
+	if err := q.publisher.PublishDeadLetter(event); err != nil {
+		return fmt.Errorf("dead-letter alert: %w", err)
+	}
+	deadLettersTotal.WithLabelValues(topic, reason).Inc()
+	slog.Error("🪦 Dead-lettered alert", logger.FieldTopic, topic, "reason", reason, "channels", event.Channels)
+	return nil
+}
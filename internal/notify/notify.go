@@ -0,0 +1,455 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"slices"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"crypto-alert/internal/logger"
+	"crypto-alert/internal/message"
+	"crypto-alert/internal/store"
+	"crypto-alert/internal/tracing"
+)
+
+// Run delivers the alert events consumed from transport to the notification
+// channels configured in the environment until ctx is done. It is the
+// notification service, also run inside the engine when no message broker is
+// configured.
+func Run(ctx context.Context, transport message.Transport) error {
+	channels, err := message.NewChannels(os.Getenv)
+	if err != nil {
+		return fmt.Errorf("notification channel configuration: %w", err)
+	}
+
+	// Alerts that still fail after the in-process retries go to the retry topic
+	// and to the dead-letter topic when they can't be delivered at all, on the
+	// transports `crypto-alert dlq` can read the dead letters back from. In
+	// process and on RabbitMQ, where nothing could read them, the alerts given
+	// up on are only logged.
+	_, replayable := transport.(message.Replayer)
+	_, inProcess := transport.(*message.MemoryTransport)
+	if !replayable && !inProcess {
+		slog.Warn("⚠️  The transport can't replay the dead-letter topic: alerts given up on are only logged", "transport", transport.Name())
+	}
+	retries := &retryQueue{
+		publisher:   message.NewAlertPublisher(transport),
+		maxAttempts: envInt("NOTIFY_RETRY_TOPIC_ATTEMPTS", 5),
+		backoff:     envDuration("NOTIFY_RETRY_TOPIC_BACKOFF", time.Minute),
+		deadLetters: replayable,
+
+		handlerAttempts: envInt("NOTIFY_HANDLER_ATTEMPTS", 5),
+	}
+
+	// Delivery attempts are recorded in notification_log when MySQL is configured
+	var deliveries *store.NotificationLog
+	if dsn := os.Getenv("MYSQL_DSN"); dsn != "" {
+		deliveries, err = store.NewNotificationLog(dsn)
+		if err != nil {
+			slog.Warn("⚠️  Notification log disabled", logger.FieldError, err)
+		} else {
+			defer deliveries.Close()
+			slog.Info("🗒️  Recording delivery attempts in notification_log")
+		}
+	}
+
+	// Chats registered through the bot's /start command are looked up when
+	// MySQL is configured, so rules can name a registration token. The bot
+	// also applies the snooze / disable buttons of Telegram alerts.
+	var telegramChats *store.TelegramChats
+	var ruleActions *store.RuleActions
+	var escalations *store.Escalations
+	var mutes *store.Mutes
+	if dsn := os.Getenv("MYSQL_DSN"); dsn != "" {
+		telegramChats, err = store.NewTelegramChats(dsn)
+		if err != nil {
+			slog.Warn("⚠️  Telegram chat registration disabled", logger.FieldError, err)
+		} else {
+			defer telegramChats.Close()
+			// The prefix and mentions chats set with /prefix and /mentions
+			message.SetTelegramChatSettings(func(chatID string) message.TelegramChatSettings {
+				settings, err := telegramChats.ChatSettings(chatID)
+				if err != nil {
+					slog.Warn("⚠️  Telegram chat settings failed", "chat_id", chatID, logger.FieldError, err)
+				}
+				return message.TelegramChatSettings(settings)
+			})
+		}
+		ruleActions, err = store.NewRuleActions(dsn)
+		if err != nil {
+			slog.Warn("⚠️  Telegram alert buttons disabled", logger.FieldError, err)
+		} else {
+			defer ruleActions.Close()
+		}
+		// Critical alerts of rules with escalate_after_minutes wait for an
+		// acknowledgement and are escalated when none comes
+		escalations, err = store.NewEscalations(dsn)
+		if err != nil {
+			slog.Warn("⚠️  Alert escalation disabled", logger.FieldError, err)
+		} else {
+			defer escalations.Close()
+		}
+		// Mute windows set through the log API or the bot's /mute command
+		mutes, err = store.NewMutes(dsn)
+		if err != nil {
+			slog.Warn("⚠️  Mute windows disabled", logger.FieldError, err)
+		} else {
+			defer mutes.Close()
+		}
+	}
+
+	n := &notifier{
+		channels:      channels,
+		deliveries:    deliveries,
+		telegramChats: telegramChats,
+		escalations:   escalations,
+		mutes:         mutes,
+		muter:         message.NewMuter(),
+		dedup:         message.NewDeduper(envDuration("NOTIFY_DEDUP_WINDOW", message.DefaultDedupWindow)),
+		limiter: message.NewRateLimiter(
+			envInt("NOTIFY_RATE_LIMIT_PER_RECIPIENT", message.DefaultRateLimitPerRecipient),
+			envInt("NOTIFY_RATE_LIMIT_PER_CHANNEL", message.DefaultRateLimitPerChannel),
+			envDuration("NOTIFY_RATE_LIMIT_WINDOW", message.DefaultRateLimitWindow),
+		),
+		digests: message.NewDigester(),
+		groups:  message.NewGrouper(envDuration("NOTIFY_GROUP_WINDOW", message.DefaultGroupWindow)),
+	}
+
+	// Consumption can be paused per topic through the admin interface, e.g.
+	// during a provider incident
+	p := newPauses(topics())
+	if addr := os.Getenv("NOTIFY_ADMIN_ADDR"); addr != "" {
+		if secret := os.Getenv("NOTIFY_ADMIN_SECRET"); secret != "" {
+			go serveAdmin(ctx, addr, secret, p)
+		} else {
+			slog.Warn("⚠️  Admin interface disabled: NOTIFY_ADMIN_SECRET is not set")
+		}
+	}
+
+	// Metrics and the health endpoints for container probes
+	consumed := append(slices.Clone(alertConsumers), retryConsumer, summaryConsumer)
+	h := newHealth(transport, consumed, envDuration("NOTIFY_LIVENESS_TIMEOUT", 5*time.Minute))
+	if addr := os.Getenv("NOTIFY_HTTP_ADDR"); addr != "" {
+		go serveHTTP(ctx, addr, h)
+	}
+
+	// Wait until the transport is ready. For any consumer group that hasn't read
+	// a topic (partition) yet — fresh deploy, first run, a partition added to the
+	// topic, or after a coordinator failure that prevented committing — start from
+	// the earliest event (the latest with KAFKA_READER_START_OFFSET=last). Groups
+	// that already have a position are left completely untouched — no duplicate
+	// emails on normal restarts. Reader settings come from KAFKA_READER_*.
+	if kafka, ok := transport.(*message.KafkaTransport); ok {
+		readers, err := message.KafkaReaderConfigs(os.Getenv, topics())
+		if err != nil {
+			return fmt.Errorf("kafka reader configuration: %w", err)
+		}
+		kafka.SetReaderConfigs(readers)
+		kafka.CreateTopics(ctx, message.KafkaTopicConfig{
+			Partitions:        envInt("KAFKA_TOPIC_PARTITIONS", 3),
+			ReplicationFactor: envInt("KAFKA_REPLICATION_FACTOR", 1),
+			Retention:         time.Duration(envInt("KAFKA_RETENTION_HOURS", 168)) * time.Hour,
+		})
+	}
+	prepare := append(alertConsumers, retryConsumer, summaryConsumer)
+	transport.Prepare(ctx, prepare)
+	h.prepared()
+
+	// With Kafka, each topic's events of different rules are handled by up to
+	// NOTIFY_CONSUMER_WORKERS workers, so a slow provider call doesn't hold
+	// back the other rules
+	workers := envInt("NOTIFY_CONSUMER_WORKERS", 4)
+	var consumers sync.WaitGroup // Waited for on shutdown, see drain
+	for _, c := range alertConsumers {
+		consumers.Add(1)
+		go func() {
+			defer consumers.Done()
+			consumeAlerts(ctx, transport, withWorkers(c, workers), p, h, n, retries)
+		}()
+	}
+	consumers.Add(2)
+	go func() {
+		defer consumers.Done()
+		consumeRetries(ctx, transport, withWorkers(retryConsumer, workers), p, h, n, retries)
+	}()
+	go func() {
+		defer consumers.Done()
+		consumeDailySummaries(ctx, transport, withWorkers(summaryConsumer, workers), p, h, n, retries)
+	}()
+	go watchLag(ctx, transport, prepare, envDuration("NOTIFY_LAG_INTERVAL", 15*time.Second))
+	go n.sendSummaries(ctx)
+	go n.sendDigests(ctx)
+	go n.sendGroups(ctx)
+	go n.sendEscalations(ctx)
+	go n.applyMutes(ctx)
+
+	// Answer /start and the alert buttons with long polling, unless updates
+	// come in through the log API's webhook (TELEGRAM_UPDATES=off)
+	if botToken := os.Getenv("TELEGRAM_BOT_TOKEN"); botToken != "" && telegramChats != nil && os.Getenv("TELEGRAM_UPDATES") != "off" {
+		bot := message.NewTelegramBot(botToken, telegramChats, ruleActions)
+		bot.RequireRegistrationCodes(os.Getenv("TELEGRAM_REGISTRATION_SECRET"))
+		if mutes != nil {
+			bot.EnableMutes(mutes, os.Getenv("TELEGRAM_ADMIN_CHATS"))
+		}
+		go bot.Poll(ctx)
+		slog.Info("🤖 Polling Telegram for /start registrations and alert buttons")
+	}
+
+	slog.Info("🔔 Notification service started", "transport", transport.Name())
+
+	<-ctx.Done()
+	drain(&consumers, envDuration("NOTIFY_DRAIN_TIMEOUT", 30*time.Second))
+	n.flushGroups()
+	n.flushDigests()
+	return nil
+}
+
+// drain waits up to timeout for the consumers to finish the events they are
+// handling once ctx is done, so those are sent and acknowledged before the
+// stores close and the process exits. The consumers stop reading and start no
+// new events when ctx is done; events still in flight at the deadline are
+// read again on the next start.
+func drain(consumers *sync.WaitGroup, timeout time.Duration) {
+	drained := make(chan struct{})
+	go func() {
+		consumers.Wait()
+		close(drained)
+	}()
+	slog.Info("⏳ Draining in-flight events", "timeout", timeout)
+	select {
+	case <-drained:
+		slog.Info("✅ In-flight events drained")
+	case <-time.After(timeout):
+		slog.Warn("⚠️  Events still in flight, stopping anyway; they are read again on the next start", "timeout", timeout)
+	}
+}
+
+// The consumer groups of the notification service
+var (
+	alertConsumers = []message.Consumer{
+		{Group: "notification-service-token", Topic: message.TopicTokenAlert},
+		{Group: "notification-service-defi", Topic: message.TopicDeFiAlert},
+		{Group: "notification-service-predict", Topic: message.TopicPredictAlert},
+		{Group: "notification-service-watch", Topic: message.TopicWatchAlert},
+	}
+	retryConsumer   = message.Consumer{Group: "notification-service-retry", Topic: message.TopicRetry}
+	summaryConsumer = message.Consumer{Group: "notification-service-summary", Topic: message.TopicSummary}
+)
+
+// topics returns the topics the service consumes
+func topics() []string {
+	var out []string
+	for _, c := range append(alertConsumers, retryConsumer, summaryConsumer) {
+		out = append(out, c.Topic)
+	}
+	return out
+}
+
+// withWorkers returns c handling up to workers events at once
+func withWorkers(c message.Consumer, workers int) message.Consumer {
+	c.Workers = workers
+	return c
+}
+
+// consumeAlerts reads alert events from topic and sends them to the rule's
+// channels. Channels that still fail after their in-process retries are queued
+// on the retry topic; alerts that can't be decoded or fail permanently go to
+// the dead-letter topic.
+func consumeAlerts(ctx context.Context, transport message.Transport, c message.Consumer, p *pauses, h *health, n *notifier, retries *retryQueue) {
+	consumeWithBackoff(ctx, transport, c, p, h, retries,
+		func(ctx context.Context, value []byte) error {
+			failed, err := n.deliver(ctx, c.Topic, value, nil, 0)
+			if err != nil {
+				slog.Warn("⚠️  Unmarshal error", logger.FieldTopic, c.Topic, logger.FieldError, err)
+				return retries.deadLetter(c.Topic, value, map[string]error{"decode": err}, 0, message.DeadLetterUndecodable)
+			}
+			// Keep the message unacknowledged when the retry can't be queued,
+			// so it is read again once the transport is reachable
+			if err := retries.schedule(ctx, c.Topic, value, failed, 1); err != nil {
+				return err
+			}
+			n.markDelivered(c.Topic, value)
+			return nil
+		},
+		func(value []byte, err error) error {
+			return retries.deadLetter(c.Topic, value, map[string]error{"handler": err}, 0, message.DeadLetterHandlerFailed)
+		},
+	)
+}
+
+// consumeRetries reads the retry topic and, once each event is due, retries
+// its alert on the channels that failed. Events of a rule are handled in
+// order, so an event waiting for its backoff also holds back the ones queued
+// after it on its worker (or partition, with a single worker).
+func consumeRetries(ctx context.Context, transport message.Transport, c message.Consumer, p *pauses, h *health, n *notifier, retries *retryQueue) {
+	consumeWithBackoff(ctx, transport, c, p, h, retries,
+		func(ctx context.Context, value []byte) error {
+			var event message.RetryEvent
+			if err := json.Unmarshal(value, &event); err != nil {
+				slog.Warn("⚠️  Unmarshal error", logger.FieldTopic, message.TopicRetry, logger.FieldError, err)
+				return nil
+			}
+			if wait := time.Until(event.NotBefore); wait > 0 {
+				select {
+				case <-ctx.Done():
+					return ctx.Err()
+				case <-time.After(wait):
+				}
+			}
+
+			slog.Info("🔁 Retrying alert", logger.FieldTopic, event.Topic, "attempt", event.Attempt, "channels", event.Channels)
+			failed, err := n.deliver(ctx, event.Topic, event.Payload, event.Channels, event.Attempt)
+			if err != nil {
+				slog.Warn("⚠️  Retry of undecodable alert", logger.FieldTopic, event.Topic, logger.FieldError, err)
+				return retries.deadLetter(event.Topic, event.Payload, map[string]error{"decode": err}, event.Attempt, message.DeadLetterUndecodable)
+			}
+			return retries.schedule(ctx, event.Topic, event.Payload, failed, event.Attempt+1)
+		},
+		// The retry's alert is dead-lettered for the channels it was retried on
+		func(value []byte, err error) error {
+			var event message.RetryEvent
+			if json.Unmarshal(value, &event) != nil {
+				return nil
+			}
+			failed := map[string]error{}
+			for _, ch := range event.Channels {
+				failed[ch] = err
+			}
+			return retries.deadLetter(event.Topic, event.Payload, failed, event.Attempt-1, message.DeadLetterHandlerFailed)
+		},
+	)
+}
+
+// consumeDailySummaries reads the summary topic and emails each daily summary
+// to its recipient. A summary that fails to send is logged and dropped; the
+// next day's summary replaces it.
+func consumeDailySummaries(ctx context.Context, transport message.Transport, c message.Consumer, p *pauses, h *health, n *notifier, retries *retryQueue) {
+	consumeWithBackoff(ctx, transport, c, p, h, retries,
+		func(ctx context.Context, value []byte) error {
+			var event message.SummaryEvent
+			if err := json.Unmarshal(value, &event); err != nil {
+				slog.Warn("⚠️  Unmarshal error", logger.FieldTopic, message.TopicSummary, logger.FieldError, err)
+				return nil
+			}
+			n.sendDailySummary(event, value)
+			return nil
+		},
+		nil,
+	)
+}
+
+// consumeWithBackoff runs the consume loop for a topic/group, consuming again with
+// exponential backoff whenever the transport returns a persistent error or an event
+// can't be handled. This handles transient broker errors (e.g. "Group Coordinator Not
+// Available") without spinning the CPU. While the topic is paused, events are
+// held unhandled.
+//
+// An event that fails (or panics) NOTIFY_HANDLER_ATTEMPTS times in a row is a
+// poison message: it is passed to setAside, typically to dead-letter it, and
+// acknowledged, so it doesn't block the events after it. Without setAside it
+// is dropped.
+func consumeWithBackoff(
+	ctx context.Context,
+	transport message.Transport,
+	c message.Consumer,
+	p *pauses,
+	h *health,
+	retries *retryQueue,
+	handle func(context.Context, []byte) error,
+	setAside func(value []byte, err error) error,
+) {
+	slog.Info("🔄 Consumer started, waiting for messages", logger.FieldTopic, c.Topic)
+	attempts := newHandlerAttempts(retries.handlerAttempts)
+
+	const (
+		backoffMin = 2 * time.Second
+		backoffMax = 60 * time.Second
+	)
+	backoff := backoffMin
+	// Set by the handlers, which may run concurrently, when an event was
+	// handled; the loop resets the backoff after Consume returns
+	var progressed atomic.Bool
+
+	for {
+		if ctx.Err() != nil {
+			return
+		}
+
+		h.consuming(c.Topic)
+		err := transport.Consume(ctx, c, func(ctx context.Context, value []byte) error {
+			// Events read just before shutdown are left for the next start
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			if err := p.wait(ctx, c.Topic); err != nil {
+				return err
+			}
+			// A span of the trace the publisher started, if the transport carried it
+			spanCtx, span := tracing.StartConsumer(ctx, c.Topic, c.Group)
+			err := handleSafely(spanCtx, c.Topic, value, handle)
+			tracing.End(span, err)
+			if err == nil {
+				attempts.handled(value)
+				eventsProcessed.WithLabelValues(c.Topic, "ok").Inc()
+				h.processed(c.Topic)
+				progressed.Store(true)
+				return nil
+			}
+			eventsProcessed.WithLabelValues(c.Topic, "error").Inc()
+			// Failing because of shutdown isn't the event's fault
+			if ctx.Err() != nil {
+				return err
+			}
+			n, last := attempts.failed(value)
+			if !last {
+				return err
+			}
+			slog.Error("☠️  Setting an event aside after repeated failures", logger.FieldTopic, c.Topic, "attempts", n, logger.FieldError, err)
+			if setAside != nil {
+				if err := setAside(value, err); err != nil {
+					return err // Tried again on the next failure
+				}
+			}
+			attempts.handled(value)
+			return nil
+		})
+		if ctx.Err() != nil {
+			return
+		}
+		if progressed.Swap(false) {
+			backoff = backoffMin // reset after handled events
+		}
+		h.failed(c.Topic, err)
+		slog.Warn("⚠️  Read error", logger.FieldTopic, c.Topic, "retry_in", backoff, logger.FieldError, err)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+		// Exponential backoff, capped at backoffMax
+		backoff *= 2
+		if backoff > backoffMax {
+			backoff = backoffMax
+		}
+	}
+}
+
+func envInt(key string, defaultVal int) int {
+	if v, err := strconv.Atoi(os.Getenv(key)); err == nil {
+		return v
+	}
+	return defaultVal
+}
+
+func envDuration(key string, defaultVal time.Duration) time.Duration {
+	if v, err := time.ParseDuration(os.Getenv(key)); err == nil {
+		return v
+	}
+	return defaultVal
+}
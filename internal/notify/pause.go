@@ -0,0 +1,79 @@
+package notify
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"time"
+)
+
+// pauses holds the topics whose consumption is paused through the admin
+// interface, e.g. during a provider incident
+type pauses struct {
+	topics []string // The topics that can be paused
+
+	mu      sync.Mutex
+	paused  map[string]time.Time // Topic -> when it was paused
+	resumed chan struct{}        // Closed and replaced on every resume
+}
+
+func newPauses(topics []string) *pauses {
+	return &pauses{topics: topics, paused: map[string]time.Time{}, resumed: make(chan struct{})}
+}
+
+// known reports whether topic is consumed by the service
+func (p *pauses) known(topic string) bool {
+	return slices.Contains(p.topics, topic)
+}
+
+// pause stops handing topic's events to their handlers. It reports whether
+// the topic was running.
+func (p *pauses) pause(topic string) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if _, ok := p.paused[topic]; ok {
+		return false
+	}
+	p.paused[topic] = time.Now()
+	return true
+}
+
+// resume hands topic's events to their handlers again. It reports whether the
+// topic was paused.
+func (p *pauses) resume(topic string) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if _, ok := p.paused[topic]; !ok {
+		return false
+	}
+	delete(p.paused, topic)
+	close(p.resumed)
+	p.resumed = make(chan struct{})
+	return true
+}
+
+// pausedAt returns when topic was paused, or false when it is running
+func (p *pauses) pausedAt(topic string) (time.Time, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	at, ok := p.paused[topic]
+	return at, ok
+}
+
+// wait blocks while topic is paused, until ctx is done
+func (p *pauses) wait(ctx context.Context, topic string) error {
+	for {
+		p.mu.Lock()
+		_, paused := p.paused[topic]
+		resumed := p.resumed
+		p.mu.Unlock()
+		if !paused {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-resumed:
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"log/slog"
+	"runtime/debug"
+	"sync"
+
+	"crypto-alert/internal/logger"
+)
+
+// maxTrackedEvents bounds the failed events tracked per consumer; beyond it
+// the counts start over
+const maxTrackedEvents = 10000
+
+// handlerAttempts counts how often each event of a consumer failed to be
+// handled, so one that keeps failing is set aside instead of being read again
+// forever and holding back the events after it on its partition
+type handlerAttempts struct {
+	max int // Failed attempts before an event is set aside
+
+	mu     sync.Mutex
+	counts map[uint64]int // Hash of the event -> failed attempts
+}
+
+func newHandlerAttempts(max int) *handlerAttempts {
+	return &handlerAttempts{max: max, counts: map[uint64]int{}}
+}
+
+// failed counts a failed attempt to handle value and reports whether the
+// event is out of attempts
+func (a *handlerAttempts) failed(value []byte) (int, bool) {
+	key := eventHash(value)
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if len(a.counts) >= maxTrackedEvents {
+		clear(a.counts)
+	}
+	a.counts[key]++
+	n := a.counts[key]
+	return n, n >= a.max
+}
+
+// handled forgets the failed attempts of value, once it was handled or set
+// aside
+func (a *handlerAttempts) handled(value []byte) {
+	key := eventHash(value)
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	delete(a.counts, key)
+}
+
+func eventHash(value []byte) uint64 {
+	h := fnv.New64a()
+	h.Write(value)
+	return h.Sum64()
+}
+
+// handleSafely runs handle, turning a panic into an error so the event
+// counts as failed instead of taking the service down
+func handleSafely(ctx context.Context, topic string, value []byte, handle func(context.Context, []byte) error) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			slog.Error("💥 Handler panicked", logger.FieldTopic, topic, "panic", r, "stack", string(debug.Stack()))
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return handle(ctx, value)
+}
@@ -0,0 +1,98 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"time"
+
+	"crypto-alert/internal/logger"
+	"crypto-alert/internal/message"
+	"crypto-alert/internal/store"
+)
+
+// Replay reads the events published on an alert topic since from again and
+// sends each to the rule's channels, e.g. after a provider outage. Replayed
+// alerts skip the dedup check, digests, grouping and the rate limit, and open
+// no escalations. With dryRun nothing is sent; each event is printed with the
+// destinations it would go to, e.g. to try template changes on real payloads.
+func Replay(ctx context.Context, transport *message.KafkaTransport, topic string, from time.Time, dryRun bool) error {
+	if _, ok := alertDecoders[topic]; !ok {
+		return fmt.Errorf("%s is not an alert topic", topic)
+	}
+	channels, err := message.NewChannels(os.Getenv)
+	if err != nil {
+		return fmt.Errorf("notification channel configuration: %w", err)
+	}
+	n := &notifier{
+		channels: channels,
+		muter:    message.NewMuter(),
+		dedup:    message.NewDeduper(0),
+		digests:  message.NewDigester(),
+		groups:   message.NewGrouper(0),
+		replay:   true,
+	}
+	// Registered Telegram chats resolve registration tokens; replayed sends
+	// are recorded in the delivery log
+	if dsn := os.Getenv("MYSQL_DSN"); dsn != "" {
+		if n.telegramChats, err = store.NewTelegramChats(dsn); err != nil {
+			slog.Warn("⚠️  Telegram chat registration lookups disabled", logger.FieldError, err)
+		} else {
+			defer n.telegramChats.Close()
+		}
+		if !dryRun {
+			if n.deliveries, err = store.NewNotificationLog(dsn); err != nil {
+				slog.Warn("⚠️  Notification log disabled", logger.FieldError, err)
+			} else {
+				defer n.deliveries.Close()
+			}
+		}
+	}
+
+	var replayed, failed int
+	err = transport.Replay(ctx, topic, from, func(e message.ReplayedEvent) error {
+		replayed++
+		if dryRun {
+			n.print(topic, e)
+			return nil
+		}
+		slog.Info("⏪ Replaying event", logger.FieldTopic, topic, logger.FieldEventID, e.ID, "time", e.Time.UTC())
+		channels, err := n.deliver(ctx, topic, e.Value, nil, 0)
+		if err != nil {
+			slog.Warn("⚠️  Unmarshal error", logger.FieldTopic, topic, logger.FieldEventID, e.ID, logger.FieldError, err)
+			return nil
+		}
+		if len(channels) > 0 {
+			failed++
+		}
+		return nil
+	})
+	slog.Info("⏪ Replayed events", logger.FieldTopic, topic, "since", from.UTC(), "events", replayed, "failed", failed)
+	return err
+}
+
+// print writes a replayed event, the destinations it would be sent to and its
+// payload to stdout
+func (n *notifier) print(topic string, e message.ReplayedEvent) {
+	fmt.Printf("--- %s event %s, published %s\n", topic, e.ID, e.Time.UTC().Format(time.RFC3339))
+	targets, what, _, err := alertDecoders[topic](e.Value)
+	if err != nil {
+		fmt.Printf("undecodable: %v\n", err)
+	} else {
+		fmt.Printf("alert for %s, event %s\n", what, targets.EventID)
+		for _, ch := range n.channels {
+			for _, to := range n.destinations(ch.Name(), targets) {
+				fmt.Printf("  would send %s to %s\n", ch.Name(), to)
+			}
+		}
+	}
+	var payload bytes.Buffer
+	if json.Indent(&payload, e.Value, "", "  ") != nil {
+		payload.Reset()
+		payload.Write(e.Value)
+	}
+	fmt.Println(payload.String())
+}
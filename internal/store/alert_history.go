@@ -0,0 +1,361 @@
+package store
+
+import (
+	"database/sql"
+	"encoding/json"
+	"slices"
+	"strings"
+	"time"
+)
+
+// AlertHistoryEntry is one alert a rule triggered
+type AlertHistoryEntry struct {
+	ID          int64     `json:"id"`
+	RuleKind    string    `json:"rule_kind"` // token, defi, predict or watch
+	RuleID      int64     `json:"rule_id"`
+	EventID     string    `json:"event_id,omitempty"` // Alert event ID, the event_id of its deliveries in notification_log
+	Subject     string    `json:"subject"`            // Token symbol, DeFi protocol, prediction market or watch source
+	Field       string    `json:"field"`
+	Value       float64   `json:"value"`
+	Threshold   float64   `json:"threshold"`
+	Direction   string    `json:"direction,omitempty"`
+	Severity    string    `json:"severity,omitempty"`
+	Message     string    `json:"message"`
+	Tags        []string  `json:"tags"` // The rule's tags when the alert triggered
+	TriggeredAt time.Time `json:"triggered_at"`
+	Channels    []string  `json:"channels"` // Channels the alert was sent on, from notification_log
+}
+
+// AlertHistory records the alerts the engine triggered in the alert_history
+// table, whichever channels they went out on
+type AlertHistory struct {
+	db      *sql.DB
+	dialect dialect
+}
+
+func NewAlertHistory(dsn string) (*AlertHistory, error) {
+	d, dsn := dialectOf(dsn)
+	db, err := openDB(d, dsn, 2, 1)
+	if err != nil {
+		return nil, err
+	}
+	return &AlertHistory{db: db, dialect: d}, nil
+}
+
+func (h *AlertHistory) Close() {
+	if h != nil && h.db != nil {
+		h.db.Close()
+	}
+}
+
+// Record inserts a triggered alert, at the current time. It is a no-op on a
+// nil history.
+func (h *AlertHistory) Record(e AlertHistoryEntry) error {
+	if h == nil {
+		return nil
+	}
+	tags := ""
+	if len(e.Tags) > 0 {
+		b, err := json.Marshal(e.Tags)
+		if err != nil {
+			return err
+		}
+		tags = string(b)
+	}
+	_, err := h.db.Exec(
+		h.dialect.rebind(`INSERT INTO alert_history (rule_kind, rule_id, event_id, subject, field, value, threshold, direction, severity, message, tags, triggered_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, `+h.dialect.now()+`)`),
+		e.RuleKind, e.RuleID, e.EventID, e.Subject, e.Field, e.Value, e.Threshold, e.Direction, e.Severity, e.Message, tags,
+	)
+	return err
+}
+
+// AlertHistoryFilter selects alerts from the history; empty fields match
+// every alert
+type AlertHistoryFilter struct {
+	Kind      string // token, defi, predict or watch
+	RuleID    int64
+	Subject   string // Token symbol, DeFi protocol, prediction market or watch source, any case
+	Severity  string
+	Tag       string    // Alerts of rules with this tag, lowercase
+	Recipient string    // Alerts with a send to this recipient in notification_log
+	Since     time.Time // Alerts triggered at or after
+	Until     time.Time // Alerts triggered before
+	BeforeID  int64     // Alerts older than the one with this ID, the cursor of the next page
+	Limit     int
+}
+
+// List returns the latest alerts the filter selects, newest first, each with
+// the channels the notification service sent it on
+func (h *AlertHistory) List(f AlertHistoryFilter) ([]AlertHistoryEntry, error) {
+	if h == nil {
+		return nil, nil
+	}
+	query := `SELECT ` + alertHistoryColumns(h.dialect) + ` FROM alert_history WHERE 1 = 1`
+	var args []interface{}
+	if f.Kind != "" {
+		query, args = query+` AND rule_kind = ?`, append(args, f.Kind)
+	}
+	if f.RuleID != 0 {
+		query, args = query+` AND rule_id = ?`, append(args, f.RuleID)
+	}
+	if f.Subject != "" {
+		query, args = query+` AND LOWER(subject) = LOWER(?)`, append(args, f.Subject)
+	}
+	if f.Severity != "" {
+		query, args = query+` AND severity = ?`, append(args, f.Severity)
+	}
+	if f.Tag != "" {
+		// tags is the JSON array of the rule's tags
+		query, args = query+` AND tags LIKE ? ESCAPE '!'`, append(args, `%"`+likeEscaper.Replace(f.Tag)+`"%`)
+	}
+	if f.Recipient != "" {
+		query, args = query+` AND event_id IN (SELECT event_id FROM notification_log WHERE recipient = ?)`, append(args, f.Recipient)
+	}
+	if !f.Since.IsZero() {
+		query, args = query+` AND triggered_at >= ?`, append(args, f.Since.UTC().Format("2006-01-02 15:04:05"))
+	}
+	if !f.Until.IsZero() {
+		query, args = query+` AND triggered_at < ?`, append(args, f.Until.UTC().Format("2006-01-02 15:04:05"))
+	}
+	if f.BeforeID != 0 {
+		query, args = query+` AND id < ?`, append(args, f.BeforeID)
+	}
+	query, args = query+` ORDER BY id DESC LIMIT ?`, append(args, f.Limit)
+	rows, err := h.db.Query(h.dialect.rebind(query), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []AlertHistoryEntry
+	for rows.Next() {
+		e, err := scanAlertHistory(rows)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return entries, h.addChannels(entries)
+}
+
+// Get returns the alert with id, with the channels it was sent on, or nil
+// when there is none
+func (h *AlertHistory) Get(id int64) (*AlertHistoryEntry, error) {
+	if h == nil {
+		return nil, nil
+	}
+	row := h.db.QueryRow(h.dialect.rebind(`SELECT `+alertHistoryColumns(h.dialect)+` FROM alert_history WHERE id = ?`), id)
+	e, err := scanAlertHistory(row)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	entries := []AlertHistoryEntry{e}
+	if err := h.addChannels(entries); err != nil {
+		return nil, err
+	}
+	return &entries[0], nil
+}
+
+// LatestID returns the ID of the latest alert, 0 when there is none
+func (h *AlertHistory) LatestID() (int64, error) {
+	var id int64
+	err := h.db.QueryRow(`SELECT COALESCE(MAX(id), 0) FROM alert_history`).Scan(&id)
+	return id, err
+}
+
+// After returns up to limit alerts newer than the one with afterID, oldest
+// first and without their channels, for following the alerts as they come
+func (h *AlertHistory) After(afterID int64, limit int) ([]AlertHistoryEntry, error) {
+	rows, err := h.db.Query(h.dialect.rebind(`SELECT `+alertHistoryColumns(h.dialect)+` FROM alert_history WHERE id > ? ORDER BY id LIMIT ?`), afterID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []AlertHistoryEntry
+	for rows.Next() {
+		e, err := scanAlertHistory(rows)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, e)
+	}
+	return entries, rows.Err()
+}
+
+// AlertDayCount is how many alerts the rules of a kind triggered on a day
+type AlertDayCount struct {
+	Day   string // yyyyMMdd, UTC
+	Kind  string
+	Count int
+}
+
+// DailyCounts returns how many alerts each rule kind triggered per day, from
+// since until before until
+func (h *AlertHistory) DailyCounts(since, until time.Time) ([]AlertDayCount, error) {
+	day := h.dialect.day("triggered_at")
+	rows, err := h.db.Query(
+		h.dialect.rebind(`SELECT `+day+`, rule_kind, COUNT(*) FROM alert_history WHERE triggered_at >= ? AND triggered_at < ? GROUP BY `+day+`, rule_kind`),
+		since.UTC().Format("2006-01-02 15:04:05"), until.UTC().Format("2006-01-02 15:04:05"),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var counts []AlertDayCount
+	for rows.Next() {
+		var c AlertDayCount
+		if err := rows.Scan(&c.Day, &c.Kind, &c.Count); err != nil {
+			return nil, err
+		}
+		counts = append(counts, c)
+	}
+	return counts, rows.Err()
+}
+
+// SubjectCount is how many alerts the rules on a subject triggered
+type SubjectCount struct {
+	Subject string `json:"subject"`
+	Alerts  int    `json:"alerts"`
+}
+
+// TopSubjects returns the limit subjects of kind's rules that triggered the
+// most alerts from since until before until, most first
+func (h *AlertHistory) TopSubjects(kind string, since, until time.Time, limit int) ([]SubjectCount, error) {
+	rows, err := h.db.Query(
+		h.dialect.rebind(`SELECT subject, COUNT(*) AS alerts FROM alert_history WHERE rule_kind = ? AND triggered_at >= ? AND triggered_at < ? GROUP BY subject ORDER BY alerts DESC, subject LIMIT ?`),
+		kind, since.UTC().Format("2006-01-02 15:04:05"), until.UTC().Format("2006-01-02 15:04:05"), limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := []SubjectCount{}
+	for rows.Next() {
+		var c SubjectCount
+		if err := rows.Scan(&c.Subject, &c.Alerts); err != nil {
+			return nil, err
+		}
+		counts = append(counts, c)
+	}
+	return counts, rows.Err()
+}
+
+// AlertDelivery is one send of an alert, as notification_log has it
+type AlertDelivery struct {
+	Channel   string    `json:"channel"`
+	Recipient string    `json:"recipient"`
+	Status    string    `json:"status"`
+	Error     string    `json:"error,omitempty"`
+	Attempt   int       `json:"attempt"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Deliveries returns the sends of the alert with eventID, oldest first
+func (h *AlertHistory) Deliveries(eventID string) ([]AlertDelivery, error) {
+	if h == nil || eventID == "" {
+		return nil, nil
+	}
+	rows, err := h.db.Query(
+		h.dialect.rebind(`SELECT channel, recipient, status, COALESCE(error, ''), attempt, `+h.dialect.secondsBetween(`created_at`, h.dialect.now())+` FROM notification_log WHERE event_id = ? ORDER BY id`),
+		eventID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	now := time.Now().UTC()
+	var deliveries []AlertDelivery
+	for rows.Next() {
+		var d AlertDelivery
+		var secondsAgo int64
+		if err := rows.Scan(&d.Channel, &d.Recipient, &d.Status, &d.Error, &d.Attempt, &secondsAgo); err != nil {
+			return nil, err
+		}
+		d.CreatedAt = now.Add(-time.Duration(secondsAgo) * time.Second).Truncate(time.Second)
+		deliveries = append(deliveries, d)
+	}
+	return deliveries, rows.Err()
+}
+
+// alertHistoryColumns are the columns scanAlertHistory reads
+func alertHistoryColumns(d dialect) string {
+	return `id, rule_kind, rule_id, event_id, subject, field, value, threshold, direction, severity, message, tags, ` + d.secondsBetween(`triggered_at`, d.now())
+}
+
+// scanAlertHistory reads an alert of alertHistoryColumns
+func scanAlertHistory(row interface{ Scan(...interface{}) error }) (AlertHistoryEntry, error) {
+	var e AlertHistoryEntry
+	var tags string
+	var secondsAgo int64
+	if err := row.Scan(&e.ID, &e.RuleKind, &e.RuleID, &e.EventID, &e.Subject, &e.Field, &e.Value, &e.Threshold, &e.Direction, &e.Severity, &e.Message, &tags, &secondsAgo); err != nil {
+		return e, err
+	}
+	e.Tags = []string{}
+	if tags != "" {
+		json.Unmarshal([]byte(tags), &e.Tags)
+	}
+	e.TriggeredAt = time.Now().UTC().Add(-time.Duration(secondsAgo) * time.Second).Truncate(time.Second)
+	return e, nil
+}
+
+// likeEscaper escapes the LIKE wildcards of a value, with ESCAPE '!'
+var likeEscaper = strings.NewReplacer("!", "!!", "%", "!%", "_", "!_")
+
+// sentStatuses are the notification_log statuses of sends that went out
+var sentStatuses = []string{DeliveryStatusSent, DeliveryStatusDelivered, DeliveryStatusBounced, DeliveryStatusComplained}
+
+// addChannels sets the channels each entry was sent on
+func (h *AlertHistory) addChannels(entries []AlertHistoryEntry) error {
+	var eventIDs []interface{}
+	for i := range entries {
+		entries[i].Channels = []string{}
+		if entries[i].EventID != "" {
+			eventIDs = append(eventIDs, entries[i].EventID)
+		}
+	}
+	if len(eventIDs) == 0 {
+		return nil
+	}
+	args := eventIDs
+	for _, s := range sentStatuses {
+		args = append(args, s)
+	}
+	rows, err := h.db.Query(h.dialect.rebind(
+		`SELECT DISTINCT event_id, channel FROM notification_log WHERE event_id IN (`+placeholders(len(eventIDs))+`) AND status IN (`+placeholders(len(sentStatuses))+`)`,
+	), args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	channels := map[string][]string{}
+	for rows.Next() {
+		var eventID, channel string
+		if err := rows.Scan(&eventID, &channel); err != nil {
+			return err
+		}
+		channels[eventID] = append(channels[eventID], channel)
+	}
+	for i, e := range entries {
+		if chs := channels[e.EventID]; len(chs) > 0 {
+			slices.Sort(chs)
+			entries[i].Channels = chs
+		}
+	}
+	return rows.Err()
+}
+
+// placeholders is a list of n ? placeholders
+func placeholders(n int) string {
+	return strings.TrimSuffix(strings.Repeat("?, ", n), ", ")
+}
@@ -0,0 +1,169 @@
+package store
+
+import (
+	"database/sql"
+	"fmt"
+	"strconv"
+	"strings"
+
+	_ "github.com/go-sql-driver/mysql"
+	_ "github.com/lib/pq"
+	_ "modernc.org/sqlite"
+)
+
+// dialect is the SQL that differs between the databases the stores run on.
+// Queries are written with ? placeholders and the helpers below for what
+// MySQL, PostgreSQL and SQLite spell differently.
+type dialect struct {
+	name string // database/sql driver name: mysql, postgres or sqlite
+}
+
+var (
+	mysqlDialect    = dialect{name: "mysql"}
+	postgresDialect = dialect{name: "postgres"}
+	sqliteDialect   = dialect{name: "sqlite"}
+)
+
+// sqlitePragmas are set on every SQLite connection: the services share the
+// database file, so writers wait for each other instead of failing
+const sqlitePragmas = "_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)"
+
+// dialectOf picks the database of dsn by its scheme: a postgres:// or
+// postgresql:// URL is PostgreSQL, sqlite:<path> (sqlite:///var/lib/alerts.db
+// for an absolute path) a SQLite file, anything else a go-sql-driver MySQL
+// DSN (user:pass@tcp(host:3306)/web3), optionally prefixed with mysql://. It
+// returns the DSN the driver takes.
+func dialectOf(dsn string) (dialect, string) {
+	switch {
+	case strings.HasPrefix(dsn, "postgres://"), strings.HasPrefix(dsn, "postgresql://"):
+		return postgresDialect, dsn
+	case strings.HasPrefix(dsn, "sqlite:"):
+		path := strings.TrimPrefix(strings.TrimPrefix(dsn, "sqlite:"), "//")
+		if strings.Contains(path, "?") {
+			return sqliteDialect, path + "&" + sqlitePragmas
+		}
+		return sqliteDialect, path + "?" + sqlitePragmas
+	}
+	return mysqlDialect, strings.TrimPrefix(dsn, "mysql://")
+}
+
+// openDB opens and pings a connection pool of at most maxOpen connections
+func openDB(d dialect, dsn string, maxOpen, maxIdle int) (*sql.DB, error) {
+	db, err := sql.Open(d.name, dsn)
+	if err != nil {
+		return nil, fmt.Errorf("open %s: %w", d.name, err)
+	}
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("%s ping: %w", d.name, err)
+	}
+	db.SetMaxOpenConns(maxOpen)
+	db.SetMaxIdleConns(maxIdle)
+	return db, nil
+}
+
+// rebind turns the ? placeholders of query into the dialect's
+func (d dialect) rebind(query string) string {
+	if d != postgresDialect { // MySQL and SQLite take ?
+		return query
+	}
+	var b strings.Builder
+	n := 0
+	for _, r := range query {
+		if r == '?' {
+			n++
+			b.WriteString("$" + strconv.Itoa(n))
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// now is the current UTC time, as the DATETIME / TIMESTAMP columns hold it
+func (d dialect) now() string {
+	switch d {
+	case postgresDialect:
+		return `(NOW() AT TIME ZONE 'UTC')`
+	case sqliteDialect:
+		return `datetime('now')`
+	}
+	return `UTC_TIMESTAMP()`
+}
+
+// nowPlusSeconds is the current UTC time plus a ? placeholder of seconds
+func (d dialect) nowPlusSeconds() string {
+	switch d {
+	case postgresDialect:
+		return d.now() + ` + make_interval(secs => ?)`
+	case sqliteDialect:
+		return `datetime('now', ? || ' seconds')`
+	}
+	return `UTC_TIMESTAMP() + INTERVAL ? SECOND`
+}
+
+// secondsBetween is the whole seconds from one time column or expression to
+// another, NULL when either is
+func (d dialect) secondsBetween(from, to string) string {
+	switch d {
+	case postgresDialect:
+		return `CAST(EXTRACT(EPOCH FROM (` + to + ` - ` + from + `)) AS BIGINT)`
+	case sqliteDialect:
+		return `(` + d.unixTime(to) + ` - ` + d.unixTime(from) + `)`
+	}
+	return `TIMESTAMPDIFF(SECOND, ` + from + `, ` + to + `)`
+}
+
+// unixTime is a time column or expression as seconds since the epoch
+func (d dialect) unixTime(expr string) string {
+	switch d {
+	case postgresDialect:
+		return `CAST(EXTRACT(EPOCH FROM ` + expr + `) AS BIGINT)`
+	case sqliteDialect:
+		return `CAST(strftime('%s', ` + expr + `) AS INTEGER)`
+	}
+	return `UNIX_TIMESTAMP(` + expr + `)`
+}
+
+// day is a time column or expression as its yyyyMMdd day
+func (d dialect) day(expr string) string {
+	switch d {
+	case postgresDialect:
+		return `to_char(` + expr + `, 'YYYYMMDD')`
+	case sqliteDialect:
+		return `strftime('%Y%m%d', ` + expr + `)`
+	}
+	return `DATE_FORMAT(` + expr + `, '%Y%m%d')`
+}
+
+// upsert is the clause that makes an INSERT update columns of the row whose
+// key (the primary key columns) already exists
+func (d dialect) upsert(key string, columns ...string) string {
+	set := make([]string, len(columns))
+	for i, c := range columns {
+		if d == mysqlDialect {
+			set[i] = c + ` = VALUES(` + c + `)`
+		} else {
+			set[i] = c + ` = EXCLUDED.` + c
+		}
+	}
+	if d == mysqlDialect {
+		return ` ON DUPLICATE KEY UPDATE ` + strings.Join(set, ", ")
+	}
+	return ` ON CONFLICT (` + key + `) DO UPDATE SET ` + strings.Join(set, ", ")
+}
+
+// insertID runs an INSERT into a table with an id column and returns the
+// new row's id
+func (d dialect) insertID(db *sql.DB, query string, args ...interface{}) (int64, error) {
+	if d == postgresDialect {
+		var id int64
+		err := db.QueryRow(d.rebind(query)+` RETURNING id`, args...).Scan(&id)
+		return id, err
+	}
+	res, err := db.Exec(query, args...)
+	if err != nil {
+		return 0, err
+	}
+	return res.LastInsertId()
+}
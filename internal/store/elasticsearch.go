@@ -4,30 +4,35 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"fmt"
+	"os"
 	"sort"
 	"strings"
 	"time"
 
+	"crypto-alert/internal/logger"
+
 	"github.com/elastic/go-elasticsearch/v9"
 	"github.com/elastic/go-elasticsearch/v9/esapi"
 )
 
-// ESClient queries Elasticsearch for log dates and log lines (e.g. crypto-alert-logs index).
+// ESClient queries Elasticsearch for log dates and log lines (e.g.
+// crypto-alert-logs index). It is the LogSource of the indexed logs.
 type ESClient struct {
 	client *elasticsearch.Client
 	index  string
 }
 
-// NewESClient creates a client for querying logs from ES. Caller should close the client when done.
-func NewESClient(addresses []string, index string) (*ESClient, error) {
-	if len(addresses) == 0 || index == "" {
+// NewESClient creates a client for querying logs from the ES index of cfg. Caller should close the client when done.
+func NewESClient(cfg *logger.ESConfig) (*ESClient, error) {
+	if len(cfg.Addresses) == 0 || cfg.Index == "" {
 		return nil, nil
 	}
-	client, err := elasticsearch.NewClient(elasticsearch.Config{Addresses: addresses})
+	client, err := logger.NewESClient(cfg)
 	if err != nil {
 		return nil, err
 	}
-	return &ESClient{client: client, index: index}, nil
+	return &ESClient{client: client, index: cfg.Index}, nil
 }
 
 // Close releases the ES client.
@@ -38,8 +43,12 @@ func (c *ESClient) Close() error {
 	return nil
 }
 
-// GetDates returns sorted list of dates (yyyyMMdd) that have logs in ES, most recent first.
-func (c *ESClient) GetDates(ctx context.Context) ([]string, error) {
+func (c *ESClient) Name() string {
+	return "elasticsearch"
+}
+
+// Dates returns sorted list of dates (yyyyMMdd) that have logs in ES, most recent first.
+func (c *ESClient) Dates(ctx context.Context) ([]string, error) {
 	if c == nil || c.client == nil {
 		return nil, nil
 	}
@@ -96,130 +105,234 @@ func (c *ESClient) GetDates(ctx context.Context) ([]string, error) {
 // LogEntry is a single log line with a parsed timestamp.
 type LogEntry struct {
 	Message string `json:"message"`
-	TS      string `json:"ts"` // RFC3339
+	Level   string `json:"level"`             // INFO, WARN or ERROR
+	Service string `json:"service,omitempty"` // Service that wrote it, see logger.Services
+	TS      string `json:"ts"`                // RFC3339
 }
 
-// buildQuery wraps a range query with an optional full-text search on message.
-func buildQuery(tsRange map[string]interface{}, searchQ string) map[string]interface{} {
+// buildQuery wraps a range query with an optional full-text search on message
+// and the levels and services of f.
+func buildQuery(tsRange map[string]interface{}, f LogFilter) map[string]interface{} {
 	rangeQ := map[string]interface{}{"range": map[string]interface{}{"@timestamp": tsRange}}
-	if searchQ == "" {
+	if f.Query == "" && len(f.Levels) == 0 && len(f.Services) == 0 {
 		return rangeQ
 	}
-	return map[string]interface{}{
-		"bool": map[string]interface{}{
-			"must": []interface{}{
-				rangeQ,
-				map[string]interface{}{
-					"simple_query_string": map[string]interface{}{
-						"query":  searchQ,
-						"fields": []string{"message"},
-					},
-				},
+	must := []interface{}{rangeQ}
+	if f.Query != "" {
+		must = append(must, map[string]interface{}{
+			"simple_query_string": map[string]interface{}{
+				"query":  f.Query,
+				"fields": []string{"message"},
 			},
-		},
+		})
 	}
-}
-
-// fetchESLogs pages through ES results for the given query using search_after, returning all entries.
-func (c *ESClient) fetchESLogs(ctx context.Context, query map[string]interface{}) ([]LogEntry, error) {
-	const pageSize = 10000
-	sortClause := []map[string]interface{}{{"@timestamp": map[string]string{"order": "asc"}}}
-
-	var allEntries []LogEntry
-	var searchAfter []interface{}
-
-	for {
-		body := map[string]interface{}{
-			"size":    pageSize,
-			"sort":    sortClause,
-			"_source": []string{"message", "@timestamp"},
-			"query":   query,
+	for field, values := range map[string][]string{"level": f.Levels, "service": f.Services} {
+		if len(values) == 0 {
+			continue
 		}
-		if len(searchAfter) > 0 {
-			body["search_after"] = searchAfter
+		// A match phrase per value works whether the field is mapped as text or keyword
+		var should []interface{}
+		for _, v := range values {
+			should = append(should, map[string]interface{}{"match_phrase": map[string]interface{}{field: v}})
 		}
+		must = append(must, map[string]interface{}{
+			"bool": map[string]interface{}{"should": should, "minimum_should_match": 1},
+		})
+	}
+	return map[string]interface{}{"bool": map[string]interface{}{"must": must}}
+}
 
-		var buf bytes.Buffer
-		if err := json.NewEncoder(&buf).Encode(body); err != nil {
-			return nil, err
-		}
-		res, err := esapi.SearchRequest{Index: []string{c.index}, Body: &buf}.Do(ctx, c.client)
-		if err != nil {
-			return nil, err
-		}
-		var out struct {
-			Hits struct {
-				Hits []struct {
-					Source struct {
-						Message   string `json:"message"`
-						Timestamp string `json:"@timestamp"`
-					} `json:"_source"`
-					Sort []interface{} `json:"sort"`
-				} `json:"hits"`
+// Page returns the page of at most q.Limit log entries of the given date
+// (yyyyMMdd) starting at q.After, oldest first, those q selects. Entries
+// indexed without a level or service only match without q.Levels or
+// q.Services.
+func (c *ESClient) Page(ctx context.Context, dateStr string, q LogQuery) (LogPage, error) {
+	if c == nil || c.client == nil {
+		return LogPage{}, nil
+	}
+	f, after, limit := q.LogFilter, q.After, q.Limit
+	t, err := time.Parse("20060102", dateStr)
+	if err != nil {
+		return LogPage{}, err
+	}
+	tsRange := map[string]interface{}{"gte": t.UTC().Format(time.RFC3339), "lt": t.Add(24 * time.Hour).UTC().Format(time.RFC3339)}
+	if f.Since != "" {
+		delete(tsRange, "gte")
+		tsRange["gt"] = f.Since
+	}
+	total, err := c.countLogs(ctx, buildQuery(tsRange, f))
+	if err != nil || total == 0 {
+		return LogPage{End: after}, err
+	}
+
+	// The entries at the cursor's timestamp come first, the pages so far had Skip of them
+	if !after.TS.IsZero() {
+		delete(tsRange, "gt")
+		tsRange["gte"] = after.TS.UTC().Format(time.RFC3339Nano)
+	}
+	body := map[string]interface{}{
+		"from":    after.Skip,
+		"size":    limit + 1,
+		"sort":    []map[string]interface{}{{"@timestamp": map[string]string{"order": "asc"}}},
+		"_source": []string{"message", "level", "service", "@timestamp"},
+		"query":   buildQuery(tsRange, f),
+	}
+	var buf bytes.Buffer
+	if err := json.NewEncoder(&buf).Encode(body); err != nil {
+		return LogPage{}, err
+	}
+	res, err := esapi.SearchRequest{Index: []string{c.index}, Body: &buf}.Do(ctx, c.client)
+	if err != nil {
+		return LogPage{}, err
+	}
+	var out struct {
+		Hits struct {
+			Hits []struct {
+				Source struct {
+					Message   string `json:"message"`
+					Level     string `json:"level"`
+					Service   string `json:"service"`
+					Timestamp string `json:"@timestamp"`
+				} `json:"_source"`
 			} `json:"hits"`
-		}
-		decodeErr := json.NewDecoder(res.Body).Decode(&out)
-		res.Body.Close()
-		if res.IsError() {
-			return nil, errFromESResponse(res)
-		}
-		if decodeErr != nil {
-			return nil, decodeErr
-		}
+		} `json:"hits"`
+	}
+	decodeErr := json.NewDecoder(res.Body).Decode(&out)
+	res.Body.Close()
+	if res.IsError() {
+		return LogPage{}, errFromESResponse(res)
+	}
+	if decodeErr != nil {
+		return LogPage{}, decodeErr
+	}
 
-		hits := out.Hits.Hits
-		if len(hits) == 0 {
-			break
+	page := LogPage{Total: total, End: after}
+	hits := out.Hits.Hits
+	for _, h := range hits[:min(limit, len(hits))] {
+		level := h.Source.Level
+		if level == "" {
+			level = logger.LevelOf(h.Source.Message) // Indexed before documents had a level
 		}
-		for _, h := range hits {
-			msg := strings.TrimSpace(h.Source.Message)
-			if msg != "" {
-				allEntries = append(allEntries, LogEntry{Message: msg, TS: h.Source.Timestamp})
+		page.Entries = append(page.Entries, LogEntry{Message: strings.TrimSpace(h.Source.Message), Level: level, Service: h.Source.Service, TS: h.Source.Timestamp})
+	}
+	if n := len(page.Entries); n > 0 {
+		end := LogCursor{}
+		end.TS, _ = time.Parse(time.RFC3339Nano, page.Entries[n-1].TS)
+		for _, e := range page.Entries {
+			if ts, err := time.Parse(time.RFC3339Nano, e.TS); err == nil && ts.Equal(end.TS) {
+				end.Skip++
 			}
 		}
-		if len(hits) < pageSize {
-			break
+		if end.TS.Equal(after.TS) {
+			end.Skip += after.Skip
 		}
-		searchAfter = hits[len(hits)-1].Sort
-		if len(searchAfter) == 0 {
-			break
+		page.End = end
+		if len(hits) > limit {
+			page.Next = end.String()
 		}
 	}
-	return allEntries, nil
+	return page, nil
 }
 
-// GetLogsForDate returns all log entries for the given date (yyyyMMdd), optionally filtered by searchQ.
-// Pages through ES automatically using search_after to return the complete day's logs.
-func (c *ESClient) GetLogsForDate(ctx context.Context, dateStr, searchQ string) ([]LogEntry, error) {
+// CountPhrases returns, per day (yyyyMMdd, UTC) from since until before
+// until and for each name of phrases, how many log entries contain all of its
+// phrases. Days without entries are left out.
+func (c *ESClient) CountPhrases(ctx context.Context, since, until time.Time, phrases map[string][]string) (map[string]map[string]int, string, error) {
 	if c == nil || c.client == nil {
-		return nil, nil
+		return nil, c.Name(), nil
 	}
-	t, err := time.Parse("20060102", dateStr)
+	filters := map[string]interface{}{}
+	for name, all := range phrases {
+		var must []interface{}
+		for _, p := range all {
+			must = append(must, map[string]interface{}{"match_phrase": map[string]interface{}{"message": p}})
+		}
+		filters[name] = map[string]interface{}{"bool": map[string]interface{}{"must": must}}
+	}
+	body := map[string]interface{}{
+		"size": 0,
+		"query": map[string]interface{}{"range": map[string]interface{}{"@timestamp": map[string]interface{}{
+			"gte": since.UTC().Format(time.RFC3339),
+			"lt":  until.UTC().Format(time.RFC3339),
+		}}},
+		"aggs": map[string]interface{}{
+			"by_day": map[string]interface{}{
+				"date_histogram": map[string]interface{}{
+					"field":             "@timestamp",
+					"calendar_interval": "day",
+					"format":            "yyyyMMdd",
+					"min_doc_count":     1,
+				},
+				"aggs": map[string]interface{}{
+					"phrases": map[string]interface{}{"filters": map[string]interface{}{"filters": filters}},
+				},
+			},
+		},
+	}
+	var buf bytes.Buffer
+	if err := json.NewEncoder(&buf).Encode(body); err != nil {
+		return nil, "", err
+	}
+	res, err := esapi.SearchRequest{Index: []string{c.index}, Body: &buf}.Do(ctx, c.client)
 	if err != nil {
-		return nil, err
+		return nil, "", err
 	}
-	start := t.UTC().Format(time.RFC3339)
-	end := t.Add(24 * time.Hour).UTC().Format(time.RFC3339)
-	return c.fetchESLogs(ctx, buildQuery(map[string]interface{}{"gte": start, "lt": end}, searchQ))
+	var out struct {
+		Aggregations struct {
+			ByDay struct {
+				Buckets []struct {
+					Key     string `json:"key_as_string"`
+					Phrases struct {
+						Buckets map[string]struct {
+							DocCount int `json:"doc_count"`
+						} `json:"buckets"`
+					} `json:"phrases"`
+				} `json:"buckets"`
+			} `json:"by_day"`
+		} `json:"aggregations"`
+	}
+	decodeErr := json.NewDecoder(res.Body).Decode(&out)
+	res.Body.Close()
+	if res.IsError() {
+		return nil, "", errFromESResponse(res)
+	}
+	if decodeErr != nil {
+		return nil, "", decodeErr
+	}
+	days := map[string]map[string]int{}
+	for _, b := range out.Aggregations.ByDay.Buckets {
+		days[b.Key] = map[string]int{}
+		for name, p := range b.Phrases.Buckets {
+			days[b.Key][name] = p.DocCount
+		}
+	}
+	return days, c.Name(), nil
 }
 
-// GetLogsSince returns only log entries that arrived strictly after `since` (RFC3339) for the given date.
-// Used for incremental checkpoint-based updates.
-func (c *ESClient) GetLogsSince(ctx context.Context, dateStr, since, searchQ string) ([]LogEntry, error) {
-	if c == nil || c.client == nil {
-		return nil, nil
+// countLogs returns how many log entries match the query
+func (c *ESClient) countLogs(ctx context.Context, query map[string]interface{}) (int, error) {
+	var buf bytes.Buffer
+	if err := json.NewEncoder(&buf).Encode(map[string]interface{}{"query": query}); err != nil {
+		return 0, err
 	}
-	t, err := time.Parse("20060102", dateStr)
+	res, err := esapi.CountRequest{Index: []string{c.index}, Body: &buf}.Do(ctx, c.client)
 	if err != nil {
-		return nil, err
+		return 0, err
 	}
-	end := t.Add(24 * time.Hour).UTC().Format(time.RFC3339)
-	return c.fetchESLogs(ctx, buildQuery(map[string]interface{}{"gt": since, "lt": end}, searchQ))
+	var out struct {
+		Count int `json:"count"`
+	}
+	decodeErr := json.NewDecoder(res.Body).Decode(&out)
+	res.Body.Close()
+	if res.IsError() {
+		return 0, errFromESResponse(res)
+	}
+	return out.Count, decodeErr
 }
 
-// GetCheckpoint returns the RFC3339 timestamp of the most recent log entry for the given date.
+// Checkpoint returns the RFC3339 timestamp of the most recent log entry for the given date.
 // Returns an empty string when no entries exist.
-func (c *ESClient) GetCheckpoint(ctx context.Context, dateStr string) (string, error) {
+func (c *ESClient) Checkpoint(ctx context.Context, dateStr string) (string, error) {
 	if c == nil || c.client == nil {
 		return "", nil
 	}
@@ -271,6 +384,135 @@ func (c *ESClient) GetCheckpoint(ctx context.Context, dateStr string) (string, e
 	return out.Hits.Hits[0].Source.Timestamp, nil
 }
 
+// Scan hands the day's entries f selects to fn a page at a time, so a large
+// day is never held in memory
+func (c *ESClient) Scan(ctx context.Context, dateStr string, f LogFilter, fn func(LogEntry) error) error {
+	q := LogQuery{LogFilter: f, Limit: logFollowPageSize}
+	page, err := c.Page(ctx, dateStr, q)
+	if err != nil {
+		return err
+	}
+	if page.Total == 0 {
+		return fmt.Errorf("no entries of %s in %s: %w", dateStr, c.index, os.ErrNotExist)
+	}
+	for {
+		for _, e := range page.Entries {
+			if err := fn(e); err != nil {
+				return err
+			}
+		}
+		if page.Next == "" {
+			return nil
+		}
+		q.After = page.End
+		if page, err = c.Page(ctx, dateStr, q); err != nil {
+			return err
+		}
+	}
+}
+
+// Follow follows today's (UTC) entries from the latest one, or after
+// lastEventID when it is from today. Without entries today, it returns nil.
+func (c *ESClient) Follow(ctx context.Context, f LogFilter, lastEventID string) (LogFollower, error) {
+	follower := &esLogFollower{es: c, filter: f}
+	if follower.resume(lastEventID) {
+		return follower, nil
+	}
+	// Start after the latest entry, skipping what was logged before
+	follower.date = time.Now().UTC().Format("20060102")
+	checkpoint, err := c.Checkpoint(ctx, follower.date)
+	if err != nil || checkpoint == "" {
+		return nil, err
+	}
+	follower.cursor.TS, _ = time.Parse(time.RFC3339Nano, checkpoint)
+	if _, _, err := follower.Next(ctx); err != nil {
+		return nil, err
+	}
+	return follower, nil
+}
+
+// esLogFollower follows today's (UTC) logs in Elasticsearch with a log cursor
+type esLogFollower struct {
+	es     *ESClient
+	filter LogFilter
+	date   string
+	cursor LogCursor
+}
+
+func (f *esLogFollower) Next(ctx context.Context) ([]LogEntry, string, error) {
+	if date := time.Now().UTC().Format("20060102"); date != f.date {
+		f.date, f.cursor = date, LogCursor{}
+	}
+	var entries []LogEntry
+	for {
+		page, err := f.es.Page(ctx, f.date, LogQuery{LogFilter: f.filter, After: f.cursor, Limit: logFollowPageSize})
+		if err != nil {
+			return entries, f.eventID(), err
+		}
+		entries = append(entries, page.Entries...)
+		f.cursor = page.End
+		if page.Next == "" {
+			return entries, f.eventID(), nil
+		}
+	}
+}
+
+// eventID is <yyyyMMdd>/<cursor>, see resume
+func (f *esLogFollower) eventID() string {
+	return f.date + "/" + f.cursor.String()
+}
+
+// resume continues after the event ID a reconnecting client sent, when it is
+// from today
+func (f *esLogFollower) resume(lastEventID string) bool {
+	date, cursor, ok := strings.Cut(lastEventID, "/")
+	c, err := ParseLogCursor(cursor)
+	if !ok || err != nil || date != time.Now().UTC().Format("20060102") {
+		return false
+	}
+	f.date, f.cursor = date, c
+	return true
+}
+
+// DeleteLogsBefore deletes the log entries of the days before date (yyyyMMdd)
+// and returns how many it deleted
+func (c *ESClient) DeleteLogsBefore(ctx context.Context, dateStr string) (int, error) {
+	if c == nil || c.client == nil {
+		return 0, nil
+	}
+	t, err := time.Parse("20060102", dateStr)
+	if err != nil {
+		return 0, err
+	}
+	var buf bytes.Buffer
+	query := map[string]interface{}{
+		"query": map[string]interface{}{
+			"range": map[string]interface{}{
+				"@timestamp": map[string]interface{}{"lt": t.UTC().Format(time.RFC3339)},
+			},
+		},
+	}
+	if err := json.NewEncoder(&buf).Encode(query); err != nil {
+		return 0, err
+	}
+	res, err := esapi.DeleteByQueryRequest{Index: []string{c.index}, Body: &buf, Conflicts: "proceed"}.Do(ctx, c.client)
+	if err != nil {
+		return 0, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode == 404 {
+		return 0, nil // No index yet
+	}
+	if res.IsError() {
+		return 0, errFromESResponse(res)
+	}
+	var out struct {
+		Deleted int `json:"deleted"`
+	}
+	err = json.NewDecoder(res.Body).Decode(&out)
+	return out.Deleted, err
+}
+
 func errFromESResponse(res *esapi.Response) error {
 	var e struct {
 		Error struct {
@@ -0,0 +1,87 @@
+package store
+
+import (
+	"database/sql"
+	"time"
+)
+
+// Escalation is a critical alert that wasn't acknowledged in time and is due
+// to be re-sent to its rule's escalation contacts
+type Escalation struct {
+	ID       int64
+	RuleKind string // token, defi, predict or watch
+	RuleID   int64
+	Topic    string
+	Payload  []byte // The alert event
+}
+
+// Escalations tracks the critical alerts of rules with an escalation policy
+// until they're acknowledged or escalated
+type Escalations struct {
+	db      *sql.DB
+	dialect dialect
+}
+
+func NewEscalations(dsn string) (*Escalations, error) {
+	d, dsn := dialectOf(dsn)
+	db, err := openDB(d, dsn, 2, 1)
+	if err != nil {
+		return nil, err
+	}
+	return &Escalations{db: db, dialect: d}, nil
+}
+
+func (e *Escalations) Close() {
+	if e != nil && e.db != nil {
+		e.db.Close()
+	}
+}
+
+// Open starts the escalation clock of an alert: unless acknowledged it is due
+// after d. It does nothing while the rule already has an escalation pending,
+// so repeated alerts escalate once.
+func (e *Escalations) Open(kind string, ruleID int64, topic string, payload []byte, d time.Duration) error {
+	if e.dialect != mysqlDialect {
+		// A partial unique index allows one pending escalation per rule
+		_, err := e.db.Exec(
+			e.dialect.rebind(`INSERT INTO alert_escalation (rule_kind, rule_id, topic, payload, due_at, created_at)
+			VALUES (?, ?, ?, ?, `+e.dialect.nowPlusSeconds()+`, `+e.dialect.now()+`) ON CONFLICT DO NOTHING`),
+			kind, ruleID, topic, string(payload), int64(d/time.Second),
+		)
+		return err
+	}
+	_, err := e.db.Exec(
+		`INSERT INTO alert_escalation (rule_kind, rule_id, topic, payload, due_at, created_at)
+		SELECT ?, ?, ?, ?, UTC_TIMESTAMP() + INTERVAL ? SECOND, UTC_TIMESTAMP() FROM DUAL
+		WHERE NOT EXISTS (SELECT 1 FROM alert_escalation WHERE rule_kind = ? AND rule_id = ? AND acked_at IS NULL AND escalated_at IS NULL)`,
+		kind, ruleID, topic, string(payload), int64(d/time.Second), kind, ruleID,
+	)
+	return err
+}
+
+// Due returns the pending escalations whose time is up
+func (e *Escalations) Due() ([]Escalation, error) {
+	rows, err := e.db.Query(`SELECT id, rule_kind, rule_id, topic, payload FROM alert_escalation WHERE acked_at IS NULL AND escalated_at IS NULL AND due_at <= ` + e.dialect.now() + ` ORDER BY due_at`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var due []Escalation
+	for rows.Next() {
+		var esc Escalation
+		var payload string
+		if err := rows.Scan(&esc.ID, &esc.RuleKind, &esc.RuleID, &esc.Topic, &payload); err != nil {
+			return nil, err
+		}
+		esc.Payload = []byte(payload)
+		due = append(due, esc)
+	}
+	return due, rows.Err()
+}
+
+// MarkEscalated records that an escalation was sent
+func (e *Escalations) MarkEscalated(id int64) error {
+	_, err := e.db.Exec(e.dialect.rebind(`UPDATE alert_escalation SET escalated_at = `+e.dialect.now()+` WHERE id = ?`), id)
+	return err
+}
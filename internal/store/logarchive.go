@@ -0,0 +1,131 @@
+package store
+
+import (
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"io"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"time"
+)
+
+// LogArchive keeps the closed days of the log files in an S3-compatible
+// bucket, a gzipped object <prefix><yyyyMMdd>.log.gz each
+type LogArchive struct {
+	s3     *s3Client
+	prefix string
+}
+
+// NewLogArchive creates the archive in the bucket of cfg, under prefix (e.g.
+// "logs/"). Without a bucket there is no archive, and it returns nil.
+func NewLogArchive(cfg S3Config, prefix string) (*LogArchive, error) {
+	if cfg.Bucket == "" {
+		return nil, nil
+	}
+	c, err := newS3Client(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return &LogArchive{s3: c, prefix: prefix}, nil
+}
+
+func (a *LogArchive) key(date string) string {
+	return a.prefix + date + ".log.gz"
+}
+
+// Dates returns the archived dates (yyyyMMdd), newest first
+func (a *LogArchive) Dates(ctx context.Context) ([]string, error) {
+	keys, err := a.s3.list(ctx, a.prefix)
+	if err != nil {
+		return nil, err
+	}
+	var dates []string
+	for _, k := range keys {
+		date, ok := strings.CutSuffix(path.Base(k), ".log.gz")
+		if !ok || len(date) != 8 || k != a.key(date) {
+			continue
+		}
+		if _, err := time.Parse("20060102", date); err == nil {
+			dates = append(dates, date)
+		}
+	}
+	sort.Sort(sort.Reverse(sort.StringSlice(dates)))
+	return dates, nil
+}
+
+// Upload archives the day's log files in logDir, segments included, as one
+// gzipped object, replacing the day's object if there is one
+func (a *LogArchive) Upload(ctx context.Context, logDir, date string) error {
+	day, err := OpenLogDay(logDir, date)
+	if err != nil {
+		return err
+	}
+	defer day.Close()
+
+	// Compress to a temporary file first: the upload needs its size and hash
+	tmp, err := os.CreateTemp("", "crypto-alert-"+date+"-*.log.gz")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+	hash := sha256.New()
+	zw := gzip.NewWriter(io.MultiWriter(tmp, hash))
+	if _, err := io.Copy(zw, day); err != nil {
+		return err
+	}
+	if err := zw.Close(); err != nil {
+		return err
+	}
+	size, err := tmp.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	return a.s3.put(ctx, a.key(date), tmp, size, hash.Sum(nil))
+}
+
+// Open downloads an archived day as its log file content. The error wraps
+// os.ErrNotExist when the day isn't archived.
+func (a *LogArchive) Open(ctx context.Context, date string) (io.ReadCloser, error) {
+	body, err := a.s3.get(ctx, a.key(date))
+	if err != nil {
+		return nil, err
+	}
+	zr, err := gzip.NewReader(body)
+	if err != nil {
+		body.Close()
+		return nil, err
+	}
+	return archivedDay{zr, body}, nil
+}
+
+// Read downloads an archived day, see Open
+func (a *LogArchive) Read(ctx context.Context, date string) (string, error) {
+	r, err := a.Open(ctx, date)
+	if err != nil {
+		return "", err
+	}
+	defer r.Close()
+	var b strings.Builder
+	if _, err := io.Copy(&b, r); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+// archivedDay reads the gunzipped download and closes its body
+type archivedDay struct {
+	*gzip.Reader
+	body io.ReadCloser
+}
+
+func (d archivedDay) Close() error {
+	d.Reader.Close()
+	return d.body.Close()
+}
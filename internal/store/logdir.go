@@ -0,0 +1,185 @@
+package store
+
+import (
+	"compress/gzip"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"crypto-alert/internal/logger"
+)
+
+// LogDates returns the dates (yyyyMMdd) logDir has log files of, newest first
+func LogDates(logDir string) ([]string, error) {
+	files, err := os.ReadDir(logDir)
+	if err != nil {
+		return nil, err
+	}
+	seen := make(map[string]struct{})
+	var dates []string
+	for _, file := range files {
+		if file.IsDir() {
+			continue
+		}
+		if date, _, _, ok := logger.ParseLogFileName(file.Name()); ok {
+			if _, dup := seen[date]; !dup {
+				seen[date] = struct{}{}
+				dates = append(dates, date)
+			}
+		}
+	}
+	sort.Sort(sort.Reverse(sort.StringSlice(dates)))
+	return dates, nil
+}
+
+// RemoveLogDaysBefore removes the log files of the days before date (yyyyMMdd)
+// from logDir, and returns how many days it removed
+func RemoveLogDaysBefore(logDir, date string) (int, error) {
+	dates, err := LogDates(logDir)
+	if err != nil {
+		return 0, err
+	}
+	removed := 0
+	for _, d := range dates {
+		if d >= date {
+			continue
+		}
+		matches, _ := filepath.Glob(filepath.Join(logDir, d+".*"))
+		for _, m := range matches {
+			if md, _, _, ok := logger.ParseLogFileName(filepath.Base(m)); !ok || md != d {
+				continue
+			}
+			if err := os.Remove(m); err != nil && !errors.Is(err, os.ErrNotExist) {
+				return removed, err
+			}
+		}
+		removed++
+	}
+	return removed, nil
+}
+
+// LogSegments returns the paths of a day's log files in logDir in the order
+// they were written: the segments rotated aside by size, gzipped or not, then
+// the day's current file
+func LogSegments(logDir, date string) []string {
+	matches, _ := filepath.Glob(filepath.Join(logDir, date+".*"))
+	byN := make(map[int]string)
+	for _, m := range matches {
+		d, n, gzipped, ok := logger.ParseLogFileName(filepath.Base(m))
+		if !ok || d != date {
+			continue
+		}
+		// A segment being compressed has both files for a moment, the plain one complete
+		if _, dup := byN[n]; !dup || !gzipped {
+			byN[n] = m
+		}
+	}
+	ns := make([]int, 0, len(byN))
+	for n := range byN {
+		if n > 0 {
+			ns = append(ns, n)
+		}
+	}
+	sort.Ints(ns)
+	paths := make([]string, 0, len(byN))
+	for _, n := range ns {
+		paths = append(paths, byN[n])
+	}
+	if p, ok := byN[0]; ok {
+		paths = append(paths, p)
+	}
+	return paths
+}
+
+// OpenLogDay opens a day's log files in logDir as one stream, gunzipping the
+// gzipped segments. It returns an os.ErrNotExist error when the day has none.
+func OpenLogDay(logDir, date string) (io.ReadCloser, error) {
+	paths := LogSegments(logDir, date)
+	if len(paths) == 0 {
+		return nil, &os.PathError{Op: "open", Path: filepath.Join(logDir, logger.LogFileName(date, 0)), Err: os.ErrNotExist}
+	}
+	return &logDayReader{paths: paths}, nil
+}
+
+// ReadLogDay reads a day's log files in logDir, see OpenLogDay
+func ReadLogDay(logDir, date string) (string, error) {
+	r, err := OpenLogDay(logDir, date)
+	if err != nil {
+		return "", err
+	}
+	defer r.Close()
+	var b strings.Builder
+	if _, err := io.Copy(&b, r); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+// logDayReader reads the files of a day one after the other, opening each
+// when the one before it is done
+type logDayReader struct {
+	paths []string
+	file  *os.File
+	r     io.Reader // file, or its gzip reader
+}
+
+func (d *logDayReader) Read(p []byte) (int, error) {
+	for {
+		if d.r == nil {
+			if len(d.paths) == 0 {
+				return 0, io.EOF
+			}
+			if err := d.open(); err != nil {
+				return 0, err
+			}
+		}
+		n, err := d.r.Read(p)
+		if err == io.EOF {
+			d.file.Close()
+			d.file, d.r = nil, nil
+			err = nil
+		}
+		if n > 0 || err != nil {
+			return n, err
+		}
+	}
+}
+
+// open opens the next file of the day
+func (d *logDayReader) open() error {
+	path := d.paths[0]
+	d.paths = d.paths[1:]
+	file, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) && strings.HasSuffix(path, ".log") && len(d.paths) > 0 {
+		// The segment was compressed since it was listed
+		path += ".gz"
+		file, err = os.Open(path)
+	}
+	if err != nil {
+		return err
+	}
+	d.file, d.r = file, file
+	if strings.HasSuffix(path, ".gz") {
+		zr, err := gzip.NewReader(file)
+		if err != nil {
+			file.Close()
+			d.file, d.r = nil, nil
+			return err
+		}
+		d.r = zr
+	}
+	return nil
+}
+
+func (d *logDayReader) Close() error {
+	d.paths = nil
+	if d.file == nil {
+		return nil
+	}
+	err := d.file.Close()
+	d.file, d.r = nil, nil
+	return err
+}
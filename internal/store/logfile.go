@@ -1,8 +1,17 @@
 package store
 
 import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strconv"
 	"strings"
 	"time"
+
+	"crypto-alert/internal/logger"
 )
 
 // Log line prefix format from Go's log.LstdFlags: "2006/01/02 15:04:05 "
@@ -10,22 +19,102 @@ const logTimePrefixLen = 19
 
 var logTimeLayout = "2006/01/02 15:04:05"
 
-// GetLogsFromFile parses file content and returns all entries for the day.
-// searchQ optionally filters by message substring (empty = no filter).
-func GetLogsFromFile(content string, searchQ string) []LogEntry {
-	return parseLogLines(content, time.Time{}, searchQ)
+// LogCursor is where the next page of a day's logs starts: after the entries
+// up to TS, and after the first Skip entries at TS itself, which the pages so
+// far had. Lines without a timestamp of their own count as at the timestamp
+// of the line before them. The zero cursor starts at the day's first entry.
+type LogCursor struct {
+	TS   time.Time
+	Skip int
 }
 
-// GetLogsFromFileSince parses file content and returns only entries strictly after `since` (RFC3339).
-// Used for incremental checkpoint-based updates.
-func GetLogsFromFileSince(content, since, searchQ string) []LogEntry {
-	sinceTime := time.Time{}
-	if since != "" {
-		if t, err := time.Parse(time.RFC3339, since); err == nil {
-			sinceTime = t.UTC()
+// ParseLogCursor parses the <RFC3339 timestamp>,<skip> form of String; an
+// empty string is the zero cursor
+func ParseLogCursor(s string) (LogCursor, error) {
+	if s == "" {
+		return LogCursor{}, nil
+	}
+	ts, skip, ok := strings.Cut(s, ",")
+	n, err := strconv.Atoi(skip)
+	if !ok || err != nil || n < 0 {
+		return LogCursor{}, fmt.Errorf("invalid cursor %q", s)
+	}
+	c := LogCursor{Skip: n}
+	if ts != "" {
+		if c.TS, err = time.Parse(time.RFC3339Nano, ts); err != nil {
+			return LogCursor{}, fmt.Errorf("invalid cursor %q", s)
+		}
+	}
+	return c, nil
+}
+
+func (c LogCursor) String() string {
+	ts := ""
+	if !c.TS.IsZero() {
+		ts = c.TS.UTC().Format(time.RFC3339Nano)
+	}
+	return ts + "," + strconv.Itoa(c.Skip)
+}
+
+// LogFilter selects the log entries of a page or a tail
+type LogFilter struct {
+	Since    string   // RFC3339: only entries strictly after it, empty for all
+	Query    string   // Message text
+	Levels   []string // INFO, WARN and/or ERROR, empty for all
+	Services []string // Services that wrote the entries, see logger.Services; empty for all
+}
+
+// LogPage is a page of a day's logs, oldest first
+type LogPage struct {
+	Entries []LogEntry
+	Total   int       // Entries of the day the query matches, on all pages
+	Next    string    // Cursor of the next page, empty on the last one
+	End     LogCursor // Where the entries after this page's will start, also once they are logged
+}
+
+// GetLogPageFromFile parses file content and returns the page of at most limit
+// entries starting at after, those f selects; f.Query filters by message
+// substring.
+func GetLogPageFromFile(content string, f LogFilter, after LogCursor, limit int) LogPage {
+	entries, _ := parseLogLines(content, f, logger.LevelInfo)
+
+	// Each entry's timestamp, or the one of the entry before for lines without
+	times := make([]time.Time, len(entries))
+	var last time.Time
+	for i, e := range entries {
+		if t, err := time.Parse(time.RFC3339Nano, e.TS); err == nil {
+			last = t
+		}
+		times[i] = last
+	}
+
+	start, seen := 0, 0
+	for ; start < len(entries); start++ {
+		if times[start].After(after.TS) {
+			break
+		}
+		if times[start].Equal(after.TS) {
+			if seen == after.Skip {
+				break
+			}
+			seen++
 		}
 	}
-	return parseLogLines(content, sinceTime, searchQ)
+	end := min(start+limit, len(entries))
+
+	page := LogPage{Entries: entries[start:end], Total: len(entries), End: after}
+	if end > start {
+		page.End = LogCursor{TS: times[end-1]}
+		for _, t := range times[:end] {
+			if t.Equal(page.End.TS) {
+				page.End.Skip++
+			}
+		}
+		if end < len(entries) {
+			page.Next = page.End.String()
+		}
+	}
+	return page
 }
 
 // GetCheckpointFromFile returns the RFC3339 timestamp of the last log line that has a parseable
@@ -43,35 +132,175 @@ func GetCheckpointFromFile(content string) string {
 	return ""
 }
 
-// parseLogLines is the shared implementation for GetLogsFromFile and GetLogsFromFileSince.
-// When sinceTime is non-zero, only entries strictly after that time are included.
-func parseLogLines(content string, sinceTime time.Time, searchQ string) []LogEntry {
-	searchLower := strings.ToLower(strings.TrimSpace(searchQ))
-
+// parseLogLines parses the lines of a log file for GetLogPageFromFile and
+// returns those f selects. Lines without a timestamp of their own, e.g. of a
+// stack trace, have the level and service of the line before them, level for
+// the first; the level of the last line is returned with the entries.
+func parseLogLines(content string, f LogFilter, level string) ([]LogEntry, string) {
+	p := newLogLineParser(f, level)
 	var entries []LogEntry
 	for _, line := range strings.Split(content, "\n") {
-		line = strings.TrimSuffix(line, "\r")
-		trimmed := strings.TrimSpace(line)
-		if trimmed == "" {
-			continue
+		if e, ok := p.parse(line); ok {
+			entries = append(entries, e)
 		}
-		var ts time.Time
-		if len(trimmed) >= logTimePrefixLen {
-			if t, err := time.Parse(logTimeLayout, trimmed[:logTimePrefixLen]); err == nil {
-				ts = t.UTC()
+	}
+	return entries, p.level
+}
+
+// ScanLogFile hands each entry of the log file r that f selects to fn, oldest
+// first, reading a line at a time, until fn returns an error
+func ScanLogFile(r io.Reader, f LogFilter, fn func(LogEntry) error) error {
+	p := newLogLineParser(f, logger.LevelInfo)
+	br := bufio.NewReader(r)
+	for {
+		line, err := br.ReadString('\n')
+		if e, ok := p.parse(strings.TrimSuffix(line, "\n")); ok {
+			if err := fn(e); err != nil {
+				return err
 			}
 		}
-		if !sinceTime.IsZero() && !ts.IsZero() && !ts.After(sinceTime) {
-			continue
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// logLineParser parses log file lines one at a time, keeping the level and
+// service of the last line for the lines that continue it
+type logLineParser struct {
+	searchLower string
+	levels      []string
+	services    []string
+	since       time.Time
+	level       string
+	service     string
+}
+
+func newLogLineParser(f LogFilter, level string) *logLineParser {
+	p := &logLineParser{searchLower: strings.ToLower(strings.TrimSpace(f.Query)), levels: f.Levels, services: f.Services, level: level}
+	if f.Since != "" {
+		if t, err := time.Parse(time.RFC3339, f.Since); err == nil {
+			p.since = t.UTC()
 		}
-		tsStr := ""
-		if !ts.IsZero() {
-			tsStr = ts.Format(time.RFC3339Nano)
+	}
+	return p
+}
+
+// parse returns the entry of line, and whether the filter selects it
+func (p *logLineParser) parse(line string) (LogEntry, bool) {
+	line = strings.TrimSuffix(line, "\r")
+	trimmed := strings.TrimSpace(line)
+	if trimmed == "" {
+		return LogEntry{}, false
+	}
+	var ts time.Time
+	if len(trimmed) >= logTimePrefixLen {
+		if t, err := time.Parse(logTimeLayout, trimmed[:logTimePrefixLen]); err == nil {
+			ts = t.UTC()
 		}
-		if searchLower != "" && !strings.Contains(strings.ToLower(line), searchLower) {
-			continue
+	}
+	if !ts.IsZero() {
+		p.level = logger.LevelOf(trimmed)
+		p.service, _ = logger.CutService(strings.TrimSpace(trimmed[logTimePrefixLen:]))
+	}
+	if len(p.levels) > 0 && !slices.Contains(p.levels, p.level) {
+		return LogEntry{}, false
+	}
+	if len(p.services) > 0 && !slices.Contains(p.services, p.service) {
+		return LogEntry{}, false
+	}
+	if !p.since.IsZero() && !ts.IsZero() && !ts.After(p.since) {
+		return LogEntry{}, false
+	}
+	tsStr := ""
+	if !ts.IsZero() {
+		tsStr = ts.Format(time.RFC3339Nano)
+	}
+	if p.searchLower != "" && !strings.Contains(strings.ToLower(line), p.searchLower) {
+		return LogEntry{}, false
+	}
+	return LogEntry{Message: line, Level: p.level, Service: p.service, TS: tsStr}, true
+}
+
+// CountLogPhrases returns, for each name of phrases, how many lines of file
+// content contain all of its phrases
+func CountLogPhrases(content string, phrases map[string][]string) map[string]int {
+	counts := map[string]int{}
+	for _, line := range strings.Split(content, "\n") {
+		for name, all := range phrases {
+			matched := true
+			for _, p := range all {
+				if !strings.Contains(line, p) {
+					matched = false
+					break
+				}
+			}
+			if matched {
+				counts[name]++
+			}
 		}
-		entries = append(entries, LogEntry{Message: line, TS: tsStr})
 	}
-	return entries
+	return counts
+}
+
+// maxLogTailRead caps what one LogTail.Read reads, the rest waits for the next
+const maxLogTailRead = 4 << 20
+
+// LogTail follows a log file as lines are appended to it, like tail -f
+type LogTail struct {
+	path    string
+	file    os.FileInfo // Of the file read, to notice it was rotated aside
+	offset  int64
+	partial []byte // Start of a line whose newline wasn't written yet
+	level   string // Level of the last line read
+}
+
+// NewLogTail follows the log file at path from its current end, or from its
+// start with fromStart. The file doesn't have to exist yet.
+func NewLogTail(path string, fromStart bool) *LogTail {
+	t := &LogTail{path: path, level: logger.LevelInfo}
+	if info, err := os.Stat(path); err == nil && !fromStart {
+		t.file, t.offset = info, info.Size()
+	}
+	return t
+}
+
+// Read returns the lines appended since the last Read that f selects, like
+// GetLogPageFromFile. A file that got shorter, or was rotated aside for a new
+// one, is read again from the start.
+func (t *LogTail) Read(f LogFilter) ([]LogEntry, error) {
+	file, err := os.Open(t.path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil {
+		return nil, err
+	}
+	if info.Size() < t.offset || (t.file != nil && !os.SameFile(info, t.file)) {
+		t.offset, t.partial, t.level = 0, nil, logger.LevelInfo
+	}
+	t.file = info
+	if info.Size() == t.offset {
+		return nil, nil
+	}
+
+	data, err := io.ReadAll(io.NewSectionReader(file, t.offset, min(info.Size()-t.offset, maxLogTailRead)))
+	if err != nil {
+		return nil, err
+	}
+	t.offset += int64(len(data))
+	data = append(t.partial, data...)
+	end := bytes.LastIndexByte(data, '\n') + 1
+	t.partial = bytes.Clone(data[end:])
+	var entries []LogEntry
+	entries, t.level = parseLogLines(string(data[:end]), f, t.level)
+	return entries, nil
 }
@@ -0,0 +1,321 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"crypto-alert/internal/logger"
+)
+
+// LogSource reads the services' logs from where they are kept: the log files
+// (FileLogSource), Elasticsearch (ESClient), or the first of several that has
+// them (LogSources)
+type LogSource interface {
+	// Name names the source in responses, e.g. "elasticsearch"
+	Name() string
+
+	// Dates returns the days (yyyyMMdd) with logs, newest first
+	Dates(ctx context.Context) ([]string, error)
+
+	// Page returns the page of a day's entries q selects, oldest first
+	Page(ctx context.Context, date string, q LogQuery) (LogPage, error)
+
+	// Scan hands each entry of a day that f selects to fn, oldest first,
+	// until fn returns an error. The error wraps os.ErrNotExist when the
+	// source has no entries of the day.
+	Scan(ctx context.Context, date string, f LogFilter, fn func(LogEntry) error) error
+
+	// Checkpoint returns the RFC3339 timestamp of a day's latest entry, ""
+	// when it has none
+	Checkpoint(ctx context.Context, date string) (string, error)
+
+	// CountPhrases returns, per day (yyyyMMdd, UTC) from since until before
+	// until and for each name of phrases, how many entries contain all of its
+	// phrases, and the Name of the source that counted them. Days without
+	// entries are left out.
+	CountPhrases(ctx context.Context, since, until time.Time, phrases map[string][]string) (map[string]map[string]int, string, error)
+
+	// Follow returns a follower of the entries f selects logged from now on,
+	// or after lastEventID when it's an event ID of the source's followers.
+	// It returns nil when the source doesn't have today's logs.
+	Follow(ctx context.Context, f LogFilter, lastEventID string) (LogFollower, error)
+}
+
+// LogQuery selects a page of a day's log entries
+type LogQuery struct {
+	LogFilter
+	After LogCursor // Where the page starts, the zero cursor for the day's first entry
+	Limit int       // Entries of the page at most
+}
+
+// LogFollower returns the log entries logged since its last call, and the
+// event ID a client resumes after them with, if any
+type LogFollower interface {
+	Next(ctx context.Context) ([]LogEntry, string, error)
+}
+
+// logFollowPageSize is how many entries a follower reads per request
+const logFollowPageSize = 5000
+
+// LogSources reads the logs from the first of its sources that has them,
+// e.g. Elasticsearch before the log files, and lists the days of all. A
+// source that fails is logged and stands aside for the next one.
+type LogSources []LogSource
+
+func (ls LogSources) Name() string {
+	names := make([]string, len(ls))
+	for i, s := range ls {
+		names[i] = s.Name()
+	}
+	return strings.Join(names, "+")
+}
+
+func (ls LogSources) Dates(ctx context.Context) ([]string, error) {
+	seen := make(map[string]struct{})
+	var dates []string
+	var lastErr error
+	failed := 0
+	for _, s := range ls {
+		ds, err := s.Dates(ctx)
+		if err != nil {
+			ls.failed(s, "Dates", err)
+			lastErr = err
+			failed++
+			continue
+		}
+		for _, d := range ds {
+			if _, dup := seen[d]; !dup {
+				seen[d] = struct{}{}
+				dates = append(dates, d)
+			}
+		}
+	}
+	if failed > 0 && failed == len(ls) {
+		return nil, lastErr
+	}
+	sort.Sort(sort.Reverse(sort.StringSlice(dates)))
+	return dates, nil
+}
+
+func (ls LogSources) Page(ctx context.Context, date string, q LogQuery) (LogPage, error) {
+	for _, s := range ls {
+		page, err := s.Page(ctx, date, q)
+		if err != nil {
+			ls.failed(s, "Page", err)
+			continue
+		}
+		if page.Total > 0 {
+			return page, nil
+		}
+	}
+	return LogPage{End: q.After}, nil
+}
+
+func (ls LogSources) Scan(ctx context.Context, date string, f LogFilter, fn func(LogEntry) error) error {
+	err := error(&os.PathError{Op: "scan", Path: date, Err: os.ErrNotExist})
+	for _, s := range ls {
+		scanned := false
+		err = s.Scan(ctx, date, f, func(e LogEntry) error {
+			scanned = true
+			return fn(e)
+		})
+		if err == nil || scanned {
+			return err
+		}
+		ls.failed(s, "Scan", err)
+	}
+	return err
+}
+
+func (ls LogSources) Checkpoint(ctx context.Context, date string) (string, error) {
+	for _, s := range ls {
+		checkpoint, err := s.Checkpoint(ctx, date)
+		if err != nil {
+			ls.failed(s, "Checkpoint", err)
+			continue
+		}
+		if checkpoint != "" {
+			return checkpoint, nil
+		}
+	}
+	return "", nil
+}
+
+func (ls LogSources) CountPhrases(ctx context.Context, since, until time.Time, phrases map[string][]string) (map[string]map[string]int, string, error) {
+	source := ""
+	for _, s := range ls {
+		counts, name, err := s.CountPhrases(ctx, since, until, phrases)
+		if err != nil {
+			ls.failed(s, "CountPhrases", err)
+			continue
+		}
+		if len(counts) > 0 {
+			return counts, name, nil
+		}
+		source = name
+	}
+	return map[string]map[string]int{}, source, nil
+}
+
+func (ls LogSources) Follow(ctx context.Context, f LogFilter, lastEventID string) (LogFollower, error) {
+	for _, s := range ls {
+		follower, err := s.Follow(ctx, f, lastEventID)
+		if err != nil {
+			ls.failed(s, "Follow", err)
+			continue
+		}
+		if follower != nil {
+			return follower, nil
+		}
+	}
+	return nil, nil
+}
+
+// failed logs the error of a source the next one stands in for. Not having
+// the day's logs isn't one.
+func (ls LogSources) failed(s LogSource, op string, err error) {
+	if errors.Is(err, os.ErrNotExist) || errors.Is(err, context.Canceled) {
+		return
+	}
+	slog.Warn("⚠️  Log source failed", "source", s.Name(), "op", op, logger.FieldError, err)
+}
+
+// FileLogSource reads the days' log files from the log directory, and the
+// days that are only left in the log archive (if any) from the archive
+type FileLogSource struct {
+	dir     string
+	archive *LogArchive
+}
+
+// NewFileLogSource reads the log files of dir, and the days of archive when
+// it isn't nil
+func NewFileLogSource(dir string, archive *LogArchive) *FileLogSource {
+	return &FileLogSource{dir: dir, archive: archive}
+}
+
+func (f *FileLogSource) Name() string {
+	return "files"
+}
+
+// Dates returns the days with log files, local or archived
+func (f *FileLogSource) Dates(ctx context.Context) ([]string, error) {
+	dates, err := LogDates(f.dir)
+	if err != nil || f.archive == nil {
+		return dates, err
+	}
+	archived, err := f.archive.Dates(ctx)
+	if err != nil {
+		slog.Warn("⚠️  Log archive: failed to list the archived days", logger.FieldError, err)
+	}
+	seen := make(map[string]struct{}, len(dates))
+	for _, d := range dates {
+		seen[d] = struct{}{}
+	}
+	for _, d := range archived {
+		if _, dup := seen[d]; !dup {
+			dates = append(dates, d)
+		}
+	}
+	sort.Sort(sort.Reverse(sort.StringSlice(dates)))
+	return dates, nil
+}
+
+// open opens a day's log files, downloading the day from the archive when the
+// log directory no longer has it
+func (f *FileLogSource) open(ctx context.Context, date string) (io.ReadCloser, error) {
+	r, err := OpenLogDay(f.dir, date)
+	if errors.Is(err, os.ErrNotExist) && f.archive != nil {
+		return f.archive.Open(ctx, date)
+	}
+	return r, err
+}
+
+// read reads a day's log files, see open
+func (f *FileLogSource) read(ctx context.Context, date string) (string, error) {
+	r, err := f.open(ctx, date)
+	if err != nil {
+		return "", err
+	}
+	defer r.Close()
+	var b strings.Builder
+	if _, err := io.Copy(&b, r); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+func (f *FileLogSource) Page(ctx context.Context, date string, q LogQuery) (LogPage, error) {
+	content, err := f.read(ctx, date)
+	if err != nil {
+		return LogPage{End: q.After}, err
+	}
+	return GetLogPageFromFile(content, q.LogFilter, q.After, q.Limit), nil
+}
+
+// Scan reads the day's log files a line at a time, so a large day is never
+// held in memory
+func (f *FileLogSource) Scan(ctx context.Context, date string, filter LogFilter, fn func(LogEntry) error) error {
+	r, err := f.open(ctx, date)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+	return ScanLogFile(r, filter, fn)
+}
+
+func (f *FileLogSource) Checkpoint(ctx context.Context, date string) (string, error) {
+	content, err := f.read(ctx, date)
+	if errors.Is(err, os.ErrNotExist) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	return GetCheckpointFromFile(content), nil
+}
+
+// CountPhrases counts in the log directory's files only: downloading the
+// archived days of a range would take too long for a count
+func (f *FileLogSource) CountPhrases(ctx context.Context, since, until time.Time, phrases map[string][]string) (map[string]map[string]int, string, error) {
+	days := map[string]map[string]int{}
+	for d := since; d.Before(until); d = d.AddDate(0, 0, 1) {
+		date := d.Format("20060102")
+		if content, err := ReadLogDay(f.dir, date); err == nil {
+			days[date] = CountLogPhrases(content, phrases)
+		}
+	}
+	return days, f.Name(), nil
+}
+
+// Follow tails today's log file from its current end; the followers have no
+// event IDs to resume after
+func (f *FileLogSource) Follow(ctx context.Context, filter LogFilter, lastEventID string) (LogFollower, error) {
+	follower := &fileLogFollower{dir: f.dir, filter: filter}
+	follower.Next(ctx)
+	return follower, nil
+}
+
+// fileLogFollower tails today's log file, and the next day's from its start
+// once the date changes
+type fileLogFollower struct {
+	dir    string
+	filter LogFilter
+	date   string
+	tail   *LogTail
+}
+
+func (f *fileLogFollower) Next(ctx context.Context) ([]LogEntry, string, error) {
+	if date := time.Now().Format("20060102"); date != f.date {
+		f.tail = NewLogTail(filepath.Join(f.dir, logger.LogFileName(date, 0)), f.date != "")
+		f.date = date
+	}
+	entries, err := f.tail.Read(f.filter)
+	return entries, "", err
+}
@@ -4,8 +4,6 @@ import (
 	"database/sql"
 	"fmt"
 	"time"
-
-	_ "github.com/go-sql-driver/mysql"
 )
 
 type MetricPoint struct {
@@ -21,21 +19,17 @@ type MetricInfo struct {
 }
 
 type MetricStore struct {
-	db *sql.DB
+	db      *sql.DB
+	dialect dialect
 }
 
 func NewMetricStore(dsn string) (*MetricStore, error) {
-	db, err := sql.Open("mysql", dsn)
+	d, dsn := dialectOf(dsn)
+	db, err := openDB(d, dsn, 5, 2)
 	if err != nil {
-		return nil, fmt.Errorf("open mysql: %w", err)
-	}
-	if err := db.Ping(); err != nil {
-		db.Close()
-		return nil, fmt.Errorf("mysql ping: %w", err)
+		return nil, err
 	}
-	db.SetMaxOpenConns(5)
-	db.SetMaxIdleConns(2)
-	return &MetricStore{db: db}, nil
+	return &MetricStore{db: db, dialect: d}, nil
 }
 
 func (s *MetricStore) Close() {
@@ -49,7 +43,7 @@ func (s *MetricStore) InsertMetricSnapshot(metricType, identifier, label, field
 		return nil
 	}
 	_, err := s.db.Exec(
-		`INSERT INTO metric_snapshots (type, identifier, label, field, value, recorded_at) VALUES (?, ?, ?, ?, ?, UTC_TIMESTAMP())`,
+		s.dialect.rebind(`INSERT INTO metric_snapshots (type, identifier, label, field, value, recorded_at) VALUES (?, ?, ?, ?, ?, `+s.dialect.now()+`)`),
 		metricType, identifier, label, field, value,
 	)
 	return err
@@ -60,7 +54,7 @@ func (s *MetricStore) GetMetricHistory(metricType, identifier, field string, sin
 		return nil, nil
 	}
 	rows, err := s.db.Query(
-		`SELECT value, recorded_at FROM metric_snapshots WHERE type=? AND identifier=? AND field=? AND recorded_at >= ? ORDER BY recorded_at ASC`,
+		s.dialect.rebind(`SELECT value, recorded_at FROM metric_snapshots WHERE type=? AND identifier=? AND field=? AND recorded_at >= ? ORDER BY recorded_at ASC`),
 		metricType, identifier, field, since.UTC().Format("2006-01-02 15:04:05"),
 	)
 	if err != nil {
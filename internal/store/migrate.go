@@ -0,0 +1,93 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"fmt"
+	"io/fs"
+	"path"
+
+	"github.com/pressly/goose/v3"
+	"github.com/pressly/goose/v3/lock"
+)
+
+// migrations are the schema of each dialect, migrations/<dialect>/NNNNN_*.sql
+// applied in order by goose, which records the applied versions in
+// goose_db_version. A schema change is a new file in every dialect.
+//
+//go:embed migrations
+var migrations embed.FS
+
+var gooseDialects = map[dialect]goose.Dialect{
+	mysqlDialect:    goose.DialectMySQL,
+	postgresDialect: goose.DialectPostgres,
+	sqliteDialect:   goose.DialectSQLite3,
+}
+
+// Migrate creates the tables of the database of dsn, or upgrades them to the
+// latest migration, and returns the migrations it applied. The services may
+// start together: on MySQL and PostgreSQL they take turns through a database
+// lock, and a database that is up to date is left alone.
+func Migrate(dsn string) ([]string, error) {
+	d, dsn := dialectOf(dsn)
+	db, err := openDB(d, dsn, 2, 1)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	dir, err := fs.Sub(migrations, "migrations/"+d.name)
+	if err != nil {
+		return nil, err
+	}
+	var options []goose.ProviderOption
+	switch d {
+	case mysqlDialect:
+		options = append(options, goose.WithSessionLocker(mysqlLocker{}))
+	case postgresDialect:
+		locker, err := lock.NewPostgresSessionLocker()
+		if err != nil {
+			return nil, err
+		}
+		options = append(options, goose.WithSessionLocker(locker))
+	}
+	provider, err := goose.NewProvider(gooseDialects[d], db, dir, options...)
+	if err != nil {
+		return nil, fmt.Errorf("migrations: %w", err)
+	}
+	results, err := provider.Up(context.Background())
+	var applied []string
+	for _, r := range results {
+		if r.Error == nil {
+			applied = append(applied, path.Base(r.Source.Path))
+		}
+	}
+	if err != nil {
+		return applied, fmt.Errorf("migrate: %w", err)
+	}
+	return applied, nil
+}
+
+// migrationLock is the name of the MySQL lock held while migrating
+const migrationLock = "crypto_alert_migrations"
+
+// mysqlLocker holds a MySQL named lock for the session that migrates,
+// waiting up to a minute for another service's migrations to finish
+type mysqlLocker struct{}
+
+func (mysqlLocker) SessionLock(ctx context.Context, conn *sql.Conn) error {
+	var got sql.NullInt64
+	if err := conn.QueryRowContext(ctx, `SELECT GET_LOCK(?, 60)`, migrationLock).Scan(&got); err != nil {
+		return err
+	}
+	if got.Int64 != 1 {
+		return fmt.Errorf("timed out waiting for the %s lock", migrationLock)
+	}
+	return nil
+}
+
+func (mysqlLocker) SessionUnlock(ctx context.Context, conn *sql.Conn) error {
+	_, err := conn.ExecContext(ctx, `SELECT RELEASE_LOCK(?)`, migrationLock)
+	return err
+}
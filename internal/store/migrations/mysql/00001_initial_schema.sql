@@ -0,0 +1,376 @@
+-- Initial schema: the rule tables and the tables of the services. Tables
+-- that already exist, e.g. created from the schema file of older releases,
+-- are left as they are.
+
+-- +goose Up
+
+-- Notification columns shared by all rule tables:
+--   recipient_email, telegram_chat_id, whatsapp_to, teams_webhook_url, ntfy_topic,
+--   pushover_user_key, webhook_url: per-channel destinations
+--   recipient_emails, telegram_chat_ids: JSON arrays of further email / Telegram
+--                     destinations, notified in addition to recipient_email / telegram_chat_id
+--                     (a Telegram destination <chat id>/<topic id> posts to a forum topic)
+--   contact_group: name of an alert_contact_group whose members are notified too
+--   channels: optional JSON array of channels to notify, e.g. ["email"] or ["telegram","teams"];
+--             NULL notifies every channel the rule has a destination for
+--   message_template: optional Go template replacing the notification body, e.g.
+--             '{{define "subject"}}{{.Symbol}} hit {{.Price}}{{end}}{{.Symbol}} is {{.Price}}, rebalance now'
+--   locale: language of the built-in notification messages: en (default), zh or es
+--   digest_minutes: batch info / warning email and Telegram alerts into one digest every N minutes
+--   escalate_after_minutes, escalate_to: a critical alert nobody acknowledges within N minutes
+--             is re-sent to escalate_to, a JSON array of channel:destination pairs, e.g.
+--             ["pagerduty:<routing key>", "whatsapp:+15551234567"] (see alert_escalation)
+--   telegram_chart (token and DeFi rules): attach a chart of the last 24h to Telegram alerts
+--   telegram_format: parse mode of Telegram alerts: html (default), markdownv2 or text
+--   telegram_silent: send Telegram alerts without sound; telegram_no_preview: no link previews
+--   snoozed_until: UTC time until which the rule doesn't alert, set by the snooze
+--             buttons on Telegram alerts; picked up on the next rule reload
+--   severity: info | warning (default) | critical
+--   pagerduty_routing_key: critical alerts open a PagerDuty incident keyed by the rule ID
+--   opsgenie_api_key: alerts create an Opsgenie alert (priority from severity, alias = rule ID)
+--   resolves_rule_id: recovery rule; when it triggers, resolve the PagerDuty incident /
+--                     close the Opsgenie alert of that rule (same table) instead of opening one
+--   last_triggered: UTC time the rule last alerted, written by the engine; a triggered ONCE
+--             rule is also set enabled = false so a restart doesn't re-arm it
+--   updated_at: set on every change; the engine polls it and only re-reads the rules
+--             when a rule table or alert_contact_group changed (see RULE_RELOAD_INTERVAL)
+
+-- Token (price) alert rules
+CREATE TABLE IF NOT EXISTS alert_rule_token_config (
+  id               BIGINT AUTO_INCREMENT PRIMARY KEY,
+  symbol           VARCHAR(64) NOT NULL,
+  price_feed_id    VARCHAR(128) NOT NULL,
+  threshold        DOUBLE NOT NULL,
+  direction        VARCHAR(8) NOT NULL,
+  enabled          BOOLEAN NOT NULL DEFAULT true,
+  frequency        JSON,
+  recipient_email  VARCHAR(255) DEFAULT NULL,
+  telegram_chat_id VARCHAR(64) DEFAULT NULL,
+  webhook_url      VARCHAR(512) DEFAULT NULL,
+  whatsapp_to      VARCHAR(32) DEFAULT NULL,
+  teams_webhook_url VARCHAR(512) DEFAULT NULL,
+  severity         VARCHAR(16) DEFAULT NULL,
+  pagerduty_routing_key VARCHAR(64) DEFAULT NULL,
+  resolves_rule_id BIGINT DEFAULT NULL,
+  opsgenie_api_key VARCHAR(64) DEFAULT NULL,
+  ntfy_topic       VARCHAR(255) DEFAULT NULL,
+  pushover_user_key VARCHAR(64) DEFAULT NULL,
+  recipient_emails JSON,
+  telegram_chat_ids JSON,
+  contact_group    VARCHAR(64) DEFAULT NULL,
+  channels         JSON,
+  message_template TEXT,
+  locale VARCHAR(8) DEFAULT NULL,
+  digest_minutes INT DEFAULT NULL,
+  escalate_after_minutes INT DEFAULT NULL,
+  escalate_to      JSON,
+  telegram_chart BOOLEAN NOT NULL DEFAULT false,
+  telegram_format VARCHAR(16) DEFAULT NULL,
+  telegram_silent BOOLEAN NOT NULL DEFAULT false,
+  telegram_no_preview BOOLEAN NOT NULL DEFAULT false,
+  snoozed_until DATETIME DEFAULT NULL,
+  last_triggered DATETIME DEFAULT NULL,
+  updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP
+);
+
+-- DeFi alert rules (params and frequency stored as JSON)
+CREATE TABLE IF NOT EXISTS alert_rule_defi_config (
+  id               BIGINT AUTO_INCREMENT PRIMARY KEY,
+  protocol         VARCHAR(64) NOT NULL,
+  version          VARCHAR(32) NOT NULL,
+  chain_id         VARCHAR(32) NOT NULL,
+  params           JSON,
+  field            VARCHAR(64) NOT NULL,
+  threshold        DOUBLE NOT NULL,
+  direction        VARCHAR(8) NOT NULL,
+  enabled          BOOLEAN NOT NULL DEFAULT true,
+  frequency        JSON,
+  recipient_email  VARCHAR(255) DEFAULT NULL,
+  telegram_chat_id VARCHAR(64) DEFAULT NULL,
+  webhook_url      VARCHAR(512) DEFAULT NULL,
+  whatsapp_to      VARCHAR(32) DEFAULT NULL,
+  teams_webhook_url VARCHAR(512) DEFAULT NULL,
+  severity         VARCHAR(16) DEFAULT NULL,
+  pagerduty_routing_key VARCHAR(64) DEFAULT NULL,
+  resolves_rule_id BIGINT DEFAULT NULL,
+  opsgenie_api_key VARCHAR(64) DEFAULT NULL,
+  ntfy_topic       VARCHAR(255) DEFAULT NULL,
+  pushover_user_key VARCHAR(64) DEFAULT NULL,
+  recipient_emails JSON,
+  telegram_chat_ids JSON,
+  contact_group    VARCHAR(64) DEFAULT NULL,
+  channels         JSON,
+  message_template TEXT,
+  locale VARCHAR(8) DEFAULT NULL,
+  digest_minutes INT DEFAULT NULL,
+  escalate_after_minutes INT DEFAULT NULL,
+  escalate_to      JSON,
+  telegram_chart BOOLEAN NOT NULL DEFAULT false,
+  telegram_format VARCHAR(16) DEFAULT NULL,
+  telegram_silent BOOLEAN NOT NULL DEFAULT false,
+  telegram_no_preview BOOLEAN NOT NULL DEFAULT false,
+  snoozed_until DATETIME DEFAULT NULL,
+  last_triggered DATETIME DEFAULT NULL,
+  updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP
+);
+
+-- Prediction market alert rules
+-- predict_market: polymarket | limitless
+-- params JSON fields: negRisk, question_id, question,
+--                     condition_id, outcome (YES/NO), token_id,
+--                     market_slug, group_item (token_id auto-discovery, see below),
+--                     depth_side (BID/ASK), depth_price (DEPTH only)
+--                     token_ids, group_items (SUM / DIFF only)
+--                     window_minutes (MOVE only, default 60)
+-- field: MIDPOINT  (threshold is compared against the CLOB midpoint price)
+--        SPREAD    (best ask - best bid from the CLOB order book)
+--        DEPTH     (USDC resting on depth_side: bids at or above / asks at or below depth_price)
+--        SUM       (sum of the midpoints of several outcome tokens, e.g. YES across candidates)
+--        DIFF      (midpoint of the first outcome token minus the second; threshold may be negative)
+--        MOVE      (largest midpoint move within window_minutes, in points: 5 = 0.05)
+-- token_id may be omitted: it is then resolved from market_slug or condition_id
+-- (plus outcome, default YES) via the Gamma API. For a multi-market negRisk event
+-- slug, group_item selects the market by candidate name. Limitless rules use
+-- market_slug + outcome (token_id form: "<market slug>:<YES|NO>"). SUM / DIFF rules name
+-- their tokens with token_ids, or market_slug + group_items (SUM with no
+-- group_items combines every open market of the event).
+CREATE TABLE IF NOT EXISTS alert_rule_predict_market_config (
+  id               BIGINT AUTO_INCREMENT PRIMARY KEY,
+  predict_market   VARCHAR(64) NOT NULL,
+  params           JSON,
+  field            VARCHAR(64) NOT NULL,
+  threshold        DOUBLE NOT NULL,
+  direction        VARCHAR(8) NOT NULL,
+  enabled          BOOLEAN NOT NULL DEFAULT true,
+  frequency        JSON,
+  recipient_email  VARCHAR(255) DEFAULT NULL,
+  telegram_chat_id VARCHAR(64) DEFAULT NULL,
+  webhook_url      VARCHAR(512) DEFAULT NULL,
+  whatsapp_to      VARCHAR(32) DEFAULT NULL,
+  teams_webhook_url VARCHAR(512) DEFAULT NULL,
+  severity         VARCHAR(16) DEFAULT NULL,
+  pagerduty_routing_key VARCHAR(64) DEFAULT NULL,
+  resolves_rule_id BIGINT DEFAULT NULL,
+  opsgenie_api_key VARCHAR(64) DEFAULT NULL,
+  ntfy_topic       VARCHAR(255) DEFAULT NULL,
+  pushover_user_key VARCHAR(64) DEFAULT NULL,
+  recipient_emails JSON,
+  telegram_chat_ids JSON,
+  contact_group    VARCHAR(64) DEFAULT NULL,
+  channels         JSON,
+  message_template TEXT,
+  locale VARCHAR(8) DEFAULT NULL,
+  digest_minutes INT DEFAULT NULL,
+  escalate_after_minutes INT DEFAULT NULL,
+  escalate_to      JSON,
+  telegram_format VARCHAR(16) DEFAULT NULL,
+  telegram_silent BOOLEAN NOT NULL DEFAULT false,
+  telegram_no_preview BOOLEAN NOT NULL DEFAULT false,
+  snoozed_until DATETIME DEFAULT NULL,
+  last_triggered DATETIME DEFAULT NULL,
+  updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP
+);
+
+-- Watch alert rules for auxiliary sources (Safe multisig, token approvals, oracles, governance, bridges, Bitcoin, ...)
+-- source: safe
+--   field: PROPOSED (new queued transaction) | QUORUM (queued transaction reached its confirmation threshold)
+--   params JSON fields: safe_address, tx_service_url (optional)
+-- source: approval
+--   field: UNLIMITED (unlimited ERC-20 approval granted) | AMOUNT (approval amount in token units vs threshold)
+--   params JSON fields: wallet_address, token_addresses (optional array)
+-- source: oracle
+--   field: DEVIATION (% difference between Pyth and Chainlink) | STALENESS (age in seconds of the older price)
+--   params JSON fields: symbol, pyth_feed_id, chainlink_feed (aggregator address on chain_id)
+-- source: snapshot | governor
+--   field: CREATED (new proposal) | ENDING (active proposal; value is hours left, e.g. direction "<=" threshold 24)
+--   params JSON fields: snapshot_space (snapshot; chain_id is informational) | governor_address (governor)
+-- source: bridge
+--   field: DROP (largest % drop of a token's total bridge balance vs its peak in the window)
+--   params JSON fields: bridge_addresses (array), token_addresses (array, "native" for ETH), window_minutes (optional, default 60)
+-- source: mempool (chain_id "bitcoin")
+--   field: FEE_FASTEST | FEE_HALF_HOUR | FEE_HOUR | FEE_ECONOMY (sat/vB) | MEMPOOL_COUNT (txs) | MEMPOOL_VSIZE (vMB)
+-- source: btc_address (chain_id "bitcoin")
+--   field: RECEIVED | SPENT (per transaction, value is net BTC) | BALANCE (BTC)
+--   params JSON fields: btc_address or xpub (xpub/ypub/zpub), xpub_gap (optional, default 20)
+-- direction/threshold are required by measured fields, AMOUNT and ENDING, and optionally filter other discrete events
+CREATE TABLE IF NOT EXISTS alert_rule_watch_config (
+  id               BIGINT AUTO_INCREMENT PRIMARY KEY,
+  source           VARCHAR(64) NOT NULL,
+  chain_id         VARCHAR(32) NOT NULL,
+  label            VARCHAR(255) DEFAULT NULL,
+  params           JSON,
+  field            VARCHAR(64) NOT NULL,
+  threshold        DOUBLE NOT NULL DEFAULT 0,
+  direction        VARCHAR(8) DEFAULT NULL,
+  enabled          BOOLEAN NOT NULL DEFAULT true,
+  frequency        JSON,
+  recipient_email  VARCHAR(255) DEFAULT NULL,
+  telegram_chat_id VARCHAR(64) DEFAULT NULL,
+  webhook_url      VARCHAR(512) DEFAULT NULL,
+  whatsapp_to      VARCHAR(32) DEFAULT NULL,
+  teams_webhook_url VARCHAR(512) DEFAULT NULL,
+  severity         VARCHAR(16) DEFAULT NULL,
+  pagerduty_routing_key VARCHAR(64) DEFAULT NULL,
+  resolves_rule_id BIGINT DEFAULT NULL,
+  opsgenie_api_key VARCHAR(64) DEFAULT NULL,
+  ntfy_topic       VARCHAR(255) DEFAULT NULL,
+  pushover_user_key VARCHAR(64) DEFAULT NULL,
+  recipient_emails JSON,
+  telegram_chat_ids JSON,
+  contact_group    VARCHAR(64) DEFAULT NULL,
+  channels         JSON,
+  message_template TEXT,
+  locale VARCHAR(8) DEFAULT NULL,
+  digest_minutes INT DEFAULT NULL,
+  escalate_after_minutes INT DEFAULT NULL,
+  escalate_to      JSON,
+  telegram_format VARCHAR(16) DEFAULT NULL,
+  telegram_silent BOOLEAN NOT NULL DEFAULT false,
+  telegram_no_preview BOOLEAN NOT NULL DEFAULT false,
+  snoozed_until DATETIME DEFAULT NULL,
+  last_triggered DATETIME DEFAULT NULL,
+  updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP
+);
+
+-- Contact groups referenced by rules through contact_group. Members are added to
+-- the rule's destinations on every rule (re)load; webhook_url is used when the
+-- rule has no webhook of its own.
+CREATE TABLE IF NOT EXISTS alert_contact_group (
+  name              VARCHAR(64) PRIMARY KEY,
+  emails            JSON,
+  telegram_chat_ids JSON,
+  webhook_url       VARCHAR(512) DEFAULT NULL,
+  updated_at        DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP
+);
+
+-- Telegram chats registered by sending the bot /start <token>. A rule's
+-- telegram_chat_id(s) may name a token instead of a numeric chat ID; its alerts
+-- then go to every chat registered with that token.
+CREATE TABLE IF NOT EXISTS telegram_chat (
+  token      VARCHAR(255) NOT NULL,
+  chat_id    VARCHAR(64)  NOT NULL,
+  name       VARCHAR(255) NOT NULL DEFAULT '',
+  created_at DATETIME     NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (token, chat_id)
+);
+
+-- Per-chat settings of Telegram alerts, set with the bot's /prefix and
+-- /mentions commands. chat_id is a chat ID, or <chat id>/<topic id> for one
+-- forum topic (a topic without its own row uses its chat's). prefix is
+-- prepended to every alert; mentions (space-separated @usernames) are tagged
+-- on alerts of mention_severity or above, e.g. the on-call for critical alerts.
+CREATE TABLE IF NOT EXISTS telegram_chat_settings (
+  chat_id          VARCHAR(64)  NOT NULL PRIMARY KEY,
+  prefix           VARCHAR(255) NOT NULL DEFAULT '',
+  mentions         VARCHAR(512) NOT NULL DEFAULT '',
+  mention_severity VARCHAR(16)  NOT NULL DEFAULT 'critical',
+  updated_at       DATETIME     NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP
+);
+
+-- Critical alerts of rules with escalate_after_minutes waiting to be
+-- acknowledged (Telegram "Acknowledge" button or POST /api/alerts/ack). The
+-- notification service re-sends the alert (payload, the alert event) to the
+-- rule's escalate_to once due_at passes unacknowledged. A rule has at most one
+-- pending escalation; alerts while one is pending don't open another.
+CREATE TABLE IF NOT EXISTS alert_escalation (
+  id           BIGINT AUTO_INCREMENT PRIMARY KEY,
+  rule_kind    VARCHAR(16)  NOT NULL, -- token, defi, predict or watch
+  rule_id      BIGINT       NOT NULL,
+  topic        VARCHAR(64)  NOT NULL,
+  payload      MEDIUMTEXT   NOT NULL,
+  due_at       DATETIME     NOT NULL,
+  acked_at     DATETIME     DEFAULT NULL,
+  acked_by     VARCHAR(255) DEFAULT NULL,
+  escalated_at DATETIME     DEFAULT NULL,
+  created_at   DATETIME     NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  INDEX idx_alert_escalation_rule (rule_kind, rule_id),
+  INDEX idx_alert_escalation_due (due_at)
+);
+
+-- Mute windows, e.g. for planned maintenance, set through POST /api/mutes or the
+-- bot's /mute command. A mute silences every notification until ends_at, or only
+-- those of one rule kind (token, defi, predict or watch) and optionally one
+-- subject (token symbol, DeFi protocol, prediction market or watch source).
+-- suppressed counts the alerts it held back; once it ends, the notification
+-- service sends the summary to notify (a channel:destination pair) and sets
+-- summary_sent_at.
+CREATE TABLE IF NOT EXISTS notification_mute (
+  id              BIGINT AUTO_INCREMENT PRIMARY KEY,
+  kind            VARCHAR(16)  NOT NULL DEFAULT '',
+  subject         VARCHAR(255) NOT NULL DEFAULT '',
+  reason          VARCHAR(255) NOT NULL DEFAULT '',
+  created_by      VARCHAR(255) NOT NULL DEFAULT '',
+  notify          VARCHAR(512) NOT NULL DEFAULT '',
+  suppressed      INT          NOT NULL DEFAULT 0,
+  created_at      DATETIME     NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  ends_at         DATETIME     NOT NULL,
+  summary_sent_at DATETIME     DEFAULT NULL,
+  INDEX idx_notification_mute_ends (ends_at)
+);
+
+-- Alerts the engine triggered, one row per alert whichever channels it went
+-- out on (notification_log has the deliveries). subject is the token symbol,
+-- DeFi protocol, prediction market or watch source of the rule.
+CREATE TABLE IF NOT EXISTS alert_history (
+  id           BIGINT AUTO_INCREMENT PRIMARY KEY,
+  rule_kind    VARCHAR(16)  NOT NULL, -- token, defi, predict or watch
+  rule_id      BIGINT       NOT NULL DEFAULT 0,
+  subject      VARCHAR(255) NOT NULL DEFAULT '',
+  field        VARCHAR(64)  NOT NULL DEFAULT '',
+  value        DOUBLE       NOT NULL DEFAULT 0,
+  threshold    DOUBLE       NOT NULL DEFAULT 0,
+  direction    VARCHAR(8)   NOT NULL DEFAULT '',
+  severity     VARCHAR(16)  NOT NULL DEFAULT '',
+  message      TEXT         NOT NULL,
+  triggered_at DATETIME     NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  INDEX idx_alert_history_rule (rule_kind, rule_id, triggered_at),
+  INDEX idx_alert_history_time (triggered_at)
+);
+
+-- One row per notification delivery attempt, written by the notification service.
+-- recipient has integration keys and webhook URL paths masked; provider_message_id
+-- is the Resend email ID or SMTP Message-ID for email. attempt is 0 for the first
+-- delivery and the alerts.retry attempt number for retries. status is sent, failed,
+-- suppressed, rate_limited, digested (held for the rule's digest, which is logged as
+-- its own row when sent) or muted (see notification_mute); Resend webhooks move sent
+-- emails to delivered, bounced or complained. event_id is the alert event's
+-- idempotency key; the notification service skips events whose first delivery is
+-- already logged here (NOTIFY_DEDUP_WINDOW).
+CREATE TABLE IF NOT EXISTS notification_log (
+  id                  BIGINT AUTO_INCREMENT PRIMARY KEY,
+  rule_id             BIGINT NOT NULL DEFAULT 0,
+  event_id            VARCHAR(64) DEFAULT NULL,
+  topic               VARCHAR(64) NOT NULL,
+  channel             VARCHAR(32) NOT NULL,
+  recipient           VARCHAR(512) NOT NULL,
+  status              VARCHAR(16) NOT NULL,
+  provider_message_id VARCHAR(255) DEFAULT NULL,
+  error               TEXT,
+  attempt             INT NOT NULL DEFAULT 0,
+  created_at          DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  status_updated_at   DATETIME DEFAULT NULL,
+  INDEX idx_notification_rule (rule_id, created_at),
+  INDEX idx_notification_provider_message (provider_message_id),
+  INDEX idx_notification_event (event_id)
+);
+
+-- Email addresses that no longer get alerts, added on hard bounces reported by
+-- the Resend webhook and by unsubscribe links (reason 'unsubscribed'). Delete a
+-- row to resume sending to that address.
+CREATE TABLE IF NOT EXISTS email_suppression (
+  email      VARCHAR(255) PRIMARY KEY,
+  reason     VARCHAR(512) NOT NULL DEFAULT '',
+  created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Time-series snapshots for dashboard charts
+CREATE TABLE IF NOT EXISTS metric_snapshots (
+  id          BIGINT AUTO_INCREMENT PRIMARY KEY,
+  type        VARCHAR(32) NOT NULL,
+  identifier  VARCHAR(256) NOT NULL,
+  label       VARCHAR(256) NOT NULL DEFAULT '',
+  field       VARCHAR(64) NOT NULL,
+  value       DOUBLE NOT NULL,
+  recorded_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  INDEX idx_metric_lookup (type, identifier, field, recorded_at)
+);
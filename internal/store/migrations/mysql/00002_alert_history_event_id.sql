@@ -0,0 +1,8 @@
+-- The alert event ID of each alert_history row, the event_id of its
+-- deliveries in notification_log: the channels the alert went out on.
+
+-- +goose Up
+
+ALTER TABLE alert_history
+  ADD COLUMN event_id VARCHAR(64) NOT NULL DEFAULT '' AFTER rule_id,
+  ADD INDEX idx_alert_history_event (event_id);
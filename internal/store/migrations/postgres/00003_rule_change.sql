@@ -0,0 +1,21 @@
+-- Audit log of the rules: every create, update and delete of a rule, with
+-- who made it and the rule's row before and after as JSON (without
+-- last_triggered and updated_at). version counts the changes of each rule.
+-- changed_by is the Telegram user or API caller for changes made through
+-- crypto-alert, "engine" for ONCE rules it disabled, and "database" for
+-- changes the engine found in the tables on a rule reload.
+
+-- +goose Up
+
+CREATE TABLE IF NOT EXISTS rule_change (
+  id         BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+  rule_kind  VARCHAR(16)  NOT NULL, -- token, defi, predict or watch
+  rule_id    BIGINT       NOT NULL,
+  version    INT          NOT NULL,
+  action     VARCHAR(8)   NOT NULL, -- create, update or delete
+  changed_by VARCHAR(255) NOT NULL DEFAULT '',
+  old_value  TEXT,
+  new_value  TEXT,
+  changed_at TIMESTAMP    NOT NULL DEFAULT (NOW() AT TIME ZONE 'UTC')
+);
+CREATE INDEX IF NOT EXISTS idx_rule_change_rule ON rule_change (rule_kind, rule_id, id);
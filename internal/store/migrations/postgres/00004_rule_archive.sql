@@ -0,0 +1,12 @@
+-- Archived rules: deleted_at is the UTC time a rule was archived (removed),
+-- NULL while it is live. The engine doesn't load archived rules, but their
+-- rows stay, so alert_history and rule_change keep pointing at them and a
+-- rule can be restored by setting deleted_at back to NULL. rule_change
+-- records archiving and restoring as the actions archive and restore.
+
+-- +goose Up
+
+ALTER TABLE alert_rule_token_config ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP DEFAULT NULL;
+ALTER TABLE alert_rule_defi_config ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP DEFAULT NULL;
+ALTER TABLE alert_rule_predict_market_config ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP DEFAULT NULL;
+ALTER TABLE alert_rule_watch_config ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP DEFAULT NULL;
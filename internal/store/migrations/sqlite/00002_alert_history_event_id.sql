@@ -0,0 +1,7 @@
+-- The alert event ID of each alert_history row, the event_id of its
+-- deliveries in notification_log: the channels the alert went out on.
+
+-- +goose Up
+
+ALTER TABLE alert_history ADD COLUMN event_id VARCHAR(64) NOT NULL DEFAULT '';
+CREATE INDEX IF NOT EXISTS idx_alert_history_event ON alert_history (event_id);
@@ -0,0 +1,13 @@
+-- Rule tags: free-form labels such as "treasury", "personal" or "degen", a
+-- JSON array on each rule. Rule lists, mutes and digests go by them.
+-- alert_history keeps the tags the rule had when the alert triggered, and a
+-- mute with a tag only silences the alerts of rules with that tag.
+
+-- +goose Up
+
+ALTER TABLE alert_rule_token_config ADD COLUMN tags TEXT;
+ALTER TABLE alert_rule_defi_config ADD COLUMN tags TEXT;
+ALTER TABLE alert_rule_predict_market_config ADD COLUMN tags TEXT;
+ALTER TABLE alert_rule_watch_config ADD COLUMN tags TEXT;
+ALTER TABLE alert_history ADD COLUMN tags VARCHAR(1024) NOT NULL DEFAULT '';
+ALTER TABLE notification_mute ADD COLUMN tag VARCHAR(64) NOT NULL DEFAULT '';
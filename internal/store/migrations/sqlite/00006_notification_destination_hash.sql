@@ -0,0 +1,8 @@
+-- The full destination of each notification_log row, hashed: recipient is
+-- masked for display and can't tell two destinations apart, so the
+-- notification service matches the destination_hash of the deliveries of an
+-- event it reads again to skip the destinations it was already sent to.
+
+-- +goose Up
+
+ALTER TABLE notification_log ADD COLUMN destination_hash VARCHAR(64) NOT NULL DEFAULT '';
@@ -0,0 +1,97 @@
+package store
+
+import (
+	"database/sql"
+	"time"
+
+	"crypto-alert/internal/core"
+)
+
+// Mutes stores the mute windows set through the log API and the bot's /mute
+// command. The notification service reads the active ones and sends each
+// mute's summary once it has ended.
+type Mutes struct {
+	db      *sql.DB
+	dialect dialect
+}
+
+func NewMutes(dsn string) (*Mutes, error) {
+	d, dsn := dialectOf(dsn)
+	db, err := openDB(d, dsn, 2, 1)
+	if err != nil {
+		return nil, err
+	}
+	return &Mutes{db: db, dialect: d}, nil
+}
+
+func (s *Mutes) Close() {
+	if s != nil && s.db != nil {
+		s.db.Close()
+	}
+}
+
+// Create mutes the alerts m matches for d and returns the mute's ID
+func (s *Mutes) Create(m core.Mute, d time.Duration) (int64, error) {
+	return s.dialect.insertID(s.db,
+		`INSERT INTO notification_mute (kind, subject, tag, reason, created_by, notify, created_at, ends_at) VALUES (?, ?, ?, ?, ?, ?, `+s.dialect.now()+`, `+s.dialect.nowPlusSeconds()+`)`,
+		m.Kind, m.Subject, m.Tag, m.Reason, m.CreatedBy, m.Notify, int64(d/time.Second),
+	)
+}
+
+// Active returns the mutes that haven't ended yet
+func (s *Mutes) Active() ([]core.Mute, error) {
+	return s.query(`ends_at > ` + s.dialect.now())
+}
+
+// Ended returns the mutes that ended and whose summary wasn't sent yet
+func (s *Mutes) Ended() ([]core.Mute, error) {
+	return s.query(`ends_at <= ` + s.dialect.now() + ` AND summary_sent_at IS NULL`)
+}
+
+func (s *Mutes) query(where string) ([]core.Mute, error) {
+	rows, err := s.db.Query(`SELECT id, kind, subject, tag, reason, created_by, notify, suppressed, ` + s.dialect.secondsBetween(s.dialect.now(), `ends_at`) + ` FROM notification_mute WHERE ` + where + ` ORDER BY ends_at`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	now := time.Now()
+	var mutes []core.Mute
+	for rows.Next() {
+		var m core.Mute
+		var seconds int64
+		if err := rows.Scan(&m.ID, &m.Kind, &m.Subject, &m.Tag, &m.Reason, &m.CreatedBy, &m.Notify, &m.Suppressed, &seconds); err != nil {
+			return nil, err
+		}
+		m.Until = now.Add(time.Duration(seconds) * time.Second)
+		mutes = append(mutes, m)
+	}
+	return mutes, rows.Err()
+}
+
+// End ends the mute with the given ID now, or every active mute when id is 0,
+// and returns how many it ended
+func (s *Mutes) End(id int64) (int64, error) {
+	now := s.dialect.now()
+	query, args := `UPDATE notification_mute SET ends_at = `+now+` WHERE ends_at > `+now, []interface{}{}
+	if id != 0 {
+		query, args = query+` AND id = ?`, append(args, id)
+	}
+	res, err := s.db.Exec(s.dialect.rebind(query), args...)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// CountSuppressed counts an alert the mute suppressed
+func (s *Mutes) CountSuppressed(id int64) error {
+	_, err := s.db.Exec(s.dialect.rebind(`UPDATE notification_mute SET suppressed = suppressed + 1 WHERE id = ?`), id)
+	return err
+}
+
+// MarkSummarized records that the summary of an ended mute was sent
+func (s *Mutes) MarkSummarized(id int64) error {
+	_, err := s.db.Exec(s.dialect.rebind(`UPDATE notification_mute SET summary_sent_at = `+s.dialect.now()+` WHERE id = ?`), id)
+	return err
+}
@@ -1,46 +1,91 @@
 package store
 
 import (
+	"crypto/sha256"
 	"database/sql"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"io"
+	"strings"
+	"time"
 
 	"crypto-alert/internal/config"
 	"crypto-alert/internal/core"
-
-	_ "github.com/go-sql-driver/mysql"
 )
 
 const (
 	tokenTable         = "alert_rule_token_config"
 	defiTable          = "alert_rule_defi_config"
 	predictMarketTable = "alert_rule_predict_market_config"
+	watchTable         = "alert_rule_watch_config"
+	contactGroupTable  = "alert_contact_group"
 )
 
-// LoadAlertRulesFromMySQL loads token and DeFi alert rules from the web3 database.
-// Tables: alert_rule_token_config, alert_rule_defi_config.
-// frequency and params columns are stored as JSON (MySQL JSON type is returned as []byte).
-func LoadAlertRulesFromMySQL(dsn string) ([]*core.AlertRule, []*core.DeFiAlertRule, error) {
+// MySQLStore reads the alert rules from the web3 database over a pooled
+// connection, kept open for the rule reloads, and writes their triggers back
+type MySQLStore struct {
+	*sqlRules
+}
+
+func NewMySQLStore(dsn string) (*MySQLStore, error) {
 	if dsn == "" {
-		return nil, nil, fmt.Errorf("MySQL DSN is required when ALERT_RULES_SOURCE=mysql")
+		return nil, fmt.Errorf("MySQL DSN is required when ALERT_RULES_SOURCE=mysql")
+	}
+	s, err := openRules(mysqlDialect, strings.TrimPrefix(dsn, "mysql://"))
+	if err != nil {
+		return nil, err
 	}
+	return &MySQLStore{s}, nil
+}
+
+// sqlRules is the rule store of a SQL database; the tables are the same in
+// each, see migrations/
+type sqlRules struct {
+	db      *sql.DB
+	dialect dialect
+	audit   *ruleAudit
+
+	triggers chan ruleTrigger // Written by writeTriggers, see RecordTrigger
+	written  chan struct{}    // Closed once writeTriggers has returned
+}
 
-	db, err := sql.Open("mysql", dsn)
+func openRules(d dialect, dsn string) (*sqlRules, error) {
+	db, err := openDB(d, dsn, 4, 2)
 	if err != nil {
-		return nil, nil, fmt.Errorf("open mysql: %w", err)
+		return nil, err
+	}
+	db.SetConnMaxLifetime(30 * time.Minute)
+	s := &sqlRules{db: db, dialect: d, audit: &ruleAudit{db: db, dialect: d}, triggers: make(chan ruleTrigger, 256), written: make(chan struct{})}
+	go s.writeTriggers()
+	return s, nil
+}
+
+// Close writes the triggers still queued and closes the connection
+func (s *sqlRules) Close() {
+	if s != nil && s.db != nil {
+		close(s.triggers)
+		<-s.written
+		s.db.Close()
 	}
-	defer db.Close()
+}
 
-	if err := db.Ping(); err != nil {
-		return nil, nil, fmt.Errorf("mysql ping: %w", err)
+// LoadAlertRules loads the token and DeFi alert rules.
+// Tables: alert_rule_token_config, alert_rule_defi_config.
+// frequency and params columns are stored as JSON (returned as []byte).
+// Archived rules (deleted_at set) are left out, by every loader.
+func (s *sqlRules) LoadAlertRules() ([]*core.AlertRule, []*core.DeFiAlertRule, error) {
+	groups, err := loadContactGroups(s.db)
+	if err != nil {
+		return nil, nil, fmt.Errorf("load contact groups: %w", err)
 	}
 
-	priceRules, err := loadTokenRules(db)
+	priceRules, err := loadTokenRules(s.db, s.dialect, groups)
 	if err != nil {
 		return nil, nil, fmt.Errorf("load token rules: %w", err)
 	}
 
-	defiRules, err := loadDeFiRules(db)
+	defiRules, err := loadDeFiRules(s.db, s.dialect, groups)
 	if err != nil {
 		return nil, nil, fmt.Errorf("load defi rules: %w", err)
 	}
@@ -48,27 +93,76 @@ func LoadAlertRulesFromMySQL(dsn string) ([]*core.AlertRule, []*core.DeFiAlertRu
 	return priceRules, defiRules, nil
 }
 
-// LoadPredictMarketRulesFromMySQL loads prediction market alert rules from the web3 database.
-func LoadPredictMarketRulesFromMySQL(dsn string) ([]*core.PredictMarketAlertRule, error) {
-	if dsn == "" {
-		return nil, fmt.Errorf("MySQL DSN is required when ALERT_RULES_SOURCE=mysql")
+// LoadPredictMarketRules loads the prediction market alert rules.
+func (s *sqlRules) LoadPredictMarketRules() ([]*core.PredictMarketAlertRule, error) {
+	groups, err := loadContactGroups(s.db)
+	if err != nil {
+		return nil, fmt.Errorf("load contact groups: %w", err)
 	}
+	return loadPredictMarketRules(s.db, s.dialect, groups)
+}
 
-	db, err := sql.Open("mysql", dsn)
+// LoadWatchRules loads the watch alert rules (Safe multisig, ...).
+func (s *sqlRules) LoadWatchRules() ([]*core.WatchAlertRule, error) {
+	groups, err := loadContactGroups(s.db)
 	if err != nil {
-		return nil, fmt.Errorf("open mysql: %w", err)
+		return nil, fmt.Errorf("load contact groups: %w", err)
+	}
+	return loadWatchRules(s.db, s.dialect, groups)
+}
+
+// RulesVersion returns a checksum of the rows of the rule and contact group
+// tables, which changes whenever a row is added, removed or updated, also
+// twice within a second, so a reload can be skipped while it stays the same.
+// The columns a rule change leaves out (last_triggered, updated_at) are left
+// out, so trigger writes don't count.
+func (s *sqlRules) RulesVersion() (string, error) {
+	sum := sha256.New()
+	for _, table := range []string{tokenTable, defiTable, predictMarketTable, watchTable, contactGroupTable} {
+		if err := checksumTable(s.db, table, sum); err != nil {
+			return "", fmt.Errorf("%s: %w", table, err)
+		}
 	}
-	defer db.Close()
+	return hex.EncodeToString(sum.Sum(nil)), nil
+}
 
-	if err := db.Ping(); err != nil {
-		return nil, fmt.Errorf("mysql ping: %w", err)
+// checksumTable writes the rows of table, by primary key, to sum as JSON
+func checksumTable(db *sql.DB, table string, sum io.Writer) error {
+	rows, err := db.Query(`SELECT * FROM ` + table + ` ORDER BY 1`)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	columns, err := rows.Columns()
+	if err != nil {
+		return err
 	}
 
-	return loadPredictMarketRules(db)
+	values := make([]interface{}, len(columns))
+	ptrs := make([]interface{}, len(columns))
+	for i := range values {
+		ptrs[i] = &values[i]
+	}
+	enc := json.NewEncoder(sum)
+	for rows.Next() {
+		if err := rows.Scan(ptrs...); err != nil {
+			return err
+		}
+		row := map[string]interface{}{}
+		for i, c := range columns {
+			if !ruleChangeIgnored[c] {
+				row[c] = ruleChangeValue(values[i])
+			}
+		}
+		if err := enc.Encode(row); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
 }
 
-func loadPredictMarketRules(db *sql.DB) ([]*core.PredictMarketAlertRule, error) {
-	query := `SELECT id, predict_market, params, field, threshold, direction, enabled, frequency, COALESCE(recipient_email, ''), COALESCE(telegram_chat_id, '') FROM ` + predictMarketTable
+func loadPredictMarketRules(db *sql.DB, d dialect, groups config.ContactGroups) ([]*core.PredictMarketAlertRule, error) {
+	query := `SELECT id, predict_market, params, field, threshold, direction, enabled, frequency, ` + notificationColumns + `, tags, COALESCE(` + d.secondsBetween(d.now(), `snoozed_until`) + `, 0), COALESCE(` + d.secondsBetween(`last_triggered`, d.now()) + `, -1) FROM ` + predictMarketTable + ` WHERE deleted_at IS NULL`
 	rows, err := db.Query(query)
 	if err != nil {
 		return nil, err
@@ -78,12 +172,15 @@ func loadPredictMarketRules(db *sql.DB) ([]*core.PredictMarketAlertRule, error)
 	var rules []*core.PredictMarketAlertRule
 	for rows.Next() {
 		var id int64
-		var predictMarket, field, direction, recipientEmail, telegramChatID string
+		var predictMarket, field, direction string
+		var snoozeSeconds, triggeredSecondsAgo int64
 		var threshold float64
 		var enabled bool
-		var paramsJSON, frequencyJSON []byte
+		var paramsJSON, frequencyJSON, tagsJSON []byte
+		var notification notificationRow
 
-		if err := rows.Scan(&id, &predictMarket, &paramsJSON, &field, &threshold, &direction, &enabled, &frequencyJSON, &recipientEmail, &telegramChatID); err != nil {
+		targets := append([]interface{}{&id, &predictMarket, &paramsJSON, &field, &threshold, &direction, &enabled, &frequencyJSON}, notification.targets()...)
+		if err := rows.Scan(append(targets, &tagsJSON, &snoozeSeconds, &triggeredSecondsAgo)...); err != nil {
 			return nil, err
 		}
 
@@ -94,15 +191,23 @@ func loadPredictMarketRules(db *sql.DB) ([]*core.PredictMarketAlertRule, error)
 			}
 		}
 
+		nc, err := notification.config(groups)
+		if err != nil {
+			return nil, fmt.Errorf("predict market rule id %d: %w", id, err)
+		}
 		rc := config.PredictMarketAlertRuleConfig{
-			PredictMarket:  predictMarket,
-			Params:         params,
-			Field:          field,
-			Threshold:      threshold,
-			Direction:      direction,
-			Enabled:        enabled,
-			RecipientEmail: recipientEmail,
-			TelegramChatID: telegramChatID,
+			PredictMarket:      predictMarket,
+			Params:             params,
+			Field:              field,
+			Threshold:          threshold,
+			Direction:          direction,
+			Enabled:            enabled,
+			NotificationConfig: nc,
+		}
+		if len(tagsJSON) > 0 {
+			if err := json.Unmarshal(tagsJSON, &rc.Tags); err != nil {
+				return nil, fmt.Errorf("predict market rule id %d: invalid tags JSON: %w", id, err)
+			}
 		}
 		if len(frequencyJSON) > 0 {
 			var freq config.FrequencyConfig
@@ -117,39 +222,216 @@ func loadPredictMarketRules(db *sql.DB) ([]*core.PredictMarketAlertRule, error)
 			return nil, fmt.Errorf("predict market rule id %d: %w", id, err)
 		}
 		rule.ID = id
+		rule.SnoozedUntil = snoozedUntil(snoozeSeconds)
+		rule.LastTriggered = lastTriggered(triggeredSecondsAgo)
 		rules = append(rules, rule)
 	}
 	return rules, rows.Err()
 }
 
-func loadTokenRules(db *sql.DB) ([]*core.AlertRule, error) {
-	query := `SELECT id, symbol, price_feed_id, threshold, direction, enabled, frequency, COALESCE(recipient_email, ''), COALESCE(telegram_chat_id, '') FROM ` + tokenTable
+func loadWatchRules(db *sql.DB, d dialect, groups config.ContactGroups) ([]*core.WatchAlertRule, error) {
+	query := `SELECT id, source, chain_id, COALESCE(label, ''), params, field, threshold, COALESCE(direction, ''), enabled, frequency, ` + notificationColumns + `, tags, COALESCE(` + d.secondsBetween(d.now(), `snoozed_until`) + `, 0), COALESCE(` + d.secondsBetween(`last_triggered`, d.now()) + `, -1) FROM ` + watchTable + ` WHERE deleted_at IS NULL`
 	rows, err := db.Query(query)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	var rules []*core.AlertRule
+	var rules []*core.WatchAlertRule
 	for rows.Next() {
 		var id int64
-		var symbol, priceFeedID, direction, recipientEmail, telegramChatID string
+		var source, chainID, label, field, direction string
+		var snoozeSeconds, triggeredSecondsAgo int64
 		var threshold float64
 		var enabled bool
-		var frequencyJSON []byte
+		var paramsJSON, frequencyJSON, tagsJSON []byte
+		var notification notificationRow
 
-		if err := rows.Scan(&id, &symbol, &priceFeedID, &threshold, &direction, &enabled, &frequencyJSON, &recipientEmail, &telegramChatID); err != nil {
+		targets := append([]interface{}{&id, &source, &chainID, &label, &paramsJSON, &field, &threshold, &direction, &enabled, &frequencyJSON}, notification.targets()...)
+		if err := rows.Scan(append(targets, &tagsJSON, &snoozeSeconds, &triggeredSecondsAgo)...); err != nil {
 			return nil, err
 		}
 
+		nc, err := notification.config(groups)
+		if err != nil {
+			return nil, fmt.Errorf("watch rule id %d: %w", id, err)
+		}
+		rc := config.WatchAlertRuleConfig{
+			Source:             source,
+			ChainID:            chainID,
+			Label:              label,
+			Field:              field,
+			Threshold:          threshold,
+			Direction:          direction,
+			Enabled:            enabled,
+			NotificationConfig: nc,
+		}
+		if len(paramsJSON) > 0 {
+			if err := json.Unmarshal(paramsJSON, &rc.Params); err != nil {
+				return nil, fmt.Errorf("watch rule id %d: invalid params JSON: %w", id, err)
+			}
+		}
+		if len(tagsJSON) > 0 {
+			if err := json.Unmarshal(tagsJSON, &rc.Tags); err != nil {
+				return nil, fmt.Errorf("watch rule id %d: invalid tags JSON: %w", id, err)
+			}
+		}
+		if len(frequencyJSON) > 0 {
+			var freq config.FrequencyConfig
+			if err := json.Unmarshal(frequencyJSON, &freq); err != nil {
+				return nil, fmt.Errorf("watch rule id %d: invalid frequency JSON: %w", id, err)
+			}
+			rc.Frequency = &freq
+		}
+
+		rule, err := config.ParseWatchRule(rc)
+		if err != nil {
+			return nil, fmt.Errorf("watch rule id %d: %w", id, err)
+		}
+		rule.ID = id
+		rule.SnoozedUntil = snoozedUntil(snoozeSeconds)
+		rule.LastTriggered = lastTriggered(triggeredSecondsAgo)
+		rules = append(rules, rule)
+	}
+	return rules, rows.Err()
+}
+
+// notificationColumns are the delivery settings columns every rule table has,
+// read into a notificationRow
+const notificationColumns = `COALESCE(recipient_email, ''), COALESCE(telegram_chat_id, ''), COALESCE(webhook_url, ''), COALESCE(whatsapp_to, ''), COALESCE(teams_webhook_url, ''), COALESCE(severity, ''), COALESCE(pagerduty_routing_key, ''), COALESCE(resolves_rule_id, 0), COALESCE(opsgenie_api_key, ''), COALESCE(ntfy_topic, ''), COALESCE(pushover_user_key, ''), recipient_emails, telegram_chat_ids, COALESCE(contact_group, ''), channels, COALESCE(message_template, ''), COALESCE(locale, ''), COALESCE(digest_minutes, 0), COALESCE(telegram_format, ''), COALESCE(telegram_silent, FALSE), COALESCE(telegram_no_preview, FALSE), COALESCE(escalate_after_minutes, 0), escalate_to`
+
+// notificationRow holds the notificationColumns of a rule row
+type notificationRow struct {
+	config.NotificationConfig
+	recipientEmailsJSON, telegramChatIDsJSON, channelsJSON, escalateToJSON []byte
+}
+
+// targets returns where rows.Scan reads the notificationColumns to
+func (r *notificationRow) targets() []interface{} {
+	nc := &r.NotificationConfig
+	return []interface{}{&nc.RecipientEmail, &nc.TelegramChatID, &nc.WebhookURL, &nc.WhatsAppTo, &nc.TeamsWebhookURL, &nc.Severity, &nc.PagerDutyRoutingKey, &nc.ResolvesRuleID, &nc.OpsgenieAPIKey, &nc.NtfyTopic, &nc.PushoverUserKey, &r.recipientEmailsJSON, &r.telegramChatIDsJSON, &nc.ContactGroup, &r.channelsJSON, &nc.MessageTemplate, &nc.Locale, &nc.DigestMinutes, &nc.TelegramFormat, &nc.TelegramSilent, &nc.TelegramNoPreview, &nc.EscalateAfterMinutes, &r.escalateToJSON}
+}
+
+// config decodes the JSON array columns and adds the members of the rule's
+// contact group
+func (r *notificationRow) config(groups config.ContactGroups) (config.NotificationConfig, error) {
+	nc := r.NotificationConfig
+	for _, column := range []struct {
+		name  string
+		value []byte
+		to    *[]string
+	}{
+		{"recipient_emails", r.recipientEmailsJSON, &nc.RecipientEmails},
+		{"telegram_chat_ids", r.telegramChatIDsJSON, &nc.TelegramChatIDs},
+		{"channels", r.channelsJSON, &nc.Channels},
+		{"escalate_to", r.escalateToJSON, &nc.EscalateTo},
+	} {
+		if len(column.value) > 0 {
+			if err := json.Unmarshal(column.value, column.to); err != nil {
+				return nc, fmt.Errorf("invalid %s JSON: %w", column.name, err)
+			}
+		}
+	}
+	if err := groups.Expand(nc.ContactGroup, &nc.RecipientEmails, &nc.TelegramChatIDs, &nc.WebhookURL); err != nil {
+		return nc, err
+	}
+	return nc, nil
+}
+
+// snoozedUntil returns when a rule's snooze ends from the seconds it has left,
+// or nil when the rule isn't snoozed. The database computes the seconds, so
+// snoozed_until is compared in UTC whatever the connection's time zone.
+func snoozedUntil(seconds int64) *time.Time {
+	if seconds <= 0 {
+		return nil
+	}
+	until := time.Now().Add(time.Duration(seconds) * time.Second)
+	return &until
+}
+
+// lastTriggered returns when a rule last triggered from the seconds since,
+// or nil when it never did (negative seconds)
+func lastTriggered(secondsAgo int64) *time.Time {
+	if secondsAgo < 0 {
+		return nil
+	}
+	at := time.Now().Add(-time.Duration(secondsAgo) * time.Second)
+	return &at
+}
+
+// loadContactGroups loads the contact groups rules can reference by name.
+// emails and telegram_chat_ids are JSON arrays.
+func loadContactGroups(db *sql.DB) (config.ContactGroups, error) {
+	query := `SELECT name, emails, telegram_chat_ids, COALESCE(webhook_url, '') FROM ` + contactGroupTable
+	rows, err := db.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	groups := make(config.ContactGroups)
+	for rows.Next() {
+		var g config.ContactGroupConfig
+		var emailsJSON, telegramChatIDsJSON []byte
+
+		if err := rows.Scan(&g.Name, &emailsJSON, &telegramChatIDsJSON, &g.WebhookURL); err != nil {
+			return nil, err
+		}
+		if len(emailsJSON) > 0 {
+			if err := json.Unmarshal(emailsJSON, &g.Emails); err != nil {
+				return nil, fmt.Errorf("contact group %s: invalid emails JSON: %w", g.Name, err)
+			}
+		}
+		if len(telegramChatIDsJSON) > 0 {
+			if err := json.Unmarshal(telegramChatIDsJSON, &g.TelegramChatIDs); err != nil {
+				return nil, fmt.Errorf("contact group %s: invalid telegram_chat_ids JSON: %w", g.Name, err)
+			}
+		}
+		groups[g.Name] = g
+	}
+	return groups, rows.Err()
+}
+
+func loadTokenRules(db *sql.DB, d dialect, groups config.ContactGroups) ([]*core.AlertRule, error) {
+	query := `SELECT id, symbol, price_feed_id, threshold, direction, enabled, frequency, COALESCE(telegram_chart, FALSE), ` + notificationColumns + `, tags, COALESCE(` + d.secondsBetween(d.now(), `snoozed_until`) + `, 0), COALESCE(` + d.secondsBetween(`last_triggered`, d.now()) + `, -1) FROM ` + tokenTable + ` WHERE deleted_at IS NULL`
+	rows, err := db.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var rules []*core.AlertRule
+	for rows.Next() {
+		var id int64
+		var symbol, priceFeedID, direction string
+		var snoozeSeconds, triggeredSecondsAgo int64
+		var threshold float64
+		var enabled, telegramChart bool
+		var frequencyJSON, tagsJSON []byte
+		var notification notificationRow
+
+		targets := append([]interface{}{&id, &symbol, &priceFeedID, &threshold, &direction, &enabled, &frequencyJSON, &telegramChart}, notification.targets()...)
+		if err := rows.Scan(append(targets, &tagsJSON, &snoozeSeconds, &triggeredSecondsAgo)...); err != nil {
+			return nil, err
+		}
+
+		nc, err := notification.config(groups)
+		if err != nil {
+			return nil, fmt.Errorf("token rule id %d: %w", id, err)
+		}
 		rc := config.AlertRuleConfig{
-			Symbol:         symbol,
-			PriceFeedID:    priceFeedID,
-			Threshold:      threshold,
-			Direction:      direction,
-			Enabled:        enabled,
-			RecipientEmail: recipientEmail,
-			TelegramChatID: telegramChatID,
+			Symbol:             symbol,
+			PriceFeedID:        priceFeedID,
+			Threshold:          threshold,
+			Direction:          direction,
+			Enabled:            enabled,
+			NotificationConfig: nc,
+			TelegramChart:      telegramChart,
+		}
+		if len(tagsJSON) > 0 {
+			if err := json.Unmarshal(tagsJSON, &rc.Tags); err != nil {
+				return nil, fmt.Errorf("token rule id %d: invalid tags JSON: %w", id, err)
+			}
 		}
 		if len(frequencyJSON) > 0 {
 			var freq config.FrequencyConfig
@@ -164,13 +446,15 @@ func loadTokenRules(db *sql.DB) ([]*core.AlertRule, error) {
 			return nil, fmt.Errorf("token rule id %d: %w", id, err)
 		}
 		rule.ID = id
+		rule.SnoozedUntil = snoozedUntil(snoozeSeconds)
+		rule.LastTriggered = lastTriggered(triggeredSecondsAgo)
 		rules = append(rules, rule)
 	}
 	return rules, rows.Err()
 }
 
-func loadDeFiRules(db *sql.DB) ([]*core.DeFiAlertRule, error) {
-	query := `SELECT id, protocol, version, chain_id, params, field, threshold, direction, enabled, frequency, COALESCE(recipient_email, ''), COALESCE(telegram_chat_id, '') FROM ` + defiTable
+func loadDeFiRules(db *sql.DB, d dialect, groups config.ContactGroups) ([]*core.DeFiAlertRule, error) {
+	query := `SELECT id, protocol, version, chain_id, params, field, threshold, direction, enabled, frequency, COALESCE(telegram_chart, FALSE), ` + notificationColumns + `, tags, COALESCE(` + d.secondsBetween(d.now(), `snoozed_until`) + `, 0), COALESCE(` + d.secondsBetween(`last_triggered`, d.now()) + `, -1) FROM ` + defiTable + ` WHERE deleted_at IS NULL`
 	rows, err := db.Query(query)
 	if err != nil {
 		return nil, err
@@ -180,12 +464,15 @@ func loadDeFiRules(db *sql.DB) ([]*core.DeFiAlertRule, error) {
 	var rules []*core.DeFiAlertRule
 	for rows.Next() {
 		var id int64
-		var protocol, version, chainID, field, direction, recipientEmail, telegramChatID string
+		var protocol, version, chainID, field, direction string
+		var snoozeSeconds, triggeredSecondsAgo int64
 		var threshold float64
-		var enabled bool
-		var paramsJSON, frequencyJSON []byte
+		var enabled, telegramChart bool
+		var paramsJSON, frequencyJSON, tagsJSON []byte
+		var notification notificationRow
 
-		if err := rows.Scan(&id, &protocol, &version, &chainID, &paramsJSON, &field, &threshold, &direction, &enabled, &frequencyJSON, &recipientEmail, &telegramChatID); err != nil {
+		targets := append([]interface{}{&id, &protocol, &version, &chainID, &paramsJSON, &field, &threshold, &direction, &enabled, &frequencyJSON, &telegramChart}, notification.targets()...)
+		if err := rows.Scan(append(targets, &tagsJSON, &snoozeSeconds, &triggeredSecondsAgo)...); err != nil {
 			return nil, err
 		}
 
@@ -207,18 +494,27 @@ func loadDeFiRules(db *sql.DB) ([]*core.DeFiAlertRule, error) {
 			}
 		}
 
+		nc, err := notification.config(groups)
+		if err != nil {
+			return nil, fmt.Errorf("defi rule id %d: %w", id, err)
+		}
 		rc := config.DeFiAlertRuleConfig{
-			Protocol:       protocol,
-			Category:       category,
-			Version:        version,
-			ChainID:        chainID,
-			Field:          field,
-			Threshold:      threshold,
-			Direction:      direction,
-			Enabled:        enabled,
-			RecipientEmail: recipientEmail,
-			TelegramChatID: telegramChatID,
-			Params:         params,
+			Protocol:           protocol,
+			Category:           category,
+			Version:            version,
+			ChainID:            chainID,
+			Field:              field,
+			Threshold:          threshold,
+			Direction:          direction,
+			Enabled:            enabled,
+			NotificationConfig: nc,
+			TelegramChart:      telegramChart,
+			Params:             params,
+		}
+		if len(tagsJSON) > 0 {
+			if err := json.Unmarshal(tagsJSON, &rc.Tags); err != nil {
+				return nil, fmt.Errorf("defi rule id %d: invalid tags JSON: %w", id, err)
+			}
 		}
 		if len(frequencyJSON) > 0 {
 			var freq config.FrequencyConfig
@@ -233,6 +529,8 @@ func loadDeFiRules(db *sql.DB) ([]*core.DeFiAlertRule, error) {
 			return nil, fmt.Errorf("defi rule id %d: %w", id, err)
 		}
 		rule.ID = id
+		rule.SnoozedUntil = snoozedUntil(snoozeSeconds)
+		rule.LastTriggered = lastTriggered(triggeredSecondsAgo)
 		rules = append(rules, rule)
 	}
 	return rules, rows.Err()
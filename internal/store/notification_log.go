@@ -0,0 +1,181 @@
+package store
+
+import (
+	"database/sql"
+	"strings"
+	"time"
+)
+
+// Delivery statuses recorded in notification_log. Sends are recorded as sent,
+// failed, suppressed, rate_limited, digested (held for the rule's digest) or
+// muted; provider webhooks later move sent emails to delivered, bounced or
+// complained.
+const (
+	DeliveryStatusSent        = "sent"
+	DeliveryStatusFailed      = "failed"
+	DeliveryStatusSuppressed  = "suppressed"
+	DeliveryStatusRateLimited = "rate_limited"
+	DeliveryStatusDigested    = "digested"
+	DeliveryStatusMuted       = "muted"
+	DeliveryStatusDelivered   = "delivered"
+	DeliveryStatusBounced     = "bounced"
+	DeliveryStatusComplained  = "complained"
+)
+
+// NotificationLogEntry is one delivery attempt of an alert to one recipient
+type NotificationLogEntry struct {
+	RuleID            int64
+	EventID           string // Idempotency key of the alert event
+	Topic             string
+	Channel           string
+	Recipient         string // Masked for display, see message.DisplayDestination
+	DestinationHash   string // Identifies the full destination, see message.DestinationHash
+	Status            string
+	ProviderMessageID string
+	Error             string
+	Attempt           int // 0 for the first delivery, then the alerts.retry attempt number
+}
+
+// NotificationLog records notification delivery attempts in MySQL or
+// PostgreSQL
+type NotificationLog struct {
+	db      *sql.DB
+	dialect dialect
+}
+
+func NewNotificationLog(dsn string) (*NotificationLog, error) {
+	d, dsn := dialectOf(dsn)
+	db, err := openDB(d, dsn, 5, 2)
+	if err != nil {
+		return nil, err
+	}
+	return &NotificationLog{db: db, dialect: d}, nil
+}
+
+func (l *NotificationLog) Close() {
+	if l != nil && l.db != nil {
+		l.db.Close()
+	}
+}
+
+// Record inserts a delivery attempt. It is a no-op on a nil log.
+func (l *NotificationLog) Record(e NotificationLogEntry) error {
+	if l == nil {
+		return nil
+	}
+	_, err := l.db.Exec(
+		l.dialect.rebind(`INSERT INTO notification_log (rule_id, event_id, topic, channel, recipient, destination_hash, status, provider_message_id, error, attempt, created_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, `+l.dialect.now()+`)`),
+		e.RuleID, nullString(e.EventID), e.Topic, e.Channel, e.Recipient, e.DestinationHash, e.Status, nullString(e.ProviderMessageID), nullString(e.Error), e.Attempt,
+	)
+	return err
+}
+
+// DeliveryDayCount is how many deliveries had a status on a day
+type DeliveryDayCount struct {
+	Day    string // yyyyMMdd, UTC
+	Status string
+	Count  int
+}
+
+// DailyStatusCounts returns how many deliveries had each status per day, from
+// since until before until
+func (l *NotificationLog) DailyStatusCounts(since, until time.Time) ([]DeliveryDayCount, error) {
+	day := l.dialect.day("created_at")
+	rows, err := l.db.Query(
+		l.dialect.rebind(`SELECT `+day+`, status, COUNT(*) FROM notification_log WHERE created_at >= ? AND created_at < ? GROUP BY `+day+`, status`),
+		since.UTC().Format("2006-01-02 15:04:05"), until.UTC().Format("2006-01-02 15:04:05"),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var counts []DeliveryDayCount
+	for rows.Next() {
+		var c DeliveryDayCount
+		if err := rows.Scan(&c.Day, &c.Status, &c.Count); err != nil {
+			return nil, err
+		}
+		counts = append(counts, c)
+	}
+	return counts, rows.Err()
+}
+
+// EventRecipients returns, per channel, the destination hashes of the
+// recipients the alert event with the given ID was sent to within the last
+// window, on its first delivery or a retry. Sends held for a digest count as
+// sent; failed, suppressed, rate limited and muted ones don't.
+func (l *NotificationLog) EventRecipients(eventID string, window time.Duration) (map[string][]string, error) {
+	if l == nil || eventID == "" {
+		return nil, nil
+	}
+	rows, err := l.db.Query(
+		l.dialect.rebind(`SELECT DISTINCT channel, destination_hash FROM notification_log WHERE event_id = ? AND status IN (?, ?, ?, ?, ?) AND created_at > `+l.dialect.nowPlusSeconds()),
+		eventID, DeliveryStatusSent, DeliveryStatusDelivered, DeliveryStatusBounced, DeliveryStatusComplained, DeliveryStatusDigested, -int64(window/time.Second),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	recipients := map[string][]string{}
+	for rows.Next() {
+		var channel, hash string
+		if err := rows.Scan(&channel, &hash); err != nil {
+			return nil, err
+		}
+		recipients[channel] = append(recipients[channel], hash)
+	}
+	return recipients, rows.Err()
+}
+
+// UpdateStatus sets the status of the deliveries with the given provider
+// message ID, keeping the recorded error when errMsg is empty. It returns the
+// number of deliveries updated.
+func (l *NotificationLog) UpdateStatus(providerMessageID, status, errMsg string) (int64, error) {
+	if l == nil || providerMessageID == "" {
+		return 0, nil
+	}
+	res, err := l.db.Exec(
+		l.dialect.rebind(`UPDATE notification_log SET status = ?, error = COALESCE(?, error), status_updated_at = `+l.dialect.now()+` WHERE provider_message_id = ?`),
+		status, nullString(errMsg), providerMessageID,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// SuppressEmail adds an address to the email suppression list. Suppressed
+// addresses are no longer sent alerts.
+func (l *NotificationLog) SuppressEmail(email, reason string) error {
+	if l == nil {
+		return nil
+	}
+	_, err := l.db.Exec(
+		l.dialect.rebind(`INSERT INTO email_suppression (email, reason, created_at) VALUES (?, ?, `+l.dialect.now()+`)`+l.dialect.upsert(`email`, `reason`)),
+		normalizeEmail(email), reason,
+	)
+	return err
+}
+
+// IsEmailSuppressed reports whether an address is on the email suppression list
+func (l *NotificationLog) IsEmailSuppressed(email string) (bool, error) {
+	if l == nil {
+		return false, nil
+	}
+	var n int
+	err := l.db.QueryRow(l.dialect.rebind(`SELECT COUNT(*) FROM email_suppression WHERE email = ?`), normalizeEmail(email)).Scan(&n)
+	if err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
+
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+func nullString(s string) sql.NullString {
+	return sql.NullString{String: s, Valid: s != ""}
+}
@@ -0,0 +1,20 @@
+package store
+
+import "fmt"
+
+// PostgresStore reads the alert rules from PostgreSQL, in the tables of
+// migrations/postgres, and writes their triggers back
+type PostgresStore struct {
+	*sqlRules
+}
+
+func NewPostgresStore(dsn string) (*PostgresStore, error) {
+	if dsn == "" {
+		return nil, fmt.Errorf("PostgreSQL DSN is required")
+	}
+	s, err := openRules(postgresDialect, dsn)
+	if err != nil {
+		return nil, err
+	}
+	return &PostgresStore{s}, nil
+}
@@ -0,0 +1,175 @@
+package store
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"crypto-alert/internal/core"
+)
+
+// ruleTables are the rule tables by rule kind, as alerts name them
+var ruleTables = map[string]string{
+	"token":   tokenTable,
+	"defi":    defiTable,
+	"predict": predictMarketTable,
+	"watch":   watchTable,
+}
+
+// ErrRuleNotFound is returned for an action on a rule that doesn't exist, or
+// is archived (restoring: isn't archived)
+var ErrRuleNotFound = errors.New("rule not found")
+
+// RuleActions changes the state of alert rules from their notifications,
+// e.g. the snooze and disable buttons on Telegram alerts. The engine picks
+// the changes up on its next rule reload. Each change is recorded in the
+// rule change audit log.
+type RuleActions struct {
+	db      *sql.DB
+	dialect dialect
+	audit   *ruleAudit
+}
+
+func NewRuleActions(dsn string) (*RuleActions, error) {
+	d, dsn := dialectOf(dsn)
+	db, err := openDB(d, dsn, 2, 1)
+	if err != nil {
+		return nil, err
+	}
+	return &RuleActions{db: db, dialect: d, audit: &ruleAudit{db: db, dialect: d}}, nil
+}
+
+func (a *RuleActions) Close() {
+	if a != nil && a.db != nil {
+		a.db.Close()
+	}
+}
+
+// Snooze keeps the rule of kind (token, defi, predict or watch) from alerting
+// for d; by names who snoozed it
+func (a *RuleActions) Snooze(kind string, ruleID int64, d time.Duration, by string) error {
+	return a.update(kind, ruleID, false, by, `snoozed_until = `+a.dialect.nowPlusSeconds(), int64(d/time.Second))
+}
+
+// Disable disables the rule of kind (token, defi, predict or watch); by
+// names who disabled it
+func (a *RuleActions) Disable(kind string, ruleID int64, by string) error {
+	return a.update(kind, ruleID, false, by, `enabled = FALSE`)
+}
+
+// Archive archives (soft deletes) the rule of kind: the engine drops it on
+// its next rule reload, but its row stays for the alert history and can be
+// restored. by names who archived it.
+func (a *RuleActions) Archive(kind string, ruleID int64, by string) error {
+	return a.update(kind, ruleID, false, by, `deleted_at = `+a.dialect.now())
+}
+
+// Restore restores the archived rule of kind, as it was when archived; by
+// names who restored it
+func (a *RuleActions) Restore(kind string, ruleID int64, by string) error {
+	return a.update(kind, ruleID, true, by, `deleted_at = NULL`)
+}
+
+// ruleSummaryColumns are the subject and field of each kind's rules
+var ruleSummaryColumns = map[string][2]string{
+	"token":   {`symbol`, `'PRICE'`},
+	"defi":    {`protocol`, `field`},
+	"predict": {`predict_market`, `field`},
+	"watch":   {`source`, `field`},
+}
+
+// List returns the live rules of kind, or of every kind when kind is empty,
+// with tag when it isn't empty, by kind and ID
+func (a *RuleActions) List(kind, tag string) ([]core.RuleSummary, error) {
+	if a == nil {
+		return nil, fmt.Errorf("rule actions need a database")
+	}
+	kinds := ruleKinds
+	if kind != "" {
+		if _, ok := ruleTables[kind]; !ok {
+			return nil, fmt.Errorf("unknown rule kind %q", kind)
+		}
+		kinds = []string{kind}
+	}
+	var rules []core.RuleSummary
+	for _, k := range kinds {
+		cols := ruleSummaryColumns[k]
+		rows, err := a.db.Query(`SELECT id, ` + cols[0] + `, ` + cols[1] + `, COALESCE(direction, ''), threshold, enabled, COALESCE(` + a.dialect.secondsBetween(a.dialect.now(), `snoozed_until`) + `, 0), tags FROM ` + ruleTables[k] + ` WHERE deleted_at IS NULL ORDER BY id`)
+		if err != nil {
+			return nil, err
+		}
+		for rows.Next() {
+			r := core.RuleSummary{Kind: k, Tags: []string{}}
+			var snoozeSeconds int64
+			var tagsJSON []byte
+			if err := rows.Scan(&r.ID, &r.Subject, &r.Field, &r.Direction, &r.Threshold, &r.Enabled, &snoozeSeconds, &tagsJSON); err != nil {
+				rows.Close()
+				return nil, err
+			}
+			if len(tagsJSON) > 0 {
+				json.Unmarshal(tagsJSON, &r.Tags)
+			}
+			for i, t := range r.Tags {
+				r.Tags[i] = strings.ToLower(t) // The engine lowercases them too
+			}
+			if tag != "" && !core.HasTag(r.Tags, tag) {
+				continue
+			}
+			r.Snoozed = snoozeSeconds > 0
+			rules = append(rules, r)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return nil, err
+		}
+	}
+	return rules, nil
+}
+
+// Acknowledge acknowledges the pending escalation of the rule of kind, so its
+// critical alert isn't escalated; by names who acknowledged it. It reports
+// whether the rule had an escalation pending.
+func (a *RuleActions) Acknowledge(kind string, ruleID int64, by string) (bool, error) {
+	if a == nil {
+		return false, fmt.Errorf("rule actions need a database")
+	}
+	res, err := a.db.Exec(
+		a.dialect.rebind(`UPDATE alert_escalation SET acked_at = `+a.dialect.now()+`, acked_by = ? WHERE rule_kind = ? AND rule_id = ? AND acked_at IS NULL AND escalated_at IS NULL`),
+		by, kind, ruleID,
+	)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// update sets the columns of one rule, live or archived, and records the
+// change, made by by
+func (a *RuleActions) update(kind string, ruleID int64, archived bool, by, set string, args ...interface{}) error {
+	if a == nil {
+		return fmt.Errorf("rule actions need a database")
+	}
+	table, ok := ruleTables[kind]
+	if !ok {
+		return fmt.Errorf("unknown rule kind %q", kind)
+	}
+	var exists bool
+	state := `deleted_at IS NULL`
+	if archived {
+		state = `deleted_at IS NOT NULL`
+	}
+	if err := a.db.QueryRow(a.dialect.rebind(`SELECT EXISTS(SELECT 1 FROM `+table+` WHERE id = ? AND `+state+`)`), ruleID).Scan(&exists); err != nil {
+		return err
+	}
+	if !exists {
+		return ErrRuleNotFound
+	}
+	return a.audit.change(kind, ruleID, by, func() error {
+		_, err := a.db.Exec(a.dialect.rebind(`UPDATE `+table+` SET `+set+` WHERE id = ?`), append(args, ruleID)...)
+		return err
+	})
+}
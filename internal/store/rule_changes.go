@@ -0,0 +1,361 @@
+package store
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"slices"
+	"sync"
+	"time"
+
+	"crypto-alert/internal/logger"
+)
+
+// Actions of rule changes
+const (
+	RuleCreated  = "create"
+	RuleUpdated  = "update"
+	RuleDeleted  = "delete"
+	RuleArchived = "archive"
+	RuleRestored = "restore"
+)
+
+// ruleChangeIgnored are the columns a rule change leaves out: the engine
+// writes them on every trigger
+var ruleChangeIgnored = map[string]bool{"last_triggered": true, "updated_at": true}
+
+// RuleChange is one create, update or delete of a rule in the audit log
+type RuleChange struct {
+	ID        int64           `json:"id"`
+	RuleKind  string          `json:"rule_kind"`
+	RuleID    int64           `json:"rule_id"`
+	Version   int             `json:"version"`
+	Action    string          `json:"action"`
+	ChangedBy string          `json:"changed_by"`
+	Old       json.RawMessage `json:"old"` // The rule's row before, null when it was created
+	New       json.RawMessage `json:"new"` // The rule's row after, null when it was deleted
+	ChangedAt time.Time       `json:"changed_at"`
+}
+
+// ruleAudit records rule changes in the rule_change table by comparing the
+// rule rows with the latest version recorded of each
+type ruleAudit struct {
+	db      *sql.DB
+	dialect dialect
+	mu      sync.Mutex // Keeps the versions of one process's changes apart
+}
+
+// recordAll records the changes of every rule since their latest versions,
+// made by by, and returns how many there were
+func (a *ruleAudit) recordAll(by string) (int, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	latest, err := a.latest(`1 = 1`)
+	if err != nil {
+		return 0, err
+	}
+	n := 0
+	for _, kind := range ruleKinds {
+		rows, err := a.rows(kind, `id > ?`, 0)
+		if err != nil {
+			return n, err
+		}
+		ids := make([]int64, 0, len(rows))
+		for id := range rows {
+			ids = append(ids, id)
+		}
+		slices.Sort(ids)
+		for _, id := range ids {
+			key := ruleKey{kind, id}
+			recorded, err := a.record(key, latest[key], rows[id], by)
+			if err != nil {
+				return n, err
+			}
+			if recorded {
+				n++
+			}
+			delete(latest, key)
+		}
+	}
+	// The rules left were deleted
+	for key, prev := range latest {
+		recorded, err := a.record(key, prev, nil, by)
+		if err != nil {
+			return n, err
+		}
+		if recorded {
+			n++
+		}
+	}
+	return n, nil
+}
+
+// RecordChanges records the changes of every rule since their latest
+// recorded versions, made outside the services, e.g. in the web UI
+func (s *sqlRules) RecordChanges() (int, error) {
+	return s.audit.recordAll("database")
+}
+
+// change makes the change of the rule of kind with id that apply does, and
+// records it as made by by. The changes made in the database since the rule's
+// latest recorded version are recorded first, as made by "database".
+func (a *ruleAudit) change(kind string, id int64, by string, apply func() error) error {
+	if err := a.recordRule(kind, id, "database"); err != nil {
+		slog.Warn("⚠️  Failed to record the changes of a rule", logger.FieldKind, kind, logger.FieldRuleID, id, logger.FieldError, err)
+	}
+	if err := apply(); err != nil {
+		return err
+	}
+	if err := a.recordRule(kind, id, by); err != nil {
+		slog.Warn("⚠️  Failed to record the change of a rule", logger.FieldKind, kind, logger.FieldRuleID, id, logger.FieldError, err)
+	}
+	return nil
+}
+
+// recordRule records the change of the rule of kind with id since its
+// latest version, made by by
+func (a *ruleAudit) recordRule(kind string, id int64, by string) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	key := ruleKey{kind, id}
+	latest, err := a.latest(`rule_kind = ? AND rule_id = ?`, kind, id)
+	if err != nil {
+		return err
+	}
+	rows, err := a.rows(kind, `id = ?`, id)
+	if err != nil {
+		return err
+	}
+	_, err = a.record(key, latest[key], rows[id], by)
+	return err
+}
+
+// ruleKinds are the rule kinds in the order their changes are recorded
+var ruleKinds = []string{"token", "defi", "predict", "watch"}
+
+// ruleKey is a rule of one kind
+type ruleKey struct {
+	kind string
+	id   int64
+}
+
+// ruleVersion is the latest recorded version of a rule
+type ruleVersion struct {
+	version int
+	deleted bool
+	row     string // JSON of the row, empty when deleted
+}
+
+// latest returns the latest recorded version of the rules the where clause selects
+func (a *ruleAudit) latest(where string, args ...interface{}) (map[ruleKey]ruleVersion, error) {
+	rows, err := a.db.Query(a.dialect.rebind(
+		`SELECT rule_kind, rule_id, version, action, COALESCE(new_value, '') FROM rule_change WHERE id IN (SELECT MAX(id) FROM rule_change WHERE `+where+` GROUP BY rule_kind, rule_id)`,
+	), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	latest := map[ruleKey]ruleVersion{}
+	for rows.Next() {
+		var key ruleKey
+		var v ruleVersion
+		var action string
+		if err := rows.Scan(&key.kind, &key.id, &v.version, &action, &v.row); err != nil {
+			return nil, err
+		}
+		v.deleted = action == RuleDeleted
+		latest[key] = v
+	}
+	return latest, rows.Err()
+}
+
+// rows returns the rows of kind's table the where clause selects as JSON, by
+// rule ID. Every query takes one argument, so MySQL returns the values in the
+// same types each time.
+func (a *ruleAudit) rows(kind, where string, arg interface{}) (map[int64]*string, error) {
+	table, ok := ruleTables[kind]
+	if !ok {
+		return nil, fmt.Errorf("unknown rule kind %q", kind)
+	}
+	rows, err := a.db.Query(a.dialect.rebind(`SELECT * FROM `+table+` WHERE `+where), arg)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+
+	out := map[int64]*string{}
+	values := make([]interface{}, len(columns))
+	ptrs := make([]interface{}, len(columns))
+	for i := range values {
+		ptrs[i] = &values[i]
+	}
+	for rows.Next() {
+		if err := rows.Scan(ptrs...); err != nil {
+			return nil, err
+		}
+		row := map[string]interface{}{}
+		var id int64
+		for i, c := range columns {
+			if c == "id" {
+				id, _ = asInt64(values[i])
+			}
+			if !ruleChangeIgnored[c] {
+				row[c] = ruleChangeValue(values[i])
+			}
+		}
+		b, err := json.Marshal(row) // Keys sorted, so equal rows compare equal
+		if err != nil {
+			return nil, err
+		}
+		s := string(b)
+		out[id] = &s
+	}
+	return out, rows.Err()
+}
+
+// record inserts the change from the rule's latest version to row (nil when
+// the rule doesn't exist) if there is one, and reports whether it did
+func (a *ruleAudit) record(key ruleKey, prev ruleVersion, row *string, by string) (bool, error) {
+	var action string
+	var oldValue, newValue interface{}
+	switch {
+	case row == nil && (prev.version == 0 || prev.deleted):
+		return false, nil
+	case row == nil:
+		action, oldValue = RuleDeleted, prev.row
+	case prev.version == 0 || prev.deleted:
+		action, newValue = RuleCreated, *row
+	case *row == prev.row:
+		return false, nil
+	default:
+		action, oldValue, newValue = RuleUpdated, prev.row, *row
+		if was, is := archivedRow(prev.row), archivedRow(*row); !was && is {
+			action = RuleArchived
+		} else if was && !is {
+			action = RuleRestored
+		}
+	}
+	if len(by) > 255 {
+		by = by[:255]
+	}
+	_, err := a.db.Exec(
+		a.dialect.rebind(`INSERT INTO rule_change (rule_kind, rule_id, version, action, changed_by, old_value, new_value, changed_at) VALUES (?, ?, ?, ?, ?, ?, ?, `+a.dialect.now()+`)`),
+		key.kind, key.id, prev.version+1, action, by, oldValue, newValue,
+	)
+	return err == nil, err
+}
+
+// archivedRow reports whether the rule row JSON is of an archived rule
+func archivedRow(row string) bool {
+	var r struct {
+		DeletedAt *string `json:"deleted_at"`
+	}
+	json.Unmarshal([]byte(row), &r)
+	return r.DeletedAt != nil
+}
+
+// ruleChangeValue is a column value as the rule change JSON has it: text,
+// and JSON columns as JSON
+func ruleChangeValue(v interface{}) interface{} {
+	switch v := v.(type) {
+	case []byte:
+		return ruleChangeValue(string(v))
+	case string:
+		if len(v) > 0 && (v[0] == '[' || v[0] == '{') && json.Valid([]byte(v)) {
+			return json.RawMessage(v)
+		}
+		return v
+	case time.Time:
+		return v.UTC().Format("2006-01-02 15:04:05")
+	}
+	return v
+}
+
+// asInt64 reads an integer column value
+func asInt64(v interface{}) (int64, bool) {
+	switch v := v.(type) {
+	case int64:
+		return v, true
+	case []byte:
+		var n int64
+		_, err := fmt.Sscan(string(v), &n)
+		return n, err == nil
+	}
+	return 0, false
+}
+
+// RuleChanges reads the audit log of the rules
+type RuleChanges struct {
+	db      *sql.DB
+	dialect dialect
+}
+
+func NewRuleChanges(dsn string) (*RuleChanges, error) {
+	d, dsn := dialectOf(dsn)
+	db, err := openDB(d, dsn, 2, 1)
+	if err != nil {
+		return nil, err
+	}
+	return &RuleChanges{db: db, dialect: d}, nil
+}
+
+func (c *RuleChanges) Close() {
+	if c != nil && c.db != nil {
+		c.db.Close()
+	}
+}
+
+// List returns the latest limit changes, newest first: of the rule of kind
+// with ruleID, of every rule of kind when ruleID is 0, or of every rule when
+// kind is empty; only those older than the change beforeID when it isn't 0
+func (c *RuleChanges) List(kind string, ruleID int64, beforeID int64, limit int) ([]RuleChange, error) {
+	if c == nil {
+		return nil, nil
+	}
+	query := `SELECT id, rule_kind, rule_id, version, action, changed_by, COALESCE(old_value, ''), COALESCE(new_value, ''), ` + c.dialect.secondsBetween(`changed_at`, c.dialect.now()) + ` FROM rule_change WHERE 1 = 1`
+	var args []interface{}
+	if kind != "" {
+		query, args = query+` AND rule_kind = ?`, append(args, kind)
+	}
+	if ruleID != 0 {
+		query, args = query+` AND rule_id = ?`, append(args, ruleID)
+	}
+	if beforeID != 0 {
+		query, args = query+` AND id < ?`, append(args, beforeID)
+	}
+	query, args = query+` ORDER BY id DESC LIMIT ?`, append(args, limit)
+	rows, err := c.db.Query(c.dialect.rebind(query), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	now := time.Now().UTC()
+	var changes []RuleChange
+	for rows.Next() {
+		var ch RuleChange
+		var oldValue, newValue string
+		var secondsAgo int64
+		if err := rows.Scan(&ch.ID, &ch.RuleKind, &ch.RuleID, &ch.Version, &ch.Action, &ch.ChangedBy, &oldValue, &newValue, &secondsAgo); err != nil {
+			return nil, err
+		}
+		ch.Old, ch.New = rawJSON(oldValue), rawJSON(newValue)
+		ch.ChangedAt = now.Add(-time.Duration(secondsAgo) * time.Second).Truncate(time.Second)
+		changes = append(changes, ch)
+	}
+	return changes, rows.Err()
+}
+
+// rawJSON is the JSON s, null when empty
+func rawJSON(s string) json.RawMessage {
+	if s == "" {
+		return json.RawMessage("null")
+	}
+	return json.RawMessage(s)
+}
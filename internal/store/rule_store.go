@@ -0,0 +1,43 @@
+package store
+
+import "crypto-alert/internal/core"
+
+// RuleStore is the database the engine reads its alert rules from and writes
+// their triggers back to
+type RuleStore interface {
+	LoadAlertRules() ([]*core.AlertRule, []*core.DeFiAlertRule, error)
+	LoadPredictMarketRules() ([]*core.PredictMarketAlertRule, error)
+	LoadWatchRules() ([]*core.WatchAlertRule, error)
+	// RulesVersion changes whenever a rule or contact group does
+	RulesVersion() (string, error)
+	RecordTrigger(kind string, id int64, once bool)
+	// RecordChanges records the rule changes made in the database since the
+	// last time in the rule change audit log, and returns how many there were
+	RecordChanges() (int, error)
+	Close()
+}
+
+// NewRuleStore opens the rule store dsn points at, by its scheme: a
+// postgres:// or postgresql:// URL opens PostgreSQL, sqlite:<path> a SQLite
+// file, anything else MySQL
+func NewRuleStore(dsn string) (RuleStore, error) {
+	switch d, _ := dialectOf(dsn); d {
+	case postgresDialect:
+		s, err := NewPostgresStore(dsn)
+		if err != nil {
+			return nil, err
+		}
+		return s, nil
+	case sqliteDialect:
+		s, err := NewSQLiteStore(dsn)
+		if err != nil {
+			return nil, err
+		}
+		return s, nil
+	}
+	s, err := NewMySQLStore(dsn)
+	if err != nil {
+		return nil, err
+	}
+	return s, nil
+}
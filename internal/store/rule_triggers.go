@@ -0,0 +1,57 @@
+package store
+
+import (
+	"log/slog"
+
+	"crypto-alert/internal/logger"
+)
+
+// ruleTrigger is a rule trigger waiting to be written to its table
+type ruleTrigger struct {
+	kind string // token, defi, predict or watch
+	id   int64
+	once bool
+}
+
+// RecordTrigger queues writing that the rule of kind (token, defi, predict or
+// watch) triggered: its last_triggered is set to now, and a ONCE rule (once)
+// is disabled, so the web UI shows the rule's state and a restart neither
+// forgets the frequency suppression nor re-arms the rule. It doesn't block;
+// when the database falls behind, the trigger is only logged.
+func (s *sqlRules) RecordTrigger(kind string, id int64, once bool) {
+	select {
+	case s.triggers <- ruleTrigger{kind: kind, id: id, once: once}:
+	default:
+		slog.Warn("⚠️  Too many rule triggers queued, the trigger isn't saved", logger.FieldKind, kind, logger.FieldRuleID, id)
+	}
+}
+
+// writeTriggers writes the queued triggers until Close
+func (s *sqlRules) writeTriggers() {
+	defer close(s.written)
+	for t := range s.triggers {
+		table, ok := ruleTables[t.kind]
+		if !ok {
+			continue
+		}
+		// updated_at keeps its value: the engine already has this state, so
+		// the write shouldn't make it read the rules again
+		set := `last_triggered = ` + s.dialect.now() + `, updated_at = updated_at`
+		if t.once {
+			set += `, enabled = FALSE`
+		}
+		write := func() error {
+			_, err := s.db.Exec(s.dialect.rebind(`UPDATE `+table+` SET `+set+` WHERE id = ?`), t.id)
+			return err
+		}
+		var err error
+		if t.once {
+			err = s.audit.change(t.kind, t.id, "engine", write)
+		} else {
+			err = write()
+		}
+		if err != nil {
+			slog.Warn("⚠️  Failed to save the trigger of a rule", logger.FieldKind, t.kind, logger.FieldRuleID, t.id, logger.FieldError, err)
+		}
+	}
+}
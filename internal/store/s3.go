@@ -0,0 +1,236 @@
+package store
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// S3Config holds the settings of an S3-compatible bucket (AWS S3, MinIO,
+// Cloudflare R2, ...), addressed path-style: <endpoint>/<bucket>/<key>
+type S3Config struct {
+	Endpoint  string // e.g. https://s3.eu-west-1.amazonaws.com or http://minio:9000
+	Region    string // e.g. eu-west-1; us-east-1 for most other stores
+	Bucket    string
+	AccessKey string
+	SecretKey string
+}
+
+// s3Client makes the object requests of the log archive, signed with AWS
+// Signature Version 4
+type s3Client struct {
+	cfg    S3Config
+	base   *url.URL // Endpoint with the bucket path
+	client *http.Client
+}
+
+func newS3Client(cfg S3Config) (*s3Client, error) {
+	u, err := url.Parse(strings.TrimSuffix(cfg.Endpoint, "/"))
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("invalid S3 endpoint %q: expected http(s)://host[:port]", cfg.Endpoint)
+	}
+	if cfg.Bucket == "" {
+		return nil, fmt.Errorf("S3 bucket is required")
+	}
+	if cfg.Region == "" {
+		cfg.Region = "us-east-1"
+	}
+	u.Path += "/" + cfg.Bucket
+	return &s3Client{cfg: cfg, base: u, client: &http.Client{Timeout: 10 * time.Minute}}, nil
+}
+
+// put uploads the object key with size bytes of body, whose SHA-256 is sum
+func (c *s3Client) put(ctx context.Context, key string, body io.Reader, size int64, sum []byte) error {
+	req, err := c.request(ctx, http.MethodPut, key, nil, body)
+	if err != nil {
+		return err
+	}
+	req.ContentLength = size
+	res, err := c.do(req, hex.EncodeToString(sum))
+	if err != nil {
+		return err
+	}
+	res.Body.Close()
+	return nil
+}
+
+// get downloads the object key; the error wraps os.ErrNotExist when there is
+// no such object
+func (c *s3Client) get(ctx context.Context, key string) (io.ReadCloser, error) {
+	req, err := c.request(ctx, http.MethodGet, key, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	res, err := c.do(req, emptyPayloadSHA256)
+	if err != nil {
+		return nil, err
+	}
+	return res.Body, nil
+}
+
+// list returns the keys of the objects starting with prefix
+func (c *s3Client) list(ctx context.Context, prefix string) ([]string, error) {
+	var keys []string
+	token := ""
+	for {
+		q := url.Values{"list-type": {"2"}, "prefix": {prefix}}
+		if token != "" {
+			q.Set("continuation-token", token)
+		}
+		req, err := c.request(ctx, http.MethodGet, "", q, nil)
+		if err != nil {
+			return nil, err
+		}
+		res, err := c.do(req, emptyPayloadSHA256)
+		if err != nil {
+			return nil, err
+		}
+		var out struct {
+			Contents []struct {
+				Key string
+			}
+			IsTruncated           bool
+			NextContinuationToken string
+		}
+		err = xml.NewDecoder(res.Body).Decode(&out)
+		res.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("S3 list: %w", err)
+		}
+		for _, o := range out.Contents {
+			keys = append(keys, o.Key)
+		}
+		if !out.IsTruncated || out.NextContinuationToken == "" {
+			return keys, nil
+		}
+		token = out.NextContinuationToken
+	}
+}
+
+// request creates the request of the object key, of the bucket without one
+func (c *s3Client) request(ctx context.Context, method, key string, q url.Values, body io.Reader) (*http.Request, error) {
+	u := *c.base
+	if key != "" {
+		u.Path += "/" + key
+	}
+	u.RawPath = s3URIEscape(u.Path, false)
+	u.RawQuery = s3CanonicalQuery(q)
+	return http.NewRequestWithContext(ctx, method, u.String(), body)
+}
+
+// do signs and sends req, whose payload has the hex SHA-256 payloadHash, and
+// turns error responses into errors
+func (c *s3Client) do(req *http.Request, payloadHash string) (*http.Response, error) {
+	signS3Request(req, c.cfg, payloadHash, time.Now())
+	res, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if res.StatusCode < 300 {
+		return res, nil
+	}
+	defer res.Body.Close()
+	var e struct {
+		Code    string
+		Message string
+	}
+	_ = xml.NewDecoder(io.LimitReader(res.Body, 1<<16)).Decode(&e)
+	err = fmt.Errorf("S3 %s %s: %s %s %s", req.Method, req.URL.Path, res.Status, e.Code, e.Message)
+	if res.StatusCode == http.StatusNotFound && e.Code != "NoSuchBucket" {
+		err = fmt.Errorf("%w: %w", err, os.ErrNotExist)
+	}
+	return nil, err
+}
+
+// emptyPayloadSHA256 is the hex SHA-256 of an empty body
+const emptyPayloadSHA256 = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+
+// signS3Request adds the AWS Signature Version 4 headers to req, signing its
+// host and the headers it has
+func signS3Request(req *http.Request, cfg S3Config, payloadHash string, now time.Time) {
+	amzDate := now.UTC().Format("20060102T150405Z")
+	scope := amzDate[:8] + "/" + cfg.Region + "/s3/aws4_request"
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	headers := map[string]string{"host": req.URL.Host}
+	for k, v := range req.Header {
+		headers[strings.ToLower(k)] = strings.TrimSpace(strings.Join(v, ","))
+	}
+	names := make([]string, 0, len(headers))
+	for k := range headers {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	var canonicalHeaders strings.Builder
+	for _, k := range names {
+		canonicalHeaders.WriteString(k + ":" + headers[k] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		s3URIEscape(req.URL.Path, false),
+		req.URL.RawQuery, // Already canonical, see s3CanonicalQuery
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+	requestHash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(requestHash[:])
+
+	key := []byte("AWS4" + cfg.SecretKey)
+	for _, part := range []string{amzDate[:8], cfg.Region, "s3", "aws4_request"} {
+		key = hmacSHA256(key, part)
+	}
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+	req.Header.Set("Authorization", "AWS4-HMAC-SHA256 Credential="+cfg.AccessKey+"/"+scope+", SignedHeaders="+signedHeaders+", Signature="+signature)
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+// s3CanonicalQuery encodes q sorted by key, the way Signature Version 4 wants
+// it in the URL and the canonical request
+func s3CanonicalQuery(q url.Values) string {
+	keys := make([]string, 0, len(q))
+	for k := range q {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var parts []string
+	for _, k := range keys {
+		for _, v := range q[k] {
+			parts = append(parts, s3URIEscape(k, true)+"="+s3URIEscape(v, true))
+		}
+	}
+	return strings.Join(parts, "&")
+}
+
+// s3URIEscape percent-encodes s except for the unreserved characters, and '/'
+// unless encodeSlash
+func s3URIEscape(s string, encodeSlash bool) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if ('A' <= c && c <= 'Z') || ('a' <= c && c <= 'z') || ('0' <= c && c <= '9') || c == '-' || c == '_' || c == '.' || c == '~' || (c == '/' && !encodeSlash) {
+			b.WriteByte(c)
+		} else {
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
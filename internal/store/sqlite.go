@@ -0,0 +1,25 @@
+package store
+
+import (
+	"fmt"
+	"strings"
+)
+
+// SQLiteStore reads the alert rules from a SQLite file, in the tables of
+// migrations/sqlite, and writes their triggers back. It suits a single node,
+// where the engine, the notification service and the log API share the file.
+type SQLiteStore struct {
+	*sqlRules
+}
+
+func NewSQLiteStore(dsn string) (*SQLiteStore, error) {
+	if !strings.HasPrefix(dsn, "sqlite:") {
+		return nil, fmt.Errorf("SQLite DSN must be sqlite:<path>")
+	}
+	_, path := dialectOf(dsn)
+	s, err := openRules(sqliteDialect, path)
+	if err != nil {
+		return nil, err
+	}
+	return &SQLiteStore{s}, nil
+}
@@ -0,0 +1,147 @@
+package store
+
+import (
+	"database/sql"
+	"strings"
+	"sync"
+	"time"
+
+	"crypto-alert/internal/core"
+)
+
+// telegramSettingsTTL is how long chat settings are cached before they're
+// read again, so changes made through the other service show up within it
+const telegramSettingsTTL = time.Minute
+
+// TelegramChats stores the Telegram chats registered through the bot's /start
+// command, keyed by the token (e.g. an email address) the user registered with
+type TelegramChats struct {
+	db      *sql.DB
+	dialect dialect
+
+	mu         sync.Mutex
+	settings   map[string]TelegramChatSettings // By chat ID, cached
+	settingsAt time.Time
+}
+
+// TelegramChatSettings are what a chat adds to its alerts: Prefix before each
+// alert and Mentions (@usernames) on alerts of MentionSeverity or above
+type TelegramChatSettings struct {
+	Prefix          string
+	Mentions        []string
+	MentionSeverity core.Severity
+}
+
+func NewTelegramChats(dsn string) (*TelegramChats, error) {
+	d, dsn := dialectOf(dsn)
+	db, err := openDB(d, dsn, 3, 1)
+	if err != nil {
+		return nil, err
+	}
+	return &TelegramChats{db: db, dialect: d}, nil
+}
+
+func (c *TelegramChats) Close() {
+	if c != nil && c.db != nil {
+		c.db.Close()
+	}
+}
+
+// Register links a chat to token. Registering the chat again updates its name.
+func (c *TelegramChats) Register(token, chatID, name string) error {
+	_, err := c.db.Exec(
+		c.dialect.rebind(`INSERT INTO telegram_chat (token, chat_id, name, created_at) VALUES (?, ?, ?, `+c.dialect.now()+`)`+c.dialect.upsert(`token, chat_id`, `name`)),
+		token, chatID, name,
+	)
+	return err
+}
+
+// ChatIDs returns the IDs of the chats registered with token. It returns nil
+// on a nil store.
+func (c *TelegramChats) ChatIDs(token string) ([]string, error) {
+	if c == nil {
+		return nil, nil
+	}
+	rows, err := c.db.Query(c.dialect.rebind(`SELECT chat_id FROM telegram_chat WHERE token = ? ORDER BY created_at`), token)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// ChatSettings returns the settings of chatID, a chat ID or <chat>/<topic>;
+// the zero settings when it has none. Settings are cached for a minute.
+func (c *TelegramChats) ChatSettings(chatID string) (TelegramChatSettings, error) {
+	if c == nil {
+		return TelegramChatSettings{}, nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.settings == nil || time.Since(c.settingsAt) > telegramSettingsTTL {
+		settings, err := c.loadSettings()
+		if err != nil {
+			return TelegramChatSettings{}, err
+		}
+		c.settings, c.settingsAt = settings, time.Now()
+	}
+	return c.settings[chatID], nil
+}
+
+func (c *TelegramChats) loadSettings() (map[string]TelegramChatSettings, error) {
+	rows, err := c.db.Query(`SELECT chat_id, prefix, mentions, mention_severity FROM telegram_chat_settings`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	settings := map[string]TelegramChatSettings{}
+	for rows.Next() {
+		var chatID, mentions, severity string
+		var s TelegramChatSettings
+		if err := rows.Scan(&chatID, &s.Prefix, &mentions, &severity); err != nil {
+			return nil, err
+		}
+		s.Mentions = strings.Fields(mentions)
+		s.MentionSeverity = core.Severity(severity)
+		settings[chatID] = s
+	}
+	return settings, rows.Err()
+}
+
+// SetPrefix sets the text prepended to the alerts of chatID; empty removes it
+func (c *TelegramChats) SetPrefix(chatID, prefix string) error {
+	_, err := c.db.Exec(
+		c.dialect.rebind(`INSERT INTO telegram_chat_settings (chat_id, prefix) VALUES (?, ?)`+c.dialect.upsert(`chat_id`, `prefix`)),
+		chatID, prefix,
+	)
+	c.invalidateSettings()
+	return err
+}
+
+// SetMentions sets the @usernames tagged on the alerts of chatID of severity
+// or above; no mentions removes them
+func (c *TelegramChats) SetMentions(chatID string, mentions []string, severity core.Severity) error {
+	_, err := c.db.Exec(
+		c.dialect.rebind(`INSERT INTO telegram_chat_settings (chat_id, mentions, mention_severity) VALUES (?, ?, ?)`+c.dialect.upsert(`chat_id`, `mentions`, `mention_severity`)),
+		chatID, strings.Join(mentions, " "), string(severity),
+	)
+	c.invalidateSettings()
+	return err
+}
+
+// invalidateSettings makes the next ChatSettings read the settings again
+func (c *TelegramChats) invalidateSettings() {
+	c.mu.Lock()
+	c.settings = nil
+	c.mu.Unlock()
+}
@@ -0,0 +1,126 @@
+package tracing
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"time"
+
+	"crypto-alert/internal/logger"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.34.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Span attributes of the alert path
+const (
+	AttrRuleID  = attribute.Key("alert.rule_id")
+	AttrKind    = attribute.Key("alert.kind")
+	AttrSource  = attribute.Key("alert.source")
+	AttrChannel = attribute.Key("alert.channel")
+)
+
+var tracer = otel.Tracer("crypto-alert")
+
+// Init exports the spans of service to the OTLP/HTTP endpoint of
+// OTEL_EXPORTER_OTLP_ENDPOINT (or OTEL_EXPORTER_OTLP_TRACES_ENDPOINT), with
+// the other OTEL_* variables of the OpenTelemetry SDK, e.g.
+// OTEL_EXPORTER_OTLP_HEADERS and OTEL_TRACES_SAMPLER. Without an endpoint,
+// or with OTEL_SDK_DISABLED=true, spans are not recorded. Either way the
+// trace context is propagated in W3C traceparent headers. The returned
+// function exports the spans still waiting; call it on shutdown.
+func Init(ctx context.Context, service string) (func(), error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+	if !Enabled() {
+		return func() {}, nil
+	}
+
+	exporter, err := otlptracehttp.New(ctx)
+	if err != nil {
+		return nil, err
+	}
+	// OTEL_SERVICE_NAME and OTEL_RESOURCE_ATTRIBUTES override the service name
+	res, err := resource.Merge(
+		resource.NewSchemaless(semconv.ServiceName(service)),
+		resource.Environment(),
+	)
+	if err != nil {
+		return nil, err
+	}
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+	)
+	otel.SetTracerProvider(provider)
+	slog.Info("🔭 Exporting traces", "endpoint", endpoint())
+	return func() {
+		ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+		if err := provider.Shutdown(ctx); err != nil {
+			slog.Warn("⚠️  Failed to export the last spans", logger.FieldError, err)
+		}
+	}, nil
+}
+
+// shutdownTimeout is how long the spans still waiting get to be exported on shutdown
+const shutdownTimeout = 5 * time.Second
+
+// Enabled reports whether the environment configures an OTLP endpoint
+func Enabled() bool {
+	if os.Getenv("OTEL_SDK_DISABLED") == "true" {
+		return false
+	}
+	return endpoint() != ""
+}
+
+// endpoint returns the OTLP endpoint spans are exported to
+func endpoint() string {
+	if e := os.Getenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT"); e != "" {
+		return e
+	}
+	return os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT")
+}
+
+// Start starts a span of kind internal, a child of the span of ctx if any
+func Start(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return tracer.Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// StartProducer starts the span of publishing an event to topic
+func StartProducer(ctx context.Context, topic string) (context.Context, trace.Span) {
+	return tracer.Start(ctx, "publish "+topic, trace.WithSpanKind(trace.SpanKindProducer),
+		trace.WithAttributes(semconv.MessagingDestinationName(topic), semconv.MessagingOperationTypeSend))
+}
+
+// StartConsumer starts the span of handling an event of topic, a child of
+// the producer span whose context the transport extracted into ctx
+func StartConsumer(ctx context.Context, topic, group string) (context.Context, trace.Span) {
+	return tracer.Start(ctx, "process "+topic, trace.WithSpanKind(trace.SpanKindConsumer),
+		trace.WithAttributes(semconv.MessagingDestinationName(topic), semconv.MessagingConsumerGroupName(group), semconv.MessagingOperationTypeProcess))
+}
+
+// End ends span, recording err as its status when it isn't nil
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// Inject writes the trace context of ctx to headers, e.g. of a message
+func Inject(ctx context.Context, headers propagation.TextMapCarrier) {
+	otel.GetTextMapPropagator().Inject(ctx, headers)
+}
+
+// Extract returns ctx with the trace context of headers, if they have one
+func Extract(ctx context.Context, headers propagation.TextMapCarrier) context.Context {
+	return otel.GetTextMapPropagator().Extract(ctx, headers)
+}